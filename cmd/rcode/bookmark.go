@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var bookmarkCmd = &cobra.Command{
+	Use:   "bookmark",
+	Short: "Manage bookmarks for frequently opened paths",
+	Long: `Bookmarks name paths that are long to type. "rcode @name" opens a bookmark,
+and "rcode @name/sub/dir" a path below it. Bookmarks are kept under
+bookmarks in the client configuration file.`,
+	Example: `  rcode bookmark add api ~/src/monorepo/services/api
+  rcode @api
+  rcode @api/internal/server.go:42`,
+	Args: cobra.NoArgs,
+	RunE: runBookmarkList,
+}
+
+var bookmarkAddCmd = &cobra.Command{
+	Use:   "add NAME [path]",
+	Short: "Bookmark a path (default: the current directory)",
+	Args:  cobra.RangeArgs(1, 2),
+	RunE:  runBookmarkAdd,
+}
+
+var bookmarkRemoveCmd = &cobra.Command{
+	Use:     "remove NAME",
+	Aliases: []string{"rm"},
+	Short:   "Remove a bookmark",
+	Args:    cobra.ExactArgs(1),
+	RunE:    runBookmarkRemove,
+}
+
+var bookmarkListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List bookmarks",
+	Args:    cobra.NoArgs,
+	RunE:    runBookmarkList,
+}
+
+func runBookmarkAdd(_ *cobra.Command, args []string) error {
+	name := strings.TrimPrefix(args[0], "@")
+	if !config.ValidBookmarkName(name) {
+		return fmt.Errorf("invalid bookmark name %q: use letters, digits, - and _", args[0])
+	}
+	dir := "."
+	if len(args) == 2 {
+		dir = args[1]
+	}
+	path, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	if err := config.SetClientConfigValue(configFile, "bookmarks."+name, path); err != nil {
+		return err
+	}
+	fmt.Printf("Bookmarked %s as @%s\n", path, name)
+	return nil
+}
+
+func runBookmarkRemove(_ *cobra.Command, args []string) error {
+	name := strings.TrimPrefix(args[0], "@")
+	cfg, err := loadClientConfig()
+	if err != nil {
+		return err
+	}
+	if _, ok := cfg.Bookmarks[name]; !ok {
+		return fmt.Errorf("no bookmark named %q", name)
+	}
+
+	if err := config.UnsetClientConfigValue(configFile, "bookmarks."+name); err != nil {
+		return err
+	}
+	fmt.Printf("Removed bookmark @%s\n", name)
+	return nil
+}
+
+func runBookmarkList(_ *cobra.Command, _ []string) error {
+	cfg, err := loadClientConfig()
+	if err != nil {
+		return err
+	}
+	if structuredOutput() {
+		bookmarks := cfg.Bookmarks
+		if bookmarks == nil {
+			bookmarks = map[string]string{}
+		}
+		return writeStructured(os.Stdout, bookmarks)
+	}
+
+	if len(cfg.Bookmarks) == 0 {
+		fmt.Println(`No bookmarks. Add one with "rcode bookmark add NAME [path]".`)
+		return nil
+	}
+	names := bookmarkNames(cfg.Bookmarks)
+	width := 0
+	for _, name := range names {
+		width = max(width, len(name)+1)
+	}
+	for _, name := range names {
+		fmt.Printf("  %-*s  %s\n", width, "@"+name, cfg.Bookmarks[name])
+	}
+	return nil
+}
+
+// resolveBookmark expands arg when it names a bookmark as "@name" or a path
+// below one as "@name/sub/path". Other arguments are returned unchanged.
+func resolveBookmark(bookmarks map[string]string, arg string) (string, error) {
+	if !strings.HasPrefix(arg, "@") {
+		return arg, nil
+	}
+	name, rest, _ := strings.Cut(arg[1:], "/")
+	path, ok := bookmarks[name]
+	if !ok {
+		return "", fmt.Errorf("unknown bookmark %q (see rcode bookmark list; use ./%s for a file)", "@"+name, arg)
+	}
+	if rest == "" {
+		return path, nil
+	}
+	return filepath.Join(path, filepath.FromSlash(rest)), nil
+}
+
+// bookmarkNames returns the bookmark names in order
+func bookmarkNames(bookmarks map[string]string) []string {
+	names := make([]string, 0, len(bookmarks))
+	for name := range bookmarks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// completeBookmarkPaths completes "@name" arguments from the bookmarks and
+// leaves everything else to file completion
+func completeBookmarkPaths(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if !strings.HasPrefix(toComplete, "@") {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	cfg, err := loadClientConfig()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return matchingBookmarks(cfg.Bookmarks, toComplete, "@"), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeBookmarkName completes a bookmark name as the first argument
+func completeBookmarkName(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	cfg, err := loadClientConfig()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return matchingBookmarks(cfg.Bookmarks, toComplete, ""), cobra.ShellCompDirectiveNoFileComp
+}
+
+// matchingBookmarks returns the bookmark names, written with prefix, that
+// start with toComplete
+func matchingBookmarks(bookmarks map[string]string, toComplete, prefix string) []string {
+	var matches []string
+	for _, name := range bookmarkNames(bookmarks) {
+		if strings.HasPrefix(prefix+name, toComplete) {
+			matches = append(matches, prefix+name)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolveBookmark(t *testing.T) {
+	bookmarks := map[string]string{"api": "/src/monorepo/services/api"}
+
+	tests := []struct {
+		arg     string
+		want    string
+		wantErr bool
+	}{
+		{arg: "@api", want: "/src/monorepo/services/api"},
+		{arg: "@api/internal/server.go", want: "/src/monorepo/services/api/internal/server.go"},
+		{arg: "./@api", want: "./@api"},
+		{arg: "main.go", want: "main.go"},
+		{arg: "@web", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := resolveBookmark(bookmarks, tt.arg)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("resolveBookmark(%q) error = %v, wantErr %v", tt.arg, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("resolveBookmark(%q) = %q, want %q", tt.arg, got, tt.want)
+		}
+	}
+}
+
+func TestMatchingBookmarks(t *testing.T) {
+	bookmarks := map[string]string{"api": "/a", "app": "/b", "web": "/c"}
+
+	if got, want := matchingBookmarks(bookmarks, "@ap", "@"), []string{"@api", "@app"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("matchingBookmarks(@ap) = %v, want %v", got, want)
+	}
+	if got, want := matchingBookmarks(bookmarks, "w", ""), []string{"web"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("matchingBookmarks(w) = %v, want %v", got, want)
+	}
+}
@@ -4,14 +4,27 @@ package main
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/foxytanuki/rcode/internal/auth"
 	"github.com/foxytanuki/rcode/internal/config"
+	editorpkg "github.com/foxytanuki/rcode/internal/editor"
 	"github.com/foxytanuki/rcode/internal/logger"
+	"github.com/foxytanuki/rcode/internal/network"
+	"github.com/foxytanuki/rcode/internal/telemetry"
+	"github.com/foxytanuki/rcode/internal/validation"
 	"github.com/foxytanuki/rcode/internal/version"
 	"github.com/foxytanuki/rcode/pkg/api"
 )
@@ -21,167 +34,680 @@ type Client struct {
 	config     *config.ClientConfig
 	log        *logger.Logger
 	httpClient *http.Client
+	retry      retryPolicy
+
+	// raceOnce guards the race-mode probe; preferred is the host that won it
+	raceOnce  sync.Once
+	preferred string
+
+	// cachePath is the host cache file, empty when the cache is disabled
+	cachePath string
+	cacheOnce sync.Once
+	cache     *hostCache
+
+	// telemetry records a span per HTTP attempt under the span in traceCtx;
+	// both are unset unless telemetry is enabled
+	telemetry *telemetry.Provider
+	traceCtx  context.Context
+
+	// protocols is the protocol version each host last answered with
+	protocolMu sync.Mutex
+	protocols  map[string]int
+
+	// answered is the role of the host that answered the last request
+	answered atomic.Value
+
+	// tlsErr is why hosts.server.tls could not be loaded; every request
+	// fails with it rather than falling back to plain HTTP
+	tlsErr error
 }
 
 // NewClient creates a new client instance
 func NewClient(cfg *config.ClientConfig, log *logger.Logger) *Client {
+	var tlsConfig *tls.Config
+	var tlsErr error
+	if t := cfg.Hosts.Server.TLS; t.Enabled() {
+		tlsConfig, tlsErr = auth.ClientTLSConfig(t.CAFile, t.CertFile, t.KeyFile, t.ServerName)
+	}
+
 	// Create HTTP client with timeout
 	httpClient := &http.Client{
-		Timeout: cfg.Network.Timeout * 2, // Double the timeout for the full request
+		Transport: newTransport(cfg.Network, cfg.HostsOverrides, tlsConfig),
+		Timeout:   cfg.Network.Timeout * 2, // Double the timeout for the full request
 	}
 
-	return &Client{
+	c := &Client{
 		config:     cfg,
 		log:        log,
 		httpClient: httpClient,
+		retry:      newRetryPolicy(cfg.Network),
+		tlsErr:     tlsErr,
+	}
+	if cfg.Network.CacheTTL > 0 {
+		c.cachePath = hostCachePath()
 	}
+	return c
 }
 
-// ensurePort appends the default port if the host doesn't include one.
-func ensurePort(host string) string {
-	if !strings.Contains(host, ":") {
-		return host + ":3339"
+// serverAddr returns host as host:port, adding port (hosts.server.port) when
+// host has none and bracketing IPv6 literals. A port in host wins.
+func serverAddr(host string, port int) (string, error) {
+	if port <= 0 {
+		port = config.DefaultServerPort
 	}
-	return host
+	return validation.NormalizeHostPort(host, strconv.Itoa(port))
 }
 
-// withFallback tries fn against the primary host, then the fallback host.
-func (c *Client) withFallback(fn func(host string) error) error {
-	err := fn(c.config.Hosts.Server.Primary)
-	if err == nil {
+// newTransport returns the transport shared by every request a client makes.
+// Keep-alive connections are reused across retries and by commands that make
+// several requests; HTTP/2 is negotiated on TLS connections only when enabled.
+// Server host names are resolved with hosts_overrides and network.dns_server
+// first; tlsConfig, when not nil, verifies servers reached over HTTPS.
+func newTransport(netCfg config.ClientNetworkConfig, overrides map[string]string, tlsConfig *tls.Config) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&network.Dialer{
+		Timeout:   netCfg.Timeout,
+		KeepAlive: 30 * time.Second,
+		Overrides: overrides,
+		DNSServer: netCfg.DNSServer,
+	}).DialContext
+	transport.MaxIdleConnsPerHost = 4
+	transport.TLSClientConfig = tlsConfig
+	transport.ForceAttemptHTTP2 = netCfg.HTTP2
+	if !netCfg.HTTP2 {
+		// A non-nil empty map disables HTTP/2
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	return transport
+}
+
+// request describes one HTTP call to a server host
+type request struct {
+	method      string
+	path        string        // path and query
+	body        []byte        // request body, read afresh by every attempt; nil for none
+	contentType string        // Content-Type of body
+	timeout     time.Duration // zero means network.timeout
+	once        bool          // single attempt, no retries
+	id          string        // X-Request-ID shared by every attempt; do fills it in
+	idempotency string        // Idempotency-Key shared by every attempt; empty for none
+}
+
+// do sends r to host under the client's retry policy: network failures and
+// gateway errors are retried with backoff, anything else is returned at once.
+// The returned body must be closed, which also releases the request's timeout.
+func (c *Client) do(host string, r request) (*http.Response, error) {
+	if r.id == "" {
+		r.id = api.NewRequestID()
+	}
+	policy := c.retry
+	if r.once {
+		policy.attempts = 1
+	}
+
+	var resp *http.Response
+	err := policy.run(func(attempt int, last bool) error {
+		if attempt > 1 {
+			c.log.Debug("Retrying request",
+				"path", r.path,
+				"request_id", r.id,
+				"attempt", attempt,
+				"max_attempts", policy.attempts,
+			)
+		}
+
+		var err error
+		resp, err = c.send(host, r)
+		if err != nil {
+			return err
+		}
+		if statusErr := statusError(resp.StatusCode); statusErr != nil && !last {
+			c.closeBody(resp)
+			resp = nil
+			return statusErr
+		}
 		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// send makes a single attempt at r with the User-Agent and auth headers
+// every endpoint needs, building a fresh request and body reader with its own
+// timeout, and logs the attempt's latency at debug level. The attempt is
+// recorded as a client span whose context travels in the traceparent header.
+func (c *Client) send(host string, r request) (*http.Response, error) {
+	if c.tlsErr != nil {
+		return nil, fmt.Errorf("invalid hosts.server.tls: %w", c.tlsErr)
+	}
+	timeout := r.timeout
+	if timeout <= 0 {
+		timeout = c.config.Network.Timeout
+	}
+	parent := c.traceCtx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(parent, timeout)
+
+	var body io.Reader = http.NoBody
+	if r.body != nil {
+		body = bytes.NewReader(r.body)
+	}
+	addr, err := serverAddr(host, c.config.Hosts.Server.Port)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("invalid server address: %w", err)
+	}
+	route, _, _ := strings.Cut(r.path, "?")
+	ctx, span := c.telemetry.Start(ctx, r.method+" "+route, telemetry.KindClient,
+		slog.String("http.request.method", r.method),
+		slog.String("url.path", route),
+		slog.String("server.address", addr),
+		slog.String("rcode.request_id", r.id),
+	)
+	defer span.End()
+
+	endpoint := fmt.Sprintf("%s://%s%s", c.scheme(host), addr, r.path)
+	req, err := http.NewRequestWithContext(ctx, r.method, endpoint, body)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	telemetry.Inject(ctx, req.Header)
+	if r.contentType != "" {
+		req.Header.Set("Content-Type", r.contentType)
+	}
+	req.Header.Set("User-Agent", fmt.Sprintf("rcode/%s", version.Version))
+	api.SetProtocolHeader(req.Header)
+	if r.id != "" {
+		req.Header.Set(api.HeaderRequestID, r.id)
+	}
+	if r.idempotency != "" {
+		req.Header.Set(api.HeaderIdempotencyKey, r.idempotency)
+	}
+	auth.SetBearerToken(req, c.config.Hosts.Server.AuthToken)
+	auth.SignRequest(req, r.body, c.config.Hosts.Server.SigningKey, time.Now())
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	latency := time.Since(start)
+	if err != nil {
+		cancel()
+		span.RecordError(err)
+		c.log.Debug("HTTP request failed",
+			"method", r.method,
+			"url", endpoint,
+			"request_id", r.id,
+			"latency", latency,
+			"error", err,
+		)
+		return nil, networkError(err)
+	}
+	c.log.Debug("HTTP request",
+		"method", r.method,
+		"url", endpoint,
+		"request_id", r.id,
+		"status", resp.StatusCode,
+		"proto", resp.Proto,
+		"latency", latency,
+	)
+	span.SetAttributes(slog.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		span.RecordError(errors.New(resp.Status))
+	}
+
+	c.noteProtocol(host, api.ProtocolFromHeader(resp.Header))
+
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// scheme returns the URL scheme for host: https when hosts.server.tls is
+// set, except for the broker, which relays plain HTTP
+func (c *Client) scheme(host string) string {
+	server := c.config.Hosts.Server
+	if server.TLS.Enabled() && host != server.Broker {
+		return "https"
+	}
+	return "http"
+}
+
+// noteProtocol records the protocol version host answered with, warning the
+// first time a host is seen with a version this client is not built for
+func (c *Client) noteProtocol(host string, protocol int) {
+	c.protocolMu.Lock()
+	defer c.protocolMu.Unlock()
+
+	previous, seen := c.protocols[host]
+	if seen && previous == protocol {
+		return
+	}
+	if c.protocols == nil {
+		c.protocols = make(map[string]int)
+	}
+	c.protocols[host] = protocol
+
+	if warning := api.ProtocolWarning(protocol); warning != "" {
+		c.log.Warn("Server protocol mismatch",
+			"host", host,
+			"server_protocol", protocol,
+			"client_protocol", api.ProtocolVersion,
+			"warning", warning,
+		)
+	}
+}
+
+// serverProtocol returns the protocol version host last answered with and
+// whether it has answered at all
+func (c *Client) serverProtocol(host string) (int, bool) {
+	c.protocolMu.Lock()
+	defer c.protocolMu.Unlock()
+	protocol, ok := c.protocols[host]
+	return protocol, ok
+}
+
+// cancelOnClose releases a request's context when its response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+// closeBody closes a response body, logging a failure
+func (c *Client) closeBody(resp *http.Response) {
+	if err := resp.Body.Close(); err != nil {
+		c.log.Warn("Failed to close response body", "error", err)
+	}
+}
+
+// responseError turns a non-200 response into an error, using the server's
+// error response when the body holds one. The request ID is appended so a
+// failure can be found in the server's logs.
+func responseError(resp *http.Response) error {
+	id := resp.Header.Get(api.HeaderRequestID)
+	var errResp api.ErrorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
+		err := statusError(resp.StatusCode)
+		if err == nil {
+			err = fmt.Errorf("server returned status %d", resp.StatusCode)
+		}
+		return withRequestID(err, id)
+	}
+	if errResp.RequestID != "" {
+		id = errResp.RequestID
+	}
+	return withRequestID(fmt.Errorf("server error: %w", &errResp), id)
+}
+
+// withRequestID appends the request ID to err's message when there is one
+func withRequestID(err error, id string) error {
+	if id == "" {
+		return err
+	}
+	return fmt.Errorf("%w (request %s)", err, id)
+}
+
+// hostTarget is a configured server address and the role it plays
+type hostTarget struct {
+	role string
+	host string
+}
+
+// hostFailureMessages is logged when a request to a host of each role fails
+var hostFailureMessages = map[string]string{
+	"primary":  "Primary host failed",
+	"fallback": "Fallback host failed",
+	"tunnel":   "Tunnel failed",
+	"broker":   "Broker failed",
+}
+
+// hostTargets returns the configured hosts in fallback order: primary,
+// fallback, the SSH reverse tunnel and the broker
+func (c *Client) hostTargets() []hostTarget {
+	targets := []hostTarget{{"primary", c.config.Hosts.Server.Primary}}
+	for _, t := range []hostTarget{
+		{"fallback", c.config.Hosts.Server.Fallback},
+		{"tunnel", c.config.Hosts.Server.Tunnel},
+		{"broker", c.config.Hosts.Server.Broker},
+	} {
+		if t.host != "" {
+			targets = append(targets, t)
+		}
+	}
+	return targets
+}
+
+// hostOrder returns the hosts to try. The last host that answered, while
+// cached, goes first; otherwise in race mode the fastest healthy host is
+// probed for once per client and moved to the front. The rest keep their
+// fallback order.
+func (c *Client) hostOrder() []hostTarget {
+	targets := c.hostTargets()
+	if len(targets) < 2 {
+		return targets
+	}
+
+	preferred := c.knownGoodHost(targets)
+	if preferred == "" && c.config.Network.HostSelection == config.HostSelectionRace {
+		c.raceOnce.Do(func() {
+			c.preferred = c.raceHosts(targets)
+		})
+		preferred = c.preferred
+	}
+	if preferred == "" {
+		return targets
+	}
+
+	ordered := make([]hostTarget, 0, len(targets))
+	for _, t := range targets {
+		if t.host == preferred {
+			ordered = append([]hostTarget{t}, ordered...)
+		} else {
+			ordered = append(ordered, t)
+		}
+	}
+	return ordered
+}
+
+// raceHosts probes /health on every target in parallel and returns the first
+// host to answer healthy, or "" when none does within the probe timeout
+func (c *Client) raceHosts(targets []hostTarget) string {
+	timeout := c.config.Network.ProbeTimeout
+	if timeout <= 0 {
+		timeout = config.DefaultProbeTimeout
 	}
-	c.log.Warn("Primary host failed", "host", c.config.Hosts.Server.Primary, "error", err)
 
-	if c.config.Hosts.Server.Fallback != "" {
-		err2 := fn(c.config.Hosts.Server.Fallback)
-		if err2 == nil {
+	// Buffered so the probes that lose the race never block
+	results := make(chan string, len(targets))
+	for _, t := range targets {
+		go func(t hostTarget) {
+			start := time.Now()
+			health, err := c.fetchHealthWithin(t.host, timeout)
+			if err != nil || !health.IsHealthy() {
+				c.log.Debug("Host probe failed", "role", t.role, "host", t.host, "error", err)
+				results <- ""
+				return
+			}
+			c.log.Debug("Host probe succeeded", "role", t.role, "host", t.host, "latency", time.Since(start))
+			results <- t.host
+		}(t)
+	}
+
+	for range targets {
+		if host := <-results; host != "" {
+			c.log.Debug("Selected fastest host", "host", host)
+			return host
+		}
+	}
+	c.log.Debug("No host answered the probe; using fallback order")
+	return ""
+}
+
+// withFallback tries fn against each host from hostOrder until one succeeds:
+// by default the primary host, then the fallback host, the SSH reverse tunnel
+// and finally the broker when those are configured.
+func (c *Client) withFallback(fn func(host string) error) error {
+	var firstErr error
+	for _, t := range c.hostOrder() {
+		err := fn(t.host)
+		if err == nil {
+			c.rememberHost(t.host)
+			c.answered.Store(t.role)
 			return nil
 		}
-		c.log.Warn("Fallback host failed", "host", c.config.Hosts.Server.Fallback, "error", err2)
+		c.log.Warn(hostFailureMessages[t.role], "host", t.host, "error", err)
+		if firstErr == nil {
+			firstErr = err
+		}
 	}
-	return fmt.Errorf("failed to connect to any configured host: %w", err)
+	return fmt.Errorf("failed to connect to any configured host: %w", firstErr)
+}
+
+// answeredRole returns the role of the host that answered the last
+// request, such as "primary" or "fallback"
+func (c *Client) answeredRole() string {
+	role, _ := c.answered.Load().(string)
+	return role
 }
 
 // OpenEditor opens a file/directory in an editor on the host machine
 func (c *Client) OpenEditor(path, editor string, sshInfo *SSHInfo) error {
-	// Use default editor if not specified
-	if editor == "" {
-		editor = c.config.DefaultEditor
+	return c.OpenEditorAt(path, FilePosition{}, editor, sshInfo)
+}
+
+// OpenEditorAt opens a file in an editor on the host machine at the given position
+func (c *Client) OpenEditorAt(path string, pos FilePosition, editor string, sshInfo *SSHInfo) error {
+	return c.OpenEditorPaths([]string{path}, pos, editor, sshInfo)
+}
+
+// OpenEditorPaths opens one or more paths in a single editor invocation
+func (c *Client) OpenEditorPaths(paths []string, pos FilePosition, editor string, sshInfo *SSHInfo) error {
+	_, err := c.Open(paths, pos, editor, sshInfo)
+	return err
+}
+
+// Open opens one or more paths in a single editor invocation and returns
+// the server's response
+func (c *Client) Open(paths []string, pos FilePosition, editor string, sshInfo *SSHInfo) (*api.OpenResponse, error) {
+	if len(paths) == 0 {
+		return nil, api.ErrInvalidPath
 	}
 
-	// Create the request
-	req := api.OpenRequest{
-		Path:   path,
-		Editor: editor,
-		User:   sshInfo.User,
-		Host:   sshInfo.Host,
+	req := c.newOpenRequest(paths, pos, editor, sshInfo)
+	return c.SendOpen(req)
+}
+
+// SendOpen sends a prepared open request through the first reachable host
+func (c *Client) SendOpen(req api.OpenRequest) (*api.OpenResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := req.CleanPaths(); err != nil {
+		return nil, err
 	}
-	req.SetTimestamp()
 
-	return c.withFallback(func(host string) error {
-		return c.sendRequest(host, req)
+	var opened *api.OpenResponse
+	err := c.withFallback(func(host string) error {
+		var openErr error
+		opened, openErr = c.sendRequest(host, req)
+		return openErr
 	})
+	if err != nil {
+		return nil, err
+	}
+	return opened, nil
 }
 
-// sendRequest sends the open editor request to a specific host
-func (c *Client) sendRequest(host string, req api.OpenRequest) error {
-	host = ensurePort(host)
-	url := fmt.Sprintf("http://%s/open-editor", host)
+// OpenTerminal asks the server to open a terminal at dir, using terminal or
+// the server's default one
+func (c *Client) OpenTerminal(dir, terminal string, sshInfo *SSHInfo) (*api.OpenResponse, error) {
+	if dir == "" {
+		return nil, api.ErrInvalidPath
+	}
 
-	// Marshal request to JSON
+	req := api.NewOpenRequest([]string{dir}, terminal, sshInfo.User, sshInfo.Host)
+	req.Terminal = true
+	return c.SendOpen(req)
+}
+
+// OpenWeb asks the server to open path in a browser editor whose URL uses
+// {port}, the port of a web IDE on this machine
+func (c *Client) OpenWeb(path string, port int, editor string, sshInfo *SSHInfo) (*api.OpenResponse, error) {
+	if path == "" {
+		return nil, api.ErrInvalidPath
+	}
+
+	req := c.newOpenRequest([]string{path}, FilePosition{}, editor, sshInfo)
+	req.Port = port
+	return c.SendOpen(req)
+}
+
+// Diff asks the server to compare path with path2 using editor's diff
+// command
+func (c *Client) Diff(path, path2, editor string, sshInfo *SSHInfo) (*api.OpenResponse, error) {
+	if path == "" || path2 == "" {
+		return nil, api.ErrInvalidPath
+	}
+
+	req := c.newOpenRequest([]string{path}, FilePosition{}, editor, sshInfo)
+	req.Path2 = path2
+	return c.SendOpen(req)
+}
+
+// RenderCommand asks the server which command it would run for paths
+// without opening anything
+func (c *Client) RenderCommand(paths []string, pos FilePosition, editor string, sshInfo *SSHInfo) (*api.RenderResponse, error) {
+	if len(paths) == 0 {
+		return nil, api.ErrInvalidPath
+	}
+
+	req := c.newOpenRequest(paths, pos, editor, sshInfo)
+	if err := req.CleanPaths(); err != nil {
+		return nil, err
+	}
+
+	var rendered *api.RenderResponse
+	err := c.withFallback(func(host string) error {
+		var renderErr error
+		rendered, renderErr = c.render(host, req)
+		return renderErr
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rendered, nil
+}
+
+// newOpenRequest builds an open request for paths at pos
+func (c *Client) newOpenRequest(paths []string, pos FilePosition, editor string, sshInfo *SSHInfo) api.OpenRequest {
+	// Use default editor if not specified
+	if editor == "" {
+		editor = c.config.DefaultEditor
+	}
+
+	req := api.NewOpenRequest(paths, editor, sshInfo.User, sshInfo.Host)
+	req.Line = pos.Line
+	req.Column = pos.Column
+	return req
+}
+
+// render sends a render request to a specific host
+func (c *Client) render(host string, req api.OpenRequest) (*api.RenderResponse, error) {
 	jsonData, err := json.Marshal(req)
 	if err != nil {
-		return fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Perform retries if configured
-	var lastErr error
-	attempts := c.config.Network.RetryAttempts
-	if attempts <= 0 {
-		attempts = 1
+	resp, err := c.do(host, request{method: http.MethodPost, path: "/render", body: jsonData, contentType: "application/json"})
+	if err != nil {
+		return nil, err
 	}
+	defer c.closeBody(resp)
 
-	for i := 0; i < attempts; i++ {
-		if i > 0 {
-			c.log.Debug("Retrying request",
-				"attempt", i+1,
-				"max_attempts", attempts,
-			)
-			time.Sleep(c.config.Network.RetryDelay)
-		}
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var rendered api.RenderResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rendered); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &rendered, nil
+}
+
+// PreviewEditor asks the first reachable host what editorName would run
+// for path at pos, opened from sshInfo's machine, and what would keep it
+// from working
+func (c *Client) PreviewEditor(editorName, path string, pos FilePosition, sshInfo *SSHInfo) (*api.EditorPreviewResponse, error) {
+	query := url.Values{}
+	query.Set("path", path)
+	query.Set("user", sshInfo.User)
+	query.Set("host", sshInfo.Host)
+	if pos.Line > 0 {
+		query.Set("line", strconv.Itoa(pos.Line))
+	}
+	if pos.Column > 0 {
+		query.Set("column", strconv.Itoa(pos.Column))
+	}
+	requestPath := "/editors/" + url.PathEscape(editorName) + "/preview?" + query.Encode()
 
-		// Create fresh request for each attempt to avoid consumed body
-		ctx, cancel := context.WithTimeout(context.Background(), c.config.Network.Timeout)
-		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonData))
+	var preview *api.EditorPreviewResponse
+	err := c.withFallback(func(host string) error {
+		resp, err := c.do(host, request{method: http.MethodGet, path: requestPath})
 		if err != nil {
-			cancel()
-			return fmt.Errorf("failed to create request: %w", err)
+			return err
 		}
-		httpReq.Header.Set("Content-Type", "application/json")
-		httpReq.Header.Set("User-Agent", fmt.Sprintf("rcode/%s", version.Version))
+		defer c.closeBody(resp)
 
-		// Send request
-		resp, err := c.httpClient.Do(httpReq)
-		cancel()
-		if err != nil {
-			lastErr = fmt.Errorf("request failed: %w", err)
-			continue
+		if resp.StatusCode != http.StatusOK {
+			return responseError(resp)
 		}
+		preview = &api.EditorPreviewResponse{}
+		if err := json.NewDecoder(resp.Body).Decode(preview); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return preview, nil
+}
 
-		// Process response - close body when done
-		func() {
-			defer func() {
-				if err := resp.Body.Close(); err != nil {
-					c.log.Warn("Failed to close response body", "error", err)
-				}
-			}()
-
-			// Check status code
-			if resp.StatusCode == http.StatusOK {
-				// Parse successful response
-				var openResp api.OpenResponse
-				if err := json.NewDecoder(resp.Body).Decode(&openResp); err != nil {
-					lastErr = fmt.Errorf("failed to decode response: %w", err)
-					return
-				}
-
-				c.log.Info("Editor opened successfully",
-					"editor", openResp.Editor,
-					"command", openResp.Command,
-				)
-
-				lastErr = nil
-				return
-			}
+// sendRequest sends the open editor request to a specific host
+func (c *Client) sendRequest(host string, req api.OpenRequest) (*api.OpenResponse, error) {
+	// Marshal request to JSON
+	jsonData, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
 
-			// Parse error response
-			var errResp api.ErrorResponse
-			if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
-				lastErr = fmt.Errorf("server returned status %d", resp.StatusCode)
-			} else {
-				lastErr = fmt.Errorf("server error: %s", errResp.Error())
-			}
-		}()
+	// A retry of a launch whose response was lost gets that response back
+	// instead of opening a second window
+	resp, err := c.do(host, request{
+		method:      http.MethodPost,
+		path:        "/open-editor",
+		body:        jsonData,
+		contentType: "application/json",
+		idempotency: api.NewIdempotencyKey(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer c.closeBody(resp)
 
-		// If successful, return immediately
-		if lastErr == nil {
-			return nil
-		}
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+	if resp.Header.Get(api.HeaderIdempotentReplayed) == "true" {
+		c.log.Debug("Server replayed the response to an earlier attempt", "host", host)
 	}
 
-	return lastErr
+	var openResp api.OpenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&openResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	c.log.Info("Editor opened successfully",
+		"editor", openResp.Editor,
+		"command", openResp.Command,
+		"request_id", openResp.RequestID,
+	)
+	return &openResp, nil
 }
 
 // ListEditors lists available editors from the server
 func (c *Client) ListEditors() error {
-	var editors *api.EditorsResponse
-
-	err := c.withFallback(func(host string) error {
-		var fetchErr error
-		editors, fetchErr = c.fetchEditors(host)
-		return fetchErr
-	})
+	editors, err := c.fetchEditorsWithFallback()
 	if err != nil {
 		return err
 	}
@@ -204,6 +730,9 @@ func (c *Client) ListEditors() error {
 			status += " [unavailable]"
 		}
 		fmt.Printf("  %s%s\n", editor.Name, status)
+		if len(editor.Aliases) > 0 {
+			fmt.Printf("    Aliases: %s\n", strings.Join(editor.Aliases, ", "))
+		}
 		if editor.Type == "browser" && editor.URL != "" {
 			fmt.Printf("    URL: %s\n", editor.URL)
 			continue
@@ -214,33 +743,32 @@ func (c *Client) ListEditors() error {
 	return nil
 }
 
-// fetchEditors fetches the list of editors from a specific host
-func (c *Client) fetchEditors(host string) (*api.EditorsResponse, error) {
-	host = ensurePort(host)
-	url := fmt.Sprintf("http://%s/editors", host)
-
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), c.config.Network.Timeout)
-	defer cancel()
+// fetchEditorsWithFallback fetches the list of editors from the first reachable host
+func (c *Client) fetchEditorsWithFallback() (*api.EditorsResponse, error) {
+	var editors *api.EditorsResponse
 
-	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	err := c.withFallback(func(host string) error {
+		var fetchErr error
+		editors, fetchErr = c.fetchEditors(host)
+		if fetchErr == nil {
+			c.rememberEditors(host, editors)
+		}
+		return fetchErr
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
 
-	req.Header.Set("User-Agent", fmt.Sprintf("rcode/%s", version.Version))
+	return editors, nil
+}
 
-	// Send request
-	resp, err := c.httpClient.Do(req)
+// fetchEditors fetches the list of editors from a specific host
+func (c *Client) fetchEditors(host string) (*api.EditorsResponse, error) {
+	resp, err := c.do(host, request{method: http.MethodGet, path: "/editors"})
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, err
 	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			c.log.Warn("Failed to close response body", "error", err)
-		}
-	}()
+	defer c.closeBody(resp)
 
 	// Check status code
 	if resp.StatusCode != http.StatusOK {
@@ -256,17 +784,277 @@ func (c *Client) fetchEditors(host string) (*api.EditorsResponse, error) {
 	return &editorsResp, nil
 }
 
+// AddEditor adds an editor on the server through the admin API
+func (c *Client) AddEditor(req api.EditorRequest) (*api.EditorsResponse, error) {
+	return c.adminEditors(http.MethodPost, "", req)
+}
+
+// UpdateEditor replaces (or, with only Default set, promotes) an editor on the server
+func (c *Client) UpdateEditor(name string, req api.EditorRequest) (*api.EditorsResponse, error) {
+	return c.adminEditors(http.MethodPut, name, req)
+}
+
+// RemoveEditor removes an editor from the server
+func (c *Client) RemoveEditor(name string) (*api.EditorsResponse, error) {
+	return c.adminEditors(http.MethodDelete, name, nil)
+}
+
+// adminEditors sends an admin editor request to the first reachable host.
+// Once a server has answered, its error is returned as is rather than
+// repeating the change on the fallback hosts.
+func (c *Client) adminEditors(method, name string, body any) (*api.EditorsResponse, error) {
+	var editors *api.EditorsResponse
+	var serverErr error
+
+	err := c.withFallback(func(host string) error {
+		var reqErr error
+		editors, reqErr = c.sendAdminEditors(host, method, name, body)
+		if reqErr == nil {
+			// The response is the updated editor list
+			c.rememberEditors(host, editors)
+		}
+
+		var errResp *api.ErrorResponse
+		if errors.As(reqErr, &errResp) {
+			serverErr = reqErr
+			return nil
+		}
+		return reqErr
+	})
+	if serverErr != nil {
+		return nil, serverErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return editors, nil
+}
+
+// sendAdminEditors sends a single admin editor request to a specific host
+func (c *Client) sendAdminEditors(host, method, name string, body any) (*api.EditorsResponse, error) {
+	path := "/admin/editors"
+	if name != "" {
+		path += "/" + url.PathEscape(name)
+	}
+
+	var jsonData []byte
+	if body != nil {
+		var err error
+		jsonData, err = json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+	}
+
+	resp, err := c.do(host, request{method: method, path: path, body: jsonData, contentType: "application/json"})
+	if err != nil {
+		return nil, err
+	}
+	defer c.closeBody(resp)
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		var errResp api.ErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil || errResp.Code == "" {
+			if resp.StatusCode == http.StatusNotFound {
+				return nil, errors.New("server does not support editor management (upgrade rcode-server)")
+			}
+			return nil, fmt.Errorf("server returned status %d", resp.StatusCode)
+		}
+		return nil, newAdminEditorError(&errResp)
+	}
+
+	var editorsResp api.EditorsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&editorsResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &editorsResp, nil
+}
+
+// adminEditorError is an admin API error response with a message that says
+// what to do about it. The response stays available via errors.As.
+type adminEditorError struct {
+	msg  string
+	resp *api.ErrorResponse
+}
+
+func (e *adminEditorError) Error() string { return e.msg }
+
+func (e *adminEditorError) Unwrap() error { return e.resp }
+
+// newAdminEditorError maps an admin API error response to an adminEditorError
+func newAdminEditorError(errResp *api.ErrorResponse) error {
+	var msg string
+	switch errResp.Code {
+	case api.CodeUnauthorized:
+		msg = "not authorized to manage editors: " + errResp.Error() +
+			" (set hosts.server.auth_token or RCODE_AUTH_TOKEN to the server's auth_token)"
+	case api.CodeEditorExists:
+		msg = fmt.Sprintf("editor %q already exists on the server", errResp.Details)
+	case api.CodeEditorNotFound:
+		msg = fmt.Sprintf("editor %q not found on the server", errResp.Details)
+	case api.CodeInvalidEditor:
+		msg = "invalid editor definition: " + errResp.Details
+	default:
+		msg = "server error: " + errResp.Error()
+	}
+	return &adminEditorError{msg: msg, resp: errResp}
+}
+
+// FetchSessions fetches recently opened sessions for user from the first reachable host
+func (c *Client) FetchSessions(user string, limit int) (*api.SessionsResponse, error) {
+	var sessions *api.SessionsResponse
+
+	err := c.withFallback(func(host string) error {
+		var fetchErr error
+		sessions, fetchErr = c.fetchSessions(host, user, limit)
+		return fetchErr
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// fetchSessions fetches recent sessions from a specific host
+func (c *Client) fetchSessions(host, user string, limit int) (*api.SessionsResponse, error) {
+	query := url.Values{}
+	if user != "" {
+		query.Set("user", user)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	path := "/sessions"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	resp, err := c.do(host, request{method: http.MethodGet, path: path})
+	if err != nil {
+		return nil, err
+	}
+	defer c.closeBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server returned status %d", resp.StatusCode)
+	}
+
+	var sessionsResp api.SessionsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&sessionsResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &sessionsResp, nil
+}
+
+// OpenURL opens rawURL in the host browser through the first reachable host
+func (c *Client) OpenURL(rawURL string) (*api.OpenURLResponse, error) {
+	var opened *api.OpenURLResponse
+
+	err := c.withFallback(func(host string) error {
+		var openErr error
+		opened, openErr = c.sendOpenURL(host, rawURL)
+		return openErr
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return opened, nil
+}
+
+// sendOpenURL asks a specific host to open rawURL
+func (c *Client) sendOpenURL(host, rawURL string) (*api.OpenURLResponse, error) {
+	jsonData, err := json.Marshal(api.OpenURLRequest{URL: rawURL})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := c.do(host, request{method: http.MethodPost, path: "/open-url", body: jsonData, contentType: "application/json"})
+	if err != nil {
+		return nil, err
+	}
+	defer c.closeBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var opened api.OpenURLResponse
+	if err := json.NewDecoder(resp.Body).Decode(&opened); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &opened, nil
+}
+
+// CopyToClipboard writes data to the host clipboard through the first reachable host
+func (c *Client) CopyToClipboard(data []byte) (*api.ClipboardResponse, error) {
+	var copied *api.ClipboardResponse
+
+	err := c.withFallback(func(host string) error {
+		var copyErr error
+		copied, copyErr = c.sendClipboard(host, data)
+		return copyErr
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return copied, nil
+}
+
+// sendClipboard posts clipboard text to a specific host
+func (c *Client) sendClipboard(host string, data []byte) (*api.ClipboardResponse, error) {
+	resp, err := c.do(host, request{method: http.MethodPost, path: "/clipboard", body: data, contentType: "text/plain; charset=utf-8"})
+	if err != nil {
+		return nil, err
+	}
+	defer c.closeBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var copied api.ClipboardResponse
+	if err := json.NewDecoder(resp.Body).Decode(&copied); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &copied, nil
+}
+
 // GetManualCommand generates a manual command that can be run on the host.
 // It first tries to fetch the editor template from the server.
 // If the server is unreachable, it falls back to configured fallback editors.
 func (c *Client) GetManualCommand(path, editor string, sshInfo *SSHInfo) string {
+	return c.GetManualCommandAt(path, FilePosition{}, editor, sshInfo)
+}
+
+// GetManualCommandAt is like GetManualCommand but also fills in {line} and {column}.
+func (c *Client) GetManualCommandAt(path string, pos FilePosition, editor string, sshInfo *SSHInfo) string {
+	return c.GetManualCommandPaths([]string{path}, pos, editor, sshInfo)
+}
+
+// GetManualCommandPaths is like GetManualCommandAt for one or more paths.
+// Templates are rendered the way the server renders them, so prefixed paths
+// (TRAMP's "/ssh:{user}@{host}:{path}", "ssh://{host}/{path}") are repeated
+// for every path.
+func (c *Client) GetManualCommandPaths(paths []string, pos FilePosition, editor string, sshInfo *SSHInfo) string {
+	if len(paths) == 0 {
+		return ""
+	}
+
 	// Use default editor if not specified
 	if editor == "" {
 		editor = c.config.DefaultEditor
 	}
 
 	// Try to fetch editor template from server
-	editorTemplate := c.fetchEditorTemplate(editor)
+	editorTemplate, variables := c.fetchEditorTemplate(editor)
 
 	if editorTemplate == "" {
 		// Fall back to configured fallback editors
@@ -279,115 +1067,193 @@ func (c *Client) GetManualCommand(path, editor string, sshInfo *SSHInfo) string
 		return ""
 	}
 
-	// Replace placeholders
-	cmd := strings.ReplaceAll(editorTemplate, "{user}", sshInfo.User)
-	cmd = strings.ReplaceAll(cmd, "{host}", sshInfo.Host)
-	cmd = strings.ReplaceAll(cmd, "{path}", path)
+	tmpl, err := editorpkg.NewTemplateWithVariables(editorTemplate, variables)
+	if err != nil {
+		c.log.Debug("Invalid editor template", "editor", editor, "error", err)
+		return ""
+	}
 
-	return cmd
+	vars := editorpkg.TemplateVars{
+		User:   sshInfo.User,
+		Host:   sshInfo.Host,
+		Path:   paths[0],
+		Line:   pos.Line,
+		Column: pos.Column,
+	}
+	if len(paths) > 1 {
+		vars.Paths = paths
+	}
+
+	return tmpl.RenderWithDefaults(vars)
 }
 
-// fetchEditorTemplate fetches the template for a specific editor from the server.
+// fetchEditorTemplate fetches the template for a specific editor from the
+// server, along with the values of its user-defined placeholders.
 // Browser editors prefer URL templates while command editors use command templates.
-func (c *Client) fetchEditorTemplate(editorName string) string {
-	var editors *api.EditorsResponse
-
-	err := c.withFallback(func(host string) error {
-		var fetchErr error
-		editors, fetchErr = c.fetchEditors(host)
-		return fetchErr
-	})
+func (c *Client) fetchEditorTemplate(editorName string) (string, map[string]string) {
+	editors, err := c.cachedEditors()
 	if err != nil {
 		c.log.Debug("Failed to fetch editors from server", "error", err)
-		return ""
+		return "", nil
 	}
 
 	// Find the editor template
 	for _, editor := range editors.Editors {
 		if editor.Name == editorName {
 			if editor.Type == "browser" && editor.URL != "" {
-				return editor.URL
+				return editor.URL, editor.Variables
 			}
 			if editor.Type == "" {
 				editor.Type = "command"
 			}
 			if editor.Type == "command" && editor.Command != "" {
-				return editor.Command
+				return editor.Command, editor.Variables
 			}
-			return ""
+			return "", nil
 		}
 	}
 
-	return ""
+	return "", nil
 }
 
-// CheckHealth checks the health of the server
-func (c *Client) CheckHealth() error {
-	// Try primary host
-	healthy, err := c.checkHostHealth(c.config.Hosts.Server.Primary)
-	if err == nil && healthy {
-		fmt.Printf("Primary host (%s) is healthy\n", c.config.Hosts.Server.Primary)
-		return nil
-	}
+// hostHealth is the health check result for one server address
+type hostHealth struct {
+	Role    string `json:"role" yaml:"role"` // primary, fallback or tunnel
+	Host    string `json:"host" yaml:"host"`
+	Healthy bool   `json:"healthy" yaml:"healthy"`
+	Status  string `json:"status,omitempty" yaml:"status,omitempty"`
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	Error   string `json:"error,omitempty" yaml:"error,omitempty"`
 
-	if err != nil {
-		fmt.Printf("Primary host (%s) check failed: %v\n", c.config.Hosts.Server.Primary, err)
-	}
+	Components []api.ComponentStatus `json:"components,omitempty" yaml:"components,omitempty"` // Readiness checks, from servers with /readyz
+}
 
-	// Try fallback host if configured
-	if c.config.Hosts.Server.Fallback != "" {
-		healthy, err = c.checkHostHealth(c.config.Hosts.Server.Fallback)
-		if err == nil && healthy {
-			fmt.Printf("Fallback host (%s) is healthy\n", c.config.Hosts.Server.Fallback)
-			return nil
+// healthReport is the result of checking the configured server addresses
+type healthReport struct {
+	Healthy bool         `json:"healthy" yaml:"healthy"`
+	Hosts   []hostHealth `json:"hosts" yaml:"hosts"`
+}
+
+// healthRoleLabels names each role in text output
+var healthRoleLabels = map[string]string{
+	"primary":  "Primary host",
+	"fallback": "Fallback host",
+	"tunnel":   "Tunnel",
+}
+
+// Health checks the primary, fallback and tunnel addresses in that order,
+// stopping at the first healthy one
+func (c *Client) Health() *healthReport {
+	report := &healthReport{}
+	for _, target := range []struct{ role, host string }{
+		{"primary", c.config.Hosts.Server.Primary},
+		{"fallback", c.config.Hosts.Server.Fallback},
+		{"tunnel", c.config.Hosts.Server.Tunnel},
+	} {
+		if target.host == "" {
+			continue
 		}
 
+		result := hostHealth{Role: target.role, Host: target.host}
+		health, err := c.fetchReadiness(target.host)
 		if err != nil {
-			fmt.Printf("Fallback host (%s) check failed: %v\n", c.config.Hosts.Server.Fallback, err)
+			result.Error = err.Error()
+		} else {
+			result.Healthy = health.IsHealthy()
+			result.Status = health.Status
+			result.Version = health.Version
+			result.Components = health.Components
+		}
+		report.Hosts = append(report.Hosts, result)
+
+		if result.Healthy {
+			report.Healthy = true
+			break
 		}
 	}
+	return report
+}
 
-	return fmt.Errorf("no healthy hosts found")
+// CheckHealth checks the health of the server and prints the result
+func (c *Client) CheckHealth() error {
+	report := c.Health()
+	for _, h := range report.Hosts {
+		switch {
+		case h.Healthy:
+			fmt.Printf("%s (%s) is healthy\n", healthRoleLabels[h.Role], h.Host)
+		case h.Error != "":
+			fmt.Printf("%s (%s) check failed: %s\n", healthRoleLabels[h.Role], h.Host, h.Error)
+		default:
+			fmt.Printf("%s (%s) is not ready\n", healthRoleLabels[h.Role], h.Host)
+		}
+		for _, comp := range h.Components {
+			mark := "ok"
+			if !comp.Healthy {
+				mark = "FAIL"
+			}
+			fmt.Printf("  %-4s %s: %s\n", mark, comp.Name, comp.Message)
+		}
+	}
+
+	if !report.Healthy {
+		return fmt.Errorf("no healthy hosts found")
+	}
+	return nil
 }
 
-// checkHostHealth checks the health of a specific host
-func (c *Client) checkHostHealth(host string) (bool, error) {
-	host = ensurePort(host)
-	url := fmt.Sprintf("http://%s/health", host)
+// fetchHealth fetches the health response from a specific host
+func (c *Client) fetchHealth(host string) (*api.HealthResponse, error) {
+	return c.fetchHealthWithin(host, c.config.Network.Timeout)
+}
 
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), c.config.Network.Timeout)
-	defer cancel()
+// fetchHealthWithin fetches the health response from host, giving up after timeout
+func (c *Client) fetchHealthWithin(host string, timeout time.Duration) (*api.HealthResponse, error) {
+	return c.fetchHealthPath(host, "/health", timeout)
+}
 
-	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
-	if err != nil {
-		return false, fmt.Errorf("failed to create request: %w", err)
+// fetchReadiness fetches host's readiness with its component checks. Servers
+// without /readyz, including any known to predate protocol negotiation, are
+// asked for /health instead.
+func (c *Client) fetchReadiness(host string) (*api.HealthResponse, error) {
+	if protocol, ok := c.serverProtocol(host); ok && protocol == api.ProtocolUnknown {
+		return c.fetchHealth(host)
 	}
+	health, err := c.fetchHealthPath(host, "/readyz", c.config.Network.Timeout)
+	if errors.Is(err, errNotFound) {
+		return c.fetchHealth(host)
+	}
+	return health, err
+}
 
-	req.Header.Set("User-Agent", fmt.Sprintf("rcode/%s", version.Version))
+// errNotFound marks a health endpoint the server does not have
+var errNotFound = errors.New("endpoint not found")
 
-	// Send request
-	resp, err := c.httpClient.Do(req)
+// fetchHealthPath fetches a health response from path on host. A 503 with a
+// health body is a valid answer from an unready server.
+func (c *Client) fetchHealthPath(host, path string, timeout time.Duration) (*api.HealthResponse, error) {
+	resp, err := c.do(host, request{method: http.MethodGet, path: path, timeout: timeout, once: true})
 	if err != nil {
-		return false, fmt.Errorf("request failed: %w", err)
+		return nil, err
 	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			c.log.Warn("Failed to close response body", "error", err)
-		}
-	}()
+	defer c.closeBody(resp)
 
 	// Check status code
-	if resp.StatusCode != http.StatusOK {
-		return false, fmt.Errorf("server returned status %d", resp.StatusCode)
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusServiceUnavailable:
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", errNotFound, path)
+	default:
+		return nil, fmt.Errorf("server returned status %d", resp.StatusCode)
 	}
 
 	// Parse response
 	var healthResp api.HealthResponse
 	if err := json.NewDecoder(resp.Body).Decode(&healthResp); err != nil {
-		return false, fmt.Errorf("failed to decode response: %w", err)
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("server returned status %d", resp.StatusCode)
+		}
+		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return healthResp.IsHealthy(), nil
+	return &healthResp, nil
 }
@@ -2,11 +2,19 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/foxytanuki/rcode/internal/auth"
 	"github.com/foxytanuki/rcode/internal/config"
 	"github.com/foxytanuki/rcode/internal/logger"
 	"github.com/foxytanuki/rcode/pkg/api"
@@ -77,6 +85,437 @@ func TestClient_OpenEditor(t *testing.T) {
 	}
 }
 
+func TestClient_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := auth.GenerateCertificates(dir, auth.CertOptions{Hosts: []string{"127.0.0.1"}}); err != nil {
+		t.Fatal(err)
+	}
+	file := func(name string) string { return filepath.Join(dir, name) }
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := api.OpenResponse{Success: true, Editor: "test-editor"}
+		resp.SetTimestamp()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	tlsConfig, err := auth.ServerTLSConfig(file(auth.ServerCertFile), file(auth.ServerKeyFile), file(auth.CAFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.TLS = tlsConfig
+	server.StartTLS()
+	defer server.Close()
+
+	newClient := func(tls config.ClientTLSConfig) *Client {
+		return NewClient(&config.ClientConfig{
+			Hosts: config.HostsConfig{
+				Server: config.ServerHostConfig{
+					Primary: strings.TrimPrefix(server.URL, "https://"),
+					TLS:     tls,
+				},
+			},
+			Network: config.ClientNetworkConfig{
+				Timeout:       2 * time.Second,
+				RetryAttempts: 1,
+			},
+			Logging: config.LogConfig{Level: "error"},
+		}, createTestLogger())
+	}
+	sshInfo := SSHInfo{User: "testuser", Host: "testhost"}
+
+	client := newClient(config.ClientTLSConfig{
+		CAFile:   file(auth.CAFile),
+		CertFile: file(auth.ClientCertFile),
+		KeyFile:  file(auth.ClientKeyFile),
+	})
+	if err := client.OpenEditor("/test/path", "test-editor", &sshInfo); err != nil {
+		t.Errorf("OpenEditor() with a client certificate error = %v, want nil", err)
+	}
+
+	// Without a certificate the handshake fails
+	client = newClient(config.ClientTLSConfig{CAFile: file(auth.CAFile)})
+	if err := client.OpenEditor("/test/path", "test-editor", &sshInfo); err == nil {
+		t.Error("OpenEditor() without a client certificate succeeded")
+	}
+
+	// A missing file fails every request instead of falling back to HTTP
+	client = newClient(config.ClientTLSConfig{CAFile: file("missing.pem")})
+	if err := client.OpenEditor("/test/path", "test-editor", &sshInfo); err == nil || !strings.Contains(err.Error(), "hosts.server.tls") {
+		t.Errorf("OpenEditor() with a missing CA file error = %v, want hosts.server.tls error", err)
+	}
+}
+
+func TestClient_OpenEditorAt(t *testing.T) {
+	var got api.OpenRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(api.OpenResponse{Success: true})
+	}))
+	defer server.Close()
+
+	cfg := &config.ClientConfig{
+		Hosts: config.HostsConfig{
+			Server: config.ServerHostConfig{Primary: server.URL[7:]},
+		},
+		Network: config.ClientNetworkConfig{
+			Timeout:       2 * time.Second,
+			RetryAttempts: 1,
+		},
+		DefaultEditor: "test-editor",
+	}
+
+	client := NewClient(cfg, createTestLogger())
+	sshInfo := SSHInfo{User: "testuser", Host: "testhost"}
+
+	if err := client.OpenEditorAt("/test/main.go", FilePosition{Line: 120, Column: 5}, "", &sshInfo); err != nil {
+		t.Fatalf("OpenEditorAt() error = %v", err)
+	}
+	if got.Line != 120 || got.Column != 5 {
+		t.Errorf("OpenEditorAt() sent line=%d column=%d, want 120 and 5", got.Line, got.Column)
+	}
+}
+
+func TestClient_OpenTerminal(t *testing.T) {
+	var got api.OpenRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(api.OpenResponse{Success: true, Editor: "wezterm"})
+	}))
+	defer server.Close()
+
+	cfg := &config.ClientConfig{
+		Hosts: config.HostsConfig{
+			Server: config.ServerHostConfig{Primary: server.URL[7:]},
+		},
+		Network: config.ClientNetworkConfig{
+			Timeout:       2 * time.Second,
+			RetryAttempts: 1,
+		},
+		DefaultEditor: "test-editor",
+	}
+
+	client := NewClient(cfg, createTestLogger())
+	sshInfo := SSHInfo{User: "testuser", Host: "testhost"}
+
+	opened, err := client.OpenTerminal("/test/project", "", &sshInfo)
+	if err != nil {
+		t.Fatalf("OpenTerminal() error = %v", err)
+	}
+	if opened.Editor != "wezterm" {
+		t.Errorf("OpenTerminal() editor = %q, want wezterm", opened.Editor)
+	}
+	if !got.Terminal || got.Path != "/test/project" || got.Editor != "" {
+		t.Errorf("OpenTerminal() sent %+v, want a terminal request for /test/project without the default editor", got)
+	}
+}
+
+func TestClient_Diff(t *testing.T) {
+	var got api.OpenRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(api.OpenResponse{Success: true, Editor: got.Editor})
+	}))
+	defer server.Close()
+
+	cfg := &config.ClientConfig{
+		Hosts: config.HostsConfig{
+			Server: config.ServerHostConfig{Primary: server.URL[7:]},
+		},
+		Network: config.ClientNetworkConfig{
+			Timeout:       2 * time.Second,
+			RetryAttempts: 1,
+		},
+		DefaultEditor: "vscode",
+	}
+
+	client := NewClient(cfg, createTestLogger())
+	sshInfo := SSHInfo{User: "testuser", Host: "testhost"}
+
+	if _, err := client.Diff("/test/a.go", "/test/b.go", "", &sshInfo); err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	if got.Path != "/test/a.go" || got.Path2 != "/test/b.go" || got.Editor != "vscode" {
+		t.Errorf("Diff() sent %+v, want a.go against b.go in vscode", got)
+	}
+}
+
+func TestClient_OpenWeb(t *testing.T) {
+	var got api.OpenRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(api.OpenResponse{Success: true, Editor: got.Editor})
+	}))
+	defer server.Close()
+
+	cfg := &config.ClientConfig{
+		Hosts: config.HostsConfig{
+			Server: config.ServerHostConfig{Primary: server.URL[7:]},
+		},
+		Network: config.ClientNetworkConfig{
+			Timeout:       2 * time.Second,
+			RetryAttempts: 1,
+		},
+	}
+
+	client := NewClient(cfg, createTestLogger())
+	sshInfo := SSHInfo{User: "testuser", Host: "testhost"}
+
+	if _, err := client.OpenWeb("/test/project", 8080, "code-server", &sshInfo); err != nil {
+		t.Fatalf("OpenWeb() error = %v", err)
+	}
+	if got.Path != "/test/project" || got.Port != 8080 || got.Editor != "code-server" {
+		t.Errorf("OpenWeb() sent %+v, want /test/project on port 8080 in code-server", got)
+	}
+}
+
+func TestClient_RenderCommand(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/render" || r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var req api.OpenRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(api.RenderResponse{
+			Editor:    req.Editor,
+			Type:      "command",
+			Command:   "code " + req.Path,
+			Available: true,
+		})
+	}))
+	defer server.Close()
+
+	cfg := &config.ClientConfig{
+		Hosts: config.HostsConfig{
+			Server: config.ServerHostConfig{Primary: server.URL[7:]},
+		},
+		Network:       config.ClientNetworkConfig{Timeout: 2 * time.Second},
+		DefaultEditor: "vscode",
+	}
+
+	client := NewClient(cfg, createTestLogger())
+	rendered, err := client.RenderCommand([]string{"/repo"}, FilePosition{}, "", &SSHInfo{User: "alice", Host: "dev"})
+	if err != nil {
+		t.Fatalf("RenderCommand() error = %v", err)
+	}
+	if rendered.Editor != "vscode" || rendered.Command != "code /repo" {
+		t.Errorf("RenderCommand() = %+v, want vscode rendering of /repo", rendered)
+	}
+}
+
+func TestClient_FetchSessions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sessions" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if got := r.URL.Query().Get("user"); got != "alice" {
+			t.Errorf("user query = %v, want alice", got)
+		}
+		if got := r.URL.Query().Get("limit"); got != "5" {
+			t.Errorf("limit query = %v, want 5", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(api.SessionsResponse{
+			Sessions: []api.SessionInfo{{Path: "/repo", Editor: "vscode", User: "alice", Host: "dev"}},
+		})
+	}))
+	defer server.Close()
+
+	cfg := &config.ClientConfig{
+		Hosts: config.HostsConfig{
+			Server: config.ServerHostConfig{Primary: server.URL[7:]},
+		},
+		Network: config.ClientNetworkConfig{Timeout: 2 * time.Second},
+	}
+
+	client := NewClient(cfg, createTestLogger())
+	sessions, err := client.FetchSessions("alice", 5)
+	if err != nil {
+		t.Fatalf("FetchSessions() error = %v", err)
+	}
+	if len(sessions.Sessions) != 1 || sessions.Sessions[0].Path != "/repo" {
+		t.Errorf("FetchSessions() = %+v, want one /repo session", sessions.Sessions)
+	}
+}
+
+func TestClient_CopyToClipboard(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/clipboard" || r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "copied text" {
+			t.Errorf("body = %q, want %q", body, "copied text")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(api.ClipboardResponse{Success: true, Bytes: len(body)})
+	}))
+	defer server.Close()
+
+	cfg := &config.ClientConfig{
+		Hosts: config.HostsConfig{
+			Server: config.ServerHostConfig{Primary: server.URL[7:]},
+		},
+		Network: config.ClientNetworkConfig{Timeout: 2 * time.Second},
+	}
+
+	client := NewClient(cfg, createTestLogger())
+	copied, err := client.CopyToClipboard([]byte("copied text"))
+	if err != nil {
+		t.Fatalf("CopyToClipboard() error = %v", err)
+	}
+	if copied.Bytes != len("copied text") {
+		t.Errorf("Bytes = %d, want %d", copied.Bytes, len("copied text"))
+	}
+}
+
+func TestClient_OpenURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/open-url" || r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var req api.OpenURLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Decode() error = %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if req.URL != "https://github.com/" {
+			w.WriteHeader(http.StatusForbidden)
+			_ = json.NewEncoder(w).Encode(api.NewErrorResponse(api.ErrURLNotAllowed, api.CodeURLNotAllowed, ""))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(api.OpenURLResponse{Success: true, URL: req.URL})
+	}))
+	defer server.Close()
+
+	cfg := &config.ClientConfig{
+		Hosts: config.HostsConfig{
+			Server: config.ServerHostConfig{Primary: server.URL[7:]},
+		},
+		Network: config.ClientNetworkConfig{Timeout: 2 * time.Second},
+	}
+
+	client := NewClient(cfg, createTestLogger())
+	opened, err := client.OpenURL("https://github.com/")
+	if err != nil {
+		t.Fatalf("OpenURL() error = %v", err)
+	}
+	if opened.URL != "https://github.com/" {
+		t.Errorf("URL = %q, want %q", opened.URL, "https://github.com/")
+	}
+
+	if _, err := client.OpenURL("https://evil.example/"); err == nil {
+		t.Error("OpenURL() error = nil for a rejected URL")
+	}
+}
+
+func TestClient_AddEditor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/admin/editors" || r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q, want bearer token", got)
+		}
+		var req api.EditorRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(api.EditorsResponse{
+			Editors:       []api.EditorInfo{{Name: "code"}, {Name: req.Name, Command: req.Command}},
+			DefaultEditor: "code",
+		})
+	}))
+	defer server.Close()
+
+	cfg := &config.ClientConfig{
+		Hosts: config.HostsConfig{
+			Server: config.ServerHostConfig{Primary: server.URL[7:], AuthToken: "secret"},
+		},
+		Network: config.ClientNetworkConfig{Timeout: 2 * time.Second},
+	}
+
+	client := NewClient(cfg, createTestLogger())
+	editors, err := client.AddEditor(api.EditorRequest{Name: "zed", Command: "zed ssh://{user}@{host}/{path}"})
+	if err != nil {
+		t.Fatalf("AddEditor() error = %v", err)
+	}
+	if len(editors.Editors) != 2 || editors.Editors[1].Name != "zed" {
+		t.Errorf("AddEditor() editors = %+v, want code and zed", editors.Editors)
+	}
+}
+
+func TestClient_RemoveEditor_ServerError(t *testing.T) {
+	fallbackUsed := false
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fallbackUsed = true
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer fallback.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/admin/editors/zed" || r.Method != http.MethodDelete {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(api.NewErrorResponse(api.ErrEditorNotFound, api.CodeEditorNotFound, "zed"))
+	}))
+	defer server.Close()
+
+	cfg := &config.ClientConfig{
+		Hosts: config.HostsConfig{
+			Server: config.ServerHostConfig{Primary: server.URL[7:], Fallback: fallback.URL[7:]},
+		},
+		Network: config.ClientNetworkConfig{Timeout: 2 * time.Second},
+	}
+
+	client := NewClient(cfg, createTestLogger())
+	_, err := client.RemoveEditor("zed")
+	if err == nil {
+		t.Fatal("RemoveEditor() error = nil, want error")
+	}
+	if want := `editor "zed" not found on the server`; err.Error() != want {
+		t.Errorf("RemoveEditor() error = %q, want %q", err, want)
+	}
+
+	var errResp *api.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Code != api.CodeEditorNotFound {
+		t.Errorf("RemoveEditor() error does not wrap %s response", api.CodeEditorNotFound)
+	}
+	if fallbackUsed {
+		t.Error("RemoveEditor() retried on the fallback host after the server answered")
+	}
+}
+
 func TestClient_OpenEditor_WithFallback(t *testing.T) {
 	// Create primary server that fails
 	primaryFailed := false
@@ -145,6 +584,105 @@ func TestClient_OpenEditor_WithFallback(t *testing.T) {
 	}
 }
 
+func TestClient_OpenEditor_RaceSelection(t *testing.T) {
+	// newServer counts /health probes and /open-editor requests, answering
+	// health checks after delay
+	newServer := func(delay time.Duration, probes, opens *atomic.Int32) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			switch r.URL.Path {
+			case "/health":
+				probes.Add(1)
+				time.Sleep(delay)
+				_ = json.NewEncoder(w).Encode(api.HealthResponse{Status: "healthy"})
+			case "/open-editor":
+				opens.Add(1)
+				_ = json.NewEncoder(w).Encode(api.OpenResponse{Success: true})
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+	}
+
+	var slowProbes, slowOpens, fastProbes, fastOpens atomic.Int32
+	slow := newServer(300*time.Millisecond, &slowProbes, &slowOpens)
+	defer slow.Close()
+	fast := newServer(0, &fastProbes, &fastOpens)
+	defer fast.Close()
+
+	cfg := &config.ClientConfig{
+		Hosts: config.HostsConfig{
+			Server: config.ServerHostConfig{
+				Primary:  slow.URL[7:],
+				Fallback: fast.URL[7:],
+			},
+		},
+		Network: config.ClientNetworkConfig{
+			Timeout:       2 * time.Second,
+			RetryAttempts: 1,
+			HostSelection: config.HostSelectionRace,
+			ProbeTimeout:  time.Second,
+		},
+		DefaultEditor: "test-editor",
+	}
+
+	client := NewClient(cfg, createTestLogger())
+	sshInfo := SSHInfo{User: "testuser", Host: "testhost"}
+
+	for i := 0; i < 2; i++ {
+		if err := client.OpenEditor("/test/path", "", &sshInfo); err != nil {
+			t.Fatalf("OpenEditor() error = %v", err)
+		}
+	}
+
+	if got := fastOpens.Load(); got != 2 {
+		t.Errorf("fast host got %d open requests, want 2", got)
+	}
+	if got := slowOpens.Load(); got != 0 {
+		t.Errorf("slow primary host got %d open requests, want 0", got)
+	}
+	if got := fastProbes.Load(); got != 1 {
+		t.Errorf("fast host was probed %d times, want 1 (winner is remembered)", got)
+	}
+}
+
+func TestClient_HostOrder(t *testing.T) {
+	cfg := &config.ClientConfig{
+		Hosts: config.HostsConfig{
+			Server: config.ServerHostConfig{
+				Primary: "primary:3339",
+				Tunnel:  "localhost:3339",
+				Broker:  "broker:3340",
+			},
+		},
+		Network: config.ClientNetworkConfig{HostSelection: config.HostSelectionRace},
+	}
+	client := NewClient(cfg, createTestLogger())
+
+	// Pretend the race already ran and the tunnel won
+	client.raceOnce.Do(func() {})
+	client.preferred = "localhost:3339"
+
+	var got []string
+	for _, target := range client.hostOrder() {
+		got = append(got, target.role)
+	}
+	want := []string{"tunnel", "primary", "broker"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("hostOrder() = %v, want %v", got, want)
+	}
+
+	cfg.Network.HostSelection = config.HostSelectionOrdered
+	got = nil
+	for _, target := range client.hostOrder() {
+		got = append(got, target.role)
+	}
+	want = []string{"primary", "tunnel", "broker"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("hostOrder() in ordered mode = %v, want %v", got, want)
+	}
+}
+
 func TestClient_ListEditors(t *testing.T) {
 	// Create test server
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -194,6 +732,48 @@ func TestClient_ListEditors(t *testing.T) {
 	}
 }
 
+func TestClient_PreviewEditor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/editors/my editor/preview" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		query := r.URL.Query()
+		if query.Get("path") != "/home/user/main.go" || query.Get("user") != "user" || query.Get("host") != "remote" || query.Get("line") != "12" {
+			t.Errorf("query = %v", query)
+		}
+		if query.Has("column") {
+			t.Errorf("column sent without one: %v", query)
+		}
+
+		resp := api.EditorPreviewResponse{
+			RenderResponse: api.RenderResponse{Editor: "my editor", Command: "code /home/user/main.go"},
+			Issues:         []string{"template has no {host} placeholder"},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	cfg := &config.ClientConfig{
+		Hosts:   config.HostsConfig{Server: config.ServerHostConfig{Primary: server.URL[7:]}},
+		Network: config.ClientNetworkConfig{Timeout: 2 * time.Second},
+	}
+	client := NewClient(cfg, createTestLogger())
+
+	preview, err := client.PreviewEditor("my editor", "/home/user/main.go", FilePosition{Line: 12}, &SSHInfo{User: "user", Host: "remote"})
+	if err != nil {
+		t.Fatalf("PreviewEditor() error = %v", err)
+	}
+	if preview.Command != "code /home/user/main.go" || len(preview.Issues) != 1 {
+		t.Errorf("PreviewEditor() = %+v", preview)
+	}
+
+	if _, err := client.PreviewEditor("missing", "/home/user", FilePosition{}, &SSHInfo{User: "user", Host: "remote"}); err == nil {
+		t.Error("PreviewEditor() for an unknown editor succeeded")
+	}
+}
+
 func TestClient_CheckHealth(t *testing.T) {
 	// Create test server
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -241,6 +821,53 @@ func TestClient_CheckHealth(t *testing.T) {
 	}
 }
 
+func TestClient_Health_Readiness(t *testing.T) {
+	notReady := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/readyz" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_ = json.NewEncoder(w).Encode(api.HealthResponse{
+			Status:     api.StatusUnhealthy,
+			Components: []api.ComponentStatus{{Name: "editors", Message: "no editor is available on this machine"}},
+		})
+	}))
+	defer notReady.Close()
+
+	// An older server without /readyz is checked with /health
+	legacy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(api.HealthResponse{Status: api.StatusHealthy, Version: "0.9.0"})
+	}))
+	defer legacy.Close()
+
+	cfg := &config.ClientConfig{
+		Hosts: config.HostsConfig{
+			Server: config.ServerHostConfig{Primary: notReady.URL[7:], Fallback: legacy.URL[7:]},
+		},
+		Network: config.ClientNetworkConfig{Timeout: 2 * time.Second},
+	}
+
+	report := NewClient(cfg, createTestLogger()).Health()
+	if !report.Healthy || len(report.Hosts) != 2 {
+		t.Fatalf("Health() = %+v, want the fallback healthy after an unready primary", report)
+	}
+
+	primary, fallback := report.Hosts[0], report.Hosts[1]
+	if primary.Healthy || primary.Error != "" || len(primary.Components) != 1 || primary.Components[0].Name != "editors" {
+		t.Errorf("primary = %+v, want unready with the editors component", primary)
+	}
+	if !fallback.Healthy || fallback.Version != "0.9.0" {
+		t.Errorf("fallback = %+v, want healthy version 0.9.0", fallback)
+	}
+}
+
 func TestClient_GetManualCommand(t *testing.T) {
 	// Note: GetManualCommand now tries to fetch from server first, then falls back
 	// to well-known editor commands. These tests verify the fallback behavior
@@ -292,6 +919,16 @@ func TestClient_GetManualCommand(t *testing.T) {
 			},
 			want: "zed ssh://eve@zed.local//home/project",
 		},
+		{
+			name:   "fallback emacs tramp command",
+			path:   "/home/project",
+			editor: "emacs",
+			sshInfo: SSHInfo{
+				User: "grace",
+				Host: "emacs.local",
+			},
+			want: "emacsclient -n /ssh:grace@emacs.local:/home/project",
+		},
 		{
 			name:   "unknown editor returns empty",
 			path:   "/home/project",
@@ -324,6 +961,7 @@ func TestClient_GetManualCommand(t *testing.T) {
 					"zed":    "zed ssh://{user}@{host}/{path}",
 					"nvim":   "nvim scp://{user}@{host}/{path}",
 					"neovim": "nvim scp://{user}@{host}/{path}",
+					"emacs":  "emacsclient -n /ssh:{user}@{host}:{path}",
 				},
 				Logging: config.LogConfig{
 					Level: "error",
@@ -417,11 +1055,11 @@ func TestClient_Retry(t *testing.T) {
 	attempts := 0
 	maxAttempts := 3
 
-	// Create test server that fails first attempts
+	// Create test server that is unavailable for the first attempts
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		attempts++
 		if attempts < maxAttempts {
-			w.WriteHeader(http.StatusInternalServerError)
+			w.WriteHeader(http.StatusServiceUnavailable)
 			return
 		}
 
@@ -476,6 +1114,198 @@ func TestClient_Retry(t *testing.T) {
 	}
 }
 
+func TestClient_RetryResendsBodyOnSharedConnection(t *testing.T) {
+	var paths []string
+	newConns := 0
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req api.OpenRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("attempt %d: failed to decode body: %v", len(paths)+1, err)
+		}
+		paths = append(paths, req.Path)
+		if len(paths) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(api.OpenResponse{Success: true})
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			newConns++
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	cfg := &config.ClientConfig{
+		Hosts: config.HostsConfig{
+			Server: config.ServerHostConfig{Primary: server.URL[7:]},
+		},
+		Network: config.ClientNetworkConfig{
+			Timeout:       2 * time.Second,
+			RetryAttempts: 3,
+			RetryDelay:    time.Millisecond,
+		},
+		DefaultEditor: "test-editor",
+	}
+
+	// Two commands: the first succeeds on its third attempt
+	client := NewClient(cfg, createTestLogger())
+	for i := 0; i < 2; i++ {
+		if err := client.OpenEditor("/test/path", "", &SSHInfo{User: "u", Host: "h"}); err != nil {
+			t.Fatalf("OpenEditor() error = %v", err)
+		}
+	}
+
+	if strings.Join(paths, ",") != "/test/path,/test/path,/test/path,/test/path" {
+		t.Errorf("request paths = %v, want the full body on every attempt", paths)
+	}
+	if newConns != 1 {
+		t.Errorf("server saw %d connections, want 1 kept alive across attempts", newConns)
+	}
+}
+
+func TestClient_ServerPort(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(api.HealthResponse{Status: "healthy"})
+	}))
+	defer server.Close()
+
+	host, portStr, _ := net.SplitHostPort(server.URL[7:])
+	port, _ := strconv.Atoi(portStr)
+	cfg := &config.ClientConfig{
+		Hosts:   config.HostsConfig{Server: config.ServerHostConfig{Primary: host, Port: port}},
+		Network: config.ClientNetworkConfig{Timeout: 2 * time.Second},
+	}
+	client := NewClient(cfg, createTestLogger())
+
+	// hosts.server.port applies to hosts without a port
+	if _, err := client.fetchHealth(host); err != nil {
+		t.Errorf("fetchHealth(%q) with port %d error = %v", host, port, err)
+	}
+
+	// A port in the host wins
+	cfg.Hosts.Server.Port = 1
+	if _, err := client.fetchHealth(server.URL[7:]); err != nil {
+		t.Errorf("fetchHealth(%q) error = %v", server.URL[7:], err)
+	}
+}
+
+func TestClient_IPv6Host(t *testing.T) {
+	listener, err := net.Listen("tcp6", "[::1]:0")
+	if err != nil {
+		t.Skipf("IPv6 loopback not available: %v", err)
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(api.HealthResponse{Status: "healthy"})
+	}))
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	host := "[::1]:" + port
+	cfg := &config.ClientConfig{
+		Hosts:   config.HostsConfig{Server: config.ServerHostConfig{Primary: host}},
+		Network: config.ClientNetworkConfig{Timeout: 2 * time.Second},
+	}
+	if _, err := NewClient(cfg, createTestLogger()).fetchHealth(host); err != nil {
+		t.Errorf("fetchHealth(%q) error = %v", host, err)
+	}
+
+	// A bare IPv6 literal gets the default port, not the last group as a port
+	if got, err := serverAddr("::1", 0); err != nil || got != "[::1]:3339" {
+		t.Errorf("serverAddr(::1) = %q, %v, want [::1]:3339", got, err)
+	}
+}
+
+func TestClient_SendsAuthToken(t *testing.T) {
+	const token = "0123456789abcdef0123456789abcdef"
+
+	var gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+
+		resp := api.OpenResponse{Success: true}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			t.Fatalf("Failed to encode response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	cfg := &config.ClientConfig{
+		Hosts: config.HostsConfig{
+			Server: config.ServerHostConfig{
+				Primary:   server.URL[7:],
+				AuthToken: token,
+			},
+		},
+		Network: config.ClientNetworkConfig{
+			Timeout:       2 * time.Second,
+			RetryAttempts: 1,
+		},
+	}
+
+	client := NewClient(cfg, createTestLogger())
+	sshInfo := SSHInfo{User: "testuser", Host: "testhost"}
+
+	if err := client.OpenEditor("/test/path", "test-editor", &sshInfo); err != nil {
+		t.Fatalf("OpenEditor() error = %v", err)
+	}
+
+	if gotAuth != "Bearer "+token {
+		t.Errorf("Authorization header = %q, want %q", gotAuth, "Bearer "+token)
+	}
+}
+
+func TestClient_RequestID(t *testing.T) {
+	var ids []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(api.HeaderRequestID)
+		ids = append(ids, id)
+		if len(ids) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		resp := api.NewErrorResponse(api.ErrInvalidEditor, api.CodeInvalidEditor, "")
+		resp.RequestID = id
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	cfg := &config.ClientConfig{
+		Hosts: config.HostsConfig{
+			Server: config.ServerHostConfig{Primary: server.URL[7:]},
+		},
+		Network: config.ClientNetworkConfig{
+			Timeout:       2 * time.Second,
+			RetryAttempts: 2,
+			RetryDelay:    10 * time.Millisecond,
+		},
+	}
+
+	client := NewClient(cfg, createTestLogger())
+	sshInfo := SSHInfo{User: "testuser", Host: "testhost"}
+
+	err := client.OpenEditor("/test/path", "test-editor", &sshInfo)
+	if err == nil {
+		t.Fatal("OpenEditor() error = nil, want error")
+	}
+	if len(ids) != 2 || ids[0] == "" || ids[0] != ids[1] {
+		t.Fatalf("request IDs = %q, want one ID shared by both attempts", ids)
+	}
+	if want := "(request " + ids[0] + ")"; !strings.Contains(err.Error(), want) {
+		t.Errorf("OpenEditor() error = %q, want it to contain %q", err, want)
+	}
+}
+
 // Helper function
 func createTestLogger() *logger.Logger {
 	return logger.New(&logger.Config{
@@ -483,3 +1313,44 @@ func createTestLogger() *logger.Logger {
 		Console: false,
 	})
 }
+
+func TestClient_ProtocolNegotiation(t *testing.T) {
+	var readyzHits atomic.Int32
+	var sent atomic.Value
+	legacy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent.Store(r.Header.Get(api.HeaderProtocol))
+		switch r.URL.Path {
+		case "/readyz":
+			readyzHits.Add(1)
+			w.WriteHeader(http.StatusNotFound)
+		case "/health":
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(api.HealthResponse{Status: api.StatusHealthy})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer legacy.Close()
+
+	cfg := &config.ClientConfig{
+		Hosts:   config.HostsConfig{Server: config.ServerHostConfig{Primary: legacy.URL[7:]}},
+		Network: config.ClientNetworkConfig{Timeout: 2 * time.Second},
+	}
+	client := NewClient(cfg, createTestLogger())
+
+	for i := 0; i < 2; i++ {
+		if report := client.Health(); !report.Healthy {
+			t.Fatalf("Health() = %+v, want healthy", report)
+		}
+	}
+	if got := sent.Load(); got != strconv.Itoa(api.ProtocolVersion) {
+		t.Errorf("%s sent = %v, want %d", api.HeaderProtocol, got, api.ProtocolVersion)
+	}
+	if protocol, ok := client.serverProtocol(legacy.URL[7:]); !ok || protocol != api.ProtocolUnknown {
+		t.Errorf("serverProtocol() = %d, %v, want %d, true", protocol, ok, api.ProtocolUnknown)
+	}
+	// Once the server is known to predate negotiation, /readyz is skipped
+	if got := readyzHits.Load(); got != 1 {
+		t.Errorf("/readyz requests = %d, want 1", got)
+	}
+}
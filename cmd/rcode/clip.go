@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var clipCmd = &cobra.Command{
+	Use:   "clip",
+	Short: "Copy standard input to the host clipboard",
+	Long: `Copy standard input to the clipboard of the machine running rcode-server,
+using pbcopy, clip, wl-copy, xclip or xsel there.
+
+The server must enable the clipboard bridge with server.clipboard.enabled,
+and limits the text to server.clipboard.max_kb (default 1024 KB).`,
+	Example: `  git diff | rcode clip
+  rcode clip < notes.txt`,
+	Args: cobra.NoArgs,
+	RunE: runClip,
+}
+
+func runClip(_ *cobra.Command, _ []string) error {
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("failed to read standard input: %w", err)
+	}
+	if len(data) == 0 {
+		return errors.New("nothing to copy: standard input is empty")
+	}
+
+	return withAdminClient(func(client *Client) error {
+		copied, err := client.CopyToClipboard(data)
+		if err != nil {
+			return fmt.Errorf("failed to copy to clipboard: %w", err)
+		}
+		if structuredOutput() {
+			return writeStructured(os.Stdout, copied)
+		}
+		fmt.Fprintf(os.Stderr, "Copied %d bytes to the host clipboard.\n", copied.Bytes)
+		return nil
+	})
+}
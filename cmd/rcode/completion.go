@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/logger"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// completionTimeout bounds the /editors request made while completing
+	completionTimeout = 500 * time.Millisecond
+	// completionCacheTTL is how long fetched editor names are reused
+	completionCacheTTL = 5 * time.Minute
+)
+
+var completionCmd = &cobra.Command{
+	Use:   "completion bash|zsh|fish",
+	Short: "Generate a shell completion script",
+	Long: `Print a completion script for bash, zsh or fish. Editor names are completed
+from the server's editor list, cached for a few minutes.
+
+  # bash
+  rcode completion bash > /etc/bash_completion.d/rcode
+
+  # zsh
+  rcode completion zsh > "${fpath[1]}/_rcode"
+
+  # fish
+  rcode completion fish > ~/.config/fish/completions/rcode.fish`,
+	ValidArgs:             []string{"bash", "zsh", "fish"},
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	DisableFlagsInUseLine: true,
+	RunE:                  runCompletion,
+}
+
+func runCompletion(_ *cobra.Command, args []string) error {
+	switch args[0] {
+	case "bash":
+		return rootCmd.GenBashCompletionV2(os.Stdout, true)
+	case "zsh":
+		return rootCmd.GenZshCompletion(os.Stdout)
+	default:
+		return rootCmd.GenFishCompletion(os.Stdout, true)
+	}
+}
+
+// editorNameCache is the on-disk cache of editor names used for completion
+type editorNameCache struct {
+	Server    string    `json:"server"`
+	FetchedAt time.Time `json:"fetched_at"`
+	Names     []string  `json:"names"`
+}
+
+// completionCachePath returns the editor name cache file
+func completionCachePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "rcode", "editors.json")
+}
+
+// completeEditorNames completes editor names from the server, falling back
+// to a stale cache and then to the locally known fallback editors
+func completeEditorNames(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	names := editorNamesForCompletion(completionCachePath(), time.Now())
+
+	matches := make([]string, 0, len(names))
+	for _, name := range names {
+		if strings.HasPrefix(name, toComplete) {
+			matches = append(matches, name)
+		}
+	}
+	return matches, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeFirstEditorArg completes an editor name as the first argument only
+func completeFirstEditorArg(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeEditorNames(cmd, args, toComplete)
+}
+
+// editorNamesForCompletion returns editor names, using the cache at
+// cachePath while it is fresh for the configured server
+func editorNamesForCompletion(cachePath string, now time.Time) []string {
+	cfg, err := loadClientConfig()
+	if err != nil {
+		return nil
+	}
+	server := cfg.Hosts.Server.Primary
+
+	cached := readEditorNameCache(cachePath)
+	if cached != nil && cached.Server == server && now.Sub(cached.FetchedAt) < completionCacheTTL {
+		return cached.Names
+	}
+
+	// Keep completion snappy: one short attempt per host
+	cfg.Network.Timeout = completionTimeout
+	cfg.Network.RetryAttempts = 1
+	cfg.Network.CacheTTL = -1 // completion keeps its own cache
+	log := logger.New(&logger.Config{Level: "error", Console: true, Format: "text"})
+	defer func() { _ = log.Close() }()
+
+	editors, err := NewClient(cfg, log).fetchEditorsWithFallback()
+	if err == nil {
+		names := make([]string, 0, len(editors.Editors))
+		for _, e := range editors.Editors {
+			names = append(names, e.Name)
+			names = append(names, e.Aliases...)
+		}
+		writeEditorNameCache(cachePath, &editorNameCache{Server: server, FetchedAt: now, Names: names})
+		return names
+	}
+
+	if cached != nil && cached.Server == server {
+		return cached.Names
+	}
+
+	names := make([]string, 0, len(cfg.FallbackEditors))
+	for name := range cfg.FallbackEditors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// readEditorNameCache reads the cache, returning nil when it is missing or invalid
+func readEditorNameCache(path string) *editorNameCache {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil
+	}
+	var cache editorNameCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil
+	}
+	return &cache
+}
+
+// writeEditorNameCache saves the cache; failures only cost a later refetch
+func writeEditorNameCache(path string, cache *editorNameCache) {
+	if path == "" {
+		return
+	}
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write completion cache: %v\n", err)
+	}
+}
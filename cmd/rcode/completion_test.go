@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+func TestEditorNamesForCompletion(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(api.EditorsResponse{
+			Editors: []api.EditorInfo{{Name: "cursor"}, {Name: "zed"}},
+		})
+	}))
+	serverHost := strings.TrimPrefix(server.URL, "http://")
+
+	dir := t.TempDir()
+	configFile = filepath.Join(dir, "config.yaml")
+	defer func() { configFile = "" }()
+	cfgData := "hosts:\n  server:\n    primary: \"" + serverHost + "\"\nfallback_editors:\n  nvim: \"nvim scp://{user}@{host}/{path}\"\nlogging:\n  file: \"" + filepath.Join(dir, "client.log") + "\"\n"
+	if err := os.WriteFile(configFile, []byte(cfgData), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cachePath := filepath.Join(dir, "cache", "editors.json")
+	now := time.Now()
+
+	// Fetched from the server and cached
+	if got := strings.Join(editorNamesForCompletion(cachePath, now), ","); got != "cursor,zed" {
+		t.Fatalf("first call = %q, want cursor,zed", got)
+	}
+
+	// Served from the fresh cache
+	if got := strings.Join(editorNamesForCompletion(cachePath, now.Add(time.Minute)), ","); got != "cursor,zed" || requests != 1 {
+		t.Errorf("cached call = %q after %d requests, want cursor,zed after 1", got, requests)
+	}
+
+	// A stale cache is still better than nothing when the server is down
+	server.Close()
+	if got := strings.Join(editorNamesForCompletion(cachePath, now.Add(time.Hour)), ","); got != "cursor,zed" {
+		t.Errorf("stale cache call = %q, want cursor,zed", got)
+	}
+
+	// Without a cache, fall back to the configured fallback editors
+	if err := os.Remove(cachePath); err != nil {
+		t.Fatal(err)
+	}
+	if got := editorNamesForCompletion(cachePath, now); len(got) == 0 || !strings.Contains(strings.Join(got, ","), "nvim") {
+		t.Errorf("fallback call = %v, want the fallback editors", got)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var configGetCmd = &cobra.Command{
+	Use:   "get KEY",
+	Short: "Print a configuration value",
+	Long: `Print the value of a dotted configuration key, such as hosts.server.primary.
+Sections such as hosts.server are printed as YAML.`,
+	Example: `  rcode config get hosts.server.primary
+  rcode config get network`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConfigGet,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set KEY VALUE",
+	Short: "Set a configuration value",
+	Long: `Set a dotted configuration key in the client configuration file.
+
+VALUE is parsed according to the key's type (text, number, true/false or a
+duration such as 2s). The configuration is validated before it is saved, and
+the rest of the file, including comments, is left as it is.`,
+	Example: `  rcode config set hosts.server.primary 10.0.0.5
+  rcode config set network.timeout 3s
+  rcode config set fallback_editors.zed "zed ssh://{user}@{host}/{path}"`,
+	Args: cobra.ExactArgs(2),
+	RunE: runConfigSet,
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset KEY",
+	Short: "Remove a configuration value",
+	Long:  `Remove a dotted configuration key from the client configuration file so its default applies again.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runConfigUnset,
+}
+
+func runConfigGet(_ *cobra.Command, args []string) error {
+	cfg, err := config.LoadClientConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	value, err := config.GetClientConfigValue(cfg, args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Println(value)
+	return nil
+}
+
+func runConfigSet(_ *cobra.Command, args []string) error {
+	return config.SetClientConfigValue(configFile, args[0], args[1])
+}
+
+func runConfigUnset(_ *cobra.Command, args []string) error {
+	return config.UnsetClientConfigValue(configFile, args[0])
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// deprecationsOnce keeps the notices to one printing per run, however many
+// times the command loads the config
+var deprecationsOnce sync.Once
+
+// silenceDeprecations drops the notices for commands whose output a shell
+// reads, such as completion
+func silenceDeprecations(cmd *cobra.Command) {
+	if strings.HasPrefix(cmd.Name(), "__") {
+		deprecationsOnce.Do(func() {})
+	}
+}
+
+// printDeprecations writes the deprecation notices found loading cfg to w,
+// the first time it is called, unless hide_deprecations is set
+func printDeprecations(w io.Writer, cfg *config.ClientConfig) {
+	if cfg.HideDeprecations || len(cfg.Deprecations) == 0 {
+		return
+	}
+	deprecationsOnce.Do(func() {
+		for _, d := range cfg.Deprecations {
+			fmt.Fprintf(w, "Warning: %s - %s\n", d.Field, d.Message)
+		}
+		fmt.Fprintln(w, `Set "hide_deprecations: true" in the config to hide these notices`)
+	})
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/foxytanuki/rcode/internal/config"
+)
+
+func TestPrintDeprecations(t *testing.T) {
+	deprecationsOnce = sync.Once{}
+	defer func() { deprecationsOnce = sync.Once{} }()
+
+	cfg := &config.ClientConfig{Deprecations: []config.MigrationWarning{
+		{Field: "RCODE_HOST", Message: "RCODE_HOST is deprecated for client, use RCODE_SERVER_HOST instead"},
+	}}
+
+	var out bytes.Buffer
+	cfg.HideDeprecations = true
+	printDeprecations(&out, cfg)
+	if out.Len() != 0 {
+		t.Errorf("printed %q with hide_deprecations set", out.String())
+	}
+
+	cfg.HideDeprecations = false
+	printDeprecations(&out, cfg)
+	printDeprecations(&out, cfg)
+	if got := strings.Count(out.String(), "Warning: RCODE_HOST"); got != 1 {
+		t.Errorf("notice printed %d times, want once:\n%s", got, out.String())
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff FILE1 FILE2",
+	Short: "Compare two files in a diff tool on the host",
+	Long: `Compare two files of this machine in the diff tool of an editor on the host.
+
+The editor must have a diff command template in the server config, using
+{path} and {path2}; the known editors VS Code, Cursor and Windsurf
+("--diff"), Meld and KDiff3 come with one.`,
+	Example: `  rcode diff main.go main.go.orig
+  rcode diff -e meld config.yaml /etc/app/config.yaml`,
+	Args: cobra.ExactArgs(2),
+	RunE: runDiff,
+}
+
+func runDiff(_ *cobra.Command, args []string) error {
+	oc, err := newOpenContext()
+	if err != nil {
+		return err
+	}
+	defer oc.close()
+
+	paths := make([]string, len(args))
+	for i, arg := range args {
+		if paths[i], err = absPath(oc.cfg, arg); err != nil {
+			return err
+		}
+	}
+
+	oc.log.Info("Opening diff",
+		"path", paths[0],
+		"path2", paths[1],
+		"editor", editor,
+		"user", oc.sshInfo.User,
+		"host", oc.sshInfo.Host,
+		"server", oc.cfg.Hosts.Server.Primary,
+	)
+
+	opened, err := oc.client.Diff(paths[0], paths[1], editor, &oc.sshInfo)
+	if err != nil {
+		if isUnreachable(err) {
+			fmt.Fprintf(os.Stderr, "%s", tunnelHint(oc.cfg, &oc.sshInfo))
+		}
+		return fmt.Errorf("failed to open diff: %w", err)
+	}
+	if structuredOutput() {
+		return writeStructured(os.Stdout, opened)
+	}
+	fmt.Printf("Comparing %s and %s in %s\n", paths[0], paths[1], opened.Editor)
+	return nil
+}
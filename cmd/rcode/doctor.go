@@ -0,0 +1,206 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// checkStatus is the outcome of a single doctor check
+type checkStatus string
+
+const (
+	checkOK   checkStatus = "OK"
+	checkWarn checkStatus = "WARN"
+	checkFail checkStatus = "FAIL"
+)
+
+// doctorCheck is a single line in the doctor report
+type doctorCheck struct {
+	Name    string      `json:"name" yaml:"name"`
+	Status  checkStatus `json:"status" yaml:"status"`
+	Message string      `json:"message" yaml:"message"`
+}
+
+// doctorReport collects the results of all doctor checks
+type doctorReport struct {
+	Checks []doctorCheck    `json:"checks" yaml:"checks"`
+	Hosts  *hostExplanation `json:"hosts,omitempty" yaml:"hosts,omitempty"`
+	Failed bool             `json:"failed" yaml:"failed"` // Set by runDoctor before output
+}
+
+func (r *doctorReport) add(name string, status checkStatus, format string, args ...any) {
+	r.Checks = append(r.Checks, doctorCheck{
+		Name:    name,
+		Status:  status,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// failed returns true if any check failed
+func (r *doctorReport) failed() bool {
+	for _, check := range r.Checks {
+		if check.Status == checkFail {
+			return true
+		}
+	}
+	return false
+}
+
+// print writes the report in a human-readable format
+func (r *doctorReport) print() {
+	fmt.Println("rcode doctor")
+	fmt.Println("============")
+	for _, check := range r.Checks {
+		fmt.Printf("[%-4s] %s: %s\n", check.Status, check.Name, check.Message)
+	}
+	if r.Hosts != nil {
+		fmt.Println()
+		r.Hosts.print(os.Stdout)
+	}
+}
+
+func runDoctor(_ *cobra.Command, _ []string) error {
+	report := runDoctorChecks()
+	report.Failed = report.failed()
+	if structuredOutput() {
+		if err := writeStructured(os.Stdout, report); err != nil {
+			return err
+		}
+	} else {
+		report.print()
+	}
+
+	if report.Failed {
+		return errors.New("one or more checks failed")
+	}
+	return nil
+}
+
+// runDoctorChecks runs every doctor check and returns the report
+func runDoctorChecks() *doctorReport {
+	report := &doctorReport{}
+
+	// Configuration
+	configPath := configFile
+	if configPath == "" {
+		configPath = config.GetDefaultPaths().ClientConfig
+	}
+	cfg, err := config.LoadClientConfig(configFile)
+	if err != nil {
+		report.add("config", checkFail, "failed to load %s: %v", configPath, err)
+		return report
+	}
+	report.add("config", checkOK, "loaded %s", configPath)
+
+	if name := selectProfile(cfg); name != "" {
+		if err := cfg.ApplyProfile(name); err != nil {
+			report.add("profile", checkFail, "%v", err)
+			return report
+		}
+		report.add("profile", checkOK, "using profile %q", name)
+	}
+
+	if host != "" {
+		cfg.Hosts.Server.Primary = host
+	}
+	config.MergeClientWithEnvironment(cfg)
+
+	if err := config.ValidateClientConfig(cfg); err != nil {
+		report.add("config validation", checkFail, "%v", err)
+	} else {
+		report.add("config validation", checkOK, "configuration is valid")
+	}
+
+	// Listed even when hide_deprecations is set
+	for _, d := range cfg.Deprecations {
+		report.add("deprecation", checkWarn, "%s: %s", d.Field, d.Message)
+	}
+
+	// SSH session
+	sshInfo, err := ExtractSSHInfo()
+	if err != nil {
+		report.add("ssh session", checkWarn, "%v (host detection falls back to hostname)", err)
+	} else {
+		report.add("ssh session", checkOK, "connected from %s", sshInfo.ClientIP)
+	}
+
+	// Host resolution
+	resolver, resolved := resolveHosts(cfg, &sshInfo)
+	report.Hosts = &hostExplanation{Sources: resolver.Explain(), Resolved: resolved}
+	report.add("host resolution", checkOK, "ssh host %q (source: %s), server %q",
+		resolved.SSH, resolved.Source, cfg.Hosts.Server.Primary)
+
+	// Server connectivity
+	log := newQuietLogger()
+	defer func() {
+		if err := log.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to close logger: %v\n", err)
+		}
+	}()
+	client := NewClient(cfg, log)
+
+	reachable := false
+	for _, target := range []struct{ name, host string }{
+		{"primary server", cfg.Hosts.Server.Primary},
+		{"fallback server", cfg.Hosts.Server.Fallback},
+		{"ssh tunnel", cfg.Hosts.Server.Tunnel},
+	} {
+		if target.host == "" {
+			continue
+		}
+		health, err := client.fetchReadiness(target.host)
+		switch {
+		case err != nil:
+			report.add(target.name, checkWarn, "%s unreachable: %v", target.host, err)
+		case !health.IsHealthy():
+			report.add(target.name, checkWarn, "%s reports status %q", target.host, health.Status)
+		default:
+			reachable = true
+			report.add(target.name, checkOK, "%s healthy (version %s)", target.host, health.Version)
+		}
+		if err == nil {
+			for _, comp := range health.Components {
+				if !comp.Healthy {
+					report.add(target.name, checkWarn, "%s: %s", comp.Name, comp.Message)
+				}
+			}
+		}
+	}
+	if !reachable {
+		report.add("server", checkFail, "no healthy server found")
+		return report
+	}
+
+	// Editor availability
+	editors, err := client.fetchEditorsWithFallback()
+	if err != nil {
+		report.add("editors", checkFail, "failed to fetch editors: %v", err)
+		return report
+	}
+
+	editorName := cfg.DefaultEditor
+	if editorName == "" {
+		editorName = editors.DefaultEditor
+	}
+	found := false
+	for _, e := range editors.Editors {
+		if e.Name != editorName {
+			continue
+		}
+		found = true
+		if e.Available {
+			report.add("default editor", checkOK, "%s is available on the server", editorName)
+		} else {
+			report.add("default editor", checkWarn, "%s is configured but not available on the server", editorName)
+		}
+	}
+	if !found {
+		report.add("default editor", checkFail, "%s is not configured on the server", editorName)
+	}
+
+	return report
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+func TestRunDoctorChecks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/health":
+			_ = json.NewEncoder(w).Encode(api.HealthResponse{Status: "healthy", Version: "test"})
+		case "/editors":
+			_ = json.NewEncoder(w).Encode(api.EditorsResponse{
+				Editors: []api.EditorInfo{
+					{Name: "cursor", Command: "cursor {path}", Available: true, Default: true},
+				},
+				DefaultEditor: "cursor",
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		defaultEditor string
+		wantFailed    bool
+		wantStatus    checkStatus
+	}{
+		{"configured editor", "cursor", false, checkOK},
+		{"unknown editor", "sublime", true, checkFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "config.yaml")
+			data := fmt.Sprintf(`hosts:
+  server:
+    primary: %q
+network:
+  timeout: 2s
+  retry_attempts: 1
+default_editor: %s
+logging:
+  level: error
+  file: %q
+`, server.URL[7:], tt.defaultEditor, filepath.Join(dir, "client.log"))
+			if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+
+			t.Setenv("SSH_CONNECTION", "")
+			t.Setenv("SSH_CLIENT", "")
+			t.Setenv("SSH_TTY", "")
+			t.Setenv("RCODE_EDITOR", "")
+			t.Setenv("XDG_CACHE_HOME", dir) // keep the host cache out of the real cache dir
+			originalConfig := configFile
+			configFile = path
+			defer func() { configFile = originalConfig }()
+
+			report := runDoctorChecks()
+
+			if got := report.failed(); got != tt.wantFailed {
+				t.Errorf("failed() = %v, want %v (%+v)", got, tt.wantFailed, report.Checks)
+			}
+
+			if report.Hosts == nil || report.Hosts.Resolved.ServerSource != "config" {
+				t.Errorf("Hosts = %+v, want the host resolution with config as server source", report.Hosts)
+			}
+
+			last := report.Checks[len(report.Checks)-1]
+			if last.Name != "default editor" || last.Status != tt.wantStatus {
+				t.Errorf("last check = %+v, want default editor %s", last, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRunDoctorChecks_Deprecations(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	data := fmt.Sprintf(`network:
+  primary_host: 127.0.0.1:1
+  retry_attempts: 1
+hide_deprecations: true
+logging:
+  level: error
+  file: %q
+`, filepath.Join(dir, "client.log"))
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	t.Setenv("SSH_CONNECTION", "")
+	t.Setenv("SSH_CLIENT", "")
+	t.Setenv("SSH_TTY", "")
+	t.Setenv("RCODE_HOST", "")
+	t.Setenv("XDG_CACHE_HOME", dir)
+	originalConfig := configFile
+	configFile = path
+	defer func() { configFile = originalConfig }()
+
+	report := runDoctorChecks()
+
+	var deprecations []string
+	for _, check := range report.Checks {
+		if check.Name == "deprecation" {
+			if check.Status != checkWarn {
+				t.Errorf("deprecation check = %+v, want WARN", check)
+			}
+			deprecations = append(deprecations, check.Message)
+		}
+	}
+	// The legacy key and the hint to migrate it, even with hide_deprecations
+	if len(deprecations) != 2 {
+		t.Errorf("deprecation checks = %q, want 2", deprecations)
+	}
+}
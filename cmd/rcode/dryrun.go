@@ -0,0 +1,125 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/foxytanuki/rcode/internal/network"
+)
+
+// dryRunReport is everything "rcode --dry-run" prints
+type dryRunReport struct {
+	Sources        []network.SourceResult `json:"sources" yaml:"sources"`
+	Resolved       network.ResolvedHosts  `json:"resolved" yaml:"resolved"`
+	Server         string                 `json:"server" yaml:"server"`
+	ServerFallback string                 `json:"server_fallback,omitempty" yaml:"server_fallback,omitempty"`
+	User           string                 `json:"user" yaml:"user"`
+	Editor         string                 `json:"editor" yaml:"editor"`
+	Paths          []string               `json:"paths" yaml:"paths"`
+	Command        string                 `json:"command" yaml:"command"`
+	WorkDir        string                 `json:"workdir,omitempty" yaml:"workdir,omitempty"`
+	CommandSource  string                 `json:"command_source" yaml:"command_source"`
+}
+
+// dryRun resolves hosts and renders the editor command without opening anything
+func (oc *openContext) dryRun(absPaths []string, pos FilePosition, editorName string) error {
+	if editorName == "" {
+		editorName = oc.cfg.DefaultEditor
+	}
+
+	report := &dryRunReport{
+		Sources:        oc.resolver.Explain(),
+		Resolved:       oc.resolved,
+		Server:         oc.cfg.Hosts.Server.Primary,
+		ServerFallback: oc.cfg.Hosts.Server.Fallback,
+		User:           oc.sshInfo.User,
+		Editor:         editorName,
+		Paths:          absPaths,
+	}
+
+	if oc.useLocal() {
+		command, err := renderLocalCommand(oc.cfg, absPaths, pos, editorName, &oc.sshInfo)
+		report.Command = command
+		report.CommandSource = "local mode, runs on this machine"
+		report.write(os.Stdout)
+		return err
+	}
+
+	// Prefer the server's own rendering; fall back to local templates
+	rendered, err := oc.client.RenderCommand(absPaths, pos, editorName, &oc.sshInfo)
+	if err == nil {
+		report.Editor = rendered.Editor
+		report.Command = rendered.Command
+		report.WorkDir = rendered.WorkDir
+		report.CommandSource = "rendered by server"
+		if !rendered.Available {
+			report.CommandSource += ", editor not available"
+		}
+	} else {
+		oc.log.Debug("Server render failed, using local template", "error", err)
+
+		report.Command = oc.client.GetManualCommandPaths(absPaths, pos, editorName, &oc.sshInfo)
+		report.CommandSource = "local template"
+	}
+
+	report.write(os.Stdout)
+
+	if report.Command == "" {
+		return errors.New("no template found for editor " + editorName)
+	}
+	return nil
+}
+
+// write prints the report in the selected output format
+func (r *dryRunReport) write(w io.Writer) {
+	if structuredOutput() {
+		if err := writeStructured(w, r); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+		}
+		return
+	}
+	r.print(w)
+}
+
+// print writes the report in a human-readable format
+func (r *dryRunReport) print(w io.Writer) {
+	fmt.Fprintln(w, "Host resolution (highest priority first):")
+	for _, src := range r.Sources {
+		marker := " "
+		if src.Name == r.Resolved.Source {
+			marker = "*"
+		}
+		fmt.Fprintf(w, " %s %-16s server=%-22s ssh=%s\n", marker, src.Name, valueOrDash(src.Server), valueOrDash(src.SSH))
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Server:    %s\n", valueOrDash(r.Server))
+	if r.ServerFallback != "" {
+		fmt.Fprintf(w, "Fallback:  %s\n", r.ServerFallback)
+	}
+	fmt.Fprintf(w, "SSH host:  %s (source: %s)\n", valueOrDash(r.Resolved.SSH), valueOrDash(r.Resolved.Source))
+	fmt.Fprintf(w, "User:      %s\n", r.User)
+	fmt.Fprintf(w, "Editor:    %s\n", valueOrDash(r.Editor))
+	fmt.Fprintf(w, "Paths:     %s\n", strings.Join(r.Paths, " "))
+
+	fmt.Fprintln(w)
+	if r.Command == "" {
+		fmt.Fprintln(w, "Command:   (no template available)")
+		return
+	}
+	fmt.Fprintf(w, "Command (%s):\n  %s\n", r.CommandSource, r.Command)
+	if r.WorkDir != "" {
+		fmt.Fprintf(w, "Workdir:   %s\n", r.WorkDir)
+	}
+}
+
+// valueOrDash returns "-" for empty strings
+func valueOrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/foxytanuki/rcode/internal/network"
+)
+
+func TestDryRunReport_Print(t *testing.T) {
+	report := &dryRunReport{
+		Sources: []network.SourceResult{
+			{Name: "config", Priority: network.PriorityConfig, Server: "192.168.1.100", SSH: "dev-server"},
+			{Name: "hostname", Priority: network.PriorityHostname, SSH: "localhost"},
+		},
+		Resolved:      network.ResolvedHosts{Server: "192.168.1.100", SSH: "dev-server", Source: "config"},
+		Server:        "192.168.1.100",
+		User:          "alice",
+		Editor:        "cursor",
+		Paths:         []string{"/home/alice/project"},
+		Command:       "cursor --remote ssh-remote+alice@dev-server /home/alice/project",
+		CommandSource: "local template",
+	}
+
+	var buf bytes.Buffer
+	report.print(&buf)
+	out := buf.String()
+
+	for _, want := range []string{
+		"* config",
+		"  hostname",
+		"SSH host:  dev-server (source: config)",
+		"Editor:    cursor",
+		"Command (local template):\n  cursor --remote ssh-remote+alice@dev-server /home/alice/project",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("print() output missing %q\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/foxytanuki/rcode/pkg/api"
+	"github.com/spf13/cobra"
+)
+
+// Flags for "rcode editors add"
+var (
+	editorsAddBrowser bool
+	editorsAddDefault bool
+)
+
+var editorsAddCmd = &cobra.Command{
+	Use:   "add NAME TEMPLATE",
+	Short: "Add an editor to the server",
+	Long: `Add an editor to rcode-server's editor list. TEMPLATE is the command to run,
+with {user}, {host}, {path}, {line} and {column} placeholders; with --browser
+it is a URL to open instead.
+
+The server must have server.auth_token set, and this client must send the same
+token (hosts.server.auth_token or RCODE_AUTH_TOKEN).`,
+	Example: `  rcode editors add zed "zed ssh://{user}@{host}/{path}"
+  rcode editors add vscode-web --browser "https://vscode.dev/+ms-vscode.remote-server/{host}{path}"`,
+	Args: cobra.ExactArgs(2),
+	RunE: runEditorsAdd,
+}
+
+var editorsRemoveCmd = &cobra.Command{
+	Use:     "remove NAME",
+	Aliases: []string{"rm"},
+	Short:   "Remove an editor from the server",
+	Args:    cobra.ExactArgs(1),
+	RunE:    runEditorsRemove,
+}
+
+var editorsSetDefaultCmd = &cobra.Command{
+	Use:   "set-default NAME",
+	Short: "Make an editor the server's default",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runEditorsSetDefault,
+}
+
+var editorsTestCmd = &cobra.Command{
+	Use:   "test NAME [PATH[:LINE[:COLUMN]]]",
+	Short: "Show what an editor would run, and what would keep it from working",
+	Long: `Ask the server to render the command NAME would run to open PATH (default:
+the current directory) from this machine, without opening anything, and to
+report the problems it finds: an editor that is not installed on the host or
+whose command is not on PATH (rcode-server install-shims links macOS editor
+commands), a path outside the server's allowed_paths, a template that opens
+paths on the host rather than over SSH, or one that ignores line numbers.
+
+The command exits with status 1 when problems are found.`,
+	Example: `  rcode editors test nvim
+  rcode editors test cursor ~/src/api/main.go:42`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runEditorsTest,
+}
+
+func runEditorsTest(cmd *cobra.Command, args []string) error {
+	oc, err := newOpenContext()
+	if err != nil {
+		return err
+	}
+	defer oc.close()
+
+	arg := "."
+	if len(args) == 2 {
+		arg = args[1]
+	}
+	arg, pos := parsePathPosition(arg)
+	path, err := absPath(oc.cfg, arg)
+	if err != nil {
+		return err
+	}
+
+	preview, err := oc.client.PreviewEditor(args[0], path, pos, &oc.sshInfo)
+	if err != nil {
+		return fmt.Errorf("failed to preview editor: %w", err)
+	}
+	if structuredOutput() {
+		if err := writeStructured(os.Stdout, preview); err != nil {
+			return err
+		}
+	} else {
+		printEditorPreview(os.Stdout, preview)
+	}
+
+	if len(preview.Issues) > 0 {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("editor %s has %d problems", preview.Editor, len(preview.Issues))
+	}
+	return nil
+}
+
+// printEditorPreview writes the rendered command and the problems found
+func printEditorPreview(w io.Writer, preview *api.EditorPreviewResponse) {
+	availability := "installed"
+	if !preview.Available {
+		availability = "not installed"
+	}
+	fmt.Fprintf(w, "Editor:    %s (%s, %s)\n", preview.Editor, preview.Type, availability)
+	fmt.Fprintf(w, "Command:   %s\n", valueOrDash(preview.Command))
+	if preview.WorkDir != "" {
+		fmt.Fprintf(w, "Workdir:   %s\n", preview.WorkDir)
+	}
+
+	fmt.Fprintln(w)
+	if len(preview.Issues) == 0 {
+		fmt.Fprintln(w, "No problems found.")
+		return
+	}
+	fmt.Fprintln(w, "Problems:")
+	for _, issue := range preview.Issues {
+		fmt.Fprintf(w, "  - %s\n", issue)
+	}
+}
+
+func runEditorsAdd(_ *cobra.Command, args []string) error {
+	req := api.EditorRequest{
+		Name:    args[0],
+		Command: args[1],
+		Default: editorsAddDefault,
+	}
+	if editorsAddBrowser {
+		req.Type = "browser"
+		req.Command = ""
+		req.URL = args[1]
+	}
+
+	return withAdminClient(func(client *Client) error {
+		editors, err := client.AddEditor(req)
+		if err != nil {
+			return fmt.Errorf("failed to add editor: %w", err)
+		}
+		fmt.Printf("Added editor %q.\n", req.Name)
+		printDefaultEditor(os.Stdout, editors)
+		return nil
+	})
+}
+
+func runEditorsRemove(_ *cobra.Command, args []string) error {
+	return withAdminClient(func(client *Client) error {
+		editors, err := client.RemoveEditor(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to remove editor: %w", err)
+		}
+		fmt.Printf("Removed editor %q.\n", args[0])
+		printDefaultEditor(os.Stdout, editors)
+		return nil
+	})
+}
+
+func runEditorsSetDefault(_ *cobra.Command, args []string) error {
+	return withAdminClient(func(client *Client) error {
+		editors, err := client.UpdateEditor(args[0], api.EditorRequest{Default: true})
+		if err != nil {
+			return fmt.Errorf("failed to set default editor: %w", err)
+		}
+		printDefaultEditor(os.Stdout, editors)
+
+		if local := client.config.DefaultEditor; local != "" && local != editors.DefaultEditor {
+			fmt.Printf("Note: this client's default_editor (%s) still takes precedence.\n", local)
+		}
+		return nil
+	})
+}
+
+// withAdminClient runs fn with a client built from the current configuration
+func withAdminClient(fn func(*Client) error) error {
+	cfg, err := loadClientConfig()
+	if err != nil {
+		return err
+	}
+
+	log := newQuietLogger()
+	defer func() {
+		if err := log.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to close logger: %v\n", err)
+		}
+	}()
+
+	return fn(NewClient(cfg, log))
+}
+
+// printDefaultEditor reports the server's default editor after a change
+func printDefaultEditor(w io.Writer, editors *api.EditorsResponse) {
+	fmt.Fprintf(w, "Server default editor: %s (%d editors configured)\n", valueOrDash(editors.DefaultEditor), len(editors.Editors))
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/internal/network"
+)
+
+// hostExplanation is what "rcode --explain-hosts" prints: the value every
+// host source offers and which of them were used
+type hostExplanation struct {
+	Sources  []network.SourceResult `json:"sources" yaml:"sources"`
+	Resolved network.ResolvedHosts  `json:"resolved" yaml:"resolved"`
+}
+
+// explainHosts runs host resolution for cfg and records every source's answer
+func explainHosts(cfg *config.ClientConfig, clientIP string) *hostExplanation {
+	resolver := network.NewResolverFromConfig(cfg, host, clientIP)
+	return &hostExplanation{
+		Sources:  resolver.Explain(),
+		Resolved: resolver.Resolve(),
+	}
+}
+
+// runExplainHosts prints how the server and SSH hosts are chosen
+func runExplainHosts() error {
+	cfg, err := loadClientConfig()
+	if err != nil {
+		return err
+	}
+	sshInfo, _ := ExtractSSHInfo()
+	explanation := explainHosts(cfg, sshInfo.ClientIP)
+
+	if structuredOutput() {
+		return writeStructured(os.Stdout, explanation)
+	}
+	explanation.print(os.Stdout)
+	return nil
+}
+
+// print writes every source in priority order with the values that won
+func (e *hostExplanation) print(w io.Writer) {
+	fmt.Fprintln(w, "Host resolution (highest priority first, * = used):")
+	fmt.Fprintf(w, "  %-16s %8s  %-30s %s\n", "SOURCE", "PRIORITY", "SERVER", "SSH")
+	for _, src := range e.Sources {
+		server := valueOrDash(src.Server)
+		switch src.Name {
+		case e.Resolved.ServerSource:
+			server += " *"
+		case e.Resolved.ServerFallbackSource:
+			server += " (fallback)"
+		}
+		ssh := valueOrDash(src.SSH)
+		if src.Name == e.Resolved.Source {
+			ssh += " *"
+		}
+		fmt.Fprintf(w, "  %-16s %8d  %-30s %s\n", src.Name, src.Priority, server, ssh)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Server:    %s (source: %s)\n", valueOrDash(e.Resolved.Server), valueOrDash(e.Resolved.ServerSource))
+	if e.Resolved.ServerFallback != "" {
+		fmt.Fprintf(w, "Fallback:  %s (source: %s)\n", e.Resolved.ServerFallback, e.Resolved.ServerFallbackSource)
+	}
+	fmt.Fprintf(w, "SSH host:  %s (source: %s)\n", valueOrDash(e.Resolved.SSH), valueOrDash(e.Resolved.Source))
+}
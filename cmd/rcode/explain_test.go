@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/internal/network"
+)
+
+func TestExplainHosts(t *testing.T) {
+	t.Setenv("RCODE_SERVER_HOST", "")
+	t.Setenv("RCODE_SSH_HOST", "")
+	t.Setenv("RCODE_HOST", "")
+	originalHost := host
+	host = ""
+	defer func() { host = originalHost }()
+
+	cfg := &config.ClientConfig{
+		Hosts: config.HostsConfig{
+			Server: config.ServerHostConfig{Primary: "192.168.1.100", Fallback: "100.64.0.1"},
+		},
+	}
+	explanation := explainHosts(cfg, "192.168.1.50")
+
+	want := network.ResolvedHosts{
+		Server:               "192.168.1.100",
+		ServerFallback:       "100.64.0.1",
+		SSH:                  "192.168.1.50",
+		Source:               "ssh-connection",
+		ServerSource:         "config",
+		ServerFallbackSource: "config-fallback",
+	}
+	if explanation.Resolved != want {
+		t.Errorf("Resolved = %+v, want %+v", explanation.Resolved, want)
+	}
+
+	var buf bytes.Buffer
+	explanation.print(&buf)
+	out := buf.String()
+	for _, line := range []string{
+		"192.168.1.100 *",
+		"100.64.0.1 (fallback)",
+		"192.168.1.50 *",
+		"Server:    192.168.1.100 (source: config)",
+		"Fallback:  100.64.0.1 (source: config-fallback)",
+		"SSH host:  192.168.1.50 (source: ssh-connection)",
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("print() output missing %q\n%s", line, out)
+		}
+	}
+
+	// Every source is listed, in priority order
+	names := make([]string, 0, len(explanation.Sources))
+	for _, src := range explanation.Sources {
+		names = append(names, src.Name)
+	}
+	if got := strings.Join(names, ","); got != "environment,config,config-fallback,ssh-connection,hostname" {
+		t.Errorf("sources = %s", got)
+	}
+}
+
+func TestResolveHosts(t *testing.T) {
+	t.Setenv("RCODE_SERVER_HOST", "")
+	t.Setenv("RCODE_SSH_HOST", "env-ssh")
+	t.Setenv("RCODE_HOST", "")
+	originalHost := host
+	host = "cli-server"
+	defer func() { host = originalHost }()
+
+	cfg := &config.ClientConfig{
+		Hosts: config.HostsConfig{
+			Server: config.ServerHostConfig{Primary: "192.168.1.100"},
+			SSH:    config.SSHHostConfig{Host: "config-ssh"},
+		},
+	}
+	sshInfo := SSHInfo{User: "alice", ClientIP: "192.168.1.50"}
+
+	_, resolved := resolveHosts(cfg, &sshInfo)
+
+	// --host names both hosts and outranks the environment and config
+	if sshInfo.Host != "cli-server" || resolved.Source != "command-line" {
+		t.Errorf("ssh host = %q (source %s), want cli-server from command-line", sshInfo.Host, resolved.Source)
+	}
+	if cfg.Hosts.Server.Primary != "cli-server" || cfg.Hosts.Server.Fallback != "192.168.1.100" {
+		t.Errorf("server = %q, fallback = %q, want cli-server, 192.168.1.100",
+			cfg.Hosts.Server.Primary, cfg.Hosts.Server.Fallback)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/foxytanuki/rcode/pkg/api"
+	"github.com/spf13/cobra"
+)
+
+// listenCheckTimeout bounds the check for a server on the forwarded port
+const listenCheckTimeout = 500 * time.Millisecond
+
+var forwardCmd = &cobra.Command{
+	Use:   "forward PORT [path]",
+	Short: "Open a web IDE running on this machine in the host browser",
+	Long: `Open a web app listening on PORT of this machine, such as code-server or
+Jupyter, in the browser of the host machine.
+
+The host browser reaches the port through an SSH local forward; rcode prints
+the ssh -L command that sets one up. Without --editor the server opens
+http://localhost:PORT/. With --editor it opens the path (default: the current
+directory) in a browser editor whose URL uses {port}, such as
+"http://localhost:{port}/?folder={path|urlencode}".
+
+Either way the URL must match server.open_url.allowed on the host, for
+example "http://localhost:*".`,
+	Example: `  rcode forward 8888
+  rcode forward 8080 ~/project -e code-server`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runForward,
+}
+
+func runForward(_ *cobra.Command, args []string) error {
+	port, err := strconv.Atoi(args[0])
+	if err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("invalid port %q: must be a number between 1 and 65535", args[0])
+	}
+	if len(args) == 2 && editor == "" {
+		return errors.New("opening a path needs --editor, a browser editor whose URL uses {port}")
+	}
+
+	oc, err := newOpenContext()
+	if err != nil {
+		return err
+	}
+	defer oc.close()
+
+	if !listening(port) {
+		fmt.Fprintf(os.Stderr, "Warning: nothing is listening on localhost:%d on this machine yet\n", port)
+	}
+	fmt.Fprint(os.Stderr, forwardHint(port, &oc.sshInfo))
+
+	var opened any
+	var target string
+	if editor == "" {
+		target = fmt.Sprintf("http://localhost:%d/", port)
+		oc.log.Info("Opening forwarded port", "url", target, "server", oc.cfg.Hosts.Server.Primary)
+		opened, err = oc.client.OpenURL(target)
+	} else {
+		dir := "."
+		if len(args) == 2 {
+			dir = args[1]
+		}
+		if target, err = filepath.Abs(dir); err != nil {
+			return fmt.Errorf("failed to resolve path: %w", err)
+		}
+		oc.log.Info("Opening path in web IDE", "path", target, "port", port, "editor", editor, "server", oc.cfg.Hosts.Server.Primary)
+		opened, err = oc.client.OpenWeb(target, port, editor, &oc.sshInfo)
+	}
+	if err != nil {
+		var errResp *api.ErrorResponse
+		switch {
+		case isUnreachable(err):
+			fmt.Fprintf(os.Stderr, "%s", tunnelHint(oc.cfg, &oc.sshInfo))
+		case errors.As(err, &errResp) && errResp.Code == api.CodeURLNotAllowed:
+			fmt.Fprintln(os.Stderr, `Allow it on the host with server.open_url.allowed: ["http://localhost:*"]`)
+		}
+		return fmt.Errorf("failed to open %s: %w", target, err)
+	}
+	if structuredOutput() {
+		return writeStructured(os.Stdout, opened)
+	}
+	fmt.Printf("Opened %s in the host browser\n", target)
+	return nil
+}
+
+// listening reports whether a server accepts connections on localhost:port
+func listening(port int) bool {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort("localhost", strconv.Itoa(port)), listenCheckTimeout)
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
+
+// forwardHint explains how to forward port from the host to this machine
+// over SSH
+func forwardHint(port int, sshInfo *SSHInfo) string {
+	dest := "<this-machine>"
+	if sshInfo != nil && sshInfo.Host != "" {
+		dest = sshInfo.Host
+		if sshInfo.User != "" {
+			dest = sshInfo.User + "@" + dest
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("The host browser reaches the port through an SSH forward. If it is not set up, run on the host:\n")
+	fmt.Fprintf(&b, "  ssh -N -L %d:localhost:%d %s\n", port, port, dest)
+	return b.String()
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestForwardHint(t *testing.T) {
+	hint := forwardHint(8080, &SSHInfo{User: "alice", Host: "devbox"})
+	if want := "ssh -N -L 8080:localhost:8080 alice@devbox"; !strings.Contains(hint, want) {
+		t.Errorf("forwardHint() = %q, want it to contain %q", hint, want)
+	}
+
+	hint = forwardHint(8888, nil)
+	if want := "ssh -N -L 8888:localhost:8888 <this-machine>"; !strings.Contains(hint, want) {
+		t.Errorf("forwardHint() without SSH info = %q, want it to contain %q", hint, want)
+	}
+}
+
+func TestListening(t *testing.T) {
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	if !listening(port) {
+		t.Errorf("listening(%d) = false with a listener", port)
+	}
+
+	_ = ln.Close()
+	if listening(port) {
+		t.Errorf("listening(%d) = true after the listener closed", port)
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/foxytanuki/rcode/internal/prompt"
+	"github.com/foxytanuki/rcode/internal/session"
+	"github.com/foxytanuki/rcode/pkg/api"
+	"github.com/spf13/cobra"
+)
+
+// historyLimit is the number of entries listed by "rcode history"
+var historyLimit int
+
+// historySelect asks which listed entry to open again
+var historySelect bool
+
+var historyCmd = &cobra.Command{
+	Use:   "history [query]",
+	Short: "List or re-open paths opened from this machine",
+	Long: `List the paths opened from this machine, most recent first. The history is
+kept in history_file (default: ~/.local/share/rcode/history.json).
+
+A query keeps the entries whose path holds its characters in order, such as
+"apisrv" for ~/src/api/server.go, best matches first. With --select, pick an
+entry to open again.`,
+	Example: `  rcode history
+  rcode history api --select`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runHistory,
+}
+
+var lastCmd = &cobra.Command{
+	Use:   "last [query]",
+	Short: "Re-open the last path opened from this machine",
+	Long: `Open the most recently opened path again, in the editor it was opened in
+unless --editor is given. A query opens the best match from the history
+instead, as listed by "rcode history".`,
+	Example: `  rcode last
+  rcode last api`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runLast,
+}
+
+func runHistory(_ *cobra.Command, args []string) error {
+	oc, err := newOpenContext()
+	if err != nil {
+		return err
+	}
+	defer oc.close()
+
+	entries, err := oc.historyMatches(args)
+	if err != nil {
+		return err
+	}
+	if historyLimit > 0 && len(entries) > historyLimit {
+		entries = entries[:historyLimit]
+	}
+
+	if structuredOutput() {
+		return writeStructured(os.Stdout, historyInfo(entries))
+	}
+	printHistory(entries)
+	if !historySelect || len(entries) == 0 {
+		return nil
+	}
+
+	answer, err := prompt.New(os.Stdin, os.Stderr).Ask("Open which entry?", "1", func(answer string) error {
+		n, err := strconv.Atoi(answer)
+		if err != nil || n < 1 || n > len(entries) {
+			return fmt.Errorf("enter a number from 1 to %d", len(entries))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	n, _ := strconv.Atoi(answer)
+	return oc.reopen(entries[n-1])
+}
+
+func runLast(_ *cobra.Command, args []string) error {
+	oc, err := newOpenContext()
+	if err != nil {
+		return err
+	}
+	defer oc.close()
+
+	entries, err := oc.historyMatches(args)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		if len(args) == 1 {
+			return fmt.Errorf("nothing in the history matches %q", args[0])
+		}
+		return errors.New("nothing has been opened from this machine yet")
+	}
+	return oc.reopen(entries[0])
+}
+
+// historyStore opens the history file
+func (oc *openContext) historyStore() (*session.Store, error) {
+	store, err := session.NewStore(oc.cfg.HistoryFile, oc.cfg.MaxHistory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return store, nil
+}
+
+// historyMatches returns the history entries matching the optional query in
+// args, best matches first
+func (oc *openContext) historyMatches(args []string) ([]session.Entry, error) {
+	store, err := oc.historyStore()
+	if err != nil {
+		return nil, err
+	}
+	entries := store.List(session.Filter{})
+	if len(args) == 0 {
+		return entries, nil
+	}
+	return matchHistory(entries, args[0]), nil
+}
+
+// recordHistory adds an open of paths in editorName to the history. A
+// history that cannot be written does not fail the open.
+func (oc *openContext) recordHistory(paths []string, editorName string) {
+	store, err := oc.historyStore()
+	if err != nil {
+		oc.log.Debug("Failed to record history", "file", oc.cfg.HistoryFile, "error", err)
+		return
+	}
+	entry := session.Entry{
+		Path:   paths[0],
+		Editor: editorName,
+		User:   oc.sshInfo.User,
+		Host:   oc.sshInfo.Host,
+	}
+	if len(paths) > 1 {
+		entry.Paths = paths
+	}
+	if err := store.Record(entry); err != nil {
+		oc.log.Debug("Failed to record history", "file", oc.cfg.HistoryFile, "error", err)
+	}
+}
+
+// reopen opens a history entry again, in --editor when given
+func (oc *openContext) reopen(entry session.Entry) error {
+	paths := entry.Paths
+	if len(paths) == 0 {
+		paths = []string{entry.Path}
+	}
+	editorName := editor
+	if editorName == "" {
+		editorName = entry.Editor
+	}
+	if oc.useLocal() {
+		return oc.openLocal(paths, FilePosition{}, editorName)
+	}
+	return oc.open(paths, FilePosition{}, editorName)
+}
+
+// matchHistory returns the entries whose paths match query, best matches
+// first and the most recent first among equal matches
+func matchHistory(entries []session.Entry, query string) []session.Entry {
+	type match struct {
+		entry session.Entry
+		score int
+	}
+	var matches []match
+	for _, e := range entries {
+		target := e.Path
+		if len(e.Paths) > 0 {
+			target = strings.Join(e.Paths, " ")
+		}
+		if score, ok := fuzzyScore(query, target); ok {
+			matches = append(matches, match{e, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+
+	result := make([]session.Entry, len(matches))
+	for i, m := range matches {
+		result[i] = m.entry
+	}
+	return result
+}
+
+// fuzzyScore reports whether every character of query appears in target in
+// order, ignoring case, and scores the match. Runs of consecutive characters
+// and characters in the last path element score higher. Characters are
+// matched from the end, so matches in the file name are preferred.
+func fuzzyScore(query, target string) (int, bool) {
+	q := []rune(strings.ToLower(query))
+	t := []rune(strings.ToLower(target))
+	base := utf8.RuneCountInString(target[:strings.LastIndex(target, "/")+1])
+
+	score, qi, next := 0, len(q)-1, -1
+	for i := len(t) - 1; i >= 0 && qi >= 0; i-- {
+		if t[i] != q[qi] {
+			continue
+		}
+		score++
+		if i+1 == next {
+			score += 2
+		}
+		if i >= base {
+			score++
+		}
+		next = i
+		qi--
+	}
+	return score, qi < 0
+}
+
+// historyInfo converts history entries for structured output
+func historyInfo(entries []session.Entry) []api.SessionInfo {
+	infos := make([]api.SessionInfo, len(entries))
+	for i, e := range entries {
+		infos[i] = api.SessionInfo{
+			Path:     e.Path,
+			Paths:    e.Paths,
+			Editor:   e.Editor,
+			User:     e.User,
+			Host:     e.Host,
+			OpenedAt: e.OpenedAt,
+		}
+	}
+	return infos
+}
+
+// printHistory prints a numbered list of history entries
+func printHistory(entries []session.Entry) {
+	if len(entries) == 0 {
+		fmt.Println("No history.")
+		return
+	}
+
+	fmt.Println("History:")
+	fmt.Println("========")
+	for i, e := range entries {
+		path := e.Path
+		if len(e.Paths) > 0 {
+			path = strings.Join(e.Paths, " ")
+		}
+		fmt.Printf("  %2d. %s\n", i+1, path)
+		fmt.Printf("      %s on %s, %s\n", valueOrDash(e.Editor), valueOrDash(e.Host), e.OpenedAt.Local().Format("2006-01-02 15:04"))
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/internal/session"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		query, target string
+		want          bool
+	}{
+		{"", "/src/api/server.go", true},
+		{"apisrv", "/src/api/server.go", true},
+		{"SERVER", "/src/api/server.go", true},
+		{"srvapi", "/src/api/server.go", false},
+		{"client", "/src/api/server.go", false},
+	}
+	for _, tt := range tests {
+		if _, got := fuzzyScore(tt.query, tt.target); got != tt.want {
+			t.Errorf("fuzzyScore(%q, %q) matched = %v, want %v", tt.query, tt.target, got, tt.want)
+		}
+	}
+
+	// Consecutive characters in the file name beat scattered ones
+	name, _ := fuzzyScore("main", "/src/cmd/main.go")
+	scattered, _ := fuzzyScore("main", "/src/mobile/android/init.go")
+	if name <= scattered {
+		t.Errorf("fuzzyScore() = %d for the file name, want more than %d for scattered characters", name, scattered)
+	}
+}
+
+func TestMatchHistory(t *testing.T) {
+	entries := []session.Entry{
+		{Path: "/src/web/main.ts"},
+		{Path: "/src/docs"},
+		{Path: "/src/cmd/main.go"},
+	}
+
+	got := matchHistory(entries, "main")
+	if len(got) != 2 {
+		t.Fatalf("matchHistory() = %v, want the two main files", got)
+	}
+	// Equal matches keep the most recent first
+	if got[0].Path != "/src/web/main.ts" || got[1].Path != "/src/cmd/main.go" {
+		t.Errorf("matchHistory() order = %q, %q", got[0].Path, got[1].Path)
+	}
+
+	if got := matchHistory(entries, "maingo"); len(got) != 1 || got[0].Path != "/src/cmd/main.go" {
+		t.Errorf("matchHistory(maingo) = %v, want /src/cmd/main.go", got)
+	}
+}
+
+func TestRecordHistory(t *testing.T) {
+	oc := &openContext{
+		cfg:     &config.ClientConfig{HistoryFile: filepath.Join(t.TempDir(), "history.json")},
+		log:     createTestLogger(),
+		sshInfo: SSHInfo{User: "dev", Host: "box"},
+	}
+
+	oc.recordHistory([]string{"/src/a"}, "cursor")
+	oc.recordHistory([]string{"/src/b", "/src/c"}, "zed")
+	oc.recordHistory([]string{"/src/a"}, "vscode")
+
+	entries, err := oc.historyMatches(nil)
+	if err != nil {
+		t.Fatalf("historyMatches() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("history has %d entries, want 2: %v", len(entries), entries)
+	}
+	if e := entries[0]; e.Path != "/src/a" || e.Editor != "vscode" || e.Host != "box" || e.User != "dev" {
+		t.Errorf("latest entry = %+v, want /src/a in vscode from dev@box", e)
+	}
+	if e := entries[1]; len(e.Paths) != 2 || e.Editor != "zed" {
+		t.Errorf("second entry = %+v, want both paths in zed", e)
+	}
+
+	if entries, _ := oc.historyMatches([]string{"src/c"}); len(entries) != 1 || entries[0].Editor != "zed" {
+		t.Errorf("historyMatches(src/c) = %v, want the zed entry", entries)
+	}
+}
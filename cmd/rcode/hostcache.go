@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+// hostCache remembers the last host that answered and the editor list it
+// returned, so later invocations skip dead hosts and the /editors round trip
+type hostCache struct {
+	Host      string               `json:"host,omitempty"`
+	CheckedAt time.Time            `json:"checked_at,omitempty"`
+	Editors   *api.EditorsResponse `json:"editors,omitempty"`
+	EditorsAt time.Time            `json:"editors_at,omitempty"`
+}
+
+// hostCachePath returns the host cache file
+func hostCachePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "rcode", "hosts.json")
+}
+
+// readHostCache reads the cache, returning an empty cache when it is missing or invalid
+func readHostCache(path string) *hostCache {
+	cache := &hostCache{}
+	if path == "" {
+		return cache
+	}
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(data, cache); err != nil {
+		return &hostCache{}
+	}
+	return cache
+}
+
+// writeHostCache saves the cache; failures only cost a slower next run
+func writeHostCache(path string, cache *hostCache) error {
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// loadedCache returns the host cache, reading it on first use. Without a
+// cache file it only holds what this run has learned.
+func (c *Client) loadedCache() *hostCache {
+	c.cacheOnce.Do(func() {
+		c.cache = readHostCache(c.cachePath)
+	})
+	return c.cache
+}
+
+// cacheFresh reports whether an entry recorded at t may still be used
+func (c *Client) cacheFresh(t time.Time) bool {
+	if c.cachePath == "" {
+		// In-memory entries come from this run
+		return !t.IsZero()
+	}
+	return time.Since(t) < c.config.Network.CacheTTL
+}
+
+// saveCache persists the host cache when a cache file is configured
+func (c *Client) saveCache() {
+	if c.cachePath == "" {
+		return
+	}
+	if err := writeHostCache(c.cachePath, c.cache); err != nil {
+		c.log.Debug("Failed to write host cache", "file", c.cachePath, "error", err)
+	}
+}
+
+// knownGoodHost returns the cached last good host while it is fresh and
+// still one of targets
+func (c *Client) knownGoodHost(targets []hostTarget) string {
+	cache := c.loadedCache()
+	if cache.Host == "" || !c.cacheFresh(cache.CheckedAt) || !hasHost(targets, cache.Host) {
+		return ""
+	}
+	return cache.Host
+}
+
+// rememberHost records host as the last one that answered
+func (c *Client) rememberHost(host string) {
+	cache := c.loadedCache()
+	if cache.Host == host && c.cacheFresh(cache.CheckedAt) {
+		return
+	}
+	if cache.Host != host {
+		// The editor list may differ between servers
+		cache.Editors = nil
+		cache.EditorsAt = time.Time{}
+	}
+	cache.Host = host
+	cache.CheckedAt = time.Now()
+	c.saveCache()
+}
+
+// rememberEditors records the editor list host returned
+func (c *Client) rememberEditors(host string, editors *api.EditorsResponse) {
+	cache := c.loadedCache()
+	cache.Host = host
+	cache.CheckedAt = time.Now()
+	cache.Editors = editors
+	cache.EditorsAt = cache.CheckedAt
+	c.saveCache()
+}
+
+// cachedEditors returns the editor list from the cache while it is fresh,
+// fetching it otherwise. A stale list is still used when no host answers.
+func (c *Client) cachedEditors() (*api.EditorsResponse, error) {
+	cache := c.loadedCache()
+	usable := cache.Editors != nil && hasHost(c.hostTargets(), cache.Host)
+	if usable && c.cacheFresh(cache.EditorsAt) {
+		return cache.Editors, nil
+	}
+
+	editors, err := c.fetchEditorsWithFallback()
+	if err != nil && usable {
+		c.log.Debug("Using stale editor list", "host", cache.Host, "error", err)
+		return cache.Editors, nil
+	}
+	return editors, err
+}
+
+// hasHost reports whether host is one of targets
+func hasHost(targets []hostTarget, host string) bool {
+	for _, t := range targets {
+		if t.host == host {
+			return true
+		}
+	}
+	return false
+}
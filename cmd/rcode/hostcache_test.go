@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+func newCachingClient(t *testing.T, cachePath string, hosts config.ServerHostConfig) *Client {
+	t.Helper()
+	cfg := &config.ClientConfig{
+		Hosts: config.HostsConfig{Server: hosts},
+		Network: config.ClientNetworkConfig{
+			Timeout:       2 * time.Second,
+			RetryAttempts: 1,
+			CacheTTL:      time.Minute,
+		},
+		DefaultEditor: "cursor",
+	}
+	client := NewClient(cfg, createTestLogger())
+	client.cachePath = cachePath
+	return client
+}
+
+func TestHostCache_RemembersLastGoodHost(t *testing.T) {
+	primaryHits := 0
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		primaryHits++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer primary.Close()
+
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(api.OpenResponse{Success: true})
+	}))
+	defer fallback.Close()
+
+	hosts := config.ServerHostConfig{Primary: primary.URL[7:], Fallback: fallback.URL[7:]}
+	cachePath := filepath.Join(t.TempDir(), "hosts.json")
+	sshInfo := SSHInfo{User: "testuser", Host: "testhost"}
+
+	if err := newCachingClient(t, cachePath, hosts).OpenEditor("/p", "", &sshInfo); err != nil {
+		t.Fatalf("first OpenEditor() error = %v", err)
+	}
+	if primaryHits != 1 {
+		t.Fatalf("primary hits = %d after first run, want 1", primaryHits)
+	}
+	if got := readHostCache(cachePath).Host; got != fallback.URL[7:] {
+		t.Errorf("cached host = %q, want %q", got, fallback.URL[7:])
+	}
+
+	// A later run goes straight to the host that answered
+	if err := newCachingClient(t, cachePath, hosts).OpenEditor("/p", "", &sshInfo); err != nil {
+		t.Fatalf("second OpenEditor() error = %v", err)
+	}
+	if primaryHits != 1 {
+		t.Errorf("primary hits = %d after cached run, want 1", primaryHits)
+	}
+
+	// Once the entry expires the configured order applies again
+	cache := readHostCache(cachePath)
+	cache.CheckedAt = time.Now().Add(-time.Hour)
+	if err := writeHostCache(cachePath, cache); err != nil {
+		t.Fatal(err)
+	}
+	if err := newCachingClient(t, cachePath, hosts).OpenEditor("/p", "", &sshInfo); err != nil {
+		t.Fatalf("third OpenEditor() error = %v", err)
+	}
+	if primaryHits != 2 {
+		t.Errorf("primary hits = %d after expiry, want 2", primaryHits)
+	}
+}
+
+func TestHostCache_Editors(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(api.EditorsResponse{
+			Editors: []api.EditorInfo{{Name: "cursor", Command: "cursor {path}", Available: true}},
+		})
+	}))
+
+	hosts := config.ServerHostConfig{Primary: server.URL[7:]}
+	cachePath := filepath.Join(t.TempDir(), "hosts.json")
+
+	client := newCachingClient(t, cachePath, hosts)
+	for i := 0; i < 2; i++ {
+		if tmpl, _ := client.fetchEditorTemplate("cursor"); tmpl != "cursor {path}" {
+			t.Fatalf("fetchEditorTemplate() = %q, want %q", tmpl, "cursor {path}")
+		}
+	}
+	if tmpl, _ := newCachingClient(t, cachePath, hosts).fetchEditorTemplate("cursor"); tmpl != "cursor {path}" {
+		t.Fatalf("fetchEditorTemplate() from cache = %q", tmpl)
+	}
+	if requests != 1 {
+		t.Errorf("/editors requests = %d, want 1", requests)
+	}
+
+	// A stale list still beats nothing when the server is down
+	server.Close()
+	cache := readHostCache(cachePath)
+	cache.EditorsAt = time.Now().Add(-time.Hour)
+	if err := writeHostCache(cachePath, cache); err != nil {
+		t.Fatal(err)
+	}
+	if tmpl, _ := newCachingClient(t, cachePath, hosts).fetchEditorTemplate("cursor"); tmpl != "cursor {path}" {
+		t.Errorf("fetchEditorTemplate() with stale cache = %q, want %q", tmpl, "cursor {path}")
+	}
+
+	// The cache belongs to the configured hosts
+	other := config.ServerHostConfig{Primary: "127.0.0.1:1"}
+	if tmpl, _ := newCachingClient(t, cachePath, other).fetchEditorTemplate("cursor"); tmpl != "" {
+		t.Errorf("fetchEditorTemplate() for other hosts = %q, want empty", tmpl)
+	}
+}
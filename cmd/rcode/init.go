@@ -0,0 +1,133 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/internal/network"
+	"github.com/foxytanuki/rcode/internal/prompt"
+	"github.com/spf13/cobra"
+)
+
+var initForce bool
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Create the client configuration interactively",
+	Long: `Ask for the host machine's addresses, Tailscale usage, the SSH host and the
+preferred editor, then write the client configuration file.
+
+Press Enter to keep the value in brackets, or enter "-" to clear it.`,
+	Args: cobra.NoArgs,
+	RunE: runInit,
+}
+
+func runInit(_ *cobra.Command, _ []string) error {
+	path := configFile
+	if path == "" {
+		path = config.GetDefaultPaths().ClientConfig
+	}
+	if config.IsUnifiedConfigFile(path) {
+		return fmt.Errorf("%s is a unified client/server configuration; edit it directly", path)
+	}
+
+	p := prompt.New(os.Stdin, os.Stdout)
+
+	// Start from the existing file so re-running init edits it
+	cfg := config.GetDefaultClientConfig()
+	cfg.Hosts.Server.Primary = ""
+	cfg.Hosts.Server.Fallback = ""
+	if _, err := os.Stat(path); err == nil {
+		if !initForce {
+			overwrite, err := p.Confirm(fmt.Sprintf("%s already exists. Update it?", path), true)
+			if err != nil {
+				return err
+			}
+			if !overwrite {
+				fmt.Println("Configuration left unchanged")
+				return nil
+			}
+		}
+		if cfg, err = config.LoadClientConfig(path); err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+	}
+
+	if err := runClientWizard(p, cfg); err != nil {
+		return err
+	}
+
+	if err := config.ValidateClientConfig(cfg); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	if err := config.SaveClientConfig(path, cfg); err != nil {
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
+
+	fmt.Printf("\nConfiguration written to %s\n", path)
+	fmt.Println("Run 'rcode doctor' to check the connection to rcode-server.")
+	return nil
+}
+
+// runClientWizard asks the setup questions and stores the answers in cfg.
+// Each answer is checked with ValidateClientConfig before moving on.
+func runClientWizard(p *prompt.Prompter, cfg *config.ClientConfig) error {
+	validate := func(field string, apply func(string)) func(string) error {
+		return func(answer string) error {
+			apply(answer)
+			return config.FieldError(config.ValidateClientConfig(cfg), field)
+		}
+	}
+
+	// The machine we are connected from is usually the one running rcode-server
+	primary := cfg.Hosts.Server.Primary
+	if primary == "" {
+		primary = network.ExtractSSHClientIP()
+	}
+	if _, err := p.Ask("Host machine address (where rcode-server runs)", primary,
+		validate("hosts.server.primary", func(s string) { cfg.Hosts.Server.Primary = s })); err != nil {
+		return err
+	}
+
+	tailscale, err := p.Confirm("Do you reach the host over Tailscale?", cfg.Hosts.SSH.AutoDetect.Tailscale)
+	if err != nil {
+		return err
+	}
+	cfg.Hosts.SSH.AutoDetect.Tailscale = tailscale
+	if tailscale {
+		if _, err := p.Ask("Host's Tailscale address, tried when the first one fails (optional)", cfg.Hosts.Server.Fallback,
+			validate("hosts.server.fallback", func(s string) { cfg.Hosts.Server.Fallback = s })); err != nil {
+			return err
+		}
+	} else {
+		cfg.Hosts.Server.Fallback = ""
+	}
+
+	if _, err := p.Ask("SSH host the editor connects back to (empty = auto-detect)", cfg.Hosts.SSH.Host,
+		validate("hosts.ssh.host", func(s string) { cfg.Hosts.SSH.Host = s })); err != nil {
+		return err
+	}
+
+	editors := make([]string, 0, len(config.GetDefaultFallbackEditors()))
+	for name := range config.GetDefaultFallbackEditors() {
+		editors = append(editors, name)
+	}
+	sort.Strings(editors)
+	p.Printf("Common editors: %s (any editor configured on the server works)\n", strings.Join(editors, ", "))
+
+	if _, err := p.Ask("Preferred editor", cfg.DefaultEditor, func(s string) error {
+		if s == "" {
+			return errors.New("an editor name is required")
+		}
+		cfg.DefaultEditor = s
+		return config.FieldError(config.ValidateClientConfig(cfg), "default_editor")
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
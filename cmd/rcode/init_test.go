@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/internal/prompt"
+)
+
+func TestRunClientWizard(t *testing.T) {
+	t.Setenv("SSH_CONNECTION", "192.168.1.20 52000 192.168.1.30 22")
+
+	cfg := config.GetDefaultClientConfig()
+	cfg.Hosts.Server.Primary = ""
+	cfg.Hosts.Server.Fallback = ""
+
+	// primary (detected), tailscale, fallback, ssh host, editor
+	input := "\ny\n100.100.1.1\ndev-box\nzed\n"
+
+	var out bytes.Buffer
+	if err := runClientWizard(prompt.New(strings.NewReader(input), &out), cfg); err != nil {
+		t.Fatalf("runClientWizard() error = %v\n%s", err, out.String())
+	}
+
+	if cfg.Hosts.Server.Primary != "192.168.1.20" {
+		t.Errorf("Primary = %q, want the SSH client IP", cfg.Hosts.Server.Primary)
+	}
+	if !cfg.Hosts.SSH.AutoDetect.Tailscale || cfg.Hosts.Server.Fallback != "100.100.1.1" {
+		t.Errorf("Tailscale = %v, Fallback = %q", cfg.Hosts.SSH.AutoDetect.Tailscale, cfg.Hosts.Server.Fallback)
+	}
+	if cfg.Hosts.SSH.Host != "dev-box" || cfg.DefaultEditor != "zed" {
+		t.Errorf("SSH host = %q, editor = %q", cfg.Hosts.SSH.Host, cfg.DefaultEditor)
+	}
+}
+
+func TestRunClientWizardRequiresPrimary(t *testing.T) {
+	t.Setenv("SSH_CONNECTION", "")
+	t.Setenv("SSH_CLIENT", "")
+
+	cfg := config.GetDefaultClientConfig()
+	cfg.Hosts.Server.Primary = ""
+
+	// empty primary is rejected, then no tailscale, no ssh host, default editor
+	input := "\n10.0.0.5\nn\n\n\n"
+
+	var out bytes.Buffer
+	if err := runClientWizard(prompt.New(strings.NewReader(input), &out), cfg); err != nil {
+		t.Fatalf("runClientWizard() error = %v\n%s", err, out.String())
+	}
+
+	if !strings.Contains(out.String(), "primary server host cannot be empty") {
+		t.Errorf("output missing validation error\n%s", out.String())
+	}
+	if cfg.Hosts.Server.Primary != "10.0.0.5" || cfg.Hosts.Server.Fallback != "" {
+		t.Errorf("Primary = %q, Fallback = %q", cfg.Hosts.Server.Primary, cfg.Hosts.Server.Fallback)
+	}
+	if cfg.DefaultEditor != "cursor" {
+		t.Errorf("DefaultEditor = %q, want cursor", cfg.DefaultEditor)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	editorpkg "github.com/foxytanuki/rcode/internal/editor"
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+// useLocal reports whether editors are launched on this machine instead of
+// through the server
+func (oc *openContext) useLocal() bool {
+	if localFlag {
+		return true
+	}
+
+	switch oc.cfg.LocalMode {
+	case config.LocalModeAlways:
+		return true
+	case config.LocalModeAuto:
+		return !oc.inSSH
+	default:
+		return false
+	}
+}
+
+// renderLocalCommand renders the local command template for editorName
+func renderLocalCommand(cfg *config.ClientConfig, absPaths []string, pos FilePosition, editorName string, sshInfo *SSHInfo) (string, error) {
+	command := cfg.LocalEditorCommand(editorName)
+	if command == "" {
+		return "", fmt.Errorf("no local command for editor %s (add it to local_editors)", editorName)
+	}
+
+	tmpl, err := editorpkg.NewTemplate(command)
+	if err != nil {
+		return "", fmt.Errorf("invalid local command for editor %s: %w", editorName, err)
+	}
+
+	vars := editorpkg.TemplateVars{
+		User:       sshInfo.User,
+		Host:       sshInfo.Host,
+		Path:       absPaths[0],
+		Line:       pos.Line,
+		Column:     pos.Column,
+		ShellQuote: true,
+	}
+	if len(absPaths) > 1 {
+		vars.Paths = absPaths
+	}
+	return tmpl.Render(vars)
+}
+
+// openLocal launches the editor on this machine without contacting the server
+func (oc *openContext) openLocal(absPaths []string, pos FilePosition, editorName string) error {
+	chosen := editorName
+	if editorName == "" {
+		editorName = oc.cfg.DefaultEditor
+	}
+
+	command, err := renderLocalCommand(oc.cfg, absPaths, pos, editorName, &oc.sshInfo)
+	if err != nil {
+		return fmt.Errorf("failed to open editor locally: %w", err)
+	}
+
+	if createFlag != "" {
+		if err := createLocal(oc.cfg, absPaths, createFlag == api.CreateDir); err != nil {
+			return err
+		}
+	}
+
+	oc.log.Info("Opening editor locally", "editor", editorName, "command", command)
+
+	if err := editorpkg.ExecuteDetached(command, oc.log); err != nil {
+		return fmt.Errorf("failed to open editor locally: %w", err)
+	}
+	oc.recordHistory(absPaths, editorName)
+	oc.recordOpenStats(editorName, "local")
+	oc.rememberEditor(absPaths, chosen)
+
+	fmt.Printf("Successfully opened %s\n", strings.Join(absPaths, " "))
+	return nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/foxytanuki/rcode/internal/config"
+)
+
+func TestUseLocal(t *testing.T) {
+	tests := []struct {
+		name  string
+		mode  config.LocalMode
+		inSSH bool
+		flag  bool
+		want  bool
+	}{
+		{name: "default in ssh", inSSH: true, want: false},
+		{name: "default outside ssh", inSSH: false, want: false},
+		{name: "auto in ssh", mode: config.LocalModeAuto, inSSH: true, want: false},
+		{name: "auto outside ssh", mode: config.LocalModeAuto, inSSH: false, want: true},
+		{name: "always in ssh", mode: config.LocalModeAlways, inSSH: true, want: true},
+		{name: "never outside ssh", mode: config.LocalModeNever, inSSH: false, want: false},
+		{name: "flag overrides never", mode: config.LocalModeNever, inSSH: true, flag: true, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			localFlag = tt.flag
+			defer func() { localFlag = false }()
+
+			oc := &openContext{cfg: &config.ClientConfig{LocalMode: tt.mode}, inSSH: tt.inSSH}
+			if got := oc.useLocal(); got != tt.want {
+				t.Errorf("useLocal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderLocalCommand(t *testing.T) {
+	sshInfo := &SSHInfo{User: "alice", Host: "localhost"}
+
+	tests := []struct {
+		name    string
+		cfg     *config.ClientConfig
+		paths   []string
+		pos     FilePosition
+		editor  string
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "built-in template",
+			cfg:    &config.ClientConfig{},
+			paths:  []string{"/home/alice/my project"},
+			editor: "vscode",
+			want:   "code '/home/alice/my project'",
+		},
+		{
+			name:   "local_editors override",
+			cfg:    &config.ClientConfig{LocalEditors: map[string]string{"cursor": "cursor --goto {path}:{line}"}},
+			paths:  []string{"/src/main.go"},
+			pos:    FilePosition{Line: 12},
+			editor: "cursor",
+			want:   "cursor --goto /src/main.go:12",
+		},
+		{
+			name:   "multiple paths",
+			cfg:    &config.ClientConfig{},
+			paths:  []string{"/src/a", "/src/b"},
+			editor: "zed",
+			want:   "zed /src/a /src/b",
+		},
+		{
+			name:    "unknown editor",
+			cfg:     &config.ClientConfig{},
+			paths:   []string{"/src"},
+			editor:  "sublime",
+			wantErr: "no local command for editor sublime",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderLocalCommand(tt.cfg, tt.paths, tt.pos, tt.editor, sshInfo)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("renderLocalCommand() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("renderLocalCommand() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("renderLocalCommand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
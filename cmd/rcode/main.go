@@ -1,17 +1,25 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
-	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/foxytanuki/rcode/internal/config"
 	"github.com/foxytanuki/rcode/internal/logger"
 	"github.com/foxytanuki/rcode/internal/network"
+	"github.com/foxytanuki/rcode/internal/telemetry"
 	"github.com/foxytanuki/rcode/internal/version"
+	"github.com/foxytanuki/rcode/pkg/api"
 	"github.com/spf13/cobra"
 )
 
+// telemetryShutdownTimeout bounds the telemetry export when a command ends
+const telemetryShutdownTimeout = 2 * time.Second
+
 // Command-line flags
 var (
 	configFile       string
@@ -19,25 +27,72 @@ var (
 	host             string
 	logLevel         string
 	verbose          bool
+	dryRun           bool
+	localFlag        bool
+	createFlag       string
+	explainHostsFlag bool
+	profileFlag      string
 	serverConfigFile string
 )
 
 func main() {
-	if err := rootCmd.Execute(); err != nil {
+	cmd, err := rootCmd.ExecuteC()
+	recordStats(cmd, err)
+	if err != nil {
 		os.Exit(1)
 	}
 }
 
 var rootCmd = &cobra.Command{
-	Use:   "rcode [path]",
+	Use:   "rcode [path...]",
 	Short: "Remote Code Launcher - Open code editors from remote machines",
 	Long: `rcode is a CLI tool that allows launching host machine code editors
 from SSH-connected remote machines without requiring SSH server on the host.
 
-By default, it opens the current directory or the specified path in the configured editor.`,
-	Args:    cobra.MaximumNArgs(1),
+By default, it opens the current directory or the specified path in the configured editor.
+"rcode PATH" is a shortcut for "rcode open PATH".`,
+	Args:    cobra.ArbitraryArgs,
 	Version: version.Version,
-	RunE:    runOpen,
+	PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+		if err := validateOutputFormat(); err != nil {
+			return err
+		}
+		silenceDeprecations(cmd)
+		startUpdateCheck(cmd)
+		return nil
+	},
+	PersistentPostRun: printUpdateHint,
+	RunE:              runOpen,
+}
+
+var openCmd = &cobra.Command{
+	Use:   "open [path...]",
+	Short: "Open paths in an editor on the host",
+	Long: `Open the current directory or the specified paths in the configured editor on the host machine.
+Multiple paths are opened in a single editor invocation.
+
+A project (the git repository a path is in, or else the directory opened)
+opened with --editor keeps opening in that editor until another one is
+given; set forget_editors in the client config to always use default_editor.`,
+	Args: cobra.ArbitraryArgs,
+	RunE: runOpen,
+}
+
+var healthCmd = &cobra.Command{
+	Use:   "health",
+	Short: "Check server health",
+	Long:  `Check whether the configured primary and fallback servers are reachable and healthy.`,
+	Args:  cobra.NoArgs,
+	RunE:  runHealth,
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose configuration and connectivity problems",
+	Long: `Run a series of checks covering configuration, SSH session detection,
+host resolution, server connectivity, and editor availability.`,
+	Args: cobra.NoArgs,
+	RunE: runDoctor,
 }
 
 var configCmd = &cobra.Command{
@@ -55,44 +110,164 @@ var configShowCmd = &cobra.Command{
 
 var configMigrateCmd = &cobra.Command{
 	Use:   "migrate",
-	Short: "Migrate split config files into unified config.yaml",
-	Long:  `Merge client config.yaml and server-config.yaml into a single unified config.yaml file.`,
-	RunE:  runConfigMigrate,
+	Short: "Rewrite legacy config keys, or merge split config files",
+	Long: `Rewrite the legacy keys of config.yaml (network.primary_host,
+network.fallback_host, ssh_host, auto_detect_tailscale and
+tailscale_host_pattern) to the hosts section, keeping comments and other
+settings. The original is backed up next to it and the changes are shown as
+a diff; --dry-run only shows them.
+
+When a separate server-config.yaml exists, it is merged with config.yaml into
+a single unified config.yaml instead, migrating legacy keys on the way.`,
+	Args: cobra.NoArgs,
+	RunE: runConfigMigrate,
 }
 
 var editorsCmd = &cobra.Command{
 	Use:   "editors",
-	Short: "List available editors",
-	Long:  `List all configured editors that can be used with rcode.`,
-	RunE:  runListEditors,
+	Short: "List and manage available editors",
+	Long: `List all configured editors that can be used with rcode.
+
+Use the add, remove and set-default subcommands to change the server's editor
+list remotely, and test to check what an editor would run.`,
+	Args: cobra.NoArgs,
+	RunE: runListEditors,
 }
 
 func init() {
 	// Global flags
 	rootCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "", "Path to configuration file")
 	rootCmd.PersistentFlags().StringVarP(&logLevel, "log-level", "l", "", "Log level (debug, info, warn, error)")
+	rootCmd.PersistentFlags().BoolVar(&config.StrictKeys, "strict-config", false, "Fail on unknown keys in the config file instead of warning")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output")
+	rootCmd.PersistentFlags().StringVar(&profileFlag, "profile", "", "Configuration profile to use (overrides RCODE_PROFILE)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", outputText, "Output format for command results (text, json, yaml)")
 
-	// Root command flags
-	rootCmd.Flags().StringVarP(&editor, "editor", "e", "", "Editor to use (overrides default)")
+	// Root command flags (shortcut for open)
+	rootCmd.Flags().StringVarP(&editor, "editor", "e", "", "Editor to use (default: the one last used for the project, then default_editor)")
 	rootCmd.Flags().StringVarP(&host, "host", "H", "", "Server host (overrides config)")
+	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the command that would run without opening anything")
+	rootCmd.Flags().BoolVar(&localFlag, "local", false, "Launch the editor on this machine instead of through the server")
+	rootCmd.Flags().StringVar(&createFlag, "create", "", "Create missing paths before opening them: file (default) or dir")
+	rootCmd.Flags().Lookup("create").NoOptDefVal = api.CreateFile
+	rootCmd.Flags().BoolVar(&explainHostsFlag, "explain-hosts", false, "Show how the server and SSH hosts are resolved, then exit")
+	rootCmd.Flags().BoolVar(&queueFlag, "queue", false, "Queue the open when no host is reachable, delivering it later")
+	rootCmd.Flags().BoolVar(&stdinJSON, "stdin-json", false, "Read a JSON request from stdin and write a JSON response to stdout (for editor plugins)")
+
+	// Open command flags
+	openCmd.Flags().StringVarP(&editor, "editor", "e", "", "Editor to use (default: the one last used for the project, then default_editor)")
+	openCmd.Flags().StringVarP(&host, "host", "H", "", "Server host (overrides config)")
+	openCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the command that would run without opening anything")
+	openCmd.Flags().BoolVar(&localFlag, "local", false, "Launch the editor on this machine instead of through the server")
+	openCmd.Flags().StringVar(&createFlag, "create", "", "Create missing paths before opening them: file (default) or dir")
+	openCmd.Flags().Lookup("create").NoOptDefVal = api.CreateFile
+	openCmd.Flags().BoolVar(&queueFlag, "queue", false, "Queue the open when no host is reachable, delivering it later")
+
+	// Queue command flags
+	queueFlushCmd.Flags().DurationVar(&queueRetry, "retry", 0, "Keep retrying at this interval until the queue is delivered")
+	queueFlushCmd.Flags().StringVarP(&host, "host", "H", "", "Server host (overrides config)")
+
+	// Diff command flags
+	diffCmd.Flags().StringVarP(&editor, "editor", "e", "", "Editor to use (overrides default)")
+	diffCmd.Flags().StringVarP(&host, "host", "H", "", "Server host (overrides config)")
+
+	// Forward command flags
+	forwardCmd.Flags().StringVarP(&editor, "editor", "e", "", "Browser editor whose URL uses {port}")
+	forwardCmd.Flags().StringVarP(&host, "host", "H", "", "Server host (overrides config)")
+
+	// Terminal command flags
+	terminalCmd.Flags().StringVarP(&terminalName, "terminal", "t", "", "Terminal to use (overrides the server's default)")
+	terminalCmd.Flags().StringVarP(&host, "host", "H", "", "Server host (overrides config)")
+
+	// Health, doctor and status command flags
+	healthCmd.Flags().StringVarP(&host, "host", "H", "", "Server host (overrides config)")
+	doctorCmd.Flags().StringVarP(&host, "host", "H", "", "Server host (overrides config)")
+	statusCmd.Flags().StringVarP(&host, "host", "H", "", "Server host (overrides config)")
+
+	// Recent command flags
+	recentCmd.Flags().IntVarP(&recentLimit, "limit", "n", 10, "Number of sessions to list")
+	recentCmd.Flags().StringVarP(&editor, "editor", "e", "", "Editor to use (overrides the recorded editor)")
+	recentCmd.Flags().StringVarP(&host, "host", "H", "", "Server host (overrides config)")
+
+	// History and last command flags
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "Number of entries to list (0 for all)")
+	historyCmd.Flags().BoolVarP(&historySelect, "select", "s", false, "Pick an entry to open again")
+	historyCmd.Flags().StringVarP(&editor, "editor", "e", "", "Editor to use (overrides the recorded editor)")
+	historyCmd.Flags().StringVarP(&host, "host", "H", "", "Server host (overrides config)")
+	lastCmd.Flags().StringVarP(&editor, "editor", "e", "", "Editor to use (overrides the recorded editor)")
+	lastCmd.Flags().StringVarP(&host, "host", "H", "", "Server host (overrides config)")
+
+	// Editors command flags
+	editorsCmd.PersistentFlags().StringVarP(&host, "host", "H", "", "Server host (overrides config)")
+	editorsAddCmd.Flags().BoolVar(&editorsAddBrowser, "browser", false, "Treat TEMPLATE as a URL to open in the browser")
+	editorsAddCmd.Flags().BoolVar(&editorsAddDefault, "default", false, "Make the new editor the server's default")
+
+	// Init command flags
+	initCmd.Flags().BoolVarP(&initForce, "force", "f", false, "Update an existing configuration without asking")
 
 	// Add subcommands
+	rootCmd.AddCommand(initCmd)
+	rootCmd.AddCommand(openCmd)
+	rootCmd.AddCommand(terminalCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(recentCmd)
+	rootCmd.AddCommand(historyCmd)
+	statsCmd.AddCommand(statsResetCmd)
+	rootCmd.AddCommand(statsCmd)
+	rootCmd.AddCommand(bookmarkCmd)
+	rootCmd.AddCommand(queueCmd)
+	queueCmd.AddCommand(queueFlushCmd)
+	queueCmd.AddCommand(queueClearCmd)
+	bookmarkCmd.AddCommand(bookmarkAddCmd)
+	bookmarkCmd.AddCommand(bookmarkRemoveCmd)
+	bookmarkCmd.AddCommand(bookmarkListCmd)
+	rootCmd.AddCommand(lastCmd)
+	rootCmd.AddCommand(clipCmd)
+	rootCmd.AddCommand(openURLCmd)
+	rootCmd.AddCommand(forwardCmd)
+	rootCmd.AddCommand(healthCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(statusCmd)
 	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(editorsCmd)
+	editorsCmd.AddCommand(editorsAddCmd)
+	editorsCmd.AddCommand(editorsRemoveCmd)
+	editorsCmd.AddCommand(editorsSetDefaultCmd)
+	editorsCmd.AddCommand(editorsTestCmd)
 	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
 	configCmd.AddCommand(configMigrateCmd)
 	configMigrateCmd.Flags().StringVar(&serverConfigFile, "server-config", "", "Path to legacy server configuration file")
+	configMigrateCmd.Flags().BoolVarP(&migrateDryRun, "dry-run", "n", false, "Show the changes without writing them")
+
+	// Shell completion, with editor names fetched from the server
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	rootCmd.AddCommand(completionCmd)
+	for _, cmd := range []*cobra.Command{rootCmd, openCmd, recentCmd, historyCmd, lastCmd, diffCmd, forwardCmd} {
+		_ = cmd.RegisterFlagCompletionFunc("editor", completeEditorNames)
+	}
+	editorsRemoveCmd.ValidArgsFunction = completeFirstEditorArg
+	rootCmd.ValidArgsFunction = completeBookmarkPaths
+	openCmd.ValidArgsFunction = completeBookmarkPaths
+	bookmarkRemoveCmd.ValidArgsFunction = completeBookmarkName
+	editorsSetDefaultCmd.ValidArgsFunction = completeFirstEditorArg
 
 	// Custom version template
 	rootCmd.SetVersionTemplate(fmt.Sprintf("rcode version %s\nBuilt: %s\nGit: %s\n", version.Version, version.BuildTime, version.GitHash))
 }
 
-func runOpen(_ *cobra.Command, args []string) error {
+// loadClientConfig loads the client configuration and applies command-line
+// and environment overrides, then validates the result.
+func loadClientConfig() (*config.ClientConfig, error) {
 	// Load configuration
 	cfg, err := config.LoadClientConfig(configFile)
 	if err != nil {
-		return fmt.Errorf("failed to load configuration: %w", err)
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if err := cfg.ApplyProfile(selectProfile(cfg)); err != nil {
+		return nil, err
 	}
 
 	// Apply command-line overrides
@@ -111,7 +286,117 @@ func runOpen(_ *cobra.Command, args []string) error {
 
 	// Validate configuration
 	if err := config.ValidateClientConfig(cfg); err != nil {
-		return fmt.Errorf("invalid configuration: %w", err)
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	printDeprecations(os.Stderr, cfg)
+
+	return cfg, nil
+}
+
+// selectProfile returns the profile named by --profile or RCODE_PROFILE, or
+// else the first one whose match rules accept this machine
+func selectProfile(cfg *config.ClientConfig) string {
+	if profileFlag != "" {
+		return profileFlag
+	}
+	if name := os.Getenv("RCODE_PROFILE"); name != "" {
+		return name
+	}
+	hostname, _ := os.Hostname()
+	return cfg.MatchProfile(network.ExtractSSHServerIP(), hostname)
+}
+
+// newQuietLogger creates a console logger that only reports errors,
+// for commands whose output is meant for the user rather than the log.
+func newQuietLogger() *logger.Logger {
+	level := "error"
+	if verbose {
+		level = "debug"
+	}
+	return logger.New(&logger.Config{
+		Level:   level,
+		Console: true,
+		Format:  "text",
+	})
+}
+
+func runOpen(cmd *cobra.Command, args []string) error {
+	if explainHostsFlag {
+		return runExplainHosts()
+	}
+	if stdinJSON {
+		// The JSON response carries any error
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
+		return runStdinJSON(os.Stdin, os.Stdout)
+	}
+	if createFlag != "" && createFlag != api.CreateFile && createFlag != api.CreateDir {
+		return fmt.Errorf("invalid --create %q (must be %s or %s)", createFlag, api.CreateFile, api.CreateDir)
+	}
+
+	oc, err := newOpenContext()
+	if err != nil {
+		return err
+	}
+	defer oc.close()
+
+	// Get the paths to open (default to current directory)
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+
+	// Split off an optional :line[:column] suffix (single path only)
+	var pos FilePosition
+	if len(args) == 1 {
+		args[0], pos = parsePathPosition(args[0])
+	}
+
+	// Convert to absolute paths, expanding @bookmarks
+	absPaths := make([]string, 0, len(args))
+	for _, arg := range args {
+		arg, err := resolveBookmark(oc.cfg.Bookmarks, arg)
+		if err != nil {
+			return err
+		}
+		abs, err := absPath(oc.cfg, arg)
+		if err != nil {
+			return err
+		}
+		absPaths = append(absPaths, abs)
+	}
+
+	editorName := oc.projectEditor(absPaths, editor)
+	if dryRun {
+		return oc.dryRun(absPaths, pos, editorName)
+	}
+	if oc.useLocal() {
+		return oc.openLocal(absPaths, pos, editorName)
+	}
+	return oc.open(absPaths, pos, editorName)
+}
+
+// openContext holds everything needed to send open requests: configuration,
+// logger, client, and the resolved SSH connection details.
+type openContext struct {
+	cfg      *config.ClientConfig
+	log      *logger.Logger
+	client   *Client
+	sshInfo  SSHInfo
+	inSSH    bool
+	resolver *network.Resolver
+	resolved network.ResolvedHosts
+
+	// telemetry and span cover the whole command when telemetry is enabled
+	telemetry *telemetry.Provider
+	span      *telemetry.Span
+}
+
+// newOpenContext loads configuration, sets up logging, and resolves hosts.
+// Callers must call close when done.
+func newOpenContext() (*openContext, error) {
+	cfg, err := loadClientConfig()
+	if err != nil {
+		return nil, err
 	}
 
 	// Initialize logger
@@ -123,7 +408,10 @@ func runOpen(_ *cobra.Command, args []string) error {
 		MaxBackups: cfg.Logging.MaxBackups,
 		MaxAge:     cfg.Logging.MaxAge,
 		Compress:   cfg.Logging.Compress,
-		Format:     "text",
+		Format:     cfg.Logging.Format,
+		Rotate:     cfg.Logging.Rotate,
+		DateNames:  cfg.Logging.DateNames,
+		Dedup:      cfg.Logging.Dedup,
 	}
 
 	// Use debug level if verbose flag is set
@@ -131,13 +419,12 @@ func runOpen(_ *cobra.Command, args []string) error {
 		logConfig.Level = "debug"
 		logConfig.Console = true
 	}
+	// Machine mode keeps stdout and stderr for the JSON response
+	if stdinJSON {
+		logConfig.Console = false
+	}
 
 	log := logger.New(logConfig)
-	defer func() {
-		if err := log.Close(); err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to close logger: %v\n", err)
-		}
-	}()
 
 	// Debug: Log loaded configuration values
 	if verbose {
@@ -148,24 +435,10 @@ func runOpen(_ *cobra.Command, args []string) error {
 		)
 	}
 
-	// Create client
-	client := NewClient(cfg, log)
-
-	// Get the path to open (default to current directory)
-	path := "."
-	if len(args) > 0 {
-		path = args[0]
-	}
-
-	// Convert to absolute path
-	absPath, err := filepath.Abs(path)
-	if err != nil {
-		return fmt.Errorf("failed to resolve path: %w", err)
-	}
-
 	// Extract SSH connection information
 	sshInfo, err := ExtractSSHInfo()
-	if err != nil {
+	inSSH := err == nil
+	if !inSSH && cfg.LocalMode != config.LocalModeAuto && !localFlag {
 		log.Warn("Not in SSH session", "error", err)
 		// Continue anyway - might be testing locally
 	}
@@ -178,11 +451,47 @@ func runOpen(_ *cobra.Command, args []string) error {
 		}
 	}
 
-	// Use the Resolver to determine hosts
+	tel := telemetry.New(cfg.Telemetry, "rcode", log)
+	ctx, span := tel.Start(context.Background(), "rcode", telemetry.KindInternal)
+
+	_, resolveSpan := tel.Start(ctx, "resolve hosts", telemetry.KindInternal)
+	resolver, resolved := resolveHosts(cfg, &sshInfo)
+	resolveSpan.SetAttributes(
+		slog.String("rcode.server", resolved.Server),
+		slog.String("rcode.server.source", resolved.ServerSource),
+		slog.String("rcode.ssh", resolved.SSH),
+		slog.String("rcode.ssh.source", resolved.Source),
+	)
+	resolveSpan.End()
+
+	log.Debug("Host resolution completed",
+		"ssh_host", sshInfo.Host,
+		"source", resolved.Source,
+		"server", cfg.Hosts.Server.Primary,
+	)
+
+	client := NewClient(cfg, log)
+	client.telemetry, client.traceCtx = tel, ctx
+
+	return &openContext{
+		cfg:       cfg,
+		log:       log,
+		client:    client,
+		sshInfo:   sshInfo,
+		inSSH:     inSSH,
+		resolver:  resolver,
+		resolved:  resolved,
+		telemetry: tel,
+		span:      span,
+	}, nil
+}
+
+// resolveHosts runs host resolution for the SSH session and applies the
+// result: the SSH host to sshInfo and the server hosts to cfg
+func resolveHosts(cfg *config.ClientConfig, sshInfo *SSHInfo) (*network.Resolver, network.ResolvedHosts) {
 	resolver := network.NewResolverFromConfig(cfg, host, sshInfo.ClientIP)
 	resolved := resolver.Resolve()
 
-	// Apply resolved hosts
 	sshInfo.Host = resolved.SSH
 	if resolved.Server != "" {
 		cfg.Hosts.Server.Primary = resolved.Server
@@ -190,30 +499,60 @@ func runOpen(_ *cobra.Command, args []string) error {
 	if resolved.ServerFallback != "" {
 		cfg.Hosts.Server.Fallback = resolved.ServerFallback
 	}
+	return resolver, resolved
+}
 
-	log.Debug("Host resolution completed",
-		"ssh_host", sshInfo.Host,
-		"source", resolved.Source,
-		"server", cfg.Hosts.Server.Primary,
-	)
+// close exports buffered telemetry and releases the logger
+func (oc *openContext) close() {
+	oc.span.End()
+	ctx, cancel := context.WithTimeout(context.Background(), telemetryShutdownTimeout)
+	defer cancel()
+	if err := oc.telemetry.Shutdown(ctx); err != nil {
+		oc.log.Debug("Failed to export telemetry", "error", err)
+	}
+
+	if err := oc.log.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to close logger: %v\n", err)
+	}
+}
+
+// open sends the open request and prints a manual command on failure
+func (oc *openContext) open(absPaths []string, pos FilePosition, editorName string) error {
+	absPath := strings.Join(absPaths, " ")
+
+	logEditor := editorName
+	if logEditor == "" {
+		logEditor = oc.cfg.DefaultEditor
+	}
 
 	// Log the request details
-	log.Info("Opening editor",
+	oc.log.Info("Opening editor",
 		"path", absPath,
-		"editor", cfg.DefaultEditor,
-		"user", sshInfo.User,
-		"host", sshInfo.Host,
-		"server", cfg.Hosts.Server.Primary,
+		"line", pos.Line,
+		"column", pos.Column,
+		"editor", logEditor,
+		"user", oc.sshInfo.User,
+		"host", oc.sshInfo.Host,
+		"server", oc.cfg.Hosts.Server.Primary,
 	)
 
 	// Open the editor
-	err = client.OpenEditor(absPath, editor, &sshInfo)
+	req := oc.client.newOpenRequest(absPaths, pos, editorName, &oc.sshInfo)
+	req.Create = createFlag
+	opened, err := oc.client.SendOpen(req)
 	if err != nil {
+		if queueFlag && isUnreachable(err) {
+			return oc.queueOpen(absPaths, pos, editorName)
+		}
+
 		// Show manual command as fallback
 		fmt.Fprintf(os.Stderr, "Failed to open editor: %v\n", err)
+		if isUnreachable(err) {
+			fmt.Fprintf(os.Stderr, "\n%s", tunnelHint(oc.cfg, &oc.sshInfo))
+		}
 
 		// Generate manual command
-		manualCmd := client.GetManualCommand(absPath, editor, &sshInfo)
+		manualCmd := oc.client.GetManualCommandPaths(absPaths, pos, editorName, &oc.sshInfo)
 		if manualCmd != "" {
 			fmt.Fprintf(os.Stderr, "\nYou can try running this command manually on your host machine:\n")
 			fmt.Fprintf(os.Stderr, "  %s\n", manualCmd)
@@ -221,8 +560,20 @@ func runOpen(_ *cobra.Command, args []string) error {
 
 		return fmt.Errorf("failed to open editor: %w", err)
 	}
+	oc.recordHistory(absPaths, opened.Editor)
+	oc.recordOpenStats(opened.Editor, oc.client.answeredRole())
+	oc.rememberEditor(absPaths, editorName)
 
+	for _, p := range opened.Created {
+		fmt.Printf("Created %s on the host\n", p)
+	}
 	fmt.Printf("Successfully opened %s\n", absPath)
+	for _, info := range opened.PathInfo {
+		if !info.Exists {
+			fmt.Fprintf(os.Stderr, "Warning: %s does not exist on the host\n", info.Path)
+		}
+	}
+	oc.flushAfterOpen()
 	return nil
 }
 
@@ -232,24 +583,38 @@ func runConfigShow(_ *cobra.Command, _ []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
+	profile := selectProfile(cfg)
+	if err := cfg.ApplyProfile(profile); err != nil {
+		return err
+	}
+	printDeprecations(os.Stderr, cfg)
+
+	if structuredOutput() {
+		// Never print the auth tokens and signing keys
+		shown := *cfg
+		shown.Hosts.Server.AuthToken = ""
+		shown.Hosts.Server.SigningKey = ""
+		shown.Profiles = make(map[string]config.ProfileConfig, len(cfg.Profiles))
+		for name, profile := range cfg.Profiles {
+			profile.Server.AuthToken = ""
+			profile.Server.SigningKey = ""
+			shown.Profiles[name] = profile
+		}
+		return writeStructured(os.Stdout, &shown)
+	}
 
-	showConfiguration(cfg)
+	showConfiguration(cfg, profile)
 	return nil
 }
 
 func runListEditors(_ *cobra.Command, _ []string) error {
-	// Load configuration
-	cfg, err := config.LoadClientConfig(configFile)
+	cfg, err := loadClientConfig()
 	if err != nil {
-		return fmt.Errorf("failed to load configuration: %w", err)
+		return err
 	}
 
 	// Initialize logger (minimal for this command)
-	log := logger.New(&logger.Config{
-		Level:   "error",
-		Console: true,
-		Format:  "text",
-	})
+	log := newQuietLogger()
 	defer func() {
 		if err := log.Close(); err != nil {
 			fmt.Fprintf(os.Stderr, "Failed to close logger: %v\n", err)
@@ -258,39 +623,63 @@ func runListEditors(_ *cobra.Command, _ []string) error {
 
 	// Create client and list editors
 	client := NewClient(cfg, log)
+	if structuredOutput() {
+		editors, err := client.fetchEditorsWithFallback()
+		if err != nil {
+			return fmt.Errorf("failed to list editors: %w", err)
+		}
+		return writeStructured(os.Stdout, editors)
+	}
 	if err := client.ListEditors(); err != nil {
 		return fmt.Errorf("failed to list editors: %w", err)
 	}
 	return nil
 }
 
-func runConfigMigrate(_ *cobra.Command, _ []string) error {
-	result, err := config.MigrateToUnifiedConfig(configFile, serverConfigFile)
+func runHealth(_ *cobra.Command, _ []string) error {
+	cfg, err := loadClientConfig()
 	if err != nil {
-		return fmt.Errorf("failed to migrate configuration: %w", err)
+		return err
 	}
 
-	fmt.Printf("Unified config written to %s\n", result.UnifiedPath)
-	if result.ClientBackupPath != "" {
-		fmt.Printf("Client backup saved to %s\n", result.ClientBackupPath)
-	}
-	if result.ServerBackupPath != "" {
-		fmt.Printf("Server backup saved to %s\n", result.ServerBackupPath)
-	}
+	log := newQuietLogger()
+	defer func() {
+		if err := log.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to close logger: %v\n", err)
+		}
+	}()
 
-	return nil
+	client := NewClient(cfg, log)
+	if structuredOutput() {
+		report := client.Health()
+		if err := writeStructured(os.Stdout, report); err != nil {
+			return err
+		}
+		if !report.Healthy {
+			return fmt.Errorf("no healthy hosts found")
+		}
+		return nil
+	}
+	return client.CheckHealth()
 }
 
 // showConfiguration displays the current configuration
-func showConfiguration(cfg *config.ClientConfig) {
+func showConfiguration(cfg *config.ClientConfig, profile string) {
 	fmt.Println("Current Configuration:")
 	fmt.Println("======================")
+	if profile != "" {
+		fmt.Printf("Profile: %s\n", profile)
+	}
 	fmt.Printf("Hosts:\n")
 	fmt.Printf("  Server:\n")
 	fmt.Printf("    Primary: %s\n", cfg.Hosts.Server.Primary)
 	if cfg.Hosts.Server.Fallback != "" {
 		fmt.Printf("    Fallback: %s\n", cfg.Hosts.Server.Fallback)
 	}
+	if cfg.Hosts.Server.Tunnel != "" {
+		fmt.Printf("    Tunnel: %s\n", cfg.Hosts.Server.Tunnel)
+	}
+	fmt.Printf("    Port: %d\n", cfg.Hosts.Server.Port)
 	fmt.Printf("  SSH:\n")
 	if cfg.Hosts.SSH.Host != "" {
 		fmt.Printf("    Host: %s\n", cfg.Hosts.SSH.Host)
@@ -301,10 +690,16 @@ func showConfiguration(cfg *config.ClientConfig) {
 	if cfg.Hosts.SSH.AutoDetect.TailscalePattern != "" {
 		fmt.Printf("    Tailscale Pattern: %s\n", cfg.Hosts.SSH.AutoDetect.TailscalePattern)
 	}
+	if len(cfg.Profiles) > 0 {
+		fmt.Printf("\nProfiles: %s\n", strings.Join(cfg.ProfileNames(), ", "))
+	}
 	fmt.Printf("\nNetwork:\n")
 	fmt.Printf("  Timeout: %v\n", cfg.Network.Timeout)
 	fmt.Printf("  Retry Attempts: %d\n", cfg.Network.RetryAttempts)
 	fmt.Printf("\nDefault Editor: %s\n", cfg.DefaultEditor)
+	if cfg.LocalMode != "" {
+		fmt.Printf("Local Mode: %s\n", cfg.LocalMode)
+	}
 	fmt.Printf("  (Editor definitions are fetched from the server. Use 'rcode editors' to see available editors.)\n")
 
 	if len(cfg.FallbackEditors) > 0 {
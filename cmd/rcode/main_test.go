@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadClientConfig_Profile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `hosts:
+  server:
+    primary: 192.168.1.100
+default_editor: cursor
+profiles:
+  office:
+    server:
+      primary: 10.0.0.5
+    default_editor: zed
+  home:
+    server:
+      primary: 192.168.0.2
+    match:
+      server_ip: [192.168.0.0/24]
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("RCODE_EDITOR", "")
+	t.Setenv("SSH_CONNECTION", "")
+	originalConfig, originalProfile := configFile, profileFlag
+	configFile = path
+	defer func() { configFile, profileFlag = originalConfig, originalProfile }()
+
+	tests := []struct {
+		name        string
+		flag        string
+		env         string
+		wantPrimary string
+		wantEditor  string
+	}{
+		{"no profile", "", "", "192.168.1.100", "cursor"},
+		{"environment", "", "office", "10.0.0.5", "zed"},
+		{"flag wins over environment", "home", "office", "192.168.0.2", "cursor"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profileFlag = tt.flag
+			t.Setenv("RCODE_PROFILE", tt.env)
+
+			cfg, err := loadClientConfig()
+			if err != nil {
+				t.Fatalf("loadClientConfig() error = %v", err)
+			}
+			if cfg.Hosts.Server.Primary != tt.wantPrimary || cfg.DefaultEditor != tt.wantEditor {
+				t.Errorf("primary = %q, editor = %q, want %q, %q",
+					cfg.Hosts.Server.Primary, cfg.DefaultEditor, tt.wantPrimary, tt.wantEditor)
+			}
+		})
+	}
+
+	// With no profile named, the SSH server IP picks one
+	profileFlag = ""
+	t.Setenv("RCODE_PROFILE", "")
+	t.Setenv("SSH_CONNECTION", "192.168.0.50 52000 192.168.0.2 22")
+	if cfg, err := loadClientConfig(); err != nil || cfg.Hosts.Server.Primary != "192.168.0.2" {
+		t.Errorf("loadClientConfig() by SSH server IP = %v, want the home profile", err)
+	}
+
+	profileFlag = "cafe"
+	if _, err := loadClientConfig(); err == nil {
+		t.Error("loadClientConfig() with an unknown profile succeeded, want error")
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// migrateDryRun shows the changes of "rcode config migrate" without writing
+var migrateDryRun bool
+
+// diffContext is how many unchanged lines surround each change in a diff
+const diffContext = 3
+
+func runConfigMigrate(_ *cobra.Command, _ []string) error {
+	paths := config.GetDefaultPaths()
+	clientPath := configFile
+	if clientPath == "" {
+		clientPath = paths.ClientConfig
+	}
+	serverPath := serverConfigFile
+	if serverPath == "" {
+		serverPath = paths.ServerConfig
+	}
+
+	if _, err := os.Stat(serverPath); err == nil && filepath.Clean(serverPath) != filepath.Clean(clientPath) {
+		return mergeSplitConfig(os.Stdout, clientPath, serverPath)
+	}
+
+	result, err := config.MigrateLegacyConfigFile(clientPath, migrateDryRun)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no config file to migrate at %s", clientPath)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to migrate configuration: %w", err)
+	}
+	if len(result.Warnings) == 0 {
+		fmt.Printf("%s has no legacy keys; nothing to migrate\n", clientPath)
+		return nil
+	}
+
+	for _, w := range result.Warnings {
+		fmt.Printf("%s: %s\n", w.Field, w.Message)
+	}
+	fmt.Println()
+	writeDiff(os.Stdout, clientPath, string(result.Before), string(result.After))
+	fmt.Println()
+	if migrateDryRun {
+		fmt.Println("Dry run; nothing was written.")
+		return nil
+	}
+	fmt.Printf("Updated %s\n", clientPath)
+	fmt.Printf("Backup saved to %s\n", result.BackupPath)
+	return nil
+}
+
+// mergeSplitConfig merges the separate server config file into the client
+// config file, writing the changes to w
+func mergeSplitConfig(w io.Writer, clientPath, serverPath string) error {
+	result, err := config.MigrateToUnifiedConfig(clientPath, serverPath, migrateDryRun)
+	if err != nil {
+		return fmt.Errorf("failed to migrate configuration: %w", err)
+	}
+	writeDiff(w, result.UnifiedPath, string(result.Before), string(result.After))
+	fmt.Fprintln(w)
+	if migrateDryRun {
+		fmt.Fprintf(w, "Dry run; %s would be merged into %s and nothing was written.\n", serverPath, clientPath)
+		return nil
+	}
+
+	fmt.Fprintf(w, "Unified config written to %s\n", result.UnifiedPath)
+	if result.ClientBackupPath != "" {
+		fmt.Fprintf(w, "Client backup saved to %s\n", result.ClientBackupPath)
+	}
+	if result.ServerBackupPath != "" {
+		fmt.Fprintf(w, "Server backup saved to %s\n", result.ServerBackupPath)
+	}
+	return nil
+}
+
+// diffLine is one line of a line diff: ' ' kept, '-' removed or '+' added
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// diffLines returns the shortest edit turning before into after, from the
+// longest common subsequence of their lines
+func diffLines(before, after []string) []diffLine {
+	// lcs[i][j] is the LCS length of before[i:] and after[j:]
+	lcs := make([][]int, len(before)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(before) || j < len(after) {
+		switch {
+		case i < len(before) && j < len(after) && before[i] == after[j]:
+			lines = append(lines, diffLine{' ', before[i]})
+			i++
+			j++
+		case i < len(before) && (j == len(after) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, diffLine{'-', before[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', after[j]})
+			j++
+		}
+	}
+	return lines
+}
+
+// writeDiff writes the changes from before to after as a unified diff of
+// the file name
+func writeDiff(w io.Writer, name, before, after string) {
+	lines := diffLines(splitLines(before), splitLines(after))
+
+	// Keep the lines within diffContext of a change
+	keep := make([]bool, len(lines))
+	for i, l := range lines {
+		if l.op == ' ' {
+			continue
+		}
+		for k := max(0, i-diffContext); k <= min(len(lines)-1, i+diffContext); k++ {
+			keep[k] = true
+		}
+	}
+
+	fmt.Fprintf(w, "--- %s\n+++ %s (migrated)\n", name, name)
+	oldLine, newLine := 1, 1
+	for i := 0; i < len(lines); {
+		if !keep[i] {
+			oldLine++
+			newLine++
+			i++
+			continue
+		}
+
+		end := i
+		var oldCount, newCount int
+		for ; end < len(lines) && keep[end]; end++ {
+			if lines[end].op != '+' {
+				oldCount++
+			}
+			if lines[end].op != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(w, "@@ -%d,%d +%d,%d @@\n", oldLine, oldCount, newLine, newCount)
+		for _, l := range lines[i:end] {
+			fmt.Fprintf(w, "%c%s\n", l.op, l.text)
+		}
+		oldLine += oldCount
+		newLine += newCount
+		i = end
+	}
+}
+
+// splitLines splits s into lines without their newlines
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
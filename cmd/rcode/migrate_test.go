@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/foxytanuki/rcode/internal/config"
+)
+
+func TestWriteDiff(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	after := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+
+	var out bytes.Buffer
+	writeDiff(&out, "config.yaml", before, after)
+
+	want := `--- config.yaml
++++ config.yaml (migrated)
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -8,3 +8,4 @@
+ h
+ i
+ j
++k
+`
+	if out.String() != want {
+		t.Errorf("writeDiff() =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+// setMigrateFlags points rcode config migrate at dir for the rest of the test
+func setMigrateFlags(t *testing.T, dir string, dryRun bool) (clientPath, serverPath string) {
+	t.Helper()
+	originalConfig, originalServer, originalDryRun := configFile, serverConfigFile, migrateDryRun
+	t.Cleanup(func() { configFile, serverConfigFile, migrateDryRun = originalConfig, originalServer, originalDryRun })
+
+	clientPath, serverPath = filepath.Join(dir, "config.yaml"), filepath.Join(dir, "server-config.yaml")
+	configFile, serverConfigFile, migrateDryRun = clientPath, serverPath, dryRun
+	return clientPath, serverPath
+}
+
+func TestRunConfigMigrate(t *testing.T) {
+	clientPath, _ := setMigrateFlags(t, t.TempDir(), false)
+	legacy := "network:\n  primary_host: 192.168.1.100\nssh_host: 192.168.1.50\n"
+	if err := os.WriteFile(clientPath, []byte(legacy), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	if err := runConfigMigrate(nil, nil); err != nil {
+		t.Fatalf("runConfigMigrate() error = %v", err)
+	}
+
+	backup, err := os.ReadFile(clientPath + ".bak")
+	if err != nil {
+		t.Fatalf("ReadFile(backup) error = %v", err)
+	}
+	if string(backup) != legacy {
+		t.Errorf("backup = %q, want the original file %q", backup, legacy)
+	}
+
+	cfg, err := config.LoadClientConfig(clientPath)
+	if err != nil {
+		t.Fatalf("LoadClientConfig() error = %v", err)
+	}
+	if cfg.Hosts.Server.Primary != "192.168.1.100" || cfg.Hosts.SSH.Host != "192.168.1.50" {
+		t.Errorf("hosts = %+v, want the legacy keys moved under hosts", cfg.Hosts)
+	}
+	rewritten, err := os.ReadFile(clientPath)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if strings.Contains(string(rewritten), "primary_host") || strings.Contains(string(rewritten), "ssh_host") {
+		t.Errorf("rewritten file still has legacy keys:\n%s", rewritten)
+	}
+}
+
+func TestMergeSplitConfigDryRun(t *testing.T) {
+	clientPath, serverPath := setMigrateFlags(t, t.TempDir(), true)
+	client := "hosts:\n  server:\n    primary: 192.168.1.100\n"
+	server := "server:\n  port: 4000\n"
+	if err := os.WriteFile(clientPath, []byte(client), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := os.WriteFile(serverPath, []byte(server), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := mergeSplitConfig(&out, clientPath, serverPath); err != nil {
+		t.Fatalf("mergeSplitConfig() error = %v", err)
+	}
+
+	if !strings.Contains(out.String(), "--- "+clientPath) || !strings.Contains(out.String(), "+    port: 4000") {
+		t.Errorf("output = %q, want a diff adding the server settings", out.String())
+	}
+	if data, err := os.ReadFile(clientPath); err != nil || string(data) != client {
+		t.Errorf("client file = %q (%v), want it unchanged", data, err)
+	}
+	if _, err := os.Stat(serverPath); err != nil {
+		t.Errorf("server file removed by a dry run: %v", err)
+	}
+	if _, err := os.Stat(clientPath + ".bak"); !os.IsNotExist(err) {
+		t.Errorf("backup written by a dry run: %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var openURLCmd = &cobra.Command{
+	Use:   "open-url URL",
+	Short: "Open a URL in the host browser",
+	Long: `Open a URL in the default browser of the machine running rcode-server.
+
+The server only opens URLs matching server.open_url.allowed, a list of
+"scheme://host" patterns such as "https://*.github.com" or "http://localhost:*".`,
+	Example: `  rcode open-url https://github.com/foxytanuki/rcode/pulls
+  rcode open-url http://localhost:5173/`,
+	Args: cobra.ExactArgs(1),
+	RunE: runOpenURL,
+}
+
+func runOpenURL(_ *cobra.Command, args []string) error {
+	return withAdminClient(func(client *Client) error {
+		opened, err := client.OpenURL(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to open URL: %w", err)
+		}
+		if structuredOutput() {
+			return writeStructured(os.Stdout, opened)
+		}
+		fmt.Printf("Opened %s on the host.\n", opened.URL)
+		return nil
+	})
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+func TestWriteStructured(t *testing.T) {
+	report := &doctorReport{Failed: true}
+	report.add("server", checkFail, "no healthy server found")
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{outputJSON, "{\n  \"checks\": [\n    {\n      \"name\": \"server\",\n      \"status\": \"FAIL\",\n      \"message\": \"no healthy server found\"\n    }\n  ],\n  \"failed\": true\n}\n"},
+		{outputYAML, "checks:\n  - name: server\n    status: FAIL\n    message: no healthy server found\nfailed: true\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			outputFormat = tt.format
+			defer func() { outputFormat = outputText }()
+
+			var buf bytes.Buffer
+			if err := writeStructured(&buf, report); err != nil {
+				t.Fatalf("writeStructured() error = %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("writeStructured() =\n%s\nwant\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateOutputFormat(t *testing.T) {
+	defer func() { outputFormat = outputText }()
+
+	for _, format := range []string{outputText, outputJSON, outputYAML} {
+		outputFormat = format
+		if err := validateOutputFormat(); err != nil {
+			t.Errorf("validateOutputFormat(%s) error = %v", format, err)
+		}
+	}
+
+	outputFormat = "xml"
+	if err := validateOutputFormat(); err == nil {
+		t.Error("validateOutputFormat(xml) error = nil, want error")
+	}
+}
+
+func TestClient_Health(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(api.HealthResponse{Status: "healthy", Version: "1.2.3"})
+	}))
+	defer server.Close()
+
+	cfg := &config.ClientConfig{
+		Hosts: config.HostsConfig{
+			Server: config.ServerHostConfig{
+				Primary:  "127.0.0.1:1",
+				Fallback: strings.TrimPrefix(server.URL, "http://"),
+				Tunnel:   "127.0.0.1:2",
+			},
+		},
+		Network: config.ClientNetworkConfig{Timeout: time.Second, RetryAttempts: 1},
+	}
+
+	report := NewClient(cfg, createTestLogger()).Health()
+
+	if !report.Healthy {
+		t.Fatalf("Health() Healthy = false, want true: %+v", report)
+	}
+	if len(report.Hosts) != 2 {
+		t.Fatalf("Health() checked %d hosts, want 2 (stop at the first healthy one): %+v", len(report.Hosts), report.Hosts)
+	}
+	if primary := report.Hosts[0]; primary.Role != "primary" || primary.Healthy || primary.Error == "" {
+		t.Errorf("primary = %+v, want an unreachable primary", primary)
+	}
+	if fallback := report.Hosts[1]; fallback.Role != "fallback" || !fallback.Healthy || fallback.Version != "1.2.3" {
+		t.Errorf("fallback = %+v, want a healthy fallback", fallback)
+	}
+}
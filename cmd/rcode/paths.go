@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/internal/pathcheck"
+)
+
+// absPath returns arg as the absolute, cleaned path sent to the server,
+// rejecting paths with control characters. With resolve_symlinks the
+// symlinks in it are replaced by their targets.
+func absPath(cfg *config.ClientConfig, arg string) (string, error) {
+	abs, err := filepath.Abs(arg)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path: %w", err)
+	}
+	return pathcheck.Check(abs, pathcheck.Options{ResolveSymlinks: cfg.ResolveSymlinks})
+}
+
+// createLocal creates the paths that are missing on this machine, for
+// --create in local mode, with the permissions of the create config
+func createLocal(cfg *config.ClientConfig, paths []string, dir bool) error {
+	for _, p := range paths {
+		created, err := pathcheck.Create(p, dir, cfg.Create.File(), cfg.Create.Dir())
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", p, err)
+		}
+		if created {
+			fmt.Printf("Created %s\n", p)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/internal/pathcheck"
+)
+
+func TestAbsPath(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := filepath.Join(dir, "target")
+	if err := os.Mkdir(target, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("cannot create symlinks: %v", err)
+	}
+
+	cfg := &config.ClientConfig{}
+	if got, err := absPath(cfg, link+"/./src/../main.go"); err != nil || got != filepath.Join(link, "main.go") {
+		t.Errorf("absPath() = %q, %v, want %q", got, err, filepath.Join(link, "main.go"))
+	}
+	if _, err := absPath(cfg, "main\x1b.go"); !errors.Is(err, pathcheck.ErrControlChar) {
+		t.Errorf("absPath() with a control character error = %v, want ErrControlChar", err)
+	}
+
+	cfg.ResolveSymlinks = true
+	if got, err := absPath(cfg, filepath.Join(link, "main.go")); err != nil || got != filepath.Join(target, "main.go") {
+		t.Errorf("absPath() with resolve_symlinks = %q, %v, want %q", got, err, filepath.Join(target, "main.go"))
+	}
+}
+
+func TestCreateLocal(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.ClientConfig{}
+	file := filepath.Join(dir, "src", "main.go")
+	sub := filepath.Join(dir, "docs")
+
+	if err := createLocal(cfg, []string{file}, false); err != nil {
+		t.Fatalf("createLocal() error = %v", err)
+	}
+	if info, err := os.Stat(file); err != nil || info.IsDir() {
+		t.Errorf("%s is not a file after createLocal: %v", file, err)
+	}
+	if err := createLocal(cfg, []string{sub, dir}, true); err != nil {
+		t.Fatalf("createLocal() error = %v", err)
+	}
+	if info, err := os.Stat(sub); err != nil || !info.IsDir() {
+		t.Errorf("%s is not a directory after createLocal: %v", sub, err)
+	}
+
+	// A file where a directory is missing cannot be created
+	if err := createLocal(cfg, []string{filepath.Join(file, "nested")}, true); err == nil {
+		t.Error("createLocal() under a file succeeded")
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// FilePosition is an optional cursor position within a file (1-based, 0 = unset)
+type FilePosition struct {
+	Line   int
+	Column int
+}
+
+// parsePathPosition splits "file:line" or "file:line:column" into its parts.
+// Arguments that exist on disk as-is are never split, so file names that
+// contain colons keep working.
+func parsePathPosition(arg string) (string, FilePosition) {
+	var pos FilePosition
+
+	if _, err := os.Stat(arg); err == nil {
+		return arg, pos
+	}
+
+	path := arg
+	numbers := make([]int, 0, 2)
+	for len(numbers) < 2 {
+		idx := strings.LastIndex(path, ":")
+		if idx <= 0 {
+			break
+		}
+		n, err := strconv.Atoi(path[idx+1:])
+		if err != nil || n <= 0 {
+			break
+		}
+		numbers = append(numbers, n)
+		path = path[:idx]
+	}
+
+	switch len(numbers) {
+	case 1:
+		pos.Line = numbers[0]
+	case 2:
+		pos.Line, pos.Column = numbers[1], numbers[0]
+	default:
+		return arg, pos
+	}
+
+	return path, pos
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParsePathPosition(t *testing.T) {
+	dir := t.TempDir()
+	colonFile := filepath.Join(dir, "notes:12")
+	if err := os.WriteFile(colonFile, nil, 0o600); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		arg      string
+		wantPath string
+		wantPos  FilePosition
+	}{
+		{"plain path", "main.go", "main.go", FilePosition{}},
+		{"line", "main.go:120", "main.go", FilePosition{Line: 120}},
+		{"line and column", "main.go:120:5", "main.go", FilePosition{Line: 120, Column: 5}},
+		{"absolute path", "/src/main.go:7:3", "/src/main.go", FilePosition{Line: 7, Column: 3}},
+		{"non-numeric suffix", "host:path", "host:path", FilePosition{}},
+		{"zero line", "main.go:0", "main.go:0", FilePosition{}},
+		{"only digits after colon", ":12", ":12", FilePosition{}},
+		{"existing file with colon", colonFile, colonFile, FilePosition{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotPath, gotPos := parsePathPosition(tt.arg)
+			if gotPath != tt.wantPath {
+				t.Errorf("parsePathPosition() path = %v, want %v", gotPath, tt.wantPath)
+			}
+			if gotPos != tt.wantPos {
+				t.Errorf("parsePathPosition() pos = %+v, want %+v", gotPos, tt.wantPos)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/projects"
+)
+
+// projectEditor returns the editor to open absPaths in: editorName when it
+// is given, otherwise the editor last used for the project of the first
+// path, or "" for the default editor
+func (oc *openContext) projectEditor(absPaths []string, editorName string) string {
+	if editorName != "" || oc.cfg.ForgetEditors || len(absPaths) == 0 {
+		return editorName
+	}
+	m, err := projects.Load(oc.cfg.ProjectsFile)
+	if err != nil {
+		oc.log.Debug("Failed to read project editors", "file", oc.cfg.ProjectsFile, "error", err)
+		return ""
+	}
+	if name := m.Editor(absPaths[0]); name != "" {
+		oc.log.Info("Using the editor last used for this project", "editor", name, "path", absPaths[0])
+		return name
+	}
+	return ""
+}
+
+// rememberEditor records that the project of the first of absPaths was
+// opened in editorName. Opens in the default editor are not recorded, so
+// changing default_editor still affects the projects never opened in
+// another editor.
+func (oc *openContext) rememberEditor(absPaths []string, editorName string) {
+	if editorName == "" || oc.cfg.ForgetEditors || len(absPaths) == 0 {
+		return
+	}
+	if err := projects.Remember(oc.cfg.ProjectsFile, absPaths[0], editorName, time.Now()); err != nil {
+		oc.log.Debug("Failed to record project editor", "file", oc.cfg.ProjectsFile, "error", err)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/foxytanuki/rcode/internal/config"
+)
+
+func TestProjectEditor(t *testing.T) {
+	repo := t.TempDir()
+	cfg := &config.ClientConfig{ProjectsFile: filepath.Join(t.TempDir(), "projects.json")}
+	oc := &openContext{cfg: cfg, log: createTestLogger()}
+	file := []string{filepath.Join(repo, "main.go")}
+
+	if got := oc.projectEditor(file, ""); got != "" {
+		t.Errorf("projectEditor() before any open = %q, want the default", got)
+	}
+
+	// Opens in the default editor are not remembered
+	oc.rememberEditor([]string{repo}, "")
+	if got := oc.projectEditor(file, ""); got != "" {
+		t.Errorf("projectEditor() after a default open = %q, want the default", got)
+	}
+
+	oc.rememberEditor([]string{repo}, "nvim")
+	if got := oc.projectEditor(file, ""); got != "nvim" {
+		t.Errorf("projectEditor() = %q, want nvim", got)
+	}
+	if got := oc.projectEditor(file, "cursor"); got != "cursor" {
+		t.Errorf("projectEditor() with --editor = %q, want cursor", got)
+	}
+
+	cfg.ForgetEditors = true
+	if got := oc.projectEditor(file, ""); got != "" {
+		t.Errorf("projectEditor() with forget_editors = %q, want the default", got)
+	}
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/foxytanuki/rcode/pkg/api"
+	"github.com/spf13/cobra"
+)
+
+// queueMaxAge is how long a queued open is kept; opening a path a day after
+// asking for it would be more surprising than useful
+const queueMaxAge = 24 * time.Hour
+
+// queueFlag queues the open when no host is reachable
+var queueFlag bool
+
+// queueRetry is how often "rcode queue flush --retry" tries again
+var queueRetry time.Duration
+
+var queueCmd = &cobra.Command{
+	Use:   "queue",
+	Short: "List opens queued while the host was unreachable",
+	Long: `"rcode --queue PATH" queues the open when no host answers, for instance
+while the laptop running rcode-server sleeps. Queued opens are delivered by
+the next successful open, or by "rcode queue flush". Opens queued more than
+a day ago are dropped.`,
+	Example: `  rcode --queue ~/project
+  rcode queue
+  rcode queue flush --retry 30s`,
+	Args: cobra.NoArgs,
+	RunE: runQueueList,
+}
+
+var queueFlushCmd = &cobra.Command{
+	Use:   "flush",
+	Short: "Deliver queued opens now",
+	Long: `Deliver the queued opens. With --retry, keep trying at that interval until
+every queued open is delivered or dropped, which can run in the background:
+
+  rcode queue flush --retry 30s &`,
+	Args: cobra.NoArgs,
+	RunE: runQueueFlush,
+}
+
+var queueClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Drop all queued opens",
+	Args:  cobra.NoArgs,
+	RunE:  runQueueClear,
+}
+
+// queuedOpen is an open request waiting for a reachable host
+type queuedOpen struct {
+	Request  api.OpenRequest `json:"request" yaml:"request"`
+	QueuedAt time.Time       `json:"queued_at" yaml:"queued_at"`
+}
+
+// Outcomes of a queued open during a flush
+const (
+	queueDelivered = "delivered"
+	queueFailed    = "failed"  // the server rejected it; dropped
+	queueExpired   = "expired" // queued more than queueMaxAge ago; dropped
+	queuePending   = "pending" // no host was reachable; kept
+)
+
+// queueResult is what happened to a queued open during a flush
+type queueResult struct {
+	Open   queuedOpen `json:"open" yaml:"open"`
+	Status string     `json:"status" yaml:"status"`
+	Editor string     `json:"editor,omitempty" yaml:"editor,omitempty"`
+	Error  string     `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+func runQueueList(_ *cobra.Command, _ []string) error {
+	cfg, err := loadClientConfig()
+	if err != nil {
+		return err
+	}
+	queued, err := readQueue(cfg.QueueFile)
+	if err != nil {
+		return err
+	}
+	if structuredOutput() {
+		if queued == nil {
+			queued = []queuedOpen{}
+		}
+		return writeStructured(os.Stdout, queued)
+	}
+
+	if len(queued) == 0 {
+		fmt.Println("No queued opens.")
+		return nil
+	}
+	for _, q := range queued {
+		fmt.Printf("  %s  %s (%s)\n", q.QueuedAt.Local().Format("2006-01-02 15:04"), queuedPaths(q), valueOrDash(q.Request.Editor))
+	}
+	return nil
+}
+
+func runQueueFlush(_ *cobra.Command, _ []string) error {
+	oc, err := newOpenContext()
+	if err != nil {
+		return err
+	}
+	defer oc.close()
+
+	var all []queueResult
+	for {
+		results := oc.flushQueue(time.Now())
+		pending := 0
+		for _, r := range results {
+			if r.Status == queuePending {
+				pending++
+			}
+		}
+		done := queueRetry <= 0 || pending == 0
+
+		// Structured output reports every outcome once, and the opens still
+		// pending after the last attempt
+		for _, r := range results {
+			if r.Status != queuePending || done {
+				all = append(all, r)
+			}
+		}
+		if !structuredOutput() {
+			printQueueResults(os.Stdout, results, done)
+		}
+		if done {
+			break
+		}
+		time.Sleep(queueRetry)
+	}
+
+	if structuredOutput() {
+		if all == nil {
+			all = []queueResult{}
+		}
+		return writeStructured(os.Stdout, all)
+	}
+	if len(all) == 0 {
+		fmt.Println("No queued opens.")
+	}
+	return nil
+}
+
+func runQueueClear(_ *cobra.Command, _ []string) error {
+	cfg, err := loadClientConfig()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(cfg.QueueFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to clear queue: %w", err)
+	}
+	fmt.Println("Cleared queued opens.")
+	return nil
+}
+
+// queueOpen queues an open that no host could be reached for
+func (oc *openContext) queueOpen(absPaths []string, pos FilePosition, editorName string) error {
+	req := oc.client.newOpenRequest(absPaths, pos, editorName, &oc.sshInfo)
+	req.Create = createFlag
+	if err := enqueue(oc.cfg.QueueFile, queuedOpen{Request: req, QueuedAt: time.Now()}); err != nil {
+		return fmt.Errorf("failed to queue open: %w", err)
+	}
+	oc.log.Info("Queued open for an unreachable host", "path", strings.Join(absPaths, " "), "file", oc.cfg.QueueFile)
+	fmt.Printf("No host is reachable; queued %s.\n", strings.Join(absPaths, " "))
+	fmt.Println(`It opens with the next successful rcode open, or run "rcode queue flush".`)
+	return nil
+}
+
+// flushQueue delivers the queued opens, keeping those no host could be
+// reached for. The queue file is claimed by renaming it first, so
+// concurrent flushes never deliver an open twice.
+func (oc *openContext) flushQueue(now time.Time) []queueResult {
+	path := oc.cfg.QueueFile
+	if path == "" {
+		return nil
+	}
+	claim := path + ".flushing-" + strconv.Itoa(os.Getpid())
+	if err := os.Rename(path, claim); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			oc.log.Warn("Failed to claim the open queue", "file", path, "error", err)
+		}
+		return nil
+	}
+	queued, err := readQueue(claim)
+	if err != nil {
+		oc.log.Warn("Failed to read the open queue; left it in place", "file", claim, "error", err)
+		return nil
+	}
+
+	results := make([]queueResult, 0, len(queued))
+	var pending []queuedOpen
+	unreachable := false
+	for _, q := range queued {
+		r := queueResult{Open: q}
+		switch {
+		case now.Sub(q.QueuedAt) > queueMaxAge:
+			r.Status = queueExpired
+		case unreachable:
+			// No need to wait for every host again
+			r.Status = queuePending
+		default:
+			req := q.Request
+			req.SetTimestamp()
+			opened, err := oc.client.SendOpen(req)
+			switch {
+			case err == nil:
+				r.Status, r.Editor = queueDelivered, opened.Editor
+				oc.recordHistory(req.AllPaths(), opened.Editor)
+			case isUnreachable(err):
+				r.Status, unreachable = queuePending, true
+			default:
+				r.Status, r.Error = queueFailed, err.Error()
+			}
+		}
+		if r.Status == queuePending {
+			pending = append(pending, q)
+		}
+		results = append(results, r)
+	}
+
+	if len(pending) > 0 {
+		// Opens queued meanwhile go after the older pending ones
+		err = updateQueue(path, func(queued []queuedOpen) []queuedOpen {
+			return append(pending, queued...)
+		})
+		if err != nil {
+			oc.log.Warn("Failed to keep pending opens; left them in place", "file", claim, "error", err)
+			return results
+		}
+	}
+	_ = os.Remove(claim)
+	return results
+}
+
+// flushAfterOpen delivers the queue once a host has answered
+func (oc *openContext) flushAfterOpen() {
+	printQueueResults(os.Stdout, oc.flushQueue(time.Now()), true)
+}
+
+// printQueueResults reports the outcome of a flush; showPending adds how
+// many opens are still queued
+func printQueueResults(w io.Writer, results []queueResult, showPending bool) {
+	pending := 0
+	for _, r := range results {
+		paths := queuedPaths(r.Open)
+		switch r.Status {
+		case queueDelivered:
+			fmt.Fprintf(w, "Delivered queued open of %s in %s (queued %s ago)\n", paths, r.Editor, time.Since(r.Open.QueuedAt).Round(time.Second))
+		case queueFailed:
+			fmt.Fprintf(w, "Failed to deliver queued open of %s: %s\n", paths, r.Error)
+		case queueExpired:
+			fmt.Fprintf(w, "Dropped queued open of %s, queued more than %s ago\n", paths, queueMaxAge)
+		case queuePending:
+			pending++
+		}
+	}
+	if showPending && pending > 0 {
+		fmt.Fprintf(w, "%d queued opens are still waiting for a reachable host\n", pending)
+	}
+}
+
+// queuedPaths returns the paths of a queued open for display
+func queuedPaths(q queuedOpen) string {
+	return strings.Join(q.Request.AllPaths(), " ")
+}
+
+// enqueue adds opens to the queue at path, replacing queued opens of the
+// same paths
+func enqueue(path string, opens ...queuedOpen) error {
+	return updateQueue(path, func(queued []queuedOpen) []queuedOpen {
+		for _, open := range opens {
+			queued = slices.DeleteFunc(queued, func(q queuedOpen) bool { return sameOpen(&q.Request, &open.Request) })
+			queued = append(queued, open)
+		}
+		return queued
+	})
+}
+
+// sameOpen reports whether two requests open the same paths the same way
+func sameOpen(a, b *api.OpenRequest) bool {
+	return slices.Equal(a.AllPaths(), b.AllPaths()) &&
+		a.Editor == b.Editor && a.User == b.User && a.Host == b.Host &&
+		a.Line == b.Line && a.Column == b.Column
+}
+
+// readQueue reads the queue at path; a missing file is an empty queue
+func readQueue(path string) ([]queuedOpen, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read queue: %w", err)
+	}
+	var queued []queuedOpen
+	if err := json.Unmarshal(data, &queued); err != nil {
+		return nil, fmt.Errorf("failed to parse queue %s: %w", path, err)
+	}
+	return queued, nil
+}
+
+// updateQueue rewrites the queue at path with what change makes of it,
+// removing the file when the queue ends up empty
+func updateQueue(path string, change func([]queuedOpen) []queuedOpen) error {
+	queued, err := readQueue(path)
+	if err != nil {
+		return err
+	}
+	queued = change(queued)
+	if len(queued) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(queued, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, ".queue-*.json")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+func TestEnqueue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rcode", "queue.json")
+	now := time.Now()
+
+	first := queuedOpen{Request: api.OpenRequest{Path: "/src/a", User: "u", Host: "h", Editor: "zed"}, QueuedAt: now.Add(-time.Hour)}
+	other := queuedOpen{Request: api.OpenRequest{Path: "/src/b", User: "u", Host: "h"}, QueuedAt: now.Add(-time.Minute)}
+	again := queuedOpen{Request: api.OpenRequest{Path: "/src/a", User: "u", Host: "h", Editor: "zed"}, QueuedAt: now}
+
+	for _, q := range []queuedOpen{first, other, again} {
+		if err := enqueue(path, q); err != nil {
+			t.Fatalf("enqueue() error = %v", err)
+		}
+	}
+
+	queued, err := readQueue(path)
+	if err != nil {
+		t.Fatalf("readQueue() error = %v", err)
+	}
+	// Queuing the same open again replaces it rather than opening it twice
+	if len(queued) != 2 {
+		t.Fatalf("queue has %d opens, want 2: %v", len(queued), queued)
+	}
+	if queued[0].Request.Path != "/src/b" || queued[1].Request.Path != "/src/a" || !queued[1].QueuedAt.Equal(again.QueuedAt) {
+		t.Errorf("queue = %+v, want /src/b then the latest /src/a", queued)
+	}
+
+	if err := updateQueue(path, func([]queuedOpen) []queuedOpen { return nil }); err != nil {
+		t.Fatalf("updateQueue() error = %v", err)
+	}
+	if queued, err := readQueue(path); err != nil || len(queued) != 0 {
+		t.Errorf("readQueue() after emptying = %v, %v, want an empty queue", queued, err)
+	}
+}
+
+func TestFlushQueue(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req api.OpenRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if req.Editor == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(api.NewErrorResponse(api.ErrEditorNotFound, api.CodeEditorNotFound, "missing"))
+			return
+		}
+		resp := api.OpenResponse{Success: true, Editor: "vscode"}
+		resp.SetTimestamp()
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	cfg := &config.ClientConfig{
+		Hosts: config.HostsConfig{
+			Server: config.ServerHostConfig{Primary: server.URL[len("http://"):]},
+		},
+		Network:     config.ClientNetworkConfig{Timeout: 2 * time.Second, RetryAttempts: 1},
+		QueueFile:   filepath.Join(dir, "queue.json"),
+		HistoryFile: filepath.Join(dir, "history.json"),
+	}
+	oc := &openContext{cfg: cfg, log: createTestLogger(), client: NewClient(cfg, createTestLogger())}
+
+	now := time.Now()
+	for _, q := range []queuedOpen{
+		{Request: api.OpenRequest{Path: "/src/a", User: "u", Host: "h"}, QueuedAt: now.Add(-time.Hour)},
+		{Request: api.OpenRequest{Path: "/src/b", User: "u", Host: "h", Editor: "missing"}, QueuedAt: now.Add(-time.Hour)},
+		{Request: api.OpenRequest{Path: "/src/c", User: "u", Host: "h"}, QueuedAt: now.Add(-2 * queueMaxAge)},
+	} {
+		if err := enqueue(cfg.QueueFile, q); err != nil {
+			t.Fatalf("enqueue() error = %v", err)
+		}
+	}
+
+	results := oc.flushQueue(now)
+	want := []string{queueDelivered, queueFailed, queueExpired}
+	if len(results) != len(want) {
+		t.Fatalf("flushQueue() = %d results, want %d: %+v", len(results), len(want), results)
+	}
+	for i, r := range results {
+		if r.Status != want[i] {
+			t.Errorf("%s: status = %q, want %q (%s)", r.Open.Request.Path, r.Status, want[i], r.Error)
+		}
+	}
+	if results[0].Editor != "vscode" {
+		t.Errorf("delivered editor = %q, want vscode", results[0].Editor)
+	}
+	if queued, _ := readQueue(cfg.QueueFile); len(queued) != 0 {
+		t.Errorf("queue after flush = %v, want it empty", queued)
+	}
+	if entries, _ := oc.historyMatches(nil); len(entries) != 1 || entries[0].Path != "/src/a" {
+		t.Errorf("history after flush = %v, want the delivered open", entries)
+	}
+
+	// Opens stay queued while no host answers
+	server.Close()
+	if err := enqueue(cfg.QueueFile, queuedOpen{Request: api.OpenRequest{Path: "/src/d", User: "u", Host: "h"}, QueuedAt: now}); err != nil {
+		t.Fatalf("enqueue() error = %v", err)
+	}
+	results = oc.flushQueue(now)
+	if len(results) != 1 || results[0].Status != queuePending {
+		t.Fatalf("flushQueue() with no server = %+v, want one pending open", results)
+	}
+	if queued, _ := readQueue(cfg.QueueFile); len(queued) != 1 || queued[0].Request.Path != "/src/d" {
+		t.Errorf("queue after failed flush = %v, want /src/d kept", queued)
+	}
+}
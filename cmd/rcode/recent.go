@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/foxytanuki/rcode/pkg/api"
+	"github.com/spf13/cobra"
+)
+
+// recentLimit is the number of sessions listed by "rcode recent"
+var recentLimit int
+
+var recentCmd = &cobra.Command{
+	Use:   "recent [number]",
+	Short: "List or re-open recently opened paths",
+	Long: `List the paths you recently opened through rcode-server, most recent first.
+Pass the number shown in the list to open that entry again.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRecent,
+}
+
+func runRecent(_ *cobra.Command, args []string) error {
+	oc, err := newOpenContext()
+	if err != nil {
+		return err
+	}
+	defer oc.close()
+
+	sessions, err := oc.client.FetchSessions(oc.sshInfo.User, recentLimit)
+	if err != nil {
+		return fmt.Errorf("failed to fetch recent sessions: %w", err)
+	}
+
+	if len(args) == 0 {
+		if structuredOutput() {
+			return writeStructured(os.Stdout, sessions)
+		}
+		printSessions(sessions.Sessions)
+		return nil
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 || n > len(sessions.Sessions) {
+		return fmt.Errorf("invalid session number %q (expected 1-%d)", args[0], len(sessions.Sessions))
+	}
+	selected := sessions.Sessions[n-1]
+
+	paths := selected.Paths
+	if len(paths) == 0 {
+		paths = []string{selected.Path}
+	}
+
+	editorName := editor
+	if editorName == "" {
+		editorName = selected.Editor
+	}
+
+	return oc.open(paths, FilePosition{}, editorName)
+}
+
+// printSessions prints a numbered list of sessions
+func printSessions(sessions []api.SessionInfo) {
+	if len(sessions) == 0 {
+		fmt.Println("No recent sessions.")
+		return
+	}
+
+	fmt.Println("Recent Sessions:")
+	fmt.Println("================")
+	for i, s := range sessions {
+		path := s.Path
+		if len(s.Paths) > 0 {
+			path = strings.Join(s.Paths, " ")
+		}
+		fmt.Printf("  %2d. %s\n", i+1, path)
+		fmt.Printf("      %s on %s, %s\n", s.Editor, s.Host, s.OpenedAt.Local().Format("2006-01-02 15:04"))
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+const (
+	// maxRetryDelay caps the exponential backoff between attempts
+	maxRetryDelay = 5 * time.Second
+	// retryJitter spreads each delay by up to this fraction either way so
+	// clients retrying together do not stay in step
+	retryJitter = 0.2
+)
+
+// retryPolicy decides whether and when a failed request is tried again
+type retryPolicy struct {
+	attempts  int              // total attempts, at least 1
+	baseDelay time.Duration    // delay before the second attempt, doubled after each retry
+	maxDelay  time.Duration    // upper bound for the doubled delay
+	jitter    float64          // fraction of the delay added or removed at random
+	retryable func(error) bool // reports whether an error is worth another attempt
+
+	sleep  func(time.Duration) // time.Sleep, replaced in tests
+	random func() float64      // rand.Float64, replaced in tests
+}
+
+// newRetryPolicy builds the policy for network settings: retry_attempts
+// attempts, starting retry_delay apart, retrying network failures only
+func newRetryPolicy(network config.ClientNetworkConfig) retryPolicy {
+	return retryPolicy{
+		attempts:  network.RetryAttempts,
+		baseDelay: network.RetryDelay,
+		maxDelay:  maxRetryDelay,
+		jitter:    retryJitter,
+		retryable: api.IsNetworkError,
+		sleep:     time.Sleep,
+		random:    rand.Float64, // #nosec G404 -- jitter does not need a secure source
+	}
+}
+
+// delay returns how long to wait before attempt (2 for the first retry)
+func (p retryPolicy) delay(attempt int) time.Duration {
+	d := p.baseDelay
+	for i := 2; i < attempt && d < p.maxDelay; i++ {
+		d *= 2
+	}
+	if p.maxDelay > 0 && d > p.maxDelay {
+		d = p.maxDelay
+	}
+	if p.jitter > 0 && p.random != nil {
+		d = time.Duration(float64(d) * (1 - p.jitter + 2*p.jitter*p.random()))
+	}
+	return d
+}
+
+// run calls fn until it succeeds, returns an error that is not retryable or
+// runs out of attempts, and returns the last error. fn is told the attempt
+// number and whether it is the last one.
+func (p retryPolicy) run(fn func(attempt int, last bool) error) error {
+	attempts := p.attempts
+	if attempts <= 0 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 && p.sleep != nil {
+			p.sleep(p.delay(attempt))
+		}
+		err = fn(attempt, attempt == attempts)
+		if err == nil || p.retryable == nil || !p.retryable(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// networkError wraps a failed round trip in the api network error it
+// amounts to, so the retry policy and callers can classify it
+func networkError(err error) error {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return fmt.Errorf("request failed: %w: %w", api.ErrTimeout, err)
+	}
+	return fmt.Errorf("request failed: %w: %w", api.ErrConnectionFailed, err)
+}
+
+// statusError classifies responses from an unreachable or overloaded server
+// (or a broker without an agent) as retryable; it returns nil for others
+func statusError(status int) error {
+	switch status {
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return fmt.Errorf("%w: server returned status %d", api.ErrServerDown, status)
+	case http.StatusGatewayTimeout:
+		return fmt.Errorf("%w: server returned status %d", api.ErrTimeout, status)
+	}
+	return nil
+}
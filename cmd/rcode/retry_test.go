@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := retryPolicy{baseDelay: 100 * time.Millisecond, maxDelay: time.Second}
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{2, 100 * time.Millisecond},
+		{3, 200 * time.Millisecond},
+		{4, 400 * time.Millisecond},
+		{5, 800 * time.Millisecond},
+		{6, time.Second},
+		{20, time.Second},
+	}
+	for _, tt := range tests {
+		if got := policy.delay(tt.attempt); got != tt.want {
+			t.Errorf("delay(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+
+	// Jitter stays within the configured fraction either way
+	policy.jitter = 0.2
+	for _, r := range []float64{0, 0.5, 1} {
+		policy.random = func() float64 { return r }
+		got := policy.delay(2)
+		if got < 80*time.Millisecond || got > 120*time.Millisecond {
+			t.Errorf("delay(2) with random %v = %v, want within 80ms..120ms", r, got)
+		}
+	}
+}
+
+func TestRetryPolicy_Run(t *testing.T) {
+	errDown := fmt.Errorf("wrapped: %w", api.ErrServerDown)
+	errRejected := errors.New("server error: unauthorized")
+
+	tests := []struct {
+		name      string
+		results   []error
+		wantCalls int
+		wantErr   error
+	}{
+		{"success", []error{nil}, 1, nil},
+		{"network error then success", []error{errDown, nil}, 2, nil},
+		{"gives up after attempts", []error{errDown, errDown, errDown, nil}, 3, api.ErrServerDown},
+		{"not retryable", []error{errRejected, nil}, 1, errRejected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var slept []time.Duration
+			policy := newRetryPolicy(config.ClientNetworkConfig{RetryAttempts: 3, RetryDelay: 10 * time.Millisecond})
+			policy.jitter = 0
+			policy.sleep = func(d time.Duration) { slept = append(slept, d) }
+
+			calls := 0
+			var lastFlags []bool
+			err := policy.run(func(attempt int, last bool) error {
+				calls++
+				if attempt != calls {
+					t.Errorf("attempt = %d on call %d", attempt, calls)
+				}
+				lastFlags = append(lastFlags, last)
+				return tt.results[calls-1]
+			})
+
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("run() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(slept) != calls-1 {
+				t.Errorf("slept %d times, want %d", len(slept), calls-1)
+			}
+			if tt.wantCalls == 3 && (slept[0] != 10*time.Millisecond || slept[1] != 20*time.Millisecond) {
+				t.Errorf("delays = %v, want [10ms 20ms]", slept)
+			}
+			if calls == 3 && !lastFlags[2] {
+				t.Error("third attempt was not marked as the last")
+			}
+		})
+	}
+}
+
+func TestErrorClassification(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		network bool
+	}{
+		{"connection refused", networkError(errors.New("dial tcp: connection refused")), true},
+		{"timeout", networkError(context.DeadlineExceeded), true},
+		{"bad gateway", statusError(http.StatusBadGateway), true},
+		{"service unavailable", statusError(http.StatusServiceUnavailable), true},
+		{"gateway timeout", statusError(http.StatusGatewayTimeout), true},
+	}
+	for _, tt := range tests {
+		if got := api.IsNetworkError(tt.err); got != tt.network {
+			t.Errorf("%s: IsNetworkError(%v) = %v, want %v", tt.name, tt.err, got, tt.network)
+		}
+	}
+
+	if !errors.Is(networkError(context.DeadlineExceeded), api.ErrTimeout) {
+		t.Error("deadline exceeded is not classified as a timeout")
+	}
+	for _, status := range []int{http.StatusOK, http.StatusBadRequest, http.StatusInternalServerError} {
+		if err := statusError(status); err != nil {
+			t.Errorf("statusError(%d) = %v, want nil", status, err)
+		}
+	}
+}
+
+func TestClient_RetriesShareIdempotencyKey(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get(api.HeaderIdempotencyKey))
+		if len(keys) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(api.OpenResponse{Success: true, Editor: "e"})
+	}))
+	defer server.Close()
+
+	cfg := &config.ClientConfig{
+		Hosts: config.HostsConfig{
+			Server: config.ServerHostConfig{Primary: server.URL[7:]},
+		},
+		Network: config.ClientNetworkConfig{
+			Timeout:       2 * time.Second,
+			RetryAttempts: 3,
+			RetryDelay:    time.Millisecond,
+		},
+	}
+	client := NewClient(cfg, createTestLogger())
+
+	if err := client.OpenEditor("/p", "e", &SSHInfo{User: "u", Host: "h"}); err != nil {
+		t.Fatalf("OpenEditor() error = %v", err)
+	}
+	if len(keys) != 2 || !api.ValidIdempotencyKey(keys[0]) || keys[0] != keys[1] {
+		t.Fatalf("Idempotency-Key of the attempts = %q, want one key sent twice", keys)
+	}
+
+	// Each open is a new request with its own key
+	if err := client.OpenEditor("/p", "e", &SSHInfo{User: "u", Host: "h"}); err != nil {
+		t.Fatalf("OpenEditor() error = %v", err)
+	}
+	if keys[2] == keys[0] {
+		t.Error("a second open reused the first open's Idempotency-Key")
+	}
+}
+
+func TestClient_DoesNotRetryServerErrors(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(api.NewErrorResponse(api.ErrEditorExecution, api.CodeEditorExecution, "exit status 1"))
+	}))
+	defer server.Close()
+
+	cfg := &config.ClientConfig{
+		Hosts: config.HostsConfig{
+			Server: config.ServerHostConfig{Primary: server.URL[7:]},
+		},
+		Network: config.ClientNetworkConfig{
+			Timeout:       2 * time.Second,
+			RetryAttempts: 3,
+			RetryDelay:    time.Millisecond,
+		},
+	}
+
+	err := NewClient(cfg, createTestLogger()).OpenEditor("/p", "e", &SSHInfo{User: "u", Host: "h"})
+	if err == nil {
+		t.Fatal("OpenEditor() error = nil, want the server's error")
+	}
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want 1: a server error response is final", attempts)
+	}
+}
@@ -57,8 +57,8 @@ func ExtractSSHInfo() (SSHInfo, error) {
 		info.User = os.Getenv("LOGNAME")
 	}
 
-	// Note: Host is NOT set here - it will be determined in main.go
-	// based on priority: config ssh_host > ClientIP > hostname > localhost
+	// Note: Host is NOT set here - resolveHosts picks it with the
+	// internal/network Resolver (flag, env, config, detection, ClientIP, hostname)
 
 	// Check if we're actually in an SSH session
 	if sshConnection == "" && os.Getenv("SSH_CLIENT") == "" && os.Getenv("SSH_TTY") == "" {
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/internal/stats"
+	"github.com/spf13/cobra"
+)
+
+// openStats describes the open made by this run, for the stats
+var openStats *stats.Open
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show local usage counts",
+	Long: `Show how often each command ran and failed, and for opens which editor was
+used, how the SSH host and the server were resolved, and which host
+answered, e.g. how often the fallback host was needed.
+
+Counting is off unless "stats: true" is set in the client config. The counts
+stay on this machine, in stats_file (default:
+~/.local/share/rcode/stats.json); export them with --output json.`,
+	Example: `  rcode stats
+  rcode stats --output json > rcode-stats.json`,
+	Args: cobra.NoArgs,
+	RunE: runStats,
+}
+
+var statsResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Remove the usage counts",
+	Args:  cobra.NoArgs,
+	RunE:  runStatsReset,
+}
+
+func runStats(_ *cobra.Command, _ []string) error {
+	cfg, err := loadClientConfig()
+	if err != nil {
+		return err
+	}
+	s, err := stats.Load(cfg.StatsFile)
+	if err != nil {
+		return err
+	}
+	if structuredOutput() {
+		return writeStructured(os.Stdout, s)
+	}
+
+	if !cfg.Stats {
+		fmt.Fprintln(os.Stderr, `Counting is off; set "stats: true" in the client config to turn it on.`)
+	}
+	printStats(os.Stdout, s)
+	return nil
+}
+
+func runStatsReset(_ *cobra.Command, _ []string) error {
+	cfg, err := loadClientConfig()
+	if err != nil {
+		return err
+	}
+	if err := stats.Reset(cfg.StatsFile); err != nil {
+		return err
+	}
+	fmt.Println("Removed the usage counts.")
+	return nil
+}
+
+// printStats writes s as text
+func printStats(w io.Writer, s *stats.Stats) {
+	if len(s.Commands) == 0 {
+		fmt.Fprintln(w, "No usage recorded.")
+		return
+	}
+	fmt.Fprintf(w, "Since %s\n", s.Since.Local().Format("2006-01-02 15:04"))
+
+	fmt.Fprintln(w, "\nCommands:")
+	names := make([]string, 0, len(s.Commands))
+	for name := range s.Commands {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := s.Commands[names[i]], s.Commands[names[j]]
+		if runs := a.Succeeded + a.Failed - b.Succeeded - b.Failed; runs != 0 {
+			return runs > 0
+		}
+		return names[i] < names[j]
+	})
+	for _, name := range names {
+		o := s.Commands[name]
+		fmt.Fprintf(w, "  %-20s %5d runs, %d failed\n", name, o.Succeeded+o.Failed, o.Failed)
+	}
+
+	for _, section := range []struct {
+		title  string
+		counts map[string]int
+	}{
+		{"Editors", s.Opens.Editors},
+		{"SSH host resolved from", s.Opens.SSHSources},
+		{"Server resolved from", s.Opens.ServerSources},
+		{"Answered by", s.Opens.Hosts},
+	} {
+		if len(section.counts) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s:\n", section.title)
+		printCounts(w, section.counts)
+	}
+}
+
+// printCounts writes counts, largest first
+func printCounts(w io.Writer, counts map[string]int) {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	for _, key := range keys {
+		fmt.Fprintf(w, "  %-20s %5d\n", key, counts[key])
+	}
+}
+
+// recordStats counts the command that ran, when stats are enabled.
+// Completion and the stats commands themselves are not counted.
+func recordStats(cmd *cobra.Command, runErr error) {
+	if cmd == nil || strings.HasPrefix(cmd.Name(), "__") || cmd == statsCmd || cmd.Parent() == statsCmd {
+		return
+	}
+	cfg, err := config.LoadClientConfig(configFile)
+	if err != nil || !cfg.Stats {
+		return
+	}
+
+	event := stats.Event{Command: statsCommandName(cmd), Failed: runErr != nil, Open: openStats}
+	// Best effort: a failure to count must not fail the command
+	_ = stats.Record(cfg.StatsFile, event, time.Now())
+}
+
+// statsCommandName names cmd without the program name; "rcode PATH" counts
+// as "open"
+func statsCommandName(cmd *cobra.Command) string {
+	if cmd == rootCmd {
+		return "open"
+	}
+	return strings.TrimPrefix(cmd.CommandPath(), rootCmd.Name()+" ")
+}
+
+// recordOpenStats notes the open made by this run
+func (oc *openContext) recordOpenStats(editorName, answeredBy string) {
+	openStats = &stats.Open{
+		Editor:       editorName,
+		SSHSource:    oc.resolved.Source,
+		ServerSource: oc.resolved.ServerSource,
+		Host:         answeredBy,
+	}
+}
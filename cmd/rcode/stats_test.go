@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/foxytanuki/rcode/internal/stats"
+)
+
+func TestRecordStats(t *testing.T) {
+	dir := t.TempDir()
+	statsFile := filepath.Join(dir, "stats.json")
+	path := filepath.Join(dir, "config.yaml")
+	data := fmt.Sprintf("stats: true\nstats_file: %q\nlogging:\n  file: %q\n", statsFile, filepath.Join(dir, "client.log"))
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	originalConfig := configFile
+	configFile = path
+	defer func() { configFile, openStats = originalConfig, nil }()
+
+	openStats = &stats.Open{Editor: "cursor", Host: "fallback"}
+	recordStats(rootCmd, nil)
+	openStats = nil
+	recordStats(editorsAddCmd, errors.New("unauthorized"))
+	recordStats(statsCmd, nil)
+
+	s, err := stats.Load(statsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o := s.Commands["open"]; o == nil || o.Succeeded != 1 {
+		t.Errorf("open = %+v, want one success", o)
+	}
+	if o := s.Commands["editors add"]; o == nil || o.Failed != 1 {
+		t.Errorf("editors add = %+v, want one failure", o)
+	}
+	if _, ok := s.Commands["stats"]; ok {
+		t.Error("the stats command counted itself")
+	}
+	if s.Opens.Hosts["fallback"] != 1 {
+		t.Errorf("Hosts = %v", s.Opens.Hosts)
+	}
+
+	var out bytes.Buffer
+	printStats(&out, s)
+	for _, want := range []string{"open", "1 runs, 0 failed", "editors add", "Answered by:", "fallback"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("printStats() missing %q:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/version"
+	"github.com/foxytanuki/rcode/pkg/api"
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the status of every configured server",
+	Long: `Query every configured server (primary, fallback and tunnel) and show its
+version, uptime, latency, readiness and editor availability in one view.
+Servers running a different release than this client are flagged.`,
+	Args: cobra.NoArgs,
+	RunE: runStatus,
+}
+
+// hostStatus is the state of one server address
+type hostStatus struct {
+	Role            string `json:"role" yaml:"role"` // primary, fallback or tunnel
+	Host            string `json:"host" yaml:"host"`
+	Reachable       bool   `json:"reachable" yaml:"reachable"`
+	Healthy         bool   `json:"healthy" yaml:"healthy"`
+	Version         string `json:"version,omitempty" yaml:"version,omitempty"`
+	Uptime          int64  `json:"uptime,omitempty" yaml:"uptime,omitempty"`         // Seconds
+	LatencyMS       int64  `json:"latency_ms,omitempty" yaml:"latency_ms,omitempty"` // Round trip of the health request
+	Skew            string `json:"version_skew,omitempty" yaml:"version_skew,omitempty"`
+	Protocol        int    `json:"protocol" yaml:"protocol"` // 0 for servers that predate protocol negotiation
+	ProtocolWarning string `json:"protocol_warning,omitempty" yaml:"protocol_warning,omitempty"`
+	Error           string `json:"error,omitempty" yaml:"error,omitempty"`
+
+	Components    []api.ComponentStatus `json:"components,omitempty" yaml:"components,omitempty"`
+	Editors       []api.EditorInfo      `json:"editors,omitempty" yaml:"editors,omitempty"`
+	DefaultEditor string                `json:"default_editor,omitempty" yaml:"default_editor,omitempty"`
+	EditorsError  string                `json:"editors_error,omitempty" yaml:"editors_error,omitempty"`
+}
+
+// statusReport is the state of the whole deployment as seen by this client
+type statusReport struct {
+	ClientVersion string       `json:"client_version" yaml:"client_version"`
+	Healthy       bool         `json:"healthy" yaml:"healthy"` // At least one server is healthy
+	Hosts         []hostStatus `json:"hosts" yaml:"hosts"`
+}
+
+func runStatus(_ *cobra.Command, _ []string) error {
+	cfg, err := loadClientConfig()
+	if err != nil {
+		return err
+	}
+
+	log := newQuietLogger()
+	defer func() {
+		if err := log.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to close logger: %v\n", err)
+		}
+	}()
+
+	report := NewClient(cfg, log).Status()
+	if structuredOutput() {
+		if err := writeStructured(os.Stdout, report); err != nil {
+			return err
+		}
+	} else {
+		report.print()
+	}
+
+	if !report.Healthy {
+		return fmt.Errorf("no healthy hosts found")
+	}
+	return nil
+}
+
+// Status queries every configured server address. Unlike Health it does not
+// stop at the first healthy host.
+func (c *Client) Status() *statusReport {
+	report := &statusReport{ClientVersion: version.Version}
+	for _, t := range c.hostTargets() {
+		if t.host == "" || t.role == "broker" {
+			continue
+		}
+		status := c.hostStatus(t)
+		if status.Healthy {
+			report.Healthy = true
+		}
+		report.Hosts = append(report.Hosts, status)
+	}
+	return report
+}
+
+// hostStatus queries the readiness and editors of one server address
+func (c *Client) hostStatus(t hostTarget) hostStatus {
+	status := hostStatus{Role: t.role, Host: t.host}
+
+	start := time.Now()
+	health, err := c.fetchReadiness(t.host)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	status.LatencyMS = time.Since(start).Milliseconds()
+	status.Reachable = true
+	status.Healthy = health.IsHealthy()
+	status.Version = health.Version
+	status.Uptime = health.Uptime
+	status.Components = health.Components
+	status.Skew = versionSkew(version.Version, health.Version)
+	status.Protocol, _ = c.serverProtocol(t.host)
+	status.ProtocolWarning = api.ProtocolWarning(status.Protocol)
+
+	editors, err := c.fetchEditors(t.host)
+	if err != nil {
+		status.EditorsError = err.Error()
+		return status
+	}
+	status.Editors = editors.Editors
+	status.DefaultEditor = editors.DefaultEditor
+	return status
+}
+
+// print writes the report in a human-readable format
+func (r *statusReport) print() {
+	fmt.Printf("rcode %s (protocol %d)\n", r.ClientVersion, api.ProtocolVersion)
+	if len(r.Hosts) == 0 {
+		fmt.Println("No servers configured.")
+		return
+	}
+
+	for _, h := range r.Hosts {
+		fmt.Println()
+		fmt.Printf("%s (%s)\n", healthRoleLabels[h.Role], h.Host)
+		if !h.Reachable {
+			fmt.Printf("  Status:  unreachable: %s\n", h.Error)
+			continue
+		}
+
+		state := "healthy"
+		if !h.Healthy {
+			state = "not ready"
+		}
+		fmt.Printf("  Status:  %s\n", state)
+		fmt.Printf("  Version: %s\n", h.Version)
+		if h.Skew != "" {
+			fmt.Printf("  WARNING: %s\n", h.Skew)
+		}
+		if h.ProtocolWarning != "" {
+			fmt.Printf("  WARNING: %s\n", h.ProtocolWarning)
+		}
+		fmt.Printf("  Uptime:  %s\n", time.Duration(h.Uptime)*time.Second)
+		fmt.Printf("  Latency: %dms\n", h.LatencyMS)
+		for _, comp := range h.Components {
+			if !comp.Healthy {
+				fmt.Printf("  FAIL %s: %s\n", comp.Name, comp.Message)
+			}
+		}
+
+		if h.EditorsError != "" {
+			fmt.Printf("  Editors: failed to list: %s\n", h.EditorsError)
+			continue
+		}
+		available := 0
+		for _, e := range h.Editors {
+			if e.Available {
+				available++
+			}
+		}
+		fmt.Printf("  Editors: %d of %d available\n", available, len(h.Editors))
+		for _, e := range h.Editors {
+			var notes []string
+			if e.Name == h.DefaultEditor || e.Default {
+				notes = append(notes, "default")
+			}
+			if !e.Available {
+				notes = append(notes, "unavailable")
+			}
+			if len(notes) > 0 {
+				fmt.Printf("    %s (%s)\n", e.Name, strings.Join(notes, ", "))
+			} else {
+				fmt.Printf("    %s\n", e.Name)
+			}
+		}
+	}
+}
+
+// versionSkew describes how server's release differs from client's, or
+// returns "" when they match or either version is not a release number
+func versionSkew(client, server string) string {
+	cmp, ok := compareReleases(server, client)
+	switch {
+	case !ok || cmp == 0:
+		return ""
+	case cmp < 0:
+		return fmt.Sprintf("server %s is older than client %s", server, client)
+	default:
+		return fmt.Sprintf("server %s is newer than client %s", server, client)
+	}
+}
+
+// compareReleases returns -1, 0 or 1 as release a is older than, the same as
+// or newer than release b. ok is false when either is not a release number.
+func compareReleases(a, b string) (cmp int, ok bool) {
+	av, ok := parseRelease(a)
+	if !ok {
+		return 0, false
+	}
+	bv, ok := parseRelease(b)
+	if !ok {
+		return 0, false
+	}
+
+	for i := range av {
+		switch {
+		case av[i] < bv[i]:
+			return -1, true
+		case av[i] > bv[i]:
+			return 1, true
+		}
+	}
+	return 0, true
+}
+
+// parseRelease parses the major, minor and patch numbers of a version such as
+// "v0.3.1" or "v0.3.1-3-g1234567"
+func parseRelease(v string) ([3]int, bool) {
+	var release [3]int
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+
+	parts := strings.Split(v, ".")
+	if len(parts) != len(release) {
+		return release, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return release, false
+		}
+		release[i] = n
+	}
+	return release, true
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+func TestClient_Status(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/readyz":
+			_ = json.NewEncoder(w).Encode(api.HealthResponse{
+				Status:     api.StatusHealthy,
+				Version:    "v0.0.1",
+				Uptime:     90,
+				Components: []api.ComponentStatus{{Name: "editors", Healthy: true, Message: "1 available"}},
+			})
+		case "/editors":
+			_ = json.NewEncoder(w).Encode(api.EditorsResponse{
+				Editors: []api.EditorInfo{
+					{Name: "cursor", Available: true, Default: true},
+					{Name: "zed"},
+				},
+				DefaultEditor: "cursor",
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	// Nothing listens on the fallback address
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	cfg := &config.ClientConfig{
+		Hosts: config.HostsConfig{
+			Server: config.ServerHostConfig{Primary: server.URL[7:], Fallback: unreachable.URL[7:]},
+		},
+		Network: config.ClientNetworkConfig{Timeout: 2 * time.Second},
+	}
+
+	report := NewClient(cfg, createTestLogger()).Status()
+	if !report.Healthy || len(report.Hosts) != 2 {
+		t.Fatalf("Status() = %+v, want both hosts with one healthy", report)
+	}
+
+	primary, fallback := report.Hosts[0], report.Hosts[1]
+	if !primary.Healthy || primary.Version != "v0.0.1" || primary.Uptime != 90 {
+		t.Errorf("primary = %+v, want healthy v0.0.1 up 90s", primary)
+	}
+	if primary.Skew == "" {
+		t.Error("primary version skew is empty, want a warning for an older server")
+	}
+	if primary.Protocol != api.ProtocolUnknown || primary.ProtocolWarning == "" {
+		t.Errorf("primary protocol = %d (%q), want a warning for a server without %s",
+			primary.Protocol, primary.ProtocolWarning, api.HeaderProtocol)
+	}
+	if len(primary.Editors) != 2 || primary.DefaultEditor != "cursor" || len(primary.Components) != 1 {
+		t.Errorf("primary editors = %+v, components = %+v", primary.Editors, primary.Components)
+	}
+	if fallback.Reachable || fallback.Error == "" {
+		t.Errorf("fallback = %+v, want unreachable with an error", fallback)
+	}
+}
+
+func TestVersionSkew(t *testing.T) {
+	tests := []struct {
+		client, server string
+		want           string
+	}{
+		{"v0.3.5", "v0.3.5", ""},
+		{"v0.3.5", "v0.3.5-3-g1234567", ""},
+		{"v0.3.5", "v0.2.9", "server v0.2.9 is older than client v0.3.5"},
+		{"v0.3.5", "v1.0.0", "server v1.0.0 is newer than client v0.3.5"},
+		{"v0.3.5", "0.3.6", "server 0.3.6 is newer than client v0.3.5"},
+		{"v0.3.5", "dev", ""},
+		{"1234567", "v0.3.5", ""},
+	}
+
+	for _, tt := range tests {
+		if got := versionSkew(tt.client, tt.server); got != tt.want {
+			t.Errorf("versionSkew(%q, %q) = %q, want %q", tt.client, tt.server, got, tt.want)
+		}
+	}
+}
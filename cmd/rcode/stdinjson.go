@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+// stdinJSON is the value of the --stdin-json flag
+var stdinJSON bool
+
+// Actions a --stdin-json request may ask for
+const (
+	stdinActionOpen   = "open"
+	stdinActionRender = "render"
+)
+
+// stdinRequest is the JSON request "rcode --stdin-json" reads from stdin
+type stdinRequest struct {
+	Action string   `json:"action,omitempty"` // open (default) or render
+	Path   string   `json:"path,omitempty"`   // Path to open; relative to the working directory
+	Paths  []string `json:"paths,omitempty"`  // Several paths to open in one editor
+	Line   int      `json:"line,omitempty"`
+	Column int      `json:"column,omitempty"`
+	Editor string   `json:"editor,omitempty"` // Editor to use (default: the configured one)
+}
+
+// stdinResponse is the JSON response "rcode --stdin-json" writes to stdout
+type stdinResponse struct {
+	Success   bool        `json:"success"`
+	Action    string      `json:"action"`
+	Paths     []string    `json:"paths,omitempty"`
+	Editor    string      `json:"editor,omitempty"`
+	Command   string      `json:"command,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+	Error     *stdinError `json:"error,omitempty"`
+}
+
+// stdinError describes a failed --stdin-json request
+type stdinError struct {
+	Message string `json:"message"`
+	Code    string `json:"code"`              // api error code, e.g. EDITOR_NOT_FOUND
+	Details string `json:"details,omitempty"` // Server details, such as the editor's output
+}
+
+// errStdinFailed makes rcode exit non-zero after a failure was written as JSON
+var errStdinFailed = errors.New("request failed")
+
+// runStdinJSON answers one JSON request from in with one JSON response on
+// out. Nothing else is written to stdout or stderr, so editor plugins can
+// parse the result; failures also make rcode exit with status 1.
+func runStdinJSON(in io.Reader, out io.Writer) error {
+	var req stdinRequest
+	dec := json.NewDecoder(in)
+	dec.DisallowUnknownFields()
+	resp := stdinResponse{Action: stdinActionOpen}
+	if err := dec.Decode(&req); err != nil {
+		resp.Error = &stdinError{Message: fmt.Sprintf("invalid request: %v", err), Code: api.CodeInvalidRequest}
+		return writeStdinResponse(out, &resp)
+	}
+
+	oc, err := newOpenContext()
+	if err != nil {
+		resp.Error = &stdinError{Message: err.Error(), Code: api.CodeInternalError}
+		return writeStdinResponse(out, &resp)
+	}
+	defer oc.close()
+
+	resp = oc.client.answerStdin(req, &oc.sshInfo)
+	return writeStdinResponse(out, &resp)
+}
+
+// writeStdinResponse writes resp and returns errStdinFailed when it reports
+// a failure
+func writeStdinResponse(out io.Writer, resp *stdinResponse) error {
+	if err := json.NewEncoder(out).Encode(resp); err != nil {
+		return fmt.Errorf("failed to encode response: %w", err)
+	}
+	if !resp.Success {
+		return errStdinFailed
+	}
+	return nil
+}
+
+// answerStdin carries out a --stdin-json request through the server
+func (c *Client) answerStdin(req stdinRequest, sshInfo *SSHInfo) stdinResponse {
+	resp := stdinResponse{Action: req.Action}
+	if resp.Action == "" {
+		resp.Action = stdinActionOpen
+	}
+
+	paths := req.Paths
+	if len(paths) == 0 && req.Path != "" {
+		paths = []string{req.Path}
+	}
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	for i, p := range paths {
+		abs, err := absPath(c.config, p)
+		if err != nil {
+			resp.Error = &stdinError{Message: err.Error(), Code: api.CodeInvalidPath}
+			return resp
+		}
+		paths[i] = abs
+	}
+	resp.Paths = paths
+	pos := FilePosition{Line: req.Line, Column: req.Column}
+
+	switch resp.Action {
+	case stdinActionOpen:
+		opened, err := c.Open(paths, pos, req.Editor, sshInfo)
+		if err != nil {
+			resp.Error = newStdinError(err)
+			return resp
+		}
+		resp.Editor, resp.Command, resp.RequestID = opened.Editor, opened.Command, opened.RequestID
+	case stdinActionRender:
+		rendered, err := c.RenderCommand(paths, pos, req.Editor, sshInfo)
+		if err != nil {
+			resp.Error = newStdinError(err)
+			return resp
+		}
+		resp.Editor, resp.Command = rendered.Editor, rendered.Command
+	default:
+		resp.Error = &stdinError{
+			Message: fmt.Sprintf("unknown action %q (must be open or render)", resp.Action),
+			Code:    api.CodeInvalidRequest,
+		}
+		return resp
+	}
+
+	resp.Success = true
+	return resp
+}
+
+// newStdinError describes err with the server's error code when the server
+// answered, or the code of the client-side failure otherwise
+func newStdinError(err error) *stdinError {
+	var errResp *api.ErrorResponse
+	if errors.As(err, &errResp) {
+		return &stdinError{Message: err.Error(), Code: errResp.Code, Details: errResp.Details}
+	}
+	return &stdinError{Message: err.Error(), Code: api.GetErrorCode(err)}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+func TestClient_AnswerStdin(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req api.OpenRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if req.Editor == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(api.NewErrorResponse(api.ErrEditorNotFound, api.CodeEditorNotFound, "missing"))
+			return
+		}
+		switch r.URL.Path {
+		case "/open-editor":
+			_ = json.NewEncoder(w).Encode(api.OpenResponse{Success: true, Editor: "nvim", Command: "nvim " + req.Path, RequestID: "abc"})
+		case "/render":
+			_ = json.NewEncoder(w).Encode(api.RenderResponse{Editor: "nvim", Command: "nvim " + req.Path})
+		}
+	}))
+	defer server.Close()
+
+	cfg := &config.ClientConfig{
+		Hosts:   config.HostsConfig{Server: config.ServerHostConfig{Primary: server.URL[7:]}},
+		Network: config.ClientNetworkConfig{Timeout: 2 * time.Second, RetryAttempts: 1},
+	}
+	client := NewClient(cfg, createTestLogger())
+	sshInfo := SSHInfo{User: "testuser", Host: "testhost"}
+
+	tests := []struct {
+		name        string
+		req         stdinRequest
+		wantSuccess bool
+		wantCode    string
+		wantCommand string
+	}{
+		{"open", stdinRequest{Path: "/src/main.go", Line: 3}, true, "", "nvim /src/main.go"},
+		{"render", stdinRequest{Action: "render", Paths: []string{"/src"}}, true, "", "nvim /src"},
+		{"server error", stdinRequest{Path: "/src", Editor: "missing"}, false, api.CodeEditorNotFound, ""},
+		{"unknown action", stdinRequest{Action: "close", Path: "/src"}, false, api.CodeInvalidRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := client.answerStdin(tt.req, &sshInfo)
+			if resp.Success != tt.wantSuccess || resp.Command != tt.wantCommand {
+				t.Errorf("answerStdin() = %+v, want success %v with command %q", resp, tt.wantSuccess, tt.wantCommand)
+			}
+			if tt.wantCode != "" && (resp.Error == nil || resp.Error.Code != tt.wantCode) {
+				t.Errorf("answerStdin() error = %+v, want code %s", resp.Error, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestRunStdinJSONInvalidRequest(t *testing.T) {
+	var out bytes.Buffer
+	err := runStdinJSON(strings.NewReader(`{"path": "/src", "unknown": true}`), &out)
+	if !errors.Is(err, errStdinFailed) {
+		t.Errorf("runStdinJSON() error = %v, want errStdinFailed", err)
+	}
+
+	var resp stdinResponse
+	if err := json.Unmarshal(out.Bytes(), &resp); err != nil {
+		t.Fatalf("response %q is not JSON: %v", out.String(), err)
+	}
+	if resp.Success || resp.Error == nil || resp.Error.Code != api.CodeInvalidRequest {
+		t.Errorf("response = %+v, want an INVALID_REQUEST error", resp)
+	}
+}
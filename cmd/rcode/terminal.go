@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+// terminalName is the terminal requested with --terminal
+var terminalName string
+
+var terminalCmd = &cobra.Command{
+	Use:   "terminal [path]",
+	Short: "Open a terminal on the host at a remote path",
+	Long: `Open a terminal on the host machine running an SSH session to this machine,
+starting in the given directory (default: the current one). A file opens the
+directory that holds it.
+
+The server uses the terminals listed in its config, or else the first of
+Terminal.app, WezTerm, Alacritty, kitty and GNOME Terminal installed there.`,
+	Example: `  rcode terminal
+  rcode terminal ~/project --terminal wezterm`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runTerminal,
+}
+
+func runTerminal(_ *cobra.Command, args []string) error {
+	dir := "."
+	if len(args) == 1 {
+		dir = args[0]
+	}
+
+	oc, err := newOpenContext()
+	if err != nil {
+		return err
+	}
+	defer oc.close()
+
+	dir, err = absPath(oc.cfg, dir)
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+
+	oc.log.Info("Opening terminal",
+		"path", dir,
+		"terminal", terminalName,
+		"user", oc.sshInfo.User,
+		"host", oc.sshInfo.Host,
+		"server", oc.cfg.Hosts.Server.Primary,
+	)
+
+	opened, err := oc.client.OpenTerminal(dir, terminalName, &oc.sshInfo)
+	if err != nil {
+		if isUnreachable(err) {
+			fmt.Fprintf(os.Stderr, "%s", tunnelHint(oc.cfg, &oc.sshInfo))
+		}
+		return fmt.Errorf("failed to open terminal: %w", err)
+	}
+	if structuredOutput() {
+		return writeStructured(os.Stdout, opened)
+	}
+	fmt.Printf("Opened %s at %s on the host\n", opened.Editor, dir)
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/foxytanuki/rcode/internal/config"
+)
+
+// isUnreachable reports whether err means no server answered at all, as
+// opposed to a server that rejected the request
+func isUnreachable(err error) bool {
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// tunnelHint explains how to reach rcode-server through the current SSH
+// connection with a reverse tunnel
+func tunnelHint(cfg *config.ClientConfig, sshInfo *SSHInfo) string {
+	port := strconv.Itoa(config.DefaultServerPort)
+	if addr, err := serverAddr(cfg.Hosts.Server.Primary, cfg.Hosts.Server.Port); err == nil {
+		if _, p, err := net.SplitHostPort(addr); err == nil {
+			port = p
+		}
+	}
+
+	dest := "<this-machine>"
+	if sshInfo != nil && sshInfo.Host != "" {
+		dest = sshInfo.Host
+		if sshInfo.User != "" {
+			dest = sshInfo.User + "@" + dest
+		}
+	}
+
+	var b strings.Builder
+	if cfg.Hosts.Server.Tunnel != "" {
+		fmt.Fprintf(&b, "The SSH tunnel at %s is not up either.\n", cfg.Hosts.Server.Tunnel)
+	} else {
+		b.WriteString("rcode-server is not reachable from this machine.\n")
+	}
+	b.WriteString("You can reach it through SSH instead. On the host machine, run:\n")
+	fmt.Fprintf(&b, "  rcode-server tunnel %s\n", dest)
+	b.WriteString("or connect with a reverse forward:\n")
+	fmt.Fprintf(&b, "  ssh -R 127.0.0.1:%s:127.0.0.1:%s %s\n", port, port, dest)
+	if cfg.Hosts.Server.Tunnel == "" {
+		fmt.Fprintf(&b, "then set hosts.server.tunnel: \"localhost:%s\" in the client configuration.\n", port)
+	}
+	return b.String()
+}
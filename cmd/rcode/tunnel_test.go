@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+// closedAddress returns a loopback address with nothing listening on it
+func closedAddress(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	addr := ln.Addr().String()
+	if err := ln.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return addr
+}
+
+func TestClient_OpenEditor_ViaTunnel(t *testing.T) {
+	tunnelUsed := false
+	tunnel := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		tunnelUsed = true
+		resp := api.OpenResponse{Success: true, Editor: "test-editor"}
+		resp.SetTimestamp()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer tunnel.Close()
+
+	cfg := &config.ClientConfig{
+		Hosts: config.HostsConfig{
+			Server: config.ServerHostConfig{
+				Primary: closedAddress(t),
+				Tunnel:  tunnel.URL[7:],
+			},
+		},
+		Network: config.ClientNetworkConfig{
+			Timeout:       2 * time.Second,
+			RetryAttempts: 1,
+		},
+		DefaultEditor: "test-editor",
+	}
+
+	client := NewClient(cfg, createTestLogger())
+	if err := client.OpenEditor("/test/path", "", &SSHInfo{User: "u", Host: "h"}); err != nil {
+		t.Fatalf("OpenEditor() error = %v, want nil", err)
+	}
+	if !tunnelUsed {
+		t.Error("Tunnel was not used")
+	}
+}
+
+func TestIsUnreachable(t *testing.T) {
+	unreachable := &config.ClientConfig{
+		Hosts: config.HostsConfig{Server: config.ServerHostConfig{Primary: closedAddress(t)}},
+		Network: config.ClientNetworkConfig{
+			Timeout:       2 * time.Second,
+			RetryAttempts: 1,
+		},
+	}
+	err := NewClient(unreachable, createTestLogger()).OpenEditor("/p", "e", &SSHInfo{User: "u", Host: "h"})
+	if err == nil || !isUnreachable(err) {
+		t.Errorf("isUnreachable(%v) = false, want true", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+	rejected := *unreachable
+	rejected.Hosts.Server.Primary = server.URL[7:]
+	err = NewClient(&rejected, createTestLogger()).OpenEditor("/p", "e", &SSHInfo{User: "u", Host: "h"})
+	if err == nil || isUnreachable(err) {
+		t.Errorf("isUnreachable(%v) = true, want false", err)
+	}
+}
+
+func TestTunnelHint(t *testing.T) {
+	tests := []struct {
+		name    string
+		primary string
+		tunnel  string
+		sshInfo *SSHInfo
+		want    []string
+	}{
+		{
+			name:    "default port",
+			primary: "192.168.1.10",
+			sshInfo: &SSHInfo{User: "alice", Host: "devbox"},
+			want: []string{
+				"rcode-server tunnel alice@devbox\n",
+				"ssh -R 127.0.0.1:3339:127.0.0.1:3339 alice@devbox\n",
+				`hosts.server.tunnel: "localhost:3339"`,
+			},
+		},
+		{
+			name:    "custom port and tunnel down",
+			primary: "192.168.1.10:4000",
+			tunnel:  "localhost:4000",
+			sshInfo: &SSHInfo{},
+			want: []string{
+				"The SSH tunnel at localhost:4000 is not up",
+				"ssh -R 127.0.0.1:4000:127.0.0.1:4000 <this-machine>\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.ClientConfig{
+				Hosts: config.HostsConfig{Server: config.ServerHostConfig{Primary: tt.primary, Tunnel: tt.tunnel}},
+			}
+			got := tunnelHint(cfg, tt.sshInfo)
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("tunnelHint() = %q, want it to contain %q", got, want)
+				}
+			}
+		})
+	}
+}
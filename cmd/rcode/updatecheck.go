@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/internal/version"
+	"github.com/spf13/cobra"
+)
+
+// latestReleaseURL is the GitHub API endpoint for the newest rcode release.
+// Tests point it at a local server.
+var latestReleaseURL = "https://api.github.com/repos/foxytanuki/rcode/releases/latest"
+
+// updateCheckInterval is how often the latest release is looked up
+const updateCheckInterval = 24 * time.Hour
+
+// updateCheckTimeout bounds the release lookup, which the command waits for
+// at the end at most once per updateCheckInterval
+const updateCheckTimeout = 1500 * time.Millisecond
+
+// updates is the update check of the running command, nil when disabled
+var updates *updateCheck
+
+// updateCache records the last release lookup
+type updateCache struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Latest    string    `json:"latest,omitempty"`
+}
+
+// updateCheck compares the running version with the latest release. The
+// lookup runs in the background while the command works; its result is
+// cached so it happens at most once a day.
+type updateCheck struct {
+	current string
+	latest  string
+	done    chan struct{} // closed when the lookup ends; nil when none runs
+}
+
+// startUpdateCheck starts the update check when update_check is enabled in
+// the client configuration. Commands that produce machine-readable output,
+// including --stdin-json, or serve shell completion are left alone.
+func startUpdateCheck(cmd *cobra.Command) {
+	if strings.HasPrefix(cmd.Name(), "__") || structuredOutput() || stdinJSON {
+		return
+	}
+	cfg, err := config.LoadClientConfig(configFile)
+	if err != nil || !cfg.UpdateCheck {
+		return
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return
+	}
+	updates = newUpdateCheck(filepath.Join(dir, "rcode", "update.json"), version.Version, time.Now())
+}
+
+// printUpdateHint prints a one-line upgrade hint after a successful command
+func printUpdateHint(_ *cobra.Command, _ []string) {
+	if hint := updates.hint(); hint != "" {
+		fmt.Fprintln(os.Stderr, hint)
+	}
+}
+
+// newUpdateCheck reads the cached lookup and starts a new one in the
+// background when the cache is older than updateCheckInterval
+func newUpdateCheck(cachePath, current string, now time.Time) *updateCheck {
+	u := &updateCheck{current: current}
+
+	var cache updateCache
+	if data, err := os.ReadFile(filepath.Clean(cachePath)); err == nil {
+		_ = json.Unmarshal(data, &cache)
+	}
+	u.latest = cache.Latest
+	if now.Sub(cache.CheckedAt) < updateCheckInterval {
+		return u
+	}
+
+	u.done = make(chan struct{})
+	go func() {
+		defer close(u.done)
+		if latest, err := fetchLatestRelease(); err == nil {
+			u.latest = latest
+		}
+		// Failed lookups are cached too, so an offline machine does not
+		// retry on every command
+		data, err := json.Marshal(updateCache{CheckedAt: now, Latest: u.latest})
+		if err != nil {
+			return
+		}
+		if err := os.MkdirAll(filepath.Dir(cachePath), 0o750); err != nil {
+			return
+		}
+		_ = os.WriteFile(cachePath, data, 0o600)
+	}()
+	return u
+}
+
+// hint waits for a running lookup and returns the upgrade hint, or "" when
+// the running version is current or not a release build
+func (u *updateCheck) hint() string {
+	if u == nil {
+		return ""
+	}
+	if u.done != nil {
+		<-u.done
+	}
+	if cmp, ok := compareReleases(u.latest, u.current); !ok || cmp <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("A new version of rcode is available: %s (current %s). See https://github.com/foxytanuki/rcode/releases",
+		u.latest, u.current)
+}
+
+// fetchLatestRelease returns the tag of the latest release
+func fetchLatestRelease() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), updateCheckTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, latestReleaseURL, http.NoBody)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", fmt.Sprintf("rcode/%s", version.Version))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("release lookup returned status %d", resp.StatusCode)
+	}
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return "", fmt.Errorf("failed to decode release: %w", err)
+	}
+	return release.TagName, nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestUpdateCheck(t *testing.T) {
+	var lookups atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		lookups.Add(1)
+		_, _ = w.Write([]byte(`{"tag_name": "v0.4.0"}`))
+	}))
+	defer server.Close()
+
+	originalURL := latestReleaseURL
+	latestReleaseURL = server.URL
+	defer func() { latestReleaseURL = originalURL }()
+
+	cachePath := filepath.Join(t.TempDir(), "update.json")
+	now := time.Now()
+
+	hint := newUpdateCheck(cachePath, "v0.3.5", now).hint()
+	if !strings.Contains(hint, "v0.4.0") || !strings.Contains(hint, "v0.3.5") {
+		t.Errorf("hint() = %q, want a hint naming v0.4.0 and v0.3.5", hint)
+	}
+
+	// Within a day the cached result is used without a lookup
+	if hint := newUpdateCheck(cachePath, "v0.3.5", now.Add(time.Hour)).hint(); hint == "" {
+		t.Error("hint() from cache is empty, want the cached upgrade hint")
+	}
+	if got := lookups.Load(); got != 1 {
+		t.Errorf("lookups = %d, want 1", got)
+	}
+
+	// A day later the release is looked up again
+	newUpdateCheck(cachePath, "v0.3.5", now.Add(25*time.Hour)).hint()
+	if got := lookups.Load(); got != 2 {
+		t.Errorf("lookups after a day = %d, want 2", got)
+	}
+
+	for _, current := range []string{"v0.4.0", "v0.5.0", "1234567"} {
+		if hint := newUpdateCheck(cachePath, current, now.Add(26*time.Hour)).hint(); hint != "" {
+			t.Errorf("hint() for %s = %q, want none", current, hint)
+		}
+	}
+
+	var nilCheck *updateCheck
+	if hint := nilCheck.hint(); hint != "" {
+		t.Errorf("nil hint() = %q, want none", hint)
+	}
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/internal/editor"
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+// adminError is an admin change rejected with a specific response
+type adminError struct {
+	err     error
+	status  int
+	details string
+}
+
+func (e *adminError) Error() string { return e.err.Error() }
+
+// handleAdminEditors handles /admin/editors and /admin/editors/{name}:
+//
+//	GET    /admin/editors         list editors
+//	GET    /admin/editors/{name}  show one editor
+//	POST   /admin/editors         add an editor
+//	PUT    /admin/editors/{name}  replace an editor (or make it the default)
+//	DELETE /admin/editors/{name}  remove an editor
+//
+// Changes are written back to the config file and applied without a restart.
+// The endpoints are only served when server.auth_token is set.
+func (s *Server) handleAdminEditors(w http.ResponseWriter, r *http.Request) {
+	if s.currentConfig().Server.AuthToken == "" {
+		s.respondError(w, api.ErrUnauthorized, http.StatusForbidden, "admin API requires server.auth_token to be set")
+		return
+	}
+
+	name := strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin/editors"), "/")
+
+	switch {
+	case r.Method == http.MethodGet && name == "":
+		s.handleEditors(w, r)
+	case r.Method == http.MethodGet:
+		s.adminGetEditor(w, name)
+	case r.Method == http.MethodPost && name == "":
+		s.adminAddEditor(w, r)
+	case r.Method == http.MethodPut && name != "":
+		s.adminUpdateEditor(w, r, name)
+	case r.Method == http.MethodDelete && name != "":
+		s.adminRemoveEditor(w, name)
+	default:
+		s.respondError(w, api.ErrNotImplemented, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// adminGetEditor responds with a single editor
+func (s *Server) adminGetEditor(w http.ResponseWriter, name string) {
+	e, err := s.editors().GetEditor(name)
+	if err != nil {
+		s.respondError(w, api.ErrEditorNotFound, http.StatusNotFound, name)
+		return
+	}
+
+	s.respondJSON(w, http.StatusOK, editorInfo(e))
+}
+
+// adminAddEditor handles POST /admin/editors
+func (s *Server) adminAddEditor(w http.ResponseWriter, r *http.Request) {
+	req, ok := s.decodeEditorRequest(w, r)
+	if !ok {
+		return
+	}
+
+	s.applyEditorChange(w, http.StatusCreated, func(editors []config.EditorConfig) ([]config.EditorConfig, error) {
+		if findEditorConfig(editors, req.Name) >= 0 {
+			return nil, &adminError{api.ErrEditorExists, http.StatusConflict, req.Name}
+		}
+		return setEditorConfig(editors, -1, editorConfigFromRequest(req)), nil
+	})
+}
+
+// adminUpdateEditor handles PUT /admin/editors/{name}
+func (s *Server) adminUpdateEditor(w http.ResponseWriter, r *http.Request, name string) {
+	req, ok := s.decodeEditorRequest(w, r)
+	if !ok {
+		return
+	}
+	if req.Name != "" && req.Name != name {
+		s.respondError(w, api.ErrInvalidRequest, http.StatusBadRequest, "name in body does not match URL")
+		return
+	}
+	req.Name = name
+
+	s.applyEditorChange(w, http.StatusOK, func(editors []config.EditorConfig) ([]config.EditorConfig, error) {
+		i := findEditorConfig(editors, name)
+		if i < 0 {
+			return nil, &adminError{api.ErrEditorNotFound, http.StatusNotFound, name}
+		}
+
+		// A bare {"default": true} only changes the default editor
+		if req.Command == "" && req.URL == "" && req.Type == "" {
+			if !req.Default {
+				return nil, &adminError{api.ErrInvalidRequest, http.StatusBadRequest, "command or url is required"}
+			}
+			updated := editors[i]
+			updated.Default = true
+			return setEditorConfig(editors, i, updated), nil
+		}
+		updated := editorConfigFromRequest(req)
+		// The admin API does not manage aliases, variables, the working
+		// directory, activation, the execution policy, availability probes,
+		// the tmux session, the diff command or sync; keep them
+		updated.Aliases = editors[i].Aliases
+		updated.Variables = editors[i].Variables
+		updated.ExecMode = editors[i].ExecMode
+		updated.WaitForExit = editors[i].WaitForExit
+		updated.Timeout = editors[i].Timeout
+		updated.Retries = editors[i].Retries
+		updated.CaptureKB = editors[i].CaptureKB
+		updated.WorkDir = editors[i].WorkDir
+		updated.Activate = editors[i].Activate
+		updated.Availability = editors[i].Availability
+		updated.Session = editors[i].Session
+		updated.Diff = editors[i].Diff
+		updated.Sync = editors[i].Sync
+		return setEditorConfig(editors, i, updated), nil
+	})
+}
+
+// adminRemoveEditor handles DELETE /admin/editors/{name}
+func (s *Server) adminRemoveEditor(w http.ResponseWriter, name string) {
+	s.applyEditorChange(w, http.StatusOK, func(editors []config.EditorConfig) ([]config.EditorConfig, error) {
+		i := findEditorConfig(editors, name)
+		if i < 0 {
+			return nil, &adminError{api.ErrEditorNotFound, http.StatusNotFound, name}
+		}
+		if len(editors) == 1 {
+			return nil, &adminError{api.ErrInvalidRequest, http.StatusBadRequest, "cannot remove the last editor"}
+		}
+		return append(editors[:i], editors[i+1:]...), nil
+	})
+}
+
+// decodeEditorRequest parses an admin editor request body, held to the same
+// size limit and strictness as open requests
+func (s *Server) decodeEditorRequest(w http.ResponseWriter, r *http.Request) (api.EditorRequest, bool) {
+	var req api.EditorRequest
+	limit := s.currentConfig().Server.MaxBodyBytes()
+	if err := decodeStrictJSON(w, r, limit, &req); err != nil {
+		if errors.Is(err, api.ErrTooLarge) {
+			s.respondError(w, api.ErrTooLarge, http.StatusRequestEntityTooLarge,
+				fmt.Sprintf("request body is limited to %d KB", limit/1024))
+			return req, false
+		}
+		s.respondError(w, api.ErrInvalidRequest, http.StatusBadRequest, fmt.Sprintf("Invalid JSON: %v", err))
+		return req, false
+	}
+	return req, true
+}
+
+// applyEditorChange runs change against a copy of the configured editors,
+// validates the result, persists it and swaps it in. The new editor list is
+// returned on success.
+func (s *Server) applyEditorChange(w http.ResponseWriter, status int, change func([]config.EditorConfig) ([]config.EditorConfig, error)) {
+	s.adminMu.Lock()
+	defer s.adminMu.Unlock()
+
+	cfg := *s.currentConfig()
+	editors, err := change(append([]config.EditorConfig(nil), cfg.Editors...))
+	if err != nil {
+		var adminErr *adminError
+		if errors.As(err, &adminErr) {
+			s.respondError(w, adminErr.err, adminErr.status, adminErr.details)
+			return
+		}
+		s.respondError(w, err, http.StatusInternalServerError, "")
+		return
+	}
+
+	for _, e := range editors {
+		if err := editor.ValidateEditor(e); err != nil {
+			s.respondError(w, api.ErrInvalidEditor, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	cfg.Editors = editors
+	if err := config.ValidateServerConfig(&cfg); err != nil {
+		s.respondError(w, api.ErrInvalidEditor, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if s.configPath != "" {
+		if err := config.SaveServerEditors(s.configPath, editors); err != nil {
+			s.log.Error("Failed to save editors", "error", err, "file", s.configPath)
+			s.respondError(w, api.ErrInternalServer, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	if err := s.Reload(&cfg); err != nil {
+		s.respondError(w, api.ErrInternalServer, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	s.respondJSON(w, status, editorsResponse(s.editors(), editorFilter{}))
+}
+
+// findEditorConfig returns the index of the editor called name, or -1
+func findEditorConfig(editors []config.EditorConfig, name string) int {
+	for i, e := range editors {
+		if e.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// setEditorConfig replaces editors[i] with e (appending when i < 0). When e
+// is the default, every other editor loses its default flag.
+func setEditorConfig(editors []config.EditorConfig, i int, e config.EditorConfig) []config.EditorConfig {
+	if e.Default {
+		for j := range editors {
+			editors[j].Default = false
+		}
+	}
+	if i < 0 {
+		return append(editors, e)
+	}
+	editors[i] = e
+	return editors
+}
+
+// editorConfigFromRequest converts an admin request to an editor config
+func editorConfigFromRequest(req api.EditorRequest) config.EditorConfig {
+	return config.EditorConfig{
+		Name:      req.Name,
+		Type:      config.EditorType(req.Type),
+		Command:   req.Command,
+		URL:       req.URL,
+		Default:   req.Default,
+		Available: true,
+	}
+}
+
+// handleAdminShutdown handles POST /admin/shutdown, which lets a service
+// manager stop the server gracefully: editor launches in flight are drained
+// before it exits. Like the editor endpoints it needs server.auth_token.
+func (s *Server) handleAdminShutdown(w http.ResponseWriter, r *http.Request) {
+	if s.currentConfig().Server.AuthToken == "" {
+		s.respondError(w, api.ErrUnauthorized, http.StatusForbidden, "admin API requires server.auth_token to be set")
+		return
+	}
+	if r.Method != http.MethodPost {
+		s.respondError(w, api.ErrNotImplemented, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	s.log.Info("Shutdown requested", "remote_addr", r.RemoteAddr)
+	response := api.ShutdownResponse{
+		Success:  true,
+		InFlight: s.executor.InFlight(),
+	}
+	response.SetTimestamp()
+	s.respondJSON(w, http.StatusAccepted, response)
+
+	s.requestShutdown()
+}
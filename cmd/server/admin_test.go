@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+const adminTestToken = "admin-test-token"
+
+func adminRequest(t *testing.T, server *Server, method, path string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader io.Reader = http.NoBody
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Authorization", "Bearer "+adminTestToken)
+	rec := httptest.NewRecorder()
+	server.Router().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandleAdminEditors(t *testing.T) {
+	server := createTestServer()
+	server.config.Server.AuthToken = adminTestToken
+	server.configPath = filepath.Join(t.TempDir(), "server-config.yaml")
+	if err := config.SaveServerConfig(server.configPath, server.config); err != nil {
+		t.Fatalf("SaveServerConfig() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		body        any
+		wantStatus  int
+		wantDefault string
+		wantCount   int
+	}{
+		{"list", http.MethodGet, "/admin/editors", nil, http.StatusOK, "test-editor", 2},
+		{"add", http.MethodPost, "/admin/editors", api.EditorRequest{Name: "vim", Command: "vim {path}"}, http.StatusCreated, "test-editor", 3},
+		{"add duplicate", http.MethodPost, "/admin/editors", api.EditorRequest{Name: "vim", Command: "vim {path}"}, http.StatusConflict, "", 0},
+		{"add invalid", http.MethodPost, "/admin/editors", api.EditorRequest{Name: "bad"}, http.StatusBadRequest, "", 0},
+		{"set default", http.MethodPut, "/admin/editors/vim", api.EditorRequest{Default: true}, http.StatusOK, "vim", 3},
+		{"update missing", http.MethodPut, "/admin/editors/nope", api.EditorRequest{Command: "nope {path}"}, http.StatusNotFound, "", 0},
+		{"remove", http.MethodDelete, "/admin/editors/another-editor", nil, http.StatusOK, "vim", 2},
+		{"remove missing", http.MethodDelete, "/admin/editors/another-editor", nil, http.StatusNotFound, "", 0},
+	}
+
+	for _, tt := range tests {
+		rec := adminRequest(t, server, tt.method, tt.path, tt.body)
+		if rec.Code != tt.wantStatus {
+			t.Fatalf("%s: status = %v, want %v: %s", tt.name, rec.Code, tt.wantStatus, rec.Body.String())
+		}
+		if tt.wantCount == 0 {
+			continue
+		}
+
+		var resp api.EditorsResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("%s: Decode() error = %v", tt.name, err)
+		}
+		if resp.DefaultEditor != tt.wantDefault || len(resp.Editors) != tt.wantCount {
+			t.Errorf("%s: default = %v, editors = %d, want %v, %d", tt.name, resp.DefaultEditor, len(resp.Editors), tt.wantDefault, tt.wantCount)
+		}
+	}
+
+	// Changes are persisted to the config file
+	saved, err := config.LoadServerConfig(server.configPath)
+	if err != nil {
+		t.Fatalf("LoadServerConfig() error = %v", err)
+	}
+	if len(saved.Editors) != 2 || saved.Editors[1].Name != "vim" || !saved.Editors[1].Default {
+		t.Errorf("saved editors = %+v, want test-editor and default vim", saved.Editors)
+	}
+}
+
+func TestHandleAdminUpdateEditorKeepsSettings(t *testing.T) {
+	tests := []struct {
+		name   string
+		editor config.EditorConfig
+		check  func(t *testing.T, got config.EditorConfig)
+	}{
+		{
+			name:   "sync",
+			editor: config.EditorConfig{Name: "kept", Command: "old {path}", Sync: true},
+			check: func(t *testing.T, got config.EditorConfig) {
+				if !got.Sync {
+					t.Error("Sync = false after update, want true")
+				}
+			},
+		},
+		{
+			name: "availability",
+			editor: config.EditorConfig{Name: "kept", Command: "old {path}",
+				Availability: config.AvailabilityConfig{Paths: []string{"/Applications/Old.app"}, Flatpak: "org.example.Old"}},
+			check: func(t *testing.T, got config.EditorConfig) {
+				if len(got.Availability.Paths) != 1 || got.Availability.Flatpak != "org.example.Old" {
+					t.Errorf("Availability = %+v after update, want it kept", got.Availability)
+				}
+			},
+		},
+		{
+			name:   "session",
+			editor: config.EditorConfig{Name: "kept", Type: config.EditorTypeTmux, Command: "old {path}", Session: "dev"},
+			check: func(t *testing.T, got config.EditorConfig) {
+				if got.Session != "dev" {
+					t.Errorf("Session = %q after update, want dev", got.Session)
+				}
+			},
+		},
+		{
+			name:   "diff",
+			editor: config.EditorConfig{Name: "kept", Command: "old {path}", Diff: "old --diff {path} {path2}"},
+			check: func(t *testing.T, got config.EditorConfig) {
+				if got.Diff != "old --diff {path} {path2}" {
+					t.Errorf("Diff = %q after update, want it kept", got.Diff)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := createTestServer()
+			server.config.Server.AuthToken = adminTestToken
+			server.config.Editors = append(server.config.Editors, tt.editor)
+			server.configPath = filepath.Join(t.TempDir(), "server-config.yaml")
+			if err := config.SaveServerConfig(server.configPath, server.config); err != nil {
+				t.Fatalf("SaveServerConfig() error = %v", err)
+			}
+
+			rec := adminRequest(t, server, http.MethodPut, "/admin/editors/kept",
+				api.EditorRequest{Type: string(tt.editor.Type), Command: "new {path}"})
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %v, want %v: %s", rec.Code, http.StatusOK, rec.Body.String())
+			}
+
+			// Kept in the running configuration and in the file
+			saved, err := config.LoadServerConfig(server.configPath)
+			if err != nil {
+				t.Fatalf("LoadServerConfig() error = %v", err)
+			}
+			for _, editors := range [][]config.EditorConfig{server.currentConfig().Editors, saved.Editors} {
+				i := findEditorConfig(editors, "kept")
+				if i < 0 {
+					t.Fatal("editor missing after update")
+				}
+				if editors[i].Command != "new {path}" {
+					t.Errorf("Command = %q, want new {path}", editors[i].Command)
+				}
+				tt.check(t, editors[i])
+			}
+		})
+	}
+}
+
+func TestHandleAdminEditorsStrictBody(t *testing.T) {
+	server := createTestServer()
+	server.config.Server.AuthToken = adminTestToken
+	server.config.Server.MaxBodyKB = 1
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name:       "unknown field",
+			body:       `{"name":"vim","command":"vim {path}","colour":"red"}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   api.CodeInvalidRequest,
+		},
+		{
+			name:       "trailing data",
+			body:       `{"name":"vim","command":"vim {path}"} {}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   api.CodeInvalidRequest,
+		},
+		{
+			name:       "oversized body",
+			body:       `{"name":"vim","command":"vim {path} ` + strings.Repeat("a", 2048) + `"}`,
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantCode:   api.CodeTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/admin/editors", strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+adminTestToken)
+			rec := httptest.NewRecorder()
+			server.Router().ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v", rec.Code, tt.wantStatus)
+			}
+			var resp api.ErrorResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if resp.Code != tt.wantCode {
+				t.Errorf("error code = %v, want %v", resp.Code, tt.wantCode)
+			}
+		})
+	}
+	if findEditorConfig(server.currentConfig().Editors, "vim") >= 0 {
+		t.Error("rejected request added an editor")
+	}
+}
+
+func TestHandleAdminEditorsRequiresToken(t *testing.T) {
+	server := createTestServer()
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/editors", http.NoBody)
+	rec := httptest.NewRecorder()
+	server.Router().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("status = %v, want %v", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestHandleAdminShutdown(t *testing.T) {
+	server := createTestServer()
+
+	rec := adminRequest(t, server, http.MethodPost, "/admin/shutdown", nil)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("without auth_token: status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	server.config.Server.AuthToken = adminTestToken
+	if rec := adminRequest(t, server, http.MethodGet, "/admin/shutdown", nil); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+
+	rec = adminRequest(t, server, http.MethodPost, "/admin/shutdown", nil)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("POST: status = %d, want %d", rec.Code, http.StatusAccepted)
+	}
+	select {
+	case <-server.ShutdownRequested():
+	default:
+		t.Fatal("POST /admin/shutdown did not request a shutdown")
+	}
+
+	// A second request while one is pending must not block
+	adminRequest(t, server, http.MethodPost, "/admin/shutdown", nil)
+	adminRequest(t, server, http.MethodPost, "/admin/shutdown", nil)
+}
+
+func TestHandleOpenEditor_Draining(t *testing.T) {
+	server := createTestServer()
+	if err := server.Drain(context.Background()); err != nil {
+		t.Fatalf("Drain() error = %v", err)
+	}
+
+	body, _ := json.Marshal(api.OpenRequest{Path: "/tmp", User: "u", Host: "h"})
+	req := httptest.NewRequest(http.MethodPost, "/open-editor", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+	server.Router().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	var resp api.ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Code != api.CodeShuttingDown {
+		t.Errorf("error code = %s, want %s", resp.Code, api.CodeShuttingDown)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+// handleClipboard handles POST /clipboard. The request body is copied to
+// the host clipboard as-is.
+func (s *Server) handleClipboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.respondError(w, api.ErrNotImplemented, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	cfg := s.currentConfig().Server.Clipboard
+	if !cfg.Enabled {
+		s.respondError(w, api.ErrDisabled, http.StatusForbidden, "clipboard is disabled; set server.clipboard.enabled")
+		return
+	}
+
+	limit := cfg.MaxBytes()
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			s.respondError(w, api.ErrTooLarge, http.StatusRequestEntityTooLarge, fmt.Sprintf("clipboard text is limited to %d KB", limit/1024))
+			return
+		}
+		s.respondError(w, api.ErrInvalidRequest, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(data) == 0 {
+		s.respondError(w, api.ErrInvalidRequest, http.StatusBadRequest, "clipboard text is empty")
+		return
+	}
+
+	if err := s.writeClipboard(data); err != nil {
+		s.log.Error("Failed to write clipboard", "error", err, "remote_addr", r.RemoteAddr)
+		s.respondError(w, err, http.StatusInternalServerError, "")
+		return
+	}
+
+	s.log.Info("Clipboard written", "bytes", len(data), "remote_addr", r.RemoteAddr)
+
+	response := api.ClipboardResponse{Success: true, Bytes: len(data)}
+	response.SetTimestamp()
+	s.respondJSON(w, http.StatusOK, response)
+}
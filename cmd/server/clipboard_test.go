@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+func TestHandleClipboard(t *testing.T) {
+	tests := []struct {
+		name       string
+		clipboard  config.ClipboardConfig
+		method     string
+		body       string
+		writeErr   error
+		wantStatus int
+		wantCode   string
+		wantCopied string
+	}{
+		{
+			name:       "disabled by default",
+			method:     http.MethodPost,
+			body:       "hello",
+			wantStatus: http.StatusForbidden,
+			wantCode:   api.CodeDisabled,
+		},
+		{
+			name:       "copies body",
+			clipboard:  config.ClipboardConfig{Enabled: true},
+			method:     http.MethodPost,
+			body:       "hello\nworld",
+			wantStatus: http.StatusOK,
+			wantCopied: "hello\nworld",
+		},
+		{
+			name:       "over the size limit",
+			clipboard:  config.ClipboardConfig{Enabled: true, MaxKB: 1},
+			method:     http.MethodPost,
+			body:       strings.Repeat("x", 1025),
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantCode:   api.CodeTooLarge,
+		},
+		{
+			name:       "empty body",
+			clipboard:  config.ClipboardConfig{Enabled: true},
+			method:     http.MethodPost,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   api.CodeInvalidRequest,
+		},
+		{
+			name:       "clipboard tool fails",
+			clipboard:  config.ClipboardConfig{Enabled: true},
+			method:     http.MethodPost,
+			body:       "hello",
+			writeErr:   errors.New("no clipboard tool available"),
+			wantStatus: http.StatusInternalServerError,
+			wantCopied: "hello",
+		},
+		{
+			name:       "wrong method",
+			clipboard:  config.ClipboardConfig{Enabled: true},
+			method:     http.MethodGet,
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := createTestServer()
+			server.config.Server.Clipboard = tt.clipboard
+
+			var copied string
+			server.writeClipboard = func(data []byte) error {
+				copied = string(data)
+				return tt.writeErr
+			}
+
+			rec := httptest.NewRecorder()
+			server.handleClipboard(rec, httptest.NewRequest(tt.method, "/clipboard", strings.NewReader(tt.body)))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("handleClipboard() status = %v, want %v", rec.Code, tt.wantStatus)
+			}
+			if tt.wantCode != "" {
+				var errResp api.ErrorResponse
+				if err := json.NewDecoder(rec.Body).Decode(&errResp); err != nil {
+					t.Fatalf("Decode() error = %v", err)
+				}
+				if errResp.Code != tt.wantCode {
+					t.Errorf("code = %v, want %v", errResp.Code, tt.wantCode)
+				}
+			}
+			if copied != tt.wantCopied {
+				t.Errorf("copied %q, want %q", copied, tt.wantCopied)
+			}
+		})
+	}
+}
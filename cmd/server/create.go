@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/foxytanuki/rcode/internal/logger"
+	"github.com/foxytanuki/rcode/internal/pathcheck"
+	"github.com/foxytanuki/rcode/internal/validation"
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+// createPaths creates the paths of plan's request that are missing on this
+// machine, for requests made with rcode --create, and returns the ones it
+// created. Paths are only created under allowed_paths, checked after
+// resolving symlinks so that a link cannot lead outside them.
+func (s *Server) createPaths(log *logger.Logger, plan *openPlan) ([]string, *renderFailure) {
+	cfg := s.currentConfig().Server
+	dir := plan.req.Create == api.CreateDir
+
+	var created []string
+	for _, p := range plan.paths {
+		if info, err := pathcheck.Stat(p); err == nil && info.Exists {
+			continue
+		}
+		if !cfg.Create.Enabled {
+			return created, &renderFailure{api.ErrDisabled, http.StatusForbidden,
+				fmt.Sprintf("%s does not exist and creating paths is disabled; set server.create.enabled", p)}
+		}
+
+		target, err := pathcheck.Check(p, pathcheck.Options{ResolveSymlinks: true})
+		if err != nil {
+			return created, &renderFailure{fmt.Errorf("%w: %w", api.ErrInvalidPath, err), http.StatusBadRequest, ""}
+		}
+		if len(cfg.AllowedPaths) == 0 || !validation.PathAllowed(target, cfg.AllowedPaths) {
+			log.Warn("Path creation rejected by allowed_paths",
+				"path", p,
+				"target", target,
+				"user", plan.req.User,
+			)
+			return created, &renderFailure{api.ErrPathNotAllowed, http.StatusForbidden,
+				fmt.Sprintf("%s would be created outside the allowed paths", p)}
+		}
+
+		made, err := pathcheck.Create(target, dir, cfg.Create.File(), cfg.Create.Dir())
+		if err != nil {
+			log.Error("Failed to create path",
+				"error", err,
+				"path", target,
+			)
+			return created, &renderFailure{fmt.Errorf("%w: %w", api.ErrInternalServer, err), http.StatusInternalServerError,
+				fmt.Sprintf("failed to create %s", p)}
+		}
+		if made {
+			log.Info("Created path", "path", target, "dir", dir)
+			created = append(created, p)
+		}
+	}
+	return created, nil
+}
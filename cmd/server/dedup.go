@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+// openDedup coalesces identical open requests, so that running rcode several
+// times in a row opens one window. Requests are identical when they come from
+// the same user and host for the same paths and editor.
+type openDedup struct {
+	mu      sync.Mutex
+	entries map[string]*dedupEntry
+}
+
+// dedupEntry is the launch for one request key
+type dedupEntry struct {
+	done     chan struct{}     // closed when the launch is over
+	finished time.Time         // when the launch ended
+	response *api.OpenResponse // nil when the launch failed
+}
+
+// dedupKey identifies requests that open the same paths in the same editor
+func dedupKey(req *api.OpenRequest, paths []string, editorName string) string {
+	return strings.Join(append([]string{req.User, req.Host, editorName, strconv.FormatBool(req.Terminal), req.Path2, strconv.Itoa(req.Port)}, paths...), "\x00")
+}
+
+// join returns the launch for key and whether the caller must run it. A
+// request identical to one that is launching, or that launched successfully
+// less than window ago, joins that launch instead.
+func (d *openDedup) join(key string, window time.Duration, now time.Time) (*dedupEntry, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.entries == nil {
+		d.entries = make(map[string]*dedupEntry)
+	}
+	for k, entry := range d.entries {
+		select {
+		case <-entry.done:
+			if now.Sub(entry.finished) >= window {
+				delete(d.entries, k)
+			}
+		default:
+		}
+	}
+
+	if entry, ok := d.entries[key]; ok {
+		return entry, false
+	}
+	entry := &dedupEntry{done: make(chan struct{})}
+	d.entries[key] = entry
+	return entry, true
+}
+
+// finish records the outcome of entry's launch and releases the requests
+// that joined it. Failed launches are forgotten so the next request retries.
+func (d *openDedup) finish(key string, entry *dedupEntry, response *api.OpenResponse, now time.Time) {
+	d.mu.Lock()
+	entry.response = response
+	entry.finished = now
+	if response == nil && d.entries[key] == entry {
+		delete(d.entries, key)
+	}
+	d.mu.Unlock()
+	close(entry.done)
+}
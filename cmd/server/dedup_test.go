@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/audit"
+	"github.com/foxytanuki/rcode/internal/session"
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+func TestOpenDedup(t *testing.T) {
+	var d openDedup
+	now := time.Now()
+	window := 2 * time.Second
+
+	first, leader := d.join("key", window, now)
+	if !leader {
+		t.Fatal("join() on an empty dedup is not the leader")
+	}
+	if entry, leader := d.join("key", window, now); leader || entry != first {
+		t.Error("join() while launching did not join the running launch")
+	}
+	if _, leader := d.join("other", window, now); !leader {
+		t.Error("join() for another key is not the leader")
+	}
+
+	// A failed launch is forgotten
+	d.finish("key", first, nil, now)
+	second, leader := d.join("key", window, now)
+	if !leader {
+		t.Fatal("join() after a failed launch is not the leader")
+	}
+
+	// A successful launch answers identical requests within the window
+	d.finish("key", second, &api.OpenResponse{Success: true}, now)
+	if entry, leader := d.join("key", window, now.Add(time.Second)); leader || entry != second {
+		t.Error("join() within the window did not reuse the launch")
+	}
+	if _, leader := d.join("key", window, now.Add(window)); !leader {
+		t.Error("join() after the window is not the leader")
+	}
+}
+
+func TestHandleOpenEditorDedup(t *testing.T) {
+	server := createTestServer()
+	cfg := *server.currentConfig()
+	cfg.Server.Launch.DedupWindow = time.Minute
+	if err := server.Reload(&cfg); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	var auditLog bytes.Buffer
+	server.audit = audit.NewWithWriter(nopWriteCloser{&auditLog})
+
+	open := func(path string) api.OpenResponse {
+		t.Helper()
+		body, err := json.Marshal(api.OpenRequest{Path: path, User: "testuser", Host: "testhost"})
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		rec := httptest.NewRecorder()
+		server.handleOpenEditor(rec, httptest.NewRequest(http.MethodPost, "/open-editor", bytes.NewReader(body)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("handleOpenEditor() status = %v, want %v: %s", rec.Code, http.StatusOK, rec.Body.String())
+		}
+		var resp api.OpenResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+		return resp
+	}
+
+	if resp := open("/home/user/project"); resp.Coalesced {
+		t.Error("first request was coalesced")
+	}
+	if resp := open("/home/user/project"); !resp.Coalesced || resp.Editor != "test-editor" {
+		t.Errorf("repeated request = %+v, want it coalesced with the first", resp)
+	}
+	if resp := open("/home/user/other"); resp.Coalesced {
+		t.Error("request for another path was coalesced")
+	}
+
+	// Only the two launches are recorded
+	if sessions := server.sessions.List(session.Filter{}); len(sessions) != 2 {
+		t.Errorf("recorded %d sessions, want 2", len(sessions))
+	}
+	want := []string{audit.StatusExecuted, audit.StatusCoalesced, audit.StatusExecuted}
+	if got := auditStatuses(t, &auditLog); !slices.Equal(got, want) {
+		t.Errorf("audit statuses = %v, want %v", got, want)
+	}
+}
+
+func TestHandleOpenEditorIdempotency(t *testing.T) {
+	server := createTestServer()
+	cfg := *server.currentConfig()
+	cfg.Server.Launch.DedupWindow = 0
+	if err := server.Reload(&cfg); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	var auditLog bytes.Buffer
+	server.audit = audit.NewWithWriter(nopWriteCloser{&auditLog})
+
+	open := func(path, key string) *httptest.ResponseRecorder {
+		t.Helper()
+		body, err := json.Marshal(api.OpenRequest{Path: path, User: "testuser", Host: "testhost"})
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/open-editor", bytes.NewReader(body))
+		req.Header.Set(api.HeaderIdempotencyKey, key)
+		rec := httptest.NewRecorder()
+		server.handleOpenEditor(rec, req)
+		return rec
+	}
+
+	first := open("/home/user/project", "key-1")
+	if first.Code != http.StatusOK || first.Header().Get(api.HeaderIdempotentReplayed) != "" {
+		t.Fatalf("first request = %d %v, want a launch", first.Code, first.Header())
+	}
+	retry := open("/home/user/project", "key-1")
+	if retry.Code != http.StatusOK || retry.Header().Get(api.HeaderIdempotentReplayed) != "true" {
+		t.Fatalf("retry = %d %v, want the replayed response", retry.Code, retry.Header())
+	}
+	if retry.Body.String() != first.Body.String() {
+		t.Errorf("retry body = %s, want the original %s", retry.Body.String(), first.Body.String())
+	}
+	if got := auditStatuses(t, &auditLog); len(got) != 2 || got[0] != audit.StatusExecuted || got[1] != audit.StatusReplayed {
+		t.Errorf("audit statuses = %v, want executed then replayed", got)
+	}
+
+	// The key only replays the request it was sent with
+	if rec := open("/home/user/other", "key-1"); rec.Header().Get(api.HeaderIdempotentReplayed) != "" {
+		t.Error("a request for another path with the same key was replayed")
+	}
+	if rec := open("/home/user/project", "key-2"); rec.Header().Get(api.HeaderIdempotentReplayed) != "" {
+		t.Error("a request with another key was replayed")
+	}
+	if rec := open("/home/user/project", "not a key!"); rec.Code != http.StatusBadRequest {
+		t.Errorf("malformed key status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+// auditStatuses returns the statuses of the audit records in buf
+func auditStatuses(t *testing.T, buf *bytes.Buffer) []string {
+	t.Helper()
+	var statuses []string
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var rec audit.Record
+		if err := dec.Decode(&rec); err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+		statuses = append(statuses, rec.Status)
+	}
+	return statuses
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+// Event stream settings
+const (
+	eventBufferSize        = 16
+	eventHeartbeatInterval = 30 * time.Second
+)
+
+// eventHub fans out server events to /events subscribers. Slow subscribers
+// miss events rather than blocking request handlers.
+type eventHub struct {
+	mu          sync.Mutex
+	subscribers map[chan api.Event]struct{}
+
+	done      chan struct{} // Closed by close to end every stream
+	closeOnce sync.Once
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{
+		subscribers: make(map[chan api.Event]struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// close ends every event stream, current and future, so that a shutdown
+// does not wait for subscribers that never disconnect
+func (h *eventHub) close() {
+	h.closeOnce.Do(func() { close(h.done) })
+}
+
+// closed returns a channel that is closed once close is called
+func (h *eventHub) closed() <-chan struct{} {
+	return h.done
+}
+
+// subscribe registers a new subscriber. The returned function unsubscribes.
+func (h *eventHub) subscribe() (<-chan api.Event, func()) {
+	ch := make(chan api.Event, eventBufferSize)
+
+	h.mu.Lock()
+	h.subscribers[ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.subscribers, ch)
+		h.mu.Unlock()
+	}
+}
+
+// publish delivers event to every subscriber without blocking
+func (h *eventHub) publish(event api.Event) {
+	if event.Timestamp == 0 {
+		event.SetTimestamp()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// handleEvents handles GET /events, streaming events as Server-Sent Events
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, api.ErrNotImplemented, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	rc := http.NewResponseController(w)
+
+	// The stream outlives the server write timeout
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		s.log.Warn("Failed to clear write deadline for event stream", "error", err)
+	}
+
+	events, unsubscribe := s.events.subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := fmt.Fprint(w, ": connected\n\n"); err != nil {
+		return
+	}
+	if err := rc.Flush(); err != nil {
+		s.log.Warn("Event stream not supported on this connection", "error", err)
+		return
+	}
+
+	heartbeat := time.NewTicker(eventHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.events.closed():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case event := <-events:
+			data, err := json.Marshal(event)
+			if err != nil {
+				s.log.Error("Failed to encode event", "error", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+func TestEventHub(t *testing.T) {
+	hub := newEventHub()
+	events, unsubscribe := hub.subscribe()
+
+	hub.publish(api.Event{Type: api.EventOpen, Path: "/a"})
+
+	select {
+	case event := <-events:
+		if event.Path != "/a" {
+			t.Errorf("event.Path = %v, want /a", event.Path)
+		}
+		if event.Timestamp == 0 {
+			t.Error("publish() did not set timestamp")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscriber did not receive event")
+	}
+
+	unsubscribe()
+	hub.publish(api.Event{Type: api.EventOpen, Path: "/b"})
+
+	select {
+	case event := <-events:
+		t.Errorf("received event after unsubscribe: %+v", event)
+	default:
+	}
+}
+
+func TestHandleEvents(t *testing.T) {
+	server := createTestServer()
+	ts := httptest.NewServer(server.Router())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/events")
+	if err != nil {
+		t.Fatalf("GET /events error = %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("Content-Type = %v, want text/event-stream", got)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, ": connected") {
+		t.Fatalf("first line = %q, %v, want connected comment", line, err)
+	}
+
+	body, err := json.Marshal(api.OpenRequest{
+		Path: "/home/user/project",
+		User: "testuser",
+		Host: "testhost",
+	})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	openResp, err := http.Post(ts.URL+"/open-editor", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST /open-editor error = %v", err)
+	}
+	_ = openResp.Body.Close()
+
+	var eventType string
+	var event api.Event
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("ReadString() error = %v", err)
+		}
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "event: ") {
+			eventType = strings.TrimPrefix(line, "event: ")
+		}
+		if strings.HasPrefix(line, "data: ") {
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			break
+		}
+	}
+
+	if eventType != api.EventOpen {
+		t.Errorf("event type = %v, want %v", eventType, api.EventOpen)
+	}
+	if event.Path != "/home/user/project" || event.Editor != "test-editor" || event.User != "testuser" {
+		t.Errorf("event = %+v, want open of /home/user/project by testuser in test-editor", event)
+	}
+}
+
+func TestHandleEventsEndsWhenHubCloses(t *testing.T) {
+	server := createTestServer()
+	ts := httptest.NewServer(server.Router())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/events")
+	if err != nil {
+		t.Fatalf("GET /events error = %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	reader := bufio.NewReader(resp.Body)
+	if line, err := reader.ReadString('\n'); err != nil || !strings.HasPrefix(line, ": connected") {
+		t.Fatalf("first line = %q, %v, want connected comment", line, err)
+	}
+
+	server.events.close()
+
+	ended := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(io.Discard, reader)
+		ended <- err
+	}()
+	select {
+	case err := <-ended:
+		if err != nil {
+			t.Errorf("stream ended with error = %v, want EOF", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("event stream still open after the hub closed")
+	}
+}
+
+func TestGracefulShutdownWithEventSubscriber(t *testing.T) {
+	server := createTestServer()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := &http.Server{Handler: server.Router(), ReadHeaderTimeout: time.Second}
+	go func() { _ = httpServer.Serve(ln) }()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/events")
+	if err != nil {
+		t.Fatalf("GET /events error = %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if line, err := bufio.NewReader(resp.Body).ReadString('\n'); err != nil || !strings.HasPrefix(line, ": connected") {
+		t.Fatalf("first line = %q, %v, want connected comment", line, err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		gracefulShutdown(server, httpServer, func() {}, server.log)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("shutdown is waiting for the /events subscriber")
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/auth"
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	certDir   string
+	certHosts []string
+	certDays  int
+	certForce bool
+)
+
+var genCertsCmd = &cobra.Command{
+	Use:   "gen-certs",
+	Short: "Create a CA and certificates for mutual TLS",
+	Long: `Create a small certificate authority and two certificates signed by it: one
+for rcode-server and one for rcode clients, with their private keys. The
+server certificate is valid for this machine's hostname, localhost and the
+addresses of its network interfaces, plus any --host.
+
+Point server.tls at the server certificate and the CA, and copy the CA and
+the client certificate and key to each remote machine for hosts.server.tls.
+Keep ca-key.pem private; it is only needed to sign more certificates.`,
+	Example: `  rcode-server gen-certs
+  rcode-server gen-certs --host devbox.example.com --dir ~/rcode-tls`,
+	Args: cobra.NoArgs,
+	RunE: runGenCerts,
+}
+
+func runGenCerts(_ *cobra.Command, _ []string) error {
+	dir := certDir
+	if dir == "" {
+		dir = filepath.Join(filepath.Dir(config.ServerConfigPath(configFile)), "tls")
+	}
+
+	paths, err := auth.GenerateCertificates(dir, auth.CertOptions{
+		Hosts:    certificateHosts(certHosts),
+		Validity: time.Duration(certDays) * 24 * time.Hour,
+		Force:    certForce,
+	})
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		fmt.Printf("Wrote %s\n", path)
+	}
+
+	file := func(name string) string { return filepath.Join(dir, name) }
+	fmt.Printf(`
+Server (server-config.yaml):
+  server:
+    tls:
+      cert_file: %q
+      key_file: %q
+      client_ca_file: %q
+
+Client (config.yaml on each remote machine, after copying the files there):
+  hosts:
+    server:
+      tls:
+        ca_file: %q
+        cert_file: %q
+        key_file: %q
+`, file(auth.ServerCertFile), file(auth.ServerKeyFile), file(auth.CAFile),
+		file(auth.CAFile), file(auth.ClientCertFile), file(auth.ClientKeyFile))
+	return nil
+}
+
+// certificateHosts returns the names and addresses the server certificate
+// is valid for: this machine's hostname, localhost, the addresses of its
+// interfaces and extra
+func certificateHosts(extra []string) []string {
+	hosts := []string{"localhost"}
+	if name, err := os.Hostname(); err == nil && name != "" {
+		hosts = append(hosts, name)
+	}
+	if addrs, err := net.InterfaceAddrs(); err == nil {
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLinkLocalUnicast() {
+				hosts = append(hosts, ipNet.IP.String())
+			}
+		}
+	}
+	for _, host := range extra {
+		if !slices.Contains(hosts, host) {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
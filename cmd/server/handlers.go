@@ -2,36 +2,117 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/foxytanuki/rcode/internal/audit"
+	"github.com/foxytanuki/rcode/internal/config"
 	"github.com/foxytanuki/rcode/internal/editor"
+	"github.com/foxytanuki/rcode/internal/filesync"
+	"github.com/foxytanuki/rcode/internal/logger"
 	"github.com/foxytanuki/rcode/internal/network"
+	"github.com/foxytanuki/rcode/internal/session"
+	"github.com/foxytanuki/rcode/internal/telemetry"
+	"github.com/foxytanuki/rcode/internal/validation"
 	"github.com/foxytanuki/rcode/internal/version"
 	"github.com/foxytanuki/rcode/pkg/api"
 )
 
-// handleHealth handles GET /health
+// handleHealth handles GET /health and GET /healthz. It is a liveness check:
+// answering at all means the process is up.
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		s.respondError(w, api.ErrNotImplemented, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
+	s.respondJSON(w, http.StatusOK, s.healthResponse(api.StatusHealthy))
+}
+
+// handleReady handles GET /readyz, reporting whether the server can open
+// editors. It answers 503 with the failed components when it cannot.
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, api.ErrNotImplemented, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	components := s.readinessChecks()
+	status, code := api.StatusHealthy, http.StatusOK
+	for _, c := range components {
+		if !c.Healthy {
+			status, code = api.StatusUnhealthy, http.StatusServiceUnavailable
+			break
+		}
+	}
+
+	response := s.healthResponse(status)
+	response.Components = components
+	s.respondJSON(w, code, response)
+}
+
+// healthResponse returns a health response with the given status
+func (s *Server) healthResponse(status string) api.HealthResponse {
 	uptime := time.Since(s.startTime).Seconds()
 	response := api.HealthResponse{
-		Status:    "healthy",
+		Status:    status,
 		Version:   version.Version,
 		Uptime:    int64(uptime),
 		StartedAt: s.startTime,
+		Protocol:  api.ProtocolVersion,
 	}
 	response.SetTimestamp()
+	return response
+}
 
-	s.respondJSON(w, http.StatusOK, response)
+// readinessChecks checks what opening an editor depends on: a loaded
+// configuration, an available editor, a writable log directory and a server
+// that is not shutting down
+func (s *Server) readinessChecks() []api.ComponentStatus {
+	cfg := s.currentConfig()
+
+	configStatus := api.ComponentStatus{Name: "config", Healthy: cfg != nil, Message: "loaded"}
+	if s.configPath != "" {
+		configStatus.Message = "loaded from " + s.configPath
+	}
+
+	available := 0
+	for _, e := range s.editors().ListEditors() {
+		if e.Available {
+			available++
+		}
+	}
+	editorsStatus := api.ComponentStatus{Name: "editors", Healthy: available > 0, Message: fmt.Sprintf("%d available", available)}
+	if available == 0 {
+		editorsStatus.Message = "no editor is available on this machine"
+	}
+
+	logStatus := api.ComponentStatus{Name: "log_dir", Healthy: true, Message: "not logging to a file"}
+	if cfg != nil && cfg.Logging.File != "" {
+		dir := filepath.Dir(cfg.Logging.File)
+		if err := config.CheckDirectoryWritable(dir); err != nil {
+			logStatus = api.ComponentStatus{Name: "log_dir", Message: err.Error()}
+		} else {
+			logStatus.Message = dir + " is writable"
+		}
+	}
+
+	shutdownStatus := api.ComponentStatus{Name: "shutdown", Healthy: !s.executor.Draining(), Message: "accepting launches"}
+	if !shutdownStatus.Healthy {
+		shutdownStatus.Message = "shutting down"
+	}
+
+	return []api.ComponentStatus{configStatus, editorsStatus, logStatus, shutdownStatus}
 }
 
 // handleEditors handles GET /editors
@@ -41,68 +122,252 @@ func (s *Server) handleEditors(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	editorList := s.editor.ListEditors()
-	editors := make([]api.EditorInfo, 0, len(editorList))
+	filter, err := parseEditorFilter(r.URL.Query())
+	if err != nil {
+		s.respondError(w, api.ErrInvalidRequest, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, editorsResponse(s.editors(), filter))
+}
+
+// editorFilter selects and pages the editors listed by GET /editors
+type editorFilter struct {
+	available  *bool  // availability to match; nil for any
+	prefix     string // name or alias prefix, in any case
+	capability string // "remote", "local", or empty for any
+	limit      int    // editors returned at most; 0 for all
+	offset     int    // matching editors skipped
+}
+
+// parseEditorFilter reads the available, prefix, capability, limit and
+// offset query parameters
+func parseEditorFilter(query url.Values) (editorFilter, error) {
+	filter := editorFilter{
+		prefix:     strings.ToLower(query.Get("prefix")),
+		capability: query.Get("capability"),
+	}
+	if available := query.Get("available"); available != "" {
+		b, err := strconv.ParseBool(available)
+		if err != nil {
+			return filter, errors.New("available must be true or false")
+		}
+		filter.available = &b
+	}
+	switch filter.capability {
+	case "", "remote", "local":
+	default:
+		return filter, errors.New("capability must be remote or local")
+	}
+	for name, n := range map[string]*int{"limit": &filter.limit, "offset": &filter.offset} {
+		if v := query.Get(name); v != "" {
+			i, err := strconv.Atoi(v)
+			if err != nil || i < 0 {
+				return filter, fmt.Errorf("%s must be a non-negative integer", name)
+			}
+			*n = i
+		}
+	}
+	return filter, nil
+}
+
+// match reports whether e passes the filter
+func (f editorFilter) match(e *editor.Editor) bool {
+	if f.available != nil && e.Available != *f.available {
+		return false
+	}
+	switch f.capability {
+	case "remote":
+		if !e.RequiresHost() {
+			return false
+		}
+	case "local":
+		if e.RequiresHost() {
+			return false
+		}
+	}
+	if f.prefix == "" || strings.HasPrefix(strings.ToLower(e.Name), f.prefix) {
+		return true
+	}
+	for _, alias := range e.Aliases {
+		if strings.HasPrefix(strings.ToLower(alias), f.prefix) {
+			return true
+		}
+	}
+	return false
+}
 
-	for _, e := range editorList {
-		info := api.EditorInfo{
-			Name:      e.Name,
-			Type:      string(e.Type),
-			Command:   e.Command,
-			URL:       e.URL,
-			Available: e.Available,
-			Default:   e.Default,
+// editorsResponse lists the editors known to mgr that pass filter, sorted
+// by name
+func editorsResponse(mgr *editor.Manager, filter editorFilter) api.EditorsResponse {
+	editors := []api.EditorInfo{}
+	for _, e := range mgr.ListEditors() {
+		if filter.match(e) {
+			editors = append(editors, editorInfo(e))
 		}
-		editors = append(editors, info)
 	}
 
 	response := api.EditorsResponse{
-		Editors:       editors,
-		DefaultEditor: s.editor.GetDefaultName(),
+		DefaultEditor: mgr.GetDefaultName(),
+		Total:         len(editors),
 	}
+	editors = editors[min(filter.offset, len(editors)):]
+	if filter.limit > 0 && filter.limit < len(editors) {
+		editors = editors[:filter.limit]
+	}
+	response.Editors = editors
 	response.SetTimestamp()
+	return response
+}
 
-	s.respondJSON(w, http.StatusOK, response)
+// editorInfo describes a single editor
+func editorInfo(e *editor.Editor) api.EditorInfo {
+	info := api.EditorInfo{
+		Name:      e.Name,
+		Type:      string(e.Type),
+		Command:   e.Command,
+		URL:       e.URL,
+		Available: e.Available,
+		Default:   e.Default,
+		Aliases:   e.Aliases,
+		Variables: e.Variables,
+
+		AvailableVia: e.AvailableVia,
+		RequiresHost: e.RequiresHost(),
+		RequiresUser: e.RequiresUser(),
+		Sync:         e.Sync,
+	}
+	if e.Diff != nil {
+		info.Diff = e.Diff.String()
+	}
+	return info
 }
 
-// handleOpenEditor handles POST /open-editor
-func (s *Server) handleOpenEditor(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		s.respondError(w, api.ErrNotImplemented, http.StatusMethodNotAllowed, "Method not allowed")
-		return
+// openPlan is a validated open request with its rendered command
+type openPlan struct {
+	req     api.OpenRequest
+	paths   []string
+	editor  *editor.Editor
+	manager *editor.Manager  // Where editor came from: the editors, or the terminals
+	command string           // Rendered command, or URL for browser editors
+	workdir string           // Rendered working directory, empty for the server's own
+	sync    *filesync.Target // Remote file to pull before launching, for sync editors
+	info    []api.PathInfo   // What the paths name on this machine, with server.path_check
+	err     error            // Why the request was rejected
+	reused  string           // Audit status when answered with an earlier launch's response
+}
+
+// decodeStrictJSON decodes a request body of at most limit bytes into v.
+// Unknown fields and trailing data are errors, so schema drift between
+// client and server shows up instead of being silently ignored. A body over
+// the limit returns an error wrapping api.ErrTooLarge.
+func decodeStrictJSON(w http.ResponseWriter, r *http.Request, limit int64, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, limit))
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(v)
+	if err == nil && dec.More() {
+		err = errors.New("unexpected data after the JSON object")
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return fmt.Errorf("%w: %w", api.ErrTooLarge, err)
 	}
+	return err
+}
+
+// reject records why the plan failed and writes the error response
+func (s *Server) reject(w http.ResponseWriter, plan *openPlan, err error, status int, details string) {
+	plan.err = err
+	s.respondError(w, err, status, details)
+}
 
-	// Limit request body size to prevent DoS (1MB)
-	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+// prepareOpen parses and validates an open request into plan, looks up the
+// editor, and renders its command. On failure it writes the error response
+// and returns false; plan keeps whatever was parsed so far.
+func (s *Server) prepareOpen(w http.ResponseWriter, r *http.Request, plan *openPlan) bool {
+	log := s.requestLog(r)
 
 	// Parse request body
-	var req api.OpenRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		s.respondError(w, api.ErrInvalidRequest, http.StatusBadRequest, fmt.Sprintf("Invalid JSON: %v", err))
-		return
+	req := &plan.req
+	limit := s.currentConfig().Server.MaxBodyBytes()
+	if err := decodeStrictJSON(w, r, limit, req); err != nil {
+		if errors.Is(err, api.ErrTooLarge) {
+			s.reject(w, plan, api.ErrTooLarge, http.StatusRequestEntityTooLarge,
+				fmt.Sprintf("request body is limited to %d KB", limit/1024))
+			return false
+		}
+		s.reject(w, plan, api.ErrInvalidRequest, http.StatusBadRequest, fmt.Sprintf("Invalid JSON: %v", err))
+		return false
 	}
 
 	// Validate request
 	if err := req.Validate(); err != nil {
-		s.respondError(w, err, http.StatusBadRequest, "")
-		return
+		s.reject(w, plan, err, http.StatusBadRequest, "")
+		return false
+	}
+	if err := req.CleanPaths(); err != nil {
+		s.reject(w, plan, api.ErrInvalidPath, http.StatusBadRequest, err.Error())
+		return false
 	}
 
-	// Log the request
-	s.log.Info("Open editor request",
-		"path", req.Path,
-		"editor", req.Editor,
-		"user", req.User,
-		"host", req.Host,
-		"remote_addr", r.RemoteAddr,
-	)
+	paths := req.AllPaths()
+	if req.Path == "" {
+		req.Path = paths[0]
+	}
+	plan.paths = paths
+
+	// Enforce the allowed-paths whitelist
+	allowedPaths := s.currentConfig().Server.AllowedPaths
+	checked := paths
+	if req.Path2 != "" {
+		checked = []string{req.Path, req.Path2}
+	}
+	for _, p := range checked {
+		if !validation.PathAllowed(p, allowedPaths) {
+			log.Warn("Path rejected by allowed_paths",
+				"path", p,
+				"user", req.User,
+				"remote_addr", r.RemoteAddr,
+			)
+			s.reject(w, plan, api.ErrPathNotAllowed, http.StatusForbidden, fmt.Sprintf("%s is outside the allowed paths", p))
+			return false
+		}
+	}
+
+	// Look up the paths on this machine, when it shares the clients' files
+	if check := s.currentConfig().Server.PathCheck; check.Enabled() {
+		plan.info = statPaths(log, checked)
+		// Paths the request asks to create are created before launching
+		if missing := missingPath(plan.info); missing != nil && check.RequireExisting && req.Create == "" {
+			log.Warn("Path rejected by path_check.require_existing",
+				"path", missing.Path,
+				"user", req.User,
+			)
+			s.reject(w, plan, api.ErrPathNotFound, http.StatusNotFound, fmt.Sprintf("%s does not exist on the host", missing.Path))
+			return false
+		}
+	}
+
+	// Requests that name no editor get the user's own default, if any
+	name := req.Editor
+	if name == "" && !req.Terminal {
+		name = s.currentConfig().Users[req.User].DefaultEditor
+	}
 
-	// Look up editor via Manager
-	e, err := s.editor.GetEditor(req.Editor)
+	// Look up editor via Manager; terminals have their own
+	mgr := s.editors()
+	if req.Terminal {
+		if mgr = s.terminals(); mgr == nil {
+			log.Warn("Terminal requested but none is configured", "terminal", name)
+			s.reject(w, plan, api.ErrEditorNotFound, http.StatusNotFound, "no terminal is configured or installed on the host")
+			return false
+		}
+	}
+	e, err := mgr.GetEditor(name)
 	if err != nil {
-		s.log.Error("Failed to find editor",
+		log.Error("Failed to find editor",
 			"error", err,
-			"editor", req.Editor,
+			"editor", name,
 		)
 
 		statusCode := http.StatusInternalServerError
@@ -110,94 +375,635 @@ func (s *Server) handleOpenEditor(w http.ResponseWriter, r *http.Request) {
 			statusCode = http.StatusNotFound
 		}
 
-		s.respondError(w, err, statusCode, "")
-		return
+		s.reject(w, plan, err, statusCode, "")
+		return false
 	}
+	plan.editor, plan.manager = e, mgr
 
+	if failure := s.renderOpen(log, plan, e); failure != nil {
+		s.reject(w, plan, failure.err, failure.status, failure.details)
+		return false
+	}
+	return true
+}
+
+// renderFailure is why an editor could not render a request, with the
+// response status it calls for
+type renderFailure struct {
+	err     error
+	status  int
+	details string
+}
+
+// renderOpen renders the command, or URL for browser editors, and the
+// working directory e would use for plan's request, and stores them with e
+// in plan
+func (s *Server) renderOpen(log *logger.Logger, plan *openPlan, e *editor.Editor) *renderFailure {
+	req, paths := &plan.req, plan.paths
 	resolvedHost := network.ResolveSSHHostAlias(req.Host)
 
 	// Build template variables and render template
 	vars := editor.TemplateVars{
-		User: req.User,
-		Host: resolvedHost,
-		Path: req.Path,
+		User:   req.User,
+		Host:   resolvedHost,
+		Path:   req.Path,
+		Line:   req.Line,
+		Column: req.Column,
+		Port:   req.Port,
+	}
+	if len(paths) > 1 {
+		vars.Paths = paths
+	}
+	// Request values must not break out of their argument, whether the
+	// command is split into argv or run through a shell
+	vars.ShellQuote = e.Type != "browser"
+
+	var command, workdir string
+	var err error
+
+	// Sync editors open a local copy of the file
+	var target *filesync.Target
+	if e.Sync {
+		if len(paths) > 1 || req.Path2 != "" {
+			return &renderFailure{api.ErrInvalidRequest, http.StatusBadRequest, fmt.Sprintf("editor %s syncs a single file at a time", e.Name)}
+		}
+		target = &filesync.Target{User: req.User, Host: resolvedHost, Path: req.Path}
+		vars.Path = s.sync.LocalPath(*target)
+	}
+
+	if req.Path2 != "" {
+		if e.Diff == nil {
+			return &renderFailure{api.ErrInvalidRequest, http.StatusBadRequest, fmt.Sprintf("editor %s has no diff command", e.Name)}
+		}
+		vars.Path2 = req.Path2
+		command, err = e.Diff.Render(vars)
+		if err != nil {
+			log.Error("Failed to render editor diff command",
+				"error", err,
+				"editor", e.Name,
+				"path", req.Path,
+				"path2", req.Path2,
+			)
+			return &renderFailure{err, http.StatusInternalServerError, ""}
+		}
+		plan.editor = e
+		plan.command = normalizeRemoteAuthority(command, req.User, req.Host, resolvedHost)
+		plan.workdir = ""
+		return nil
 	}
 
-	var command string
+	if e.RequiresPort() && req.Port == 0 {
+		return &renderFailure{api.ErrInvalidRequest, http.StatusBadRequest, fmt.Sprintf("editor %s needs the port its web IDE listens on", e.Name)}
+	}
 
 	if e.Type == "browser" {
+		if len(paths) > 1 {
+			return &renderFailure{api.ErrInvalidRequest, http.StatusBadRequest, fmt.Sprintf("editor %s opens a single path at a time", e.Name)}
+		}
 		if e.URLTemplate == nil {
-			s.log.Error("Missing URL template for browser editor",
+			log.Error("Missing URL template for browser editor",
 				"editor", e.Name,
 			)
-			s.respondError(w, editor.ErrInvalidEditor, http.StatusInternalServerError, "missing browser URL template")
-			return
+			return &renderFailure{editor.ErrInvalidEditor, http.StatusInternalServerError, "missing browser URL template"}
 		}
 
 		command, err = e.URLTemplate.Render(vars)
 		if err != nil {
-			s.log.Error("Failed to render editor URL",
+			log.Error("Failed to render editor URL",
 				"error", err,
 				"editor", e.Name,
 				"path", req.Path,
 			)
-			s.respondError(w, err, http.StatusInternalServerError, "")
-			return
+			return &renderFailure{err, http.StatusInternalServerError, ""}
 		}
-
-		// Execute browser open
-		if err := editor.OpenBrowser(command, s.log); err != nil {
-			s.log.Error("Failed to open browser URL",
-				"error", err,
-				"editor", e.Name,
-				"url", command,
-			)
-			s.respondError(w, err, http.StatusInternalServerError, "")
-			return
+		if req.Port != 0 {
+			if failure := s.checkForwardedURL(command); failure != nil {
+				return failure
+			}
 		}
 	} else {
 		command, err = e.Template.Render(vars)
 		if err != nil {
-			s.log.Error("Failed to render editor command",
+			log.Error("Failed to render editor command",
 				"error", err,
 				"editor", e.Name,
 				"path", req.Path,
 			)
-			s.respondError(w, err, http.StatusInternalServerError, "")
-			return
+			return &renderFailure{err, http.StatusInternalServerError, ""}
 		}
 
 		command = normalizeRemoteAuthority(command, req.User, req.Host, resolvedHost)
 
-		// Execute the command
-		if err := editor.ExecuteDetached(command, s.log); err != nil {
-			s.log.Error("Failed to execute editor command",
+		if e.WorkDir != nil {
+			// The directory is not a shell word, and always refers to the first path
+			dirVars := vars
+			dirVars.Paths = nil
+			dirVars.ShellQuote = false
+			workdir, err = e.WorkDir.Render(dirVars)
+			if err != nil {
+				log.Error("Failed to render editor workdir",
+					"error", err,
+					"editor", e.Name,
+					"path", req.Path,
+				)
+				return &renderFailure{err, http.StatusInternalServerError, ""}
+			}
+		}
+
+		if e.Type == config.EditorTypeTmux {
+			if failure := tmuxOpen(log, e, &command, &workdir); failure != nil {
+				return failure
+			}
+		}
+	}
+
+	plan.editor = e
+	plan.command = command
+	plan.workdir = workdir
+	plan.sync = target
+	return nil
+}
+
+// tmuxOpen wraps command in the tmux command that runs it in a new window
+// of e's session, starting in workdir. The window, not tmux itself, gets
+// the working directory, so workdir is cleared.
+func tmuxOpen(log *logger.Logger, e *editor.Editor, command, workdir *string) *renderFailure {
+	session, err := e.TmuxSession()
+	if err != nil {
+		log.Warn("No tmux session to open a window in",
+			"error", err,
+			"editor", e.Name,
+		)
+		return &renderFailure{fmt.Errorf("%w: %w", api.ErrEditorNotAvailable, err), http.StatusServiceUnavailable,
+			"start tmux on the host or set the editor's session"}
+	}
+
+	wrapped, err := editor.TmuxCommand(session, *workdir, *command)
+	if err != nil {
+		return &renderFailure{err, http.StatusInternalServerError, ""}
+	}
+	*command, *workdir = wrapped, ""
+	return nil
+}
+
+// fallbackPlan prepares plan's request for the fallback editor name. It
+// returns nil when that editor is unknown, already tried, unavailable or
+// cannot open the request.
+func (s *Server) fallbackPlan(log *logger.Logger, plan *openPlan, name string, tried map[string]bool) *openPlan {
+	e, err := s.editors().GetEditor(name)
+	if err != nil || tried[e.Name] {
+		return nil
+	}
+	tried[e.Name] = true
+	if !s.editors().IsAvailable(e.Name) {
+		log.Debug("Skipping unavailable fallback editor", "editor", e.Name)
+		return nil
+	}
+
+	fallback := &openPlan{req: plan.req, paths: plan.paths, manager: s.editors(), info: plan.info}
+	if failure := s.renderOpen(log, fallback, e); failure != nil {
+		log.Debug("Skipping fallback editor that cannot open the request",
+			"editor", e.Name,
+			"error", failure.err,
+		)
+		return nil
+	}
+	return fallback
+}
+
+// launchRetryAfter is how long clients refused by a full launch queue are
+// asked to wait
+const launchRetryAfter = time.Second
+
+// launch runs plan's command, or opens its URL for browser editors. details
+// is the output captured from a failed command.
+func (s *Server) launch(ctx context.Context, log *logger.Logger, plan *openPlan) (execution *api.ExecutionInfo, details string, err error) {
+	// Wait for a free launch worker
+	release, err := s.executor.Acquire(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	defer release()
+
+	e, command := plan.editor, plan.command
+	if plan.sync != nil {
+		if _, err := s.sync.Open(ctx, *plan.sync); err != nil {
+			log.Error("Failed to pull file for sync editor",
+				"error", err,
+				"editor", e.Name,
+				"path", plan.sync.Path,
+			)
+			return nil, err.Error(), err
+		}
+	}
+
+	if e.Type == "browser" {
+		if err := s.openBrowser(command, log); err != nil {
+			log.Error("Failed to open browser URL",
 				"error", err,
 				"editor", e.Name,
-				"command", command,
+				"url", command,
 			)
-			s.respondError(w, err, http.StatusInternalServerError, "")
+			return nil, "", err
+		}
+		return nil, "", nil
+	}
+
+	opts := e.Exec
+	opts.Dir = plan.workdir
+	result, err := editor.Execute(command, opts, log)
+	if err != nil {
+		// Return captured output so the remote user can see why the launch failed
+		var failed *editor.ExecError
+		if errors.As(err, &failed) {
+			details = failed.Output
+		}
+		log.Error("Failed to execute editor command",
+			"error", err,
+			"editor", e.Name,
+			"command", command,
+			"output", details,
+		)
+		return nil, details, err
+	}
+	return &api.ExecutionInfo{
+		Outcome:    result.Outcome,
+		Attempts:   result.Attempts,
+		DurationMs: result.Duration.Milliseconds(),
+	}, "", nil
+}
+
+// claimLaunch joins the launch for key in d, waiting for a launch in
+// progress. It returns the response of an earlier successful launch to answer
+// with, or else finish, which the caller now running the launch must call with
+// its response. ok is false when the request was canceled while waiting and
+// the error response has been written.
+func (s *Server) claimLaunch(w http.ResponseWriter, r *http.Request, plan *openPlan, d *openDedup, key string, window time.Duration) (original *api.OpenResponse, finish func(*api.OpenResponse), ok bool) {
+	for {
+		entry, leader := d.join(key, window, time.Now())
+		if leader {
+			return nil, func(response *api.OpenResponse) { d.finish(key, entry, response, time.Now()) }, true
+		}
+		select {
+		case <-entry.done:
+		case <-r.Context().Done():
+			s.reject(w, plan, r.Context().Err(), http.StatusServiceUnavailable, "request canceled")
+			return nil, nil, false
+		}
+		if entry.response != nil {
+			return entry.response, nil, true
+		}
+	}
+}
+
+// handleOpenEditor handles POST /open-editor
+func (s *Server) handleOpenEditor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.respondError(w, api.ErrNotImplemented, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	log := s.requestLog(r)
+	plan := &openPlan{}
+	rec := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+	w = rec
+
+	var execErr error
+	var execution *api.ExecutionInfo
+	defer func() {
+		s.auditOpen(r, plan, rec.statusCode, execution, execErr)
+	}()
+
+	if !s.prepareOpen(w, r, plan) {
+		return
+	}
+	req, paths, e, command := &plan.req, plan.paths, plan.editor, plan.command
+
+	// Log the request
+	log.Info("Open editor request",
+		"path", req.Path,
+		"paths", len(paths),
+		"path2", req.Path2,
+		"editor", e.Name,
+		"terminal", req.Terminal,
+		"user", req.User,
+		"host", req.Host,
+		"remote_addr", r.RemoteAddr,
+	)
+
+	// A retry of a request that already launched gets the original response
+	var response *api.OpenResponse
+	if key := r.Header.Get(api.HeaderIdempotencyKey); key != "" {
+		if !api.ValidIdempotencyKey(key) {
+			s.reject(w, plan, api.ErrInvalidRequest, http.StatusBadRequest,
+				"Idempotency-Key must be up to 64 letters, digits, dots, dashes and underscores")
+			return
+		}
+		// Scoped to the request, so a reused key cannot return another's response
+		key += "\x00" + dedupKey(req, paths, e.Name)
+		original, finish, ok := s.claimLaunch(w, r, plan, &s.idempotency, key, s.currentConfig().Server.Launch.IdempotencyTTL())
+		if !ok {
+			return
+		}
+		if original != nil {
+			plan.reused = audit.StatusReplayed
+			log.Info("Replayed response to a retried open request", "path", req.Path, "editor", e.Name)
+			w.Header().Set(api.HeaderIdempotentReplayed, "true")
+			s.respondJSON(w, http.StatusOK, original)
+			return
+		}
+		defer func() { finish(response) }()
+	}
+
+	// Identical requests in quick succession share one launch
+	if window := s.currentConfig().Server.Launch.DedupWindow; window > 0 {
+		original, finish, ok := s.claimLaunch(w, r, plan, &s.dedup, dedupKey(req, paths, e.Name), window)
+		if !ok {
+			return
+		}
+		if original != nil {
+			plan.reused = audit.StatusCoalesced
+			log.Info("Coalesced duplicate open request", "path", req.Path, "editor", e.Name)
+			coalesced := *original
+			coalesced.Coalesced = true
+			coalesced.RequestID = w.Header().Get(api.HeaderRequestID)
+			coalesced.SetTimestamp()
+			response = &coalesced
+			s.respondJSON(w, http.StatusOK, coalesced)
+			return
+		}
+		defer func() { finish(response) }()
+	}
+
+	// Track the launch so a shutting-down server waits for it
+	launched, err := s.executor.Begin()
+	if err != nil {
+		s.reject(w, plan, api.ErrShuttingDown, http.StatusServiceUnavailable, "try again shortly or use another host")
+		return
+	}
+	defer launched()
+
+	// Create missing paths for rcode --create, then look them up again. Only
+	// the request that launches creates them: not a replayed, coalesced or
+	// refused one
+	var created []string
+	if req.Create != "" {
+		var failure *renderFailure
+		if created, failure = s.createPaths(log, plan); failure != nil {
+			s.reject(w, plan, failure.err, failure.status, failure.details)
 			return
 		}
+		if len(created) > 0 && plan.info != nil {
+			plan.info = statPaths(log, infoPaths(plan.info))
+		}
+	}
+
+	_, span := s.telemetry.Start(r.Context(), "editor.execute", telemetry.KindInternal,
+		slog.String("rcode.editor", e.Name),
+		slog.String("rcode.editor.type", string(e.Type)),
+	)
+	defer func() {
+		span.RecordError(execErr)
+		span.End()
+		s.telemetry.Count("rcode.server.editor.launches", 1,
+			slog.String("rcode.editor", e.Name),
+			slog.Bool("success", execErr == nil),
+		)
+	}()
+
+	// When the editor fails to launch, try the configured fallbacks in order
+	requested := e.Name
+	var details string
+	execution, details, execErr = s.launch(r.Context(), log, plan)
+	if errors.Is(execErr, editor.ErrBusy) {
+		log.Warn("Launch queue full, refusing request",
+			"editor", e.Name,
+			"in_flight", s.executor.InFlight(),
+			"queued", s.executor.Queued(),
+		)
+		w.Header().Set("Retry-After", strconv.Itoa(int(launchRetryAfter.Seconds())))
+		s.reject(w, plan, api.ErrBusy, http.StatusServiceUnavailable, "too many editor launches, try again shortly")
+		return
+	}
+	if execErr != nil && !req.Terminal {
+		tried := map[string]bool{requested: true}
+		for _, name := range s.currentConfig().Server.EditorFallback {
+			fallback := s.fallbackPlan(log, plan, name, tried)
+			if fallback == nil {
+				continue
+			}
+			log.Warn("Trying fallback editor",
+				"failed", requested,
+				"editor", fallback.editor.Name,
+			)
+			if result, _, err := s.launch(r.Context(), log, fallback); err == nil {
+				*plan = *fallback
+				e, command, execution, execErr = plan.editor, plan.command, result, nil
+				span.SetAttributes(slog.String("rcode.editor.fallback", e.Name))
+				break
+			}
+		}
+	}
+	if execErr != nil {
+		s.publishOpenEvent(api.EventOpenFailed, req, paths, requested, execErr)
+		s.respondError(w, execErr, http.StatusInternalServerError, details)
+		return
+	}
+	if execution != nil {
+		span.SetAttributes(slog.String("rcode.execution.outcome", execution.Outcome))
 	}
 
+	// A running editor may take the path without raising its window
+	editor.Activate(e.Activate, log)
+
 	editorName := req.Editor
-	if editorName == "" {
+	if editorName == "" || e.Name != requested {
 		editorName = e.Name
 	}
 
+	// Remember the session so it can be re-opened later
+	if !req.Terminal && req.Path2 == "" {
+		entry := session.Entry{
+			Path:   req.Path,
+			Editor: e.Name,
+			User:   req.User,
+			Host:   req.Host,
+		}
+		if len(paths) > 1 {
+			entry.Paths = paths
+		}
+		if err := s.sessions.Record(entry); err != nil {
+			log.Warn("Failed to record session", "error", err, "path", req.Path)
+		}
+	}
+
+	s.publishOpenEvent(api.EventOpen, req, paths, e.Name, nil)
+
+	opened := strings.Join(paths, ", ")
+	if req.Path2 != "" {
+		opened = fmt.Sprintf("%s and %s", req.Path, req.Path2)
+	}
+
 	// Success response
-	response := api.OpenResponse{
-		Success: true,
-		Message: fmt.Sprintf("Opened %s in %s", req.Path, editorName),
-		Editor:  editorName,
-		Command: command,
+	response = api.NewOpenResponse(editorName, command, fmt.Sprintf("Opened %s in %s", opened, editorName))
+	response.Execution = execution
+	response.PathInfo = plan.info
+	response.Created = created
+	response.RequestID = w.Header().Get(api.HeaderRequestID)
+	if e.Name != requested {
+		response.FallbackFrom = requested
+		response.Message += fmt.Sprintf(" (%s failed to launch)", requested)
+	}
+
+	s.respondJSON(w, http.StatusOK, response)
+}
+
+// handleRender handles POST /render, returning the command /open-editor
+// would run without executing it
+func (s *Server) handleRender(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.respondError(w, api.ErrNotImplemented, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	plan := &openPlan{}
+	if !s.prepareOpen(w, r, plan) {
+		return
+	}
+
+	editorType := string(plan.editor.Type)
+	if editorType == "" {
+		editorType = "command"
+	}
+
+	response := api.RenderResponse{
+		Editor:    plan.editor.Name,
+		Type:      editorType,
+		Command:   plan.command,
+		WorkDir:   plan.workdir,
+		Available: plan.manager.IsAvailable(plan.editor.Name),
+	}
+	response.SetTimestamp()
+
+	s.respondJSON(w, http.StatusOK, response)
+}
+
+// handleSessions handles GET /sessions
+func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, api.ErrNotImplemented, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	filter := session.Filter{
+		User: query.Get("user"),
+		Host: query.Get("host"),
+	}
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			s.respondError(w, api.ErrInvalidRequest, http.StatusBadRequest, "limit must be a non-negative integer")
+			return
+		}
+		filter.Limit = n
 	}
+
+	entries := s.sessions.List(filter)
+	sessions := make([]api.SessionInfo, 0, len(entries))
+	for _, e := range entries {
+		sessions = append(sessions, api.SessionInfo{
+			Path:     e.Path,
+			Paths:    e.Paths,
+			Editor:   e.Editor,
+			User:     e.User,
+			Host:     e.Host,
+			OpenedAt: e.OpenedAt,
+		})
+	}
+
+	response := api.SessionsResponse{Sessions: sessions}
 	response.SetTimestamp()
 
 	s.respondJSON(w, http.StatusOK, response)
 }
 
+// auditOpen writes the outcome of an /open-editor request to the audit log,
+// with the exit status of commands that were watched until they exited
+func (s *Server) auditOpen(r *http.Request, plan *openPlan, status int, execution *api.ExecutionInfo, execErr error) {
+	if s.audit == nil {
+		return
+	}
+
+	paths := plan.paths
+	if len(paths) == 0 {
+		paths = plan.req.AllPaths()
+	}
+
+	rec := audit.Record{
+		ClientIP:   getClientIP(r),
+		RequestID:  logger.GetTraceID(r.Context()),
+		User:       plan.req.User,
+		Host:       plan.req.Host,
+		Editor:     plan.req.Editor,
+		Paths:      paths,
+		Command:    plan.command,
+		HTTPStatus: status,
+	}
+	if plan.editor != nil {
+		rec.Editor = plan.editor.Name
+	}
+
+	switch {
+	case execErr != nil:
+		rec.Status = audit.StatusFailed
+		rec.Error = execErr.Error()
+		var exited *exec.ExitError
+		if errors.As(execErr, &exited) {
+			code := exited.ExitCode()
+			rec.Outcome, rec.ExitCode = editor.OutcomeExited, &code
+		}
+	case plan.reused != "" && status == http.StatusOK:
+		rec.Status = plan.reused
+	case plan.err != nil || status != http.StatusOK:
+		rec.Status = audit.StatusRejected
+		if plan.err != nil {
+			rec.Error = plan.err.Error()
+		}
+	default:
+		rec.Status = audit.StatusExecuted
+		if execution != nil {
+			rec.Outcome = execution.Outcome
+			if execution.Outcome == editor.OutcomeExited {
+				code := 0
+				rec.ExitCode = &code
+			}
+		}
+	}
+
+	if err := s.audit.Log(rec); err != nil {
+		s.log.Error("Failed to write audit log", "error", err)
+	}
+}
+
+// publishOpenEvent notifies /events subscribers about an open request
+func (s *Server) publishOpenEvent(eventType string, req *api.OpenRequest, paths []string, editorName string, err error) {
+	event := api.Event{
+		Type:   eventType,
+		Path:   req.Path,
+		Editor: editorName,
+		User:   req.User,
+		Host:   req.Host,
+	}
+	if len(paths) > 1 {
+		event.Paths = paths
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	s.events.publish(event)
+}
+
 func normalizeRemoteAuthority(command, user, originalHost, resolvedHost string) string {
 	if user == "" || originalHost == resolvedHost {
 		return command
@@ -221,6 +1027,7 @@ func (s *Server) respondJSON(w http.ResponseWriter, status int, data interface{}
 // respondError sends an error response
 func (s *Server) respondError(w http.ResponseWriter, err error, status int, details string) {
 	response := api.NewErrorResponse(err, api.GetErrorCode(err), details)
+	response.RequestID = w.Header().Get(api.HeaderRequestID)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
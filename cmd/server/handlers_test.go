@@ -2,17 +2,24 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/foxytanuki/rcode/internal/audit"
 	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/internal/filesync"
 	"github.com/foxytanuki/rcode/internal/logger"
+	"github.com/foxytanuki/rcode/internal/session"
 	"github.com/foxytanuki/rcode/internal/version"
 	"github.com/foxytanuki/rcode/pkg/api"
 )
@@ -66,6 +73,55 @@ func TestHandleHealth(t *testing.T) {
 	}
 }
 
+func TestHandleReady(t *testing.T) {
+	server := createTestServer()
+	server.config.Logging.File = filepath.Join(t.TempDir(), "server.log")
+
+	ready := func() (int, map[string]api.ComponentStatus) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", http.NoBody))
+
+		var resp api.HealthResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if (rec.Code == http.StatusOK) != resp.IsHealthy() {
+			t.Errorf("status code %d with health status %q", rec.Code, resp.Status)
+		}
+		components := map[string]api.ComponentStatus{}
+		for _, c := range resp.Components {
+			components[c.Name] = c
+		}
+		return rec.Code, components
+	}
+
+	code, components := ready()
+	if code != http.StatusOK {
+		t.Fatalf("/readyz status = %d, want %d (components %+v)", code, http.StatusOK, components)
+	}
+	for _, name := range []string{"config", "editors", "log_dir", "shutdown"} {
+		if c, ok := components[name]; !ok || !c.Healthy {
+			t.Errorf("component %s = %+v, want healthy", name, c)
+		}
+	}
+
+	if err := server.Drain(context.Background()); err != nil {
+		t.Fatalf("Drain() error = %v", err)
+	}
+	code, components = ready()
+	if code != http.StatusServiceUnavailable || components["shutdown"].Healthy {
+		t.Errorf("/readyz while draining = %d, shutdown %+v; want 503 and unhealthy", code, components["shutdown"])
+	}
+
+	// Liveness does not depend on readiness
+	rec := httptest.NewRecorder()
+	server.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", http.NoBody))
+	if rec.Code != http.StatusOK {
+		t.Errorf("/healthz while draining = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
 func TestHandleEditors(t *testing.T) {
 	server := createTestServer()
 
@@ -103,6 +159,67 @@ func TestHandleEditors(t *testing.T) {
 	}
 }
 
+func TestHandleEditorsFilter(t *testing.T) {
+	server := createTestServer()
+	for _, e := range []config.EditorConfig{
+		{Name: "remote-editor", Command: "rcode-test-no-such-editor --remote {user}@{host} {path}", Aliases: []string{"re"}},
+		{Name: "local-editor", Command: "rcode-test-no-such-editor {path}"},
+	} {
+		if err := server.editors().AddEditor(e); err != nil {
+			t.Fatalf("AddEditor() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		query      string
+		wantStatus int
+		wantNames  []string
+		wantTotal  int
+	}{
+		{"", http.StatusOK, []string{"another-editor", "local-editor", "remote-editor", "test-editor"}, 4},
+		{"?capability=remote", http.StatusOK, []string{"remote-editor", "test-editor"}, 2},
+		{"?capability=local", http.StatusOK, []string{"another-editor", "local-editor"}, 2},
+		{"?prefix=RE", http.StatusOK, []string{"remote-editor"}, 1},
+		{"?available=true&capability=local", http.StatusOK, []string{"another-editor"}, 1},
+		{"?available=false", http.StatusOK, []string{"local-editor", "remote-editor"}, 2},
+		{"?limit=2&offset=1", http.StatusOK, []string{"local-editor", "remote-editor"}, 4},
+		{"?offset=10", http.StatusOK, []string{}, 4},
+		{"?available=maybe", http.StatusBadRequest, nil, 0},
+		{"?capability=cloud", http.StatusBadRequest, nil, 0},
+		{"?limit=-1", http.StatusBadRequest, nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			server.handleEditors(rec, httptest.NewRequest(http.MethodGet, "/editors"+tt.query, http.NoBody))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("handleEditors() status = %v, want %v", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp api.EditorsResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			names := []string{}
+			for _, e := range resp.Editors {
+				names = append(names, e.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantNames, ",") || resp.Total != tt.wantTotal {
+				t.Errorf("editors = %v (total %d), want %v (total %d)", names, resp.Total, tt.wantNames, tt.wantTotal)
+			}
+			for _, e := range resp.Editors {
+				if e.Name == "remote-editor" && (!e.RequiresHost || !e.RequiresUser) {
+					t.Errorf("remote-editor requires_host = %v, requires_user = %v, want both", e.RequiresHost, e.RequiresUser)
+				}
+			}
+		})
+	}
+}
+
 func TestHandleOpenEditor(t *testing.T) {
 	server := createTestServer()
 
@@ -175,6 +292,58 @@ func TestHandleOpenEditor(t *testing.T) {
 	}
 }
 
+func TestHandleOpenEditorStrictBody(t *testing.T) {
+	server := createTestServer()
+	server.config.Server.MaxBodyKB = 1
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name:       "unknown field",
+			body:       `{"path":"/p","editor":"test-editor","user":"u","host":"h","colour":"red"}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   api.CodeInvalidRequest,
+		},
+		{
+			name:       "trailing data",
+			body:       `{"path":"/p","editor":"test-editor","user":"u","host":"h"} {}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   api.CodeInvalidRequest,
+		},
+		{
+			name:       "oversized body",
+			body:       `{"path":"/` + strings.Repeat("a", 2048) + `","user":"u","host":"h"}`,
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantCode:   api.CodeTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/open-editor", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			server.handleOpenEditor(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v", rec.Code, tt.wantStatus)
+			}
+			var resp api.ErrorResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if resp.Code != tt.wantCode {
+				t.Errorf("error code = %v, want %v", resp.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
 func TestHandleOpenEditorResolvesSSHAlias(t *testing.T) {
 	homeDir := t.TempDir()
 	sshDir := homeDir + "/.ssh"
@@ -231,102 +400,1163 @@ func TestHandleOpenEditorResolvesSSHAlias(t *testing.T) {
 	}
 }
 
-func TestRespondJSON(t *testing.T) {
+func TestHandleOpenEditorAllowedPaths(t *testing.T) {
 	server := createTestServer()
+	server.config.Server.AllowedPaths = []string{"/home/user/projects"}
 
-	data := map[string]string{
-		"test": "value",
-		"foo":  "bar",
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+	}{
+		{"inside allowed root", "/home/user/projects/app", http.StatusOK},
+		{"outside allowed root", "/etc", http.StatusForbidden},
+		{"traversal out of root", "/home/user/projects/../../../etc", http.StatusForbidden},
 	}
 
-	rec := httptest.NewRecorder()
-	server.respondJSON(rec, http.StatusOK, data)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(api.OpenRequest{
+				Path:   tt.path,
+				Editor: "test-editor",
+				User:   "testuser",
+				Host:   "testhost",
+			})
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
 
-	if rec.Code != http.StatusOK {
-		t.Errorf("respondJSON() status = %v, want %v", rec.Code, http.StatusOK)
+			req := httptest.NewRequest(http.MethodPost, "/open-editor", bytes.NewReader(body))
+			rec := httptest.NewRecorder()
+
+			server.handleOpenEditor(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("handleOpenEditor() status = %v, want %v", rec.Code, tt.wantStatus)
+			}
+
+			if tt.wantStatus == http.StatusForbidden {
+				var resp api.ErrorResponse
+				if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+					t.Fatalf("Decode() error = %v", err)
+				}
+				if resp.Code != api.CodePathNotAllowed {
+					t.Errorf("Error code = %v, want %v", resp.Code, api.CodePathNotAllowed)
+				}
+			}
+		})
 	}
+}
 
-	contentType := rec.Header().Get("Content-Type")
-	if contentType != "application/json" {
-		t.Errorf("Content-Type = %v, want application/json", contentType)
+func TestHandleOpenEditorPathCheck(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
 	}
+	file := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "new.go")
 
-	var result map[string]string
-	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
-		t.Errorf("Failed to decode response: %v", err)
+	tests := []struct {
+		name       string
+		check      config.PathCheckConfig
+		path       string
+		wantStatus int
+		wantInfo   []api.PathInfo
+	}{
+		{"disabled", config.PathCheckConfig{}, missing, http.StatusOK, nil},
+		{"stat file", config.PathCheckConfig{Stat: true}, file, http.StatusOK, []api.PathInfo{{Path: file, Exists: true}}},
+		{"stat directory", config.PathCheckConfig{Stat: true}, dir, http.StatusOK, []api.PathInfo{{Path: dir, Exists: true, IsDir: true}}},
+		{"stat missing", config.PathCheckConfig{Stat: true}, missing, http.StatusOK, []api.PathInfo{{Path: missing}}},
+		{"require existing", config.PathCheckConfig{RequireExisting: true}, file, http.StatusOK, []api.PathInfo{{Path: file, Exists: true}}},
+		{"require existing missing", config.PathCheckConfig{RequireExisting: true}, missing, http.StatusNotFound, nil},
 	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := createTestServer()
+			server.config.Server.PathCheck = tt.check
 
-	if result["test"] != "value" || result["foo"] != "bar" {
-		t.Errorf("Unexpected response data: %v", result)
+			body, err := json.Marshal(api.OpenRequest{
+				Path:   tt.path,
+				Editor: "test-editor",
+				User:   "testuser",
+				Host:   "testhost",
+			})
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/open-editor", bytes.NewReader(body))
+			rec := httptest.NewRecorder()
+
+			server.handleOpenEditor(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("handleOpenEditor() status = %v, want %v: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				var resp api.ErrorResponse
+				if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+					t.Fatalf("Decode() error = %v", err)
+				}
+				if resp.Code != api.CodePathNotFound {
+					t.Errorf("Error code = %v, want %v", resp.Code, api.CodePathNotFound)
+				}
+				return
+			}
+
+			var resp api.OpenResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if !slices.Equal(resp.PathInfo, tt.wantInfo) {
+				t.Errorf("PathInfo = %+v, want %+v", resp.PathInfo, tt.wantInfo)
+			}
+		})
 	}
 }
 
-func TestRespondError(t *testing.T) {
+func TestHandleOpenEditorCreate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses symlinks")
+	}
+	base, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := filepath.Join(base, "projects")
+	outside := filepath.Join(base, "outside")
+	for _, dir := range []string{root, outside} {
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	existing := filepath.Join(root, "main.go")
+	if err := os.WriteFile(existing, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// A link inside the allowed root that leads out of it
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	enabled := config.CreateConfig{Enabled: true}
+	tests := []struct {
+		name        string
+		create      config.CreateConfig
+		check       config.PathCheckConfig
+		path        string
+		kind        string
+		wantStatus  int
+		wantCode    string
+		wantCreated bool
+		wantDir     bool
+	}{
+		{"file", enabled, config.PathCheckConfig{}, filepath.Join(root, "new.go"), api.CreateFile, http.StatusOK, "", true, false},
+		{"directory with parents", enabled, config.PathCheckConfig{}, filepath.Join(root, "a", "b"), api.CreateDir, http.StatusOK, "", true, true},
+		{"with require_existing", enabled, config.PathCheckConfig{RequireExisting: true}, filepath.Join(root, "req.go"), api.CreateFile, http.StatusOK, "", true, false},
+		{"existing", config.CreateConfig{}, config.PathCheckConfig{}, existing, api.CreateFile, http.StatusOK, "", false, false},
+		{"disabled", config.CreateConfig{}, config.PathCheckConfig{}, filepath.Join(root, "off.go"), api.CreateFile, http.StatusForbidden, api.CodeDisabled, false, false},
+		{"outside allowed paths", enabled, config.PathCheckConfig{}, filepath.Join(outside, "new.go"), api.CreateFile, http.StatusForbidden, api.CodePathNotAllowed, false, false},
+		{"through a link out of the root", enabled, config.PathCheckConfig{}, filepath.Join(root, "escape", "new.go"), api.CreateFile, http.StatusForbidden, api.CodePathNotAllowed, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := createTestServer()
+			server.config.Server.AllowedPaths = []string{root}
+			server.config.Server.Create = tt.create
+			server.config.Server.PathCheck = tt.check
+
+			body, err := json.Marshal(api.OpenRequest{
+				Path:   tt.path,
+				Editor: "test-editor",
+				User:   "testuser",
+				Host:   "testhost",
+				Create: tt.kind,
+			})
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/open-editor", bytes.NewReader(body))
+			rec := httptest.NewRecorder()
+
+			server.handleOpenEditor(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("handleOpenEditor() status = %v, want %v: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				var resp api.ErrorResponse
+				if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+					t.Fatalf("Decode() error = %v", err)
+				}
+				if resp.Code != tt.wantCode {
+					t.Errorf("Error code = %v, want %v", resp.Code, tt.wantCode)
+				}
+				target, _ := filepath.EvalSymlinks(filepath.Dir(tt.path))
+				if _, err := os.Stat(filepath.Join(target, filepath.Base(tt.path))); err == nil {
+					t.Errorf("%s was created by a refused request", tt.path)
+				}
+				return
+			}
+
+			var resp api.OpenResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if created := len(resp.Created) == 1 && resp.Created[0] == tt.path; created != tt.wantCreated {
+				t.Errorf("Created = %v, want %v created: %v", resp.Created, tt.path, tt.wantCreated)
+			}
+			info, err := os.Stat(tt.path)
+			if err != nil {
+				t.Fatalf("%s does not exist after the request: %v", tt.path, err)
+			}
+			if info.IsDir() != tt.wantDir {
+				t.Errorf("%s IsDir = %v, want %v", tt.path, info.IsDir(), tt.wantDir)
+			}
+			if tt.check.Enabled() && (len(resp.PathInfo) != 1 || !resp.PathInfo[0].Exists) {
+				t.Errorf("PathInfo = %+v, want the created path", resp.PathInfo)
+			}
+		})
+	}
+}
+
+func TestHandleOpenEditorCreateOnlyWhenLaunching(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
 	server := createTestServer()
+	server.config.Server.AllowedPaths = []string{root}
+	server.config.Server.Create = config.CreateConfig{Enabled: true}
+	server.config.Server.Launch.DedupWindow = time.Minute
 
-	rec := httptest.NewRecorder()
-	server.respondError(rec, api.ErrInvalidPath, http.StatusBadRequest, "test details")
+	path := filepath.Join(root, "new.go")
+	open := func() int {
+		body, err := json.Marshal(api.OpenRequest{Path: path, User: "testuser", Host: "testhost", Create: api.CreateFile})
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		rec := httptest.NewRecorder()
+		server.handleOpenEditor(rec, httptest.NewRequest(http.MethodPost, "/open-editor", bytes.NewReader(body)))
+		return rec.Code
+	}
 
-	if rec.Code != http.StatusBadRequest {
-		t.Errorf("respondError() status = %v, want %v", rec.Code, http.StatusBadRequest)
+	if code := open(); code != http.StatusOK {
+		t.Fatalf("first request status = %v, want %v", code, http.StatusOK)
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("first request did not create %s: %v", path, err)
 	}
 
-	var resp api.ErrorResponse
-	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
-		t.Errorf("Failed to decode response: %v", err)
+	// Coalesced onto the first launch: nothing is created
+	if code := open(); code != http.StatusOK {
+		t.Fatalf("coalesced request status = %v, want %v", code, http.StatusOK)
+	}
+	if _, err := os.Stat(path); err == nil {
+		t.Error("a coalesced request created the path")
 	}
 
-	if resp.Message != api.ErrInvalidPath.Error() {
-		t.Errorf("Error message = %v, want %v", resp.Message, api.ErrInvalidPath.Error())
+	// Refused while draining: nothing is created
+	server.config.Server.Launch.DedupWindow = 0
+	if err := server.Drain(context.Background()); err != nil {
+		t.Fatalf("Drain() error = %v", err)
+	}
+	if code := open(); code != http.StatusServiceUnavailable {
+		t.Fatalf("draining request status = %v, want %v", code, http.StatusServiceUnavailable)
 	}
+	if _, err := os.Stat(path); err == nil {
+		t.Error("a request refused while draining created the path")
+	}
+}
 
-	if resp.Details != "test details" {
-		t.Errorf("Error details = %v, want 'test details'", resp.Details)
+// nopWriteCloser adapts a buffer for audit.NewWithWriter
+type nopWriteCloser struct{ *bytes.Buffer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+func TestHandleOpenEditorAudit(t *testing.T) {
+	server := createTestServer()
+	server.config.Server.AllowedPaths = []string{"/home/user/projects"}
+	var buf bytes.Buffer
+	server.audit = audit.NewWithWriter(nopWriteCloser{&buf})
+
+	for _, path := range []string{"/home/user/projects/app", "/etc"} {
+		body, err := json.Marshal(api.OpenRequest{
+			Path:   path,
+			Editor: "test-editor",
+			User:   "testuser",
+			Host:   "testhost",
+		})
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/open-editor", bytes.NewReader(body))
+		server.handleOpenEditor(httptest.NewRecorder(), req)
 	}
 
-	if resp.Code != api.CodeInvalidPath {
-		t.Errorf("Error code = %v, want %v", resp.Code, api.CodeInvalidPath)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("audit log has %d lines, want 2:\n%s", len(lines), buf.String())
+	}
+
+	tests := []struct {
+		wantStatus string
+		wantHTTP   int
+		wantPath   string
+	}{
+		{audit.StatusExecuted, http.StatusOK, "/home/user/projects/app"},
+		{audit.StatusRejected, http.StatusForbidden, "/etc"},
+	}
+	for i, tt := range tests {
+		var rec audit.Record
+		if err := json.Unmarshal([]byte(lines[i]), &rec); err != nil {
+			t.Fatalf("Unmarshal() error = %v", err)
+		}
+		if rec.Status != tt.wantStatus || rec.HTTPStatus != tt.wantHTTP {
+			t.Errorf("record %d status = %v/%v, want %v/%v", i, rec.Status, rec.HTTPStatus, tt.wantStatus, tt.wantHTTP)
+		}
+		if len(rec.Paths) != 1 || rec.Paths[0] != tt.wantPath {
+			t.Errorf("record %d paths = %v, want [%v]", i, rec.Paths, tt.wantPath)
+		}
+		if rec.User != "testuser" || rec.Editor != "test-editor" {
+			t.Errorf("record %d = %+v, want testuser in test-editor", i, rec)
+		}
 	}
 }
 
-// Helper function to create a test server
-func createTestServer() *Server {
-	cfg := &config.ServerConfigFile{
-		Server: config.ServerConfig{
-			Host:         "localhost",
-			Port:         3339,
-			ReadTimeout:  10 * time.Second,
-			WriteTimeout: 10 * time.Second,
-			IdleTimeout:  120 * time.Second,
-		},
-		Editors: []config.EditorConfig{
-			{
-				Name:      "test-editor",
-				Command:   "echo 'Opening {path} for {user}@{host}'",
-				Default:   true,
-				Available: true,
-			},
-			{
-				Name:      "another-editor",
-				Command:   "echo 'Another {path}'",
-				Default:   false,
-				Available: false,
-			},
-		},
-		Logging: config.LogConfig{
-			Level:   "info",
-			Console: false,
-		},
+func TestHandleOpenEditorAuditExitCode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
 	}
 
-	log := logger.New(&logger.Config{
-		Level:   "error", // Quiet logs for tests
-		Console: false,
-	})
+	server := createTestServer()
+	cfg := *server.currentConfig()
+	cfg.Editors = append(cfg.Editors,
+		config.EditorConfig{Name: "exiting-editor", Command: "true {path}", WaitForExit: true},
+		config.EditorConfig{Name: "failing-editor", Command: "sh -c 'exit 3' {path}", WaitForExit: true},
+	)
+	if err := server.Reload(&cfg); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	var buf bytes.Buffer
+	server.audit = audit.NewWithWriter(nopWriteCloser{&buf})
 
-	srv, err := NewServer(cfg, log)
-	if err != nil {
-		panic(fmt.Sprintf("createTestServer: %v", err))
+	tests := []struct {
+		editor      string
+		wantStatus  string
+		wantOutcome string
+		wantCode    int
+	}{
+		{"exiting-editor", audit.StatusExecuted, "exited", 0},
+		{"failing-editor", audit.StatusFailed, "exited", 3},
+	}
+	for _, tt := range tests {
+		buf.Reset()
+		body, err := json.Marshal(api.OpenRequest{
+			Path:   "/home/user/projects/app",
+			Editor: tt.editor,
+			User:   "testuser",
+			Host:   "testhost",
+		})
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/open-editor", bytes.NewReader(body))
+		server.handleOpenEditor(httptest.NewRecorder(), req)
+
+		var rec audit.Record
+		if err := json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &rec); err != nil {
+			t.Fatalf("Unmarshal(%q) error = %v", buf.String(), err)
+		}
+		if rec.Status != tt.wantStatus || rec.Outcome != tt.wantOutcome {
+			t.Errorf("%s: status/outcome = %v/%v, want %v/%v", tt.editor, rec.Status, rec.Outcome, tt.wantStatus, tt.wantOutcome)
+		}
+		if rec.ExitCode == nil || *rec.ExitCode != tt.wantCode {
+			t.Errorf("%s: exit code = %v, want %d", tt.editor, rec.ExitCode, tt.wantCode)
+		}
+	}
+}
+
+func TestHandleOpenEditorMultiplePaths(t *testing.T) {
+	server := createTestServer()
+
+	body, err := json.Marshal(api.OpenRequest{
+		Paths:  []string{"/home/user/a", "/home/user/b"},
+		Editor: "test-editor",
+		User:   "testuser",
+		Host:   "testhost",
+	})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/open-editor", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	server.handleOpenEditor(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("handleOpenEditor() status = %v, want %v", rec.Code, http.StatusOK)
+	}
+
+	var resp api.OpenResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	want := "echo 'Opening /home/user/a /home/user/b for testuser@testhost'"
+	if resp.Command != want {
+		t.Errorf("Command = %v, want %v", resp.Command, want)
+	}
+}
+
+func TestHandleOpenEditorBrowser(t *testing.T) {
+	server := createTestServer()
+	cfg := *server.currentConfig()
+	cfg.Editors = append(cfg.Editors, config.EditorConfig{
+		Name: "code-server",
+		Type: config.EditorTypeBrowser,
+		URL:  "http://{host}:8080/?folder={path|urlencode}",
+	})
+	if err := server.Reload(&cfg); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	var opened []string
+	server.openBrowser = func(url string, _ *logger.Logger) error {
+		opened = append(opened, url)
+		return nil
+	}
+
+	body, err := json.Marshal(api.OpenRequest{
+		Path:   "/home/user/project",
+		Editor: "code-server",
+		User:   "testuser",
+		Host:   "testhost",
+	})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	server.handleOpenEditor(rec, httptest.NewRequest(http.MethodPost, "/open-editor", bytes.NewReader(body)))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("handleOpenEditor() status = %v, want %v: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	want := "http://testhost:8080/?folder=%2Fhome%2Fuser%2Fproject"
+	if len(opened) != 1 || opened[0] != want {
+		t.Errorf("opened %q, want [%s]", opened, want)
+	}
+}
+
+func TestHandleOpenEditorReturnsCommandOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses ls")
+	}
+
+	server := createTestServer()
+	cfg := *server.currentConfig()
+	cfg.Editors = append(cfg.Editors, config.EditorConfig{
+		Name:        "failing-editor",
+		Command:     "ls {path}",
+		Available:   true,
+		WaitForExit: true,
+		CaptureKB:   1,
+	})
+	if err := server.Reload(&cfg); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	body, err := json.Marshal(api.OpenRequest{
+		Path:   "/rcode-test-no-such-path",
+		Editor: "failing-editor",
+		User:   "testuser",
+		Host:   "testhost",
+	})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/open-editor", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	server.handleOpenEditor(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("handleOpenEditor() status = %v, want %v", rec.Code, http.StatusInternalServerError)
+	}
+
+	var resp api.ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if !strings.Contains(resp.Details, "rcode-test-no-such-path") {
+		t.Errorf("Details = %q, want ls output mentioning the path", resp.Details)
+	}
+}
+
+func TestHandleOpenEditorFallback(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses ls")
+	}
+
+	server := createTestServer()
+	cfg := *server.currentConfig()
+	cfg.Editors = append(cfg.Editors,
+		config.EditorConfig{Name: "failing-editor", Command: "ls {path}", WaitForExit: true},
+		config.EditorConfig{Name: "missing-editor", Command: "rcode-test-no-such-editor {path}"},
+	)
+	cfg.Server.EditorFallback = []string{"failing-editor", "missing-editor", "test-editor"}
+	if err := server.Reload(&cfg); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	body, err := json.Marshal(api.OpenRequest{
+		Path:   "/rcode-test-no-such-path",
+		Editor: "failing-editor",
+		User:   "testuser",
+		Host:   "testhost",
+	})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/open-editor", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	server.handleOpenEditor(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("handleOpenEditor() status = %v, want %v: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var resp api.OpenResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if resp.Editor != "test-editor" || resp.FallbackFrom != "failing-editor" {
+		t.Errorf("Editor = %q, FallbackFrom = %q, want test-editor after failing-editor", resp.Editor, resp.FallbackFrom)
+	}
+	if !strings.HasPrefix(resp.Command, "echo ") {
+		t.Errorf("Command = %q, want the fallback editor's command", resp.Command)
+	}
+}
+
+func TestHandleRender(t *testing.T) {
+	server := createTestServer()
+
+	tests := []struct {
+		name       string
+		request    api.OpenRequest
+		wantStatus int
+		wantEditor string
+		wantCmd    string
+	}{
+		{
+			name:       "default editor",
+			request:    api.OpenRequest{Path: "/home/user/project", User: "testuser", Host: "testhost"},
+			wantStatus: http.StatusOK,
+			wantEditor: "test-editor",
+			wantCmd:    "echo 'Opening /home/user/project for testuser@testhost'",
+		},
+		{
+			name:       "specific editor",
+			request:    api.OpenRequest{Path: "/srv", Editor: "another-editor", User: "testuser", Host: "testhost"},
+			wantStatus: http.StatusOK,
+			wantEditor: "another-editor",
+			wantCmd:    "echo 'Another /srv'",
+		},
+		{
+			name:       "unknown editor",
+			request:    api.OpenRequest{Path: "/srv", Editor: "missing", User: "testuser", Host: "testhost"},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "invalid request",
+			request:    api.OpenRequest{User: "testuser", Host: "testhost"},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "path is cleaned",
+			request:    api.OpenRequest{Path: "/home/user/./src/../project/", Editor: "another-editor", User: "testuser", Host: "testhost"},
+			wantStatus: http.StatusOK,
+			wantEditor: "another-editor",
+			wantCmd:    "echo 'Another /home/user/project'",
+		},
+		{
+			name:       "relative path",
+			request:    api.OpenRequest{Path: "project", User: "testuser", Host: "testhost"},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "control character in path",
+			request:    api.OpenRequest{Paths: []string{"/srv/a", "/srv/\x1b]0;x\x07"}, User: "testuser", Host: "testhost"},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(tt.request)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+
+			rec := httptest.NewRecorder()
+			server.handleRender(rec, httptest.NewRequest(http.MethodPost, "/render", bytes.NewReader(body)))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("handleRender() status = %v, want %v", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp api.RenderResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if resp.Editor != tt.wantEditor {
+				t.Errorf("Editor = %v, want %v", resp.Editor, tt.wantEditor)
+			}
+			if resp.Command != tt.wantCmd {
+				t.Errorf("Command = %v, want %v", resp.Command, tt.wantCmd)
+			}
+		})
+	}
+
+	// Rendering never records a session
+	if sessions := server.sessions.List(session.Filter{}); len(sessions) != 0 {
+		t.Errorf("handleRender() recorded %d sessions, want 0", len(sessions))
+	}
+}
+
+func TestHandleOpenTerminal(t *testing.T) {
+	server := createTestServer()
+	cfg := *server.currentConfig()
+	cfg.Terminals = []config.EditorConfig{{Name: "test-terminal", Command: "echo terminal {path}"}}
+	if err := server.Reload(&cfg); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		request    api.OpenRequest
+		wantStatus int
+		wantCmd    string
+	}{
+		{
+			name:       "default terminal",
+			request:    api.OpenRequest{Path: "/home/user/project", User: "testuser", Host: "testhost", Terminal: true},
+			wantStatus: http.StatusOK,
+			wantCmd:    "echo terminal /home/user/project",
+		},
+		{
+			name:       "editors are not terminals",
+			request:    api.OpenRequest{Path: "/home/user/project", Editor: "test-editor", User: "testuser", Host: "testhost", Terminal: true},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "several paths",
+			request:    api.OpenRequest{Paths: []string{"/a", "/b"}, User: "testuser", Host: "testhost", Terminal: true},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(tt.request)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+
+			rec := httptest.NewRecorder()
+			server.handleOpenEditor(rec, httptest.NewRequest(http.MethodPost, "/open-editor", bytes.NewReader(body)))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("handleOpenEditor() status = %v, want %v: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp api.OpenResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if resp.Editor != "test-terminal" || resp.Command != tt.wantCmd {
+				t.Errorf("Editor = %q, Command = %q, want test-terminal and %q", resp.Editor, resp.Command, tt.wantCmd)
+			}
+		})
+	}
+}
+
+func TestHandleOpenDiff(t *testing.T) {
+	server := createTestServer()
+	if err := server.editors().AddEditor(config.EditorConfig{
+		Name:    "differ",
+		Command: "echo open {path}",
+		Diff:    "echo diff {path} {path2}",
+	}); err != nil {
+		t.Fatalf("AddEditor() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		request    api.OpenRequest
+		wantStatus int
+		wantCmd    string
+	}{
+		{
+			name:       "diff",
+			request:    api.OpenRequest{Path: "/srv/a", Path2: "/srv/b", Editor: "differ", User: "testuser", Host: "testhost"},
+			wantStatus: http.StatusOK,
+			wantCmd:    "echo diff /srv/a /srv/b",
+		},
+		{
+			name:       "editor without diff",
+			request:    api.OpenRequest{Path: "/srv/a", Path2: "/srv/b", Editor: "test-editor", User: "testuser", Host: "testhost"},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "several paths",
+			request:    api.OpenRequest{Paths: []string{"/srv/a", "/srv/c"}, Path2: "/srv/b", Editor: "differ", User: "testuser", Host: "testhost"},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(tt.request)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+
+			rec := httptest.NewRecorder()
+			server.handleOpenEditor(rec, httptest.NewRequest(http.MethodPost, "/open-editor", bytes.NewReader(body)))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("handleOpenEditor() status = %v, want %v: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp api.OpenResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if resp.Command != tt.wantCmd {
+				t.Errorf("Command = %q, want %q", resp.Command, tt.wantCmd)
+			}
+		})
+	}
+}
+
+func TestHandleRenderTmux(t *testing.T) {
+	server := createTestServer()
+	if err := server.editors().AddEditor(config.EditorConfig{
+		Name:    "tmux",
+		Type:    config.EditorTypeTmux,
+		Command: "nvim {path}",
+		Session: "work",
+	}); err != nil {
+		t.Fatalf("AddEditor() error = %v", err)
+	}
+
+	body, err := json.Marshal(api.OpenRequest{Path: "/home/user/my project", Editor: "tmux", User: "testuser", Host: "testhost"})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	rec := httptest.NewRecorder()
+	server.handleRender(rec, httptest.NewRequest(http.MethodPost, "/render", bytes.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("handleRender() status = %v, want %v: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	var resp api.RenderResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	want := "tmux new-window -t work: -c '/home/user/my project' nvim '/home/user/my project'"
+	if resp.Command != want || resp.WorkDir != "" {
+		t.Errorf("Command = %q, WorkDir = %q, want %q and none", resp.Command, resp.WorkDir, want)
+	}
+}
+
+func TestHandleRenderSync(t *testing.T) {
+	server := createTestServer()
+	if err := server.editors().AddEditor(config.EditorConfig{
+		Name:    "gedit",
+		Command: "gedit {path}",
+		Sync:    true,
+	}); err != nil {
+		t.Fatalf("AddEditor() error = %v", err)
+	}
+
+	body, err := json.Marshal(api.OpenRequest{Path: "/home/user/main.go", Editor: "gedit", User: "testuser", Host: "testhost"})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	rec := httptest.NewRecorder()
+	server.handleRender(rec, httptest.NewRequest(http.MethodPost, "/render", bytes.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("handleRender() status = %v, want %v: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	var resp api.RenderResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	local := server.sync.LocalPath(filesync.Target{User: "testuser", Host: "testhost", Path: "/home/user/main.go"})
+	if want := "gedit " + local; resp.Command != want {
+		t.Errorf("Command = %q, want %q", resp.Command, want)
+	}
+
+	// A sync editor opens one file at a time
+	body, err = json.Marshal(api.OpenRequest{Path: "/a", Paths: []string{"/a", "/b"}, Editor: "gedit", User: "testuser", Host: "testhost"})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	rec = httptest.NewRecorder()
+	server.handleRender(rec, httptest.NewRequest(http.MethodPost, "/render", bytes.NewReader(body)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("handleRender() with two paths status = %v, want %v", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandleRenderPort(t *testing.T) {
+	server := createTestServer()
+	if err := server.editors().AddEditor(config.EditorConfig{
+		Name: "code-server",
+		Type: config.EditorTypeBrowser,
+		URL:  "http://localhost:{port}/?folder={path|urlencode}",
+	}); err != nil {
+		t.Fatalf("AddEditor() error = %v", err)
+	}
+
+	render := func(port int) *httptest.ResponseRecorder {
+		body, err := json.Marshal(api.OpenRequest{Path: "/home/user/project", Editor: "code-server", User: "testuser", Host: "testhost", Port: port})
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		rec := httptest.NewRecorder()
+		server.handleRender(rec, httptest.NewRequest(http.MethodPost, "/render", bytes.NewReader(body)))
+		return rec
+	}
+
+	if rec := render(0); rec.Code != http.StatusBadRequest {
+		t.Errorf("handleRender() without a port status = %v, want %v", rec.Code, http.StatusBadRequest)
+	}
+	// The URL a client's port builds must be allowed like /open-url
+	if rec := render(8080); rec.Code != http.StatusForbidden {
+		t.Errorf("handleRender() with open_url disabled status = %v, want %v", rec.Code, http.StatusForbidden)
+	}
+
+	cfg := *server.currentConfig()
+	cfg.Server.OpenURL.Allowed = []string{"http://localhost:*"}
+	if err := server.Reload(&cfg); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if err := server.editors().AddEditor(config.EditorConfig{
+		Name: "code-server",
+		Type: config.EditorTypeBrowser,
+		URL:  "http://localhost:{port}/?folder={path|urlencode}",
+	}); err != nil {
+		t.Fatalf("AddEditor() error = %v", err)
+	}
+
+	rec := render(8080)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("handleRender() status = %v, want %v: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var resp api.RenderResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if want := "http://localhost:8080/?folder=%2Fhome%2Fuser%2Fproject"; resp.Command != want {
+		t.Errorf("Command = %q, want %q", resp.Command, want)
+	}
+}
+
+func TestHandleRenderUserDefault(t *testing.T) {
+	server := createTestServer()
+	cfg := *server.currentConfig()
+	cfg.Editors = append(cfg.Editors, config.EditorConfig{Name: "other-editor", Command: "other {path}"})
+	cfg.Users = map[string]config.UserConfig{"alice": {DefaultEditor: "other-editor"}}
+	if err := server.Reload(&cfg); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	tests := []struct {
+		user, editor string
+		wantEditor   string
+	}{
+		{"alice", "", "other-editor"},
+		{"alice", "test-editor", "test-editor"},
+		{"bob", "", "test-editor"},
+	}
+
+	for _, tt := range tests {
+		body, err := json.Marshal(api.OpenRequest{Path: "/home/user/project", Editor: tt.editor, User: tt.user, Host: "testhost"})
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+
+		rec := httptest.NewRecorder()
+		server.handleRender(rec, httptest.NewRequest(http.MethodPost, "/render", bytes.NewReader(body)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("handleRender() status = %v, want %v: %s", rec.Code, http.StatusOK, rec.Body.String())
+		}
+
+		var resp api.RenderResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+		if resp.Editor != tt.wantEditor {
+			t.Errorf("user %s, editor %q: Editor = %v, want %v", tt.user, tt.editor, resp.Editor, tt.wantEditor)
+		}
+	}
+}
+
+func TestHandleRenderWorkDir(t *testing.T) {
+	server := createTestServer()
+	if err := server.editors().AddEditor(config.EditorConfig{
+		Name:    "workdir-editor",
+		Command: "edit {path}",
+		WorkDir: "{path|dirname}",
+	}); err != nil {
+		t.Fatalf("AddEditor() error = %v", err)
+	}
+
+	body, err := json.Marshal(api.OpenRequest{
+		Path:   "/home/user/project/my file.go",
+		Editor: "workdir-editor",
+		User:   "testuser",
+		Host:   "testhost",
+	})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	server.handleRender(rec, httptest.NewRequest(http.MethodPost, "/render", bytes.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("handleRender() status = %v, want %v", rec.Code, http.StatusOK)
+	}
+
+	var resp api.RenderResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if resp.WorkDir != "/home/user/project" {
+		t.Errorf("WorkDir = %q, want %q", resp.WorkDir, "/home/user/project")
+	}
+	if resp.Command != "edit '/home/user/project/my file.go'" {
+		t.Errorf("Command = %q", resp.Command)
+	}
+}
+
+func TestHandleSessions(t *testing.T) {
+	server := createTestServer()
+
+	for _, path := range []string{"/home/user/a", "/home/user/b"} {
+		body, err := json.Marshal(api.OpenRequest{
+			Path: path,
+			User: "testuser",
+			Host: "testhost",
+		})
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		rec := httptest.NewRecorder()
+		server.handleOpenEditor(rec, httptest.NewRequest(http.MethodPost, "/open-editor", bytes.NewReader(body)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("handleOpenEditor() status = %v, want %v", rec.Code, http.StatusOK)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantPaths  []string
+	}{
+		{"all sessions", "", http.StatusOK, []string{"/home/user/b", "/home/user/a"}},
+		{"limit", "?limit=1", http.StatusOK, []string{"/home/user/b"}},
+		{"other user", "?user=someone", http.StatusOK, []string{}},
+		{"invalid limit", "?limit=abc", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			server.handleSessions(rec, httptest.NewRequest(http.MethodGet, "/sessions"+tt.query, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("handleSessions() status = %v, want %v", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp api.SessionsResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if len(resp.Sessions) != len(tt.wantPaths) {
+				t.Fatalf("handleSessions() returned %d sessions, want %d", len(resp.Sessions), len(tt.wantPaths))
+			}
+			for i, s := range resp.Sessions {
+				if s.Path != tt.wantPaths[i] {
+					t.Errorf("Sessions[%d].Path = %v, want %v", i, s.Path, tt.wantPaths[i])
+				}
+				if s.Editor != "test-editor" {
+					t.Errorf("Sessions[%d].Editor = %v, want test-editor", i, s.Editor)
+				}
+			}
+		})
+	}
+}
+
+func TestRespondJSON(t *testing.T) {
+	server := createTestServer()
+
+	data := map[string]string{
+		"test": "value",
+		"foo":  "bar",
+	}
+
+	rec := httptest.NewRecorder()
+	server.respondJSON(rec, http.StatusOK, data)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("respondJSON() status = %v, want %v", rec.Code, http.StatusOK)
+	}
+
+	contentType := rec.Header().Get("Content-Type")
+	if contentType != "application/json" {
+		t.Errorf("Content-Type = %v, want application/json", contentType)
+	}
+
+	var result map[string]string
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Errorf("Failed to decode response: %v", err)
+	}
+
+	if result["test"] != "value" || result["foo"] != "bar" {
+		t.Errorf("Unexpected response data: %v", result)
+	}
+}
+
+func TestRespondError(t *testing.T) {
+	server := createTestServer()
+
+	rec := httptest.NewRecorder()
+	server.respondError(rec, api.ErrInvalidPath, http.StatusBadRequest, "test details")
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("respondError() status = %v, want %v", rec.Code, http.StatusBadRequest)
+	}
+
+	var resp api.ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Errorf("Failed to decode response: %v", err)
+	}
+
+	if resp.Message != api.ErrInvalidPath.Error() {
+		t.Errorf("Error message = %v, want %v", resp.Message, api.ErrInvalidPath.Error())
+	}
+
+	if resp.Details != "test details" {
+		t.Errorf("Error details = %v, want 'test details'", resp.Details)
+	}
+
+	if resp.Code != api.CodeInvalidPath {
+		t.Errorf("Error code = %v, want %v", resp.Code, api.CodeInvalidPath)
+	}
+}
+
+// Helper function to create a test server
+func createTestServer() *Server {
+	cfg := &config.ServerConfigFile{
+		Server: config.ServerConfig{
+			Host:         "localhost",
+			Port:         3339,
+			ReadTimeout:  10 * time.Second,
+			WriteTimeout: 10 * time.Second,
+			IdleTimeout:  120 * time.Second,
+		},
+		Editors: []config.EditorConfig{
+			{
+				Name:      "test-editor",
+				Command:   "echo 'Opening {path} for {user}@{host}'",
+				Default:   true,
+				Available: true,
+			},
+			{
+				Name:      "another-editor",
+				Command:   "echo 'Another {path}'",
+				Default:   false,
+				Available: false,
+			},
+		},
+		Logging: config.LogConfig{
+			Level:   "info",
+			Console: false,
+		},
+	}
+
+	log := logger.New(&logger.Config{
+		Level:   "error", // Quiet logs for tests
+		Console: false,
+	})
+
+	srv, err := NewServer(cfg, log)
+	if err != nil {
+		panic(fmt.Sprintf("createTestServer: %v", err))
 	}
 	return srv
 }
+
+func TestHandleOpenEditorBusy(t *testing.T) {
+	server := createTestServer()
+	cfg := *server.currentConfig()
+	cfg.Server.Launch = config.LaunchConfig{Workers: 1, MaxQueued: 1}
+	if err := server.Reload(&cfg); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	// Occupy the only worker and the only queue slot
+	release, err := server.executor.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _, _ = server.executor.Acquire(ctx) }()
+	for server.executor.Queued() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	defer release()
+
+	body, err := json.Marshal(api.OpenRequest{Path: "/home/user/project", User: "testuser", Host: "testhost"})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	rec := httptest.NewRecorder()
+	server.handleOpenEditor(rec, httptest.NewRequest(http.MethodPost, "/open-editor", bytes.NewReader(body)))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("handleOpenEditor() status = %v, want %v", rec.Code, http.StatusServiceUnavailable)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want %q", got, "1")
+	}
+	var resp api.ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if resp.Code != api.CodeBusy {
+		t.Errorf("Code = %q, want %q", resp.Code, api.CodeBusy)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/internal/prompt"
+	"github.com/spf13/cobra"
+)
+
+// tailscaleCIDR is the address range Tailscale assigns to its nodes
+const tailscaleCIDR = "100.64.0.0/10"
+
+var initForce bool
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Create the server configuration interactively",
+	Long: `Ask for the listen address, the client IPs to allow, Tailscale usage and the
+default editor, then write the server configuration file.
+
+Press Enter to keep the value in brackets, or enter "-" to clear it.`,
+	Args: cobra.NoArgs,
+	RunE: runInit,
+}
+
+func runInit(_ *cobra.Command, _ []string) error {
+	path := configFile
+	if path == "" {
+		path = config.GetDefaultPaths().ServerConfig
+		if config.ServerConfigPath("") != path {
+			return fmt.Errorf("server settings are read from %s; edit it directly", config.ServerConfigPath(""))
+		}
+	}
+	if config.IsUnifiedConfigFile(path) {
+		return fmt.Errorf("%s is a unified client/server configuration; edit it directly", path)
+	}
+
+	p := prompt.New(os.Stdin, os.Stdout)
+
+	// Start from the existing file so re-running init edits it
+	cfg := defaultServerConfig()
+	if _, err := os.Stat(path); err == nil {
+		if !initForce {
+			overwrite, err := p.Confirm(fmt.Sprintf("%s already exists. Update it?", path), true)
+			if err != nil {
+				return err
+			}
+			if !overwrite {
+				fmt.Println("Configuration left unchanged")
+				return nil
+			}
+		}
+		if cfg, err = config.LoadServerConfig(path); err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+	}
+
+	if err := runServerWizard(p, cfg); err != nil {
+		return err
+	}
+
+	if err := config.ValidateServerConfig(cfg); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	if err := config.SaveServerConfig(path, cfg); err != nil {
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
+
+	fmt.Printf("\nConfiguration written to %s\n", path)
+	fmt.Println("Start the server with 'rcode-server' or install it with 'rcode-server service install'.")
+	return nil
+}
+
+// runServerWizard asks the setup questions and stores the answers in cfg.
+// Each answer is checked with ValidateServerConfig before moving on.
+func runServerWizard(p *prompt.Prompter, cfg *config.ServerConfigFile) error {
+	validate := func(field string, apply func(string) error) func(string) error {
+		return func(answer string) error {
+			if err := apply(answer); err != nil {
+				return err
+			}
+			return config.FieldError(config.ValidateServerConfig(cfg), field)
+		}
+	}
+
+	if _, err := p.Ask("Address to listen on (0.0.0.0 = all interfaces)", cfg.Server.Host,
+		validate("server.host", func(s string) error {
+			if s == "" {
+				return fmt.Errorf("a listen address is required")
+			}
+			cfg.Server.Host = s
+			return nil
+		})); err != nil {
+		return err
+	}
+
+	if _, err := p.Ask("Port", strconv.Itoa(cfg.Server.Port),
+		validate("server.port", func(s string) error {
+			port, err := strconv.Atoi(s)
+			if err != nil {
+				return fmt.Errorf("invalid port number: %s", s)
+			}
+			cfg.Server.Port = port
+			return nil
+		})); err != nil {
+		return err
+	}
+
+	if _, err := p.Ask("Client IPs or CIDRs to allow, comma separated (empty = allow all)", strings.Join(cfg.Server.AllowedIPs, ","),
+		validate("server.allowed_ips", func(s string) error {
+			cfg.Server.AllowedIPs = splitList(s)
+			return nil
+		})); err != nil {
+		return err
+	}
+
+	// Tailscale clients only need an entry when the whitelist is in use
+	if len(cfg.Server.AllowedIPs) > 0 && !containsString(cfg.Server.AllowedIPs, tailscaleCIDR) {
+		tailscale, err := p.Confirm("Do clients connect over Tailscale?", false)
+		if err != nil {
+			return err
+		}
+		if tailscale {
+			cfg.Server.AllowedIPs = append(cfg.Server.AllowedIPs, tailscaleCIDR)
+		}
+	}
+
+	names := make([]string, 0, len(cfg.Editors))
+	current := ""
+	for _, e := range cfg.Editors {
+		names = append(names, e.Name)
+		if e.Default {
+			current = e.Name
+		}
+	}
+	p.Printf("Configured editors: %s\n", strings.Join(names, ", "))
+
+	if _, err := p.Ask("Default editor", current,
+		validate("editors", func(s string) error {
+			if !containsString(names, s) {
+				return fmt.Errorf("unknown editor %q", s)
+			}
+			for i := range cfg.Editors {
+				cfg.Editors[i].Default = cfg.Editors[i].Name == s
+			}
+			return nil
+		})); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// splitList splits a comma-separated answer, dropping empty entries
+func splitList(s string) []string {
+	items := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
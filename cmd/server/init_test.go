@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/internal/prompt"
+)
+
+func TestRunServerWizard(t *testing.T) {
+	cfg := config.GetDefaultServerConfig()
+	// host, invalid port, port, allowed IPs, tailscale, unknown editor, editor
+	input := "127.0.0.1\n99999\n4000\n192.168.1.0/24, 10.0.0.1\ny\nsublime\nzed\n"
+
+	var out bytes.Buffer
+	if err := runServerWizard(prompt.New(strings.NewReader(input), &out), cfg); err != nil {
+		t.Fatalf("runServerWizard() error = %v\n%s", err, out.String())
+	}
+
+	if cfg.Server.Host != "127.0.0.1" || cfg.Server.Port != 4000 {
+		t.Errorf("listen address = %s:%d, want 127.0.0.1:4000", cfg.Server.Host, cfg.Server.Port)
+	}
+	wantIPs := []string{"192.168.1.0/24", "10.0.0.1", tailscaleCIDR}
+	if strings.Join(cfg.Server.AllowedIPs, ",") != strings.Join(wantIPs, ",") {
+		t.Errorf("AllowedIPs = %v, want %v", cfg.Server.AllowedIPs, wantIPs)
+	}
+	for _, e := range cfg.Editors {
+		if e.Default != (e.Name == "zed") {
+			t.Errorf("editor %s Default = %v", e.Name, e.Default)
+		}
+	}
+	for _, want := range []string{"invalid port number: 99999", `unknown editor "sublime"`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q\n%s", want, out.String())
+		}
+	}
+	if err := config.ValidateServerConfig(cfg); err != nil {
+		t.Errorf("ValidateServerConfig() error = %v", err)
+	}
+}
+
+func TestRunServerWizardDefaults(t *testing.T) {
+	cfg := config.GetDefaultServerConfig()
+
+	if err := runServerWizard(prompt.New(strings.NewReader("\n\n\n\n"), &bytes.Buffer{}), cfg); err != nil {
+		t.Fatalf("runServerWizard() error = %v", err)
+	}
+
+	want := config.GetDefaultServerConfig()
+	if cfg.Server.Host != want.Server.Host || cfg.Server.Port != want.Server.Port || len(cfg.Server.AllowedIPs) != 0 {
+		t.Errorf("server = %+v, want the defaults", cfg.Server)
+	}
+	if !cfg.Editors[0].Default {
+		t.Errorf("default editor changed: %+v", cfg.Editors)
+	}
+}
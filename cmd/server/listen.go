@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/internal/validation"
+)
+
+// listenAddresses returns the addresses the server binds to: those in
+// server.listen and every address of the server.listen_interfaces, or
+// server.host when neither is set. Addresses without a port use server.port.
+// interfaceIPs looks up the addresses of an interface.
+func listenAddresses(cfg *config.ServerConfig, interfaceIPs func(string) ([]net.IP, error)) ([]string, error) {
+	port := strconv.Itoa(cfg.Port)
+	if len(cfg.Listen) == 0 && len(cfg.Interfaces) == 0 {
+		return []string{net.JoinHostPort(cfg.Host, port)}, nil
+	}
+
+	var addrs []string
+	add := func(addr string) {
+		if !slices.Contains(addrs, addr) {
+			addrs = append(addrs, addr)
+		}
+	}
+	for _, entry := range cfg.Listen {
+		addr, err := validation.NormalizeHostPort(entry, port)
+		if err != nil {
+			return nil, fmt.Errorf("server.listen: %w", err)
+		}
+		add(addr)
+	}
+	for _, name := range cfg.Interfaces {
+		ips, err := interfaceIPs(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			add(net.JoinHostPort(ip.String(), port))
+		}
+	}
+	return addrs, nil
+}
+
+// interfaceIPs returns the addresses of the network interface name that a
+// server can listen on; IPv6 link-local addresses are left out
+func interfaceIPs(name string) ([]net.IP, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("listen interface %s: %w", name, err)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("listen interface %s: %w", name, err)
+	}
+
+	var ips []net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		ips = append(ips, ipNet.IP)
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("listen interface %s has no addresses; is it up?", name)
+	}
+	return ips, nil
+}
+
+// listen opens a listener on every address, closing the ones already
+// opened when one fails
+func listen(addrs []string) ([]net.Listener, error) {
+	listeners := make([]net.Listener, 0, len(addrs))
+	for _, addr := range addrs {
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			for _, opened := range listeners {
+				_ = opened.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, ln)
+	}
+	if len(listeners) == 0 {
+		return nil, errors.New("no address to listen on")
+	}
+	return listeners, nil
+}
+
+// listensEverywhere reports whether one of addrs is the unspecified address,
+// which accepts connections on every interface
+func listensEverywhere(addrs []string) bool {
+	for _, addr := range addrs {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			continue
+		}
+		if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+			return true
+		}
+	}
+	return false
+}
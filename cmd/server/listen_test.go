@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/foxytanuki/rcode/internal/config"
+)
+
+func TestListenAddresses(t *testing.T) {
+	interfaces := func(name string) ([]net.IP, error) {
+		if name != "tailscale0" {
+			return nil, errors.New("no such interface")
+		}
+		return []net.IP{net.ParseIP("100.64.0.2"), net.ParseIP("fd7a:115c:a1e0::2")}, nil
+	}
+
+	tests := []struct {
+		name    string
+		cfg     config.ServerConfig
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "host and port",
+			cfg:  config.ServerConfig{Host: "0.0.0.0", Port: 3339},
+			want: []string{"0.0.0.0:3339"},
+		},
+		{
+			name: "listen replaces host",
+			cfg:  config.ServerConfig{Host: "0.0.0.0", Port: 3339, Listen: []string{"127.0.0.1", "100.64.0.2:4000", "::1"}},
+			want: []string{"127.0.0.1:3339", "100.64.0.2:4000", "[::1]:3339"},
+		},
+		{
+			name: "interface addresses",
+			cfg:  config.ServerConfig{Port: 3339, Listen: []string{"127.0.0.1", "100.64.0.2"}, Interfaces: []string{"tailscale0"}},
+			want: []string{"127.0.0.1:3339", "100.64.0.2:3339", "[fd7a:115c:a1e0::2]:3339"},
+		},
+		{
+			name:    "unknown interface",
+			cfg:     config.ServerConfig{Port: 3339, Interfaces: []string{"wg9"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := listenAddresses(&tt.cfg, interfaces)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("listenAddresses() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("listenAddresses() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListen(t *testing.T) {
+	listeners, err := listen([]string{"127.0.0.1:0", "127.0.0.1:0"})
+	if err != nil {
+		t.Fatalf("listen() error = %v", err)
+	}
+	if len(listeners) != 2 {
+		t.Errorf("listen() opened %d listeners, want 2", len(listeners))
+	}
+	taken := listeners[0].Addr().String()
+
+	// A failure closes the listeners already opened
+	if _, err := listen([]string{"127.0.0.1:0", taken}); err == nil {
+		t.Error("listen() on a taken address succeeded")
+	}
+	for _, ln := range listeners {
+		_ = ln.Close()
+	}
+}
+
+func TestListensEverywhere(t *testing.T) {
+	if !listensEverywhere([]string{"127.0.0.1:3339", "0.0.0.0:3339"}) {
+		t.Error("listensEverywhere(0.0.0.0) = false")
+	}
+	if !listensEverywhere([]string{"[::]:3339"}) {
+		t.Error("listensEverywhere(::) = false")
+	}
+	if listensEverywhere([]string{"127.0.0.1:3339", "100.64.0.2:3339"}) {
+		t.Error("listensEverywhere(specific addresses) = true")
+	}
+}
@@ -3,27 +3,41 @@ package main
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/foxytanuki/rcode/internal/auth"
 	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/internal/editor"
 	"github.com/foxytanuki/rcode/internal/logger"
 	"github.com/foxytanuki/rcode/internal/service"
+	"github.com/foxytanuki/rcode/internal/transport"
 	"github.com/foxytanuki/rcode/internal/version"
 	"github.com/spf13/cobra"
 )
 
 // Command-line flags
 var (
-	configFile string
-	host       string
-	port       int
-	logLevel   string
+	configFile   string
+	host         string
+	port         int
+	logLevel     string
+	brokerListen string
+	brokerToken  string
+
+	serviceName   string
+	serviceBinary string
+
+	loopbackOnly     bool
+	tunnelRemotePort int
+	tunnelSSH        string
 )
 
 func main() {
@@ -46,14 +60,21 @@ By default, it starts the HTTP server listening on the configured host and port.
 var serviceCmd = &cobra.Command{
 	Use:   "service",
 	Short: "System service management commands",
-	Long:  `Commands for installing, managing, and monitoring the rcode-server as a system service.`,
+	Long: `Commands for installing, managing, and monitoring the rcode-server as a system service.
+
+Use --service-name to manage several services side by side, for example a
+relay broker next to the server:
+
+  rcode-server service install --service-name rcode-broker -- broker --listen :3340 --token <token>
+  rcode-server service status --service-name rcode-broker`,
 }
 
 var serviceInstallCmd = &cobra.Command{
-	Use:   "install",
+	Use:   "install [-- args...]",
 	Short: "Install rcode-server as a system service",
-	Long:  `Install rcode-server as a system service (launchd on macOS, systemd on Linux).`,
-	RunE:  runServiceInstall,
+	Long: `Install rcode-server as a system service (launchd on macOS, systemd on Linux,
+a Scheduled Task on Windows). Arguments after -- are passed to the binary.`,
+	RunE: runServiceInstall,
 }
 
 var serviceUninstallCmd = &cobra.Command{
@@ -84,17 +105,66 @@ var serviceStatusCmd = &cobra.Command{
 	RunE:  runServiceStatus,
 }
 
+var generateTokenCmd = &cobra.Command{
+	Use:   "generate-token",
+	Short: "Generate a shared authentication token",
+	Long: `Generate a random bearer token for authenticating rcode clients.
+
+Set the token as server.auth_token in the server configuration and as
+hosts.server.auth_token (or RCODE_AUTH_TOKEN) in the client configuration.
+A generated token also serves as a request signing key, for
+server.signing.keys and hosts.server.signing_key.`,
+	Args: cobra.NoArgs,
+	RunE: runGenerateToken,
+}
+
+var brokerCmd = &cobra.Command{
+	Use:   "broker",
+	Short: "Run a relay broker for reverse connections",
+	Long: `Run a small relay broker that rcode-server connects out to over WebSocket.
+
+Use this when the remote machine cannot reach rcode-server directly (NAT,
+firewall). Run the broker somewhere both sides can reach, set server.broker
+to its ws:// URL on the host, and point the client's hosts.server.broker at
+the broker's host:port.`,
+	Args: cobra.NoArgs,
+	RunE: runBroker,
+}
+
 func init() {
 	// Global flags
 	rootCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "", "Path to configuration file")
 	rootCmd.PersistentFlags().StringVarP(&logLevel, "log-level", "l", "", "Log level (debug, info, warn, error)")
+	rootCmd.PersistentFlags().BoolVar(&config.StrictKeys, "strict-config", false, "Fail on unknown keys in the config file instead of warning")
 
 	// Server flags
 	rootCmd.Flags().StringVarP(&host, "host", "H", "", "Server host to bind to")
 	rootCmd.Flags().IntVarP(&port, "port", "p", 0, "Server port")
+	rootCmd.Flags().BoolVar(&loopbackOnly, "loopback-only", false, "Listen on 127.0.0.1 only (for use with SSH tunnels)")
 
 	// Add subcommands
+	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(serviceCmd)
+	rootCmd.AddCommand(generateTokenCmd)
+	rootCmd.AddCommand(brokerCmd)
+	rootCmd.AddCommand(tunnelCmd)
+	rootCmd.AddCommand(installShimsCmd)
+	rootCmd.AddCommand(validateConfigCmd)
+	rootCmd.AddCommand(genCertsCmd)
+	initCmd.Flags().BoolVarP(&initForce, "force", "f", false, "Update an existing configuration without asking")
+	tunnelCmd.Flags().IntVar(&tunnelRemotePort, "remote-port", 0, "Port to listen on at the remote machine (default: the server port)")
+	tunnelCmd.Flags().StringVar(&tunnelSSH, "ssh", "ssh", "ssh binary to run")
+	installShimsCmd.Flags().StringVar(&shimDir, "dir", editor.DefaultShimDir, "Directory to link the command-line tools into")
+	validateConfigCmd.Flags().BoolVar(&validateNoProbe, "no-probe", false, "Do not look for the editors on this machine")
+	genCertsCmd.Flags().StringVar(&certDir, "dir", "", "Directory to write the certificates to (default: tls next to the config file)")
+	genCertsCmd.Flags().StringSliceVar(&certHosts, "host", nil, "Extra name or address the server certificate is valid for (repeatable)")
+	genCertsCmd.Flags().IntVar(&certDays, "days", 730, "Days the certificates are valid")
+	genCertsCmd.Flags().BoolVarP(&certForce, "force", "f", false, "Replace existing certificate files")
+	installShimsCmd.Flags().BoolVarP(&shimDryRun, "dry-run", "n", false, "Show the links without creating them")
+	brokerCmd.Flags().StringVar(&brokerListen, "listen", config.DefaultBrokerAddress, "Address for the broker to listen on")
+	brokerCmd.Flags().StringVar(&brokerToken, "token", "", "Bearer token required from connecting rcode-server agents (required)")
+	serviceCmd.PersistentFlags().StringVar(&serviceName, "service-name", service.DefaultName, "Service name (launchd label, systemd unit, Windows task)")
+	serviceInstallCmd.Flags().StringVar(&serviceBinary, "binary", "", "Binary to run as the service (default: rcode-server)")
 	serviceCmd.AddCommand(serviceInstallCmd)
 	serviceCmd.AddCommand(serviceUninstallCmd)
 	serviceCmd.AddCommand(serviceStartCmd)
@@ -105,16 +175,25 @@ func init() {
 	rootCmd.SetVersionTemplate(fmt.Sprintf("rcode-server version %s\nBuilt: %s\nGit: %s\n", version.Version, version.BuildTime, version.GitHash))
 }
 
-func runServer(_ *cobra.Command, _ []string) error {
-	// Load configuration
+// loadServerConfig loads the configuration file, applies command-line
+// overrides and validates the result
+func loadServerConfig() (*config.ServerConfigFile, error) {
+	createServerConfig()
+
 	cfg, err := config.LoadServerConfig(configFile)
 	if err != nil {
-		return fmt.Errorf("failed to load configuration: %w", err)
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
 	}
 
 	// Apply command-line overrides
+	// --host and --loopback-only replace the configured addresses
 	if host != "" {
 		cfg.Server.Host = host
+		cfg.Server.Listen, cfg.Server.Interfaces = nil, nil
+	}
+	if loopbackOnly {
+		cfg.Server.Host = "127.0.0.1"
+		cfg.Server.Listen, cfg.Server.Interfaces = nil, nil
 	}
 	if port != 0 {
 		cfg.Server.Port = port
@@ -123,9 +202,48 @@ func runServer(_ *cobra.Command, _ []string) error {
 		cfg.Logging.Level = logLevel
 	}
 
-	// Validate configuration
 	if err := config.ValidateServerConfig(cfg); err != nil {
-		return fmt.Errorf("invalid configuration: %w", err)
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+// defaultServerConfig returns the default configuration with the editors
+// installed on this machine, or every known editor when none is found
+func defaultServerConfig() *config.ServerConfigFile {
+	cfg := config.GetDefaultServerConfig()
+	if found := editor.DiscoverEditors(cfg.Editors); len(found) > 0 {
+		cfg.Editors = found
+	}
+	return cfg
+}
+
+// createServerConfig writes the default configuration on first run. Failures
+// are left to LoadServerConfig, which falls back to the static defaults.
+func createServerConfig() {
+	if configFile != "" {
+		return
+	}
+	path := config.ServerConfigPath("")
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		return
+	}
+
+	cfg := defaultServerConfig()
+	if err := config.SaveServerConfig(path, cfg); err != nil {
+		return
+	}
+	names := make([]string, len(cfg.Editors))
+	for i, e := range cfg.Editors {
+		names[i] = e.Name
+	}
+	fmt.Fprintf(os.Stderr, "Created %s with editors: %s\n", path, strings.Join(names, ", "))
+}
+
+func runServer(_ *cobra.Command, _ []string) error {
+	cfg, err := loadServerConfig()
+	if err != nil {
+		return err
 	}
 
 	// Initialize logger
@@ -137,7 +255,10 @@ func runServer(_ *cobra.Command, _ []string) error {
 		MaxBackups: cfg.Logging.MaxBackups,
 		MaxAge:     cfg.Logging.MaxAge,
 		Compress:   cfg.Logging.Compress,
-		Format:     "text",
+		Format:     cfg.Logging.Format,
+		Rotate:     cfg.Logging.Rotate,
+		DateNames:  cfg.Logging.DateNames,
+		Dedup:      cfg.Logging.Dedup,
 	})
 	defer func() {
 		if err := log.Close(); err != nil {
@@ -145,12 +266,18 @@ func runServer(_ *cobra.Command, _ []string) error {
 		}
 	}()
 
+	addrs, err := listenAddresses(&cfg.Server, interfaceIPs)
+	if err != nil {
+		return err
+	}
+
 	// Log startup information
 	log.Info("Starting rcode-server",
 		"version", version.Version,
-		"host", cfg.Server.Host,
-		"port", cfg.Server.Port,
+		"listen", addrs,
 		"editors", len(cfg.Editors),
+		"auth", cfg.Server.AuthToken != "",
+		"audit", cfg.Audit.File,
 	)
 
 	// Ensure PATH is set for editor binary lookups
@@ -158,27 +285,80 @@ func runServer(_ *cobra.Command, _ []string) error {
 		_ = os.Setenv("PATH", "/usr/local/bin:/usr/bin:/bin")
 	}
 
+	if shims := editor.MissingShims(editor.DefaultShimDir); len(shims) > 0 {
+		names := make([]string, len(shims))
+		for i, shim := range shims {
+			names[i] = shim.Name
+		}
+		log.Warn("Installed editors have no command on PATH; run 'rcode-server install-shims' to link them",
+			"editors", names)
+	}
+
 	// Create server instance
 	srv, err := NewServer(cfg, log)
 	if err != nil {
 		return fmt.Errorf("failed to create server: %w", err)
 	}
+	defer func() {
+		if err := srv.Close(); err != nil {
+			log.Error("Failed to close server", "error", err)
+		}
+	}()
 
 	// Setup HTTP server
 	httpServer := &http.Server{
-		Addr:         fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
 		Handler:      srv.Router(),
 		ReadTimeout:  cfg.Server.ReadTimeout,
 		WriteTimeout: cfg.Server.WriteTimeout,
 		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
-	// Start server in goroutine
-	serverErrors := make(chan error, 1)
-	go func() {
-		log.Info("Server listening", "address", httpServer.Addr)
-		serverErrors <- httpServer.ListenAndServe()
-	}()
+	// Serve HTTPS, requiring client certificates when a client CA is set
+	if t := cfg.Server.TLS; t.Enabled() {
+		httpServer.TLSConfig, err = auth.ServerTLSConfig(t.CertFile, t.KeyFile, t.ClientCAFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Keep a reverse connection to the broker if configured
+	agentCtx, stopAgent := context.WithCancel(context.Background())
+	defer stopAgent()
+	if cfg.Server.Broker != "" {
+		if cfg.Server.TLS.ClientCAFile != "" {
+			log.Warn("Requests relayed by the broker carry no client certificate; only the auth token protects them",
+				"broker", cfg.Server.Broker)
+		}
+		go transport.RunAgent(agentCtx, cfg.Server.Broker, cfg.Server.AuthToken, httpServer.Handler, log)
+	}
+
+	// Reload configuration on SIGHUP or when the file changes
+	srv.configPath = config.ServerConfigPath(configFile)
+	watcher := newConfigWatcher(srv.configPath, srv, loadServerConfig, log)
+	go watcher.run(agentCtx)
+
+	// Bind every address before serving any, so a bad address fails startup
+	listeners, err := listen(addrs)
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+	if listensEverywhere(addrs) && cfg.Server.AuthToken == "" && len(cfg.Server.AllowedIPs) == 0 && cfg.Server.TLS.ClientCAFile == "" {
+		log.Warn("Listening on all interfaces without auth_token or allowed_ips; set server.listen or server.listen_interfaces to expose the API only where it is needed",
+			"listen", addrs)
+	}
+
+	// Serve each address in its own goroutine
+	serverErrors := make(chan error, len(listeners))
+	for _, ln := range listeners {
+		go func(ln net.Listener) {
+			log.Info("Server listening", "address", ln.Addr().String(), "tls", httpServer.TLSConfig != nil)
+			if httpServer.TLSConfig != nil {
+				serverErrors <- httpServer.ServeTLS(ln, "", "")
+				return
+			}
+			serverErrors <- httpServer.Serve(ln)
+		}(ln)
+	}
 
 	// Setup signal handling for graceful shutdown
 	shutdown := make(chan os.Signal, 1)
@@ -193,27 +373,113 @@ func runServer(_ *cobra.Command, _ []string) error {
 		}
 	case sig := <-shutdown:
 		log.Info("Shutdown signal received", "signal", sig)
+		gracefulShutdown(srv, httpServer, stopAgent, log)
+	case <-srv.ShutdownRequested():
+		log.Info("Shutdown requested through the admin API")
+		gracefulShutdown(srv, httpServer, stopAgent, log)
+	}
 
-		// Create context with timeout for graceful shutdown
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
+	log.Info("Server stopped")
+	return nil
+}
 
-		// Attempt graceful shutdown
-		log.Info("Shutting down server gracefully...")
+// shutdownTimeout bounds draining editor launches and HTTP requests
+const shutdownTimeout = 30 * time.Second
+
+// gracefulShutdown stops the server: new editor launches are refused with
+// 503 so clients move to another host, running launches are waited for, and
+// then the HTTP server finishes its open requests. Event streams, which
+// never finish on their own, are ended first, including any relayed through
+// the broker tunnel.
+func gracefulShutdown(srv *Server, httpServer *http.Server, stopAgent context.CancelFunc, log *logger.Logger) {
+	stopAgent()
+	srv.events.close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	log.Info("Shutting down server gracefully...")
+	if err := srv.Drain(ctx); err != nil {
+		log.Warn("Editor launches still running at shutdown", "in_flight", srv.executor.InFlight(), "error", err)
+	}
+	if err := httpServer.Shutdown(ctx); err != nil {
+		log.Error("Server shutdown error", "error", err)
+		if err := httpServer.Close(); err != nil {
+			log.Error("Failed to close HTTP server", "error", err)
+		}
+	}
+}
+
+func runBroker(_ *cobra.Command, _ []string) error {
+	if brokerToken == "" {
+		return fmt.Errorf("--token is required; create one with rcode-server generate-token and set it as server.auth_token on the host")
+	}
+
+	level := config.DefaultLogLevel
+	if logLevel != "" {
+		level = logLevel
+	}
+
+	log := logger.New(&logger.Config{
+		Level:   level,
+		Console: true,
+		Format:  "text",
+	})
+	defer func() {
+		if err := log.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to close logger: %v\n", err)
+		}
+	}()
+
+	httpServer := &http.Server{
+		Addr:              brokerListen,
+		Handler:           transport.NewBroker(brokerToken, log),
+		ReadHeaderTimeout: config.DefaultReadTimeout,
+	}
+
+	serverErrors := make(chan error, 1)
+	go func() {
+		log.Info("Broker listening", "address", brokerListen)
+		serverErrors <- httpServer.ListenAndServe()
+	}()
+
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case err := <-serverErrors:
+		if err != nil && err != http.ErrServerClosed {
+			return fmt.Errorf("broker error: %w", err)
+		}
+	case sig := <-shutdown:
+		log.Info("Shutdown signal received", "signal", sig)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
 		if err := httpServer.Shutdown(ctx); err != nil {
-			log.Error("Server shutdown error", "error", err)
-			if err := httpServer.Close(); err != nil {
-				log.Error("Failed to close HTTP server", "error", err)
-			}
+			_ = httpServer.Close()
 		}
 	}
 
-	log.Info("Server stopped")
+	log.Info("Broker stopped")
+	return nil
+}
+
+func runGenerateToken(_ *cobra.Command, _ []string) error {
+	token, err := auth.GenerateToken()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(token)
+	fmt.Fprintf(os.Stderr, "\nAdd to the server configuration:\n")
+	fmt.Fprintf(os.Stderr, "  server:\n    auth_token: %q\n", token)
+	fmt.Fprintf(os.Stderr, "\nAdd to the client configuration:\n")
+	fmt.Fprintf(os.Stderr, "  hosts:\n    server:\n      auth_token: %q\n", token)
 	return nil
 }
 
-func runServiceInstall(_ *cobra.Command, _ []string) error {
-	sm, err := createServiceManager()
+func runServiceInstall(_ *cobra.Command, args []string) error {
+	sm, err := createServiceManager(args)
 	if err != nil {
 		return err
 	}
@@ -225,7 +491,7 @@ func runServiceInstall(_ *cobra.Command, _ []string) error {
 }
 
 func runServiceUninstall(_ *cobra.Command, _ []string) error {
-	sm, err := createServiceManager()
+	sm, err := createServiceManager(nil)
 	if err != nil {
 		return err
 	}
@@ -237,7 +503,7 @@ func runServiceUninstall(_ *cobra.Command, _ []string) error {
 }
 
 func runServiceStart(_ *cobra.Command, _ []string) error {
-	sm, err := createServiceManager()
+	sm, err := createServiceManager(nil)
 	if err != nil {
 		return err
 	}
@@ -249,7 +515,7 @@ func runServiceStart(_ *cobra.Command, _ []string) error {
 }
 
 func runServiceStop(_ *cobra.Command, _ []string) error {
-	sm, err := createServiceManager()
+	sm, err := createServiceManager(nil)
 	if err != nil {
 		return err
 	}
@@ -261,7 +527,7 @@ func runServiceStop(_ *cobra.Command, _ []string) error {
 }
 
 func runServiceStatus(_ *cobra.Command, _ []string) error {
-	sm, err := createServiceManager()
+	sm, err := createServiceManager(nil)
 	if err != nil {
 		return err
 	}
@@ -278,7 +544,7 @@ func runServiceStatus(_ *cobra.Command, _ []string) error {
 
 	if !isInstalled {
 		fmt.Println("Service is not installed.")
-		fmt.Println("Run 'rcode-server service install' to install it.")
+		fmt.Printf("Run '%s' to install it.\n", serviceCommand("install"))
 		return nil
 	}
 
@@ -286,20 +552,31 @@ func runServiceStatus(_ *cobra.Command, _ []string) error {
 		fmt.Println("Service is running.")
 	} else {
 		fmt.Println("Service is installed but not running.")
-		fmt.Println("Run 'rcode-server service start' to start it.")
+		fmt.Printf("Run '%s' to start it.\n", serviceCommand("start"))
 	}
 	return nil
 }
 
-func createServiceManager() (*service.ServiceManager, error) {
+func createServiceManager(args []string) (*service.ServiceManager, error) {
+	if err := service.ValidateName(serviceName); err != nil {
+		return nil, err
+	}
+
 	// Find the binary path
-	binaryPath, err := findBinaryPath()
-	if err != nil {
-		return nil, fmt.Errorf("failed to find binary path: %w", err)
+	binaryPath := serviceBinary
+	if binaryPath == "" {
+		var err error
+		binaryPath, err = findBinaryPath()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find binary path: %w", err)
+		}
 	}
 
 	// Create service manager
-	sm, err := service.NewServiceManager(binaryPath, configFile)
+	sm, err := service.NewServiceManagerWithOptions(binaryPath, configFile, service.Options{
+		Name: serviceName,
+		Args: args,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create service manager: %w", err)
 	}
@@ -307,6 +584,15 @@ func createServiceManager() (*service.ServiceManager, error) {
 	return sm, nil
 }
 
+// serviceCommand returns the rcode-server service subcommand for the selected service
+func serviceCommand(sub string) string {
+	command := "rcode-server service " + sub
+	if serviceName != service.DefaultName {
+		command += " --service-name " + serviceName
+	}
+	return command
+}
+
 // findBinaryPath finds the path to the rcode-server binary
 func findBinaryPath() (string, error) {
 	// Try to find the binary in PATH
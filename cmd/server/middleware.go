@@ -1,11 +1,102 @@
 package main
 
 import (
+	"errors"
+	"log/slog"
 	"net"
 	"net/http"
+	"strings"
 	"time"
+
+	"github.com/foxytanuki/rcode/internal/auth"
+	"github.com/foxytanuki/rcode/internal/logger"
+	"github.com/foxytanuki/rcode/internal/telemetry"
+	"github.com/foxytanuki/rcode/pkg/api"
 )
 
+// requestIDMiddleware gives every request an ID: the client's X-Request-ID
+// when it is well formed, otherwise a new one. The ID is echoed in the
+// response header and becomes the trace_id of the request's log lines.
+func (s *Server) requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(api.HeaderRequestID)
+		if !api.ValidRequestID(id) {
+			id = api.NewRequestID()
+		}
+		w.Header().Set(api.HeaderRequestID, id)
+		next.ServeHTTP(w, r.WithContext(logger.ContextWithTraceID(r.Context(), id)))
+	})
+}
+
+// protocolMiddleware answers every request with the server's protocol
+// version so clients can tell which features it has. Clients speaking a
+// newer protocol are logged, as they may send what this server ignores.
+func (s *Server) protocolMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		api.SetProtocolHeader(w.Header())
+		if client := api.ProtocolFromHeader(r.Header); client > api.ProtocolVersion {
+			s.requestLog(r).Debug("Client speaks a newer protocol",
+				"client_protocol", client,
+				"server_protocol", api.ProtocolVersion,
+			)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestLog returns the server logger tagged with r's request ID
+func (s *Server) requestLog(r *http.Request) *logger.Logger {
+	return s.log.WithContext(r.Context())
+}
+
+// tracingMiddleware records a server span and request metrics for every
+// request, continuing the client's trace when it sent a traceparent header
+func (s *Server) tracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.telemetry == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		route := routeName(r.URL.Path)
+		ctx := telemetry.Extract(r.Context(), r.Header)
+		ctx, span := s.telemetry.Start(ctx, r.Method+" "+route, telemetry.KindServer,
+			slog.String("http.request.method", r.Method),
+			slog.String("http.route", route),
+			slog.String("client.address", getClientIP(r)),
+			slog.String("rcode.request_id", logger.GetTraceID(r.Context())),
+		)
+		defer span.End()
+
+		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(wrapped, r.WithContext(ctx))
+
+		span.SetAttributes(slog.Int("http.response.status_code", wrapped.statusCode))
+		if wrapped.statusCode >= http.StatusInternalServerError {
+			span.RecordError(errors.New(http.StatusText(wrapped.statusCode)))
+		}
+		attrs := []slog.Attr{
+			slog.String("http.route", route),
+			slog.Int("http.response.status_code", wrapped.statusCode),
+		}
+		s.telemetry.Count("rcode.server.requests", 1, attrs...)
+		s.telemetry.RecordDuration("rcode.server.request.duration", time.Since(start), attrs...)
+	})
+}
+
+// routeName returns the route of path for telemetry, replacing editor
+// names so every admin request shares one route
+func routeName(path string) string {
+	if strings.HasPrefix(path, "/admin/editors/") {
+		return "/admin/editors/{name}"
+	}
+	if strings.HasPrefix(path, "/editors/") {
+		return "/editors/{name}/preview"
+	}
+	return path
+}
+
 // loggingMiddleware logs HTTP requests
 func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -22,7 +113,7 @@ func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 
 		// Log request details
 		duration := time.Since(start)
-		s.log.Info("HTTP request",
+		s.requestLog(r).Info("HTTP request",
 			"method", r.Method,
 			"path", r.URL.Path,
 			"remote_addr", r.RemoteAddr,
@@ -38,7 +129,7 @@ func (s *Server) recoveryMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				s.log.Error("Panic recovered",
+				s.requestLog(r).Error("Panic recovered",
 					"error", err,
 					"method", r.Method,
 					"path", r.URL.Path,
@@ -55,12 +146,15 @@ func (s *Server) recoveryMiddleware(next http.Handler) http.Handler {
 
 // ipWhitelistMiddleware restricts access based on IP whitelist
 func (s *Server) ipWhitelistMiddleware(next http.Handler) http.Handler {
-	// If no whitelist configured, allow all
-	if len(s.allowedIPs) == 0 && len(s.allowedNets) == 0 {
-		return next
-	}
-
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowedIPs, allowedNets := s.ipWhitelist()
+
+		// If no whitelist configured, allow all
+		if len(allowedIPs) == 0 && len(allowedNets) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
 		clientIP := getClientIP(r)
 		ip := net.ParseIP(clientIP)
 
@@ -74,14 +168,14 @@ func (s *Server) ipWhitelistMiddleware(next http.Handler) http.Handler {
 		}
 
 		allowed := false
-		for _, allowedIP := range s.allowedIPs {
+		for _, allowedIP := range allowedIPs {
 			if ip.Equal(allowedIP) {
 				allowed = true
 				break
 			}
 		}
 		if !allowed {
-			for _, ipNet := range s.allowedNets {
+			for _, ipNet := range allowedNets {
 				if ipNet.Contains(ip) {
 					allowed = true
 					break
@@ -102,6 +196,37 @@ func (s *Server) ipWhitelistMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// isHealthPath reports whether path is one of the health endpoints, which
+// stay open to monitoring without credentials or rate limits
+func isHealthPath(path string) bool {
+	return path == "/health" || path == "/healthz" || path == "/readyz"
+}
+
+// authMiddleware requires a valid bearer token when server.auth_token is set.
+// The health endpoints stay open so monitoring keeps working without credentials.
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := s.currentConfig().Server.AuthToken
+		if token == "" || isHealthPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if err := auth.VerifyRequest(r, token); err != nil {
+			s.requestLog(r).Warn("Unauthorized request",
+				"error", err,
+				"path", r.URL.Path,
+				"remote_addr", r.RemoteAddr,
+			)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="rcode"`)
+			s.respondError(w, api.ErrUnauthorized, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 // responseWriter wraps http.ResponseWriter to capture status code
 type responseWriter struct {
 	http.ResponseWriter
@@ -124,6 +249,12 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	return rw.ResponseWriter.Write(b)
 }
 
+// Unwrap returns the underlying writer so http.ResponseController can reach
+// Flush and deadline controls for streaming responses.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // getClientIP extracts the client IP from the request.
 // Only uses RemoteAddr to prevent IP spoofing via X-Forwarded-For/X-Real-IP headers.
 // rcode-server is designed for direct access without a reverse proxy.
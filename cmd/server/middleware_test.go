@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/auth"
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/internal/telemetry"
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	const token = "0123456789abcdef0123456789abcdef"
+
+	server := createTestServer()
+	server.config.Server.AuthToken = token
+	handler := server.Router()
+
+	tests := []struct {
+		name       string
+		path       string
+		token      string
+		wantStatus int
+	}{
+		{"valid token", "/editors", token, http.StatusOK},
+		{"missing token", "/editors", "", http.StatusUnauthorized},
+		{"wrong token", "/editors", "fedcba9876543210fedcba9876543210", http.StatusUnauthorized},
+		{"health is public", "/health", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, http.NoBody)
+			auth.SetBearerToken(req, tt.token)
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v", rec.Code, tt.wantStatus)
+			}
+
+			if tt.wantStatus == http.StatusUnauthorized {
+				var resp api.ErrorResponse
+				if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+					t.Fatalf("Failed to decode response: %v", err)
+				}
+				if resp.Code != api.CodeUnauthorized {
+					t.Errorf("Error code = %v, want %v", resp.Code, api.CodeUnauthorized)
+				}
+			}
+		})
+	}
+}
+
+func TestAuthMiddleware_Disabled(t *testing.T) {
+	server := createTestServer()
+	handler := server.Router()
+
+	req := httptest.NewRequest(http.MethodGet, "/editors", http.NoBody)
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %v, want %v", rec.Code, http.StatusOK)
+	}
+}
+
+func TestSignatureMiddleware(t *testing.T) {
+	const (
+		key   = "0123456789abcdef0123456789abcdef"
+		other = "fedcba9876543210fedcba9876543210"
+	)
+	body := `{"path":"/home/alice/project","user":"alice","host":"devbox"}`
+
+	server := createTestServer()
+	server.config.Server.Signing.Keys = []string{other, key}
+	handler := server.Router()
+
+	send := func(req *http.Request) int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	newRequest := func(signingKey string, at time.Time) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/render", strings.NewReader(body))
+		auth.SignRequest(req, []byte(body), signingKey, at)
+		return req
+	}
+
+	now := time.Now()
+	signed := newRequest(key, now)
+	signed.Header.Set(api.HeaderRequestID, "retried-request")
+	replayed := httptest.NewRequest(http.MethodPost, "/render", strings.NewReader(body))
+	replayed.Header = signed.Header.Clone()
+	// A client retry signs the same request again within the same second
+	retried := newRequest(key, now)
+	retried.Header.Set(api.HeaderRequestID, "retried-request")
+
+	tests := []struct {
+		name       string
+		req        *http.Request
+		wantStatus int
+	}{
+		{"signed", signed, http.StatusOK},
+		{"replayed", replayed, http.StatusUnauthorized},
+		{"retried in the same second", retried, http.StatusOK},
+		{"unsigned", newRequest("", time.Now()), http.StatusUnauthorized},
+		{"unknown key", newRequest("00000000000000000000000000000000", time.Now()), http.StatusUnauthorized},
+		{"stale", newRequest(key, time.Now().Add(-time.Hour)), http.StatusUnauthorized},
+		{"GET needs no signature", httptest.NewRequest(http.MethodGet, "/editors", http.NoBody), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := send(tt.req); got != tt.wantStatus {
+				t.Errorf("status = %v, want %v", got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	server := createTestServer()
+	handler := server.Router()
+
+	tests := []struct {
+		name   string
+		sent   string
+		wantID string // empty means a generated ID
+	}{
+		{"client ID is kept", "abc-123_x.y", "abc-123_x.y"},
+		{"missing ID is generated", "", ""},
+		{"malformed ID is replaced", "bad id", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/open-editor", strings.NewReader("{}"))
+			if tt.sent != "" {
+				req.Header.Set(api.HeaderRequestID, tt.sent)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			got := rec.Header().Get(api.HeaderRequestID)
+			if tt.wantID != "" && got != tt.wantID {
+				t.Errorf("%s = %q, want %q", api.HeaderRequestID, got, tt.wantID)
+			}
+			if !api.ValidRequestID(got) || got == tt.sent && tt.wantID == "" {
+				t.Errorf("%s = %q, want a generated ID", api.HeaderRequestID, got)
+			}
+
+			var resp api.ErrorResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if resp.RequestID != got {
+				t.Errorf("ErrorResponse.RequestID = %q, want %q", resp.RequestID, got)
+			}
+		})
+	}
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	var mu sync.Mutex
+	var spans []map[string]any
+	collector := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			return
+		}
+		var payload struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []map[string]any `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("collector: %v", err)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		spans = append(spans, payload.ResourceSpans[0].ScopeSpans[0].Spans...)
+	}))
+	defer collector.Close()
+
+	server := createTestServer()
+	server.telemetry = telemetry.New(config.TelemetryConfig{
+		Enabled:  true,
+		Endpoint: collector.URL,
+		Interval: time.Hour,
+	}, "rcode-server", server.log)
+
+	const traceID = "0af7651916cd43dd8448eb211c80319c"
+	req := httptest.NewRequest(http.MethodGet, "/editors", http.NoBody)
+	req.Header.Set("traceparent", "00-"+traceID+"-b7ad6b7169203331-01")
+	server.Router().ServeHTTP(httptest.NewRecorder(), req)
+
+	if err := server.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(spans) != 1 {
+		t.Fatalf("exported spans = %d, want 1", len(spans))
+	}
+	span := spans[0]
+	if span["name"] != "GET /editors" || span["traceId"] != traceID || span["parentSpanId"] != "b7ad6b7169203331" {
+		t.Errorf("span = %v, want GET /editors continuing trace %s", span, traceID)
+	}
+}
+
+func TestProtocolMiddleware(t *testing.T) {
+	server := createTestServer()
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	req.Header.Set(api.HeaderProtocol, "99") // a newer client is still served
+	rec := httptest.NewRecorder()
+
+	server.Router().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if got := api.ProtocolFromHeader(rec.Header()); got != api.ProtocolVersion {
+		t.Errorf("%s = %d, want %d", api.HeaderProtocol, got, api.ProtocolVersion)
+	}
+	var health api.HealthResponse
+	if err := json.NewDecoder(rec.Body).Decode(&health); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if health.Protocol != api.ProtocolVersion {
+		t.Errorf("HealthResponse.Protocol = %d, want %d", health.Protocol, api.ProtocolVersion)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/foxytanuki/rcode/internal/editor"
+	"github.com/foxytanuki/rcode/internal/validation"
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+// maxOpenURLBody bounds the /open-url request body
+const maxOpenURLBody = 64 << 10
+
+// handleOpenURL handles POST /open-url, opening an allowed URL in the
+// host's default browser
+func (s *Server) handleOpenURL(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.respondError(w, api.ErrNotImplemented, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	allowed := s.currentConfig().Server.OpenURL.Allowed
+	if len(allowed) == 0 {
+		s.respondError(w, api.ErrDisabled, http.StatusForbidden, "opening URLs is disabled; set server.open_url.allowed")
+		return
+	}
+
+	var req api.OpenURLRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxOpenURLBody)).Decode(&req); err != nil {
+		s.respondError(w, api.ErrInvalidRequest, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	u, err := url.Parse(req.URL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		s.respondError(w, api.ErrInvalidRequest, http.StatusBadRequest, fmt.Sprintf("not an absolute URL: %q", req.URL))
+		return
+	}
+	if !validation.URLAllowed(u, allowed) {
+		s.log.Warn("URL not allowed", "url", req.URL, "remote_addr", r.RemoteAddr)
+		s.respondError(w, api.ErrURLNotAllowed, http.StatusForbidden, fmt.Sprintf("%s://%s is not in server.open_url.allowed", u.Scheme, u.Host))
+		return
+	}
+
+	target := u.String()
+	err = s.executor.Run(func() error { return s.openBrowser(target, s.log) })
+	if errors.Is(err, editor.ErrShuttingDown) {
+		s.respondError(w, api.ErrShuttingDown, http.StatusServiceUnavailable, "try again shortly or use another host")
+		return
+	}
+	if err != nil {
+		s.log.Error("Failed to open URL", "error", err, "url", target)
+		s.respondError(w, err, http.StatusInternalServerError, "")
+		return
+	}
+
+	s.log.Info("Opened URL", "url", target, "remote_addr", r.RemoteAddr)
+
+	response := api.OpenURLResponse{Success: true, URL: target}
+	response.SetTimestamp()
+	s.respondJSON(w, http.StatusOK, response)
+}
+
+// checkForwardedURL checks the URL a browser editor rendered for a request
+// carrying a port against server.open_url.allowed, since the client then
+// decides part of where the host browser goes
+func (s *Server) checkForwardedURL(rawURL string) *renderFailure {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return &renderFailure{api.ErrInvalidRequest, http.StatusBadRequest, fmt.Sprintf("not an absolute URL: %q", rawURL)}
+	}
+	if !validation.URLAllowed(u, s.currentConfig().Server.OpenURL.Allowed) {
+		return &renderFailure{api.ErrURLNotAllowed, http.StatusForbidden, fmt.Sprintf("%s://%s is not in server.open_url.allowed", u.Scheme, u.Host)}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/foxytanuki/rcode/internal/logger"
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+func TestHandleOpenURL(t *testing.T) {
+	allowed := []string{"https://*.github.com", "http://localhost:*"}
+
+	tests := []struct {
+		name       string
+		allowed    []string
+		url        string
+		wantStatus int
+		wantCode   string
+		wantOpened string
+	}{
+		{
+			name:       "disabled without allow list",
+			url:        "https://gist.github.com/x",
+			wantStatus: http.StatusForbidden,
+			wantCode:   api.CodeDisabled,
+		},
+		{
+			name:       "allowed url",
+			allowed:    allowed,
+			url:        "https://gist.github.com/x?y=1",
+			wantStatus: http.StatusOK,
+			wantOpened: "https://gist.github.com/x?y=1",
+		},
+		{
+			name:       "local dev server",
+			allowed:    allowed,
+			url:        "http://localhost:5173/",
+			wantStatus: http.StatusOK,
+			wantOpened: "http://localhost:5173/",
+		},
+		{
+			name:       "url not allowed",
+			allowed:    allowed,
+			url:        "https://evil.example/",
+			wantStatus: http.StatusForbidden,
+			wantCode:   api.CodeURLNotAllowed,
+		},
+		{
+			name:       "file url",
+			allowed:    allowed,
+			url:        "file:///etc/passwd",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   api.CodeInvalidRequest,
+		},
+		{
+			name:       "relative url",
+			allowed:    allowed,
+			url:        "/just/a/path",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   api.CodeInvalidRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := createTestServer()
+			server.config.Server.OpenURL.Allowed = tt.allowed
+
+			var opened string
+			server.openBrowser = func(url string, _ *logger.Logger) error {
+				opened = url
+				return nil
+			}
+
+			body, err := json.Marshal(api.OpenURLRequest{URL: tt.url})
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+
+			rec := httptest.NewRecorder()
+			server.handleOpenURL(rec, httptest.NewRequest(http.MethodPost, "/open-url", bytes.NewReader(body)))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("handleOpenURL() status = %v, want %v", rec.Code, tt.wantStatus)
+			}
+			if tt.wantCode != "" {
+				var errResp api.ErrorResponse
+				if err := json.NewDecoder(rec.Body).Decode(&errResp); err != nil {
+					t.Fatalf("Decode() error = %v", err)
+				}
+				if errResp.Code != tt.wantCode {
+					t.Errorf("code = %v, want %v", errResp.Code, tt.wantCode)
+				}
+			}
+			if opened != tt.wantOpened {
+				t.Errorf("opened %q, want %q", opened, tt.wantOpened)
+			}
+		})
+	}
+}
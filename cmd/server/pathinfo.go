@@ -0,0 +1,46 @@
+package main
+
+import (
+	"github.com/foxytanuki/rcode/internal/logger"
+	"github.com/foxytanuki/rcode/internal/pathcheck"
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+// statPaths looks up each of paths on this machine. A path that cannot be
+// looked up, for lack of permission for example, is logged and reported as
+// missing.
+func statPaths(log *logger.Logger, paths []string) []api.PathInfo {
+	infos := make([]api.PathInfo, 0, len(paths))
+	for _, p := range paths {
+		info, err := pathcheck.Stat(p)
+		if err != nil {
+			log.Warn("Failed to look up path", "path", p, "error", err)
+		}
+		infos = append(infos, api.PathInfo{
+			Path:   p,
+			Exists: info.Exists && err == nil,
+			IsDir:  info.IsDir,
+			Target: info.Target,
+		})
+	}
+	return infos
+}
+
+// missingPath returns the first of infos that does not exist, or nil
+func missingPath(infos []api.PathInfo) *api.PathInfo {
+	for i := range infos {
+		if !infos[i].Exists {
+			return &infos[i]
+		}
+	}
+	return nil
+}
+
+// infoPaths returns the paths infos describe
+func infoPaths(infos []api.PathInfo) []string {
+	paths := make([]string, len(infos))
+	for i, info := range infos {
+		paths[i] = info.Path
+	}
+	return paths
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/internal/editor"
+	"github.com/foxytanuki/rcode/internal/validation"
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+// previewSample holds the request values a preview uses when the query
+// does not give them
+var previewSample = api.OpenRequest{
+	Path: "/home/user/project",
+	User: "user",
+	Host: "remote-host",
+}
+
+// handleEditorPreview handles GET /editors/{name}/preview, rendering what
+// the editor would run for the request given by the path, user, host,
+// line, column and port query parameters, or sample values, and listing the
+// problems found without launching anything
+func (s *Server) handleEditorPreview(w http.ResponseWriter, r *http.Request) {
+	name, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/editors/"), "/")
+	if name == "" || action != "preview" {
+		s.respondError(w, api.ErrInvalidRequest, http.StatusNotFound, fmt.Sprintf("unknown endpoint %s", r.URL.Path))
+		return
+	}
+	if r.Method != http.MethodGet {
+		s.respondError(w, api.ErrNotImplemented, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	req, err := parsePreviewRequest(name, r.URL.Query())
+	if err != nil {
+		s.respondError(w, api.ErrInvalidRequest, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	mgr := s.editors()
+	e, err := mgr.GetEditor(name)
+	if err != nil {
+		s.respondError(w, api.ErrEditorNotFound, http.StatusNotFound, name)
+		return
+	}
+
+	editorType := string(e.Type)
+	if editorType == "" {
+		editorType = "command"
+	}
+	response := api.EditorPreviewResponse{
+		RenderResponse: api.RenderResponse{
+			Editor:    e.Name,
+			Type:      editorType,
+			Available: mgr.IsAvailable(e.Name),
+		},
+		Issues: []string{},
+	}
+
+	plan := &openPlan{req: req, paths: req.AllPaths()}
+	if failure := s.renderOpen(s.requestLog(r), plan, e); failure != nil {
+		msg := failure.details
+		if msg == "" {
+			msg = failure.err.Error()
+		}
+		response.Issues = append(response.Issues, "cannot render the command: "+msg)
+	} else {
+		response.Command = plan.command
+		response.WorkDir = plan.workdir
+	}
+	response.Issues = append(response.Issues, s.previewIssues(mgr, e, &req)...)
+	response.SetTimestamp()
+
+	s.respondJSON(w, http.StatusOK, response)
+}
+
+// parsePreviewRequest builds the open request a preview renders from the
+// query, filling in previewSample for the values it does not give
+func parsePreviewRequest(name string, query url.Values) (api.OpenRequest, error) {
+	req := api.OpenRequest{
+		Editor: name,
+		Path:   query.Get("path"),
+		User:   query.Get("user"),
+		Host:   query.Get("host"),
+	}
+	if req.Path == "" {
+		req.Path = previewSample.Path
+	}
+	if req.User == "" {
+		req.User = previewSample.User
+	}
+	if req.Host == "" {
+		req.Host = previewSample.Host
+	}
+	for _, field := range []struct {
+		name string
+		dst  *int
+	}{
+		{"line", &req.Line},
+		{"column", &req.Column},
+		{"port", &req.Port},
+	} {
+		value := query.Get(field.name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return req, fmt.Errorf("%s must be a non-negative integer", field.name)
+		}
+		*field.dst = n
+	}
+	if err := req.Validate(); err != nil {
+		return req, err
+	}
+	return req, req.CleanPaths()
+}
+
+// previewIssues returns the problems that would keep e from opening req:
+// a path outside allowed_paths or missing with path_check.require_existing,
+// an editor that is not installed or whose command is not on PATH, and
+// placeholders the request does not fill
+func (s *Server) previewIssues(mgr *editor.Manager, e *editor.Editor, req *api.OpenRequest) []string {
+	var issues []string
+	if !validation.PathAllowed(req.Path, s.currentConfig().Server.AllowedPaths) {
+		issues = append(issues, fmt.Sprintf("%s is outside server.allowed_paths; open requests for it are rejected", req.Path))
+	}
+	if s.currentConfig().Server.PathCheck.RequireExisting {
+		if missing := missingPath(statPaths(s.log, []string{req.Path})); missing != nil {
+			issues = append(issues, fmt.Sprintf("%s does not exist on the host; open requests for it are rejected", req.Path))
+		}
+	}
+
+	if !mgr.IsAvailable(e.Name) {
+		issues = append(issues, "the editor is not installed on the host")
+	} else if issue := missingCommand(e, mgr.AvailableVia(e.Name)); issue != "" {
+		issues = append(issues, issue)
+	}
+
+	if e.Type == config.EditorTypeBrowser || e.Type == config.EditorTypeTmux || e.Template == nil {
+		return issues
+	}
+	if !e.Sync && !e.RequiresHost() {
+		issues = append(issues, "the template does not use {host}, so paths are opened on the host's own filesystem rather than over SSH")
+	}
+	if (req.Line > 0 || req.Column > 0) && !e.Template.HasPosition() {
+		issues = append(issues, "the template has no {line} or {column}, so positions are ignored")
+	}
+	return issues
+}
+
+// missingCommand describes an installed command editor whose executable is
+// not on PATH, where launching it would fail, or returns ""
+func missingCommand(e *editor.Editor, via string) string {
+	if e.Type != "" && e.Type != config.EditorTypeCommand {
+		return ""
+	}
+	executable, _ := editor.ParseCommand(e.Command)
+	if executable == "" || strings.Contains(executable, "{") {
+		return ""
+	}
+	if _, err := exec.LookPath(executable); err == nil || !errors.Is(err, exec.ErrNotFound) {
+		return ""
+	}
+
+	for _, shim := range editor.MissingShims(editor.DefaultShimDir) {
+		if shim.Name == executable {
+			return fmt.Sprintf("the %s command is not on PATH; run rcode-server install-shims to link it", executable)
+		}
+	}
+	return fmt.Sprintf("the %s command is not on PATH although the editor was found (%s); launching it will fail", executable, via)
+}
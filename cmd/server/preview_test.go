@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+func TestHandleEditorPreview(t *testing.T) {
+	server := createTestServer()
+	server.config.Server.AllowedPaths = []string{"/home"}
+	if err := server.editors().AddEditor(config.EditorConfig{
+		Name:    "missing-cli",
+		Command: "rcode-no-such-editor --remote {user}@{host} {path}",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	handler := server.Router()
+
+	tests := []struct {
+		name       string
+		url        string
+		wantStatus int
+		wantCmd    string
+		wantIssues []string // substrings, one per expected issue
+	}{
+		{
+			name:       "sample data",
+			url:        "/editors/test-editor/preview",
+			wantStatus: http.StatusOK,
+			wantCmd:    "echo 'Opening /home/user/project for user@remote-host'",
+		},
+		{
+			name:       "given values",
+			url:        "/editors/test-editor/preview?path=/home/alice/src&user=alice&host=devbox",
+			wantStatus: http.StatusOK,
+			wantCmd:    "echo 'Opening /home/alice/src for alice@devbox'",
+		},
+		{
+			name:       "local template with a position",
+			url:        "/editors/another-editor/preview?line=12",
+			wantStatus: http.StatusOK,
+			wantCmd:    "echo 'Another /home/user/project'",
+			wantIssues: []string{"{host}", "{line}"},
+		},
+		{
+			name:       "path outside allowed_paths",
+			url:        "/editors/test-editor/preview?path=/etc",
+			wantStatus: http.StatusOK,
+			wantCmd:    "echo 'Opening /etc for user@remote-host'",
+			wantIssues: []string{"allowed_paths"},
+		},
+		{
+			name:       "editor not installed",
+			url:        "/editors/missing-cli/preview",
+			wantStatus: http.StatusOK,
+			wantCmd:    "rcode-no-such-editor --remote user@remote-host /home/user/project",
+			wantIssues: []string{"not installed"},
+		},
+		{
+			name:       "unknown editor",
+			url:        "/editors/nope/preview",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "invalid line",
+			url:        "/editors/test-editor/preview?line=x",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown endpoint",
+			url:        "/editors/test-editor/launch",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.url, http.NoBody))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %v, want %v: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp api.EditorPreviewResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if resp.Command != tt.wantCmd {
+				t.Errorf("Command = %q, want %q", resp.Command, tt.wantCmd)
+			}
+			if len(resp.Issues) != len(tt.wantIssues) {
+				t.Fatalf("Issues = %q, want %d", resp.Issues, len(tt.wantIssues))
+			}
+			for i, want := range tt.wantIssues {
+				if !strings.Contains(resp.Issues[i], want) {
+					t.Errorf("Issues[%d] = %q, want it to mention %q", i, resp.Issues[i], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"hash/fnv"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+const (
+	// rateLimitShards spreads client buckets over independently locked maps
+	rateLimitShards = 16
+	// maxBucketsPerShard bounds memory when many addresses call the server
+	maxBucketsPerShard = 1024
+)
+
+// tokenBucket holds the remaining requests of one client
+type tokenBucket struct {
+	tokens float64
+	last   time.Time // when tokens was last brought up to date
+}
+
+// limiterShard is one locked part of the client bucket map
+type limiterShard struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// rateLimiter is a token-bucket limiter keyed by client IP. Buckets that
+// have refilled completely carry no state and are evicted.
+type rateLimiter struct {
+	rate   float64       // tokens added per second
+	burst  float64       // bucket capacity
+	idle   time.Duration // time for an empty bucket to refill
+	shards [rateLimitShards]limiterShard
+
+	now func() time.Time // time.Now, replaced in tests
+}
+
+// newRateLimiter returns a limiter for cfg, or nil when it is disabled
+func newRateLimiter(cfg config.RateLimitConfig) *rateLimiter {
+	if !cfg.Enabled {
+		return nil
+	}
+	perMinute, burst := cfg.Limits()
+	l := &rateLimiter{
+		rate:  float64(perMinute) / 60,
+		burst: float64(burst),
+		now:   time.Now,
+	}
+	l.idle = time.Duration(l.burst / l.rate * float64(time.Second))
+	for i := range l.shards {
+		l.shards[i].buckets = make(map[string]*tokenBucket)
+	}
+	return l
+}
+
+// allow takes a token from key's bucket. When none is left it reports how
+// long until the next one is available.
+func (l *rateLimiter) allow(key string) (bool, time.Duration) {
+	now := l.now()
+	shard := &l.shards[shardIndex(key)]
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if now.Sub(shard.lastSweep) >= l.idle || len(shard.buckets) >= maxBucketsPerShard {
+		l.sweep(shard, now)
+	}
+
+	bucket, ok := shard.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, last: now}
+		shard.buckets[key] = bucket
+	} else {
+		elapsed := now.Sub(bucket.last).Seconds()
+		bucket.tokens = math.Min(l.burst, bucket.tokens+elapsed*l.rate)
+		bucket.last = now
+	}
+
+	if bucket.tokens < 1 {
+		wait := time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+		return false, wait
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// sweep evicts buckets that have refilled completely. If the shard is still
+// full, the least recently used bucket goes too.
+func (l *rateLimiter) sweep(shard *limiterShard, now time.Time) {
+	shard.lastSweep = now
+
+	var oldestKey string
+	var oldest time.Time
+	for key, bucket := range shard.buckets {
+		if now.Sub(bucket.last) >= l.idle {
+			delete(shard.buckets, key)
+			continue
+		}
+		if oldestKey == "" || bucket.last.Before(oldest) {
+			oldestKey, oldest = key, bucket.last
+		}
+	}
+	if len(shard.buckets) >= maxBucketsPerShard {
+		delete(shard.buckets, oldestKey)
+	}
+}
+
+// size returns the number of tracked clients
+func (l *rateLimiter) size() int {
+	n := 0
+	for i := range l.shards {
+		l.shards[i].mu.Lock()
+		n += len(l.shards[i].buckets)
+		l.shards[i].mu.Unlock()
+	}
+	return n
+}
+
+func shardIndex(key string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % rateLimitShards)
+}
+
+// rateLimitMiddleware rejects clients that exceed server.rate_limit with 429.
+// The health endpoints are exempt so monitoring and host probes keep working.
+func (s *Server) rateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limiter := s.rateLimiter()
+		if limiter == nil || isHealthPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		clientIP := getClientIP(r)
+		if ok, wait := limiter.allow(clientIP); !ok {
+			s.requestLog(r).Warn("Rate limit exceeded",
+				"client_ip", clientIP,
+				"path", r.URL.Path,
+			)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			s.respondError(w, api.ErrRateLimited, http.StatusTooManyRequests, "too many requests")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
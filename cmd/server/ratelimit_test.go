@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+func TestRateLimiter_TokenBucket(t *testing.T) {
+	limiter := newRateLimiter(config.RateLimitConfig{Enabled: true, RequestsPerMinute: 60, Burst: 3})
+	now := time.Unix(1000, 0)
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := limiter.allow("10.0.0.1"); !ok {
+			t.Fatalf("request %d within burst rejected", i+1)
+		}
+	}
+	ok, wait := limiter.allow("10.0.0.1")
+	if ok || wait != time.Second {
+		t.Errorf("allow() after burst = %v, %v, want false, 1s", ok, wait)
+	}
+
+	// Other clients have their own bucket
+	if ok, _ := limiter.allow("10.0.0.2"); !ok {
+		t.Error("another client was rejected")
+	}
+
+	// One token per second at 60 requests per minute
+	now = now.Add(time.Second)
+	if ok, _ := limiter.allow("10.0.0.1"); !ok {
+		t.Error("request after refill rejected")
+	}
+	if ok, _ := limiter.allow("10.0.0.1"); ok {
+		t.Error("second request after a one-token refill allowed")
+	}
+}
+
+func TestRateLimiter_Eviction(t *testing.T) {
+	limiter := newRateLimiter(config.RateLimitConfig{Enabled: true, RequestsPerMinute: 60, Burst: 2})
+	now := time.Unix(1000, 0)
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 100; i++ {
+		limiter.allow(fmt.Sprintf("10.0.0.%d", i))
+	}
+	if got := limiter.size(); got != 100 {
+		t.Fatalf("size() = %d, want 100", got)
+	}
+
+	// Once a bucket has refilled it is forgotten on the next visit to its shard
+	now = now.Add(time.Minute)
+	visited := map[int]bool{}
+	for i := 0; len(visited) < rateLimitShards; i++ {
+		key := fmt.Sprintf("192.168.1.%d", i)
+		if shard := shardIndex(key); !visited[shard] {
+			visited[shard] = true
+			limiter.allow(key)
+		}
+	}
+	if got := limiter.size(); got != rateLimitShards {
+		t.Errorf("size() after idle period = %d, want %d", got, rateLimitShards)
+	}
+
+	// A flood of addresses stays bounded
+	added := 0
+	for i := 0; added < 2*maxBucketsPerShard; i++ {
+		key := fmt.Sprintf("10.1.%d.%d", i/256, i%256)
+		if shardIndex(key) == 0 {
+			limiter.allow(key)
+			added++
+		}
+	}
+	if got := len(limiter.shards[0].buckets); got > maxBucketsPerShard {
+		t.Errorf("shard size = %d, want at most %d", got, maxBucketsPerShard)
+	}
+}
+
+func TestRateLimiter_Concurrent(t *testing.T) {
+	limiter := newRateLimiter(config.RateLimitConfig{Enabled: true, RequestsPerMinute: 1, Burst: 50})
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, _ := limiter.allow("10.0.0.1"); ok {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed != 50 {
+		t.Errorf("allowed = %d, want the burst of 50", allowed)
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	server := createTestServer()
+	server.config.Server.RateLimit = config.RateLimitConfig{Enabled: true, RequestsPerMinute: 6, Burst: 1}
+	server.limiter = newRateLimiter(server.config.Server.RateLimit)
+	handler := server.Router()
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+		req.RemoteAddr = "192.0.2.1:40000"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := get("/editors"); rec.Code != http.StatusOK {
+		t.Fatalf("first request status = %d, want 200", rec.Code)
+	}
+	rec := get("/editors")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request status = %d, want 429", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "10" {
+		t.Errorf("Retry-After = %q, want %q", got, "10")
+	}
+	var resp api.ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Code != api.CodeRateLimited {
+		t.Errorf("error response = %+v (%v), want code %s", resp, err, api.CodeRateLimited)
+	}
+
+	// Health checks are not limited
+	if rec := get("/health"); rec.Code != http.StatusOK {
+		t.Errorf("/health status = %d, want 200", rec.Code)
+	}
+
+	// Disabling the limit on reload takes effect at once
+	cfg := *server.config
+	cfg.Server.RateLimit.Enabled = false
+	if err := server.Reload(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if rec := get("/editors"); rec.Code != http.StatusOK {
+		t.Errorf("status after disabling = %d, want 200", rec.Code)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/internal/logger"
+)
+
+// configPollInterval is how often the config file is checked for changes
+const configPollInterval = 2 * time.Second
+
+// configWatcher reloads the server configuration on SIGHUP and whenever the
+// config file's modification time or size changes. Polling keeps it
+// dependency-free and also catches editors that replace the file on save.
+type configWatcher struct {
+	path     string
+	srv      *Server
+	load     func() (*config.ServerConfigFile, error)
+	log      *logger.Logger
+	interval time.Duration
+	modTime  time.Time
+	size     int64
+}
+
+// newConfigWatcher creates a watcher for path that applies configs returned
+// by load to srv
+func newConfigWatcher(path string, srv *Server, load func() (*config.ServerConfigFile, error), log *logger.Logger) *configWatcher {
+	cw := &configWatcher{
+		path:     path,
+		srv:      srv,
+		load:     load,
+		log:      log,
+		interval: configPollInterval,
+	}
+	cw.changed() // Record the current state as the baseline
+	return cw
+}
+
+// run watches until ctx is done
+func (cw *configWatcher) run(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(cw.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			cw.changed()
+			cw.reload("signal")
+		case <-ticker.C:
+			if cw.changed() {
+				cw.reload("file changed")
+			}
+		}
+	}
+}
+
+// changed reports whether the config file differs from the last check
+func (cw *configWatcher) changed() bool {
+	info, err := os.Stat(cw.path)
+	if err != nil {
+		return false
+	}
+	if info.ModTime().Equal(cw.modTime) && info.Size() == cw.size {
+		return false
+	}
+	cw.modTime = info.ModTime()
+	cw.size = info.Size()
+	return true
+}
+
+// reload loads and applies the configuration. An invalid file is logged and
+// the running configuration is kept.
+func (cw *configWatcher) reload(reason string) {
+	cw.log.Info("Reloading configuration", "file", cw.path, "reason", reason)
+
+	cfg, err := cw.load()
+	if err != nil {
+		cw.log.Error("Configuration reload failed, keeping current configuration", "error", err)
+		return
+	}
+	if err := cw.srv.Reload(cfg); err != nil {
+		cw.log.Error("Configuration reload failed, keeping current configuration", "error", err)
+	}
+}
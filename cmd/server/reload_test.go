@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/config"
+)
+
+func reloadTestConfig(editors ...config.EditorConfig) *config.ServerConfigFile {
+	return &config.ServerConfigFile{
+		Server: config.ServerConfig{
+			Host: "localhost",
+			Port: 3339,
+		},
+		Editors: editors,
+		Logging: config.LogConfig{Level: "error"},
+	}
+}
+
+func TestServerReload(t *testing.T) {
+	server := createTestServer()
+
+	cfg := reloadTestConfig(config.EditorConfig{Name: "new-editor", Command: "echo {path}", Default: true})
+	cfg.Server.AllowedIPs = []string{"10.0.0.1"}
+	if err := server.Reload(cfg); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	if got := server.editors().GetDefaultName(); got != "new-editor" {
+		t.Errorf("default editor = %v, want new-editor", got)
+	}
+	if _, err := server.editors().GetEditor("test-editor"); err == nil {
+		t.Error("GetEditor(test-editor) succeeded after reload, want error")
+	}
+
+	// The new IP whitelist applies to the existing router
+	req := httptest.NewRequest(http.MethodGet, "/health", http.NoBody)
+	req.RemoteAddr = "192.168.1.50:12345"
+	rec := httptest.NewRecorder()
+	server.Router().ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("status = %v, want %v", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestServerReloadKeepsStateOnError(t *testing.T) {
+	server := createTestServer()
+
+	if err := server.Reload(reloadTestConfig()); err == nil {
+		t.Fatal("Reload() with no editors error = nil, want error")
+	}
+	if got := server.editors().GetDefaultName(); got != "test-editor" {
+		t.Errorf("default editor = %v, want test-editor", got)
+	}
+	if got := server.currentConfig().Server.Port; got != 3339 {
+		t.Errorf("port = %v, want 3339", got)
+	}
+}
+
+func TestRestartOnlyChanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*config.ServerConfigFile)
+		want   []string
+	}{
+		{"editors only", func(c *config.ServerConfigFile) { c.Server.AllowedIPs = []string{"10.0.0.1"} }, nil},
+		{"port", func(c *config.ServerConfigFile) { c.Server.Port = 4000 }, []string{"server.port"}},
+		{"listen", func(c *config.ServerConfigFile) { c.Server.Listen = []string{"127.0.0.1"} }, []string{"server.listen"}},
+		{"listen interfaces", func(c *config.ServerConfigFile) { c.Server.Interfaces = []string{"tailscale0"} }, []string{"server.listen_interfaces"}},
+		{"tls", func(c *config.ServerConfigFile) { c.Server.TLS.CertFile = "server.pem" }, []string{"server.tls"}},
+		{"log format", func(c *config.ServerConfigFile) { c.Logging.Format = "json" }, []string{"logging.format"}},
+		{"log level", func(c *config.ServerConfigFile) { c.Logging.Level = "debug" }, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := reloadTestConfig()
+			cfg := reloadTestConfig()
+			tt.modify(cfg)
+			if got := restartOnlyChanges(old, cfg); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("restartOnlyChanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigWatcher(t *testing.T) {
+	server := createTestServer()
+	path := filepath.Join(t.TempDir(), "server-config.yaml")
+
+	write := func(editorName string) {
+		cfg := reloadTestConfig(config.EditorConfig{Name: editorName, Command: "echo {path}", Default: true})
+		if err := config.SaveServerConfig(path, cfg); err != nil {
+			t.Fatalf("SaveServerConfig() error = %v", err)
+		}
+	}
+	write("first-editor")
+
+	load := func() (*config.ServerConfigFile, error) {
+		return config.LoadServerConfig(path)
+	}
+	watcher := newConfigWatcher(path, server, load, server.log)
+	watcher.interval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watcher.run(ctx)
+
+	// Make sure the rewrite is seen even on filesystems with coarse mtimes
+	write("second-editor-with-longer-name")
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatalf("Chtimes() error = %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if server.editors().GetDefaultName() == "second-editor-with-longer-name" {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("default editor = %v after file change, want second-editor-with-longer-name", server.editors().GetDefaultName())
+}
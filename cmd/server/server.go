@@ -1,24 +1,62 @@
 package main
 
 import (
+	"context"
 	"net"
 	"net/http"
+	"reflect"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/foxytanuki/rcode/internal/audit"
+	"github.com/foxytanuki/rcode/internal/clipboard"
 	"github.com/foxytanuki/rcode/internal/config"
 	"github.com/foxytanuki/rcode/internal/editor"
+	"github.com/foxytanuki/rcode/internal/filesync"
 	"github.com/foxytanuki/rcode/internal/logger"
+	"github.com/foxytanuki/rcode/internal/session"
+	"github.com/foxytanuki/rcode/internal/telemetry"
 )
 
+// telemetryShutdownTimeout bounds the final telemetry export on Close
+const telemetryShutdownTimeout = 5 * time.Second
+
 // Server represents the HTTP server
 type Server struct {
-	config      *config.ServerConfigFile
 	log         *logger.Logger
-	editor      *editor.Manager
+	sessions    *session.Store
+	events      *eventHub
+	audit       *audit.Logger
+	telemetry   *telemetry.Provider // nil when telemetry is disabled
+	executor    editor.Executor     // tracks editor launches for shutdown
+	dedup       openDedup           // coalesces identical open requests
+	idempotency openDedup           // original responses by Idempotency-Key
+	signatures  seenSignatures      // signatures of recent signed requests
+	sync        *filesync.Manager   // local copies for sync editors
 	startTime   time.Time
+
+	// shutdownRequests receives a value when /admin/shutdown is called
+	shutdownRequests chan struct{}
+
+	// writeClipboard sets the host clipboard and openBrowser opens a URL on
+	// the host; replaced in tests
+	writeClipboard func([]byte) error
+	openBrowser    func(string, *logger.Logger) error
+
+	// configPath is where admin changes are persisted; empty keeps them in memory
+	configPath string
+	adminMu    sync.Mutex
+
+	// Reloadable state, swapped as a whole by Reload
+	mu          sync.RWMutex
+	config      *config.ServerConfigFile
+	editor      *editor.Manager
+	terminal    *editor.Manager // nil when no terminal is configured or installed
 	allowedIPs  []net.IP
 	allowedNets []*net.IPNet
+	limiter     *rateLimiter // nil when rate limiting is disabled
 }
 
 // NewServer creates a new server instance
@@ -28,29 +66,203 @@ func NewServer(cfg *config.ServerConfigFile, log *logger.Logger) (*Server, error
 		return nil, err
 	}
 
-	// Parse IP whitelist once at startup
+	allowedIPs, allowedNets := parseAllowedIPs(cfg.Server.AllowedIPs)
+
+	auditLog, err := audit.New(&cfg.Audit)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := session.NewStore(cfg.Server.SessionsFile, cfg.Server.MaxSessions)
+	if err != nil {
+		log.Warn("Failed to load sessions, starting with an empty history",
+			"file", cfg.Server.SessionsFile,
+			"error", err,
+		)
+		sessions, _ = session.NewStore("", cfg.Server.MaxSessions)
+	}
+
+	s := &Server{
+		config:   cfg,
+		log:      log,
+		editor:   mgr,
+		terminal: newTerminalManager(cfg, log),
+		sessions: sessions,
+		events:   newEventHub(),
+		sync: filesync.NewManager(filesync.Options{
+			Dir:          cfg.Server.Sync.Directory(),
+			PollInterval: cfg.Server.Sync.Interval(),
+			IdleTimeout:  cfg.Server.Sync.Idle(),
+		}, log),
+
+		shutdownRequests: make(chan struct{}, 1),
+		audit:            auditLog,
+		telemetry:        telemetry.New(cfg.Telemetry, "rcode-server", log),
+		startTime:        time.Now(),
+		allowedIPs:       allowedIPs,
+		allowedNets:      allowedNets,
+		limiter:          newRateLimiter(cfg.Server.RateLimit),
+
+		writeClipboard: clipboard.Write,
+		openBrowser:    editor.OpenBrowser,
+	}
+	s.executor.SetLimits(cfg.Server.Launch.WorkerCount(), cfg.Server.Launch.QueueSize())
+	return s, nil
+}
+
+// parseAllowedIPs splits the IP whitelist into single addresses and networks
+func parseAllowedIPs(allowed []string) ([]net.IP, []*net.IPNet) {
 	var allowedIPs []net.IP
 	var allowedNets []*net.IPNet
-	for _, allowed := range cfg.Server.AllowedIPs {
-		if strings.Contains(allowed, "/") {
-			if _, ipNet, err := net.ParseCIDR(allowed); err == nil {
+	for _, entry := range allowed {
+		if strings.Contains(entry, "/") {
+			if _, ipNet, err := net.ParseCIDR(entry); err == nil {
 				allowedNets = append(allowedNets, ipNet)
 			}
 		} else {
-			if ip := net.ParseIP(allowed); ip != nil {
+			if ip := net.ParseIP(entry); ip != nil {
 				allowedIPs = append(allowedIPs, ip)
 			}
 		}
 	}
+	return allowedIPs, allowedNets
+}
+
+// currentConfig returns the configuration in effect
+func (s *Server) currentConfig() *config.ServerConfigFile {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config
+}
+
+// editors returns the editor manager in effect
+func (s *Server) editors() *editor.Manager {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.editor
+}
+
+// ipWhitelist returns the parsed IP whitelist in effect
+func (s *Server) ipWhitelist() ([]net.IP, []*net.IPNet) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.allowedIPs, s.allowedNets
+}
+
+// rateLimiter returns the rate limiter in effect, or nil
+func (s *Server) rateLimiter() *rateLimiter {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.limiter
+}
+
+// Reload applies a new configuration to the running server. Editors, the IP
+// and path whitelists, the auth token, the rate limit and the log level take
+// effect immediately; requests already in flight finish with the state they
+// started with. Settings bound at startup (listen addresses, timeouts,
+// broker, TLS, sessions and audit files, log file and format, telemetry) are
+// kept and a warning is logged.
+func (s *Server) Reload(cfg *config.ServerConfigFile) error {
+	mgr, err := editor.NewManager(cfg.Editors, s.log)
+	if err != nil {
+		return err
+	}
+	terminals := newTerminalManager(cfg, s.log)
+	allowedIPs, allowedNets := parseAllowedIPs(cfg.Server.AllowedIPs)
+
+	s.mu.Lock()
+	old := s.config
+	s.config = cfg
+	s.editor = mgr
+	s.terminal = terminals
+	s.allowedIPs = allowedIPs
+	s.allowedNets = allowedNets
+	if old.Server.RateLimit != cfg.Server.RateLimit {
+		// Start with fresh buckets under the new limits
+		s.limiter = newRateLimiter(cfg.Server.RateLimit)
+	}
+	s.mu.Unlock()
+
+	s.executor.SetLimits(cfg.Server.Launch.WorkerCount(), cfg.Server.Launch.QueueSize())
+	s.log.SetLevel(cfg.Logging.Level)
+
+	for _, field := range restartOnlyChanges(old, cfg) {
+		s.log.Warn("Configuration change requires a restart", "field", field)
+	}
+	s.log.Info("Configuration reloaded",
+		"editors", mgr.Count(),
+		"default_editor", mgr.GetDefaultName(),
+	)
+	return nil
+}
+
+// restartOnlyChanges lists settings that differ between old and cfg but are
+// only read at startup
+func restartOnlyChanges(old, cfg *config.ServerConfigFile) []string {
+	var fields []string
+	check := func(field string, changed bool) {
+		if changed {
+			fields = append(fields, field)
+		}
+	}
+	check("server.host", old.Server.Host != cfg.Server.Host)
+	check("server.port", old.Server.Port != cfg.Server.Port)
+	check("server.listen", !slices.Equal(old.Server.Listen, cfg.Server.Listen))
+	check("server.listen_interfaces", !slices.Equal(old.Server.Interfaces, cfg.Server.Interfaces))
+	check("server.read_timeout", old.Server.ReadTimeout != cfg.Server.ReadTimeout)
+	check("server.write_timeout", old.Server.WriteTimeout != cfg.Server.WriteTimeout)
+	check("server.idle_timeout", old.Server.IdleTimeout != cfg.Server.IdleTimeout)
+	check("server.broker", old.Server.Broker != cfg.Server.Broker)
+	check("server.sessions_file", old.Server.SessionsFile != cfg.Server.SessionsFile)
+	check("server.sync", old.Server.Sync != cfg.Server.Sync)
+	check("server.tls", old.Server.TLS != cfg.Server.TLS)
+	check("audit", old.Audit != cfg.Audit)
+	check("telemetry", !reflect.DeepEqual(old.Telemetry, cfg.Telemetry))
+	check("logging.file", old.Logging.File != cfg.Logging.File)
+	check("logging.console", old.Logging.Console != cfg.Logging.Console)
+	check("logging.format", old.Logging.Format != cfg.Logging.Format)
+	return fields
+}
+
+// Close releases resources held by the server, pushing saves to synced
+// files and exporting any telemetry still buffered
+func (s *Server) Close() error {
+	s.sync.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), telemetryShutdownTimeout)
+	defer cancel()
+	if err := s.telemetry.Shutdown(ctx); err != nil {
+		s.log.Warn("Failed to export telemetry", "error", err)
+	}
+
+	if s.audit != nil {
+		return s.audit.Close()
+	}
+	return nil
+}
+
+// ShutdownRequested receives a value when a shutdown is requested through
+// POST /admin/shutdown
+func (s *Server) ShutdownRequested() <-chan struct{} {
+	return s.shutdownRequests
+}
 
-	return &Server{
-		config:      cfg,
-		log:         log,
-		editor:      mgr,
-		startTime:   time.Now(),
-		allowedIPs:  allowedIPs,
-		allowedNets: allowedNets,
-	}, nil
+// requestShutdown asks the main loop to shut the server down. Repeated
+// requests while one is pending are dropped.
+func (s *Server) requestShutdown() {
+	select {
+	case s.shutdownRequests <- struct{}{}:
+	default:
+	}
+}
+
+// Drain refuses new editor launches and waits for the running ones until
+// ctx ends
+func (s *Server) Drain(ctx context.Context) error {
+	if n := s.executor.InFlight(); n > 0 {
+		s.log.Info("Waiting for editor launches to finish", "in_flight", n)
+	}
+	return s.executor.Drain(ctx)
 }
 
 // Router returns the HTTP handler with all routes configured
@@ -62,8 +274,19 @@ func (s *Server) Router() http.Handler {
 
 	// Register routes
 	mux.HandleFunc("/health", s.handleHealth)
+	mux.HandleFunc("/healthz", s.handleHealth)
+	mux.HandleFunc("/readyz", s.handleReady)
 	mux.HandleFunc("/editors", s.handleEditors)
+	mux.HandleFunc("/editors/", s.handleEditorPreview)
 	mux.HandleFunc("/open-editor", s.handleOpenEditor)
+	mux.HandleFunc("/render", s.handleRender)
+	mux.HandleFunc("/sessions", s.handleSessions)
+	mux.HandleFunc("/events", s.handleEvents)
+	mux.HandleFunc("/clipboard", s.handleClipboard)
+	mux.HandleFunc("/open-url", s.handleOpenURL)
+	mux.HandleFunc("/admin/editors", s.handleAdminEditors)
+	mux.HandleFunc("/admin/editors/", s.handleAdminEditors)
+	mux.HandleFunc("/admin/shutdown", s.handleAdminShutdown)
 
 	return handler
 }
@@ -71,8 +294,14 @@ func (s *Server) Router() http.Handler {
 // withMiddleware applies middleware to the handler
 func (s *Server) withMiddleware(handler http.Handler) http.Handler {
 	// Apply middleware in reverse order (last one runs first)
+	handler = s.signatureMiddleware(handler)
+	handler = s.authMiddleware(handler)
+	handler = s.rateLimitMiddleware(handler)
 	handler = s.recoveryMiddleware(handler)
 	handler = s.loggingMiddleware(handler)
 	handler = s.ipWhitelistMiddleware(handler)
+	handler = s.tracingMiddleware(handler)
+	handler = s.protocolMiddleware(handler)
+	handler = s.requestIDMiddleware(handler)
 	return handler
 }
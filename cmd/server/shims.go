@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/foxytanuki/rcode/internal/editor"
+	"github.com/spf13/cobra"
+)
+
+var (
+	shimDir    string
+	shimDryRun bool
+)
+
+var installShimsCmd = &cobra.Command{
+	Use:   "install-shims",
+	Short: "Link the command-line tools of installed editors into PATH",
+	Long: `Find editors installed as macOS app bundles (Cursor, VS Code, Zed, Windsurf,
+Sublime Text) whose command-line tools are not on PATH, and symlink them into
+--dir, like the editors' own "Install shell command" action. This lets
+open-editor requests work on a fresh machine.
+
+Linking into /usr/local/bin may need sudo.`,
+	Args: cobra.NoArgs,
+	RunE: runInstallShims,
+}
+
+func runInstallShims(_ *cobra.Command, _ []string) error {
+	shims := editor.MissingShims(shimDir)
+	if len(shims) == 0 {
+		fmt.Println("No editor command-line tools to install")
+		return nil
+	}
+
+	var failed int
+	for _, shim := range shims {
+		if shimDryRun {
+			fmt.Printf("Would link %s -> %s\n", shim.Link, shim.Target)
+			continue
+		}
+		if err := shim.Install(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			failed++
+			continue
+		}
+		fmt.Printf("Linked %s -> %s\n", shim.Link, shim.Target)
+	}
+
+	if failed > 0 {
+		if os.Geteuid() != 0 {
+			fmt.Fprintf(os.Stderr, "Run with sudo or choose a writable directory with --dir\n")
+		}
+		return errors.New("failed to install some command-line tools")
+	}
+	return nil
+}
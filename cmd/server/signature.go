@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/auth"
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+// errReplayedSignature is returned for a signed request that was already
+// received
+var errReplayedSignature = errors.New("request signature was already used")
+
+// seenSignatures remembers the nonces of recent signed requests, so a
+// captured signed request cannot be sent again while its timestamp is
+// accepted
+type seenSignatures struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// add records nonce as seen at now and reports whether it is new. Nonces
+// seen more than keep ago are forgotten.
+func (c *seenSignatures) add(nonce string, keep time.Duration, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.seen == nil {
+		c.seen = make(map[string]time.Time)
+	}
+	for n, at := range c.seen {
+		if now.Sub(at) >= keep {
+			delete(c.seen, n)
+		}
+	}
+	if _, ok := c.seen[nonce]; ok {
+		return false
+	}
+	c.seen[nonce] = now
+	return true
+}
+
+// signatureMiddleware requires POST requests to be signed with one of
+// server.signing.keys when any is set. The body is read here to check the
+// signature and handed on to the handler unchanged.
+func (s *Server) signatureMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg := s.currentConfig().Server
+		if !cfg.Signing.Enabled() || r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		limit := max(cfg.MaxBodyBytes(), cfg.Clipboard.MaxBytes())
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				s.respondError(w, api.ErrTooLarge, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body is limited to %d KB", limit/1024))
+				return
+			}
+			s.respondError(w, api.ErrInvalidRequest, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		// A timestamp is accepted for Window either side of now, so its
+		// nonce is kept for twice that
+		window := cfg.Signing.Window()
+		nonce, err := auth.VerifySignature(r, body, cfg.Signing.Keys, window, time.Now())
+		if err == nil && !s.signatures.add(nonce, 2*window, time.Now()) {
+			err = errReplayedSignature
+		}
+		if err != nil {
+			s.requestLog(r).Warn("Rejected request signature",
+				"error", err,
+				"path", r.URL.Path,
+				"remote_addr", r.RemoteAddr,
+			)
+			s.respondError(w, api.ErrUnauthorized, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/internal/editor"
+	"github.com/foxytanuki/rcode/internal/logger"
+)
+
+// newTerminalManager builds the terminal launchers for cfg: its terminals,
+// or the known terminals installed on this machine when it lists none. It
+// returns nil when there are none.
+func newTerminalManager(cfg *config.ServerConfigFile, log *logger.Logger) *editor.Manager {
+	terminals := cfg.Terminals
+	if len(terminals) == 0 {
+		terminals = editor.DiscoverEditors(config.KnownTerminals())
+	}
+	if len(terminals) == 0 {
+		return nil
+	}
+
+	mgr, err := editor.NewManager(terminals, log)
+	if err != nil {
+		log.Warn("No usable terminal configured", "error", err)
+		return nil
+	}
+	return mgr
+}
+
+// terminals returns the terminal launchers in effect, or nil
+func (s *Server) terminals() *editor.Manager {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.terminal
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/logger"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// tunnelRetryMin is the delay before re-establishing a dropped tunnel
+	tunnelRetryMin = time.Second
+	// tunnelRetryMax caps the reconnect backoff
+	tunnelRetryMax = 30 * time.Second
+)
+
+var tunnelCmd = &cobra.Command{
+	Use:   "tunnel [user@]remote",
+	Short: "Expose rcode-server to a remote machine over an SSH reverse tunnel",
+	Long: `Keep an SSH reverse tunnel open so rcode on the remote machine can reach
+this server through localhost, without opening any port on this host.
+
+The tunnel runs "ssh -R" to the remote machine and is re-established when it
+drops. On the remote side it listens on 127.0.0.1 only; point the client at it
+with hosts.server.tunnel: "localhost:3339". Combine with --loopback-only to
+stop the server from listening on the network at all.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTunnel,
+}
+
+// tunnelArgs builds the ssh arguments for a reverse tunnel that forwards
+// 127.0.0.1:remotePort on dest to 127.0.0.1:localPort here
+func tunnelArgs(dest string, remotePort, localPort int) []string {
+	return []string{
+		"-N",
+		"-o", "ExitOnForwardFailure=yes",
+		"-o", "ServerAliveInterval=30",
+		"-o", "ServerAliveCountMax=3",
+		"-R", fmt.Sprintf("127.0.0.1:%d:127.0.0.1:%d", remotePort, localPort),
+		dest,
+	}
+}
+
+// loopbackAllowed reports whether the IP whitelist admits tunneled
+// connections, which arrive from 127.0.0.1
+func loopbackAllowed(allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+
+	loopback := net.ParseIP("127.0.0.1")
+	allowedIPs, allowedNets := parseAllowedIPs(allowed)
+	for _, ip := range allowedIPs {
+		if ip.Equal(loopback) {
+			return true
+		}
+	}
+	for _, ipNet := range allowedNets {
+		if ipNet.Contains(loopback) {
+			return true
+		}
+	}
+	return false
+}
+
+func runTunnel(_ *cobra.Command, args []string) error {
+	cfg, err := loadServerConfig()
+	if err != nil {
+		return err
+	}
+
+	log := logger.New(&logger.Config{
+		Level:   cfg.Logging.Level,
+		Console: true,
+		Format:  cfg.Logging.Format,
+	})
+	defer func() {
+		if err := log.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to close logger: %v\n", err)
+		}
+	}()
+
+	if !loopbackAllowed(cfg.Server.AllowedIPs) {
+		log.Warn("server.allowed_ips does not include 127.0.0.1; tunneled requests will be rejected")
+	}
+
+	remotePort := tunnelRemotePort
+	if remotePort == 0 {
+		remotePort = cfg.Server.Port
+	}
+	if remotePort <= 0 || remotePort > 65535 {
+		return fmt.Errorf("invalid remote port: %d", remotePort)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return runTunnelLoop(ctx, tunnelSSH, tunnelArgs(args[0], remotePort, cfg.Server.Port), log)
+}
+
+// runTunnelLoop runs ssh until ctx is done, restarting it with exponential
+// backoff whenever it exits
+func runTunnelLoop(ctx context.Context, sshBinary string, sshArgs []string, log *logger.Logger) error {
+	delay := tunnelRetryMin
+	for {
+		log.Info("Opening SSH reverse tunnel", "command", sshBinary, "args", sshArgs)
+
+		started := time.Now()
+		cmd := exec.CommandContext(ctx, sshBinary, sshArgs...) // #nosec G204 -- the ssh binary and destination come from the operator's command line
+		cmd.Stdin = nil
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		err := cmd.Run()
+
+		if ctx.Err() != nil {
+			log.Info("Tunnel stopped")
+			return nil
+		}
+		if execErr, ok := err.(*exec.Error); ok {
+			return fmt.Errorf("failed to run %s: %w", sshBinary, execErr)
+		}
+
+		// A tunnel that stayed up for a while resets the backoff
+		if time.Since(started) > tunnelRetryMax {
+			delay = tunnelRetryMin
+		}
+		log.Warn("SSH tunnel exited, reconnecting", "error", err, "retry_in", delay)
+
+		select {
+		case <-ctx.Done():
+			log.Info("Tunnel stopped")
+			return nil
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > tunnelRetryMax {
+			delay = tunnelRetryMax
+		}
+	}
+}
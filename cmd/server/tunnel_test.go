@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/logger"
+)
+
+func TestTunnelArgs(t *testing.T) {
+	got := tunnelArgs("alice@devbox", 4000, 3339)
+	want := []string{
+		"-N",
+		"-o", "ExitOnForwardFailure=yes",
+		"-o", "ServerAliveInterval=30",
+		"-o", "ServerAliveCountMax=3",
+		"-R", "127.0.0.1:4000:127.0.0.1:3339",
+		"alice@devbox",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tunnelArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestLoopbackAllowed(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		want    bool
+	}{
+		{"no whitelist", nil, true},
+		{"loopback ip", []string{"192.168.1.10", "127.0.0.1"}, true},
+		{"loopback network", []string{"127.0.0.0/8"}, true},
+		{"lan only", []string{"192.168.1.0/24"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := loopbackAllowed(tt.allowed); got != tt.want {
+				t.Errorf("loopbackAllowed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunTunnelLoop(t *testing.T) {
+	log := logger.New(&logger.Config{Level: "error"})
+
+	t.Run("missing ssh binary", func(t *testing.T) {
+		err := runTunnelLoop(context.Background(), "rcode-test-no-such-ssh", nil, log)
+		if err == nil {
+			t.Fatal("runTunnelLoop() error = nil, want error")
+		}
+	})
+
+	t.Run("stops on cancel", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		if err := runTunnelLoop(ctx, "sleep", []string{"10"}, log); err != nil {
+			t.Errorf("runTunnelLoop() error = %v, want nil", err)
+		}
+	})
+}
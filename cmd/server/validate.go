@@ -0,0 +1,220 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/internal/editor"
+	"github.com/foxytanuki/rcode/internal/logger"
+	"github.com/spf13/cobra"
+)
+
+// validateNoProbe skips looking for the editors on this machine
+var validateNoProbe bool
+
+var validateConfigCmd = &cobra.Command{
+	Use:   "validate-config [file]",
+	Short: "Check a config file and report every problem",
+	Long: `Load a server config file (default: --config, or the default location) and
+check it without starting the server: unknown keys, the settings the server
+validates at startup, every editor and terminal template, and whether the
+editors are installed on this machine. Each check is reported as OK, WARN
+or FAIL, and the command exits with status 1 when any check fails, so it can
+run in CI for a dotfiles repository or before deploying a config.
+
+Unknown keys are warnings unless --strict-config is given. Editors that are
+not installed are warnings, except the default editor; use --no-probe to
+skip looking for them, e.g. on a CI machine without editors.`,
+	Example: `  rcode-server validate-config
+  rcode-server validate-config --strict-config --no-probe dotfiles/rcode/server-config.yaml`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runValidateConfig,
+}
+
+// checkStatus is the outcome of a single check
+type checkStatus string
+
+const (
+	checkOK   checkStatus = "OK"
+	checkWarn checkStatus = "WARN"
+	checkFail checkStatus = "FAIL"
+)
+
+// configCheck is a single line in the report
+type configCheck struct {
+	Name    string
+	Status  checkStatus
+	Message string
+}
+
+// configReport collects the results of the checks of one config file
+type configReport struct {
+	Path   string
+	Checks []configCheck
+}
+
+func (r *configReport) add(name string, status checkStatus, format string, args ...any) {
+	r.Checks = append(r.Checks, configCheck{
+		Name:    name,
+		Status:  status,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// failed returns true if any check failed
+func (r *configReport) failed() bool {
+	for _, check := range r.Checks {
+		if check.Status == checkFail {
+			return true
+		}
+	}
+	return false
+}
+
+// print writes the report and a summary
+func (r *configReport) print(w io.Writer) {
+	fmt.Fprintf(w, "Validating %s\n", r.Path)
+	counts := make(map[checkStatus]int)
+	for _, check := range r.Checks {
+		fmt.Fprintf(w, "[%-4s] %s: %s\n", check.Status, check.Name, check.Message)
+		counts[check.Status]++
+	}
+	result := "PASS"
+	if r.failed() {
+		result = "FAIL"
+	}
+	fmt.Fprintf(w, "\n%s: %d ok, %d warnings, %d failed\n", result, counts[checkOK], counts[checkWarn], counts[checkFail])
+}
+
+func runValidateConfig(_ *cobra.Command, args []string) error {
+	path := config.ServerConfigPath(configFile)
+	if len(args) == 1 {
+		path = args[0]
+	}
+
+	report := validateConfigFile(path, !validateNoProbe)
+	report.print(os.Stdout)
+	if report.failed() {
+		return errors.New("config file is invalid")
+	}
+	return nil
+}
+
+// validateConfigFile runs every check on the server config file at path;
+// probe looks for the editors on this machine
+func validateConfigFile(path string, probe bool) *configReport {
+	report := &configReport{Path: path}
+
+	if _, err := os.Stat(path); err != nil {
+		report.add("file", checkFail, "%v", err)
+		return report
+	}
+	cfg, unknown, err := config.InspectServerConfig(path)
+	if err != nil {
+		report.add("parse", checkFail, "%v", err)
+		return report
+	}
+	report.add("parse", checkOK, "loaded %d editors", len(cfg.Editors))
+	if len(cfg.Extends) > 0 {
+		report.add("extends", checkOK, "merged %d base files", len(cfg.Extends))
+	}
+
+	// Unknown keys
+	keyStatus := checkWarn
+	if config.StrictKeys {
+		keyStatus = checkFail
+	}
+	for _, k := range unknown {
+		report.add("keys", keyStatus, "%s", k)
+	}
+	if len(unknown) == 0 {
+		report.add("keys", checkOK, "no unknown keys")
+	}
+
+	// Settings checked at startup
+	var validationErrs config.ValidationErrors
+	err = config.ValidateServerConfig(cfg)
+	switch {
+	case errors.As(err, &validationErrs):
+		for _, e := range validationErrs {
+			report.add("settings", checkFail, "%s", e.Error())
+		}
+	case err != nil:
+		report.add("settings", checkFail, "%v", err)
+	default:
+		report.add("settings", checkOK, "valid")
+	}
+
+	// Templates
+	for _, e := range cfg.Editors {
+		checkTemplate(report, "editor "+e.Name, e)
+	}
+	for _, e := range cfg.Terminals {
+		checkTemplate(report, "terminal "+e.Name, e)
+	}
+
+	if probe {
+		probeInterfaces(report, cfg.Server.Interfaces)
+		probeEditors(report, cfg.Editors)
+	}
+	return report
+}
+
+// probeInterfaces reports whether the listen interfaces exist on this
+// machine and have addresses
+func probeInterfaces(report *configReport, names []string) {
+	for _, name := range names {
+		ips, err := interfaceIPs(name)
+		if err != nil {
+			report.add("listen", checkWarn, "%v", err)
+			continue
+		}
+		report.add("listen", checkOK, "interface %s has %d addresses", name, len(ips))
+	}
+}
+
+// checkTemplate reports whether the templates of an editor parse
+func checkTemplate(report *configReport, name string, cfg config.EditorConfig) {
+	e, err := editor.NewEditor(cfg)
+	if err != nil {
+		report.add(name, checkFail, "%v", err)
+		return
+	}
+	template := e.Template
+	if template == nil {
+		template = e.URLTemplate
+	}
+	report.add(name, checkOK, "template parses: %s", template.RenderWithDefaults(editor.TemplateVars{}))
+}
+
+// probeEditors reports which editors are installed on this machine; a
+// missing default editor fails
+func probeEditors(report *configReport, editors []config.EditorConfig) {
+	log := logger.New(&logger.Config{Level: "error"})
+	defer func() { _ = log.Close() }()
+
+	m, err := editor.NewManager(editors, log)
+	if err != nil {
+		report.add("availability", checkFail, "%v", err)
+		return
+	}
+	var defaultName string
+	if def, err := m.GetDefaultEditor(); err == nil {
+		defaultName = def.Name
+	}
+
+	for _, e := range m.ListEditors() {
+		name := "available " + e.Name
+		switch {
+		case m.IsAvailable(e.Name):
+			report.add(name, checkOK, "found (%s)", m.AvailableVia(e.Name))
+		case e.Name == defaultName:
+			report.add(name, checkFail, "the default editor is not installed on this machine")
+		default:
+			report.add(name, checkWarn, "not installed on this machine")
+		}
+	}
+}
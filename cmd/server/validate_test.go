@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	valid := write("valid.yaml", `server:
+  host: 127.0.0.1
+  port: 3339
+editors:
+  - name: shell
+    command: "true {path}"
+    default: true
+  - name: missing
+    command: "rcode-test-missing-editor {path}"
+`)
+	invalid := write("invalid.yaml", `server:
+  host: 127.0.0.1
+  prot: 3339
+  port: 70000
+editors:
+  - name: broken
+    command: "code {path"
+  - name: absent
+    command: "rcode-test-missing-editor {path}"
+    default: true
+`)
+
+	tests := []struct {
+		name       string
+		path       string
+		probe      bool
+		wantFailed bool
+		want       []string
+	}{
+		{
+			name:  "valid",
+			path:  valid,
+			probe: true,
+			want: []string{
+				"[OK  ] keys: no unknown keys",
+				"[OK  ] settings: valid",
+				"[OK  ] available shell: found (path)",
+				"[WARN] available missing: not installed",
+				"PASS: ",
+			},
+		},
+		{
+			name:       "invalid",
+			path:       invalid,
+			probe:      true,
+			wantFailed: true,
+			want: []string{
+				`[WARN] keys: ` + invalid + `: line 3: unknown key "server.prot" (did you mean "port"?)`,
+				"[FAIL] settings: ",
+				"[FAIL] editor broken: ",
+				"[FAIL] available absent: the default editor is not installed",
+				"FAIL: ",
+			},
+		},
+		{
+			name:       "missing file",
+			path:       filepath.Join(dir, "missing.yaml"),
+			wantFailed: true,
+			want:       []string{"[FAIL] file: "},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := validateConfigFile(tt.path, tt.probe)
+			var out bytes.Buffer
+			report.print(&out)
+
+			if report.failed() != tt.wantFailed {
+				t.Errorf("failed() = %v, want %v\n%s", report.failed(), tt.wantFailed, out.String())
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("report missing %q\n%s", want, out.String())
+				}
+			}
+		})
+	}
+
+	// Without probes the missing default editor does not fail
+	if report := validateConfigFile(valid, false); report.failed() {
+		t.Errorf("validateConfigFile() without probes failed")
+	}
+}
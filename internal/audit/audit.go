@@ -0,0 +1,102 @@
+// Package audit writes an append-only record of every open-editor request
+// handled by rcode-server. The audit log is kept separate from the
+// application log so it can be retained and reviewed independently.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/internal/logger"
+)
+
+// Record statuses
+const (
+	StatusExecuted  = "executed"  // Command was started successfully
+	StatusFailed    = "failed"    // Command could not be started
+	StatusRejected  = "rejected"  // Request was refused before execution
+	StatusReplayed  = "replayed"  // Answered with the earlier response to the same Idempotency-Key
+	StatusCoalesced = "coalesced" // Joined an identical launch within the dedup window
+)
+
+// Record is a single audit log entry, written as one JSON line. ExitCode is
+// set for commands watched until they exited (wait_for_exit or a watch
+// window).
+type Record struct {
+	Time       time.Time `json:"time"`
+	ClientIP   string    `json:"client_ip"`
+	RequestID  string    `json:"request_id,omitempty"`
+	User       string    `json:"user,omitempty"`
+	Host       string    `json:"host,omitempty"`
+	Editor     string    `json:"editor,omitempty"`
+	Paths      []string  `json:"paths,omitempty"`
+	Command    string    `json:"command,omitempty"`
+	Status     string    `json:"status"`
+	HTTPStatus int       `json:"http_status"`
+	Outcome    string    `json:"outcome,omitempty"`
+	ExitCode   *int      `json:"exit_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Logger appends audit records to a writer.
+type Logger struct {
+	mu sync.Mutex
+	w  io.WriteCloser
+}
+
+// New opens the audit log described by cfg. It returns nil when auditing is
+// disabled (cfg.File is empty).
+func New(cfg *config.AuditConfig) (*Logger, error) {
+	if cfg == nil || cfg.File == "" {
+		return nil, nil
+	}
+
+	fw, err := logger.NewFileWriter(cfg.File, &logger.FileWriterConfig{
+		MaxSize:    cfg.MaxSize,
+		MaxBackups: cfg.MaxBackups,
+		MaxAge:     cfg.MaxAge,
+		Compress:   cfg.Compress,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	return NewWithWriter(fw), nil
+}
+
+// NewWithWriter creates an audit logger that writes to w.
+func NewWithWriter(w io.WriteCloser) *Logger {
+	return &Logger{w: w}
+}
+
+// Log appends rec to the audit log, filling in the time if unset.
+func (l *Logger) Log(rec Record) error {
+	if rec.Time.IsZero() {
+		rec.Time = time.Now().UTC()
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit record: %w", err)
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := l.w.Write(data); err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying writer.
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Close()
+}
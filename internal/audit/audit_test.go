@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/foxytanuki/rcode/internal/config"
+)
+
+func TestNew_Disabled(t *testing.T) {
+	l, err := New(&config.AuditConfig{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if l != nil {
+		t.Error("New() with empty file = non-nil, want nil")
+	}
+}
+
+func TestLogger_Log(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "audit.log")
+
+	l, err := New(&config.AuditConfig{File: path})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	records := []Record{
+		{ClientIP: "192.168.1.50", User: "alice", Editor: "vscode", Paths: []string{"/a"}, Command: "code /a", Status: StatusExecuted, HTTPStatus: 200},
+		{ClientIP: "192.168.1.51", User: "bob", Paths: []string{"/etc"}, Status: StatusRejected, HTTPStatus: 403, Error: "path not allowed"},
+	}
+	for _, rec := range records {
+		if err := l.Log(rec); err != nil {
+			t.Fatalf("Log() error = %v", err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var got []Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("Unmarshal() error = %v", err)
+		}
+		got = append(got, rec)
+	}
+
+	if len(got) != len(records) {
+		t.Fatalf("audit log has %d lines, want %d", len(got), len(records))
+	}
+	for i, rec := range got {
+		if rec.Time.IsZero() {
+			t.Errorf("record %d has no time", i)
+		}
+		if rec.Status != records[i].Status || rec.ClientIP != records[i].ClientIP || rec.Command != records[i].Command {
+			t.Errorf("record %d = %+v, want %+v", i, rec, records[i])
+		}
+	}
+}
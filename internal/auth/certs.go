@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Names of the files GenerateCertificates writes
+const (
+	CAFile         = "ca.pem"
+	CAKeyFile      = "ca-key.pem"
+	ServerCertFile = "server.pem"
+	ServerKeyFile  = "server-key.pem"
+	ClientCertFile = "client.pem"
+	ClientKeyFile  = "client-key.pem"
+)
+
+// DefaultCertValidity is how long generated certificates are valid
+const DefaultCertValidity = 2 * 365 * 24 * time.Hour
+
+// ErrCertsExist is returned when GenerateCertificates would overwrite files
+var ErrCertsExist = errors.New("certificate files already exist")
+
+// CertOptions describes the certificates to generate
+type CertOptions struct {
+	Hosts    []string      // Names and IP addresses the server certificate is valid for
+	Validity time.Duration // How long the certificates are valid (default: DefaultCertValidity)
+	Force    bool          // Overwrite existing files
+}
+
+// GenerateCertificates writes a small CA, a server certificate for hosts
+// and a client certificate, both signed by the CA, with their keys into
+// dir, returning the paths written. It is meant for bootstrapping mutual TLS
+// between one server and its clients, not as a general-purpose CA.
+func GenerateCertificates(dir string, opts CertOptions) ([]string, error) {
+	if opts.Validity <= 0 {
+		opts.Validity = DefaultCertValidity
+	}
+	files := []string{CAFile, CAKeyFile, ServerCertFile, ServerKeyFile, ClientCertFile, ClientKeyFile}
+	paths := make([]string, len(files))
+	for i, name := range files {
+		paths[i] = filepath.Join(dir, name)
+		if _, err := os.Stat(paths[i]); err == nil && !opts.Force {
+			return nil, fmt.Errorf("%w: %s (use --force to replace them)", ErrCertsExist, paths[i])
+		}
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	notBefore := time.Now().Add(-time.Hour)
+	notAfter := notBefore.Add(opts.Validity)
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	caTemplate := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "rcode CA"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	caDER, caCert, err := signCertificate(caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+
+	serverTemplate := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "rcode-server"},
+		NotBefore:   notBefore,
+		NotAfter:    notAfter,
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, host := range opts.Hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			serverTemplate.IPAddresses = append(serverTemplate.IPAddresses, ip)
+		} else {
+			serverTemplate.DNSNames = append(serverTemplate.DNSNames, host)
+		}
+	}
+	clientTemplate := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "rcode"},
+		NotBefore:   notBefore,
+		NotAfter:    notAfter,
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	caKeyDER, err := x509.MarshalECPrivateKey(caKey)
+	if err != nil {
+		return nil, err
+	}
+	pems := map[string][]byte{
+		CAFile:    pemBlock("CERTIFICATE", caDER),
+		CAKeyFile: pemBlock("EC PRIVATE KEY", caKeyDER),
+	}
+	for _, leaf := range []struct {
+		template          *x509.Certificate
+		certFile, keyFile string
+	}{
+		{serverTemplate, ServerCertFile, ServerKeyFile},
+		{clientTemplate, ClientCertFile, ClientKeyFile},
+	} {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		der, _, err := signCertificate(leaf.template, caCert, &key.PublicKey, caKey)
+		if err != nil {
+			return nil, err
+		}
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		pems[leaf.certFile] = pemBlock("CERTIFICATE", der)
+		pems[leaf.keyFile] = pemBlock("EC PRIVATE KEY", keyDER)
+	}
+
+	for i, name := range files {
+		mode := os.FileMode(0o644)
+		if name == CAKeyFile || name == ServerKeyFile || name == ClientKeyFile {
+			mode = 0o600
+		}
+		if err := os.WriteFile(paths[i], pems[name], mode); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", paths[i], err)
+		}
+	}
+	return paths, nil
+}
+
+// signCertificate creates the certificate for template, signed by parent's
+// key, with a random serial number
+func signCertificate(template, parent *x509.Certificate, pub *ecdsa.PublicKey, signer *ecdsa.PrivateKey) ([]byte, *x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	template.SerialNumber = serial
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, signer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	return der, cert, nil
+}
+
+// pemBlock encodes der as a PEM block of type kind
+func pemBlock(kind string, der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der})
+}
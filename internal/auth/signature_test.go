@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+func TestVerifySignature(t *testing.T) {
+	const (
+		oldKey = "0123456789abcdef0123456789abcdef"
+		newKey = "fedcba9876543210fedcba9876543210"
+	)
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"path":"/home/alice/project"}`)
+
+	signed := func(key, path string, at time.Time) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, path, http.NoBody)
+		SignRequest(req, body, key, at)
+		return req
+	}
+
+	tests := []struct {
+		name    string
+		req     *http.Request
+		body    []byte
+		keys    []string
+		wantErr error
+	}{
+		{"valid", signed(oldKey, "/open-editor", now), body, []string{oldKey}, nil},
+		{"rotated key", signed(newKey, "/open-editor", now), body, []string{oldKey, newKey}, nil},
+		{"clock skew within window", signed(oldKey, "/open-editor", now.Add(4*time.Minute)), body, []string{oldKey}, nil},
+		{"unknown key", signed(newKey, "/open-editor", now), body, []string{oldKey}, ErrInvalidSignature},
+		{"changed body", signed(oldKey, "/open-editor", now), []byte(`{"path":"/etc"}`), []string{oldKey}, ErrInvalidSignature},
+		{"stale", signed(oldKey, "/open-editor", now.Add(-6*time.Minute)), body, []string{oldKey}, ErrStaleSignature},
+		{"from the future", signed(oldKey, "/open-editor", now.Add(6*time.Minute)), body, []string{oldKey}, ErrStaleSignature},
+		{"unsigned", httptest.NewRequest(http.MethodPost, "/open-editor", http.NoBody), body, []string{oldKey}, ErrMissingSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := VerifySignature(tt.req, tt.body, tt.keys, 5*time.Minute, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifySignature() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// The nonce is signed too
+	req := signed(oldKey, "/open-editor", now)
+	req.Header.Set(api.HeaderSignatureNonce, "another-nonce")
+	if _, err := VerifySignature(req, body, []string{oldKey}, 5*time.Minute, now); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifySignature() with a changed nonce error = %v, want %v", err, ErrInvalidSignature)
+	}
+	req.Header.Del(api.HeaderSignatureNonce)
+	if _, err := VerifySignature(req, body, []string{oldKey}, 5*time.Minute, now); !errors.Is(err, ErrMissingSignature) {
+		t.Errorf("VerifySignature() without a nonce error = %v, want %v", err, ErrMissingSignature)
+	}
+
+	// A signature made for one path does not verify on another
+	req = signed(oldKey, "/render", now)
+	req.URL.Path = "/open-editor"
+	if _, err := VerifySignature(req, body, []string{oldKey}, 5*time.Minute, now); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifySignature() on another path error = %v, want %v", err, ErrInvalidSignature)
+	}
+}
+
+func TestSignRequest(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/open-editor", http.NoBody)
+	SignRequest(req, nil, "", time.Now())
+	if req.Header.Get(api.HeaderSignature) != "" {
+		t.Error("SignRequest() with no key set a signature")
+	}
+
+	SignRequest(req, nil, "0123456789abcdef0123456789abcdef", time.Unix(1700000000, 0))
+	if got := req.Header.Get(api.HeaderSignatureTimestamp); got != "1700000000" {
+		t.Errorf("timestamp = %q, want 1700000000", got)
+	}
+	if got := req.Header.Get(api.HeaderSignature); !strings.HasPrefix(got, "v2=") || len(got) != 3+64 {
+		t.Errorf("signature = %q, want v2= and 64 hex digits", got)
+	}
+
+	// Signing again at the same time picks a new nonce and signature
+	again := httptest.NewRequest(http.MethodPost, "/open-editor", http.NoBody)
+	SignRequest(again, nil, "0123456789abcdef0123456789abcdef", time.Unix(1700000000, 0))
+	if again.Header.Get(api.HeaderSignatureNonce) == "" || again.Header.Get(api.HeaderSignatureNonce) == req.Header.Get(api.HeaderSignatureNonce) {
+		t.Errorf("nonces = %q and %q, want two different nonces", req.Header.Get(api.HeaderSignatureNonce), again.Header.Get(api.HeaderSignatureNonce))
+	}
+	if again.Header.Get(api.HeaderSignature) == req.Header.Get(api.HeaderSignature) {
+		t.Error("signing the same request twice gave the same signature")
+	}
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	paths, err := GenerateCertificates(dir, CertOptions{Hosts: []string{"127.0.0.1", "devbox"}})
+	if err != nil {
+		t.Fatalf("GenerateCertificates() error = %v", err)
+	}
+	if len(paths) != 6 {
+		t.Errorf("GenerateCertificates() wrote %d files, want 6", len(paths))
+	}
+	info, err := os.Stat(filepath.Join(dir, CAKeyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("CA key mode = %v, want 0600", info.Mode().Perm())
+	}
+	if _, err := GenerateCertificates(dir, CertOptions{}); !errors.Is(err, ErrCertsExist) {
+		t.Errorf("GenerateCertificates() over existing files error = %v, want ErrCertsExist", err)
+	}
+
+	file := func(name string) string { return filepath.Join(dir, name) }
+	serverTLS, err := ServerTLSConfig(file(ServerCertFile), file(ServerKeyFile), file(CAFile))
+	if err != nil {
+		t.Fatalf("ServerTLSConfig() error = %v", err)
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	server.TLS = serverTLS
+	server.StartTLS()
+	defer server.Close()
+
+	get := func(caFile, certFile, keyFile string) error {
+		t.Helper()
+		clientTLS, err := ClientTLSConfig(caFile, certFile, keyFile, "")
+		if err != nil {
+			t.Fatalf("ClientTLSConfig() error = %v", err)
+		}
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientTLS}}
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}
+
+	if err := get(file(CAFile), file(ClientCertFile), file(ClientKeyFile)); err != nil {
+		t.Errorf("request with the client certificate failed: %v", err)
+	}
+	if err := get(file(CAFile), "", ""); err == nil {
+		t.Error("request without a client certificate succeeded")
+	}
+	// The server certificate cannot be used as a client certificate
+	if err := get(file(CAFile), file(ServerCertFile), file(ServerKeyFile)); err == nil {
+		t.Error("request with the server certificate as client certificate succeeded")
+	}
+}
+
+func TestClientTLSConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ClientTLSConfig(notPEM, "", "", ""); err == nil {
+		t.Error("ClientTLSConfig() with a CA file without certificates succeeded")
+	}
+	if _, err := ClientTLSConfig("", filepath.Join(dir, "missing.pem"), filepath.Join(dir, "missing-key.pem"), ""); err == nil {
+		t.Error("ClientTLSConfig() with a missing client certificate succeeded")
+	}
+}
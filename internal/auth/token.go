@@ -0,0 +1,67 @@
+// Package auth provides authentication helpers for rcode: shared-secret
+// bearer tokens, HMAC request signatures and the TLS certificates used for
+// mutual TLS.
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// TokenBytes is the number of random bytes used for generated tokens.
+const TokenBytes = 32
+
+// MinTokenLength is the minimum accepted length for a configured token.
+const MinTokenLength = 16
+
+var (
+	// ErrMissingToken is returned when a request carries no bearer token.
+	ErrMissingToken = errors.New("missing bearer token")
+	// ErrInvalidToken is returned when a bearer token does not match.
+	ErrInvalidToken = errors.New("invalid bearer token")
+)
+
+// GenerateToken returns a new random hex-encoded token.
+func GenerateToken() (string, error) {
+	buf := make([]byte, TokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// SetBearerToken sets the Authorization header on req if token is not empty.
+func SetBearerToken(req *http.Request, token string) {
+	if token == "" {
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+}
+
+// BearerToken extracts the bearer token from the Authorization header.
+func BearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// VerifyRequest checks the bearer token on r against the expected token
+// using a constant-time comparison.
+func VerifyRequest(r *http.Request, expected string) error {
+	token := BearerToken(r)
+	if token == "" {
+		return ErrMissingToken
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+		return ErrInvalidToken
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGenerateToken(t *testing.T) {
+	token, err := GenerateToken()
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	if len(token) != TokenBytes*2 {
+		t.Errorf("GenerateToken() length = %d, want %d", len(token), TokenBytes*2)
+	}
+
+	other, err := GenerateToken()
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	if token == other {
+		t.Error("GenerateToken() returned the same token twice")
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{"bearer", "Bearer abc123", "abc123"},
+		{"lowercase scheme", "bearer abc123", "abc123"},
+		{"basic scheme", "Basic abc123", ""},
+		{"no scheme", "abc123", ""},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			if got := BearerToken(req); got != tt.want {
+				t.Errorf("BearerToken() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyRequest(t *testing.T) {
+	const expected = "0123456789abcdef0123456789abcdef"
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"valid token", expected, nil},
+		{"wrong token", "fedcba9876543210fedcba9876543210", ErrInvalidToken},
+		{"missing token", "", ErrMissingToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/open-editor", http.NoBody)
+			SetBearerToken(req, tt.token)
+
+			err := VerifyRequest(req, expected)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyRequest() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
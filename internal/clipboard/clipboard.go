@@ -0,0 +1,70 @@
+// Package clipboard writes text to the host clipboard using the platform's
+// command-line tools.
+package clipboard
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// writeTimeout bounds how long the clipboard tool may take
+const writeTimeout = 5 * time.Second
+
+// ErrUnsupported is returned when no clipboard tool is available
+var ErrUnsupported = errors.New("no clipboard tool available")
+
+// copyArgv returns the command that reads the clipboard contents from stdin.
+// getenv and lookPath are os.Getenv and exec.LookPath outside of tests.
+func copyArgv(goos string, getenv func(string) string, lookPath func(string) (string, error)) (string, []string, error) {
+	switch goos {
+	case "darwin":
+		return "pbcopy", nil, nil
+	case "windows":
+		return "clip", nil, nil
+	}
+
+	installed := func(name string) bool {
+		_, err := lookPath(name)
+		return err == nil
+	}
+	if getenv("WAYLAND_DISPLAY") != "" && installed("wl-copy") {
+		return "wl-copy", nil, nil
+	}
+	if installed("xclip") {
+		return "xclip", []string{"-selection", "clipboard"}, nil
+	}
+	if installed("xsel") {
+		return "xsel", []string{"--clipboard", "--input"}, nil
+	}
+	return "", nil, fmt.Errorf("%w: install wl-copy, xclip or xsel", ErrUnsupported)
+}
+
+// Write replaces the clipboard contents with data
+func Write(data []byte) error {
+	executable, args, err := copyArgv(runtime.GOOS, os.Getenv, exec.LookPath)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, executable, args...) // #nosec G204 -- fixed clipboard tools
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s failed: %w: %s", executable, err, msg)
+		}
+		return fmt.Errorf("%s failed: %w", executable, err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package clipboard
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCopyArgv(t *testing.T) {
+	installed := func(names ...string) func(string) (string, error) {
+		return func(name string) (string, error) {
+			for _, n := range names {
+				if n == name {
+					return "/usr/bin/" + name, nil
+				}
+			}
+			return "", errors.New("not found")
+		}
+	}
+	env := func(vars map[string]string) func(string) string {
+		return func(key string) string { return vars[key] }
+	}
+
+	tests := []struct {
+		name     string
+		goos     string
+		getenv   func(string) string
+		lookPath func(string) (string, error)
+		wantExec string
+		wantArgs []string
+		wantErr  bool
+	}{
+		{"macOS", "darwin", env(nil), installed(), "pbcopy", nil, false},
+		{"windows", "windows", env(nil), installed(), "clip", nil, false},
+		{"wayland", "linux", env(map[string]string{"WAYLAND_DISPLAY": "wayland-0"}), installed("wl-copy", "xclip"), "wl-copy", nil, false},
+		{"wayland without wl-copy", "linux", env(map[string]string{"WAYLAND_DISPLAY": "wayland-0"}), installed("xclip"), "xclip", []string{"-selection", "clipboard"}, false},
+		{"x11 ignores wl-copy", "linux", env(nil), installed("wl-copy", "xsel"), "xsel", []string{"--clipboard", "--input"}, false},
+		{"no tools", "linux", env(nil), installed(), "", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executable, args, err := copyArgv(tt.goos, tt.getenv, tt.lookPath)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnsupported) {
+					t.Errorf("copyArgv() error = %v, want ErrUnsupported", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("copyArgv() error = %v", err)
+			}
+			if executable != tt.wantExec || !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("copyArgv() = %s %q, want %s %q", executable, args, tt.wantExec, tt.wantArgs)
+			}
+		})
+	}
+}
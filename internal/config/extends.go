@@ -0,0 +1,217 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/foxytanuki/rcode/internal/secret"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrExtends is returned when saving a loaded config would write the
+// settings of the files it extends into it
+var ErrExtends = errors.New("config file extends other files; edit it by hand so their settings are not copied into it")
+
+// extendsKey is the top-level key naming the files a config file extends
+const extendsKey = "extends"
+
+// applyExtends merges the files that the config file at path, holding data,
+// extends, returning the merged document and the keys of the files that
+// none of layouts reads. Each file named by extends, one path or a list of
+// them, is merged in order, and the file itself last. Mappings are merged
+// key by key; any other value, lists included, replaces the one it
+// overrides. Relative paths are relative to the extending file.
+func applyExtends(path string, data []byte, layouts ...reflect.Type) ([]byte, []UnknownKey, error) {
+	unknown := fileUnknownKeys(path, data, layouts...)
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		// Left for the config decoder to report
+		return data, unknown, nil
+	}
+	bases, err := extendedFiles(path, &doc)
+	if err != nil || len(bases) == 0 {
+		return data, unknown, err
+	}
+	// Listed as the absolute paths, whichever way the file names them
+	extends := mappingValue(documentRoot(&doc), extendsKey)
+	*extends = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for _, base := range bases {
+		extends.Content = append(extends.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: base})
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	merged, err := mergeExtends(&doc, bases, []string{abs}, layouts, &unknown)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err = yaml.Marshal(merged)
+	return data, unknown, err
+}
+
+// mergeExtends returns doc merged over the files in bases, adding their
+// unknown keys to unknown; chain holds the files being merged, to detect
+// cycles
+func mergeExtends(doc *yaml.Node, bases, chain []string, layouts []reflect.Type, unknown *[]UnknownKey) (*yaml.Node, error) {
+	var merged *yaml.Node
+	for _, base := range bases {
+		if i := slices.Index(chain, base); i >= 0 {
+			return nil, fmt.Errorf("config files extend each other: %s", strings.Join(append(chain[i:], base), " -> "))
+		}
+
+		data, err := readConfigFile(base)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s, extended by %s: %w", base, chain[len(chain)-1], err)
+		}
+		*unknown = append(*unknown, fileUnknownKeys(base, data, layouts...)...)
+		var baseDoc yaml.Node
+		if err := yaml.Unmarshal(data, &baseDoc); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", base, err)
+		}
+		baseBases, err := extendedFiles(base, &baseDoc)
+		if err != nil {
+			return nil, err
+		}
+		deleteKey(&baseDoc, extendsKey)
+
+		resolved := &baseDoc
+		if len(baseBases) > 0 {
+			resolved, err = mergeExtends(&baseDoc, baseBases, append(chain[:len(chain):len(chain)], base), layouts, unknown)
+			if err != nil {
+				return nil, err
+			}
+		}
+		merged = mergeNodes(merged, resolved)
+	}
+	return mergeNodes(merged, doc), nil
+}
+
+// extendedFiles returns the absolute paths of the files the config file at
+// path, parsed into doc, extends
+func extendedFiles(path string, doc *yaml.Node) ([]string, error) {
+	value := mappingValue(documentRoot(doc), extendsKey)
+	if value == nil {
+		return nil, nil
+	}
+
+	var names []string
+	switch value.Kind {
+	case yaml.ScalarNode:
+		names = []string{value.Value}
+	case yaml.SequenceNode:
+		for _, item := range value.Content {
+			if item.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("%s: line %d: extends must list file paths", path, item.Line)
+			}
+			names = append(names, item.Value)
+		}
+	default:
+		return nil, fmt.Errorf("%s: line %d: extends must be a file path or a list of them", path, value.Line)
+	}
+
+	files := make([]string, 0, len(names))
+	for _, name := range names {
+		if name == "" {
+			return nil, fmt.Errorf("%s: extends has an empty path", path)
+		}
+		if rest, ok := strings.CutPrefix(name, "~/"); ok {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, err
+			}
+			name = filepath.Join(home, rest)
+		}
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(filepath.Dir(path), name)
+		}
+		abs, err := filepath.Abs(name)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, abs)
+	}
+	return files, nil
+}
+
+// mergeNodes returns override merged over base. Mappings are merged key by
+// key; any other override replaces base. base is modified.
+func mergeNodes(base, override *yaml.Node) *yaml.Node {
+	if base == nil {
+		return override
+	}
+	if base.Kind == yaml.DocumentNode && override.Kind == yaml.DocumentNode {
+		if len(base.Content) == 0 {
+			return override
+		}
+		if len(override.Content) > 0 {
+			base.Content[0] = mergeNodes(base.Content[0], override.Content[0])
+		}
+		return base
+	}
+	if base.Kind != yaml.MappingNode || override.Kind != yaml.MappingNode {
+		return override
+	}
+
+	for i := 0; i+1 < len(override.Content); i += 2 {
+		key, value := override.Content[i], override.Content[i+1]
+		if existing := mappingValue(base, key.Value); existing != nil {
+			*existing = *mergeNodes(existing, value)
+			continue
+		}
+		base.Content = append(base.Content, key, value)
+	}
+	return base
+}
+
+// documentRoot returns the top-level node of a parsed document
+func documentRoot(doc *yaml.Node) *yaml.Node {
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		return doc.Content[0]
+	}
+	return doc
+}
+
+// mappingValue returns the value of key in mapping node, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// deleteKey removes key from the top-level mapping of doc
+func deleteKey(doc *yaml.Node, key string) {
+	root := documentRoot(doc)
+	if root.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == key {
+			root.Content = append(root.Content[:i], root.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+// readConfigFile reads the config file at path, resolving its secrets
+func readConfigFile(path string) ([]byte, error) {
+	// Path is from user configuration or command-line argument
+	data, err := os.ReadFile(filepath.Clean(path)) // #nosec G304
+	if err != nil {
+		return nil, err
+	}
+	return secret.ResolveYAML(data)
+}
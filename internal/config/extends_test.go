@@ -0,0 +1,147 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeFiles writes files, named relative to dir, and returns dir
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadClientConfig_Extends(t *testing.T) {
+	t.Parallel()
+
+	dir := writeFiles(t, map[string]string{
+		"team/base.yaml": `default_editor: code
+hosts:
+  server:
+    primary: 10.0.0.1
+    fallback: 10.0.0.2
+bookmarks:
+  api: /src/api
+local_editors:
+  zed: zed {path}
+`,
+		"team/tailscale.yaml": `extends: base.yaml
+hosts:
+  server:
+    fallback: 100.64.0.1
+`,
+		"config.yaml": `extends:
+  - team/tailscale.yaml
+default_editor: cursor
+bookmarks:
+  web: /src/web
+local_editors: {}
+`,
+	})
+
+	cfg, err := LoadClientConfig(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatalf("LoadClientConfig() error = %v", err)
+	}
+
+	if cfg.DefaultEditor != "cursor" {
+		t.Errorf("default_editor = %q, want the local cursor", cfg.DefaultEditor)
+	}
+	if cfg.Hosts.Server.Primary != "10.0.0.1" || cfg.Hosts.Server.Fallback != "100.64.0.1" {
+		t.Errorf("hosts.server = %+v, want primary from base.yaml and fallback from tailscale.yaml", cfg.Hosts.Server)
+	}
+	// Mappings merge key by key
+	if want := map[string]string{"api": "/src/api", "web": "/src/web"}; !reflect.DeepEqual(cfg.Bookmarks, want) {
+		t.Errorf("bookmarks = %v, want %v", cfg.Bookmarks, want)
+	}
+	if len(cfg.LocalEditors) != 1 {
+		t.Errorf("local_editors = %v, want the base entry kept by an empty mapping", cfg.LocalEditors)
+	}
+	if want := []string{filepath.Join(dir, "team", "tailscale.yaml")}; !reflect.DeepEqual(cfg.Extends, want) {
+		t.Errorf("extends = %v, want %v", cfg.Extends, want)
+	}
+
+	// Saving would copy the base settings into the file
+	if err := SaveClientConfig(filepath.Join(dir, "config.yaml"), cfg); err == nil {
+		t.Error("SaveClientConfig() of an extending config succeeded, want ErrExtends")
+	}
+}
+
+func TestLoadClientConfig_ExtendsLists(t *testing.T) {
+	t.Parallel()
+
+	dir := writeFiles(t, map[string]string{
+		"a.yaml":      "default_editor: a\nnetwork:\n  timeout: 3s\n",
+		"b.yaml":      "default_editor: b\n",
+		"config.yaml": "extends: [a.yaml, b.yaml]\n",
+	})
+
+	cfg, err := LoadClientConfig(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatalf("LoadClientConfig() error = %v", err)
+	}
+	// Later files override earlier ones
+	if cfg.DefaultEditor != "b" || cfg.Network.Timeout.String() != "3s" {
+		t.Errorf("config = %q, %v, want b and 3s", cfg.DefaultEditor, cfg.Network.Timeout)
+	}
+}
+
+func TestLoadClientConfig_ExtendsErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name: "cycle",
+			files: map[string]string{
+				"config.yaml": "extends: a.yaml\n",
+				"a.yaml":      "extends: b.yaml\n",
+				"b.yaml":      "extends: a.yaml\n",
+			},
+			wantErr: "extend each other",
+		},
+		{
+			name:    "itself",
+			files:   map[string]string{"config.yaml": "extends: config.yaml\n"},
+			wantErr: "extend each other",
+		},
+		{
+			name:    "missing file",
+			files:   map[string]string{"config.yaml": "extends: missing.yaml\n"},
+			wantErr: "missing.yaml",
+		},
+		{
+			name:    "not a path",
+			files:   map[string]string{"config.yaml": "extends:\n  file: a.yaml\n"},
+			wantErr: "extends must be",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			dir := writeFiles(t, tt.files)
+			_, err := LoadClientConfig(filepath.Join(dir, "config.yaml"))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadClientConfig() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// GetClientConfigValue returns the value of a dotted key such as
+// "hosts.server.primary". Sections are returned as YAML.
+func GetClientConfigValue(cfg *ClientConfig, key string) (string, error) {
+	field, err := lookupKey(reflect.ValueOf(cfg).Elem(), key)
+	if err != nil {
+		return "", err
+	}
+	if !field.IsValid() {
+		// Missing map entry
+		return "", nil
+	}
+
+	switch {
+	case field.Type() == durationType:
+		return time.Duration(field.Int()).String(), nil
+	case field.Kind() == reflect.Struct || field.Kind() == reflect.Map || field.Kind() == reflect.Slice:
+		data, err := yaml.Marshal(field.Interface())
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(string(data), "\n"), nil
+	default:
+		return fmt.Sprint(field.Interface()), nil
+	}
+}
+
+// SetClientConfigValue parses value according to the type of the dotted key,
+// validates the resulting configuration and writes the key to the client
+// config file at path. Other settings and comments in the file are kept.
+func SetClientConfigValue(path, key, value string) error {
+	return updateClientConfigKey(path, key, func(cfg *ClientConfig) (*yaml.Node, error) {
+		parent, name, err := lookupParent(reflect.ValueOf(cfg).Elem(), key)
+		if err != nil {
+			return nil, err
+		}
+
+		var field reflect.Value
+		if parent.Kind() == reflect.Map {
+			// Map entries are not addressable; set a copy and store it
+			field = reflect.New(parent.Type().Elem()).Elem()
+			if err := setField(field, key, value); err != nil {
+				return nil, err
+			}
+			parent.SetMapIndex(reflect.ValueOf(name), field)
+		} else {
+			if field, err = fieldByYAMLName(parent, name, key); err != nil {
+				return nil, err
+			}
+			if err := setField(field, key, value); err != nil {
+				return nil, err
+			}
+		}
+
+		var node yaml.Node
+		if err := node.Encode(field.Interface()); err != nil {
+			return nil, fmt.Errorf("failed to marshal %s: %w", key, err)
+		}
+		return &node, nil
+	})
+}
+
+// UnsetClientConfigValue removes the dotted key from the client config file
+// at path, so its default applies again. The result must still validate.
+func UnsetClientConfigValue(path, key string) error {
+	return updateClientConfigKey(path, key, func(cfg *ClientConfig) (*yaml.Node, error) {
+		parent, name, err := lookupParent(reflect.ValueOf(cfg).Elem(), key)
+		if err != nil {
+			return nil, err
+		}
+		if parent.Kind() == reflect.Map {
+			parent.SetMapIndex(reflect.ValueOf(name), reflect.Value{})
+			return nil, nil
+		}
+
+		field, err := fieldByYAMLName(parent, name, key)
+		if err != nil {
+			return nil, err
+		}
+		field.Set(reflect.Zero(field.Type()))
+		return nil, nil
+	})
+}
+
+// updateClientConfigKey loads the client config at path, lets change modify
+// it and return the new YAML value for key (nil removes the key), validates
+// the result with defaults applied and writes only that key back to the file
+func updateClientConfigKey(path, key string, change func(*ClientConfig) (*yaml.Node, error)) error {
+	if path == "" {
+		path = GetDefaultPaths().ClientConfig
+	}
+
+	cfg, err := LoadClientConfig(path)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	value, err := change(cfg)
+	if err != nil {
+		return err
+	}
+
+	applyClientDefaults(cfg)
+	if err := ValidateClientConfig(cfg); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	segments := strings.Split(key, ".")
+	if IsUnifiedConfigFile(path) {
+		segments = append([]string{"client"}, segments...)
+	}
+	return writeConfigKey(path, segments, value)
+}
+
+// lookupKey returns the value of a dotted key, or the zero reflect.Value
+// for a missing map entry
+func lookupKey(cfg reflect.Value, key string) (reflect.Value, error) {
+	parent, name, err := lookupParent(cfg, key)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if parent.Kind() == reflect.Map {
+		return parent.MapIndex(reflect.ValueOf(name)), nil
+	}
+	return fieldByYAMLName(parent, name, key)
+}
+
+// lookupParent returns the struct or map holding the last segment of key,
+// creating a nil map on the way
+func lookupParent(cfg reflect.Value, key string) (reflect.Value, string, error) {
+	if key == "" {
+		return reflect.Value{}, "", fmt.Errorf("empty key")
+	}
+
+	segments := strings.Split(key, ".")
+	current := cfg
+	for i, segment := range segments[:len(segments)-1] {
+		field, err := fieldByYAMLName(current, segment, strings.Join(segments[:i+1], "."))
+		if err != nil {
+			return reflect.Value{}, "", err
+		}
+		if field.Kind() == reflect.Map {
+			if i != len(segments)-2 {
+				return reflect.Value{}, "", fmt.Errorf("unknown key %q", key)
+			}
+			if field.IsNil() {
+				field.Set(reflect.MakeMap(field.Type()))
+			}
+		}
+		current = field
+	}
+	return current, segments[len(segments)-1], nil
+}
+
+// fieldByYAMLName returns the struct field of v whose YAML name is name
+func fieldByYAMLName(v reflect.Value, name, key string) (reflect.Value, error) {
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("unknown key %q", key)
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if tag == name && tag != "-" {
+			return v.Field(i), nil
+		}
+	}
+	if suggestion := suggestKey(name, []reflect.Type{t}); suggestion != "" {
+		return reflect.Value{}, fmt.Errorf("unknown key %q (did you mean %q?)", key, suggestion)
+	}
+	return reflect.Value{}, fmt.Errorf("unknown key %q", key)
+}
+
+// setField parses raw into field according to the field's type
+func setField(field reflect.Value, key, raw string) error {
+	switch {
+	case field.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%s: invalid duration %q", key, raw)
+		}
+		field.SetInt(int64(d))
+	case field.Kind() == reflect.String:
+		field.SetString(raw)
+	case field.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%s: invalid boolean %q (use true or false)", key, raw)
+		}
+		field.SetBool(b)
+	case field.Kind() == reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%s: invalid number %q", key, raw)
+		}
+		field.SetInt(int64(n))
+	case field.Kind() == reflect.Struct || field.Kind() == reflect.Map:
+		return fmt.Errorf("%s is a section; set one of its keys instead", key)
+	default:
+		return fmt.Errorf("%s cannot be set from the command line", key)
+	}
+	return nil
+}
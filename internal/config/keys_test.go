@@ -0,0 +1,164 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const keysTestConfig = `# rcode client
+hosts:
+  server:
+    primary: "192.168.1.100" # LAN address
+    fallback: ""
+network:
+  timeout: 2s
+default_editor: cursor
+`
+
+func writeKeysTestConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSetClientConfigValue(t *testing.T) {
+	path := writeKeysTestConfig(t, keysTestConfig)
+
+	for key, value := range map[string]string{
+		"hosts.server.primary":            "10.0.0.5",
+		"network.timeout":                 "5s",
+		"network.retry_attempts":          "4",
+		"hosts.ssh.auto_detect.tailscale": "false",
+		"fallback_editors.zed":            "zed ssh://{user}@{host}/{path}",
+	} {
+		if err := SetClientConfigValue(path, key, value); err != nil {
+			t.Fatalf("SetClientConfigValue(%s) error = %v", key, err)
+		}
+	}
+
+	cfg, err := LoadClientConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Hosts.Server.Primary != "10.0.0.5" || cfg.Network.Timeout != 5*time.Second || cfg.Network.RetryAttempts != 4 {
+		t.Errorf("loaded config = %+v", cfg)
+	}
+	if cfg.Hosts.SSH.AutoDetect.Tailscale || cfg.FallbackEditors["zed"] != "zed ssh://{user}@{host}/{path}" {
+		t.Errorf("loaded config = %+v", cfg)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"# rcode client", "primary: 10.0.0.5 # LAN address", "timeout: 5s", "default_editor: cursor"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("config file missing %q\n%s", want, data)
+		}
+	}
+}
+
+func TestSetClientConfigValueRejects(t *testing.T) {
+	tests := []struct {
+		key, value, wantErr string
+	}{
+		{"network.timeout", "soon", "invalid duration"},
+		{"network.retry_attempts", "many", "invalid number"},
+		{"network.retry_attempts", "-1", "retry attempts cannot be negative"},
+		{"local_mode", "sometimes", "invalid local mode"},
+		{"network.host_selection", "fastest", "invalid host selection"},
+		{"hosts.server", "x", "is a section"},
+		{"hosts.nope", "x", `unknown key "hosts.nope"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			path := writeKeysTestConfig(t, keysTestConfig)
+
+			err := SetClientConfigValue(path, tt.key, tt.value)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("SetClientConfigValue() error = %v, want %q", err, tt.wantErr)
+			}
+
+			data, _ := os.ReadFile(path)
+			if string(data) != keysTestConfig {
+				t.Errorf("config file changed after a rejected value:\n%s", data)
+			}
+		})
+	}
+}
+
+func TestUnsetClientConfigValue(t *testing.T) {
+	path := writeKeysTestConfig(t, keysTestConfig)
+
+	if err := UnsetClientConfigValue(path, "network.timeout"); err != nil {
+		t.Fatalf("UnsetClientConfigValue() error = %v", err)
+	}
+	if err := UnsetClientConfigValue(path, "hosts.server.primary"); err == nil {
+		t.Error("UnsetClientConfigValue(hosts.server.primary) error = nil, want validation error")
+	}
+
+	cfg, err := LoadClientConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Network.Timeout != DefaultTimeout {
+		t.Errorf("Timeout = %v, want default %v", cfg.Network.Timeout, DefaultTimeout)
+	}
+	if cfg.Hosts.Server.Primary != "192.168.1.100" {
+		t.Errorf("Primary = %q, want it kept", cfg.Hosts.Server.Primary)
+	}
+}
+
+func TestClientConfigValueUnifiedFile(t *testing.T) {
+	path := writeKeysTestConfig(t, `client:
+  hosts:
+    server:
+      primary: "192.168.1.100"
+server:
+  port: 3339
+`)
+
+	if err := SetClientConfigValue(path, "default_editor", "zed"); err != nil {
+		t.Fatalf("SetClientConfigValue() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "client:\n  hosts:\n    server:\n      primary: \"192.168.1.100\"\n  default_editor: zed\n") {
+		t.Errorf("default_editor not written under client:\n%s", data)
+	}
+}
+
+func TestGetClientConfigValue(t *testing.T) {
+	cfg := GetDefaultClientConfig()
+	cfg.Network.Timeout = 3 * time.Second
+
+	tests := map[string]string{
+		"hosts.server.primary":            "192.168.1.100",
+		"network.timeout":                 "3s",
+		"hosts.ssh.auto_detect.tailscale": "true",
+		"fallback_editors.zed":            "zed ssh://{user}@{host}/{path}",
+		"local_editors.missing":           "",
+		"network":                         "timeout: 3s\nretry_attempts: 3\nretry_delay: 500ms\nprobe_timeout: 500ms\ncache_ttl: 5m0s",
+	}
+	for key, want := range tests {
+		got, err := GetClientConfigValue(cfg, key)
+		if err != nil {
+			t.Errorf("GetClientConfigValue(%s) error = %v", key, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("GetClientConfigValue(%s) = %q, want %q", key, got, want)
+		}
+	}
+
+	if _, err := GetClientConfigValue(cfg, "network.nope"); err == nil {
+		t.Error("GetClientConfigValue(network.nope) error = nil, want unknown key")
+	}
+}
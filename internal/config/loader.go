@@ -2,15 +2,25 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/foxytanuki/rcode/internal/secret"
 	"gopkg.in/yaml.v3"
 )
 
+// ErrSecretRefs is returned when saving a loaded config would replace the
+// secret references of the file with the secrets
+var ErrSecretRefs = errors.New("config file refers to secrets (!env, !keychain); edit it by hand so they are not written in plain text")
+
 type configDocument struct {
 	Client yaml.Node `yaml:"client"`
 	Server yaml.Node `yaml:"server"`
@@ -21,16 +31,40 @@ type Paths struct {
 	ServerConfig string
 	ClientConfig string
 	LogDir       string
+	DataDir      string
 }
 
 // GetDefaultPaths returns the default configuration paths
 func GetDefaultPaths() Paths {
 	homeDir, _ := os.UserHomeDir()
 
+	if runtime.GOOS == "windows" {
+		return windowsPaths(homeDir, os.Getenv("APPDATA"), os.Getenv("LOCALAPPDATA"))
+	}
+
 	return Paths{
 		ServerConfig: filepath.Join(homeDir, ".config", "rcode", "server-config.yaml"),
 		ClientConfig: filepath.Join(homeDir, ".config", "rcode", "config.yaml"),
 		LogDir:       filepath.Join(homeDir, ".local", "share", "rcode", "logs"),
+		DataDir:      filepath.Join(homeDir, ".local", "share", "rcode"),
+	}
+}
+
+// windowsPaths returns the default paths on Windows: configuration under
+// %APPDATA%\rcode and logs and data under %LOCALAPPDATA%\rcode
+func windowsPaths(homeDir, appData, localAppData string) Paths {
+	if appData == "" {
+		appData = filepath.Join(homeDir, "AppData", "Roaming")
+	}
+	if localAppData == "" {
+		localAppData = filepath.Join(homeDir, "AppData", "Local")
+	}
+
+	return Paths{
+		ServerConfig: filepath.Join(appData, "rcode", "server-config.yaml"),
+		ClientConfig: filepath.Join(appData, "rcode", "config.yaml"),
+		LogDir:       filepath.Join(localAppData, "rcode", "logs"),
+		DataDir:      filepath.Join(localAppData, "rcode"),
 	}
 }
 
@@ -47,9 +81,7 @@ func loadConfig(path, defaultPath string, createDefault func() error) ([]byte, e
 		}
 	}
 
-	// Path is from user configuration or command-line argument
-	cleanPath := filepath.Clean(path)
-	data, err := os.ReadFile(cleanPath) // #nosec G304
+	data, err := readConfigFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
@@ -57,10 +89,21 @@ func loadConfig(path, defaultPath string, createDefault func() error) ([]byte, e
 	return data, nil
 }
 
+// hasSecretRefs reports whether the config file at path refers to secrets,
+// which saving a loaded config would replace with their values
+func hasSecretRefs(path string) bool {
+	data, err := os.ReadFile(filepath.Clean(path))
+	return err == nil && secret.HasRefs(data)
+}
+
 // LoadServerConfig loads server configuration from file
 func LoadServerConfig(path string) (*ServerConfigFile, error) {
 	paths := GetDefaultPaths()
 	defaultPath := defaultServerConfigPath(paths)
+	configPath := path
+	if configPath == "" {
+		configPath = defaultPath
+	}
 
 	data, err := loadConfig(path, defaultPath, func() error {
 		config := GetDefaultServerConfig()
@@ -74,15 +117,48 @@ func LoadServerConfig(path string) (*ServerConfigFile, error) {
 		return nil, err
 	}
 
+	config, unknown, err := parseServerConfigFile(configPath, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := reportUnknownKeys(configPath, unknown); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// InspectServerConfig loads the server config file at path like
+// LoadServerConfig, but neither creates a missing file nor reports unknown
+// keys: they are returned, with those of the files it extends
+func InspectServerConfig(path string) (*ServerConfigFile, []UnknownKey, error) {
+	data, err := readConfigFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	return parseServerConfigFile(path, data)
+}
+
+// parseServerConfigFile decodes the server config file at path, holding
+// data, merging the files it extends and applying defaults
+func parseServerConfigFile(path string, data []byte) (*ServerConfigFile, []UnknownKey, error) {
+	layout := reflect.TypeOf(ServerConfigFile{})
+	if hasNestedClientConfig(data) {
+		layout = reflect.TypeOf(UnifiedConfigFile{})
+	}
+	data, unknown, err := applyExtends(path, data, layout)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	var config ServerConfigFile
 	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+		return nil, nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
 	// Apply defaults for missing values
 	applyServerDefaults(&config)
 
-	return &config, nil
+	return &config, unknown, nil
 }
 
 // LoadClientConfig loads client configuration from file
@@ -105,6 +181,19 @@ func LoadClientConfig(path string) (*ClientConfig, error) {
 		return nil, err
 	}
 
+	// Legacy fields are still read, to be migrated
+	layouts := []reflect.Type{reflect.TypeOf(ClientConfig{}), reflect.TypeOf(legacyClientConfig{})}
+	if hasNestedClientConfig(data) {
+		layouts = []reflect.Type{reflect.TypeOf(UnifiedConfigFile{})}
+	}
+	data, unknown, err := applyExtends(configPath, data, layouts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := reportUnknownKeys(configPath, unknown); err != nil {
+		return nil, err
+	}
+
 	// First, parse legacy fields from the raw data
 	var legacy legacyClientConfig
 	_ = yaml.Unmarshal(data, &legacy) // Ignore errors, just capture what we can
@@ -114,27 +203,22 @@ func LoadClientConfig(path string) (*ClientConfig, error) {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
-	// Migrate legacy fields to new format
-	legacyWarnings := MigrateFromLegacy(&legacy, config)
+	// Migrate legacy fields to new format. They are migrated in memory;
+	// "rcode config migrate" rewrites the file.
+	config.Deprecations = MigrateFromLegacy(&legacy, config)
+	if len(config.Deprecations) > 0 {
+		config.Deprecations = append(config.Deprecations, MigrationWarning{
+			Field:   configPath,
+			Message: `Run "rcode config migrate" to update the deprecated keys`,
+		})
+	}
 
 	// Run additional migrations
-	warnings := MigrateClientConfig(config)
+	config.Deprecations = append(config.Deprecations, MigrateClientConfig(config)...)
 
 	// Apply defaults for missing values
 	applyClientDefaults(config)
 
-	// If legacy fields were migrated, auto-save the new format
-	if len(legacyWarnings) > 0 {
-		if err := autoMigrateConfigFile(configPath, config, legacyWarnings); err != nil {
-			// Print warnings if auto-migration failed
-			fmt.Fprintf(os.Stderr, "Warning: Failed to auto-migrate config file: %v\n", err)
-			PrintMigrationWarnings(legacyWarnings)
-		}
-	}
-
-	// Print any additional migration warnings
-	PrintMigrationWarnings(warnings)
-
 	return config, nil
 }
 
@@ -154,9 +238,12 @@ func parseClientConfig(data []byte) (*ClientConfig, error) {
 	}
 
 	config := unified.Client
-	if config.Logging == (LogConfig{}) {
+	if config.Logging.IsZero() {
 		config.Logging = unified.Logging
 	}
+	if config.Telemetry.IsZero() {
+		config.Telemetry = unified.Telemetry
+	}
 
 	return &config, nil
 }
@@ -187,48 +274,22 @@ func defaultServerConfigPath(paths Paths) string {
 	return paths.ServerConfig
 }
 
-// autoMigrateConfigFile backs up the old config and saves the new format
-func autoMigrateConfigFile(configPath string, config *ClientConfig, warnings []MigrationWarning) error {
-	// Create backup path
-	backupPath := configPath + ".bak"
-
-	// Check if backup already exists (don't overwrite previous backups)
-	if _, err := os.Stat(backupPath); err == nil {
-		// Backup exists, use numbered backup
-		for i := 1; i < 100; i++ {
-			backupPath = fmt.Sprintf("%s.bak.%d", configPath, i)
-			if _, err := os.Stat(backupPath); os.IsNotExist(err) {
-				break
-			}
-		}
-	}
-
-	// Read original file for backup
-	// configPath is from user configuration or default path, not external input
-	originalData, err := os.ReadFile(configPath) // #nosec G304
+// IsUnifiedConfigFile reports whether the file at path holds nested client
+// and server sections rather than a single configuration
+func IsUnifiedConfigFile(path string) bool {
+	data, err := os.ReadFile(filepath.Clean(path))
 	if err != nil {
-		return fmt.Errorf("failed to read original config: %w", err)
-	}
-
-	// Write backup
-	if err := os.WriteFile(backupPath, originalData, 0o600); err != nil { // #nosec G306,G703 -- backupPath is derived from the local config file path and kept owner-only
-		return fmt.Errorf("failed to create backup: %w", err)
-	}
-
-	// Save new format
-	if err := SaveClientConfig(configPath, config); err != nil {
-		return fmt.Errorf("failed to save migrated config: %w", err)
+		return false
 	}
+	return hasNestedClientConfig(data) || hasNestedServerConfig(data)
+}
 
-	// Print migration success message
-	fmt.Fprintf(os.Stderr, "Config file migrated to new format.\n")
-	fmt.Fprintf(os.Stderr, "  Backup saved to: %s\n", backupPath)
-	fmt.Fprintf(os.Stderr, "  Migrated fields:\n")
-	for _, w := range warnings {
-		fmt.Fprintf(os.Stderr, "    - %s: %s\n", w.Field, w.Message)
+// ServerConfigPath returns the file LoadServerConfig reads for path
+func ServerConfigPath(path string) string {
+	if path != "" {
+		return filepath.Clean(path)
 	}
-
-	return nil
+	return defaultServerConfigPath(GetDefaultPaths())
 }
 
 // saveConfig is a generic function to save configuration to file
@@ -236,6 +297,9 @@ func saveConfig[T any](path, defaultPath string, config *T) error {
 	if path == "" {
 		path = defaultPath
 	}
+	if hasSecretRefs(path) {
+		return fmt.Errorf("%s: %w", path, ErrSecretRefs)
+	}
 
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0o750); err != nil {
@@ -256,30 +320,158 @@ func saveConfig[T any](path, defaultPath string, config *T) error {
 
 // SaveServerConfig saves server configuration to file
 func SaveServerConfig(path string, config *ServerConfigFile) error {
+	if len(config.Extends) > 0 {
+		return fmt.Errorf("%s: %w", path, ErrExtends)
+	}
 	return saveConfig(path, GetDefaultPaths().ServerConfig, config)
 }
 
+// SaveServerEditors replaces the editors list in the config file at path,
+// leaving every other setting (and its comments) untouched
+func SaveServerEditors(path string, editors []EditorConfig) error {
+	var value yaml.Node
+	if err := value.Encode(editors); err != nil {
+		return fmt.Errorf("failed to marshal editors: %w", err)
+	}
+	return writeConfigKey(path, []string{"editors"}, &value)
+}
+
+// writeConfigKey replaces the value at the nested key path in the YAML file
+// at path, creating missing mappings, or removes the key when value is nil.
+// Every other setting and its comments are left untouched.
+func writeConfigKey(path string, keys []string, value *yaml.Node) error {
+	cleanPath := filepath.Clean(path)
+	data, err := os.ReadFile(cleanPath) // #nosec G304
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if doc.Kind == 0 {
+		// Empty file
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return fmt.Errorf("failed to update config file: %s is not a YAML mapping", path)
+	}
+
+	setNodeKey(doc.Content[0], keys, value)
+	out, err := encodeConfig(&doc)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(cleanPath, out, 0o600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// setNodeKey sets the value of the key path keys below mapping, creating
+// sections as needed; a nil value removes the key
+func setNodeKey(mapping *yaml.Node, keys []string, value *yaml.Node) {
+	for i, key := range keys {
+		idx := -1
+		for j := 0; j+1 < len(mapping.Content); j += 2 {
+			if mapping.Content[j].Value == key {
+				idx = j
+				break
+			}
+		}
+		last := i == len(keys)-1
+
+		switch {
+		case last && value == nil:
+			if idx >= 0 {
+				mapping.Content = append(mapping.Content[:idx], mapping.Content[idx+2:]...)
+			}
+		case last:
+			if idx >= 0 {
+				// Keep comments written next to the old value
+				old := mapping.Content[idx+1]
+				value.HeadComment, value.LineComment, value.FootComment = old.HeadComment, old.LineComment, old.FootComment
+				mapping.Content[idx+1] = value
+			} else {
+				mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+			}
+		case idx >= 0 && mapping.Content[idx+1].Kind == yaml.MappingNode:
+			mapping = mapping.Content[idx+1]
+		case value == nil:
+			// Nothing to remove
+			return
+		default:
+			child := &yaml.Node{Kind: yaml.MappingNode}
+			if idx >= 0 {
+				mapping.Content[idx+1] = child
+			} else {
+				mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, child)
+			}
+			mapping = child
+		}
+	}
+}
+
+// encodeConfig marshals doc with the two-space indentation of hand-written
+// files
+func encodeConfig(doc *yaml.Node) ([]byte, error) {
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
 // SaveClientConfig saves client configuration to file
 func SaveClientConfig(path string, config *ClientConfig) error {
+	if len(config.Extends) > 0 {
+		return fmt.Errorf("%s: %w", path, ErrExtends)
+	}
 	return saveConfig(path, GetDefaultPaths().ClientConfig, config)
 }
 
 // SaveUnifiedConfig saves unified client/server configuration to file.
 func SaveUnifiedConfig(path string, config *UnifiedConfigFile) error {
+	if len(config.Extends) > 0 || len(config.Client.Extends) > 0 {
+		return fmt.Errorf("%s: %w", path, ErrExtends)
+	}
 	return saveConfig(path, GetDefaultPaths().ClientConfig, config)
 }
 
 // MergeClientWithEnvironment merges environment variables into client configuration
 func MergeClientWithEnvironment(config *ClientConfig) {
-	// Run migration for environment variables (handles deprecation warnings)
-	warnings := MigrateClientEnvironment(config)
-	PrintMigrationWarnings(warnings)
+	// Run migration for environment variables; their deprecation notices
+	// are added to the config's
+	config.Deprecations = append(config.Deprecations, MigrateClientEnvironment(config)...)
 
 	// Fallback host
 	if fallbackHost := os.Getenv("RCODE_FALLBACK_HOST"); fallbackHost != "" {
 		config.Hosts.Server.Fallback = fallbackHost
 	}
 
+	// Auth token
+	if token := os.Getenv("RCODE_AUTH_TOKEN"); token != "" {
+		config.Hosts.Server.AuthToken = token
+	}
+
+	// Signing key
+	if key := os.Getenv("RCODE_SIGNING_KEY"); key != "" {
+		config.Hosts.Server.SigningKey = key
+	}
+
+	// Server port
+	if port := os.Getenv("RCODE_SERVER_PORT"); port != "" {
+		if p, err := strconv.Atoi(port); err == nil {
+			config.Hosts.Server.Port = p
+		}
+	}
+
 	// Timeout
 	if timeout := os.Getenv("RCODE_TIMEOUT"); timeout != "" {
 		if d, err := time.ParseDuration(timeout); err == nil {
@@ -287,11 +479,21 @@ func MergeClientWithEnvironment(config *ClientConfig) {
 		}
 	}
 
+	// Host selection
+	if selection := os.Getenv("RCODE_HOST_SELECTION"); selection != "" {
+		config.Network.HostSelection = HostSelection(strings.ToLower(selection))
+	}
+
 	// Editor configuration
 	if editor := os.Getenv("RCODE_EDITOR"); editor != "" {
 		config.DefaultEditor = editor
 	}
 
+	// Local mode
+	if mode := os.Getenv("RCODE_LOCAL_MODE"); mode != "" {
+		config.LocalMode = LocalMode(strings.ToLower(mode))
+	}
+
 	// Logging configuration
 	if logLevel := os.Getenv("RCODE_LOG_LEVEL"); logLevel != "" {
 		config.Logging.Level = strings.ToLower(logLevel)
@@ -309,35 +511,12 @@ func GetDefaultServerConfig() *ServerConfigFile {
 			WriteTimeout: DefaultWriteTimeout,
 			IdleTimeout:  DefaultIdleTimeout,
 			AllowedIPs:   []string{},
+			Launch:       LaunchConfig{DedupWindow: DefaultDedupWindow},
 		},
-		Editors: []EditorConfig{
-			{
-				Name:      "cursor",
-				Command:   "cursor --remote ssh-remote+{user}@{host} {path}",
-				Default:   true,
-				Available: true,
-			},
-			{
-				Name:      "vscode",
-				Command:   "code --remote ssh-remote+{user}@{host} {path}",
-				Default:   false,
-				Available: true,
-			},
-			{
-				Name:      "zed",
-				Command:   "zed ssh://{user}@{host}/{path}",
-				Default:   false,
-				Available: true,
-			},
-			{
-				Name:      "nvim",
-				Command:   "nvim scp://{user}@{host}/{path}",
-				Default:   false,
-				Available: true,
-			},
-		},
+		Editors: KnownEditors(),
 		Logging: LogConfig{
 			Level:      DefaultLogLevel,
+			Format:     DefaultLogFormat,
 			File:       filepath.Join(paths.LogDir, "server.log"),
 			MaxSize:    DefaultLogMaxSize,
 			MaxBackups: DefaultLogMaxBackups,
@@ -348,6 +527,101 @@ func GetDefaultServerConfig() *ServerConfigFile {
 	}
 }
 
+// KnownEditors returns the editors rcode knows how to launch against a
+// remote path over SSH, with the first one as the default. A new server
+// configuration lists those installed on the machine, or all of them when
+// none is found.
+func KnownEditors() []EditorConfig {
+	return []EditorConfig{
+		{
+			Name:      "cursor",
+			Command:   "cursor --remote ssh-remote+{user}@{host} {path}",
+			Diff:      "cursor --remote ssh-remote+{user}@{host} --diff {path} {path2}",
+			Default:   true,
+			Available: true,
+		},
+		{
+			Name:      "vscode",
+			Command:   "code --remote ssh-remote+{user}@{host} {path}",
+			Diff:      "code --remote ssh-remote+{user}@{host} --diff {path} {path2}",
+			Available: true,
+		},
+		{
+			Name:      "windsurf",
+			Command:   "windsurf --remote ssh-remote+{user}@{host} {path}",
+			Diff:      "windsurf --remote ssh-remote+{user}@{host} --diff {path} {path2}",
+			Available: true,
+		},
+		{
+			Name:      "zed",
+			Command:   "zed ssh://{user}@{host}/{path}",
+			Available: true,
+		},
+		{
+			Name:      "nvim",
+			Command:   "nvim scp://{user}@{host}/{path}",
+			Available: true,
+		},
+		{
+			Name:      "emacs",
+			Command:   "emacsclient -n /ssh:{user}@{host}:{path}",
+			Available: true,
+		},
+		{
+			Name:      "meld",
+			Command:   "meld sftp://{user}@{host}{path|urlpath}",
+			Diff:      "meld sftp://{user}@{host}{path|urlpath} sftp://{user}@{host}{path2|urlpath}",
+			Available: true,
+		},
+		{
+			Name:      "kdiff3",
+			Command:   "kdiff3 sftp://{user}@{host}{path|urlpath}",
+			Diff:      "kdiff3 sftp://{user}@{host}{path|urlpath} sftp://{user}@{host}{path2|urlpath}",
+			Available: true,
+		},
+	}
+}
+
+// remoteShell is the command a terminal runs over SSH: a login shell in
+// the requested directory
+const remoteShell = `"cd {path|shellescape} && exec \$SHELL -l"`
+
+// KnownTerminals returns the terminals rcode knows how to open at a remote
+// path, each running an SSH session that starts in that directory. A server
+// whose configuration lists no terminals uses those installed on the
+// machine, the first one found being the default.
+func KnownTerminals() []EditorConfig {
+	return []EditorConfig{
+		{
+			Name: "terminal",
+			Command: `osascript -e 'on run argv' ` +
+				`-e 'tell application "Terminal" to do script "ssh -t " & quoted form of item 1 of argv & " " & quoted form of ("cd " & quoted form of item 2 of argv & " && exec $SHELL -l")' ` +
+				`-e 'tell application "Terminal" to activate' -e 'end run' {user}@{host} {path}`,
+			Available: true,
+		},
+		{
+			Name:      "wezterm",
+			Command:   "wezterm start -- ssh -t {user}@{host} " + remoteShell,
+			Available: true,
+		},
+		{
+			Name:      "alacritty",
+			Command:   "alacritty -e ssh -t {user}@{host} " + remoteShell,
+			Available: true,
+		},
+		{
+			Name:      "kitty",
+			Command:   "kitty ssh -t {user}@{host} " + remoteShell,
+			Available: true,
+		},
+		{
+			Name:      "gnome-terminal",
+			Command:   "gnome-terminal -- ssh -t {user}@{host} " + remoteShell,
+			Available: true,
+		},
+	}
+}
+
 // GetDefaultClientConfig returns default client configuration
 func GetDefaultClientConfig() *ClientConfig {
 	paths := GetDefaultPaths()
@@ -356,6 +630,7 @@ func GetDefaultClientConfig() *ClientConfig {
 			Server: ServerHostConfig{
 				Primary:  "192.168.1.100",
 				Fallback: "100.64.0.1",
+				Port:     DefaultServerPort,
 			},
 			SSH: SSHHostConfig{
 				Host: "", // Empty = auto-detect
@@ -369,11 +644,14 @@ func GetDefaultClientConfig() *ClientConfig {
 			Timeout:       DefaultTimeout,
 			RetryAttempts: DefaultRetryAttempts,
 			RetryDelay:    DefaultRetryDelay,
+			ProbeTimeout:  DefaultProbeTimeout,
+			CacheTTL:      DefaultHostCacheTTL,
 		},
 		FallbackEditors: GetDefaultFallbackEditors(),
 		DefaultEditor:   "cursor",
 		Logging: LogConfig{
 			Level:      DefaultLogLevel,
+			Format:     DefaultLogFormat,
 			File:       filepath.Join(paths.LogDir, "client.log"),
 			MaxSize:    DefaultLogMaxSize,
 			MaxBackups: DefaultLogMaxBackups,
@@ -401,12 +679,19 @@ func applyServerDefaults(config *ServerConfigFile) {
 	if config.Server.IdleTimeout == 0 {
 		config.Server.IdleTimeout = DefaultIdleTimeout
 	}
+	if config.Server.SessionsFile == "" {
+		config.Server.SessionsFile = filepath.Join(GetDefaultPaths().DataDir, "sessions.json")
+	}
 
 	applyLogDefaults(&config.Logging, "server.log")
+	applyAuditDefaults(&config.Audit)
 }
 
 // applyClientDefaults applies default values to missing client config fields
 func applyClientDefaults(config *ClientConfig) {
+	if config.Hosts.Server.Port == 0 {
+		config.Hosts.Server.Port = DefaultServerPort
+	}
 	if config.Network.Timeout == 0 {
 		config.Network.Timeout = DefaultTimeout
 	}
@@ -416,15 +701,52 @@ func applyClientDefaults(config *ClientConfig) {
 	if config.Network.RetryDelay == 0 {
 		config.Network.RetryDelay = DefaultRetryDelay
 	}
+	if config.Network.ProbeTimeout == 0 {
+		config.Network.ProbeTimeout = DefaultProbeTimeout
+	}
+	if config.Network.CacheTTL == 0 {
+		config.Network.CacheTTL = DefaultHostCacheTTL
+	}
+	if config.HistoryFile == "" {
+		config.HistoryFile = filepath.Join(GetDefaultPaths().DataDir, "history.json")
+	}
+	if config.QueueFile == "" {
+		config.QueueFile = filepath.Join(GetDefaultPaths().DataDir, "queue.json")
+	}
+	if config.StatsFile == "" {
+		config.StatsFile = filepath.Join(GetDefaultPaths().DataDir, "stats.json")
+	}
+	if config.ProjectsFile == "" {
+		config.ProjectsFile = filepath.Join(GetDefaultPaths().DataDir, "projects.json")
+	}
 
 	applyLogDefaults(&config.Logging, "client.log")
 }
 
+// applyAuditDefaults applies rotation defaults when the audit log is enabled
+func applyAuditDefaults(config *AuditConfig) {
+	if config.File == "" {
+		return
+	}
+	if config.MaxSize == 0 {
+		config.MaxSize = DefaultLogMaxSize
+	}
+	if config.MaxBackups == 0 {
+		config.MaxBackups = DefaultLogMaxBackups
+	}
+	if config.MaxAge == 0 {
+		config.MaxAge = DefaultLogMaxAge
+	}
+}
+
 // applyLogDefaults applies default values to logging config
 func applyLogDefaults(config *LogConfig, defaultFile string) {
 	if config.Level == "" {
 		config.Level = DefaultLogLevel
 	}
+	if config.Format == "" {
+		config.Format = DefaultLogFormat
+	}
 	if config.File == "" {
 		paths := GetDefaultPaths()
 		config.File = filepath.Join(paths.LogDir, defaultFile)
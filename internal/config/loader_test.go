@@ -1,8 +1,10 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -116,6 +118,22 @@ client:
 	}
 }
 
+func TestLoadServerConfig_DefaultsToLoopback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server-config.yaml")
+	if err := os.WriteFile(path, []byte("server:\n  port: 4444\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	cfg, err := LoadServerConfig(path)
+	if err != nil {
+		t.Fatalf("LoadServerConfig() error = %v", err)
+	}
+
+	if cfg.Server.Host != "127.0.0.1" {
+		t.Fatalf("Host = %q, want %q", cfg.Server.Host, "127.0.0.1")
+	}
+}
+
 func TestLoadServerConfig_PrefersUnifiedDefaultConfigPath(t *testing.T) {
 	home := t.TempDir()
 	t.Setenv("HOME", home)
@@ -168,3 +186,99 @@ editors:
 		t.Fatalf("Editors = %#v, want unified config editors", cfg.Editors)
 	}
 }
+
+func TestSaveServerEditors_KeepsOtherSettings(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+
+	data := []byte(`# rcode settings
+server:
+  port: 4444 # custom port
+editors:
+  - name: code
+    command: code {path}
+    default: true
+client:
+  default_editor: code
+`)
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	editors := []EditorConfig{
+		{Name: "code", Command: "code {path}"},
+		{Name: "cursor", Command: "cursor {path}", Default: true},
+	}
+	if err := SaveServerEditors(path, editors); err != nil {
+		t.Fatalf("SaveServerEditors() error = %v", err)
+	}
+
+	cfg, err := LoadServerConfig(path)
+	if err != nil {
+		t.Fatalf("LoadServerConfig() error = %v", err)
+	}
+	if cfg.Server.Port != 4444 {
+		t.Fatalf("Port = %d, want %d", cfg.Server.Port, 4444)
+	}
+	if len(cfg.Editors) != 2 || cfg.Editors[1].Name != "cursor" || !cfg.Editors[1].Default {
+		t.Fatalf("Editors = %#v, want code and default cursor", cfg.Editors)
+	}
+
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	for _, want := range []string{"# rcode settings", "# custom port", "default_editor: code"} {
+		if !strings.Contains(string(saved), want) {
+			t.Errorf("saved config missing %q:\n%s", want, saved)
+		}
+	}
+}
+
+func TestWindowsPaths(t *testing.T) {
+	home := filepath.Join("C:", "Users", "tester")
+
+	paths := windowsPaths(home, filepath.Join("D:", "Roaming"), "")
+	if want := filepath.Join("D:", "Roaming", "rcode", "config.yaml"); paths.ClientConfig != want {
+		t.Errorf("ClientConfig = %q, want %q", paths.ClientConfig, want)
+	}
+	if want := filepath.Join("D:", "Roaming", "rcode", "server-config.yaml"); paths.ServerConfig != want {
+		t.Errorf("ServerConfig = %q, want %q", paths.ServerConfig, want)
+	}
+	if want := filepath.Join(home, "AppData", "Local", "rcode", "logs"); paths.LogDir != want {
+		t.Errorf("LogDir = %q, want %q", paths.LogDir, want)
+	}
+}
+
+func TestLoadClientConfig_ResolvesSecrets(t *testing.T) {
+	t.Setenv("RCODE_TEST_TOKEN", "s3cret")
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := "hosts:\n  server:\n    primary: 10.0.0.1\n    auth_token: !env RCODE_TEST_TOKEN\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadClientConfig(path)
+	if err != nil {
+		t.Fatalf("LoadClientConfig() error = %v", err)
+	}
+	if cfg.Hosts.Server.AuthToken != "s3cret" {
+		t.Errorf("auth_token = %q, want the environment variable's value", cfg.Hosts.Server.AuthToken)
+	}
+
+	// Saving would write the token in plain text
+	if err := SaveClientConfig(path, cfg); !errors.Is(err, ErrSecretRefs) {
+		t.Errorf("SaveClientConfig() error = %v, want ErrSecretRefs", err)
+	}
+
+	if err := os.WriteFile(path, []byte(strings.Replace(data, "RCODE_TEST_TOKEN", "RCODE_TEST_UNSET", 1)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadClientConfig(path); err == nil || !strings.Contains(err.Error(), "RCODE_TEST_UNSET") {
+		t.Errorf("LoadClientConfig() with an unset variable error = %v, want it named", err)
+	}
+}
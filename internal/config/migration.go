@@ -3,6 +3,10 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
 // MigrationWarning represents a deprecation warning during migration.
@@ -53,7 +57,7 @@ func MigrateFromLegacy(legacy *legacyClientConfig, cfg *ClientConfig) []Migratio
 		cfg.Hosts.Server.Primary = legacy.Network.PrimaryHost
 		warnings = append(warnings, MigrationWarning{
 			Field:   "network.primary_host",
-			Message: "Migrated to hosts.server.primary",
+			Message: "Deprecated, use hosts.server.primary instead",
 		})
 	}
 
@@ -62,7 +66,7 @@ func MigrateFromLegacy(legacy *legacyClientConfig, cfg *ClientConfig) []Migratio
 		cfg.Hosts.Server.Fallback = legacy.Network.FallbackHost
 		warnings = append(warnings, MigrationWarning{
 			Field:   "network.fallback_host",
-			Message: "Migrated to hosts.server.fallback",
+			Message: "Deprecated, use hosts.server.fallback instead",
 		})
 	}
 
@@ -71,7 +75,7 @@ func MigrateFromLegacy(legacy *legacyClientConfig, cfg *ClientConfig) []Migratio
 		cfg.Hosts.SSH.Host = legacy.SSHHost
 		warnings = append(warnings, MigrationWarning{
 			Field:   "ssh_host",
-			Message: "Migrated to hosts.ssh.host",
+			Message: "Deprecated, use hosts.ssh.host instead",
 		})
 	}
 
@@ -80,7 +84,7 @@ func MigrateFromLegacy(legacy *legacyClientConfig, cfg *ClientConfig) []Migratio
 		cfg.Hosts.SSH.AutoDetect.Tailscale = legacy.AutoDetectTailscale
 		warnings = append(warnings, MigrationWarning{
 			Field:   "auto_detect_tailscale",
-			Message: "Migrated to hosts.ssh.auto_detect.tailscale",
+			Message: "Deprecated, use hosts.ssh.auto_detect.tailscale instead",
 		})
 	}
 
@@ -89,13 +93,141 @@ func MigrateFromLegacy(legacy *legacyClientConfig, cfg *ClientConfig) []Migratio
 		cfg.Hosts.SSH.AutoDetect.TailscalePattern = legacy.TailscaleHostPattern
 		warnings = append(warnings, MigrationWarning{
 			Field:   "tailscale_host_pattern",
-			Message: "Migrated to hosts.ssh.auto_detect.tailscale_pattern",
+			Message: "Deprecated, use hosts.ssh.auto_detect.tailscale_pattern instead",
 		})
 	}
 
 	return warnings
 }
 
+// legacyKeys maps the keys replaced by the hosts section to their
+// replacements, in the order MigrateFromLegacy applies them
+var legacyKeys = []struct{ old, new string }{
+	{"network.primary_host", "hosts.server.primary"},
+	{"network.fallback_host", "hosts.server.fallback"},
+	{"ssh_host", "hosts.ssh.host"},
+	{"auto_detect_tailscale", "hosts.ssh.auto_detect.tailscale"},
+	{"tailscale_host_pattern", "hosts.ssh.auto_detect.tailscale_pattern"},
+}
+
+// LegacyMigrationResult describes a rewrite of legacy keys in a client
+// config file
+type LegacyMigrationResult struct {
+	Path       string
+	BackupPath string // Empty when nothing was written
+	Before     []byte
+	After      []byte // Same as Before when there were no legacy keys
+	Warnings   []MigrationWarning
+}
+
+// MigrateLegacyConfigFile rewrites the legacy keys of the client config
+// file at path to the hosts section, backing up the original. Comments and
+// other settings are kept. With dryRun, the result is computed but nothing
+// is written.
+func MigrateLegacyConfigFile(path string, dryRun bool) (*LegacyMigrationResult, error) {
+	data, err := os.ReadFile(filepath.Clean(path)) // #nosec G304 -- path points to a local config file selected for migration
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	after, warnings, err := RewriteLegacyKeys(data)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &LegacyMigrationResult{Path: path, Before: data, After: after, Warnings: warnings}
+	if len(warnings) == 0 || dryRun {
+		return result, nil
+	}
+	if result.BackupPath, err = backupFile(path); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, after, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write config file: %w", err)
+	}
+	return result, nil
+}
+
+// RewriteLegacyKeys returns the client config document data with its legacy
+// keys moved to the hosts section, and a warning for each key moved or
+// dropped. A legacy key is dropped when its replacement is already set, as
+// the replacement is what the loader uses. Comments are kept. Documents
+// without legacy keys, unified files included, are returned unchanged.
+func RewriteLegacyKeys(data []byte) ([]byte, []MigrationWarning, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	root := documentRoot(&doc)
+	if root.Kind != yaml.MappingNode || hasNestedClientConfig(data) {
+		return data, nil, nil
+	}
+
+	var warnings []MigrationWarning
+	for _, k := range legacyKeys {
+		oldKeys, newKeys := strings.Split(k.old, "."), strings.Split(k.new, ".")
+		oldKey, value := nodeAt(root, oldKeys)
+		if value == nil {
+			continue
+		}
+
+		if _, existing := nodeAt(root, newKeys); existing != nil {
+			warnings = append(warnings, MigrationWarning{
+				Field:   k.old,
+				Message: fmt.Sprintf("Removed; %s is already set", k.new),
+			})
+		} else {
+			setNodeKey(root, newKeys, value)
+			if newKey, _ := nodeAt(root, newKeys); newKey.HeadComment == "" {
+				newKey.HeadComment = oldKey.HeadComment
+			}
+			warnings = append(warnings, MigrationWarning{
+				Field:   k.old,
+				Message: "Migrated to " + k.new,
+			})
+		}
+		setNodeKey(root, oldKeys, nil)
+	}
+	if len(warnings) == 0 {
+		return data, nil, nil
+	}
+
+	// Drop the network section when only legacy keys were in it
+	if network := mappingValue(root, "network"); network != nil && network.Kind == yaml.MappingNode && len(network.Content) == 0 {
+		setNodeKey(root, []string{"network"}, nil)
+	}
+
+	out, err := encodeConfig(&doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, warnings, nil
+}
+
+// nodeAt returns the key and value nodes of the key path keys below
+// mapping, or nils when it is not set
+func nodeAt(mapping *yaml.Node, keys []string) (*yaml.Node, *yaml.Node) {
+	for i, key := range keys {
+		if mapping.Kind != yaml.MappingNode {
+			return nil, nil
+		}
+		idx := -1
+		for j := 0; j+1 < len(mapping.Content); j += 2 {
+			if mapping.Content[j].Value == key {
+				idx = j
+				break
+			}
+		}
+		if idx < 0 {
+			return nil, nil
+		}
+		if i == len(keys)-1 {
+			return mapping.Content[idx], mapping.Content[idx+1]
+		}
+		mapping = mapping.Content[idx+1]
+	}
+	return nil, nil
+}
+
 // MigrateClientEnvironment checks for deprecated environment variables
 // and returns warnings. It also applies the new env vars to config.
 func MigrateClientEnvironment(cfg *ClientConfig) []MigrationWarning {
@@ -159,6 +291,21 @@ func GetDefaultFallbackEditors() FallbackEditorsConfig {
 		"zed":    "zed ssh://{user}@{host}/{path}",
 		"nvim":   "nvim scp://{user}@{host}/{path}",
 		"neovim": "nvim scp://{user}@{host}/{path}",
+		"emacs":  "emacsclient -n /ssh:{user}@{host}:{path}",
+	}
+}
+
+// GetDefaultLocalEditors returns the built-in command templates used to open
+// editors on this machine in local mode.
+func GetDefaultLocalEditors() map[string]string {
+	return map[string]string{
+		"cursor": "cursor {path}",
+		"vscode": "code {path}",
+		"code":   "code {path}",
+		"zed":    "zed {path}",
+		"nvim":   "nvim {path}",
+		"neovim": "nvim {path}",
+		"emacs":  "emacsclient -n {path}",
 	}
 }
 
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRewriteLegacyKeys(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`# rcode client
+network:
+  # LAN address of the host
+  primary_host: "192.168.1.100"
+  fallback_host: "100.64.0.1"
+  timeout: 2s
+hosts:
+  server:
+    fallback: 100.64.0.2
+ssh_host: dev-box
+default_editor: cursor
+`)
+
+	out, warnings, err := RewriteLegacyKeys(data)
+	if err != nil {
+		t.Fatalf("RewriteLegacyKeys() error = %v", err)
+	}
+	if len(warnings) != 3 {
+		t.Fatalf("warnings = %v, want 3", warnings)
+	}
+	if w := warnings[1]; w.Field != "network.fallback_host" || !strings.Contains(w.Message, "already set") {
+		t.Errorf("warning for a replacement that is already set = %+v", w)
+	}
+
+	cfg, err := parseClientConfig(out)
+	if err != nil {
+		t.Fatalf("rewritten config does not parse: %v\n%s", err, out)
+	}
+	if cfg.Hosts.Server.Primary != "192.168.1.100" || cfg.Hosts.Server.Fallback != "100.64.0.2" || cfg.Hosts.SSH.Host != "dev-box" {
+		t.Errorf("hosts = %+v, want the legacy values moved and the existing fallback kept", cfg.Hosts)
+	}
+	if cfg.Network.Timeout.String() != "2s" || cfg.DefaultEditor != "cursor" {
+		t.Errorf("other settings changed: %+v, %q", cfg.Network, cfg.DefaultEditor)
+	}
+	for _, want := range []string{"# rcode client", "# LAN address of the host"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("rewritten config lost comment %q\n%s", want, out)
+		}
+	}
+	for _, legacy := range []string{"primary_host", "fallback_host", "ssh_host"} {
+		if strings.Contains(string(out), legacy) {
+			t.Errorf("rewritten config still has %s\n%s", legacy, out)
+		}
+	}
+
+	// Files without legacy keys are left alone
+	current := []byte("hosts:\n  server:\n    primary: 10.0.0.1\n")
+	if out, warnings, err := RewriteLegacyKeys(current); err != nil || len(warnings) != 0 || string(out) != string(current) {
+		t.Errorf("RewriteLegacyKeys() of a current config = %q, %v, %v", out, warnings, err)
+	}
+}
+
+func TestMigrateLegacyConfigFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	original := "network:\n  primary_host: 10.0.0.1\n"
+	if err := os.WriteFile(path, []byte(original), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := MigrateLegacyConfigFile(path, true)
+	if err != nil {
+		t.Fatalf("MigrateLegacyConfigFile(dry run) error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != original || result.BackupPath != "" {
+		t.Errorf("dry run wrote the file or a backup: %q, %q", data, result.BackupPath)
+	}
+
+	result, err = MigrateLegacyConfigFile(path, false)
+	if err != nil {
+		t.Fatalf("MigrateLegacyConfigFile() error = %v", err)
+	}
+	if backup, _ := os.ReadFile(result.BackupPath); string(backup) != original {
+		t.Errorf("backup = %q, want the original", backup)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "hosts:\n  server:\n    primary: 10.0.0.1\n" {
+		t.Errorf("migrated file = %q", data)
+	}
+
+	// A migrated file is not migrated again
+	if result, err := MigrateLegacyConfigFile(path, false); err != nil || len(result.Warnings) != 0 || result.BackupPath != "" {
+		t.Errorf("second migration = %+v, %v, want nothing to do", result, err)
+	}
+}
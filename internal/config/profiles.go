@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ProfileNames returns the names of the configured profiles, sorted
+func (c *ClientConfig) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyProfile overlays the named profile on the top-level settings. An
+// empty name leaves the configuration unchanged.
+func (c *ClientConfig) ApplyProfile(name string) error {
+	if name == "" {
+		return nil
+	}
+	profile, ok := c.Profiles[name]
+	if !ok {
+		if len(c.Profiles) == 0 {
+			return fmt.Errorf("unknown profile %q: no profiles are configured", name)
+		}
+		return fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(c.ProfileNames(), ", "))
+	}
+
+	server := &c.Hosts.Server
+	for _, field := range []struct {
+		dst *string
+		src string
+	}{
+		{&server.Primary, profile.Server.Primary},
+		{&server.Fallback, profile.Server.Fallback},
+		{&server.AuthToken, profile.Server.AuthToken},
+		{&server.SigningKey, profile.Server.SigningKey},
+		{&server.Broker, profile.Server.Broker},
+		{&server.Tunnel, profile.Server.Tunnel},
+		{&c.Hosts.SSH.Host, profile.SSH.Host},
+		{&c.DefaultEditor, profile.DefaultEditor},
+	} {
+		if field.src != "" {
+			*field.dst = field.src
+		}
+	}
+	if profile.Server.Port != 0 {
+		server.Port = profile.Server.Port
+	}
+	if profile.Server.TLS != (ClientTLSConfig{}) {
+		server.TLS = profile.Server.TLS
+	}
+	if !reflect.DeepEqual(profile.SSH.AutoDetect, AutoDetectConfig{}) {
+		c.Hosts.SSH.AutoDetect = profile.SSH.AutoDetect
+	}
+	return nil
+}
+
+// MatchProfile returns the first profile, in name order, whose match rules
+// accept this machine's SSH server IP and hostname, or "" when none does
+func (c *ClientConfig) MatchProfile(serverIP, hostname string) string {
+	for _, name := range c.ProfileNames() {
+		if c.Profiles[name].Match.matches(serverIP, hostname) {
+			return name
+		}
+	}
+	return ""
+}
+
+// matches reports whether every rule that is set accepts serverIP and hostname
+func (m ProfileMatch) matches(serverIP, hostname string) bool {
+	if len(m.ServerIP) == 0 && m.Hostname == "" {
+		return false
+	}
+
+	if len(m.ServerIP) > 0 {
+		ip := net.ParseIP(serverIP)
+		if ip == nil {
+			return false
+		}
+		found := false
+		for _, cidr := range m.ServerIP {
+			if network, err := parseCIDROrIP(cidr); err == nil && network.Contains(ip) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if m.Hostname != "" {
+		re, err := regexp.Compile(m.Hostname)
+		if err != nil || !re.MatchString(hostname) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseCIDROrIP parses a CIDR, or a single address as a network of its own
+func parseCIDROrIP(s string) (*net.IPNet, error) {
+	if _, network, err := net.ParseCIDR(s); err == nil {
+		return network, nil
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid CIDR or IP address %q", s)
+	}
+	bits := 8 * net.IPv6len
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, bits = ip4, 8*net.IPv4len
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyProfile(t *testing.T) {
+	data := []byte(`hosts:
+  server:
+    primary: 192.168.1.100
+    fallback: 100.64.0.1
+  ssh:
+    host: ws01
+default_editor: cursor
+profiles:
+  office:
+    server:
+      primary: 10.0.0.5
+      port: 4000
+    ssh:
+      host: office-ws
+    default_editor: zed
+  home:
+    ssh:
+      auto_detect:
+        tailscale: true
+`)
+	load := func() *ClientConfig {
+		cfg, err := parseClientConfig(data)
+		if err != nil {
+			t.Fatalf("parseClientConfig() error = %v", err)
+		}
+		return cfg
+	}
+
+	if got := strings.Join(load().ProfileNames(), ","); got != "home,office" {
+		t.Errorf("ProfileNames() = %s, want home,office", got)
+	}
+
+	cfg := load()
+	if err := cfg.ApplyProfile("office"); err != nil {
+		t.Fatalf("ApplyProfile(office) error = %v", err)
+	}
+	if cfg.Hosts.Server.Primary != "10.0.0.5" || cfg.Hosts.Server.Port != 4000 {
+		t.Errorf("server = %s port %d, want 10.0.0.5 port 4000", cfg.Hosts.Server.Primary, cfg.Hosts.Server.Port)
+	}
+	if cfg.Hosts.Server.Fallback != "100.64.0.1" {
+		t.Errorf("fallback = %q, want the top-level fallback kept", cfg.Hosts.Server.Fallback)
+	}
+	if cfg.Hosts.SSH.Host != "office-ws" || cfg.DefaultEditor != "zed" {
+		t.Errorf("ssh host = %q, editor = %q, want office-ws, zed", cfg.Hosts.SSH.Host, cfg.DefaultEditor)
+	}
+
+	cfg = load()
+	if err := cfg.ApplyProfile("home"); err != nil {
+		t.Fatalf("ApplyProfile(home) error = %v", err)
+	}
+	if !cfg.Hosts.SSH.AutoDetect.Tailscale || cfg.Hosts.SSH.Host != "ws01" || cfg.DefaultEditor != "cursor" {
+		t.Errorf("home profile = %+v, editor %q", cfg.Hosts.SSH, cfg.DefaultEditor)
+	}
+
+	cfg = load()
+	if err := cfg.ApplyProfile(""); err != nil || cfg.Hosts.Server.Primary != "192.168.1.100" {
+		t.Errorf("ApplyProfile(\"\") = %v, primary %q, want no change", err, cfg.Hosts.Server.Primary)
+	}
+
+	err := cfg.ApplyProfile("cafe")
+	if err == nil || !strings.Contains(err.Error(), "available: home, office") {
+		t.Errorf("ApplyProfile(cafe) error = %v, want the available profiles listed", err)
+	}
+}
+
+func TestMatchProfile(t *testing.T) {
+	cfg := &ClientConfig{
+		Profiles: map[string]ProfileConfig{
+			"office": {Match: ProfileMatch{ServerIP: []string{"10.0.0.0/24", "172.16.5.9"}}},
+			"lab":    {Match: ProfileMatch{ServerIP: []string{"10.0.0.0/8"}, Hostname: `^lab-\d+$`}},
+			"home":   {Match: ProfileMatch{Hostname: "^home-"}},
+			"manual": {},
+		},
+	}
+
+	tests := []struct {
+		serverIP string
+		hostname string
+		want     string
+	}{
+		{"10.0.0.7", "devbox", "office"},
+		{"172.16.5.9", "devbox", "office"},
+		{"10.1.2.3", "lab-04", "lab"},
+		{"10.1.2.3", "devbox", ""},          // lab needs both rules
+		{"", "home-pc", "home"},             // no SSH session
+		{"10.0.0.7", "home-pc", "home"},     // name order decides between matches
+		{"192.168.1.20", "workstation", ""}, // nothing matches
+		{"not-an-ip", "workstation", ""},
+	}
+	for _, tt := range tests {
+		if got := cfg.MatchProfile(tt.serverIP, tt.hostname); got != tt.want {
+			t.Errorf("MatchProfile(%q, %q) = %q, want %q", tt.serverIP, tt.hostname, got, tt.want)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
 	"time"
 )
 
@@ -12,37 +14,356 @@ const (
 	EditorTypeCommand EditorType = "command"
 	// EditorTypeBrowser opens a browser-based editor URL.
 	EditorTypeBrowser EditorType = "browser"
+	// EditorTypeTmux runs the command in a new window of a tmux session.
+	EditorTypeTmux EditorType = "tmux"
+)
+
+// ExecMode selects how a command editor's rendered command is executed
+type ExecMode string
+
+const (
+	// ExecModeArgv splits the command into arguments and runs it directly (default).
+	ExecModeArgv ExecMode = "argv"
+	// ExecModeShell runs the command with sh -c, so quotes, pipes and variables work.
+	ExecModeShell ExecMode = "sh"
+	// ExecModeLoginShell runs the command with $SHELL -lc, picking up the user's login environment.
+	ExecModeLoginShell ExecMode = "login"
 )
 
 // EditorConfig represents configuration for a single editor
 type EditorConfig struct {
 	Name      string     `yaml:"name" json:"name"`                           // Editor name (e.g., "cursor", "vscode")
-	Type      EditorType `yaml:"type,omitempty" json:"type,omitempty"`       // Editor type: command (default) or browser
+	Type      EditorType `yaml:"type,omitempty" json:"type,omitempty"`       // Editor type: command (default), browser or tmux
 	Command   string     `yaml:"command,omitempty" json:"command,omitempty"` // Command template with placeholders (for command type)
 	URL       string     `yaml:"url,omitempty" json:"url,omitempty"`         // URL template with placeholders (for browser type)
 	Default   bool       `yaml:"default" json:"default"`                     // Whether this is the default editor
 	Available bool       `yaml:"available" json:"available"`                 // Whether the editor is available on the system
+	Aliases   []string   `yaml:"aliases,omitempty" json:"aliases,omitempty"` // Other names the editor can be requested by (e.g., "code", "c")
+
+	Variables map[string]string `yaml:"variables,omitempty" json:"variables,omitempty"` // Values for user-defined template placeholders (e.g., {port})
+
+	ExecMode    ExecMode      `yaml:"exec_mode,omitempty" json:"exec_mode,omitempty"`                 // How the command is run: argv (default), sh or login
+	WaitForExit bool          `yaml:"wait_for_exit,omitempty" json:"wait_for_exit,omitempty"`         // Wait for the command to exit and fail on a non-zero status
+	Timeout     time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`                     // Exit wait limit, or how long to watch a detached launch for early failure
+	Retries     int           `yaml:"retries,omitempty" json:"retries,omitempty"`                     // Extra attempts when the command fails to start or exits with an error
+	CaptureKB   int           `yaml:"capture_output_kb,omitempty" json:"capture_output_kb,omitempty"` // KB of stdout/stderr returned when a watched command fails
+	WorkDir     string        `yaml:"workdir,omitempty" json:"workdir,omitempty"`                     // Working directory template for the command (e.g., "{path|dirname}")
+	Activate    string        `yaml:"activate,omitempty" json:"activate,omitempty"`                   // App name (macOS) or window class (Linux) to bring to the front after launch
+
+	Availability AvailabilityConfig `yaml:"availability,omitempty" json:"availability,omitempty"` // Extra ways to find the editor when its command is not on PATH
+
+	Session string `yaml:"session,omitempty" json:"session,omitempty"` // tmux session new windows open in (tmux type); default: the attached session
+	Diff    string `yaml:"diff,omitempty" json:"diff,omitempty"`       // Command template comparing {path} with {path2}, for rcode diff
+	Sync    bool   `yaml:"sync,omitempty" json:"sync,omitempty"`       // Open a local copy pulled over scp and push saves back, for editors without SSH support
+}
+
+// AvailabilityConfig tells how to recognise an installed editor whose
+// command is not on PATH, such as a macOS app without its CLI linked. Any
+// one of the probes succeeding marks the editor available. Well-known
+// editors are also looked for in /Applications, Flatpak and Snap.
+type AvailabilityConfig struct {
+	Check   string   `yaml:"check,omitempty" json:"check,omitempty"`     // Command, run without a shell, that exits 0 when the editor is installed
+	Paths   []string `yaml:"paths,omitempty" json:"paths,omitempty"`     // Files or app bundles whose existence means installed (~ is expanded)
+	Flatpak string   `yaml:"flatpak,omitempty" json:"flatpak,omitempty"` // Flatpak application ID (Linux), e.g. com.visualstudio.code
+	Snap    string   `yaml:"snap,omitempty" json:"snap,omitempty"`       // Snap name (Linux), e.g. code
 }
 
 // ServerConfig represents server-specific configuration
 type ServerConfig struct {
-	Host         string        `yaml:"host" json:"host"`                   // Server host to bind to
-	Port         int           `yaml:"port" json:"port"`                   // Server port
-	ReadTimeout  time.Duration `yaml:"read_timeout" json:"read_timeout"`   // HTTP read timeout
-	WriteTimeout time.Duration `yaml:"write_timeout" json:"write_timeout"` // HTTP write timeout
-	IdleTimeout  time.Duration `yaml:"idle_timeout" json:"idle_timeout"`   // HTTP idle timeout
-	AllowedIPs   []string      `yaml:"allowed_ips" json:"allowed_ips"`     // IP whitelist (empty = allow all)
+	Host         string        `yaml:"host" json:"host"`                                               // Server host to bind to
+	Port         int           `yaml:"port" json:"port"`                                               // Server port
+	Listen       []string      `yaml:"listen,omitempty" json:"listen,omitempty"`                       // Addresses to bind to, host or host:port, instead of host (default port: port)
+	Interfaces   []string      `yaml:"listen_interfaces,omitempty" json:"listen_interfaces,omitempty"` // Bind to every address of these network interfaces (e.g. tailscale0) at port
+	ReadTimeout  time.Duration `yaml:"read_timeout" json:"read_timeout"`                               // HTTP read timeout
+	WriteTimeout time.Duration `yaml:"write_timeout" json:"write_timeout"`                             // HTTP write timeout
+	IdleTimeout  time.Duration `yaml:"idle_timeout" json:"idle_timeout"`                               // HTTP idle timeout
+	AllowedIPs   []string      `yaml:"allowed_ips" json:"allowed_ips"`                                 // IP whitelist (empty = allow all)
+	AllowedPaths []string      `yaml:"allowed_paths,omitempty" json:"allowed_paths,omitempty"`         // Allowed path roots and glob patterns (empty = allow all)
+	AuthToken    string        `yaml:"auth_token,omitempty" json:"-"`                                  // Shared bearer token (empty = no auth)
+	Broker       string        `yaml:"broker,omitempty" json:"broker,omitempty"`                       // Broker WebSocket URL for reverse connections (e.g., ws://remote:3340)
+	SessionsFile string        `yaml:"sessions_file,omitempty" json:"sessions_file,omitempty"`         // Recent sessions store (default: ~/.local/share/rcode/sessions.json)
+	MaxSessions  int           `yaml:"max_sessions,omitempty" json:"max_sessions,omitempty"`           // Number of recent sessions kept (default: 100)
+	MaxBodyKB    int           `yaml:"max_body_kb,omitempty" json:"max_body_kb,omitempty"`             // Largest accepted open/render/admin request body in KB (default: 64)
+
+	Clipboard ClipboardConfig `yaml:"clipboard,omitempty" json:"clipboard,omitempty"`   // Remote-to-host clipboard bridge (disabled by default)
+	OpenURL   OpenURLConfig   `yaml:"open_url,omitempty" json:"open_url,omitempty"`     // URLs clients may open in the host browser (disabled by default)
+	RateLimit RateLimitConfig `yaml:"rate_limit,omitempty" json:"rate_limit,omitempty"` // Per-client request rate limit (disabled by default)
+	Launch    LaunchConfig    `yaml:"launch,omitempty" json:"launch,omitempty"`         // How editor launches are queued and coalesced
+	Sync      SyncConfig      `yaml:"sync,omitempty" json:"sync,omitempty"`             // Local copies for editors with sync enabled
+	PathCheck PathCheckConfig `yaml:"path_check,omitempty" json:"path_check,omitempty"` // Look up requested paths on this machine (disabled by default)
+	Create    CreateConfig    `yaml:"create,omitempty" json:"create,omitempty"`         // Create missing paths for rcode --create (disabled by default)
+
+	EditorFallback []string `yaml:"editor_fallback,omitempty" json:"editor_fallback,omitempty"` // Editors tried in order when the requested one fails to launch
+
+	TLS     ServerTLSConfig `yaml:"tls,omitempty" json:"tls,omitempty"`         // Serve HTTPS, optionally requiring client certificates
+	Signing SigningConfig   `yaml:"signing,omitempty" json:"signing,omitempty"` // Require HMAC-signed POST requests
+}
+
+// SigningConfig makes the server require POST requests, such as open
+// requests, to be signed with one of Keys, the HMAC keys shared with the
+// clients. Listing several keys rotates them: add the new key, move the
+// clients over, then remove the old one. Requests signed more than MaxAge
+// ago, or already seen, are rejected.
+type SigningConfig struct {
+	Keys   []string      `yaml:"keys,omitempty" json:"-"`                    // Accepted signing keys (empty = signatures not required)
+	MaxAge time.Duration `yaml:"max_age,omitempty" json:"max_age,omitempty"` // Largest accepted clock difference (default: 5m)
+}
+
+// Enabled reports whether requests must be signed
+func (c SigningConfig) Enabled() bool {
+	return len(c.Keys) > 0
+}
+
+// Window returns how far a signature's timestamp may be from the server's
+// clock, with the default for an unset value
+func (c SigningConfig) Window() time.Duration {
+	if c.MaxAge <= 0 {
+		return DefaultSignatureMaxAge
+	}
+	return c.MaxAge
+}
+
+// ServerTLSConfig serves the API over HTTPS when CertFile and KeyFile are
+// set. With ClientCAFile also set, clients must present a certificate signed
+// by a CA in it (mutual TLS). rcode-server gen-certs creates all three.
+type ServerTLSConfig struct {
+	CertFile     string `yaml:"cert_file,omitempty" json:"cert_file,omitempty"`           // Server certificate (PEM)
+	KeyFile      string `yaml:"key_file,omitempty" json:"key_file,omitempty"`             // Server private key (PEM)
+	ClientCAFile string `yaml:"client_ca_file,omitempty" json:"client_ca_file,omitempty"` // CAs that client certificates must be signed by (empty = no client certificates)
+}
+
+// Enabled reports whether the server serves HTTPS
+func (c ServerTLSConfig) Enabled() bool {
+	return c.CertFile != ""
+}
+
+// RateLimitConfig limits how often each client IP may call the server. Each
+// IP has a token bucket refilled at RequestsPerMinute and holding up to Burst
+// requests.
+type RateLimitConfig struct {
+	Enabled           bool `yaml:"enabled" json:"enabled"`                                             // Reject clients over the limit with 429
+	RequestsPerMinute int  `yaml:"requests_per_minute,omitempty" json:"requests_per_minute,omitempty"` // Sustained rate per client IP (default: 60)
+	Burst             int  `yaml:"burst,omitempty" json:"burst,omitempty"`                             // Requests allowed at once (default: 10)
+}
+
+// Limits returns the sustained rate and burst, with defaults for unset values
+func (c RateLimitConfig) Limits() (perMinute, burst int) {
+	perMinute, burst = c.RequestsPerMinute, c.Burst
+	if perMinute <= 0 {
+		perMinute = DefaultRateLimitPerMinute
+	}
+	if burst <= 0 {
+		burst = DefaultRateLimitBurst
+	}
+	return perMinute, burst
+}
+
+// LaunchConfig controls how editor commands are launched. Identical open
+// requests arriving within DedupWindow of each other launch the editor once,
+// and at most Workers commands launch at a time. Up to MaxQueued more wait
+// their turn; launches beyond that are refused with 503. A retried request
+// with the Idempotency-Key of one that launched within IdempotencyWindow
+// gets the original response.
+type LaunchConfig struct {
+	DedupWindow       time.Duration `yaml:"dedup_window,omitempty" json:"dedup_window,omitempty"`             // Coalesce identical requests within this window (0 = off)
+	Workers           int           `yaml:"workers,omitempty" json:"workers,omitempty"`                       // Editor commands launched at once (default: 4)
+	MaxQueued         int           `yaml:"max_queued,omitempty" json:"max_queued,omitempty"`                 // Launches waiting for a worker (default: 16)
+	IdempotencyWindow time.Duration `yaml:"idempotency_window,omitempty" json:"idempotency_window,omitempty"` // How long responses are kept for retries with the same Idempotency-Key (default: 10m)
+}
+
+// IdempotencyTTL returns how long responses are kept for retried requests,
+// with the default for an unset value
+func (c LaunchConfig) IdempotencyTTL() time.Duration {
+	if c.IdempotencyWindow <= 0 {
+		return DefaultIdempotencyWindow
+	}
+	return c.IdempotencyWindow
+}
+
+// WorkerCount returns the number of launch workers, with the default for an
+// unset value
+func (c LaunchConfig) WorkerCount() int {
+	if c.Workers <= 0 {
+		return DefaultLaunchWorkers
+	}
+	return c.Workers
+}
+
+// QueueSize returns how many launches may wait for a worker, with the
+// default for an unset value
+func (c LaunchConfig) QueueSize() int {
+	if c.MaxQueued <= 0 {
+		return DefaultLaunchQueue
+	}
+	return c.MaxQueued
+}
+
+// SyncConfig controls the local copies made for editors with sync enabled:
+// each file is pulled with scp into Dir, checked for saves every
+// PollInterval and pushed back when saved. Copies left untouched for
+// IdleTimeout are removed.
+type SyncConfig struct {
+	Dir          string        `yaml:"dir,omitempty" json:"dir,omitempty"`                     // Where local copies are kept (default: rcode-sync in the temp directory)
+	PollInterval time.Duration `yaml:"poll_interval,omitempty" json:"poll_interval,omitempty"` // How often copies are checked for saves (default: 1s)
+	IdleTimeout  time.Duration `yaml:"idle_timeout,omitempty" json:"idle_timeout,omitempty"`   // How long an unchanged copy is kept (default: 30m)
+}
+
+// Directory returns where local copies are kept, with the default for an
+// unset value
+func (c SyncConfig) Directory() string {
+	if c.Dir == "" {
+		return filepath.Join(os.TempDir(), "rcode-sync")
+	}
+	return c.Dir
+}
+
+// Interval returns how often copies are checked for saves, with the default
+// for an unset value
+func (c SyncConfig) Interval() time.Duration {
+	if c.PollInterval <= 0 {
+		return DefaultSyncPollInterval
+	}
+	return c.PollInterval
+}
+
+// Idle returns how long an unchanged copy is kept, with the default for an
+// unset value
+func (c SyncConfig) Idle() time.Duration {
+	if c.IdleTimeout <= 0 {
+		return DefaultSyncIdleTimeout
+	}
+	return c.IdleTimeout
+}
+
+// MaxBodyBytes returns the largest accepted open or render request body in bytes
+func (c ServerConfig) MaxBodyBytes() int64 {
+	if c.MaxBodyKB <= 0 {
+		return DefaultMaxBodyKB * 1024
+	}
+	return int64(c.MaxBodyKB) * 1024
+}
+
+// OpenURLConfig controls the /open-url endpoint, which opens URLs in the
+// host's default browser
+type OpenURLConfig struct {
+	Allowed []string `yaml:"allowed,omitempty" json:"allowed,omitempty"` // "scheme://host" patterns (e.g., "https://*.github.com"); empty = disabled
+}
+
+// PathCheckConfig makes the server look up requested paths on its own
+// machine. That only makes sense when it sees the same files as the
+// clients, as on one machine or through a shared mount; the paths are
+// otherwise those of the remote machine.
+type PathCheckConfig struct {
+	Stat            bool `yaml:"stat,omitempty" json:"stat,omitempty"`                         // Report whether each path exists, is a directory or a symlink in open responses
+	RequireExisting bool `yaml:"require_existing,omitempty" json:"require_existing,omitempty"` // Refuse to open paths that do not exist (implies stat)
+}
+
+// Enabled reports whether requested paths are looked up
+func (c PathCheckConfig) Enabled() bool {
+	return c.Stat || c.RequireExisting
+}
+
+// CreateConfig lets open requests made with rcode --create create their
+// missing paths on the server's machine. Like path_check, that only makes
+// sense when the server sees the same files as the clients. Paths are only
+// created under allowed_paths, which must be set.
+type CreateConfig struct {
+	Enabled     bool `yaml:"enabled" json:"enabled"` // Create missing paths when asked to
+	CreateModes `yaml:",inline"`
+}
+
+// CreateModes are the permissions, before the umask, of the files and
+// directories created for rcode --create
+type CreateModes struct {
+	FileMode os.FileMode `yaml:"file_mode,omitempty" json:"file_mode,omitempty"` // Mode of created files (default: 0644)
+	DirMode  os.FileMode `yaml:"dir_mode,omitempty" json:"dir_mode,omitempty"`   // Mode of created directories, missing parents included (default: 0755)
+}
+
+// File returns the mode of created files, with the default for an unset one
+func (m CreateModes) File() os.FileMode {
+	if m.FileMode == 0 {
+		return DefaultCreateFileMode
+	}
+	return m.FileMode
+}
+
+// Dir returns the mode of created directories, with the default for an
+// unset one
+func (m CreateModes) Dir() os.FileMode {
+	if m.DirMode == 0 {
+		return DefaultCreateDirMode
+	}
+	return m.DirMode
+}
+
+// ClipboardConfig controls the /clipboard endpoint, which lets clients write
+// to the host clipboard
+type ClipboardConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`                   // Accept clipboard writes
+	MaxKB   int  `yaml:"max_kb,omitempty" json:"max_kb,omitempty"` // Largest accepted text in KB (default: 1024)
+}
+
+// MaxBytes returns the largest accepted clipboard payload in bytes
+func (c ClipboardConfig) MaxBytes() int64 {
+	if c.MaxKB <= 0 {
+		return DefaultClipboardMaxKB * 1024
+	}
+	return int64(c.MaxKB) * 1024
 }
 
 // LogConfig represents logging configuration
 type LogConfig struct {
-	Level      string `yaml:"level" json:"level"`             // Log level (debug, info, warn, error)
-	File       string `yaml:"file" json:"file"`               // Log file path
-	MaxSize    int    `yaml:"max_size" json:"max_size"`       // Max size in MB before rotation
-	MaxBackups int    `yaml:"max_backups" json:"max_backups"` // Max number of old log files
-	MaxAge     int    `yaml:"max_age" json:"max_age"`         // Max age in days
-	Compress   bool   `yaml:"compress" json:"compress"`       // Whether to compress old logs
-	Console    bool   `yaml:"console" json:"console"`         // Whether to also log to console
+	Level      string `yaml:"level" json:"level"`                               // Log level (debug, info, warn, error)
+	File       string `yaml:"file" json:"file"`                                 // Log file path
+	MaxSize    int    `yaml:"max_size" json:"max_size"`                         // Max size in MB before rotation
+	MaxBackups int    `yaml:"max_backups" json:"max_backups"`                   // Max number of old log files
+	MaxAge     int    `yaml:"max_age" json:"max_age"`                           // Max age in days
+	Compress   bool   `yaml:"compress" json:"compress"`                         // Whether to compress old logs
+	Console    bool   `yaml:"console" json:"console"`                           // Whether to also log to console
+	Format     string `yaml:"format,omitempty" json:"format,omitempty"`         // Log format: text (default), json, or ecs (JSON with Elastic Common Schema field names)
+	Rotate     string `yaml:"rotate,omitempty" json:"rotate,omitempty"`         // Also rotate when the day or week changes: daily or weekly (default: on size only)
+	DateNames  bool   `yaml:"date_names,omitempty" json:"date_names,omitempty"` // Name rotated files after their day, e.g. server-2024-05-01.log
+
+	// Dedup collapses identical records of a level logged within its window
+	// into one and a "message repeated N times" line, e.g. {warn: 1m}
+	Dedup map[string]time.Duration `yaml:"dedup,omitempty" json:"dedup,omitempty"`
+}
+
+// IsZero reports whether no logging setting is made
+func (l LogConfig) IsZero() bool {
+	return l.Level == "" && l.File == "" && l.MaxSize == 0 && l.MaxBackups == 0 && l.MaxAge == 0 &&
+		!l.Compress && !l.Console && l.Format == "" && l.Rotate == "" && !l.DateNames && len(l.Dedup) == 0
+}
+
+// AuditConfig represents the audit log configuration. The audit log is
+// separate from the application log and is only written when File is set.
+type AuditConfig struct {
+	File       string `yaml:"file,omitempty" json:"file,omitempty"`               // Audit log path (empty = disabled)
+	MaxSize    int    `yaml:"max_size,omitempty" json:"max_size,omitempty"`       // Max size in MB before rotation
+	MaxBackups int    `yaml:"max_backups,omitempty" json:"max_backups,omitempty"` // Max number of old audit files
+	MaxAge     int    `yaml:"max_age,omitempty" json:"max_age,omitempty"`         // Max age in days
+	Compress   bool   `yaml:"compress,omitempty" json:"compress,omitempty"`       // Whether to compress old audit files
+}
+
+// UserConfig holds the preferences of one user on a shared server, keyed
+// by the user name clients send with their requests
+type UserConfig struct {
+	DefaultEditor string `yaml:"default_editor,omitempty" json:"default_editor,omitempty"` // Editor for requests that name none
+}
+
+// TelemetryConfig configures OpenTelemetry trace and metric export over
+// OTLP/HTTP. Nothing is exported unless Enabled is set.
+type TelemetryConfig struct {
+	Enabled     bool              `yaml:"enabled" json:"enabled"`                                     // Whether to export traces and metrics
+	Endpoint    string            `yaml:"endpoint,omitempty" json:"endpoint,omitempty"`               // OTLP/HTTP collector URL (default $OTEL_EXPORTER_OTLP_ENDPOINT or http://localhost:4318)
+	Headers     map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`                 // Extra headers sent to the collector, e.g. for authentication
+	ServiceName string            `yaml:"service_name,omitempty" json:"service_name,omitempty"`       // service.name resource attribute (default rcode or rcode-server)
+	Interval    time.Duration     `yaml:"export_interval,omitempty" json:"export_interval,omitempty"` // How often buffered data is exported (default 10s)
+}
+
+// IsZero reports whether no telemetry setting is made
+func (t TelemetryConfig) IsZero() bool {
+	return !t.Enabled && t.Endpoint == "" && len(t.Headers) == 0 && t.ServiceName == "" && t.Interval == 0
 }
 
 // HostsConfig represents the new unified host configuration.
@@ -53,8 +374,31 @@ type HostsConfig struct {
 
 // ServerHostConfig represents server connection configuration.
 type ServerHostConfig struct {
-	Primary  string `yaml:"primary" json:"primary"`   // Primary server host (e.g., LAN IP)
-	Fallback string `yaml:"fallback" json:"fallback"` // Fallback server host (e.g., Tailscale IP)
+	Primary   string `yaml:"primary" json:"primary"`                   // Primary server host (e.g., LAN IP)
+	Fallback  string `yaml:"fallback" json:"fallback"`                 // Fallback server host (e.g., Tailscale IP)
+	Port      int    `yaml:"port,omitempty" json:"port,omitempty"`     // Server port for hosts given without one
+	AuthToken string `yaml:"auth_token,omitempty" json:"-"`            // Bearer token sent to the server
+	Broker    string `yaml:"broker,omitempty" json:"broker,omitempty"` // Broker host:port used when no direct route exists
+	Tunnel    string `yaml:"tunnel,omitempty" json:"tunnel,omitempty"` // Local end of an SSH reverse tunnel (e.g., localhost:3339)
+
+	SigningKey string `yaml:"signing_key,omitempty" json:"-"` // HMAC key requests are signed with (see server.signing)
+
+	TLS ClientTLSConfig `yaml:"tls,omitempty" json:"tls,omitempty"` // Connect over HTTPS, optionally with a client certificate
+}
+
+// ClientTLSConfig makes the client connect to the server hosts over HTTPS
+// when CAFile or CertFile is set. The broker, which relays plain HTTP, is
+// still reached without TLS.
+type ClientTLSConfig struct {
+	CAFile     string `yaml:"ca_file,omitempty" json:"ca_file,omitempty"`         // CAs the server certificate must be signed by (empty = system roots)
+	CertFile   string `yaml:"cert_file,omitempty" json:"cert_file,omitempty"`     // Client certificate for mutual TLS (PEM)
+	KeyFile    string `yaml:"key_file,omitempty" json:"key_file,omitempty"`       // Client private key (PEM)
+	ServerName string `yaml:"server_name,omitempty" json:"server_name,omitempty"` // Name to verify the server certificate against (default: the host dialed)
+}
+
+// Enabled reports whether the client connects over HTTPS
+func (c ClientTLSConfig) Enabled() bool {
+	return c.CAFile != "" || c.CertFile != ""
 }
 
 // SSHHostConfig represents SSH host configuration for editor connections.
@@ -65,61 +409,195 @@ type SSHHostConfig struct {
 
 // AutoDetectConfig represents auto-detection settings.
 type AutoDetectConfig struct {
-	Tailscale        bool   `yaml:"tailscale" json:"tailscale"`                                     // Enable Tailscale auto-detection
-	TailscalePattern string `yaml:"tailscale_pattern,omitempty" json:"tailscale_pattern,omitempty"` // Pattern for Tailscale hostname
+	Tailscale        bool              `yaml:"tailscale" json:"tailscale"`                                     // Enable Tailscale auto-detection
+	TailscalePattern string            `yaml:"tailscale_pattern,omitempty" json:"tailscale_pattern,omitempty"` // Pattern for Tailscale hostname
+	TailscaleSocket  string            `yaml:"tailscale_socket,omitempty" json:"tailscale_socket,omitempty"`   // tailscaled LocalAPI socket queried before the tailscale CLI
+	SSHConfig        bool              `yaml:"ssh_config,omitempty" json:"ssh_config,omitempty"`               // Use this machine's alias from ~/.ssh/config
+	ReverseDNS       bool              `yaml:"reverse_dns,omitempty" json:"reverse_dns,omitempty"`             // Name the SSH client IP by reverse DNS or mDNS
+	VPN              []VPNDetectConfig `yaml:"vpn,omitempty" json:"vpn,omitempty"`                             // Other VPNs to detect connections through
+}
+
+// VPNType names a VPN whose interfaces rcode can detect
+type VPNType string
+
+const (
+	// VPNWireGuard detects plain WireGuard interfaces (wg*).
+	VPNWireGuard VPNType = "wireguard"
+	// VPNZeroTier detects ZeroTier interfaces (zt*, 10.147.0.0/16).
+	VPNZeroTier VPNType = "zerotier"
+	// VPNNetbird detects Netbird interfaces (wt*, 100.64.0.0/10).
+	VPNNetbird VPNType = "netbird"
+)
+
+// VPNDetectConfig enables detection of one VPN. When the SSH connection came
+// through it, the SSH client's VPN address becomes a server host candidate
+// and Pattern names the SSH host.
+type VPNDetectConfig struct {
+	Type      VPNType `yaml:"type" json:"type"`                               // wireguard, zerotier or netbird
+	Interface string  `yaml:"interface,omitempty" json:"interface,omitempty"` // Interface name prefix, overriding the type's default
+	Pattern   string  `yaml:"pattern,omitempty" json:"pattern,omitempty"`     // SSH host pattern: {ip}, {hostname}, {hostname-} (default {ip})
 }
 
 // FallbackEditorsConfig stores editor command templates for fallback use.
 // Used when the server is unreachable.
 type FallbackEditorsConfig map[string]string
 
+// LocalMode selects when the client launches editors itself instead of
+// sending the request to the server
+type LocalMode string
+
+const (
+	// LocalModeNever always sends requests to the server (default).
+	LocalModeNever LocalMode = "never"
+	// LocalModeAuto launches editors locally when not in an SSH session.
+	LocalModeAuto LocalMode = "auto"
+	// LocalModeAlways always launches editors locally.
+	LocalModeAlways LocalMode = "always"
+)
+
+// HostSelection selects how the client picks a server host
+type HostSelection string
+
+const (
+	// HostSelectionOrdered tries primary, fallback, tunnel and broker in turn (default).
+	HostSelectionOrdered HostSelection = "ordered"
+	// HostSelectionRace probes every host's /health in parallel and uses the
+	// fastest healthy one first.
+	HostSelectionRace HostSelection = "race"
+)
+
 // ClientNetworkConfig represents client network settings (excluding host addresses).
 type ClientNetworkConfig struct {
-	Timeout       time.Duration `yaml:"timeout" json:"timeout"`               // Connection timeout
-	RetryAttempts int           `yaml:"retry_attempts" json:"retry_attempts"` // Number of retry attempts
-	RetryDelay    time.Duration `yaml:"retry_delay" json:"retry_delay"`       // Delay between retries
+	Timeout       time.Duration `yaml:"timeout" json:"timeout"`                                   // Connection timeout
+	RetryAttempts int           `yaml:"retry_attempts" json:"retry_attempts"`                     // Number of retry attempts
+	RetryDelay    time.Duration `yaml:"retry_delay" json:"retry_delay"`                           // Delay between retries
+	HostSelection HostSelection `yaml:"host_selection,omitempty" json:"host_selection,omitempty"` // How to pick a host: ordered (default) or race
+	ProbeTimeout  time.Duration `yaml:"probe_timeout,omitempty" json:"probe_timeout,omitempty"`   // Per-host /health timeout in race mode
+	CacheTTL      time.Duration `yaml:"cache_ttl,omitempty" json:"cache_ttl,omitempty"`           // How long the last good host and editor list are reused; negative disables the cache
+	HTTP2         bool          `yaml:"http2,omitempty" json:"http2,omitempty"`                   // Negotiate HTTP/2 on TLS connections
+	DNSServer     string        `yaml:"dns_server,omitempty" json:"dns_server,omitempty"`         // DNS server (ip or ip:port) to resolve server hosts with instead of the system resolver
 }
 
 // ClientConfig represents client-specific configuration.
 // Note: Editor definitions are centralized on the server. The client only stores
 // the name of the default editor to use, not the command templates.
 type ClientConfig struct {
-	Hosts           HostsConfig           `yaml:"hosts" json:"hosts"`                                           // Host configuration (server + SSH)
-	Network         ClientNetworkConfig   `yaml:"network" json:"network"`                                       // Network settings (timeout, retry)
-	FallbackEditors FallbackEditorsConfig `yaml:"fallback_editors,omitempty" json:"fallback_editors,omitempty"` // Fallback editor commands
-	DefaultEditor   string                `yaml:"default_editor" json:"default_editor"`                         // Default editor name
-	Logging         LogConfig             `yaml:"logging" json:"logging"`                                       // Logging configuration
+	Extends          []string                 `yaml:"extends,omitempty" json:"extends,omitempty"`                     // Base config files this one overrides, merged by the loader
+	Hosts            HostsConfig              `yaml:"hosts" json:"hosts"`                                             // Host configuration (server + SSH)
+	Network          ClientNetworkConfig      `yaml:"network" json:"network"`                                         // Network settings (timeout, retry)
+	FallbackEditors  FallbackEditorsConfig    `yaml:"fallback_editors,omitempty" json:"fallback_editors,omitempty"`   // Fallback editor commands
+	LocalMode        LocalMode                `yaml:"local_mode,omitempty" json:"local_mode,omitempty"`               // When to launch editors locally: never (default), auto or always
+	LocalEditors     map[string]string        `yaml:"local_editors,omitempty" json:"local_editors,omitempty"`         // Local editor commands, overriding the built-in ones
+	DefaultEditor    string                   `yaml:"default_editor" json:"default_editor"`                           // Default editor name
+	Logging          LogConfig                `yaml:"logging" json:"logging"`                                         // Logging configuration
+	Profiles         map[string]ProfileConfig `yaml:"profiles,omitempty" json:"profiles,omitempty"`                   // Named targets selected with --profile or RCODE_PROFILE
+	Telemetry        TelemetryConfig          `yaml:"telemetry,omitempty" json:"telemetry,omitempty"`                 // OpenTelemetry export
+	UpdateCheck      bool                     `yaml:"update_check,omitempty" json:"update_check,omitempty"`           // Look up the latest release once a day and print an upgrade hint (opt-in)
+	HistoryFile      string                   `yaml:"history_file,omitempty" json:"history_file,omitempty"`           // Paths opened from this machine, for rcode last and rcode history (default: ~/.local/share/rcode/history.json)
+	MaxHistory       int                      `yaml:"max_history,omitempty" json:"max_history,omitempty"`             // Number of history entries kept (default: 100)
+	Bookmarks        map[string]string        `yaml:"bookmarks,omitempty" json:"bookmarks,omitempty"`                 // Named paths, opened with rcode @name
+	QueueFile        string                   `yaml:"queue_file,omitempty" json:"queue_file,omitempty"`               // Opens queued with --queue while the host is unreachable (default: ~/.local/share/rcode/queue.json)
+	HideDeprecations bool                     `yaml:"hide_deprecations,omitempty" json:"hide_deprecations,omitempty"` // Don't print deprecation notices for legacy keys and environment variables; rcode doctor still lists them
+	Stats            bool                     `yaml:"stats,omitempty" json:"stats,omitempty"`                         // Count commands, failures, editors and resolution sources locally for rcode stats (opt-in)
+	StatsFile        string                   `yaml:"stats_file,omitempty" json:"stats_file,omitempty"`               // Where the counts are kept (default: ~/.local/share/rcode/stats.json)
+	HostsOverrides   map[string]string        `yaml:"hosts_overrides,omitempty" json:"hosts_overrides,omitempty"`     // Server host names and the IP addresses they resolve to, like /etc/hosts
+	ProjectsFile     string                   `yaml:"projects_file,omitempty" json:"projects_file,omitempty"`         // Editor last used per project (default: ~/.local/share/rcode/projects.json)
+	ForgetEditors    bool                     `yaml:"forget_editors,omitempty" json:"forget_editors,omitempty"`       // Don't reuse the editor last used for a project; open in default_editor unless --editor is given
+	ResolveSymlinks  bool                     `yaml:"resolve_symlinks,omitempty" json:"resolve_symlinks,omitempty"`   // Send the paths symlinks point to instead of the symlinks
+	Create           CreateModes              `yaml:"create,omitempty" json:"create,omitempty"`                       // Permissions of paths created for --create in local mode
+
+	// Deprecations are the notices for legacy keys and environment
+	// variables found while loading, for the CLI to show
+	Deprecations []MigrationWarning `yaml:"-" json:"-"`
+}
+
+// ProfileConfig describes one target machine. Its settings replace the
+// top-level ones when the profile is selected; empty settings keep them.
+type ProfileConfig struct {
+	Server        ServerHostConfig `yaml:"server,omitempty" json:"server,omitempty"`                 // Server hosts of this target
+	SSH           SSHHostConfig    `yaml:"ssh,omitempty" json:"ssh,omitempty"`                       // SSH host and auto-detection for this target
+	DefaultEditor string           `yaml:"default_editor,omitempty" json:"default_editor,omitempty"` // Default editor for this target
+	Match         ProfileMatch     `yaml:"match,omitempty" json:"match,omitempty"`                   // Rules that select this profile automatically
+}
+
+// ProfileMatch selects a profile when no profile is named. Every rule that is
+// set must match; a profile without rules is never selected automatically.
+type ProfileMatch struct {
+	ServerIP []string `yaml:"server_ip,omitempty" json:"server_ip,omitempty"` // CIDRs or addresses containing the SSH_CONNECTION server IP
+	Hostname string   `yaml:"hostname,omitempty" json:"hostname,omitempty"`   // Regular expression matched against this machine's hostname
 }
 
 // ServerConfigFile represents server configuration file structure
 type ServerConfigFile struct {
-	Server  ServerConfig   `yaml:"server" json:"server"`   // Server configuration
-	Editors []EditorConfig `yaml:"editors" json:"editors"` // Available editors
-	Logging LogConfig      `yaml:"logging" json:"logging"` // Logging configuration
+	Extends   []string        `yaml:"extends,omitempty" json:"extends,omitempty"`     // Base config files this one overrides, merged by the loader
+	Server    ServerConfig    `yaml:"server" json:"server"`                           // Server configuration
+	Editors   []EditorConfig  `yaml:"editors" json:"editors"`                         // Available editors
+	Logging   LogConfig       `yaml:"logging" json:"logging"`                         // Logging configuration
+	Audit     AuditConfig     `yaml:"audit,omitempty" json:"audit,omitempty"`         // Audit log configuration
+	Telemetry TelemetryConfig `yaml:"telemetry,omitempty" json:"telemetry,omitempty"` // OpenTelemetry export
+
+	Users     map[string]UserConfig `yaml:"users,omitempty" json:"users,omitempty"`         // Per-user preferences
+	Terminals []EditorConfig        `yaml:"terminals,omitempty" json:"terminals,omitempty"` // Terminal launchers for rcode terminal (default: the known terminals installed on the host)
 }
 
 // UnifiedConfigFile represents the combined client/server configuration file structure.
 type UnifiedConfigFile struct {
-	Client  ClientConfig   `yaml:"client" json:"client"`
-	Server  ServerConfig   `yaml:"server" json:"server"`
-	Editors []EditorConfig `yaml:"editors" json:"editors"`
-	Logging LogConfig      `yaml:"logging" json:"logging"`
+	Extends   []string        `yaml:"extends,omitempty" json:"extends,omitempty"`
+	Client    ClientConfig    `yaml:"client" json:"client"`
+	Server    ServerConfig    `yaml:"server" json:"server"`
+	Editors   []EditorConfig  `yaml:"editors" json:"editors"`
+	Logging   LogConfig       `yaml:"logging" json:"logging"`
+	Audit     AuditConfig     `yaml:"audit,omitempty" json:"audit,omitempty"`
+	Telemetry TelemetryConfig `yaml:"telemetry,omitempty" json:"telemetry,omitempty"`
+
+	Users     map[string]UserConfig `yaml:"users,omitempty" json:"users,omitempty"`
+	Terminals []EditorConfig        `yaml:"terminals,omitempty" json:"terminals,omitempty"`
 }
 
 // Default configuration values
 const (
-	DefaultServerHost    = "0.0.0.0"
-	DefaultServerPort    = 3339
-	DefaultTimeout       = 2 * time.Second
-	DefaultRetryAttempts = 3
-	DefaultRetryDelay    = 500 * time.Millisecond
-	DefaultLogLevel      = "info"
-	DefaultLogMaxSize    = 10 // MB
-	DefaultLogMaxBackups = 5
-	DefaultLogMaxAge     = 30 // days
-	DefaultReadTimeout   = 10 * time.Second
-	DefaultWriteTimeout  = 10 * time.Second
-	DefaultIdleTimeout   = 120 * time.Second
+	DefaultServerHost     = "127.0.0.1"
+	DefaultServerPort     = 3339
+	DefaultBrokerAddress  = "127.0.0.1:3340"
+	DefaultTimeout        = 2 * time.Second
+	DefaultRetryAttempts  = 3
+	DefaultRetryDelay     = 500 * time.Millisecond
+	DefaultProbeTimeout   = 500 * time.Millisecond
+	DefaultHostCacheTTL   = 5 * time.Minute
+	DefaultLogLevel       = "info"
+	DefaultLogFormat      = "text"
+	DefaultLogMaxSize     = 10 // MB
+	DefaultLogMaxBackups  = 5
+	DefaultLogMaxAge      = 30 // days
+	DefaultReadTimeout    = 10 * time.Second
+	DefaultWriteTimeout   = 10 * time.Second
+	DefaultIdleTimeout    = 120 * time.Second
+	MaxEditorRetries      = 5
+	MaxEditorCaptureKB    = 64
+	DefaultClipboardMaxKB = 1024
+	MaxClipboardKB        = 16 * 1024
+	DefaultMaxBodyKB      = 64
+	MaxBodyKB             = 1024
+
+	DefaultRateLimitPerMinute = 60
+	DefaultRateLimitBurst     = 10
+
+	DefaultLaunchWorkers = 4
+	DefaultLaunchQueue   = 16
+	DefaultDedupWindow   = 2 * time.Second
+
+	DefaultIdempotencyWindow = 10 * time.Minute
+
+	DefaultSignatureMaxAge = 5 * time.Minute
+
+	DefaultCreateFileMode = 0o644
+	DefaultCreateDirMode  = 0o755
+
+	DefaultSyncPollInterval = time.Second
+	DefaultSyncIdleTimeout  = 30 * time.Minute
+
+	DefaultTelemetryEndpoint = "http://localhost:4318"
+	DefaultTelemetryInterval = 10 * time.Second
 )
 
 // GetDefaultEditorName returns the default editor name for client config
@@ -128,3 +606,12 @@ const (
 func (c *ClientConfig) GetDefaultEditorName() string {
 	return c.DefaultEditor
 }
+
+// LocalEditorCommand returns the local command template for editor, preferring
+// local_editors over the built-in templates
+func (c *ClientConfig) LocalEditorCommand(editor string) string {
+	if command, ok := c.LocalEditors[editor]; ok {
+		return command
+	}
+	return GetDefaultLocalEditors()[editor]
+}
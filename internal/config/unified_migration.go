@@ -3,17 +3,23 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
 )
 
 // UnifiedMigrationResult describes the files affected by migration.
 type UnifiedMigrationResult struct {
 	UnifiedPath      string
-	ClientBackupPath string
-	ServerBackupPath string
+	ClientBackupPath string // Empty when nothing was written
+	ServerBackupPath string // Empty when nothing was written
+	Before           []byte // The client config file, empty if it did not exist
+	After            []byte // The unified config file
 }
 
 // MigrateToUnifiedConfig merges client and server config files into a unified config.yaml.
-func MigrateToUnifiedConfig(clientPath, serverPath string) (*UnifiedMigrationResult, error) {
+// With dryRun the merged file is only returned in the result.
+func MigrateToUnifiedConfig(clientPath, serverPath string, dryRun bool) (*UnifiedMigrationResult, error) {
 	paths := GetDefaultPaths()
 	if clientPath == "" {
 		clientPath = paths.ClientConfig
@@ -22,6 +28,12 @@ func MigrateToUnifiedConfig(clientPath, serverPath string) (*UnifiedMigrationRes
 		serverPath = paths.ServerConfig
 	}
 
+	for _, path := range []string{clientPath, serverPath} {
+		if hasSecretRefs(path) {
+			return nil, fmt.Errorf("%s: %w", path, ErrSecretRefs)
+		}
+	}
+
 	clientCfg, clientExists, err := loadClientConfigIfExists(clientPath)
 	if err != nil {
 		return nil, err
@@ -36,6 +48,13 @@ func MigrateToUnifiedConfig(clientPath, serverPath string) (*UnifiedMigrationRes
 		return nil, fmt.Errorf("no config files found to migrate")
 	}
 
+	if clientCfg != nil && len(clientCfg.Extends) > 0 {
+		return nil, fmt.Errorf("%s: %w", clientPath, ErrExtends)
+	}
+	if serverCfg != nil && len(serverCfg.Extends) > 0 {
+		return nil, fmt.Errorf("%s: %w", serverPath, ErrExtends)
+	}
+
 	unified := UnifiedConfigFile{}
 	if clientCfg != nil {
 		unified.Client = *clientCfg
@@ -44,9 +63,25 @@ func MigrateToUnifiedConfig(clientPath, serverPath string) (*UnifiedMigrationRes
 		unified.Server = serverCfg.Server
 		unified.Editors = serverCfg.Editors
 		unified.Logging = serverCfg.Logging
+		unified.Audit = serverCfg.Audit
+		unified.Telemetry = serverCfg.Telemetry
+		unified.Users = serverCfg.Users
+		unified.Terminals = serverCfg.Terminals
 	}
 
-	result := &UnifiedMigrationResult{UnifiedPath: clientPath}
+	after, err := yaml.Marshal(&unified)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	result := &UnifiedMigrationResult{UnifiedPath: clientPath, After: after}
+	if clientExists {
+		if result.Before, err = os.ReadFile(filepath.Clean(clientPath)); err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+	}
+	if dryRun {
+		return result, nil
+	}
 
 	if clientExists {
 		backupPath, backupErr := backupFile(clientPath)
@@ -43,7 +43,7 @@ logging:
 		t.Fatalf("WriteFile(server) error = %v", err)
 	}
 
-	result, err := MigrateToUnifiedConfig(clientPath, serverPath)
+	result, err := MigrateToUnifiedConfig(clientPath, serverPath, false)
 	if err != nil {
 		t.Fatalf("MigrateToUnifiedConfig() error = %v", err)
 	}
@@ -111,7 +111,7 @@ logging:
 		t.Fatalf("WriteFile(server) error = %v", err)
 	}
 
-	result, err := MigrateToUnifiedConfig(clientPath, serverPath)
+	result, err := MigrateToUnifiedConfig(clientPath, serverPath, false)
 	if err != nil {
 		t.Fatalf("MigrateToUnifiedConfig() error = %v", err)
 	}
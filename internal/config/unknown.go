@@ -0,0 +1,263 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StrictKeys makes unknown keys in a config file an error instead of a
+// warning (set by --strict-config)
+var StrictKeys bool
+
+var (
+	// warnedKeys keeps a file loaded several times by one command from
+	// repeating its warnings
+	warnedMu   sync.Mutex
+	warnedKeys = make(map[string]bool)
+
+	yamlNodeType    = reflect.TypeOf(yaml.Node{})
+	unmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+)
+
+// UnknownKey is a key in a config file that no setting reads, usually a typo
+type UnknownKey struct {
+	File       string // Config file the key is in, when known
+	Key        string // Dotted path of the key, e.g. "hosts.server.primry"
+	Line       int    // Line of the key in the file
+	Suggestion string // Known key at the same level it is probably meant to be
+}
+
+// String describes the key and the suggestion, if any
+func (k UnknownKey) String() string {
+	msg := fmt.Sprintf("line %d: unknown key %q", k.Line, k.Key)
+	if k.File != "" {
+		msg = k.File + ": " + msg
+	}
+	if k.Suggestion != "" {
+		msg += fmt.Sprintf(" (did you mean %q?)", k.Suggestion)
+	}
+	return msg
+}
+
+// UnknownKeysError is returned when StrictKeys is set and a config file, or
+// a file it extends, has unknown keys
+type UnknownKeysError struct {
+	Path string
+	Keys []UnknownKey
+}
+
+// Error implements the error interface
+func (e *UnknownKeysError) Error() string {
+	lines := make([]string, len(e.Keys))
+	for i, k := range e.Keys {
+		lines[i] = "  " + k.String()
+	}
+	return fmt.Sprintf("%s has unknown keys:\n%s", e.Path, strings.Join(lines, "\n"))
+}
+
+// fileUnknownKeys returns the keys of the config file at path, holding
+// data, that none of types reads
+func fileUnknownKeys(path string, data []byte, types ...reflect.Type) []UnknownKey {
+	// Syntax errors are reported by the decoder
+	keys, _ := FindUnknownKeys(data, types...)
+	for i := range keys {
+		keys[i].File = path
+	}
+	return keys
+}
+
+// reportUnknownKeys reports the unknown keys found loading the config file
+// at path: as an error when StrictKeys is set, otherwise as warnings on
+// stderr
+func reportUnknownKeys(path string, keys []UnknownKey) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if StrictKeys {
+		return &UnknownKeysError{Path: path, Keys: keys}
+	}
+
+	warnedMu.Lock()
+	defer warnedMu.Unlock()
+	for _, k := range keys {
+		warning := k.String()
+		if !warnedKeys[warning] {
+			warnedKeys[warning] = true
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+		}
+	}
+	return nil
+}
+
+// FindUnknownKeys returns the keys of the YAML document data that are not
+// read when decoding it into any of types. A key is known when one of the
+// types has a field for it, so a document can be checked against a layout
+// and the legacy fields still migrated from.
+func FindUnknownKeys(data []byte, types ...reflect.Type) ([]UnknownKey, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	var keys []UnknownKey
+	findUnknownKeys(doc.Content[0], types, "", &keys)
+	return keys, nil
+}
+
+// findUnknownKeys walks node alongside the types it is decoded into
+func findUnknownKeys(node *yaml.Node, types []reflect.Type, prefix string, keys *[]UnknownKey) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	var structs []reflect.Type
+	var elems []reflect.Type
+	for _, t := range types {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		switch {
+		case t == yamlNodeType || t.Kind() == reflect.Interface || reflect.PointerTo(t).Implements(unmarshalerType):
+			// Decoded as a whole; any content is read
+			return
+		case t.Kind() == reflect.Struct:
+			structs = append(structs, t)
+		case t.Kind() == reflect.Map || t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+			elems = append(elems, t.Elem())
+		}
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode, valueNode := node.Content[i], node.Content[i+1]
+			if keyNode.Value == "<<" {
+				// Merge key; the merged mapping is checked where it is defined
+				continue
+			}
+			key := keyNode.Value
+			if prefix != "" {
+				key = prefix + "." + keyNode.Value
+			}
+
+			children := append([]reflect.Type(nil), elems...)
+			for _, t := range structs {
+				if field, ok := yamlField(t, keyNode.Value); ok {
+					children = append(children, field.Type)
+				}
+			}
+			if len(children) == 0 {
+				if len(structs) > 0 {
+					*keys = append(*keys, UnknownKey{
+						Key:        key,
+						Line:       keyNode.Line,
+						Suggestion: suggestKey(keyNode.Value, structs),
+					})
+				}
+				continue
+			}
+			findUnknownKeys(valueNode, children, key, keys)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			findUnknownKeys(item, elems, fmt.Sprintf("%s[%d]", prefix, i), keys)
+		}
+	}
+}
+
+// yamlField returns the field of struct type t, or of a struct inlined in
+// it, that the YAML key name decodes into
+func yamlField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for _, field := range yamlFields(t) {
+		if yamlName(field) == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// yamlFields returns the fields of struct type t that YAML keys decode
+// into, with the fields of inlined structs in place of the struct
+func yamlFields(t reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("yaml")
+		if tag == "-" {
+			continue
+		}
+		if strings.Contains(tag, ",inline") && field.Type.Kind() == reflect.Struct {
+			fields = append(fields, yamlFields(field.Type)...)
+			continue
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// yamlName returns the key a field decodes from, which like yaml.v3 is the
+// lowercased field name when the tag has none
+func yamlName(field reflect.StructField) string {
+	if name := strings.Split(field.Tag.Get("yaml"), ",")[0]; name != "" {
+		return name
+	}
+	return strings.ToLower(field.Name)
+}
+
+// suggestKey returns the known key of types closest to name, or "" when
+// none is close enough to be a likely typo
+func suggestKey(name string, types []reflect.Type) string {
+	var candidates []string
+	for _, t := range types {
+		for _, field := range yamlFields(t) {
+			candidates = append(candidates, yamlName(field))
+		}
+	}
+	return closestKey(name, candidates)
+}
+
+// closestKey returns the candidate with the smallest edit distance to name,
+// allowing about one edit per three characters
+func closestKey(name string, candidates []string) string {
+	sort.Strings(candidates)
+	best, bestDistance := "", len(name)/3+1
+	for _, c := range candidates {
+		if d := editDistance(strings.ToLower(name), c); d <= bestDistance && (best == "" || d < bestDistance) {
+			best, bestDistance = c, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
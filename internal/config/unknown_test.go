@@ -0,0 +1,137 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindUnknownKeys(t *testing.T) {
+	t.Parallel()
+
+	client := []reflect.Type{reflect.TypeOf(ClientConfig{}), reflect.TypeOf(legacyClientConfig{})}
+	tests := []struct {
+		name  string
+		data  string
+		types []reflect.Type
+		want  []UnknownKey
+	}{
+		{
+			name:  "typo",
+			data:  "defualt_editor: code\n",
+			types: client,
+			want:  []UnknownKey{{Key: "defualt_editor", Line: 1, Suggestion: "default_editor"}},
+		},
+		{
+			name:  "nested typo",
+			data:  "hosts:\n  server:\n    primry: 10.0.0.1\n",
+			types: client,
+			want:  []UnknownKey{{Key: "hosts.server.primry", Line: 3, Suggestion: "primary"}},
+		},
+		{
+			name:  "no close key",
+			data:  "colour_scheme: dark\n",
+			types: client,
+			want:  []UnknownKey{{Key: "colour_scheme", Line: 1}},
+		},
+		{
+			name:  "legacy keys are known",
+			data:  "network:\n  primary_host: 10.0.0.1\n  timeout: 2s\nssh_host: box\n",
+			types: client,
+		},
+		{
+			name:  "map entries are free-form",
+			data:  "bookmarks:\n  anything: /src\n",
+			types: client,
+		},
+		{
+			name:  "editors in a list",
+			data:  "editors:\n  - name: code\n    comand: code {path}\n",
+			types: []reflect.Type{reflect.TypeOf(ServerConfigFile{})},
+			want:  []UnknownKey{{Key: "editors[0].comand", Line: 3, Suggestion: "command"}},
+		},
+		{
+			name:  "unified layout",
+			data:  "client:\n  default_editor: code\nserver:\n  prot: 3339\n",
+			types: []reflect.Type{reflect.TypeOf(UnifiedConfigFile{})},
+			want:  []UnknownKey{{Key: "server.prot", Line: 4, Suggestion: "port"}},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := FindUnknownKeys([]byte(tt.data), tt.types...)
+			if err != nil {
+				t.Fatalf("FindUnknownKeys() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindUnknownKeys() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindUnknownKeys_Examples(t *testing.T) {
+	t.Parallel()
+
+	for file, layouts := range map[string][]reflect.Type{
+		"config.yaml":        {reflect.TypeOf(ClientConfig{})},
+		"server-config.yaml": {reflect.TypeOf(ServerConfigFile{})},
+	} {
+		data, err := os.ReadFile(filepath.Join("..", "..", "examples", file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys, err := FindUnknownKeys(data, layouts...)
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		if len(keys) > 0 {
+			t.Errorf("%s has unknown keys: %v", file, keys)
+		}
+	}
+}
+
+func TestLoadClientConfig_StrictKeys(t *testing.T) {
+	// Not parallel: StrictKeys is global
+	StrictKeys = true
+	defer func() { StrictKeys = false }()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("default_editor: code\ndefualt_editor: zed\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadClientConfig(path)
+	var unknown *UnknownKeysError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("LoadClientConfig() error = %v, want *UnknownKeysError", err)
+	}
+	if len(unknown.Keys) != 1 || unknown.Keys[0].Suggestion != "default_editor" {
+		t.Errorf("unknown keys = %v, want defualt_editor with a suggestion", unknown.Keys)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"port", "port", 0},
+		{"prot", "port", 2},
+		{"primry", "primary", 1},
+		{"", "host", 4},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -1,15 +1,26 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
 	"strings"
+	"unicode"
 
+	"github.com/foxytanuki/rcode/internal/auth"
 	"github.com/foxytanuki/rcode/internal/validation"
 )
 
+// goos is the OS the configuration is checked for; a variable so tests can
+// check Windows-only rules elsewhere
+var goos = runtime.GOOS
+
 // ValidationError represents a configuration validation error
 type ValidationError struct {
 	Field   string
@@ -35,6 +46,26 @@ func (e ValidationErrors) Error() string {
 	return strings.Join(messages, "; ")
 }
 
+// FieldError returns the errors in err reported for field or any of its
+// sub-fields (field.x, field[i]), or nil when there are none
+func FieldError(err error, field string) error {
+	var all ValidationErrors
+	if !errors.As(err, &all) {
+		return nil
+	}
+
+	var matched ValidationErrors
+	for _, e := range all {
+		if e.Field == field || strings.HasPrefix(e.Field, field+".") || strings.HasPrefix(e.Field, field+"[") {
+			matched = append(matched, e)
+		}
+	}
+	if len(matched) == 0 {
+		return nil
+	}
+	return matched
+}
+
 // ValidateServerConfig validates server configuration
 //
 //nolint:gocyclo // Validation requires checking multiple fields
@@ -49,6 +80,40 @@ func ValidateServerConfig(config *ServerConfigFile) error {
 		})
 	}
 
+	if config.Server.Host != "" {
+		if err := validation.ValidateHostPort(config.Server.Host); err != nil {
+			errors = append(errors, ValidationError{
+				Field:   "server.host",
+				Message: err.Error(),
+			})
+		} else if _, _, err := net.SplitHostPort(config.Server.Host); err == nil {
+			errors = append(errors, ValidationError{
+				Field:   "server.host",
+				Message: fmt.Sprintf("%s must not include a port (set server.port instead)", config.Server.Host),
+			})
+		}
+	}
+
+	for i, addr := range config.Server.Listen {
+		if err := validation.ValidateHostPort(addr); err != nil {
+			errors = append(errors, ValidationError{
+				Field:   fmt.Sprintf("server.listen[%d]", i),
+				Message: err.Error(),
+			})
+		}
+	}
+	for i, name := range config.Server.Interfaces {
+		if strings.TrimSpace(name) == "" {
+			errors = append(errors, ValidationError{
+				Field:   fmt.Sprintf("server.listen_interfaces[%d]", i),
+				Message: "interface name cannot be empty",
+			})
+		}
+	}
+
+	errors = append(errors, validateServerTLS(&config.Server.TLS)...)
+	errors = append(errors, validateSigning(&config.Server.Signing)...)
+
 	// Validate IP whitelist if specified
 	for i, ip := range config.Server.AllowedIPs {
 		if _, _, err := net.ParseCIDR(ip); err != nil {
@@ -61,6 +126,126 @@ func ValidateServerConfig(config *ServerConfigFile) error {
 		}
 	}
 
+	// Validate allowed paths if specified
+	for i, pattern := range config.Server.AllowedPaths {
+		if err := validation.ValidatePathPattern(pattern); err != nil {
+			errors = append(errors, ValidationError{
+				Field:   fmt.Sprintf("server.allowed_paths[%d]", i),
+				Message: err.Error(),
+			})
+		}
+	}
+
+	// Validate auth token if specified
+	if err := validateAuthToken("server.auth_token", config.Server.AuthToken); err != nil {
+		errors = append(errors, *err)
+	}
+
+	// Validate broker URL if specified
+	if config.Server.Broker != "" {
+		if u, err := url.Parse(config.Server.Broker); err != nil || (u.Scheme != "ws" && u.Scheme != "wss") || u.Host == "" {
+			errors = append(errors, ValidationError{
+				Field:   "server.broker",
+				Message: fmt.Sprintf("invalid broker URL (must be ws:// or wss://): %s", config.Server.Broker),
+			})
+		}
+		// The broker only accepts agents presenting its token
+		if config.Server.AuthToken == "" {
+			errors = append(errors, ValidationError{
+				Field:   "server.broker",
+				Message: "broker requires server.auth_token, the token the broker was started with",
+			})
+		}
+	}
+
+	if config.Server.MaxBodyKB < 0 || config.Server.MaxBodyKB > MaxBodyKB {
+		errors = append(errors, ValidationError{
+			Field:   "server.max_body_kb",
+			Message: fmt.Sprintf("max_body_kb must be between 0 and %d", MaxBodyKB),
+		})
+	}
+
+	// Validate clipboard bridge
+	if config.Server.Clipboard.MaxKB < 0 || config.Server.Clipboard.MaxKB > MaxClipboardKB {
+		errors = append(errors, ValidationError{
+			Field:   "server.clipboard.max_kb",
+			Message: fmt.Sprintf("max_kb must be between 0 and %d", MaxClipboardKB),
+		})
+	}
+
+	// Validate rate limit
+	if config.Server.RateLimit.RequestsPerMinute < 0 {
+		errors = append(errors, ValidationError{
+			Field:   "server.rate_limit.requests_per_minute",
+			Message: "requests per minute cannot be negative",
+		})
+	}
+	if config.Server.RateLimit.Burst < 0 {
+		errors = append(errors, ValidationError{
+			Field:   "server.rate_limit.burst",
+			Message: "burst cannot be negative",
+		})
+	}
+
+	// Validate launch settings
+	if config.Server.Launch.DedupWindow < 0 {
+		errors = append(errors, ValidationError{
+			Field:   "server.launch.dedup_window",
+			Message: "dedup window cannot be negative",
+		})
+	}
+	if config.Server.Launch.IdempotencyWindow < 0 {
+		errors = append(errors, ValidationError{
+			Field:   "server.launch.idempotency_window",
+			Message: "idempotency window cannot be negative",
+		})
+	}
+	if config.Server.Launch.Workers < 0 {
+		errors = append(errors, ValidationError{
+			Field:   "server.launch.workers",
+			Message: "workers cannot be negative",
+		})
+	}
+	if config.Server.Launch.MaxQueued < 0 {
+		errors = append(errors, ValidationError{
+			Field:   "server.launch.max_queued",
+			Message: "max queued cannot be negative",
+		})
+	}
+
+	// Validate file sync settings
+	if config.Server.Sync.PollInterval < 0 {
+		errors = append(errors, ValidationError{
+			Field:   "server.sync.poll_interval",
+			Message: "poll interval cannot be negative",
+		})
+	}
+	if config.Server.Sync.IdleTimeout < 0 {
+		errors = append(errors, ValidationError{
+			Field:   "server.sync.idle_timeout",
+			Message: "idle timeout cannot be negative",
+		})
+	}
+
+	// Validate path creation
+	if config.Server.Create.Enabled && len(config.Server.AllowedPaths) == 0 {
+		errors = append(errors, ValidationError{
+			Field:   "server.create.enabled",
+			Message: "creating paths requires server.allowed_paths",
+		})
+	}
+	errors = append(errors, validateCreateModes("server.create", config.Server.Create.CreateModes)...)
+
+	// Validate open-url allow list
+	for i, pattern := range config.Server.OpenURL.Allowed {
+		if err := validation.ValidateURLPattern(pattern); err != nil {
+			errors = append(errors, ValidationError{
+				Field:   fmt.Sprintf("server.open_url.allowed[%d]", i),
+				Message: err.Error(),
+			})
+		}
+	}
+
 	// Validate timeouts
 	if config.Server.ReadTimeout < 0 {
 		errors = append(errors, ValidationError{
@@ -89,7 +274,8 @@ func ValidateServerConfig(config *ServerConfigFile) error {
 		})
 	}
 
-	editorNames := make(map[string]bool)
+	// Lower-cased editor names and aliases, mapped to what defined them
+	editorNames := make(map[string]string)
 	defaultCount := 0
 	for i, editor := range config.Editors {
 		if editor.Name == "" {
@@ -103,15 +289,15 @@ func ValidateServerConfig(config *ServerConfigFile) error {
 		if typeValue == "" {
 			typeValue = EditorTypeCommand
 		}
-		if typeValue != EditorTypeCommand && typeValue != EditorTypeBrowser {
+		if typeValue != EditorTypeCommand && typeValue != EditorTypeBrowser && typeValue != EditorTypeTmux {
 			errors = append(errors, ValidationError{
 				Field:   fmt.Sprintf("editors[%d].type", i),
-				Message: "editor type must be command or browser",
+				Message: "editor type must be command, browser or tmux",
 			})
 		}
 
 		switch typeValue {
-		case EditorTypeCommand:
+		case EditorTypeCommand, EditorTypeTmux:
 			if editor.Command == "" {
 				errors = append(errors, ValidationError{
 					Field:   fmt.Sprintf("editors[%d].command", i),
@@ -127,33 +313,107 @@ func ValidateServerConfig(config *ServerConfigFile) error {
 			}
 		}
 
-		// Check for duplicate names
-		if editorNames[editor.Name] {
+		// Check for duplicate names; lookups ignore case
+		if other, exists := editorNames[strings.ToLower(editor.Name)]; exists {
 			errors = append(errors, ValidationError{
 				Field:   fmt.Sprintf("editors[%d].name", i),
-				Message: fmt.Sprintf("duplicate editor name: %s", editor.Name),
+				Message: fmt.Sprintf("duplicate editor name: %s (conflicts with %s)", editor.Name, other),
+			})
+		} else {
+			editorNames[strings.ToLower(editor.Name)] = fmt.Sprintf("editor %s", editor.Name)
+		}
+
+		if typeValue == EditorTypeTmux {
+			if editor.ExecMode != "" && editor.ExecMode != ExecModeArgv {
+				errors = append(errors, ValidationError{
+					Field:   fmt.Sprintf("editors[%d].exec_mode", i),
+					Message: "tmux editors always run their command through tmux",
+				})
+			}
+		} else if editor.Session != "" {
+			errors = append(errors, ValidationError{
+				Field:   fmt.Sprintf("editors[%d].session", i),
+				Message: "session is only used by tmux editors",
+			})
+		}
+
+		// Validate execution policy
+		switch editor.ExecMode {
+		case "", ExecModeArgv:
+		case ExecModeShell, ExecModeLoginShell:
+			// Substituted values are quoted for POSIX shells, which cmd.exe
+			// does not understand
+			if goos == "windows" {
+				errors = append(errors, ValidationError{
+					Field:   fmt.Sprintf("editors[%d].exec_mode", i),
+					Message: fmt.Sprintf("exec_mode %s is not supported on Windows; use %s", editor.ExecMode, ExecModeArgv),
+				})
+			}
+		default:
+			errors = append(errors, ValidationError{
+				Field:   fmt.Sprintf("editors[%d].exec_mode", i),
+				Message: fmt.Sprintf("exec_mode must be %s, %s or %s", ExecModeArgv, ExecModeShell, ExecModeLoginShell),
+			})
+		}
+		if editor.Timeout < 0 {
+			errors = append(errors, ValidationError{
+				Field:   fmt.Sprintf("editors[%d].timeout", i),
+				Message: "timeout cannot be negative",
+			})
+		}
+		if editor.Retries < 0 || editor.Retries > MaxEditorRetries {
+			errors = append(errors, ValidationError{
+				Field:   fmt.Sprintf("editors[%d].retries", i),
+				Message: fmt.Sprintf("retries must be between 0 and %d", MaxEditorRetries),
+			})
+		}
+		if editor.CaptureKB < 0 || editor.CaptureKB > MaxEditorCaptureKB {
+			errors = append(errors, ValidationError{
+				Field:   fmt.Sprintf("editors[%d].capture_output_kb", i),
+				Message: fmt.Sprintf("capture_output_kb must be between 0 and %d", MaxEditorCaptureKB),
 			})
 		}
-		editorNames[editor.Name] = true
 
 		// Count default editors
 		if editor.Default {
 			defaultCount++
 		}
 
-		// Validate templates
+		// Validate user-defined variables and templates
+		for name := range editor.Variables {
+			if err := validation.ValidateVariableName(name); err != nil {
+				errors = append(errors, ValidationError{
+					Field:   fmt.Sprintf("editors[%d].variables.%s", i, name),
+					Message: err.Error(),
+				})
+			}
+		}
 		if typeValue == EditorTypeBrowser {
 			if editor.URL != "" {
-				if err := validateCommandTemplate(editor.URL); err != nil {
+				if err := validation.ValidateTemplate(editor.URL, editor.Variables); err != nil {
 					errors = append(errors, ValidationError{
 						Field:   fmt.Sprintf("editors[%d].url", i),
 						Message: err.Error(),
 					})
 				}
 			}
+			if editor.WorkDir != "" {
+				errors = append(errors, ValidationError{
+					Field:   fmt.Sprintf("editors[%d].workdir", i),
+					Message: "workdir is only used by command editors",
+				})
+			}
 		} else {
+			if editor.WorkDir != "" {
+				if err := validation.ValidatePlaceholders(editor.WorkDir, editor.Variables); err != nil {
+					errors = append(errors, ValidationError{
+						Field:   fmt.Sprintf("editors[%d].workdir", i),
+						Message: err.Error(),
+					})
+				}
+			}
 			if editor.Command != "" {
-				if err := validateCommandTemplate(editor.Command); err != nil {
+				if err := validation.ValidateTemplate(editor.Command, editor.Variables); err != nil {
 					errors = append(errors, ValidationError{
 						Field:   fmt.Sprintf("editors[%d].command", i),
 						Message: err.Error(),
@@ -161,6 +421,25 @@ func ValidateServerConfig(config *ServerConfigFile) error {
 				}
 			}
 		}
+		if editor.Diff != "" {
+			if typeValue != EditorTypeCommand {
+				errors = append(errors, ValidationError{
+					Field:   fmt.Sprintf("editors[%d].diff", i),
+					Message: "diff is only used by command editors",
+				})
+			} else if err := validation.ValidateDiffTemplate(editor.Diff, editor.Variables); err != nil {
+				errors = append(errors, ValidationError{
+					Field:   fmt.Sprintf("editors[%d].diff", i),
+					Message: err.Error(),
+				})
+			}
+		}
+		if editor.Sync && typeValue != EditorTypeCommand {
+			errors = append(errors, ValidationError{
+				Field:   fmt.Sprintf("editors[%d].sync", i),
+				Message: "sync is only used by command editors",
+			})
+		}
 	}
 
 	if defaultCount > 1 {
@@ -170,11 +449,66 @@ func ValidateServerConfig(config *ServerConfigFile) error {
 		})
 	}
 
+	// Aliases must not collide with any editor name or other alias
+	for i, editor := range config.Editors {
+		for j, alias := range editor.Aliases {
+			field := fmt.Sprintf("editors[%d].aliases[%d]", i, j)
+			if strings.TrimSpace(alias) == "" {
+				errors = append(errors, ValidationError{
+					Field:   field,
+					Message: "alias cannot be empty",
+				})
+				continue
+			}
+			key := strings.ToLower(alias)
+			if other, exists := editorNames[key]; exists {
+				errors = append(errors, ValidationError{
+					Field:   field,
+					Message: fmt.Sprintf("alias %s conflicts with %s", alias, other),
+				})
+				continue
+			}
+			editorNames[key] = fmt.Sprintf("alias %s of editor %s", alias, editor.Name)
+		}
+	}
+
+	// Fallback editors must name a configured editor or alias
+	for i, name := range config.Server.EditorFallback {
+		if _, exists := editorNames[strings.ToLower(name)]; !exists {
+			errors = append(errors, ValidationError{
+				Field:   fmt.Sprintf("server.editor_fallback[%d]", i),
+				Message: fmt.Sprintf("unknown editor: %s", name),
+			})
+		}
+	}
+
+	// Per-user default editors must name a configured editor or alias
+	for user, prefs := range config.Users {
+		if prefs.DefaultEditor == "" {
+			continue
+		}
+		if _, exists := editorNames[strings.ToLower(prefs.DefaultEditor)]; !exists {
+			errors = append(errors, ValidationError{
+				Field:   fmt.Sprintf("users.%s.default_editor", user),
+				Message: fmt.Sprintf("unknown editor: %s", prefs.DefaultEditor),
+			})
+		}
+	}
+
+	errors = append(errors, validateTerminals(config.Terminals)...)
+
 	// Validate logging
 	if err := validateLogConfig(&config.Logging); err != nil {
 		errors = append(errors, err...)
 	}
 
+	// Validate audit log
+	if err := validateAuditConfig(&config.Audit); err != nil {
+		errors = append(errors, err...)
+	}
+
+	errors = append(errors, validateTelemetryConfig(&config.Telemetry)...)
+
 	if len(errors) > 0 {
 		return errors
 	}
@@ -194,6 +528,40 @@ func ValidateClientConfig(config *ClientConfig) error {
 			Message: "primary server host cannot be empty",
 		})
 	}
+	errors = append(errors, validateServerHosts("hosts.server", &config.Hosts.Server)...)
+
+	for i, vpn := range config.Hosts.SSH.AutoDetect.VPN {
+		switch vpn.Type {
+		case VPNWireGuard, VPNZeroTier, VPNNetbird:
+		default:
+			errors = append(errors, ValidationError{
+				Field:   fmt.Sprintf("hosts.ssh.auto_detect.vpn[%d].type", i),
+				Message: fmt.Sprintf("invalid VPN type %q (must be wireguard, zerotier or netbird)", vpn.Type),
+			})
+		}
+	}
+
+	// Validate profiles; an empty setting keeps the top-level one
+	for _, name := range config.ProfileNames() {
+		profile := config.Profiles[name]
+		errors = append(errors, validateServerHosts("profiles."+name+".server", &profile.Server)...)
+		for i, cidr := range profile.Match.ServerIP {
+			if _, err := parseCIDROrIP(cidr); err != nil {
+				errors = append(errors, ValidationError{
+					Field:   fmt.Sprintf("profiles.%s.match.server_ip[%d]", name, i),
+					Message: err.Error(),
+				})
+			}
+		}
+		if profile.Match.Hostname != "" {
+			if _, err := regexp.Compile(profile.Match.Hostname); err != nil {
+				errors = append(errors, ValidationError{
+					Field:   "profiles." + name + ".match.hostname",
+					Message: fmt.Sprintf("invalid regular expression: %v", err),
+				})
+			}
+		}
+	}
 
 	// Validate network settings
 	if config.Network.Timeout < 0 {
@@ -217,11 +585,56 @@ func ValidateClientConfig(config *ClientConfig) error {
 		})
 	}
 
-	// Validate fallback editors if configured
-	if err := validateFallbackEditors(config.FallbackEditors); err != nil {
+	switch config.Network.HostSelection {
+	case "", HostSelectionOrdered, HostSelectionRace:
+	default:
+		errors = append(errors, ValidationError{
+			Field:   "network.host_selection",
+			Message: fmt.Sprintf("invalid host selection %q (must be ordered or race)", config.Network.HostSelection),
+		})
+	}
+
+	if config.Network.ProbeTimeout < 0 {
+		errors = append(errors, ValidationError{
+			Field:   "network.probe_timeout",
+			Message: "probe timeout cannot be negative",
+		})
+	}
+	if config.Network.DNSServer != "" && !validDNSServer(config.Network.DNSServer) {
+		errors = append(errors, ValidationError{
+			Field:   "network.dns_server",
+			Message: fmt.Sprintf("invalid DNS server %q (must be an IP address, optionally with a port)", config.Network.DNSServer),
+		})
+	}
+	errors = append(errors, validateHostsOverrides(config.HostsOverrides)...)
+
+	// Validate fallback and local editors if configured
+	if err := validateEditorTemplates("fallback_editors", config.FallbackEditors); err != nil {
+		errors = append(errors, err...)
+	}
+	if err := validateEditorTemplates("local_editors", config.LocalEditors); err != nil {
 		errors = append(errors, err...)
 	}
 
+	errors = append(errors, validateCreateModes("create", config.Create)...)
+
+	switch config.LocalMode {
+	case "", LocalModeNever, LocalModeAuto, LocalModeAlways:
+	default:
+		errors = append(errors, ValidationError{
+			Field:   "local_mode",
+			Message: fmt.Sprintf("invalid local mode %q (must be never, auto or always)", config.LocalMode),
+		})
+	}
+
+	if config.MaxHistory < 0 {
+		errors = append(errors, ValidationError{
+			Field:   "max_history",
+			Message: "max history cannot be negative",
+		})
+	}
+	errors = append(errors, validateBookmarks(config.Bookmarks)...)
+
 	// Note: DefaultEditor is not validated here because editor definitions
 	// are centralized on the server. The server will validate the editor name
 	// when processing the open-editor request.
@@ -231,20 +644,69 @@ func ValidateClientConfig(config *ClientConfig) error {
 		errors = append(errors, err...)
 	}
 
+	errors = append(errors, validateTelemetryConfig(&config.Telemetry)...)
+
 	if len(errors) > 0 {
 		return errors
 	}
 	return nil
 }
 
-// validateFallbackEditors validates fallback editor configurations
-func validateFallbackEditors(editors FallbackEditorsConfig) ValidationErrors {
+// validateServerHosts validates the port, addresses and auth token of the
+// server hosts at field
+func validateServerHosts(field string, hosts *ServerHostConfig) ValidationErrors {
+	var errors ValidationErrors
+
+	if hosts.Port < 0 || hosts.Port > 65535 {
+		errors = append(errors, ValidationError{
+			Field:   field + ".port",
+			Message: fmt.Sprintf("invalid port number: %d", hosts.Port),
+		})
+	}
+	for _, addr := range []struct{ field, value string }{
+		{field + ".primary", hosts.Primary},
+		{field + ".fallback", hosts.Fallback},
+		{field + ".tunnel", hosts.Tunnel},
+		{field + ".broker", hosts.Broker},
+	} {
+		if addr.value == "" {
+			continue
+		}
+		if err := validation.ValidateHostPort(addr.value); err != nil {
+			errors = append(errors, ValidationError{
+				Field:   addr.field,
+				Message: err.Error(),
+			})
+		}
+	}
+
+	// The client certificate needs its key
+	if (hosts.TLS.CertFile == "") != (hosts.TLS.KeyFile == "") {
+		errors = append(errors, ValidationError{
+			Field:   field + ".tls",
+			Message: "cert_file and key_file must be set together",
+		})
+	}
+
+	// Validate auth token if specified
+	if err := validateAuthToken(field+".auth_token", hosts.AuthToken); err != nil {
+		errors = append(errors, *err)
+	}
+	if err := validateAuthToken(field+".signing_key", hosts.SigningKey); err != nil {
+		errors = append(errors, *err)
+	}
+
+	return errors
+}
+
+// validateEditorTemplates validates a map of editor command templates
+func validateEditorTemplates(field string, editors map[string]string) ValidationErrors {
 	var errors ValidationErrors
 
 	for name, command := range editors {
 		if command == "" {
 			errors = append(errors, ValidationError{
-				Field:   fmt.Sprintf("fallback_editors.%s", name),
+				Field:   fmt.Sprintf("%s.%s", field, name),
 				Message: "editor command cannot be empty",
 			})
 			continue
@@ -253,7 +715,165 @@ func validateFallbackEditors(editors FallbackEditorsConfig) ValidationErrors {
 		// Validate command template
 		if err := validateCommandTemplate(command); err != nil {
 			errors = append(errors, ValidationError{
-				Field:   fmt.Sprintf("fallback_editors.%s", name),
+				Field:   fmt.Sprintf("%s.%s", field, name),
+				Message: err.Error(),
+			})
+		}
+	}
+
+	return errors
+}
+
+// validateBookmarks checks bookmark names, which are typed after "@", and
+// that each bookmark is an absolute path
+func validateBookmarks(bookmarks map[string]string) ValidationErrors {
+	var errors ValidationErrors
+	for name, path := range bookmarks {
+		field := "bookmarks." + name
+		if !ValidBookmarkName(name) {
+			errors = append(errors, ValidationError{
+				Field:   field,
+				Message: "bookmark names may only hold letters, digits, - and _",
+			})
+		}
+		if !filepath.IsAbs(path) {
+			errors = append(errors, ValidationError{
+				Field:   field,
+				Message: fmt.Sprintf("bookmark path %q must be absolute", path),
+			})
+		}
+	}
+	return errors
+}
+
+// validateServerTLS checks that the certificate and key come together and
+// that client certificates are only required over TLS
+func validateServerTLS(tls *ServerTLSConfig) ValidationErrors {
+	var errors ValidationErrors
+	if (tls.CertFile == "") != (tls.KeyFile == "") {
+		errors = append(errors, ValidationError{
+			Field:   "server.tls",
+			Message: "cert_file and key_file must be set together",
+		})
+	}
+	if tls.ClientCAFile != "" && tls.CertFile == "" {
+		errors = append(errors, ValidationError{
+			Field:   "server.tls.client_ca_file",
+			Message: "client certificates require cert_file and key_file",
+		})
+	}
+	return errors
+}
+
+// validateSigning checks the signing keys like auth tokens, and that each
+// is listed once
+func validateSigning(signing *SigningConfig) ValidationErrors {
+	var errors ValidationErrors
+	seen := make(map[string]bool, len(signing.Keys))
+	for i, key := range signing.Keys {
+		field := fmt.Sprintf("server.signing.keys[%d]", i)
+		if key == "" {
+			errors = append(errors, ValidationError{Field: field, Message: "signing key cannot be empty"})
+			continue
+		}
+		if err := validateAuthToken(field, key); err != nil {
+			errors = append(errors, *err)
+		}
+		if seen[key] {
+			errors = append(errors, ValidationError{Field: field, Message: "signing key is listed twice"})
+		}
+		seen[key] = true
+	}
+	if signing.MaxAge < 0 {
+		errors = append(errors, ValidationError{
+			Field:   "server.signing.max_age",
+			Message: "max age cannot be negative",
+		})
+	}
+	return errors
+}
+
+// validateHostsOverrides checks that every override maps a host name to
+// an IP address
+func validateHostsOverrides(overrides map[string]string) ValidationErrors {
+	var errors ValidationErrors
+	for name, ip := range overrides {
+		field := "hosts_overrides." + name
+		if strings.TrimSpace(name) == "" || strings.ContainsAny(name, " :/") {
+			errors = append(errors, ValidationError{
+				Field:   field,
+				Message: fmt.Sprintf("invalid host name %q", name),
+			})
+		}
+		if net.ParseIP(ip) == nil {
+			errors = append(errors, ValidationError{
+				Field:   field,
+				Message: fmt.Sprintf("%q is not an IP address", ip),
+			})
+		}
+	}
+	return errors
+}
+
+// validDNSServer reports whether server is an IP address, with or without
+// a port
+func validDNSServer(server string) bool {
+	host, port, err := net.SplitHostPort(server)
+	if err != nil {
+		return net.ParseIP(server) != nil
+	}
+	p, err := strconv.Atoi(port)
+	return err == nil && p > 0 && p <= 65535 && net.ParseIP(host) != nil
+}
+
+// ValidBookmarkName reports whether name can be used as a bookmark
+func ValidBookmarkName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// validateTerminals validates the terminal launchers, which are command
+// editors under another name
+func validateTerminals(terminals []EditorConfig) ValidationErrors {
+	var errors ValidationErrors
+
+	names := make(map[string]bool)
+	for i, terminal := range terminals {
+		field := fmt.Sprintf("terminals[%d]", i)
+		if terminal.Name == "" {
+			errors = append(errors, ValidationError{
+				Field:   field + ".name",
+				Message: "terminal name cannot be empty",
+			})
+		} else if names[strings.ToLower(terminal.Name)] {
+			errors = append(errors, ValidationError{
+				Field:   field + ".name",
+				Message: fmt.Sprintf("duplicate terminal name: %s", terminal.Name),
+			})
+		}
+		names[strings.ToLower(terminal.Name)] = true
+
+		if terminal.Type != "" && terminal.Type != EditorTypeCommand {
+			errors = append(errors, ValidationError{
+				Field:   field + ".type",
+				Message: "terminals must be command editors",
+			})
+		}
+		if terminal.Command == "" {
+			errors = append(errors, ValidationError{
+				Field:   field + ".command",
+				Message: "terminal command cannot be empty",
+			})
+		} else if err := validation.ValidateTemplate(terminal.Command, terminal.Variables); err != nil {
+			errors = append(errors, ValidationError{
+				Field:   field + ".command",
 				Message: err.Error(),
 			})
 		}
@@ -281,12 +901,47 @@ func validateLogConfig(config *LogConfig) ValidationErrors {
 		})
 	}
 
+	// Validate log format
+	switch config.Format {
+	case "", "text", "json", "ecs":
+	default:
+		errors = append(errors, ValidationError{
+			Field:   "logging.format",
+			Message: fmt.Sprintf("invalid log format: %s (must be text, json, or ecs)", config.Format),
+		})
+	}
+
+	// Validate deduplication windows
+	for name, window := range config.Dedup {
+		if !validLevels[strings.ToLower(name)] {
+			errors = append(errors, ValidationError{
+				Field:   "logging.dedup." + name,
+				Message: fmt.Sprintf("invalid log level: %s (must be debug, info, warn, or error)", name),
+			})
+		} else if window <= 0 {
+			errors = append(errors, ValidationError{
+				Field:   "logging.dedup." + name,
+				Message: "window must be positive",
+			})
+		}
+	}
+
+	// Validate rotation schedule
+	switch config.Rotate {
+	case "", "daily", "weekly":
+	default:
+		errors = append(errors, ValidationError{
+			Field:   "logging.rotate",
+			Message: fmt.Sprintf("invalid rotation schedule: %s (must be daily or weekly)", config.Rotate),
+		})
+	}
+
 	// Validate log file path
 	if config.File != "" {
 		dir := filepath.Dir(config.File)
 		if dir != "" && dir != "." {
 			// Check if parent directory is writable
-			if err := checkDirectoryWritable(dir); err != nil {
+			if err := CheckDirectoryWritable(dir); err != nil {
 				errors = append(errors, ValidationError{
 					Field:   "logging.file",
 					Message: fmt.Sprintf("log directory not writable: %s", err),
@@ -320,20 +975,104 @@ func validateLogConfig(config *LogConfig) ValidationErrors {
 	return errors
 }
 
+// validateAuditConfig validates audit log configuration
+func validateAuditConfig(config *AuditConfig) ValidationErrors {
+	var errors ValidationErrors
+
+	if config.File != "" {
+		dir := filepath.Dir(config.File)
+		if dir != "" && dir != "." {
+			if err := CheckDirectoryWritable(dir); err != nil {
+				errors = append(errors, ValidationError{
+					Field:   "audit.file",
+					Message: fmt.Sprintf("audit directory not writable: %s", err),
+				})
+			}
+		}
+	}
+
+	if config.MaxSize < 0 {
+		errors = append(errors, ValidationError{
+			Field:   "audit.max_size",
+			Message: "max size cannot be negative",
+		})
+	}
+
+	if config.MaxBackups < 0 {
+		errors = append(errors, ValidationError{
+			Field:   "audit.max_backups",
+			Message: "max backups cannot be negative",
+		})
+	}
+
+	if config.MaxAge < 0 {
+		errors = append(errors, ValidationError{
+			Field:   "audit.max_age",
+			Message: "max age cannot be negative",
+		})
+	}
+
+	return errors
+}
+
+// validateTelemetryConfig validates OpenTelemetry export settings
+func validateTelemetryConfig(config *TelemetryConfig) ValidationErrors {
+	var errors ValidationErrors
+
+	if config.Endpoint != "" {
+		u, err := url.Parse(config.Endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errors = append(errors, ValidationError{
+				Field:   "telemetry.endpoint",
+				Message: fmt.Sprintf("invalid collector URL %q: want http:// or https://", config.Endpoint),
+			})
+		}
+	}
+
+	if config.Interval < 0 {
+		errors = append(errors, ValidationError{
+			Field:   "telemetry.export_interval",
+			Message: "export interval cannot be negative",
+		})
+	}
+
+	return errors
+}
+
+// validateAuthToken validates an optional bearer token
+func validateAuthToken(field, token string) *ValidationError {
+	if token == "" {
+		return nil
+	}
+	if len(token) < auth.MinTokenLength {
+		return &ValidationError{
+			Field:   field,
+			Message: fmt.Sprintf("auth token must be at least %d characters", auth.MinTokenLength),
+		}
+	}
+	if strings.ContainsAny(token, " \t\r\n") {
+		return &ValidationError{
+			Field:   field,
+			Message: "auth token cannot contain whitespace",
+		}
+	}
+	return nil
+}
+
 // validateCommandTemplate validates an editor command template
 func validateCommandTemplate(command string) error {
 	return validation.ValidateCommandTemplate(command)
 }
 
-// checkDirectoryWritable checks if a directory is writable
-func checkDirectoryWritable(dir string) error {
+// CheckDirectoryWritable checks if a directory is writable
+func CheckDirectoryWritable(dir string) error {
 	// If directory doesn't exist, check parent
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
 		parent := filepath.Dir(dir)
 		if parent == dir {
 			return fmt.Errorf("cannot determine parent directory")
 		}
-		return checkDirectoryWritable(parent)
+		return CheckDirectoryWritable(parent)
 	}
 
 	// Try to create a temporary file to test writability
@@ -349,3 +1088,21 @@ func checkDirectoryWritable(dir string) error {
 
 	return nil
 }
+
+// validateCreateModes checks that the modes under prefix are permission bits
+func validateCreateModes(prefix string, modes CreateModes) []ValidationError {
+	var errors []ValidationError
+	if modes.FileMode&^os.ModePerm != 0 {
+		errors = append(errors, ValidationError{
+			Field:   prefix + ".file_mode",
+			Message: fmt.Sprintf("file mode %#o has bits other than permissions (at most 0777)", uint32(modes.FileMode)),
+		})
+	}
+	if modes.DirMode&^os.ModePerm != 0 {
+		errors = append(errors, ValidationError{
+			Field:   prefix + ".dir_mode",
+			Message: fmt.Sprintf("dir mode %#o has bits other than permissions (at most 0777)", uint32(modes.DirMode)),
+		})
+	}
+	return errors
+}
@@ -1,9 +1,12 @@
 package config
 
 import (
+	"errors"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/foxytanuki/rcode/internal/validation"
 )
 
 func TestValidateServerConfig(t *testing.T) {
@@ -23,190 +26,1024 @@ func TestValidateServerConfig(t *testing.T) {
 					WriteTimeout: 10 * time.Second,
 					IdleTimeout:  120 * time.Second,
 				},
-				Editors: []EditorConfig{
-					{Name: "cursor", Command: "cursor {path}", Default: true},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor {path}", Default: true},
+				},
+				Logging: LogConfig{
+					Level:      "info",
+					File:       "/tmp/test.log",
+					MaxSize:    10,
+					MaxBackups: 5,
+					MaxAge:     30,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid port",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port: 70000,
+				},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor {path}"},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "invalid port number",
+		},
+		{
+			name: "listen host with port",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Host: "0.0.0.0:3339",
+					Port: 3339,
+				},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor {path}"},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "must not include a port",
+		},
+		{
+			name: "create without allowed paths",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port:   3339,
+					Create: CreateConfig{Enabled: true},
+				},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor {path}"},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "creating paths requires server.allowed_paths",
+		},
+		{
+			name: "create with allowed paths",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port:         3339,
+					AllowedPaths: []string{"/home/user/projects"},
+					Create:       CreateConfig{Enabled: true, CreateModes: CreateModes{FileMode: 0o600, DirMode: 0o700}},
+				},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor {path}"},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "create mode beyond permissions",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port:   3339,
+					Create: CreateConfig{CreateModes: CreateModes{DirMode: 0o1777}},
+				},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor {path}"},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "dir mode 01777 has bits other than permissions",
+		},
+		{
+			name: "invalid listen address",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port:       3339,
+					Listen:     []string{"127.0.0.1", "http://100.64.0.2"},
+					Interfaces: []string{"tailscale0"},
+				},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor {path}"},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "server.listen[1]",
+		},
+		{
+			name: "TLS certificate without a key",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port: 3339,
+					TLS:  ServerTLSConfig{CertFile: "/etc/rcode/tls/server.pem"},
+				},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor {path}"},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "server.tls",
+		},
+		{
+			name: "duplicate signing key",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port: 3339,
+					Signing: SigningConfig{Keys: []string{
+						"0123456789abcdef0123456789abcdef",
+						"0123456789abcdef0123456789abcdef",
+					}},
+				},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor {path}"},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "server.signing.keys[1]",
+		},
+		{
+			name: "short signing key",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port:    3339,
+					Signing: SigningConfig{Keys: []string{"short"}},
+				},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor {path}"},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "server.signing.keys[0]",
+		},
+		{
+			name: "TLS client CA without a certificate",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port: 3339,
+					TLS:  ServerTLSConfig{ClientCAFile: "/etc/rcode/tls/ca.pem"},
+				},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor {path}"},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "server.tls.client_ca_file",
+		},
+		{
+			name: "IPv6 listen host",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Host: "::",
+					Port: 3339,
+				},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor {path}"},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid IP in whitelist",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port:       3339,
+					AllowedIPs: []string{"192.168.1.1", "invalid-ip"},
+				},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor {path}"},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "invalid IP or CIDR",
+		},
+		{
+			name: "auth token too short",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port:      3339,
+					AuthToken: "short",
+				},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor {path}"},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "auth token must be at least",
+		},
+		{
+			name: "no editors",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port: 3339,
+				},
+				Editors: []EditorConfig{},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "at least one editor must be configured",
+		},
+		{
+			name: "multiple default editors",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port: 3339,
+				},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor {path}", Default: true},
+					{Name: "vscode", Command: "code {path}", Default: true},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "only one editor can be marked as default",
+		},
+		{
+			name: "duplicate editor names",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port: 3339,
+				},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor {path}"},
+					{Name: "cursor", Command: "cursor2 {path}"},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "duplicate editor name",
+		},
+		{
+			name: "duplicate editor names differing in case",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port: 3339,
+				},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor {path}"},
+					{Name: "Cursor", Command: "cursor2 {path}"},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "duplicate editor name",
+		},
+		{
+			name: "valid aliases",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port: 3339,
+				},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor {path}", Aliases: []string{"c"}},
+					{Name: "vscode", Command: "code {path}", Aliases: []string{"code"}},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "alias conflicts with editor name",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port: 3339,
+				},
+				Editors: []EditorConfig{
+					{Name: "vscode", Command: "code {path}", Aliases: []string{"Cursor"}},
+					{Name: "cursor", Command: "cursor {path}"},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "alias Cursor conflicts with editor cursor",
+		},
+		{
+			name: "alias conflicts with another alias",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port: 3339,
+				},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor {path}", Aliases: []string{"c"}},
+					{Name: "vscode", Command: "code {path}", Aliases: []string{"C"}},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "alias C conflicts with alias c of editor cursor",
+		},
+		{
+			name: "empty alias",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port: 3339,
+				},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor {path}", Aliases: []string{" "}},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "alias cannot be empty",
+		},
+		{
+			name: "template variables",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port: 3339,
+				},
+				Editors: []EditorConfig{
+					{
+						Name:      "zed",
+						Command:   "zed ssh://{user}@{host}:{port}{path|urlpath}",
+						Variables: map[string]string{"port": "22"},
+					},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "undefined template variable",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port: 3339,
+				},
+				Editors: []EditorConfig{
+					{Name: "zed", Command: "zed ssh://{host}:{ssh_port}{path}"},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "unknown placeholder {ssh_port}",
+		},
+		{
+			name: "variable redefines built-in placeholder",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port: 3339,
+				},
+				Editors: []EditorConfig{
+					{Name: "zed", Command: "zed {path}", Variables: map[string]string{"host": "x"}},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "variable host is built in",
+		},
+		{
+			name: "missing required placeholder",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port: 3339,
+				},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor --remote"},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "missing required placeholder: {path}",
+		},
+		{
+			name: "invalid log level",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port: 3339,
+				},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor {path}"},
+				},
+				Logging: LogConfig{
+					Level: "invalid",
+				},
+			},
+			wantErr: true,
+			errMsg:  "invalid log level",
+		},
+		{
+			name: "invalid log format",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port: 3339,
+				},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor {path}"},
+				},
+				Logging: LogConfig{
+					Level:  "info",
+					Format: "logfmt",
+				},
+			},
+			wantErr: true,
+			errMsg:  "invalid log format",
+		},
+		{
+			name: "invalid rotation schedule",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port: 3339,
+				},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor {path}"},
+				},
+				Logging: LogConfig{
+					Level:  "info",
+					Rotate: "hourly",
+				},
+			},
+			wantErr: true,
+			errMsg:  "invalid rotation schedule",
+		},
+		{
+			name: "dedup window of an unknown level",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port: 3339,
+				},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor {path}"},
+				},
+				Logging: LogConfig{
+					Level: "info",
+					Dedup: map[string]time.Duration{"fatal": time.Minute},
+				},
+			},
+			wantErr: true,
+			errMsg:  "logging.dedup.fatal",
+		},
+		{
+			name: "negative idempotency window",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port:   3339,
+					Launch: LaunchConfig{IdempotencyWindow: -time.Minute},
+				},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor {path}"},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "server.launch.idempotency_window",
+		},
+		{
+			name: "negative audit max size",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port: 3339,
+				},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor {path}"},
+				},
+				Logging: LogConfig{Level: "info"},
+				Audit:   AuditConfig{MaxSize: -1},
+			},
+			wantErr: true,
+			errMsg:  "max size cannot be negative",
+		},
+		{
+			name: "too many editor retries",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port: 3339,
+				},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor {path}", Retries: MaxEditorRetries + 1},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "retries must be between 0",
+		},
+		{
+			name: "invalid exec mode",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port: 3339,
+				},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor {path}", ExecMode: "bash"},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "exec_mode must be",
+		},
+		{
+			name: "broker without auth token",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port:   3339,
+					Broker: "ws://remote:3340",
+				},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor {path}"},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "broker requires server.auth_token",
+		},
+		{
+			name: "session on command editor",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port: 3339,
+				},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor {path}", Session: "dev"},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "session is only used by tmux editors",
+		},
+		{
+			name: "sync on browser editor",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port: 3339,
+				},
+				Editors: []EditorConfig{
+					{Name: "vscode-web", Type: EditorTypeBrowser, URL: "https://vscode.dev/{path}", Sync: true},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "sync is only used by command editors",
+		},
+		{
+			name: "negative sync poll interval",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port: 3339,
+					Sync: SyncConfig{PollInterval: -time.Second},
+				},
+				Editors: []EditorConfig{
+					{Name: "gedit", Command: "gedit {path}", Sync: true},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "poll interval cannot be negative",
+		},
+		{
+			name: "duplicate terminal",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port: 3339,
+				},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor {path}"},
+				},
+				Terminals: []EditorConfig{
+					{Name: "wezterm", Command: "wezterm start --cwd {path}"},
+					{Name: "WezTerm", Command: "wezterm start --cwd {path}"},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "duplicate terminal name",
+		},
+		{
+			name: "tmux editor",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port: 3339,
+				},
+				Editors: []EditorConfig{
+					{Name: "tmux", Type: EditorTypeTmux, Command: "nvim {path}", Session: "dev"},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "negative editor timeout",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port: 3339,
+				},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor {path}", WaitForExit: true, Timeout: -time.Second},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "timeout cannot be negative",
+		},
+		{
+			name: "unknown fallback editor",
+			config: ServerConfigFile{
+				Server: ServerConfig{
+					Port:           3339,
+					EditorFallback: []string{"c", "zed"},
+				},
+				Editors: []EditorConfig{
+					{Name: "cursor", Command: "cursor {path}", Aliases: []string{"c"}},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "server.editor_fallback[1] - unknown editor: zed",
+		},
+		{
+			name: "unknown user default editor",
+			config: ServerConfigFile{
+				Server:  ServerConfig{Port: 3339},
+				Editors: []EditorConfig{{Name: "cursor", Command: "cursor {path}"}},
+				Logging: LogConfig{Level: "info"},
+				Users: map[string]UserConfig{
+					"alice": {DefaultEditor: "Cursor"},
+					"bob":   {DefaultEditor: "nvim"},
+				},
+			},
+			wantErr: true,
+			errMsg:  "users.bob.default_editor - unknown editor: nvim",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt // Capture range variable
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateServerConfig(&tt.config)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ValidateServerConfig() error = nil, want error containing %q", tt.errMsg)
+				} else if !strings.Contains(err.Error(), tt.errMsg) {
+					t.Errorf("ValidateServerConfig() error = %v, want error containing %q", err, tt.errMsg)
+				}
+			} else {
+				if err != nil {
+					t.Errorf("ValidateServerConfig() error = %v, want nil", err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateServerConfig_ShellModesOnWindows(t *testing.T) {
+	original := goos
+	defer func() { goos = original }()
+
+	for _, mode := range []ExecMode{ExecModeShell, ExecModeLoginShell} {
+		cfg := &ServerConfigFile{
+			Server:  ServerConfig{Port: 3339},
+			Editors: []EditorConfig{{Name: "cursor", Command: "cursor {path}", ExecMode: mode}},
+			Logging: LogConfig{Level: "info"},
+		}
+
+		goos = "linux"
+		if err := ValidateServerConfig(cfg); err != nil {
+			t.Errorf("exec_mode %s on linux: error = %v, want nil", mode, err)
+		}
+		goos = "windows"
+		err := ValidateServerConfig(cfg)
+		if err == nil || !strings.Contains(err.Error(), "not supported on Windows") {
+			t.Errorf("exec_mode %s on windows: error = %v, want not supported on Windows", mode, err)
+		}
+	}
+}
+
+func TestValidateClientConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  ClientConfig
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name: "valid config",
+			config: ClientConfig{
+				Hosts: HostsConfig{
+					Server: ServerHostConfig{
+						Primary:  "192.168.1.100",
+						Fallback: "100.64.0.1",
+					},
+				},
+				Network: ClientNetworkConfig{
+					Timeout:       2 * time.Second,
+					RetryAttempts: 3,
+					RetryDelay:    500 * time.Millisecond,
+				},
+				DefaultEditor: "cursor",
+				Logging: LogConfig{
+					Level: "info",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "hosts override and DNS server",
+			config: ClientConfig{
+				Hosts: HostsConfig{
+					Server: ServerHostConfig{Primary: "ws01tail"},
+				},
+				Network:        ClientNetworkConfig{DNSServer: "100.100.100.100"},
+				HostsOverrides: map[string]string{"ws01tail": "100.64.0.2"},
+				Logging:        LogConfig{Level: "info"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "hosts override to a name",
+			config: ClientConfig{
+				Hosts: HostsConfig{
+					Server: ServerHostConfig{Primary: "ws01tail"},
+				},
+				HostsOverrides: map[string]string{"ws01tail": "ws01.example.com"},
+				Logging:        LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "hosts_overrides.ws01tail",
+		},
+		{
+			name: "client certificate without a key",
+			config: ClientConfig{
+				Hosts: HostsConfig{
+					Server: ServerHostConfig{
+						Primary: "ws01tail",
+						TLS:     ClientTLSConfig{CAFile: "/home/alice/.config/rcode/tls/ca.pem", CertFile: "/home/alice/.config/rcode/tls/client.pem"},
+					},
+				},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "hosts.server.tls",
+		},
+		{
+			name: "DNS server with a bad port",
+			config: ClientConfig{
+				Hosts: HostsConfig{
+					Server: ServerHostConfig{Primary: "ws01tail"},
+				},
+				Network: ClientNetworkConfig{DNSServer: "10.0.0.1:0"},
+				Logging: LogConfig{Level: "info"},
+			},
+			wantErr: true,
+			errMsg:  "network.dns_server",
+		},
+		{
+			name: "valid config with any default editor name",
+			config: ClientConfig{
+				Hosts: HostsConfig{
+					Server: ServerHostConfig{
+						Primary: "192.168.1.100",
+					},
+				},
+				DefaultEditor: "sublime", // Any editor name is valid - validation happens on server
+				Logging: LogConfig{
+					Level: "info",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "missing primary host",
+			config: ClientConfig{
+				Hosts: HostsConfig{
+					Server: ServerHostConfig{
+						Primary: "",
+					},
+				},
+				Logging: LogConfig{
+					Level: "info",
+				},
+			},
+			wantErr: true,
+			errMsg:  "primary server host cannot be empty",
+		},
+		{
+			name: "negative timeout",
+			config: ClientConfig{
+				Hosts: HostsConfig{
+					Server: ServerHostConfig{
+						Primary: "192.168.1.100",
+					},
+				},
+				Network: ClientNetworkConfig{
+					Timeout: -1 * time.Second,
 				},
 				Logging: LogConfig{
-					Level:      "info",
-					File:       "/tmp/test.log",
-					MaxSize:    10,
-					MaxBackups: 5,
-					MaxAge:     30,
+					Level: "info",
+				},
+			},
+			wantErr: true,
+			errMsg:  "timeout cannot be negative",
+		},
+		{
+			name: "bookmarks",
+			config: ClientConfig{
+				Hosts: HostsConfig{
+					Server: ServerHostConfig{
+						Primary: "192.168.1.100",
+					},
+				},
+				Bookmarks: map[string]string{"api": "/src/monorepo/services/api", "web-app_2": "/src/web"},
+				Logging: LogConfig{
+					Level: "info",
 				},
 			},
 			wantErr: false,
 		},
 		{
-			name: "invalid port",
-			config: ServerConfigFile{
-				Server: ServerConfig{
-					Port: 70000,
+			name: "invalid bookmark name",
+			config: ClientConfig{
+				Hosts: HostsConfig{
+					Server: ServerHostConfig{
+						Primary: "192.168.1.100",
+					},
 				},
-				Editors: []EditorConfig{
-					{Name: "cursor", Command: "cursor {path}"},
+				Bookmarks: map[string]string{"my/api": "/src/api"},
+				Logging: LogConfig{
+					Level: "info",
 				},
-				Logging: LogConfig{Level: "info"},
 			},
 			wantErr: true,
-			errMsg:  "invalid port number",
+			errMsg:  "bookmark names may only hold letters, digits, - and _",
 		},
 		{
-			name: "invalid IP in whitelist",
-			config: ServerConfigFile{
-				Server: ServerConfig{
-					Port:       3339,
-					AllowedIPs: []string{"192.168.1.1", "invalid-ip"},
+			name: "relative bookmark path",
+			config: ClientConfig{
+				Hosts: HostsConfig{
+					Server: ServerHostConfig{
+						Primary: "192.168.1.100",
+					},
 				},
-				Editors: []EditorConfig{
-					{Name: "cursor", Command: "cursor {path}"},
+				Bookmarks: map[string]string{"api": "src/api"},
+				Logging: LogConfig{
+					Level: "info",
 				},
-				Logging: LogConfig{Level: "info"},
 			},
 			wantErr: true,
-			errMsg:  "invalid IP or CIDR",
+			errMsg:  "must be absolute",
 		},
 		{
-			name: "no editors",
-			config: ServerConfigFile{
-				Server: ServerConfig{
-					Port: 3339,
+			name: "local mode with local editors",
+			config: ClientConfig{
+				Hosts: HostsConfig{
+					Server: ServerHostConfig{
+						Primary: "192.168.1.100",
+					},
+				},
+				LocalMode:    LocalModeAuto,
+				LocalEditors: map[string]string{"sublime": "subl {path}"},
+				Logging: LogConfig{
+					Level: "info",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid local mode",
+			config: ClientConfig{
+				Hosts: HostsConfig{
+					Server: ServerHostConfig{
+						Primary: "192.168.1.100",
+					},
+				},
+				LocalMode: "sometimes",
+				Logging: LogConfig{
+					Level: "info",
 				},
-				Editors: []EditorConfig{},
-				Logging: LogConfig{Level: "info"},
 			},
 			wantErr: true,
-			errMsg:  "at least one editor must be configured",
+			errMsg:  "invalid local mode",
 		},
 		{
-			name: "multiple default editors",
-			config: ServerConfigFile{
-				Server: ServerConfig{
-					Port: 3339,
+			name: "invalid create mode",
+			config: ClientConfig{
+				Hosts: HostsConfig{
+					Server: ServerHostConfig{
+						Primary: "192.168.1.100",
+					},
 				},
-				Editors: []EditorConfig{
-					{Name: "cursor", Command: "cursor {path}", Default: true},
-					{Name: "vscode", Command: "code {path}", Default: true},
+				Create: CreateModes{FileMode: 0o4755},
+				Logging: LogConfig{
+					Level: "info",
 				},
-				Logging: LogConfig{Level: "info"},
 			},
 			wantErr: true,
-			errMsg:  "only one editor can be marked as default",
+			errMsg:  "file mode 04755 has bits other than permissions",
 		},
 		{
-			name: "duplicate editor names",
-			config: ServerConfigFile{
-				Server: ServerConfig{
-					Port: 3339,
+			name: "server address with scheme",
+			config: ClientConfig{
+				Hosts: HostsConfig{
+					Server: ServerHostConfig{
+						Primary:  "192.168.1.100",
+						Fallback: "http://100.64.0.1:3339",
+					},
 				},
-				Editors: []EditorConfig{
-					{Name: "cursor", Command: "cursor {path}"},
-					{Name: "cursor", Command: "cursor2 {path}"},
+				Logging: LogConfig{
+					Level: "info",
 				},
-				Logging: LogConfig{Level: "info"},
 			},
 			wantErr: true,
-			errMsg:  "duplicate editor name",
+			errMsg:  "hosts.server.fallback",
 		},
 		{
-			name: "missing required placeholder",
-			config: ServerConfigFile{
-				Server: ServerConfig{
-					Port: 3339,
+			name: "invalid server port",
+			config: ClientConfig{
+				Hosts: HostsConfig{
+					Server: ServerHostConfig{
+						Primary: "192.168.1.100",
+						Port:    70000,
+					},
 				},
-				Editors: []EditorConfig{
-					{Name: "cursor", Command: "cursor --remote"},
+				Logging: LogConfig{
+					Level: "info",
 				},
-				Logging: LogConfig{Level: "info"},
 			},
 			wantErr: true,
-			errMsg:  "missing required placeholder: {path}",
+			errMsg:  "hosts.server.port",
 		},
 		{
-			name: "invalid log level",
-			config: ServerConfigFile{
-				Server: ServerConfig{
-					Port: 3339,
+			name: "IPv6 server addresses",
+			config: ClientConfig{
+				Hosts: HostsConfig{
+					Server: ServerHostConfig{
+						Primary:  "fd7a:115c:a1e0::1",
+						Fallback: "[fd7a:115c:a1e0::2]:3339",
+					},
 				},
-				Editors: []EditorConfig{
-					{Name: "cursor", Command: "cursor {path}"},
+				Logging: LogConfig{
+					Level: "info",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid host selection",
+			config: ClientConfig{
+				Hosts: HostsConfig{
+					Server: ServerHostConfig{
+						Primary: "192.168.1.100",
+					},
+				},
+				Network: ClientNetworkConfig{
+					HostSelection: "fastest",
 				},
 				Logging: LogConfig{
-					Level: "invalid",
+					Level: "info",
 				},
 			},
 			wantErr: true,
-			errMsg:  "invalid log level",
+			errMsg:  "invalid host selection",
 		},
-	}
-
-	for _, tt := range tests {
-		tt := tt // Capture range variable
-		t.Run(tt.name, func(t *testing.T) {
-			err := ValidateServerConfig(&tt.config)
-			if tt.wantErr {
-				if err == nil {
-					t.Errorf("ValidateServerConfig() error = nil, want error containing %q", tt.errMsg)
-				} else if !strings.Contains(err.Error(), tt.errMsg) {
-					t.Errorf("ValidateServerConfig() error = %v, want error containing %q", err, tt.errMsg)
-				}
-			} else {
-				if err != nil {
-					t.Errorf("ValidateServerConfig() error = %v, want nil", err)
-				}
-			}
-		})
-	}
-}
-
-func TestValidateClientConfig(t *testing.T) {
-	tests := []struct {
-		name    string
-		config  ClientConfig
-		wantErr bool
-		errMsg  string
-	}{
 		{
-			name: "valid config",
+			name: "invalid profile server address",
 			config: ClientConfig{
 				Hosts: HostsConfig{
 					Server: ServerHostConfig{
-						Primary:  "192.168.1.100",
-						Fallback: "100.64.0.1",
+						Primary: "192.168.1.100",
 					},
 				},
-				Network: ClientNetworkConfig{
-					Timeout:       2 * time.Second,
-					RetryAttempts: 3,
-					RetryDelay:    500 * time.Millisecond,
+				Profiles: map[string]ProfileConfig{
+					"office": {Server: ServerHostConfig{Primary: "http://10.0.0.5/"}},
 				},
-				DefaultEditor: "cursor",
 				Logging: LogConfig{
 					Level: "info",
 				},
 			},
-			wantErr: false,
+			wantErr: true,
+			errMsg:  "profiles.office.server.primary",
 		},
 		{
-			name: "valid config with any default editor name",
+			name: "invalid profile match rules",
 			config: ClientConfig{
 				Hosts: HostsConfig{
 					Server: ServerHostConfig{
 						Primary: "192.168.1.100",
 					},
 				},
-				DefaultEditor: "sublime", // Any editor name is valid - validation happens on server
+				Profiles: map[string]ProfileConfig{
+					"office": {Match: ProfileMatch{ServerIP: []string{"10.0.0.0/33"}, Hostname: "(office"}},
+				},
 				Logging: LogConfig{
 					Level: "info",
 				},
 			},
-			wantErr: false,
+			wantErr: true,
+			errMsg:  "profiles.office.match.server_ip[0]",
 		},
 		{
-			name: "missing primary host",
+			name: "invalid VPN type",
 			config: ClientConfig{
 				Hosts: HostsConfig{
 					Server: ServerHostConfig{
-						Primary: "",
+						Primary: "192.168.1.100",
+					},
+					SSH: SSHHostConfig{
+						AutoDetect: AutoDetectConfig{
+							VPN: []VPNDetectConfig{{Type: VPNZeroTier}, {Type: "openvpn"}},
+						},
 					},
 				},
 				Logging: LogConfig{
@@ -214,25 +1051,39 @@ func TestValidateClientConfig(t *testing.T) {
 				},
 			},
 			wantErr: true,
-			errMsg:  "primary server host cannot be empty",
+			errMsg:  "hosts.ssh.auto_detect.vpn[1].type",
 		},
 		{
-			name: "negative timeout",
+			name: "local editor without path",
 			config: ClientConfig{
 				Hosts: HostsConfig{
 					Server: ServerHostConfig{
 						Primary: "192.168.1.100",
 					},
 				},
-				Network: ClientNetworkConfig{
-					Timeout: -1 * time.Second,
+				LocalEditors: map[string]string{"sublime": "subl"},
+				Logging: LogConfig{
+					Level: "info",
+				},
+			},
+			wantErr: true,
+			errMsg:  "local_editors.sublime",
+		},
+		{
+			name: "invalid telemetry endpoint",
+			config: ClientConfig{
+				Hosts: HostsConfig{
+					Server: ServerHostConfig{
+						Primary: "192.168.1.100",
+					},
 				},
 				Logging: LogConfig{
 					Level: "info",
 				},
+				Telemetry: TelemetryConfig{Enabled: true, Endpoint: "localhost:4318"},
 			},
 			wantErr: true,
-			errMsg:  "timeout cannot be negative",
+			errMsg:  "telemetry.endpoint",
 		},
 	}
 
@@ -289,6 +1140,17 @@ func TestValidateCommandTemplate(t *testing.T) {
 			command: "ssh {user}@{host} editor {path}",
 			wantErr: false,
 		},
+		{
+			name:    "valid tramp file name",
+			command: "emacsclient -n /ssh:{user}@{host}:{path}",
+			wantErr: false,
+		},
+		{
+			name:    "tramp file name without colon before path",
+			command: "emacsclient -n /ssh:{user}@{host}{path}",
+			wantErr: true,
+			errMsg:  "must end with :{path}",
+		},
 	}
 
 	for _, tt := range tests {
@@ -309,6 +1171,51 @@ func TestValidateCommandTemplate(t *testing.T) {
 	}
 }
 
+func FuzzValidateCommandTemplate(f *testing.F) {
+	for _, seed := range []string{
+		"cursor --remote ssh-remote+{user}@{host} {path}",
+		"emacsclient -n /ssh:{user}@{host}:{path}",
+		"ssh {user}@{host} 'editor {path}'",
+		`sh -c "editor {path}"`,
+		`editor \{path}`,
+		"code {path|dirname|shellescape} {port:8080}",
+		"editor {path} {{path}} {path",
+		"editor {päth} {path:ü}",
+		"",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, command string) {
+		err := validateCommandTemplate(command)
+		if err != nil {
+			if !errors.Is(err, validation.ErrInvalidTemplate) && !errors.Is(err, validation.ErrMissingPlaceholder) {
+				t.Fatalf("validateCommandTemplate(%q) error = %v, want an invalid template error", command, err)
+			}
+			return
+		}
+
+		// An accepted template parses, uses {path} and only placeholders the
+		// server fills in
+		placeholders, err := validation.ParsePlaceholders(command)
+		if err != nil {
+			t.Fatalf("validateCommandTemplate(%q) accepted a template that does not parse: %v", command, err)
+		}
+		hasPath := false
+		for _, p := range placeholders {
+			if p.Name == "path" {
+				hasPath = true
+			}
+			if !validation.BuiltinPlaceholders[p.Name] && !p.HasDefault {
+				t.Fatalf("validateCommandTemplate(%q) accepted unknown placeholder %s", command, p.Raw)
+			}
+		}
+		if !hasPath {
+			t.Fatalf("validateCommandTemplate(%q) accepted a template without {path}", command)
+		}
+	})
+}
+
 func TestValidationError(t *testing.T) {
 	err := ValidationError{
 		Field:   "test.field",
@@ -341,3 +1248,23 @@ func TestValidationErrors(t *testing.T) {
 		t.Errorf("Empty ValidationErrors.Error() = %q, want empty string", emptyErrors.Error())
 	}
 }
+
+func TestFieldError(t *testing.T) {
+	err := ValidationErrors{
+		{Field: "server.port", Message: "invalid port number: 0"},
+		{Field: "server.allowed_ips[1]", Message: "invalid IP or CIDR: x"},
+	}
+
+	if got := FieldError(err, "server.allowed_ips"); got == nil || !strings.Contains(got.Error(), "invalid IP or CIDR") {
+		t.Errorf("FieldError(allowed_ips) = %v, want the allowed_ips error", got)
+	}
+	if got := FieldError(err, "server.port"); got == nil || strings.Contains(got.Error(), "allowed_ips") {
+		t.Errorf("FieldError(port) = %v, want only the port error", got)
+	}
+	if got := FieldError(err, "server.host"); got != nil {
+		t.Errorf("FieldError(host) = %v, want nil", got)
+	}
+	if got := FieldError(nil, "server.port"); got != nil {
+		t.Errorf("FieldError(nil) = %v, want nil", got)
+	}
+}
@@ -0,0 +1,9 @@
+// Package e2e holds end-to-end tests that build rcode and rcode-server,
+// start the server with an editor that only records what it was asked to
+// open, and run the client against it the way it runs in an SSH session.
+//
+// The tests are behind the e2e build tag, since they build both binaries
+// and run real processes; run them with make e2e or
+//
+//	go test -tags e2e ./internal/e2e/...
+package e2e
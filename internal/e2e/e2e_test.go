@@ -0,0 +1,127 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestOpen(t *testing.T) {
+	e := newEnv(t)
+	server := e.startServer()
+	config := e.clientConfig(server, "", 1)
+
+	res := e.rcode("--config", config, e.home)
+	if res.err != nil {
+		t.Fatalf("rcode failed: %v\nstdout: %s\nstderr: %s", res.err, res.stdout, res.stderr)
+	}
+	if !strings.Contains(res.stdout, "Successfully opened "+e.home) {
+		t.Errorf("stdout = %q", res.stdout)
+	}
+	want := "alice@192.0.2.10 " + e.home
+	if got := e.openedLines(); len(got) != 1 || got[0] != want {
+		t.Errorf("editor opened %q, want [%q]", got, want)
+	}
+
+	// A dry run renders the command without running it
+	res = e.rcode("--config", config, "--dry-run", e.home)
+	if res.err != nil {
+		t.Fatalf("rcode --dry-run failed: %v\nstderr: %s", res.err, res.stderr)
+	}
+	if !strings.Contains(res.stdout, "echo alice@192.0.2.10") {
+		t.Errorf("dry run stdout = %q", res.stdout)
+	}
+	if got := e.openedLines(); len(got) != 1 {
+		t.Errorf("dry run ran the editor: %q", got)
+	}
+
+	res = e.rcode("--config", config, "editors")
+	if res.err != nil || !strings.Contains(res.stdout, "echo") {
+		t.Errorf("rcode editors = %q, %v", res.stdout, res.err)
+	}
+}
+
+func TestFallback(t *testing.T) {
+	e := newEnv(t)
+	server := e.startServer()
+	// Nothing listens on the primary host
+	config := e.clientConfig(freeAddr(t), server, 1)
+
+	res := e.rcode("--config", config, "--verbose", e.home)
+	if res.err != nil {
+		t.Fatalf("rcode failed: %v\nstdout: %s\nstderr: %s", res.err, res.stdout, res.stderr)
+	}
+	// --verbose logs to stdout
+	if !strings.Contains(res.stdout, "Primary host failed") {
+		t.Errorf("log does not report the primary host failing:\n%s", res.stdout)
+	}
+	if got := e.openedLines(); len(got) != 1 {
+		t.Errorf("editor opened %q, want one open", got)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	e := newEnv(t)
+	server := e.startServer()
+	proxy, opens := flakyProxy(t, server, 2)
+	config := e.clientConfig(proxy, "", 3)
+
+	res := e.rcode("--config", config, e.home)
+	if res.err != nil {
+		t.Fatalf("rcode failed: %v\nstdout: %s\nstderr: %s", res.err, res.stdout, res.stderr)
+	}
+	if n := opens.Load(); n != 3 {
+		t.Errorf("server was sent %d open requests, want 3", n)
+	}
+	if got := e.openedLines(); len(got) != 1 {
+		t.Errorf("editor opened %q, want one open", got)
+	}
+}
+
+func TestRetryExhausted(t *testing.T) {
+	e := newEnv(t)
+	server := e.startServer()
+	proxy, opens := flakyProxy(t, server, 100)
+	config := e.clientConfig(proxy, "", 2)
+
+	res := e.rcode("--config", config, e.home)
+	if res.err == nil {
+		t.Fatalf("rcode succeeded through an unavailable server\nstdout: %s", res.stdout)
+	}
+	if n := opens.Load(); n != 2 {
+		t.Errorf("server was sent %d open requests, want 2", n)
+	}
+	if got := e.openedLines(); len(got) != 0 {
+		t.Errorf("editor opened %q through an unavailable server", got)
+	}
+}
+
+// flakyProxy starts a proxy in front of the server at addr that answers
+// the first failures open requests with 503 Service Unavailable, as a
+// restarting server or a gateway would, and forwards everything else. It
+// returns the proxy's address and the number of open requests it received.
+func flakyProxy(t *testing.T, addr string, failures int64) (string, *atomic.Int64) {
+	t.Helper()
+	target, err := url.Parse("http://" + addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forward := httputil.NewSingleHostReverseProxy(target)
+
+	var opens atomic.Int64
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/open-editor" && opens.Add(1) <= failures {
+			http.Error(w, "server restarting", http.StatusServiceUnavailable)
+			return
+		}
+		forward.ServeHTTP(w, r)
+	}))
+	t.Cleanup(proxy.Close)
+	return strings.TrimPrefix(proxy.URL, "http://"), &opens
+}
@@ -0,0 +1,228 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startTimeout bounds how long the server may take to answer /health
+const startTimeout = 10 * time.Second
+
+// binDir holds the binaries built by TestMain
+var binDir string
+
+func TestMain(m *testing.M) {
+	if runtime.GOOS == "windows" {
+		// The echo editor runs through /bin/sh
+		fmt.Println("skipping end-to-end tests on windows")
+		os.Exit(0)
+	}
+
+	dir, err := os.MkdirTemp("", "rcode-e2e-")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	binDir = dir
+
+	code := 1
+	if err := buildBinaries(dir); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	} else {
+		code = m.Run()
+	}
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// buildBinaries builds rcode and rcode-server into dir
+func buildBinaries(dir string) error {
+	for name, pkg := range map[string]string{
+		"rcode":        "github.com/foxytanuki/rcode/cmd/rcode",
+		"rcode-server": "github.com/foxytanuki/rcode/cmd/server",
+	} {
+		cmd := exec.Command("go", "build", "-o", filepath.Join(dir, name), pkg) // #nosec G204 -- fixed arguments
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to build %s: %v\n%s", name, err, out)
+		}
+	}
+	return nil
+}
+
+// env is one test's isolated machine: a home directory for the config,
+// state and log files of both binaries, and the file the echo editor
+// appends each open to
+type env struct {
+	t      *testing.T
+	dir    string
+	home   string
+	opened string
+}
+
+func newEnv(t *testing.T) *env {
+	t.Helper()
+	dir := t.TempDir()
+	e := &env{
+		t:      t,
+		dir:    dir,
+		home:   filepath.Join(dir, "home"),
+		opened: filepath.Join(dir, "opened.log"),
+	}
+	if err := os.MkdirAll(e.home, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+// environ returns the environment both binaries run with, as if rcode ran
+// in an SSH session from 192.0.2.10 as alice
+func (e *env) environ() []string {
+	return []string{
+		"HOME=" + e.home,
+		"XDG_CACHE_HOME=" + filepath.Join(e.home, ".cache"),
+		"XDG_CONFIG_HOME=" + filepath.Join(e.home, ".config"),
+		"PATH=" + os.Getenv("PATH"),
+		"USER=alice",
+		"SSH_CONNECTION=192.0.2.10 52000 192.0.2.20 22",
+	}
+}
+
+// writeFile writes a file under the test directory and returns its path
+func (e *env) writeFile(name, content string) string {
+	e.t.Helper()
+	path := filepath.Join(e.dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		e.t.Fatal(err)
+	}
+	return path
+}
+
+// startServer starts rcode-server with an echo editor as the default and
+// returns its address once it answers /health
+func (e *env) startServer() string {
+	e.t.Helper()
+	addr := freeAddr(e.t)
+	host, port, _ := net.SplitHostPort(addr)
+	config := e.writeFile("server-config.yaml", fmt.Sprintf(`server:
+  host: %s
+  port: %s
+editors:
+  - name: echo
+    command: "echo {user}@{host} {path} >> %s"
+    exec_mode: sh
+    wait_for_exit: true
+    default: true
+logging:
+  level: debug
+`, host, port, e.opened))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, filepath.Join(binDir, "rcode-server"), "--config", config) // #nosec G204 -- built by TestMain
+	cmd.Env = e.environ()
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Start(); err != nil {
+		cancel()
+		e.t.Fatalf("failed to start rcode-server: %v", err)
+	}
+	e.t.Cleanup(func() {
+		cancel()
+		_ = cmd.Wait()
+		if e.t.Failed() {
+			e.t.Logf("rcode-server output:\n%s", out.String())
+		}
+	})
+
+	deadline := time.Now().Add(startTimeout)
+	for {
+		resp, err := http.Get("http://" + addr + "/health")
+		if err == nil {
+			_ = resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return addr
+			}
+		}
+		if time.Now().After(deadline) {
+			e.t.Fatalf("rcode-server did not start on %s: %v", addr, err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// clientConfig writes a client config using primary and fallback as the
+// server hosts and returns its path
+func (e *env) clientConfig(primary, fallback string, retryAttempts int) string {
+	e.t.Helper()
+	return e.writeFile("config.yaml", fmt.Sprintf(`hosts:
+  server:
+    primary: %q
+    fallback: %q
+network:
+  timeout: 2s
+  retry_attempts: %d
+  retry_delay: 10ms
+  cache_ttl: -1s
+`, primary, fallback, retryAttempts))
+}
+
+// result is the outcome of one rcode run
+type result struct {
+	stdout string
+	stderr string
+	err    error
+}
+
+// rcode runs the client with args and waits for it to exit
+func (e *env) rcode(args ...string) result {
+	e.t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, filepath.Join(binDir, "rcode"), args...) // #nosec G204 -- built by TestMain
+	cmd.Env = e.environ()
+	cmd.Dir = e.home
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	return result{stdout: stdout.String(), stderr: stderr.String(), err: err}
+}
+
+// openedLines returns what the echo editor was asked to open, one line per
+// open
+func (e *env) openedLines() []string {
+	e.t.Helper()
+	data, err := os.ReadFile(e.opened)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+// freeAddr returns a loopback address no one is listening on
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	_ = l.Close()
+	return addr
+}
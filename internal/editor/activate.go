@@ -0,0 +1,68 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/logger"
+)
+
+// activateDelay gives a freshly launched editor time to open its window
+// before it is brought to the front
+const activateDelay = 500 * time.Millisecond
+
+// ErrActivateUnsupported is returned when no way to focus a window is known
+// for the platform
+var ErrActivateUnsupported = errors.New("window activation not supported")
+
+// activateArgv returns the command that brings app to the front on goos.
+// lookPath reports whether a program is installed.
+func activateArgv(goos, app string, lookPath func(string) (string, error)) (string, []string, error) {
+	switch goos {
+	case "darwin":
+		// AppleScript string literal: escape backslashes and quotes
+		name := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(app)
+		return "osascript", []string{"-e", fmt.Sprintf(`tell application "%s" to activate`, name)}, nil
+	case "windows":
+		return "", nil, fmt.Errorf("%w on %s", ErrActivateUnsupported, goos)
+	default:
+		// X11 only; Wayland compositors do not let clients raise other windows
+		if _, err := lookPath("wmctrl"); err == nil {
+			return "wmctrl", []string{"-x", "-a", app}, nil
+		}
+		if _, err := lookPath("xdotool"); err == nil {
+			return "xdotool", []string{"search", "--onlyvisible", "--class", app, "windowactivate"}, nil
+		}
+		return "", nil, fmt.Errorf("%w: install wmctrl or xdotool", ErrActivateUnsupported)
+	}
+}
+
+// Activate brings app (a macOS application name or an X11 window class) to
+// the front after a short delay, so a running editor that received a new
+// path does not stay behind other windows. Failures are only logged.
+func Activate(app string, log *logger.Logger) {
+	if app == "" {
+		return
+	}
+
+	executable, args, err := activateArgv(runtime.GOOS, app, exec.LookPath)
+	if err != nil {
+		log.Warn("Cannot activate editor window", "app", app, "error", err)
+		return
+	}
+
+	time.AfterFunc(activateDelay, func() {
+		cmd := exec.Command(executable, args...) // #nosec G204 -- the app name comes from the server config
+		if output, err := cmd.CombinedOutput(); err != nil {
+			log.Warn("Failed to activate editor window",
+				"app", app,
+				"error", err,
+				"output", strings.TrimSpace(string(output)),
+			)
+		}
+	})
+}
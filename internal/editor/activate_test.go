@@ -0,0 +1,95 @@
+package editor
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestActivateArgv(t *testing.T) {
+	installed := func(names ...string) func(string) (string, error) {
+		return func(name string) (string, error) {
+			for _, n := range names {
+				if n == name {
+					return "/usr/bin/" + name, nil
+				}
+			}
+			return "", errors.New("not found")
+		}
+	}
+
+	tests := []struct {
+		name     string
+		goos     string
+		app      string
+		lookPath func(string) (string, error)
+		wantExec string
+		wantArgs []string
+		wantErr  bool
+	}{
+		{
+			name:     "macOS osascript",
+			goos:     "darwin",
+			app:      "Visual Studio Code",
+			lookPath: installed(),
+			wantExec: "osascript",
+			wantArgs: []string{"-e", `tell application "Visual Studio Code" to activate`},
+		},
+		{
+			name:     "macOS escapes quotes",
+			goos:     "darwin",
+			app:      `Bad" to quit`,
+			lookPath: installed(),
+			wantExec: "osascript",
+			wantArgs: []string{"-e", `tell application "Bad\" to quit" to activate`},
+		},
+		{
+			name:     "linux prefers wmctrl",
+			goos:     "linux",
+			app:      "cursor",
+			lookPath: installed("wmctrl", "xdotool"),
+			wantExec: "wmctrl",
+			wantArgs: []string{"-x", "-a", "cursor"},
+		},
+		{
+			name:     "linux falls back to xdotool",
+			goos:     "linux",
+			app:      "code",
+			lookPath: installed("xdotool"),
+			wantExec: "xdotool",
+			wantArgs: []string{"search", "--onlyvisible", "--class", "code", "windowactivate"},
+		},
+		{
+			name:     "linux without tools",
+			goos:     "linux",
+			app:      "code",
+			lookPath: installed(),
+			wantErr:  true,
+		},
+		{
+			name:     "windows unsupported",
+			goos:     "windows",
+			app:      "Code",
+			lookPath: installed(),
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executable, args, err := activateArgv(tt.goos, tt.app, tt.lookPath)
+			if tt.wantErr {
+				if !errors.Is(err, ErrActivateUnsupported) {
+					t.Errorf("activateArgv() error = %v, want ErrActivateUnsupported", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("activateArgv() error = %v", err)
+			}
+			if executable != tt.wantExec || !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("activateArgv() = %s %q, want %s %q", executable, args, tt.wantExec, tt.wantArgs)
+			}
+		})
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package editor
+
+import "os/exec"
+
+// detach prepares cmd to outlive the server process. Released children are
+// already reparented on Unix, so there is nothing to set.
+func detach(_ *exec.Cmd) {}
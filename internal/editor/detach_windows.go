@@ -0,0 +1,19 @@
+//go:build windows
+
+package editor
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detachedProcess is the DETACHED_PROCESS creation flag, which syscall does not export.
+const detachedProcess = 0x00000008
+
+// detach starts cmd in its own process group without the server's console, so
+// console events such as Ctrl+C sent to rcode-server do not reach the editor.
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP | detachedProcess,
+	}
+}
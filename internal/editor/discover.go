@@ -0,0 +1,21 @@
+package editor
+
+import "github.com/foxytanuki/rcode/internal/config"
+
+// DiscoverEditors returns the candidates installed on this machine, found
+// the same way as availability: on PATH, by their check command or through
+// a known install location. The first one found becomes the default. It
+// returns nil when none is installed.
+func DiscoverEditors(candidates []config.EditorConfig) []config.EditorConfig {
+	var m Manager
+	var found []config.EditorConfig
+	for _, candidate := range candidates {
+		editor, err := NewEditor(candidate)
+		if err != nil || m.probeAvailability(editor) == "" {
+			continue
+		}
+		candidate.Default = len(found) == 0
+		found = append(found, candidate)
+	}
+	return found
+}
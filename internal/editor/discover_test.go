@@ -0,0 +1,33 @@
+package editor
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/foxytanuki/rcode/internal/config"
+)
+
+func TestDiscoverEditors(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses true")
+	}
+
+	candidates := []config.EditorConfig{
+		{Name: "missing", Command: "rcode-test-no-such-editor {path}", Default: true},
+		{Name: "first", Command: "true {path}"},
+		{Name: "checked", Command: "rcode-test-no-such-editor {path}", Availability: config.AvailabilityConfig{Check: "true"}},
+		{Name: "invalid"},
+	}
+
+	found := DiscoverEditors(candidates)
+	if len(found) != 2 || found[0].Name != "first" || found[1].Name != "checked" {
+		t.Fatalf("DiscoverEditors() = %+v, want first and checked", found)
+	}
+	if !found[0].Default || found[1].Default {
+		t.Errorf("DiscoverEditors() defaults = %v, %v, want only the first", found[0].Default, found[1].Default)
+	}
+
+	if found := DiscoverEditors(candidates[:1]); found != nil {
+		t.Errorf("DiscoverEditors() = %+v, want nil when nothing is installed", found)
+	}
+}
@@ -2,34 +2,247 @@
 package editor
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
 	"runtime"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/foxytanuki/rcode/internal/config"
 	"github.com/foxytanuki/rcode/internal/logger"
 )
 
+// Execution outcomes reported in ExecResult
+const (
+	// OutcomeDetached means the command was started and not watched
+	OutcomeDetached = "detached"
+	// OutcomeRunning means the command was still running when the watch window ended
+	OutcomeRunning = "running"
+	// OutcomeExited means the command exited successfully
+	OutcomeExited = "exited"
+)
+
+// ExecOptions controls how an editor command is run
+type ExecOptions struct {
+	// Mode selects direct execution or a shell (default: argv)
+	Mode config.ExecMode
+	// WaitForExit waits for the command to finish and treats a non-zero
+	// exit status as a failure. Timeout, when set, bounds the wait.
+	WaitForExit bool
+	// Timeout is how long to wait for exit, or when WaitForExit is false,
+	// how long to watch a detached command for an early failure
+	Timeout time.Duration
+	// Retries is the number of extra attempts after a failure
+	Retries int
+	// CaptureOutput keeps up to this many bytes of combined stdout and
+	// stderr from watched commands and attaches them to failures
+	CaptureOutput int
+	// Dir is the working directory of the command (default: the server's)
+	Dir string
+}
+
+// ExecError is a failed editor command together with the output it produced
+type ExecError struct {
+	Err    error
+	Output string // Captured stdout/stderr, possibly truncated
+}
+
+func (e *ExecError) Error() string { return e.Err.Error() }
+
+// Unwrap returns the underlying error
+func (e *ExecError) Unwrap() error { return e.Err }
+
+// cappedBuffer keeps the first max bytes written to it and discards the rest
+type cappedBuffer struct {
+	mu        sync.Mutex
+	buf       []byte
+	max       int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if room := b.max - len(b.buf); room < len(p) {
+		b.buf = append(b.buf, p[:max(room, 0)]...)
+		b.truncated = true
+	} else {
+		b.buf = append(b.buf, p...)
+	}
+	return len(p), nil
+}
+
+// String returns the captured output, marking truncation
+func (b *cappedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	out := strings.TrimRight(string(b.buf), "\n")
+	if b.truncated {
+		out += "\n... (output truncated)"
+	}
+	return out
+}
+
+// failure wraps err with the captured output, if any
+func failure(err error, output *cappedBuffer) error {
+	if output == nil {
+		return err
+	}
+	if captured := output.String(); captured != "" {
+		return &ExecError{Err: err, Output: captured}
+	}
+	return err
+}
+
+// ExecResult describes how an editor command ended up
+type ExecResult struct {
+	Outcome  string        // OutcomeDetached, OutcomeRunning or OutcomeExited
+	Attempts int           // Number of attempts made
+	Duration time.Duration // Time spent in the final attempt
+}
+
 // ExecuteDetached executes a command string, detaching the process for GUI editors.
 func ExecuteDetached(command string, log *logger.Logger) error {
-	executable, args := ParseCommand(command)
+	_, err := Execute(command, ExecOptions{}, log)
+	return err
+}
+
+// Execute runs an editor command according to opts, retrying failed
+// attempts. With zero options it starts the command and returns at once.
+func Execute(command string, opts ExecOptions, log *logger.Logger) (*ExecResult, error) {
+	executable, args, err := commandArgv(command, opts.Mode)
+	if err != nil {
+		return nil, err
+	}
 	if executable == "" {
-		return fmt.Errorf("empty command")
+		return nil, fmt.Errorf("empty command")
 	}
 
-	cmd := exec.Command(executable, args...) // #nosec G204
+	attempts := opts.Retries + 1
+	var lastErr error
+	for i := 1; i <= attempts; i++ {
+		if i > 1 {
+			log.Warn("Retrying editor command", "attempt", i, "max_attempts", attempts, "error", lastErr)
+		}
+
+		result, err := runOnce(executable, args, opts, log)
+		if err == nil {
+			result.Attempts = i
+			return result, nil
+		}
+		lastErr = err
+	}
+
+	if attempts > 1 {
+		var execErr *ExecError
+		if errors.As(lastErr, &execErr) {
+			return nil, &ExecError{Err: fmt.Errorf("%w (after %d attempts)", execErr.Err, attempts), Output: execErr.Output}
+		}
+		return nil, fmt.Errorf("%w (after %d attempts)", lastErr, attempts)
+	}
+	return nil, lastErr
+}
+
+// commandArgv returns the program and arguments that run command in mode
+func commandArgv(command string, mode config.ExecMode) (string, []string, error) {
+	if strings.TrimSpace(command) == "" {
+		return "", nil, nil
+	}
+
+	switch mode {
+	case config.ExecModeShell:
+		if runtime.GOOS == "windows" {
+			// Values are quoted for sh, not cmd.exe; refused by the validator too
+			return "", nil, fmt.Errorf("exec_mode %s is not supported on Windows", mode)
+		}
+		return "/bin/sh", []string{"-c", command}, nil
+	case config.ExecModeLoginShell:
+		if runtime.GOOS == "windows" {
+			return "", nil, fmt.Errorf("exec_mode %s is not supported on Windows", mode)
+		}
+		shell := os.Getenv("SHELL")
+		if shell == "" {
+			shell = "/bin/sh"
+		}
+		return shell, []string{"-lc", command}, nil
+	default:
+		words, err := SplitCommand(command)
+		if err != nil {
+			return "", nil, err
+		}
+		return words[0], words[1:], nil
+	}
+}
+
+// runOnce makes a single attempt at running the command
+func runOnce(executable string, args []string, opts ExecOptions, log *logger.Logger) (*ExecResult, error) {
+	ctx := context.Background()
+	if opts.WaitForExit && opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, executable, args...) // #nosec G204
+	cmd.Dir = opts.Dir
 	cmd.Stdout = nil
 	cmd.Stderr = nil
 	cmd.Stdin = nil
+	if !opts.WaitForExit {
+		detach(cmd)
+	}
+
+	// Only watched commands are captured; an unwatched one would hold the pipe open
+	var output *cappedBuffer
+	if opts.CaptureOutput > 0 && (opts.WaitForExit || opts.Timeout > 0) {
+		output = &cappedBuffer{max: opts.CaptureOutput}
+		cmd.Stdout = output
+		cmd.Stderr = output
+	}
 
+	start := time.Now()
 	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start command: %w", err)
+		return nil, fmt.Errorf("failed to start command: %w", err)
 	}
 
-	if err := cmd.Process.Release(); err != nil {
-		log.Warn("Failed to release process", "error", err)
+	if opts.WaitForExit {
+		err := cmd.Wait()
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, failure(fmt.Errorf("command timed out after %s", opts.Timeout), output)
+		}
+		if err != nil {
+			return nil, failure(fmt.Errorf("command failed: %w", err), output)
+		}
+		return &ExecResult{Outcome: OutcomeExited, Duration: time.Since(start)}, nil
 	}
 
-	return nil
+	if opts.Timeout <= 0 {
+		if err := cmd.Process.Release(); err != nil {
+			log.Warn("Failed to release process", "error", err)
+		}
+		return &ExecResult{Outcome: OutcomeDetached, Duration: time.Since(start)}, nil
+	}
+
+	// Watch the launch for a while; launchers such as `code` exit quickly
+	// with status 0 once the editor is up, while a broken command fails fast
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return nil, failure(fmt.Errorf("command failed: %w", err), output)
+		}
+		return &ExecResult{Outcome: OutcomeExited, Duration: time.Since(start)}, nil
+	case <-time.After(opts.Timeout):
+		return &ExecResult{Outcome: OutcomeRunning, Duration: time.Since(start)}, nil
+	}
 }
 
 // OpenBrowser opens a URL using the OS default browser.
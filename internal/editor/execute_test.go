@@ -0,0 +1,176 @@
+package editor
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/internal/logger"
+)
+
+func TestExecute(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX utilities")
+	}
+
+	log := logger.New(&logger.Config{Level: "error"})
+
+	tests := []struct {
+		name         string
+		command      string
+		opts         ExecOptions
+		wantOutcome  string
+		wantAttempts int
+		wantErr      string
+	}{
+		{
+			name:         "detached by default",
+			command:      "true",
+			wantOutcome:  OutcomeDetached,
+			wantAttempts: 1,
+		},
+		{
+			name:         "wait for exit",
+			command:      "true",
+			opts:         ExecOptions{WaitForExit: true},
+			wantOutcome:  OutcomeExited,
+			wantAttempts: 1,
+		},
+		{
+			name:    "wait for exit reports failure",
+			command: "false",
+			opts:    ExecOptions{WaitForExit: true, Retries: 2},
+			wantErr: "after 3 attempts",
+		},
+		{
+			name:    "wait for exit times out",
+			command: "sleep 5",
+			opts:    ExecOptions{WaitForExit: true, Timeout: 50 * time.Millisecond},
+			wantErr: "timed out",
+		},
+		{
+			name:         "runs in workdir",
+			command:      `[ "$(pwd)" = / ]`,
+			opts:         ExecOptions{Mode: config.ExecModeShell, WaitForExit: true, Dir: "/"},
+			wantOutcome:  OutcomeExited,
+			wantAttempts: 1,
+		},
+		{
+			name:         "watched launch still running",
+			command:      "sleep 5",
+			opts:         ExecOptions{Timeout: 50 * time.Millisecond},
+			wantOutcome:  OutcomeRunning,
+			wantAttempts: 1,
+		},
+		{
+			name:    "watched launch fails early",
+			command: "false",
+			opts:    ExecOptions{Timeout: time.Second},
+			wantErr: "command failed",
+		},
+		{
+			name:         "argv mode keeps quoted arguments together",
+			command:      `test "a b" = 'a b'`,
+			opts:         ExecOptions{WaitForExit: true},
+			wantOutcome:  OutcomeExited,
+			wantAttempts: 1,
+		},
+		{
+			name:    "argv mode does not run shell operators",
+			command: `test a = a && false`,
+			opts:    ExecOptions{WaitForExit: true},
+			wantErr: "command failed",
+		},
+		{
+			name:    "unterminated quote",
+			command: `test "a b = a`,
+			opts:    ExecOptions{WaitForExit: true},
+			wantErr: "unterminated quote",
+		},
+		{
+			name:         "shell mode",
+			command:      `test "a b" = "a b" && true`,
+			opts:         ExecOptions{Mode: config.ExecModeShell, WaitForExit: true},
+			wantOutcome:  OutcomeExited,
+			wantAttempts: 1,
+		},
+		{
+			name:         "login shell mode",
+			command:      `test "a b" = "a b"`,
+			opts:         ExecOptions{Mode: config.ExecModeLoginShell, WaitForExit: true},
+			wantOutcome:  OutcomeExited,
+			wantAttempts: 1,
+		},
+		{
+			name:    "missing executable",
+			command: "rcode-test-no-such-editor {path}",
+			opts:    ExecOptions{Retries: 1},
+			wantErr: "failed to start command",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Execute(tt.command, tt.opts, log)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Execute() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if result.Outcome != tt.wantOutcome {
+				t.Errorf("Execute() outcome = %q, want %q", result.Outcome, tt.wantOutcome)
+			}
+			if result.Attempts != tt.wantAttempts {
+				t.Errorf("Execute() attempts = %d, want %d", result.Attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestExecuteCapturesOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX utilities")
+	}
+
+	log := logger.New(&logger.Config{Level: "error"})
+
+	_, err := Execute("ls /rcode-test-no-such-path", ExecOptions{WaitForExit: true, Retries: 1, CaptureOutput: 16}, log)
+	var execErr *ExecError
+	if !errors.As(err, &execErr) {
+		t.Fatalf("Execute() error = %v, want *ExecError", err)
+	}
+	if !strings.Contains(execErr.Error(), "after 2 attempts") {
+		t.Errorf("Execute() error = %q, want attempt count", execErr.Error())
+	}
+	if !strings.HasSuffix(execErr.Output, "(output truncated)") || len(execErr.Output) > 16+len("\n... (output truncated)") {
+		t.Errorf("Execute() output = %q, want 16 bytes and a truncation marker", execErr.Output)
+	}
+
+	// Output is not captured unless asked for
+	_, err = Execute("ls /rcode-test-no-such-path", ExecOptions{WaitForExit: true}, log)
+	if errors.As(err, &execErr) {
+		t.Errorf("Execute() error = %#v, want no captured output", err)
+	}
+}
+
+func TestExecuteShellModesOnWindows(t *testing.T) {
+	if runtime.GOOS != "windows" {
+		t.Skip("Windows only")
+	}
+
+	log := logger.New(&logger.Config{Level: "error"})
+	for _, mode := range []config.ExecMode{config.ExecModeShell, config.ExecModeLoginShell} {
+		// A path with cmd.exe metacharacters must never reach cmd /C
+		_, err := Execute(`notepad 'C:\a&calc.txt'`, ExecOptions{Mode: mode}, log)
+		if err == nil || !strings.Contains(err.Error(), "not supported on Windows") {
+			t.Errorf("Execute() in %s mode error = %v, want not supported on Windows", mode, err)
+		}
+	}
+}
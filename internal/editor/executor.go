@@ -0,0 +1,146 @@
+package editor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// ErrShuttingDown is returned for launches attempted while an Executor drains
+	ErrShuttingDown = errors.New("server is shutting down")
+	// ErrBusy is returned for launches when every worker is busy and the
+	// queue is full
+	ErrBusy = errors.New("too many editor launches")
+)
+
+// Executor runs editor launches and keeps count of those in flight, so a
+// server that is shutting down can refuse new launches and wait for the
+// running ones. It can also cap how many commands launch at once and how
+// many wait for their turn. The zero value is ready to use.
+type Executor struct {
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+	draining bool
+	inFlight atomic.Int64
+	workers  chan struct{} // one value per busy worker; nil when unlimited
+	queue    int           // launches allowed to wait for a worker
+	waiting  atomic.Int64
+}
+
+// Begin registers a launch as in flight; done must be called when it is
+// over. It returns ErrShuttingDown once Drain has been called.
+func (x *Executor) Begin() (done func(), err error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.draining {
+		return nil, ErrShuttingDown
+	}
+	x.wg.Add(1)
+	x.inFlight.Add(1)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			x.inFlight.Add(-1)
+			x.wg.Done()
+		})
+	}, nil
+}
+
+// Run calls launch as an in-flight launch, or returns ErrShuttingDown
+// without calling it once Drain has been called
+func (x *Executor) Run(launch func() error) error {
+	done, err := x.Begin()
+	if err != nil {
+		return err
+	}
+	defer done()
+	return launch()
+}
+
+// SetLimits lets at most workers commands launch at once and queue more
+// wait for a free worker; launches beyond that fail with ErrBusy. workers <= 0
+// removes the limit. Launches holding a worker keep it until they release it.
+func (x *Executor) SetLimits(workers, queue int) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.queue = queue
+	if workers <= 0 {
+		x.workers = nil
+		return
+	}
+	if x.workers == nil || cap(x.workers) != workers {
+		x.workers = make(chan struct{}, workers)
+	}
+}
+
+// Acquire takes a free worker, waiting in the queue for one when all are
+// busy, and returns the function that frees it again. It returns ErrBusy
+// when the queue is full and ctx's error when ctx ends while waiting.
+func (x *Executor) Acquire(ctx context.Context) (release func(), err error) {
+	x.mu.Lock()
+	workers, queue := x.workers, x.queue
+	x.mu.Unlock()
+	if workers == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case workers <- struct{}{}:
+	default:
+		if x.waiting.Add(1) > int64(queue) {
+			x.waiting.Add(-1)
+			return nil, ErrBusy
+		}
+		defer x.waiting.Add(-1)
+		select {
+		case workers <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() { <-workers })
+	}, nil
+}
+
+// Queued returns the number of launches waiting for a worker
+func (x *Executor) Queued() int {
+	return int(x.waiting.Load())
+}
+
+// Draining reports whether Drain has been called
+func (x *Executor) Draining() bool {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.draining
+}
+
+// InFlight returns the number of launches currently running
+func (x *Executor) InFlight() int {
+	return int(x.inFlight.Load())
+}
+
+// Drain stops new launches and waits for the running ones to finish or for
+// ctx to end, whichever comes first
+func (x *Executor) Drain(ctx context.Context) error {
+	x.mu.Lock()
+	x.draining = true
+	x.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		x.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,117 @@
+package editor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestExecutor_Drain(t *testing.T) {
+	var x Executor
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go func() {
+		_ = x.Run(func() error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+
+	if got := x.InFlight(); got != 1 {
+		t.Errorf("InFlight() = %d, want 1", got)
+	}
+
+	// The launch is still running, so a short drain times out
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := x.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Drain() with a running launch error = %v, want deadline exceeded", err)
+	}
+
+	// New launches are refused once draining started
+	called := false
+	if err := x.Run(func() error { called = true; return nil }); !errors.Is(err, ErrShuttingDown) || called {
+		t.Errorf("Run() while draining = %v (called %v), want ErrShuttingDown without calling", err, called)
+	}
+	if _, err := x.Begin(); !errors.Is(err, ErrShuttingDown) {
+		t.Errorf("Begin() while draining error = %v, want ErrShuttingDown", err)
+	}
+
+	close(release)
+	if err := x.Drain(context.Background()); err != nil {
+		t.Errorf("Drain() after the launch finished error = %v", err)
+	}
+	if got := x.InFlight(); got != 0 {
+		t.Errorf("InFlight() = %d, want 0", got)
+	}
+}
+
+func TestExecutor_BeginDoneOnce(t *testing.T) {
+	var x Executor
+	done, err := x.Begin()
+	if err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	done()
+	done() // a second call must not unbalance the count
+
+	if got := x.InFlight(); got != 0 {
+		t.Errorf("InFlight() = %d, want 0", got)
+	}
+	if err := x.Drain(context.Background()); err != nil {
+		t.Errorf("Drain() error = %v", err)
+	}
+}
+
+func TestExecutor_Acquire(t *testing.T) {
+	var x Executor
+
+	// Without a limit every launch gets a worker
+	release, err := x.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	release()
+
+	x.SetLimits(1, 1)
+	release, err = x.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	// The only worker is busy, so a second launch waits until its context ends
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := x.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Acquire() with a busy worker error = %v, want deadline exceeded", err)
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		next, err := x.Acquire(context.Background())
+		if err == nil {
+			next()
+		}
+		close(acquired)
+	}()
+	for x.Queued() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// The queue holds one launch, so the next is refused
+	if _, err := x.Acquire(context.Background()); !errors.Is(err, ErrBusy) {
+		t.Errorf("Acquire() with a full queue error = %v, want ErrBusy", err)
+	}
+
+	release()
+	release() // a second call must not free another worker
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("Acquire() still waiting after the worker was released")
+	}
+}
@@ -3,7 +3,7 @@ package editor
 import (
 	"errors"
 	"fmt"
-	"os/exec"
+	"sort"
 	"strings"
 	"sync"
 
@@ -29,19 +29,60 @@ type Manager struct {
 	log          *logger.Logger
 	mu           sync.RWMutex
 	availability map[string]bool
+	availableVia map[string]string // How each available editor was found
 	availMu      sync.RWMutex
 }
 
 // Editor represents a single editor configuration
 type Editor struct {
 	Name        string
+	Aliases     []string
+	Variables   map[string]string
 	Command     string
 	Type        config.EditorType
 	URL         string
 	Default     bool
 	Available   bool
 	Template    *Template
+	WorkDir     *Template // Working directory template, nil when unset
 	URLTemplate *Template
+	Exec        ExecOptions
+	Activate    string    // Window to bring to the front after launch, empty to skip
+	Session     string    // tmux session to open windows in (tmux editors), empty for the attached one
+	Diff        *Template // Diff command template, nil when the editor cannot compare files
+	Sync        bool      // Opens a local copy of the file, kept in sync over scp
+
+	Availability config.AvailabilityConfig // Extra ways to find the editor when its command is not on PATH
+	AvailableVia string                    // How the editor was found (set by ListEditors)
+}
+
+// launchTemplate returns the command template, or the URL template for
+// browser editors
+func (e *Editor) launchTemplate() *Template {
+	if e.Type == config.EditorTypeBrowser {
+		return e.URLTemplate
+	}
+	return e.Template
+}
+
+// RequiresHost reports whether the editor's template uses {host}, that is
+// whether it opens the path on the remote machine rather than locally
+func (e *Editor) RequiresHost() bool {
+	t := e.launchTemplate()
+	return t != nil && t.RequiresHost()
+}
+
+// RequiresUser reports whether the editor's template uses {user}
+func (e *Editor) RequiresUser() bool {
+	t := e.launchTemplate()
+	return t != nil && t.RequiresUser()
+}
+
+// RequiresPort reports whether the editor's template needs a {port}, that
+// is whether it opens a web IDE whose port each request must give
+func (e *Editor) RequiresPort() bool {
+	t := e.launchTemplate()
+	return t != nil && t.RequiresPort()
 }
 
 // NewManager creates a new editor manager
@@ -54,6 +95,7 @@ func NewManager(configs []config.EditorConfig, log *logger.Logger) (*Manager, er
 		editors:      make(map[string]*Editor),
 		log:          log,
 		availability: make(map[string]bool),
+		availableVia: make(map[string]string),
 	}
 
 	// Initialize editors from config
@@ -103,24 +145,68 @@ func NewEditor(cfg config.EditorConfig) (*Editor, error) {
 		typeValue = config.EditorTypeCommand
 	}
 
+	if cfg.Sync && typeValue != config.EditorTypeCommand {
+		return nil, fmt.Errorf("%w: sync is only used by command editors", ErrInvalidEditor)
+	}
+
 	switch typeValue {
-	case config.EditorTypeCommand:
+	case config.EditorTypeCommand, config.EditorTypeTmux:
 		if cfg.Command == "" {
-			return nil, fmt.Errorf("%w: command is required for command editor", ErrInvalidEditor)
+			return nil, fmt.Errorf("%w: command is required for %s editor", ErrInvalidEditor, typeValue)
 		}
 
-		template, err := NewTemplate(cfg.Command)
+		template, err := NewTemplateWithVariables(cfg.Command, cfg.Variables)
 		if err != nil {
 			return nil, fmt.Errorf("%w: invalid command template: %v", ErrInvalidEditor, err)
 		}
 
+		// A tmux window starts in the opened path unless told otherwise
+		dir := cfg.WorkDir
+		if dir == "" && typeValue == config.EditorTypeTmux {
+			dir = DefaultTmuxWorkDir
+		}
+
+		var workDir *Template
+		if dir != "" {
+			workDir, err = NewWorkDirTemplate(dir, cfg.Variables)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid workdir template: %v", ErrInvalidEditor, err)
+			}
+		}
+
+		var diff *Template
+		if cfg.Diff != "" {
+			if typeValue != config.EditorTypeCommand {
+				return nil, fmt.Errorf("%w: diff is only used by command editors", ErrInvalidEditor)
+			}
+			if diff, err = NewDiffTemplate(cfg.Diff, cfg.Variables); err != nil {
+				return nil, fmt.Errorf("%w: invalid diff template: %v", ErrInvalidEditor, err)
+			}
+		}
+
 		return &Editor{
 			Name:      cfg.Name,
+			Aliases:   cfg.Aliases,
+			Variables: cfg.Variables,
 			Type:      typeValue,
 			Command:   cfg.Command,
 			Default:   cfg.Default,
 			Available: cfg.Available,
 			Template:  template,
+			WorkDir:   workDir,
+			Activate:  cfg.Activate,
+			Session:   cfg.Session,
+			Diff:      diff,
+			Sync:      cfg.Sync,
+
+			Availability: cfg.Availability,
+			Exec: ExecOptions{
+				Mode:          cfg.ExecMode,
+				WaitForExit:   cfg.WaitForExit,
+				Timeout:       cfg.Timeout,
+				Retries:       cfg.Retries,
+				CaptureOutput: cfg.CaptureKB * 1024,
+			},
 		}, nil
 
 	case config.EditorTypeBrowser:
@@ -128,26 +214,29 @@ func NewEditor(cfg config.EditorConfig) (*Editor, error) {
 			return nil, fmt.Errorf("%w: url is required for browser editor", ErrInvalidEditor)
 		}
 
-		urlTemplate, err := NewTemplate(cfg.URL)
+		urlTemplate, err := NewTemplateWithVariables(cfg.URL, cfg.Variables)
 		if err != nil {
 			return nil, fmt.Errorf("%w: invalid url template: %v", ErrInvalidEditor, err)
 		}
 
 		return &Editor{
 			Name:        cfg.Name,
+			Aliases:     cfg.Aliases,
+			Variables:   cfg.Variables,
 			Type:        typeValue,
 			URL:         cfg.URL,
 			Default:     cfg.Default,
 			Available:   cfg.Available,
 			URLTemplate: urlTemplate,
+			Activate:    cfg.Activate,
 		}, nil
 
 	default:
-		return nil, fmt.Errorf("%w: type must be %q or %q", ErrInvalidEditor, config.EditorTypeCommand, config.EditorTypeBrowser)
+		return nil, fmt.Errorf("%w: type must be %q, %q or %q", ErrInvalidEditor, config.EditorTypeCommand, config.EditorTypeBrowser, config.EditorTypeTmux)
 	}
 }
 
-// GetEditor returns an editor by name
+// GetEditor returns an editor by name or alias, ignoring case
 func (m *Manager) GetEditor(name string) (*Editor, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -156,14 +245,36 @@ func (m *Manager) GetEditor(name string) (*Editor, error) {
 		return m.getDefaultEditor()
 	}
 
-	editor, exists := m.editors[name]
-	if !exists {
+	editor := m.findEditor(name)
+	if editor == nil {
 		return nil, fmt.Errorf("%w: %s", ErrEditorNotFound, name)
 	}
 
 	return editor, nil
 }
 
+// findEditor looks name up as an exact name, then as a name or alias in any
+// case (must be called with lock held). Returns nil when there is no match.
+func (m *Manager) findEditor(name string) *Editor {
+	if editor, exists := m.editors[name]; exists {
+		return editor
+	}
+
+	for _, editor := range m.editors {
+		if strings.EqualFold(editor.Name, name) {
+			return editor
+		}
+	}
+	for _, editor := range m.editors {
+		for _, alias := range editor.Aliases {
+			if strings.EqualFold(alias, name) {
+				return editor
+			}
+		}
+	}
+	return nil
+}
+
 // GetDefaultEditor returns the default editor
 func (m *Manager) GetDefaultEditor() (*Editor, error) {
 	m.mu.RLock()
@@ -206,8 +317,10 @@ func (m *Manager) ListEditors() []*Editor {
 		// Create a copy to avoid mutation
 		editorCopy := *editor
 		editorCopy.Available = m.IsAvailable(editor.Name)
+		editorCopy.AvailableVia = m.AvailableVia(editor.Name)
 		editors = append(editors, &editorCopy)
 	}
+	sort.Slice(editors, func(i, j int) bool { return editors[i].Name < editors[j].Name })
 
 	return editors
 }
@@ -231,13 +344,23 @@ func (m *Manager) IsAvailable(name string) bool {
 		return false
 	}
 
-	available = m.checkAvailability(editor)
+	via := m.probeAvailability(editor)
 
 	m.availMu.Lock()
-	m.availability[name] = available
+	m.availability[name] = via != ""
+	m.availableVia[name] = via
 	m.availMu.Unlock()
 
-	return available
+	return via != ""
+}
+
+// AvailableVia returns how an available editor was found: "path",
+// "browser", "check", or the file, app bundle, Flatpak or Snap that
+// revealed it. It returns "" for unavailable or unchecked editors.
+func (m *Manager) AvailableVia(name string) string {
+	m.availMu.RLock()
+	defer m.availMu.RUnlock()
+	return m.availableVia[name]
 }
 
 // RefreshAvailability refreshes the availability status of all editors
@@ -253,13 +376,15 @@ func (m *Manager) RefreshAvailability() {
 	defer m.availMu.Unlock()
 
 	for _, editor := range editors {
-		available := m.checkAvailability(editor)
-		m.availability[editor.Name] = available
+		via := m.probeAvailability(editor)
+		m.availability[editor.Name] = via != ""
+		m.availableVia[editor.Name] = via
 
-		if available {
+		if via != "" {
 			m.log.Debug("Editor available",
 				"name", editor.Name,
 				"default", editor.Default,
+				"via", via,
 			)
 		} else {
 			m.log.Debug("Editor not available",
@@ -269,27 +394,6 @@ func (m *Manager) RefreshAvailability() {
 	}
 }
 
-// checkAvailability checks if an editor is available
-func (m *Manager) checkAvailability(editor *Editor) bool {
-	if editor.Type == config.EditorTypeBrowser {
-		return true
-	}
-
-	if editor.Template == nil {
-		return false
-	}
-
-	// Extract the executable from the command
-	executable := m.extractExecutable(editor.Command)
-	if executable == "" {
-		return false
-	}
-
-	// Check if the executable exists in PATH
-	_, err := exec.LookPath(executable)
-	return err == nil
-}
-
 // extractExecutable extracts the executable name from a command string
 func (m *Manager) extractExecutable(command string) string {
 	// Find the first part before any flags or arguments
@@ -314,7 +418,8 @@ func (m *Manager) SetDefault(name string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if _, exists := m.editors[name]; !exists {
+	selected := m.findEditor(name)
+	if selected == nil {
 		return fmt.Errorf("%w: %s", ErrEditorNotFound, name)
 	}
 
@@ -324,10 +429,10 @@ func (m *Manager) SetDefault(name string) error {
 	}
 
 	// Set new default
-	m.editors[name].Default = true
-	m.defaultName = name
+	selected.Default = true
+	m.defaultName = selected.Name
 
-	m.log.Info("Default editor changed", "editor", name)
+	m.log.Info("Default editor changed", "editor", selected.Name)
 
 	return nil
 }
@@ -345,14 +450,16 @@ func (m *Manager) AddEditor(cfg config.EditorConfig) error {
 	m.editors[editor.Name] = editor
 
 	// Check availability
-	available := m.checkAvailability(editor)
+	via := m.probeAvailability(editor)
 	m.availMu.Lock()
-	m.availability[editor.Name] = available
+	m.availability[editor.Name] = via != ""
+	m.availableVia[editor.Name] = via
 	m.availMu.Unlock()
 
 	m.log.Info("Editor added",
 		"name", editor.Name,
-		"available", available,
+		"available", via != "",
+		"via", via,
 	)
 
 	return nil
@@ -371,6 +478,7 @@ func (m *Manager) RemoveEditor(name string) error {
 
 	m.availMu.Lock()
 	delete(m.availability, name)
+	delete(m.availableVia, name)
 	m.availMu.Unlock()
 
 	// Update default if necessary
@@ -398,26 +506,61 @@ func ValidateEditor(cfg config.EditorConfig) error {
 		typeValue = config.EditorTypeCommand
 	}
 
+	switch cfg.ExecMode {
+	case "", config.ExecModeArgv, config.ExecModeShell, config.ExecModeLoginShell:
+	default:
+		return fmt.Errorf("%w: exec_mode must be %s, %s or %s", ErrInvalidEditor, config.ExecModeArgv, config.ExecModeShell, config.ExecModeLoginShell)
+	}
+	if cfg.Timeout < 0 {
+		return fmt.Errorf("%w: timeout cannot be negative", ErrInvalidEditor)
+	}
+	if cfg.Retries < 0 || cfg.Retries > config.MaxEditorRetries {
+		return fmt.Errorf("%w: retries must be between 0 and %d", ErrInvalidEditor, config.MaxEditorRetries)
+	}
+	if cfg.CaptureKB < 0 || cfg.CaptureKB > config.MaxEditorCaptureKB {
+		return fmt.Errorf("%w: capture_output_kb must be between 0 and %d", ErrInvalidEditor, config.MaxEditorCaptureKB)
+	}
+
+	if typeValue == config.EditorTypeTmux && cfg.ExecMode != "" && cfg.ExecMode != config.ExecModeArgv {
+		return fmt.Errorf("%w: tmux editors always run their command through tmux", ErrInvalidEditor)
+	}
+	if cfg.Sync && typeValue != config.EditorTypeCommand {
+		return fmt.Errorf("%w: sync is only used by command editors", ErrInvalidEditor)
+	}
+
 	switch typeValue {
-	case config.EditorTypeCommand:
+	case config.EditorTypeCommand, config.EditorTypeTmux:
 		if cfg.Command == "" {
 			return fmt.Errorf("%w: command is required", ErrInvalidEditor)
 		}
 
-		if _, err := NewTemplate(cfg.Command); err != nil {
+		if _, err := NewTemplateWithVariables(cfg.Command, cfg.Variables); err != nil {
 			return fmt.Errorf("%w: invalid command template: %v", ErrInvalidEditor, err)
 		}
+		if cfg.WorkDir != "" {
+			if _, err := NewWorkDirTemplate(cfg.WorkDir, cfg.Variables); err != nil {
+				return fmt.Errorf("%w: invalid workdir template: %v", ErrInvalidEditor, err)
+			}
+		}
+		if cfg.Diff != "" {
+			if typeValue != config.EditorTypeCommand {
+				return fmt.Errorf("%w: diff is only used by command editors", ErrInvalidEditor)
+			}
+			if _, err := NewDiffTemplate(cfg.Diff, cfg.Variables); err != nil {
+				return fmt.Errorf("%w: invalid diff template: %v", ErrInvalidEditor, err)
+			}
+		}
 
 	case config.EditorTypeBrowser:
 		if cfg.URL == "" {
 			return fmt.Errorf("%w: url is required", ErrInvalidEditor)
 		}
 
-		if _, err := NewTemplate(cfg.URL); err != nil {
+		if _, err := NewTemplateWithVariables(cfg.URL, cfg.Variables); err != nil {
 			return fmt.Errorf("%w: invalid url template: %v", ErrInvalidEditor, err)
 		}
 	default:
-		return fmt.Errorf("%w: type must be %q or %q", ErrInvalidEditor, config.EditorTypeCommand, config.EditorTypeBrowser)
+		return fmt.Errorf("%w: type must be %q, %q or %q", ErrInvalidEditor, config.EditorTypeCommand, config.EditorTypeBrowser, config.EditorTypeTmux)
 	}
 
 	return nil
@@ -150,6 +150,49 @@ func TestManager_GetEditor(t *testing.T) {
 	}
 }
 
+func TestManager_GetEditorAliases(t *testing.T) {
+	manager, err := NewManager([]config.EditorConfig{
+		{Name: "cursor", Command: "cursor {path}", Aliases: []string{"c"}},
+		{Name: "vscode", Command: "code {path}", Aliases: []string{"code"}},
+		{Name: "Code", Command: "code-insiders {path}"},
+	}, createTestLogger())
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		editorName string
+		want       string
+	}{
+		{name: "exact name", editorName: "cursor", want: "cursor"},
+		{name: "name in other case", editorName: "VSCode", want: "vscode"},
+		{name: "alias", editorName: "c", want: "cursor"},
+		{name: "alias in other case", editorName: "C", want: "cursor"},
+		{name: "exact name wins over alias", editorName: "Code", want: "Code"},
+		{name: "name wins over alias in other case", editorName: "CODE", want: "Code"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			editor, err := manager.GetEditor(tt.editorName)
+			if err != nil {
+				t.Fatalf("GetEditor(%q) error = %v", tt.editorName, err)
+			}
+			if editor.Name != tt.want {
+				t.Errorf("GetEditor(%q) = %s, want %s", tt.editorName, editor.Name, tt.want)
+			}
+		})
+	}
+
+	if err := manager.SetDefault("c"); err != nil {
+		t.Fatalf("SetDefault(alias) error = %v", err)
+	}
+	if got := manager.GetDefaultName(); got != "cursor" {
+		t.Errorf("default after SetDefault(alias) = %s, want cursor", got)
+	}
+}
+
 func TestManager_GetDefaultEditor(t *testing.T) {
 	tests := []struct {
 		name        string
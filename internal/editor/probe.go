@@ -0,0 +1,149 @@
+package editor
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/config"
+)
+
+// checkTimeout bounds an editor's availability check command
+const checkTimeout = 2 * time.Second
+
+// Where installs are looked for; variables so tests can point them at
+// temporary directories
+var (
+	goos        = runtime.GOOS
+	macAppDirs  = []string{"/Applications", "~/Applications"}
+	flatpakDirs = []string{"/var/lib/flatpak/app", "~/.local/share/flatpak/app"}
+	snapBinDir  = "/snap/bin"
+)
+
+// knownInstall is where a well-known editor is installed when its CLI is
+// not on PATH
+type knownInstall struct {
+	app     string // macOS app bundle name
+	shim    string // CLI inside the app bundle, linked by install-shims
+	flatpak string // Flatpak application ID
+	snap    string // Snap name
+}
+
+// knownInstalls maps editor executables to their usual installs
+var knownInstalls = map[string]knownInstall{
+	"cursor":   {app: "Cursor.app", shim: "Contents/Resources/app/bin/cursor"},
+	"code":     {app: "Visual Studio Code.app", shim: "Contents/Resources/app/bin/code", flatpak: "com.visualstudio.code", snap: "code"},
+	"zed":      {app: "Zed.app", shim: "Contents/MacOS/cli", flatpak: "dev.zed.Zed"},
+	"windsurf": {app: "Windsurf.app", shim: "Contents/Resources/app/bin/windsurf"},
+	"subl":     {app: "Sublime Text.app", shim: "Contents/SharedSupport/bin/subl", flatpak: "com.sublimetext.three", snap: "sublime-text"},
+	"idea":     {app: "IntelliJ IDEA.app", flatpak: "com.jetbrains.IntelliJ-IDEA-Ultimate", snap: "intellij-idea-ultimate"},
+	"goland":   {app: "GoLand.app", snap: "goland"},
+}
+
+// probeAvailability returns how editor was found on this machine, or ""
+// when it was not: on PATH, by its check command, by one of its install
+// paths, or as a macOS app bundle, Flatpak or Snap. Command editors whose
+// CLI is not linked into PATH are still found through their install.
+func (m *Manager) probeAvailability(editor *Editor) string {
+	if editor.Type == config.EditorTypeBrowser {
+		return "browser"
+	}
+	if editor.Template == nil {
+		return ""
+	}
+	if editor.Type == config.EditorTypeTmux {
+		if _, err := exec.LookPath(tmuxBinary); err == nil {
+			return "path"
+		}
+		return ""
+	}
+
+	executable := m.extractExecutable(editor.Command)
+	if executable != "" {
+		if _, err := exec.LookPath(executable); err == nil {
+			return "path"
+		}
+	}
+
+	probe := editor.Availability
+	if probe.Check != "" && runCheck(probe.Check) {
+		return "check"
+	}
+	for _, p := range probe.Paths {
+		if exists(p) {
+			return "file " + p
+		}
+	}
+
+	known := knownInstalls[filepath.Base(executable)]
+	if probe.Flatpak == "" {
+		probe.Flatpak = known.flatpak
+	}
+	if probe.Snap == "" {
+		probe.Snap = known.snap
+	}
+
+	switch goos {
+	case "darwin":
+		if known.app == "" {
+			return ""
+		}
+		for _, dir := range macAppDirs {
+			if p := filepath.Join(dir, known.app); exists(p) {
+				return "app " + p
+			}
+		}
+	case "linux":
+		if probe.Flatpak != "" {
+			for _, dir := range flatpakDirs {
+				if exists(filepath.Join(dir, probe.Flatpak)) {
+					return "flatpak " + probe.Flatpak
+				}
+			}
+		}
+		if probe.Snap != "" && exists(filepath.Join(snapBinDir, probe.Snap)) {
+			return "snap " + probe.Snap
+		}
+	}
+	return ""
+}
+
+// runCheck reports whether command, split into arguments and run without a
+// shell, exits with status 0 within checkTimeout
+func runCheck(command string) bool {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancel()
+	return exec.CommandContext(ctx, args[0], args[1:]...).Run() == nil // #nosec G204 -- the check command comes from the server config
+}
+
+// exists reports whether path, with a leading ~ expanded, exists
+func exists(path string) bool {
+	path, ok := expandHome(path)
+	if !ok {
+		return false
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// expandHome expands a leading ~ in path. It reports false when the home
+// directory is unknown.
+func expandHome(path string) (string, bool) {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return path, true
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", false
+	}
+	return filepath.Join(home, rest), true
+}
@@ -0,0 +1,75 @@
+package editor
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/foxytanuki/rcode/internal/config"
+)
+
+func TestManager_AvailableVia(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses true")
+	}
+	if _, err := exec.LookPath("cursor"); err == nil {
+		t.Skip("cursor is on PATH")
+	}
+
+	dir := t.TempDir()
+	installed := filepath.Join(dir, "MyEditor.app")
+	if err := os.Mkdir(installed, 0o750); err != nil {
+		t.Fatalf("Mkdir() error = %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "Cursor.app"), 0o750); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "flatpak", "org.example.Editor"), 0o750); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+
+	originalGOOS, originalApps, originalFlatpak := goos, macAppDirs, flatpakDirs
+	macAppDirs = []string{dir}
+	flatpakDirs = []string{filepath.Join(dir, "flatpak")}
+	defer func() { goos, macAppDirs, flatpakDirs = originalGOOS, originalApps, originalFlatpak }()
+
+	missing := "rcode-test-no-such-editor {path}"
+	tests := []struct {
+		name   string
+		goos   string
+		editor config.EditorConfig
+		want   string
+	}{
+		{"on PATH", "linux", config.EditorConfig{Command: "true {path}"}, "path"},
+		{"check command", "linux", config.EditorConfig{Command: missing, Availability: config.AvailabilityConfig{Check: "true"}}, "check"},
+		{"failing check", "linux", config.EditorConfig{Command: missing, Availability: config.AvailabilityConfig{Check: "false"}}, ""},
+		{"install path", "linux", config.EditorConfig{Command: missing, Availability: config.AvailabilityConfig{Paths: []string{installed}}}, "file " + installed},
+		{"macOS app bundle", "darwin", config.EditorConfig{Command: "cursor {path}"}, "app " + filepath.Join(dir, "Cursor.app")},
+		{"app bundle only on macOS", "linux", config.EditorConfig{Command: "cursor {path}"}, ""},
+		{"flatpak", "linux", config.EditorConfig{Command: missing, Availability: config.AvailabilityConfig{Flatpak: "org.example.Editor"}}, "flatpak org.example.Editor"},
+		{"not installed", "linux", config.EditorConfig{Command: missing}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goos = tt.goos
+			tt.editor.Name = "probe"
+			manager, err := NewManager([]config.EditorConfig{tt.editor}, createTestLogger())
+			if err != nil {
+				t.Fatalf("NewManager() error = %v", err)
+			}
+
+			if got := manager.AvailableVia("probe"); got != tt.want {
+				t.Errorf("AvailableVia() = %q, want %q", got, tt.want)
+			}
+			if got := manager.IsAvailable("probe"); got != (tt.want != "") {
+				t.Errorf("IsAvailable() = %v, want %v", got, tt.want != "")
+			}
+			if got := manager.ListEditors()[0].AvailableVia; got != tt.want {
+				t.Errorf("ListEditors() AvailableVia = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
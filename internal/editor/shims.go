@@ -0,0 +1,68 @@
+package editor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+)
+
+// DefaultShimDir is where install-shims links editor commands by default,
+// the directory the editors' own "install shell command" actions use
+const DefaultShimDir = "/usr/local/bin"
+
+// Shim is the command-line tool of an installed macOS editor that is not
+// on PATH yet
+type Shim struct {
+	Name   string // command name, such as "cursor"
+	Target string // command-line tool inside the app bundle
+	Link   string // symlink that makes it available on PATH
+}
+
+// MissingShims returns the command-line tools of the editors installed as
+// macOS app bundles whose commands are neither on PATH nor already linked
+// in binDir, sorted by name. It returns nothing on other systems.
+func MissingShims(binDir string) []Shim {
+	if goos != "darwin" {
+		return nil
+	}
+
+	var shims []Shim
+	for name, known := range knownInstalls {
+		if known.shim == "" {
+			continue
+		}
+		if _, err := exec.LookPath(name); err == nil {
+			continue
+		}
+		link := filepath.Join(binDir, name)
+		if _, err := os.Lstat(link); err == nil {
+			continue
+		}
+		for _, dir := range macAppDirs {
+			dir, ok := expandHome(dir)
+			if !ok {
+				continue
+			}
+			if target := filepath.Join(dir, known.app, known.shim); exists(target) {
+				shims = append(shims, Shim{Name: name, Target: target, Link: link})
+				break
+			}
+		}
+	}
+
+	sort.Slice(shims, func(i, j int) bool { return shims[i].Name < shims[j].Name })
+	return shims
+}
+
+// Install creates the shim's symlink, and its directory when missing
+func (s Shim) Install() error {
+	if err := os.MkdirAll(filepath.Dir(s.Link), 0o755); err != nil { // #nosec G301 -- bin directories are world-readable
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(s.Link), err)
+	}
+	if err := os.Symlink(s.Target, s.Link); err != nil {
+		return fmt.Errorf("failed to link %s: %w", s.Name, err)
+	}
+	return nil
+}
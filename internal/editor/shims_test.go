@@ -0,0 +1,57 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMissingShims(t *testing.T) {
+	apps := t.TempDir()
+	bin := filepath.Join(t.TempDir(), "bin")
+	for _, shim := range []string{
+		"Cursor.app/Contents/Resources/app/bin/cursor",
+		"Visual Studio Code.app/Contents/Resources/app/bin/code",
+	} {
+		path := filepath.Join(apps, shim)
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+		if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0o600); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+	// Zed is installed without its command-line tool
+	if err := os.MkdirAll(filepath.Join(apps, "Zed.app"), 0o750); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+
+	originalGOOS, originalApps := goos, macAppDirs
+	macAppDirs = []string{apps}
+	defer func() { goos, macAppDirs = originalGOOS, originalApps }()
+	t.Setenv("PATH", "")
+
+	goos = "linux"
+	if shims := MissingShims(bin); len(shims) != 0 {
+		t.Errorf("MissingShims() on linux = %+v, want none", shims)
+	}
+
+	goos = "darwin"
+	shims := MissingShims(bin)
+	if len(shims) != 2 || shims[0].Name != "code" || shims[1].Name != "cursor" {
+		t.Fatalf("MissingShims() = %+v, want code and cursor", shims)
+	}
+	if want := filepath.Join(bin, "cursor"); shims[1].Link != want {
+		t.Errorf("Link = %q, want %q", shims[1].Link, want)
+	}
+
+	if err := shims[1].Install(); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if target, err := os.Readlink(shims[1].Link); err != nil || target != shims[1].Target {
+		t.Errorf("Readlink() = %q, %v, want %q", target, err, shims[1].Target)
+	}
+	if shims := MissingShims(bin); len(shims) != 1 || shims[0].Name != "code" {
+		t.Errorf("MissingShims() after Install() = %+v, want only code", shims)
+	}
+}
@@ -3,8 +3,12 @@ package editor
 import (
 	"errors"
 	"fmt"
+	"net/url"
+	"path"
+	"strconv"
 	"strings"
 
+	"github.com/foxytanuki/rcode/internal/pathcheck"
 	"github.com/foxytanuki/rcode/internal/validation"
 )
 
@@ -15,100 +19,371 @@ var (
 	ErrMissingPlaceholder = validation.ErrMissingPlaceholder
 )
 
-// Template represents a command template with placeholders
+// Template represents a command template with placeholders. Placeholders
+// take the form {name}, {name:default} or {name|filter|...}; names other
+// than the built-in ones are variables defined with the template.
 type Template struct {
 	raw          string
+	tokens       []templateToken
+	variables    map[string]string
 	hasUser      bool
 	hasHost      bool
 	hasPath      bool
+	hasLine      bool
+	hasColumn    bool
+	required     map[string]bool // Placeholders used without a default
+	tramp        bool            // Command holds an Emacs TRAMP file name
 	placeholders []string
 }
 
+// templateToken is a run of whitespace, literal text or a placeholder
+type templateToken struct {
+	space       string
+	text        string
+	placeholder *validation.Placeholder
+}
+
 // TemplateVars holds the values for template substitution
 type TemplateVars struct {
-	User string
-	Host string
-	Path string
+	User   string
+	Host   string
+	Path   string
+	Paths  []string // Additional paths; when set, {path} expands to all of them
+	Path2  string   // Second file of a diff
+	Line   int      // 1-based line number (0 = unspecified, rendered as 1)
+	Column int      // 1-based column number (0 = unspecified, rendered as 1)
+	Port   int      // Port a web IDE listens on (0 = unspecified)
+
+	// ShellQuote escapes {user}, {host}, {path2} and a single {path} for a
+	// POSIX shell or SplitCommand, unless the placeholder ends in an
+	// escaping filter. Multiple paths are always escaped.
+	ShellQuote bool
 }
 
 // NewTemplate creates a new template from a command string
 func NewTemplate(command string) (*Template, error) {
-	// ValidateCommandTemplate checks empty, {path} required, and placeholder validity
-	if err := validation.ValidateCommandTemplate(command); err != nil {
+	return NewTemplateWithVariables(command, nil)
+}
+
+// NewTemplateWithVariables creates a new template whose user-defined
+// placeholders take their values from variables
+func NewTemplateWithVariables(command string, variables map[string]string) (*Template, error) {
+	// ValidateTemplate checks empty, {path} required, and placeholder validity
+	if err := validation.ValidateTemplate(command, variables); err != nil {
+		return nil, err
+	}
+	return newTemplate(command, variables)
+}
+
+// NewWorkDirTemplate creates a template for an editor's working directory.
+// Unlike a command template it need not contain {path}.
+func NewWorkDirTemplate(dir string, variables map[string]string) (*Template, error) {
+	if err := validation.ValidatePlaceholders(dir, variables); err != nil {
+		return nil, err
+	}
+	return newTemplate(dir, variables)
+}
+
+// NewDiffTemplate creates a template for an editor's diff command, which
+// must contain {path2} as well as {path}
+func NewDiffTemplate(command string, variables map[string]string) (*Template, error) {
+	if err := validation.ValidateDiffTemplate(command, variables); err != nil {
+		return nil, err
+	}
+	return newTemplate(command, variables)
+}
+
+// newTemplate builds a template that has already been validated
+func newTemplate(command string, variables map[string]string) (*Template, error) {
+	parts, err := validation.SplitTemplate(command)
+	if err != nil {
 		return nil, err
 	}
 
 	t := &Template{
 		raw:          command,
+		tokens:       tokenize(parts),
+		variables:    variables,
+		required:     make(map[string]bool),
+		tramp:        validation.TrampToken(command) != "",
 		placeholders: make([]string, 0),
 	}
 
 	// Check for placeholders
-	t.hasUser = strings.Contains(command, "{user}")
-	t.hasHost = strings.Contains(command, "{host}")
-	t.hasPath = strings.Contains(command, "{path}")
-
-	// Collect all placeholders
-	if t.hasUser {
-		t.placeholders = append(t.placeholders, "{user}")
-	}
-	if t.hasHost {
-		t.placeholders = append(t.placeholders, "{host}")
-	}
-	if t.hasPath {
-		t.placeholders = append(t.placeholders, "{path}")
+	seen := make(map[string]bool)
+	for _, part := range parts {
+		p := part.Placeholder
+		if p == nil {
+			continue
+		}
+		if !p.HasDefault {
+			t.required[p.Name] = true
+		}
+		if !seen[p.Name] {
+			seen[p.Name] = true
+			t.placeholders = append(t.placeholders, "{"+p.Name+"}")
+		}
 	}
+	t.hasUser = seen["user"]
+	t.hasHost = seen["host"]
+	t.hasPath = seen["path"]
+	t.hasLine = seen["line"]
+	t.hasColumn = seen["column"]
 
 	return t, nil
 }
 
+// tokenize splits the literal text of parts at spaces and tabs, so Render
+// can tell which word holds a {path} placeholder
+func tokenize(parts []validation.TemplatePart) []templateToken {
+	var tokens []templateToken
+	for _, part := range parts {
+		if part.Placeholder != nil {
+			tokens = append(tokens, templateToken{placeholder: part.Placeholder})
+			continue
+		}
+
+		text := part.Text
+		for text != "" {
+			n := strings.IndexAny(text, " \t")
+			if n < 0 {
+				tokens = append(tokens, templateToken{text: text})
+				break
+			}
+			if n > 0 {
+				tokens = append(tokens, templateToken{text: text[:n]})
+			}
+			end := n
+			for end < len(text) && (text[end] == ' ' || text[end] == '\t') {
+				end++
+			}
+			tokens = append(tokens, templateToken{space: text[n:end]})
+			text = text[end:]
+		}
+	}
+	return tokens
+}
+
 // Render applies the template variables to generate the final command
 func (t *Template) Render(vars TemplateVars) (string, error) {
 	// Validate required variables
-	if t.hasPath && vars.Path == "" {
+	if t.required["path"] && vars.Path == "" && len(vars.Paths) == 0 {
 		return "", fmt.Errorf("path is required for this template")
 	}
-	if t.hasUser && vars.User == "" {
+	if t.required["user"] && vars.User == "" {
 		return "", fmt.Errorf("user is required for this template")
 	}
-	if t.hasHost && vars.Host == "" {
+	if t.required["host"] && vars.Host == "" {
 		return "", fmt.Errorf("host is required for this template")
 	}
+	if t.required["path2"] && vars.Path2 == "" {
+		return "", fmt.Errorf("a second path is required for this template")
+	}
+	if t.RequiresPort() && vars.Port == 0 {
+		return "", fmt.Errorf("a port is required for this template")
+	}
 
-	// Perform substitution
-	result := t.raw
-	result = strings.ReplaceAll(result, "{user}", vars.User)
-	result = strings.ReplaceAll(result, "{host}", vars.Host)
-	result = strings.ReplaceAll(result, "{path}", vars.Path)
-
-	return result, nil
+	return t.expand(vars, false), nil
 }
 
-// RenderWithDefaults renders the template with default values for missing vars
+// RenderWithDefaults renders the template with default values for missing
+// vars. Placeholders without a value or default are left as written.
 func (t *Template) RenderWithDefaults(vars TemplateVars) string {
-	result := t.raw
+	if vars.Path == "" && len(vars.Paths) == 0 {
+		vars.Path = "."
+	}
+	if vars.Path2 == "" {
+		vars.Path2 = "."
+	}
+	return t.expand(vars, true)
+}
+
+// expand substitutes every placeholder. Multiple paths are escaped
+// individually and the whole word holding {path} is repeated once per path,
+// so prefixed forms such as "ssh://{host}/{path}" or TRAMP's
+// "/ssh:{host}:{path}" apply to every path.
+func (t *Template) expand(vars TemplateVars, preview bool) string {
+	var b strings.Builder
+
+	writeWord := func(word []templateToken) {
+		if len(vars.Paths) == 0 || !hasPathToken(word) {
+			t.writeWord(&b, word, vars, vars.Path, false, preview)
+			return
+		}
+		for i, p := range vars.Paths {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			t.writeWord(&b, word, vars, p, true, preview)
+		}
+	}
+
+	var word []templateToken
+	for _, tok := range t.tokens {
+		if tok.space == "" {
+			word = append(word, tok)
+			continue
+		}
+		writeWord(word)
+		word = word[:0]
+		b.WriteString(tok.space)
+	}
+	writeWord(word)
+
+	return b.String()
+}
+
+// writeWord writes one whitespace-free word of the template using file for
+// {path}
+func (t *Template) writeWord(b *strings.Builder, word []templateToken, vars TemplateVars, file string, multiPath, preview bool) {
+	for _, tok := range word {
+		if tok.placeholder == nil {
+			b.WriteString(tok.text)
+			continue
+		}
+		b.WriteString(t.value(tok.placeholder, vars, file, multiPath, preview))
+	}
+}
+
+// value returns the substitution for a single placeholder
+func (t *Template) value(p *validation.Placeholder, vars TemplateVars, file string, multiPath, preview bool) string {
+	var value string
+	quote := false
+	switch p.Name {
+	case "user":
+		value, quote = vars.User, vars.ShellQuote
+	case "host":
+		value, quote = vars.Host, vars.ShellQuote
+	case "path":
+		value, quote = file, vars.ShellQuote || multiPath
+	case "path2":
+		value, quote = vars.Path2, vars.ShellQuote
+	case "line":
+		value = position(vars.Line)
+	case "column":
+		value = position(vars.Column)
+	case "port":
+		value = position(vars.Port)
+		if value == "" {
+			value = t.variables["port"]
+		}
+	default:
+		value = t.variables[p.Name]
+	}
 
-	// Use provided values or defaults
-	user := vars.User
-	if user == "" {
-		user = "user"
+	if value == "" && p.HasDefault {
+		value = p.Default
+	}
+	if value == "" {
+		switch {
+		case p.Name == "line" || p.Name == "column":
+			value = "1"
+		case preview && p.Name == "user":
+			value = "user"
+		case preview && p.Name == "host":
+			value = "localhost"
+		case preview && p.Name == "port":
+			return p.Raw
+		case preview && !validation.BuiltinPlaceholders[p.Name]:
+			return p.Raw
+		}
+	}
+	if p.Name == "host" {
+		value = t.hostValue(value)
 	}
 
-	host := vars.Host
-	if host == "" {
-		host = "localhost"
+	for _, filter := range p.Filters {
+		value = applyFilter(filter, value)
+	}
+	// Only the last filter decides: a later filter such as basename can
+	// cut an escaped value apart. Inside quotes an escaped value, such as
+	// one shell-quoted for a command run over ssh, is still escaped for
+	// the quotes
+	if n := len(p.Filters); n > 0 && validation.ValidFilters[p.Filters[n-1]] && p.Quote == 0 {
+		quote = false
+	}
+	if quote {
+		value = escapeFor(p.Quote, value)
 	}
+	return value
+}
 
-	path := vars.Path
-	if path == "" {
-		path = "."
+// escapeFor escapes value for a placeholder written inside quote: outside
+// quotes it is quoted as a whole, inside single quotes its single quotes
+// close and reopen them, and inside double quotes the characters a shell
+// still reads there are backslash-escaped
+func escapeFor(quote rune, value string) string {
+	switch quote {
+	case '\'':
+		return strings.ReplaceAll(value, "'", "'\\''")
+	case '"':
+		var b strings.Builder
+		for i := 0; i < len(value); i++ {
+			if strings.IndexByte("$`\"\\", value[i]) >= 0 {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(value[i])
+		}
+		return b.String()
 	}
+	return EscapePath(value)
+}
 
-	result = strings.ReplaceAll(result, "{user}", user)
-	result = strings.ReplaceAll(result, "{host}", host)
-	result = strings.ReplaceAll(result, "{path}", path)
+// hasPathToken reports whether word holds a {path} placeholder
+func hasPathToken(word []templateToken) bool {
+	for _, tok := range word {
+		if tok.placeholder != nil && tok.placeholder.Name == "path" {
+			return true
+		}
+	}
+	return false
+}
 
-	return result
+// position formats a 1-based line or column number, or "" when unset
+func position(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// applyFilter applies a named placeholder filter to value
+func applyFilter(filter, value string) string {
+	switch filter {
+	case "urlencode":
+		return url.QueryEscape(value)
+	case "urlpath":
+		segments := strings.Split(value, "/")
+		for i, segment := range segments {
+			segments[i] = url.PathEscape(segment)
+		}
+		return strings.Join(segments, "/")
+	case "shellescape":
+		return EscapePath(value)
+	case "basename":
+		return path.Base(value)
+	case "dirname":
+		return path.Dir(value)
+	}
+	return value
+}
+
+// hostValue returns the {host} substitution. TRAMP needs IPv6 addresses in
+// brackets, since it splits the file name on colons.
+func (t *Template) hostValue(host string) string {
+	if t.tramp && strings.Contains(host, ":") && !strings.HasPrefix(host, "[") {
+		return "[" + host + "]"
+	}
+	return host
+}
+
+// JoinPaths escapes each path and joins them with spaces
+func JoinPaths(paths []string) string {
+	escaped := make([]string, len(paths))
+	for i, p := range paths {
+		escaped[i] = EscapePath(p)
+	}
+	return strings.Join(escaped, " ")
 }
 
 // RequiresUser returns true if the template requires a user variable
@@ -121,6 +396,18 @@ func (t *Template) RequiresHost() bool {
 	return t.hasHost
 }
 
+// HasPosition returns true if the template uses {line} or {column}
+func (t *Template) HasPosition() bool {
+	return t.hasLine || t.hasColumn
+}
+
+// RequiresPort returns true if the template uses {port} without a default
+// or a port variable, so the request must give the port
+func (t *Template) RequiresPort() bool {
+	_, defined := t.variables["port"]
+	return t.required["port"] && !defined
+}
+
 // RequiresPath returns true if the template requires a path variable
 func (t *Template) RequiresPath() bool {
 	return t.hasPath
@@ -138,24 +425,46 @@ func (t *Template) String() string {
 
 // Clone creates a copy of the template
 func (t *Template) Clone() *Template {
+	required := make(map[string]bool, len(t.required))
+	for name, v := range t.required {
+		required[name] = v
+	}
+
 	return &Template{
 		raw:          t.raw,
+		tokens:       append([]templateToken(nil), t.tokens...),
+		variables:    t.variables,
 		hasUser:      t.hasUser,
 		hasHost:      t.hasHost,
 		hasPath:      t.hasPath,
+		hasLine:      t.hasLine,
+		hasColumn:    t.hasColumn,
+		required:     required,
+		tramp:        t.tramp,
 		placeholders: append([]string(nil), t.placeholders...),
 	}
 }
 
 // EscapePath escapes special characters in a path for shell commands
 func EscapePath(path string) string {
-	// Basic shell escaping - in production, use a proper shell escaping library
-	if strings.ContainsAny(path, " \t\n'\"\\$`!") {
-		// Use single quotes and escape single quotes
-		escaped := strings.ReplaceAll(path, "'", "'\\''")
-		return "'" + escaped + "'"
+	if path != "" && strings.IndexFunc(path, needsQuoting) < 0 {
+		return path
 	}
-	return path
+	// Use single quotes and escape single quotes
+	escaped := strings.ReplaceAll(path, "'", "'\\''")
+	return "'" + escaped + "'"
+}
+
+// needsQuoting reports whether r is outside the characters a POSIX shell
+// leaves alone in an unquoted word
+func needsQuoting(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return false
+	case strings.ContainsRune("_@%+=:,./-", r):
+		return false
+	}
+	return true
 }
 
 // ExpandPath expands ~ to home directory and resolves relative paths
@@ -168,11 +477,15 @@ func ExpandPath(path string) string {
 	return path
 }
 
-// ValidateVars validates template variables
+// ValidateVars validates template variables: the path must pass
+// pathcheck.Clean and hold no "../" segment
 func ValidateVars(vars TemplateVars) error {
 	if vars.Path == "" {
 		return errors.New("path cannot be empty")
 	}
+	if _, err := pathcheck.Clean(vars.Path); err != nil {
+		return err
+	}
 
 	// Path traversal check
 	if strings.Contains(vars.Path, "../") {
@@ -182,23 +495,93 @@ func ValidateVars(vars TemplateVars) error {
 	return nil
 }
 
-// ParseCommand parses a command string into executable and arguments
+// ErrUnterminatedQuote is returned by SplitCommand for a quote that is never closed
+var ErrUnterminatedQuote = errors.New("unterminated quote")
+
+// ParseCommand parses a command string into executable and arguments using
+// SplitCommand. An unterminated quote runs to the end of the command.
 func ParseCommand(command string) (executable string, args []string) {
-	parts := strings.Fields(command)
-	if len(parts) == 0 {
+	words, _ := SplitCommand(command)
+	if len(words) == 0 {
 		return "", nil
 	}
 
-	executable = parts[0]
-	args = parts[1:]
+	return words[0], words[1:]
+}
 
-	return executable, args
+// SplitCommand splits a command into words the way a POSIX shell does,
+// honouring single quotes, double quotes and backslash escapes. It does not
+// expand variables, globs or any other shell syntax. The special characters
+// are all ASCII, so the command is read byte by byte and bytes that are not
+// valid UTF-8 are kept as they are.
+func SplitCommand(command string) ([]string, error) {
+	var (
+		words   []string
+		word    strings.Builder
+		inWord  bool
+		quote   byte // ' or " while inside quotes
+		escaped bool
+	)
+
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case escaped:
+			// Inside double quotes a backslash only escapes $ ` " \ and newline
+			if quote == '"' && strings.IndexByte("$`\"\\\n", c) < 0 {
+				word.WriteByte('\\')
+			}
+			if c != '\n' {
+				word.WriteByte(c)
+			}
+			escaped = false
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			} else {
+				word.WriteByte(c)
+			}
+		case c == '\\':
+			escaped = true
+			inWord = true
+		case quote == '"':
+			if c == '"' {
+				quote = 0
+			} else {
+				word.WriteByte(c)
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			inWord = true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+
+	if escaped {
+		word.WriteByte('\\')
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	if quote != 0 {
+		return words, fmt.Errorf("%w in command: %s", ErrUnterminatedQuote, command)
+	}
+	return words, nil
 }
 
 // BuildCommand builds a command string from executable and arguments
+// Arguments are escaped so ParseCommand returns them unchanged.
 func BuildCommand(executable string, args []string) string {
 	if len(args) == 0 {
 		return executable
 	}
-	return executable + " " + strings.Join(args, " ")
+	return executable + " " + JoinPaths(args)
 }
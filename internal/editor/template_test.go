@@ -1,8 +1,12 @@
 package editor
 
 import (
+	"errors"
+	"slices"
 	"strings"
 	"testing"
+
+	"github.com/foxytanuki/rcode/internal/validation"
 )
 
 func TestNewTemplate(t *testing.T) {
@@ -22,6 +26,12 @@ func TestNewTemplate(t *testing.T) {
 			command: "editor {path}",
 			wantErr: false,
 		},
+		{
+			name:    "placeholder after a backslash",
+			command: `editor \{path}`,
+			wantErr: true,
+			errMsg:  "follows a backslash",
+		},
 		{
 			name:    "missing path placeholder",
 			command: "editor file.txt",
@@ -46,6 +56,33 @@ func TestNewTemplate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "unclosed placeholder",
 		},
+		{
+			name:    "variable with default",
+			command: "editor --port {port:22} {path}",
+			wantErr: false,
+		},
+		{
+			name:    "filters",
+			command: "open http://{host}/?folder={path|urlencode}",
+			wantErr: false,
+		},
+		{
+			name:    "unknown filter",
+			command: "editor {path|upper}",
+			wantErr: true,
+			errMsg:  "unknown filter",
+		},
+		{
+			name:    "invalid placeholder name",
+			command: "editor {Path} {path}",
+			wantErr: true,
+			errMsg:  "invalid placeholder",
+		},
+		{
+			name:    "tramp path with filter",
+			command: "emacsclient -n /ssh:{host}:{path|dirname}",
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -125,6 +162,236 @@ func TestTemplate_Render(t *testing.T) {
 			want:    "bob@example.com:/tmp -> bob",
 			wantErr: false,
 		},
+		{
+			name:    "line and column",
+			command: "code --goto {path}:{line}:{column}",
+			vars: TemplateVars{
+				Path:   "/home/project/main.go",
+				Line:   120,
+				Column: 5,
+			},
+			want:    "code --goto /home/project/main.go:120:5",
+			wantErr: false,
+		},
+		{
+			name:    "line and column default to 1",
+			command: "code --goto {path}:{line}:{column}",
+			vars: TemplateVars{
+				Path: "/home/project/main.go",
+			},
+			want:    "code --goto /home/project/main.go:1:1",
+			wantErr: false,
+		},
+		{
+			name:    "multiple paths",
+			command: "code {path}",
+			vars: TemplateVars{
+				Paths: []string{"/src/a", "/src/my file.go"},
+			},
+			want:    "code /src/a '/src/my file.go'",
+			wantErr: false,
+		},
+		{
+			name:    "tramp file name",
+			command: "emacsclient -n /ssh:{user}@{host}:{path}",
+			vars: TemplateVars{
+				User: "alice",
+				Host: "server.com",
+				Path: "/home/project",
+			},
+			want:    "emacsclient -n /ssh:alice@server.com:/home/project",
+			wantErr: false,
+		},
+		{
+			name:    "tramp multiple paths repeat the prefix",
+			command: "emacsclient -n /ssh:{user}@{host}:{path}",
+			vars: TemplateVars{
+				User:  "alice",
+				Host:  "server.com",
+				Paths: []string{"/src/a", "/src/b"},
+			},
+			want:    "emacsclient -n /ssh:alice@server.com:/src/a /ssh:alice@server.com:/src/b",
+			wantErr: false,
+		},
+		{
+			name:    "tramp brackets ipv6 host",
+			command: "emacsclient -n /ssh:{user}@{host}:{path}",
+			vars: TemplateVars{
+				User: "alice",
+				Host: "fd7a:115c::1",
+				Path: "/src",
+			},
+			want:    "emacsclient -n /ssh:alice@[fd7a:115c::1]:/src",
+			wantErr: false,
+		},
+		{
+			name:    "url prefix with multiple paths",
+			command: "zed ssh://{user}@{host}/{path}",
+			vars: TemplateVars{
+				User:  "alice",
+				Host:  "server.com",
+				Paths: []string{"/src/a", "/src/b"},
+			},
+			want:    "zed ssh://alice@server.com//src/a ssh://alice@server.com//src/b",
+			wantErr: false,
+		},
+		{
+			name:    "shell quoting",
+			command: "ssh {user}@{host} editor {path}",
+			vars: TemplateVars{
+				User:       "alice",
+				Host:       "server.com",
+				Path:       "/src/a; rm -rf ~",
+				ShellQuote: true,
+			},
+			want:    "ssh alice@server.com editor '/src/a; rm -rf ~'",
+			wantErr: false,
+		},
+		{
+			name:    "shell quoting inside single quotes",
+			command: "ssh {user}@{host} 'editor {path}'",
+			vars: TemplateVars{
+				User:       "alice",
+				Host:       "server.com",
+				Path:       "/src/it's; rm -rf ~",
+				ShellQuote: true,
+			},
+			want:    `ssh alice@server.com 'editor /src/it'\''s; rm -rf ~'`,
+			wantErr: false,
+		},
+		{
+			name:    "shell quoting inside double quotes",
+			command: `sh -c "editor {path}"`,
+			vars: TemplateVars{
+				Path:       "/src/$(rm -rf ~)/\"a\"",
+				ShellQuote: true,
+			},
+			want:    `sh -c "editor /src/\$(rm -rf ~)/\"a\""`,
+			wantErr: false,
+		},
+		{
+			name:    "default value",
+			command: "ssh -p {port:22} {user:git}@{host} {path}",
+			vars: TemplateVars{
+				Host: "server.com",
+				Path: "/src",
+			},
+			want:    "ssh -p 22 git@server.com /src",
+			wantErr: false,
+		},
+		{
+			name:    "value overrides default",
+			command: "ssh {user:git}@{host} {path}",
+			vars: TemplateVars{
+				User: "alice",
+				Host: "server.com",
+				Path: "/src",
+			},
+			want:    "ssh alice@server.com /src",
+			wantErr: false,
+		},
+		{
+			name:    "urlencode filter",
+			command: "http://{host}:8080/?folder={path|urlencode}",
+			vars: TemplateVars{
+				Host: "server.com",
+				Path: "/src/my app&co",
+			},
+			want:    "http://server.com:8080/?folder=%2Fsrc%2Fmy+app%26co",
+			wantErr: false,
+		},
+		{
+			name:    "urlpath filter keeps slashes",
+			command: "jetbrains://gateway/ssh/{host}{path|urlpath}",
+			vars: TemplateVars{
+				Host: "server.com",
+				Path: "/src/my app",
+			},
+			want:    "jetbrains://gateway/ssh/server.com/src/my%20app",
+			wantErr: false,
+		},
+		{
+			name:    "urlpath output without shell characters is not quoted",
+			command: "zed ssh://{host}{path|urlpath}",
+			vars: TemplateVars{
+				Host:       "server.com",
+				Path:       "/src/a b",
+				ShellQuote: true,
+			},
+			want:    "zed ssh://server.com/src/a%20b",
+			wantErr: false,
+		},
+		{
+			name:    "shellescape filter inside double quotes",
+			command: `wezterm start -- ssh -t {host} "cd {path|shellescape} && exec \$SHELL -l"`,
+			vars: TemplateVars{
+				Host:       "server.com",
+				Path:       "/src/$HOME dir",
+				ShellQuote: true,
+			},
+			want:    `wezterm start -- ssh -t server.com "cd '/src/\$HOME dir' && exec \$SHELL -l"`,
+			wantErr: false,
+		},
+		{
+			name:    "escaping filter in the middle of a chain is quoted",
+			command: "code {path|urlencode|basename}",
+			vars: TemplateVars{
+				Path:       "/src/a b",
+				ShellQuote: true,
+			},
+			want:    "code %2Fsrc%2Fa+b",
+			wantErr: false,
+		},
+		{
+			name:    "shellescape output cut by a later filter is quoted",
+			command: "code {path|shellescape|dirname}",
+			vars: TemplateVars{
+				Path:       "/src/a b/c",
+				ShellQuote: true,
+			},
+			want:    `code ''\''/src/a b'`,
+			wantErr: false,
+		},
+		{
+			name:    "urlpath output is shell quoted",
+			command: "zed ssh://{host}{path|urlpath}",
+			vars: TemplateVars{
+				Host:       "server.com",
+				Path:       "/src/a&b$c",
+				ShellQuote: true,
+			},
+			want:    "zed ssh://server.com'/src/a&b$c'",
+			wantErr: false,
+		},
+		{
+			name:    "shellescape filter",
+			command: "open {path|shellescape}",
+			vars: TemplateVars{
+				Path: "/src/it's",
+			},
+			want:    `open '/src/it'\''s'`,
+			wantErr: false,
+		},
+		{
+			name:    "non-escaping filter is still shell quoted",
+			command: "code {path|dirname}",
+			vars: TemplateVars{
+				Path:       "/src/my dir/main.go",
+				ShellQuote: true,
+			},
+			want:    "code '/src/my dir'",
+			wantErr: false,
+		},
+		{
+			name:    "filters apply to each of multiple paths",
+			command: "open http://{host}/?f={path|urlencode}",
+			vars: TemplateVars{
+				Host:  "server.com",
+				Paths: []string{"/a b", "/c"},
+			},
+			want:    "open http://server.com/?f=%2Fa+b http://server.com/?f=%2Fc",
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -151,6 +418,98 @@ func TestTemplate_Render(t *testing.T) {
 	}
 }
 
+func TestNewTemplateWithVariables(t *testing.T) {
+	variables := map[string]string{"port": "2222", "workspace": "/srv/work"}
+
+	template, err := NewTemplateWithVariables("ssh -p {port} {host} {workspace}/{path|basename}", variables)
+	if err != nil {
+		t.Fatalf("NewTemplateWithVariables() error = %v", err)
+	}
+	got, err := template.Render(TemplateVars{Host: "server.com", Path: "/src/main.go"})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if want := "ssh -p 2222 server.com /srv/work/main.go"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+
+	if _, err := NewTemplateWithVariables("editor {project} {path}", variables); !errors.Is(err, ErrInvalidTemplate) {
+		t.Errorf("undefined variable error = %v, want ErrInvalidTemplate", err)
+	}
+
+	preview, err := NewTemplateWithVariables("editor {project:x} {path}", nil)
+	if err != nil {
+		t.Fatalf("NewTemplateWithVariables() error = %v", err)
+	}
+	if got := preview.RenderWithDefaults(TemplateVars{}); got != "editor x ." {
+		t.Errorf("RenderWithDefaults() = %q, want %q", got, "editor x .")
+	}
+}
+
+func TestNewDiffTemplate(t *testing.T) {
+	template, err := NewDiffTemplate("code --remote ssh-remote+{user}@{host} --diff {path} {path2}", nil)
+	if err != nil {
+		t.Fatalf("NewDiffTemplate() error = %v", err)
+	}
+	got, err := template.Render(TemplateVars{User: "dev", Host: "box", Path: "/src/a.go", Path2: "/src/my b.go", ShellQuote: true})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if want := "code --remote ssh-remote+dev@box --diff /src/a.go '/src/my b.go'"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+
+	if _, err := template.Render(TemplateVars{User: "dev", Host: "box", Path: "/src/a.go"}); err == nil {
+		t.Error("Render() without path2 should fail")
+	}
+	if _, err := NewDiffTemplate("code --diff {path}", nil); !errors.Is(err, ErrMissingPlaceholder) {
+		t.Errorf("NewDiffTemplate() without {path2} error = %v, want ErrMissingPlaceholder", err)
+	}
+}
+
+func TestTemplatePort(t *testing.T) {
+	template, err := NewTemplate("http://localhost:{port}/?folder={path|urlencode}")
+	if err != nil {
+		t.Fatalf("NewTemplate() error = %v", err)
+	}
+	if !template.RequiresPort() {
+		t.Error("RequiresPort() = false for a template using {port}")
+	}
+	got, err := template.Render(TemplateVars{Path: "/home/dev/my project", Port: 8080})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if want := "http://localhost:8080/?folder=%2Fhome%2Fdev%2Fmy+project"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+	if _, err := template.Render(TemplateVars{Path: "/home/dev"}); err == nil {
+		t.Error("Render() without a port should fail")
+	}
+
+	withDefault, err := NewTemplate("http://localhost:{port:8888}/lab/tree{path|urlpath}")
+	if err != nil {
+		t.Fatalf("NewTemplate() error = %v", err)
+	}
+	if withDefault.RequiresPort() {
+		t.Error("RequiresPort() = true for {port} with a default")
+	}
+	if got, _ := withDefault.Render(TemplateVars{Path: "/nb"}); got != "http://localhost:8888/lab/tree/nb" {
+		t.Errorf("Render() with the default port = %q", got)
+	}
+
+	// A port variable fills {port} for requests without one
+	withVariable, err := NewTemplateWithVariables("ssh -p {port} {host} {path}", map[string]string{"port": "2222"})
+	if err != nil {
+		t.Fatalf("NewTemplateWithVariables() error = %v", err)
+	}
+	if withVariable.RequiresPort() {
+		t.Error("RequiresPort() = true for {port} with a port variable")
+	}
+	if got, _ := withVariable.Render(TemplateVars{Host: "box", Path: "/a", Port: 2200}); got != "ssh -p 2200 box /a" {
+		t.Errorf("Render() with a request port = %q", got)
+	}
+}
+
 func TestTemplate_RenderWithDefaults(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -338,6 +697,20 @@ func TestValidateVars(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "control character",
+			vars: TemplateVars{
+				Path: "/home/project\x00.txt",
+			},
+			wantErr: true,
+		},
+		{
+			name: "relative path",
+			vars: TemplateVars{
+				Path: "project",
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -382,6 +755,21 @@ func TestEscapePath(t *testing.T) {
 			path: "/home/user/$project",
 			want: "'/home/user/$project'",
 		},
+		{
+			name: "path with shell operators",
+			path: "/tmp/a;b|c&d",
+			want: "'/tmp/a;b|c&d'",
+		},
+		{
+			name: "path with glob",
+			path: "/tmp/*.go",
+			want: "'/tmp/*.go'",
+		},
+		{
+			name: "empty path",
+			path: "",
+			want: "''",
+		},
 	}
 
 	for _, tt := range tests {
@@ -425,6 +813,42 @@ func TestParseCommand(t *testing.T) {
 			executable: "editor",
 			args:       []string{"--flag", "value"},
 		},
+		{
+			name:       "single quotes",
+			command:    `editor '/home/user/my project' 'it''s'`,
+			executable: "editor",
+			args:       []string{"/home/user/my project", "its"},
+		},
+		{
+			name:       "double quotes with escapes",
+			command:    `editor "say \"hi\"" "a\b" "$HOME"`,
+			executable: "editor",
+			args:       []string{`say "hi"`, `a\b`, "$HOME"},
+		},
+		{
+			name:       "backslash escapes",
+			command:    `editor my\ file.txt \'x\'`,
+			executable: "editor",
+			args:       []string{"my file.txt", "'x'"},
+		},
+		{
+			name:       "quoted part of a word",
+			command:    `nvim scp://alice@host/'/srv/my repo'`,
+			executable: "nvim",
+			args:       []string{"scp://alice@host//srv/my repo"},
+		},
+		{
+			name:       "empty quoted argument",
+			command:    `editor "" x`,
+			executable: "editor",
+			args:       []string{"", "x"},
+		},
+		{
+			name:       "quoted executable",
+			command:    `"/Applications/My Editor/bin/edit" file`,
+			executable: "/Applications/My Editor/bin/edit",
+			args:       []string{"file"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -446,6 +870,14 @@ func TestParseCommand(t *testing.T) {
 	}
 }
 
+func TestSplitCommandUnterminatedQuote(t *testing.T) {
+	for _, command := range []string{`editor "file`, `editor 'file`, `editor "a\"`} {
+		if _, err := SplitCommand(command); !errors.Is(err, ErrUnterminatedQuote) {
+			t.Errorf("SplitCommand(%q) error = %v, want ErrUnterminatedQuote", command, err)
+		}
+	}
+}
+
 func TestBuildCommand(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -471,6 +903,12 @@ func TestBuildCommand(t *testing.T) {
 			args:       nil,
 			want:       "editor",
 		},
+		{
+			name:       "args needing quotes",
+			executable: "editor",
+			args:       []string{"my file.txt", "it's"},
+			want:       `editor 'my file.txt' 'it'\''s'`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -482,3 +920,101 @@ func TestBuildCommand(t *testing.T) {
 		})
 	}
 }
+
+// Placeholder values used to find where the fuzzed values end up in a
+// rendered command; they need no quoting
+const (
+	fuzzUser = "fuzzuser"
+	fuzzHost = "fuzzhost"
+	fuzzPath = "/fuzz/path"
+)
+
+func FuzzTemplateRender(f *testing.F) {
+	for _, seed := range []struct{ command, user, host, path string }{
+		{"cursor --remote ssh-remote+{user}@{host} {path}", "alice", "devbox", "/home/alice/project"},
+		{"ssh {user}@{host} 'editor {path}'", "alice", "devbox", "/src/it's"},
+		{`sh -c "editor {path}"`, "alice", "devbox", "/src/$(id)"},
+		{"nvim scp://{user}@{host}/{path}", "bob", "10.0.0.5", "/srv/my repo; rm -rf ~"},
+		{"emacsclient -n +{line}:{column} /ssh:{user}@{host}:{path}", "alice", "::1", "/a b"},
+		{"code {path|dirname} {path:.}", "alice", "devbox", "/src/a\nb"},
+		{"zed ssh://{host}{path|urlpath}", "alice", "devbox", "/src/a&b`c`"},
+		{"{path}{{path}}", "alice", "devbox", "/ü/日本"},
+		{`editor "{path}'{path}'" \{path}`, "alice", "devbox", `/"'\`},
+		{"editor {path} {unknown:x} {user", "alice", "devbox", "/x"},
+	} {
+		f.Add(seed.command, seed.user, seed.host, seed.path)
+	}
+
+	f.Fuzz(func(t *testing.T, command, user, host, path string) {
+		tmpl, err := NewTemplate(command)
+		if err != nil {
+			return
+		}
+		vars := TemplateVars{User: user, Host: host, Path: path, Line: 3, Column: 7, Port: 8080, ShellQuote: true}
+		rendered, renderErr := tmpl.Render(vars)
+		_ = tmpl.RenderWithDefaults(vars)
+		if renderErr != nil || user == "" || host == "" || path == "" {
+			return
+		}
+
+		// Quoting must keep each value inside the word it is written in:
+		// the words of the command are those rendered with plain values,
+		// with the fuzzed values in their place. Filters change values and
+		// TRAMP changes hosts, so only plain placeholders are compared.
+		if strings.Contains(command, "fuzz") || validation.TrampToken(command) != "" {
+			return
+		}
+		placeholders, _ := validation.ParsePlaceholders(command)
+		for _, p := range placeholders {
+			if len(p.Filters) > 0 {
+				return
+			}
+		}
+		plain, err := tmpl.Render(TemplateVars{User: fuzzUser, Host: fuzzHost, Path: fuzzPath, Line: 3, Column: 7, Port: 8080, ShellQuote: true})
+		if err != nil {
+			t.Fatalf("Render() with plain values error = %v", err)
+		}
+		want, err := SplitCommand(plain)
+		if err != nil {
+			return
+		}
+		replacer := strings.NewReplacer(fuzzUser, user, fuzzHost, host, fuzzPath, path)
+		for i := range want {
+			want[i] = replacer.Replace(want[i])
+		}
+		got, err := SplitCommand(rendered)
+		if err != nil {
+			t.Fatalf("Render() = %q, which does not split: %v", rendered, err)
+		}
+		if !slices.Equal(got, want) {
+			t.Fatalf("Render() = %q\nsplits into %q\nwant %q", rendered, got, want)
+		}
+	})
+}
+
+func FuzzParseCommand(f *testing.F) {
+	for _, seed := range []string{
+		"editor --remote file.txt",
+		`editor '/home/user/my project' 'it''s'`,
+		`editor "say \"hi\"" "a\b" "$HOME"`,
+		`editor my\ file.txt \'x\'`,
+		"editor \"unterminated",
+		"editor \\\n next",
+		"\xff\xfe '\x80'",
+		"",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, command string) {
+		ParseCommand(command)
+
+		// Arguments built into a command come back unchanged
+		args := strings.Split(command, "\x00")
+		built := BuildCommand("editor", args)
+		gotExecutable, gotArgs := ParseCommand(built)
+		if gotExecutable != "editor" || !slices.Equal(gotArgs, args) {
+			t.Fatalf("ParseCommand(BuildCommand(%q)) = %q, %q", args, gotExecutable, gotArgs)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package editor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// DefaultTmuxWorkDir is where a tmux editor's window starts when the
+// editor sets no workdir
+const DefaultTmuxWorkDir = "{path}"
+
+// ErrNoTmuxSession is returned when a tmux editor has no session to open a
+// window in
+var ErrNoTmuxSession = errors.New("no attached tmux session")
+
+// tmuxBinary is the tmux executable; a variable so tests can replace it
+var tmuxBinary = "tmux"
+
+// TmuxSession returns the session a tmux editor opens windows in: the
+// configured one, or else the attached session that was active last
+func (e *Editor) TmuxSession() (string, error) {
+	if e.Session != "" {
+		return e.Session, nil
+	}
+	return AttachedTmuxSession()
+}
+
+// AttachedTmuxSession asks the tmux server for its sessions and returns
+// the name of the attached one that was active last. It returns
+// ErrNoTmuxSession when tmux is not running or no client is attached.
+func AttachedTmuxSession() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, tmuxBinary, "list-sessions", "-F", "#{session_attached} #{session_activity} #{session_name}").Output()
+	if err != nil {
+		// tmux exits non-zero when no server is running
+		return "", fmt.Errorf("%w: %v", ErrNoTmuxSession, err)
+	}
+	name := attachedSession(string(out))
+	if name == "" {
+		return "", ErrNoTmuxSession
+	}
+	return name, nil
+}
+
+// attachedSession picks the attached session with the latest activity from
+// list-sessions output in the format "attached activity name"
+func attachedSession(listing string) string {
+	var best string
+	var bestActivity int64 = -1
+	for _, line := range strings.Split(listing, "\n") {
+		fields := strings.SplitN(strings.TrimSpace(line), " ", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		attached, err := strconv.Atoi(fields[0])
+		if err != nil || attached == 0 {
+			continue
+		}
+		activity, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		if activity > bestActivity {
+			best, bestActivity = fields[2], activity
+		}
+	}
+	return best
+}
+
+// TmuxCommand returns the command that runs command, rendered from a tmux
+// editor's template, in a new window of session starting in dir. The words
+// of command are passed to tmux separately, so tmux runs it without a shell.
+func TmuxCommand(session, dir, command string) (string, error) {
+	words, err := SplitCommand(command)
+	if err != nil {
+		return "", err
+	}
+	if len(words) == 0 {
+		return "", fmt.Errorf("empty command")
+	}
+
+	args := []string{"new-window", "-t", session + ":"}
+	if dir != "" {
+		args = append(args, "-c", dir)
+	}
+	return BuildCommand(tmuxBinary, append(args, words...)), nil
+}
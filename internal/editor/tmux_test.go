@@ -0,0 +1,62 @@
+package editor
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAttachedSession(t *testing.T) {
+	tests := []struct {
+		name    string
+		listing string
+		want    string
+	}{
+		{"none", "", ""},
+		{"detached only", "0 1700000000 work\n", ""},
+		{"single attached", "0 1700000000 work\n1 1600000000 main\n", "main"},
+		{"latest attached wins", "1 1600000000 old\n1 1700000000 new session\n0 1800000000 idle\n", "new session"},
+		{"malformed lines", "garbage\n1 x broken\n1 1700000000 ok\n", "ok"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := attachedSession(tt.listing); got != tt.want {
+				t.Errorf("attachedSession() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTmuxCommand(t *testing.T) {
+	got, err := TmuxCommand("dev", "/home/user/my project", "nvim '/home/user/my project/main.go'")
+	if err != nil {
+		t.Fatalf("TmuxCommand() error = %v", err)
+	}
+	want := "tmux new-window -t dev: -c '/home/user/my project' nvim '/home/user/my project/main.go'"
+	if got != want {
+		t.Errorf("TmuxCommand() = %q, want %q", got, want)
+	}
+
+	if got, _ := TmuxCommand("dev", "", "nvim /srv"); got != "tmux new-window -t dev: nvim /srv" {
+		t.Errorf("TmuxCommand() without dir = %q", got)
+	}
+	if _, err := TmuxCommand("dev", "", "  "); err == nil {
+		t.Error("TmuxCommand() with an empty command should fail")
+	}
+}
+
+func TestTmuxSession(t *testing.T) {
+	e := &Editor{Name: "tmux", Session: "work"}
+	if got, err := e.TmuxSession(); err != nil || got != "work" {
+		t.Errorf("TmuxSession() = %q, %v, want the configured session", got, err)
+	}
+
+	old := tmuxBinary
+	tmuxBinary = "rcode-test-no-such-tmux"
+	defer func() { tmuxBinary = old }()
+
+	e.Session = ""
+	if _, err := e.TmuxSession(); !errors.Is(err, ErrNoTmuxSession) {
+		t.Errorf("TmuxSession() without tmux error = %v, want ErrNoTmuxSession", err)
+	}
+}
@@ -0,0 +1,272 @@
+// Package filesync keeps local copies of remote files for editors that
+// cannot open files over SSH. A file is pulled into a local directory,
+// watched for saves, and each save is pushed back unless the remote file
+// changed since it was last synced.
+package filesync
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/logger"
+)
+
+// ErrConflict is returned when a save cannot be pushed because the remote
+// file changed since it was pulled
+var ErrConflict = errors.New("remote file changed since it was pulled")
+
+// pushTimeout bounds a single pull or push made by the watcher
+const pushTimeout = time.Minute
+
+// Target is a file on a remote machine
+type Target struct {
+	User string
+	Host string
+	Path string // Absolute path on the remote machine
+}
+
+// Options configures a Manager
+type Options struct {
+	Dir          string        // Where local copies are kept
+	PollInterval time.Duration // How often copies are checked for saves
+	IdleTimeout  time.Duration // How long an unchanged copy is kept
+	Transport    Transport     // How files are copied (default: scp)
+}
+
+// Manager keeps the local copies and their watchers
+type Manager struct {
+	opts  Options
+	log   *logger.Logger
+	mu    sync.Mutex
+	files map[string]*file // by local path
+	wg    sync.WaitGroup
+	done  chan struct{}
+	once  sync.Once
+}
+
+// file is a local copy being watched
+type file struct {
+	target  Target
+	local   string
+	synced  [32]byte // hash of the content both sides last agreed on
+	modTime time.Time
+	size    int64
+	touched time.Time // last pull or save
+}
+
+// NewManager creates a Manager; Close stops its watchers
+func NewManager(opts Options, log *logger.Logger) *Manager {
+	if opts.Transport == nil {
+		opts.Transport = SCP{}
+	}
+	return &Manager{
+		opts:  opts,
+		log:   log,
+		files: make(map[string]*file),
+		done:  make(chan struct{}),
+	}
+}
+
+// LocalPath returns where the copy of t is kept
+func (m *Manager) LocalPath(t Target) string {
+	return filepath.Join(m.opts.Dir, t.User+"@"+t.Host, filepath.FromSlash(path.Clean("/"+t.Path)))
+}
+
+// Open pulls t into its local copy and starts watching the copy for saves,
+// returning the local path. A file that is already watched is not pulled
+// again, so saves not yet pushed are kept.
+func (m *Manager) Open(ctx context.Context, t Target) (string, error) {
+	local := m.LocalPath(t)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if f, ok := m.files[local]; ok {
+		f.touched = time.Now()
+		return local, nil
+	}
+	select {
+	case <-m.done:
+		return "", errors.New("file sync is stopped")
+	default:
+	}
+
+	if err := os.MkdirAll(filepath.Dir(local), 0o700); err != nil {
+		return "", fmt.Errorf("failed to create sync directory: %w", err)
+	}
+	if err := m.opts.Transport.Pull(ctx, t, local); err != nil {
+		return "", fmt.Errorf("failed to pull %s: %w", t.Path, err)
+	}
+
+	f := &file{target: t, local: local, touched: time.Now()}
+	if err := f.refresh(); err != nil {
+		return "", err
+	}
+	sum, err := hashFile(local)
+	if err != nil {
+		return "", err
+	}
+	f.synced = sum
+	m.files[local] = f
+
+	m.wg.Add(1)
+	go m.watch(f)
+
+	m.log.Info("Pulled file for local editing",
+		"path", t.Path,
+		"host", t.Host,
+		"local", local,
+	)
+	return local, nil
+}
+
+// Close stops watching, pushing saves made since the last check, and
+// removes the local copies that are in sync
+func (m *Manager) Close() {
+	m.once.Do(func() { close(m.done) })
+	m.wg.Wait()
+}
+
+// watch checks f for saves until it has been idle for IdleTimeout or the
+// manager is closed, then removes it
+func (m *Manager) watch(f *file) {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.check(f)
+			m.mu.Lock()
+			idle := time.Since(f.touched) >= m.opts.IdleTimeout
+			m.mu.Unlock()
+			if idle {
+				m.remove(f)
+				return
+			}
+		case <-m.done:
+			m.check(f)
+			m.remove(f)
+			return
+		}
+	}
+}
+
+// check pushes f when it was saved since the last check
+func (m *Manager) check(f *file) {
+	info, err := os.Stat(f.local)
+	if err != nil || (info.ModTime().Equal(f.modTime) && info.Size() == f.size) {
+		return
+	}
+	f.modTime, f.size = info.ModTime(), info.Size()
+
+	m.mu.Lock()
+	f.touched = time.Now()
+	m.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), pushTimeout)
+	defer cancel()
+	err = m.push(ctx, f)
+	switch {
+	case errors.Is(err, ErrConflict):
+		m.log.Warn("Remote file changed while it was edited locally; kept its version next to the local copy",
+			"path", f.target.Path,
+			"host", f.target.Host,
+			"remote_copy", f.local+".remote",
+		)
+	case err != nil:
+		m.log.Error("Failed to push saved file", "error", err, "path", f.target.Path, "host", f.target.Host)
+	}
+}
+
+// push sends f back unless its content is already in sync or the remote
+// file changed since it was synced. On a conflict the remote version is
+// saved next to the copy and counts as synced, so the next save, which is
+// expected to merge it, overwrites the remote file.
+func (m *Manager) push(ctx context.Context, f *file) error {
+	sum, err := hashFile(f.local)
+	if err != nil {
+		return err
+	}
+	if sum == f.synced {
+		return nil
+	}
+
+	remoteCopy := f.local + ".remote"
+	if err := m.opts.Transport.Pull(ctx, f.target, remoteCopy); err != nil {
+		return fmt.Errorf("failed to check the remote file: %w", err)
+	}
+	remoteSum, err := hashFile(remoteCopy)
+	if err != nil {
+		return err
+	}
+	if remoteSum != f.synced {
+		f.synced = remoteSum
+		return ErrConflict
+	}
+	_ = os.Remove(remoteCopy)
+
+	if err := m.opts.Transport.Push(ctx, f.target, f.local); err != nil {
+		return err
+	}
+	f.synced = sum
+	m.log.Info("Pushed saved file", "path", f.target.Path, "host", f.target.Host)
+	return nil
+}
+
+// remove stops tracking f and deletes its copy when it is in sync; a copy
+// with changes that could not be pushed is left for the user
+func (m *Manager) remove(f *file) {
+	m.mu.Lock()
+	delete(m.files, f.local)
+	m.mu.Unlock()
+
+	if sum, err := hashFile(f.local); err == nil && sum != f.synced {
+		m.log.Warn("Keeping local copy with unpushed changes", "local", f.local, "path", f.target.Path)
+		return
+	}
+	if _, err := os.Stat(f.local + ".remote"); err == nil {
+		m.log.Warn("Keeping local copy with an unresolved conflict", "local", f.local, "path", f.target.Path)
+		return
+	}
+	_ = os.Remove(f.local)
+}
+
+// Watched returns the local paths of the copies being watched
+func (m *Manager) Watched() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	locals := make([]string, 0, len(m.files))
+	for local := range m.files {
+		locals = append(locals, local)
+	}
+	return locals
+}
+
+// refresh records the copy's current modification time and size
+func (f *file) refresh() error {
+	info, err := os.Stat(f.local)
+	if err != nil {
+		return err
+	}
+	f.modTime, f.size = info.ModTime(), info.Size()
+	return nil
+}
+
+// hashFile returns the SHA-256 of the file at name
+func hashFile(name string) ([32]byte, error) {
+	data, err := os.ReadFile(name) // #nosec G304 -- copies live in the sync directory
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return sha256.Sum256(data), nil
+}
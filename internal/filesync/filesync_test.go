@@ -0,0 +1,189 @@
+package filesync
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/logger"
+)
+
+// fakeTransport keeps remote files in memory
+type fakeTransport struct {
+	mu     sync.Mutex
+	files  map[string]string
+	pushes int
+}
+
+func (f *fakeTransport) Pull(_ context.Context, t Target, local string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, ok := f.files[t.Path]
+	if !ok {
+		return errors.New("no such file")
+	}
+	return os.WriteFile(local, []byte(data), 0o600)
+}
+
+func (f *fakeTransport) Push(_ context.Context, t Target, local string) error {
+	data, err := os.ReadFile(local)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.files[t.Path] = string(data)
+	f.pushes++
+	return nil
+}
+
+func (f *fakeTransport) get(path string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.files[path]
+}
+
+func (f *fakeTransport) set(path, data string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.files[path] = data
+}
+
+func newTestManager(t *testing.T, transport Transport) *Manager {
+	t.Helper()
+	m := NewManager(Options{
+		Dir:          t.TempDir(),
+		PollInterval: time.Hour, // tests call check themselves
+		IdleTimeout:  time.Hour,
+		Transport:    transport,
+	}, logger.New(&logger.Config{Level: "error"}))
+	t.Cleanup(m.Close)
+	return m
+}
+
+// save writes data to the copy at local and records it as a new save
+func save(t *testing.T, m *Manager, local, data string) *file {
+	t.Helper()
+	if err := os.WriteFile(local, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	m.mu.Lock()
+	f := m.files[local]
+	m.mu.Unlock()
+	f.modTime = time.Time{} // mtime granularity may hide quick saves
+	return f
+}
+
+func TestManagerPushesSaves(t *testing.T) {
+	transport := &fakeTransport{files: map[string]string{"/src/main.go": "v1"}}
+	m := newTestManager(t, transport)
+	target := Target{User: "dev", Host: "box", Path: "/src/main.go"}
+
+	local, err := m.Open(context.Background(), target)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if want := filepath.Join(m.opts.Dir, "dev@box", "src", "main.go"); local != want {
+		t.Errorf("Open() = %q, want %q", local, want)
+	}
+	if data, _ := os.ReadFile(local); string(data) != "v1" {
+		t.Errorf("local copy = %q, want v1", data)
+	}
+
+	m.check(save(t, m, local, "v2"))
+	if got := transport.get("/src/main.go"); got != "v2" {
+		t.Errorf("remote after save = %q, want v2", got)
+	}
+
+	// Nothing changed, nothing is pushed
+	m.check(save(t, m, local, "v2"))
+	if transport.pushes != 1 {
+		t.Errorf("pushes = %d, want 1", transport.pushes)
+	}
+
+	m.Close()
+	if _, err := os.Stat(local); !os.IsNotExist(err) {
+		t.Errorf("local copy in sync should be removed on Close, stat error = %v", err)
+	}
+}
+
+func TestManagerDetectsConflicts(t *testing.T) {
+	transport := &fakeTransport{files: map[string]string{"/src/main.go": "v1"}}
+	m := newTestManager(t, transport)
+
+	local, err := m.Open(context.Background(), Target{User: "dev", Host: "box", Path: "/src/main.go"})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	transport.set("/src/main.go", "changed remotely")
+	f := save(t, m, local, "changed locally")
+	if err := m.push(context.Background(), f); !errors.Is(err, ErrConflict) {
+		t.Fatalf("push() error = %v, want ErrConflict", err)
+	}
+	if got := transport.get("/src/main.go"); got != "changed remotely" {
+		t.Errorf("remote after conflict = %q, want it untouched", got)
+	}
+	if data, _ := os.ReadFile(local + ".remote"); string(data) != "changed remotely" {
+		t.Errorf("remote copy = %q, want the remote version", data)
+	}
+
+	// The next save is taken to merge the remote version
+	f = save(t, m, local, "merged")
+	if err := m.push(context.Background(), f); err != nil {
+		t.Fatalf("push() after merge error = %v", err)
+	}
+	if got := transport.get("/src/main.go"); got != "merged" {
+		t.Errorf("remote after merge = %q, want merged", got)
+	}
+	if _, err := os.Stat(local + ".remote"); !os.IsNotExist(err) {
+		t.Errorf("remote copy should be removed after a push, stat error = %v", err)
+	}
+}
+
+func TestManagerKeepsUnpushedCopies(t *testing.T) {
+	transport := &fakeTransport{files: map[string]string{"/src/main.go": "v1"}}
+	m := newTestManager(t, transport)
+
+	local, err := m.Open(context.Background(), Target{User: "dev", Host: "box", Path: "/src/main.go"})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	transport.set("/src/main.go", "changed remotely")
+	save(t, m, local, "changed locally")
+
+	m.Close()
+	if data, _ := os.ReadFile(local); string(data) != "changed locally" {
+		t.Errorf("local copy = %q, want it kept with its changes", data)
+	}
+}
+
+func TestManagerOpenFailure(t *testing.T) {
+	m := newTestManager(t, &fakeTransport{files: map[string]string{}})
+	if _, err := m.Open(context.Background(), Target{User: "dev", Host: "box", Path: "/missing"}); err == nil {
+		t.Error("Open() of a missing file should fail")
+	}
+	if len(m.Watched()) != 0 {
+		t.Errorf("Watched() = %v, want none", m.Watched())
+	}
+}
+
+func TestRemoteSpec(t *testing.T) {
+	tests := []struct {
+		target Target
+		want   string
+	}{
+		{Target{User: "dev", Host: "box", Path: "/a"}, "dev@box:/a"},
+		{Target{Host: "box", Path: "/a"}, "box:/a"},
+		{Target{User: "dev", Host: "fd00::1", Path: "/a"}, "dev@[fd00::1]:/a"},
+	}
+	for _, tt := range tests {
+		if got := remoteSpec(tt.target); got != tt.want {
+			t.Errorf("remoteSpec(%+v) = %q, want %q", tt.target, got, tt.want)
+		}
+	}
+}
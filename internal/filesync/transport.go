@@ -0,0 +1,61 @@
+package filesync
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Transport copies files between this machine and a remote one
+type Transport interface {
+	// Pull copies the remote file t to local
+	Pull(ctx context.Context, t Target, local string) error
+	// Push copies local to the remote file t
+	Push(ctx context.Context, t Target, local string) error
+}
+
+// SCP copies files with scp, relying on the user's SSH keys and config.
+// BatchMode keeps scp from waiting for a password nobody can type.
+type SCP struct {
+	Binary string // scp executable (default: scp)
+}
+
+// Pull copies the remote file t to local
+func (s SCP) Pull(ctx context.Context, t Target, local string) error {
+	return s.run(ctx, remoteSpec(t), local)
+}
+
+// Push copies local to the remote file t
+func (s SCP) Push(ctx context.Context, t Target, local string) error {
+	return s.run(ctx, local, remoteSpec(t))
+}
+
+// run copies from to to, reporting scp's message on failure
+func (s SCP) run(ctx context.Context, from, to string) error {
+	binary := s.Binary
+	if binary == "" {
+		binary = "scp"
+	}
+	out, err := exec.CommandContext(ctx, binary, "-q", "-p", "-o", "BatchMode=yes", "--", from, to).CombinedOutput() // #nosec G204 -- arguments are not run through a shell
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("scp failed: %w: %s", err, msg)
+		}
+		return fmt.Errorf("scp failed: %w", err)
+	}
+	return nil
+}
+
+// remoteSpec returns scp's user@host:path form of t; IPv6 addresses are
+// bracketed so their colons are not taken for the path separator
+func remoteSpec(t Target) string {
+	host := t.Host
+	if strings.Contains(host, ":") && !strings.HasPrefix(host, "[") {
+		host = "[" + host + "]"
+	}
+	if t.User == "" {
+		return host + ":" + t.Path
+	}
+	return t.User + "@" + host + ":" + t.Path
+}
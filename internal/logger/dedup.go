@@ -0,0 +1,144 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DedupHandler collapses repeated log records. A record with the same level,
+// message and attributes as one logged less than its level's window ago is
+// dropped and counted; the count is logged as "message repeated N times"
+// when the record next gets through, or on Close. Levels without a window
+// are passed through.
+type DedupHandler struct {
+	next    slog.Handler
+	windows map[slog.Level]time.Duration
+	prefix  string // attributes and group added to the handler, part of the key
+	state   *dedupState
+}
+
+// dedupState is shared by a DedupHandler and the handlers derived from it
+type dedupState struct {
+	mu   sync.Mutex
+	seen map[string]*dedupEntry
+}
+
+// dedupEntry is a record that got through, and the copies dropped since
+type dedupEntry struct {
+	handler slog.Handler // to log the count with the record's attributes
+	level   slog.Level
+	message string
+	logged  time.Time
+	dropped int
+}
+
+// NewDedupHandler returns a handler that collapses the records of each
+// level in windows repeated within its window before passing them to next
+func NewDedupHandler(next slog.Handler, windows map[slog.Level]time.Duration) *DedupHandler {
+	return &DedupHandler{
+		next:    next,
+		windows: windows,
+		state:   &dedupState{seen: make(map[string]*dedupEntry)},
+	}
+}
+
+// Enabled reports whether the handler handles records at the given level
+func (h *DedupHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle passes r on unless it repeats a record within its window
+//
+//nolint:gocritic // slog.Handler interface requires value receiver
+func (h *DedupHandler) Handle(ctx context.Context, r slog.Record) error {
+	window, ok := h.windows[r.Level]
+	if !ok || window <= 0 {
+		return h.next.Handle(ctx, r)
+	}
+
+	key := h.key(r)
+	h.state.mu.Lock()
+	if e, ok := h.state.seen[key]; ok && r.Time.Sub(e.logged) < window {
+		e.dropped++
+		h.state.mu.Unlock()
+		return nil
+	}
+	// Report the copies dropped by the records whose windows are over,
+	// this one's included, and forget them
+	var expired []*dedupEntry
+	for k, e := range h.state.seen {
+		if r.Time.Sub(e.logged) >= h.windows[e.level] || k == key {
+			delete(h.state.seen, k)
+			if e.dropped > 0 {
+				expired = append(expired, e)
+			}
+		}
+	}
+	h.state.seen[key] = &dedupEntry{handler: h.next, level: r.Level, message: r.Message, logged: r.Time}
+	h.state.mu.Unlock()
+
+	for _, e := range expired {
+		_ = e.report(ctx, r.Time)
+	}
+	return h.next.Handle(ctx, r)
+}
+
+// WithAttrs returns a new Handler with the given attributes added
+func (h *DedupHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var sb strings.Builder
+	sb.WriteString(h.prefix)
+	for _, a := range attrs {
+		fmt.Fprintf(&sb, "%s=%s ", a.Key, a.Value)
+	}
+	return &DedupHandler{next: h.next.WithAttrs(attrs), windows: h.windows, prefix: sb.String(), state: h.state}
+}
+
+// WithGroup returns a new Handler with the given group name
+func (h *DedupHandler) WithGroup(name string) slog.Handler {
+	return &DedupHandler{next: h.next.WithGroup(name), windows: h.windows, prefix: h.prefix + name + ".", state: h.state}
+}
+
+// Close logs the copies dropped since their record last got through
+func (h *DedupHandler) Close() error {
+	h.state.mu.Lock()
+	var dropped []*dedupEntry
+	for k, e := range h.state.seen {
+		delete(h.state.seen, k)
+		if e.dropped > 0 {
+			dropped = append(dropped, e)
+		}
+	}
+	h.state.mu.Unlock()
+
+	now := time.Now()
+	for _, e := range dropped {
+		if err := e.report(context.Background(), now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// key identifies the records that are copies of r
+//
+//nolint:gocritic // slog.Record is passed by value throughout slog
+func (h *DedupHandler) key(r slog.Record) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s%s %s", h.prefix, r.Level, r.Message)
+	r.Attrs(func(a slog.Attr) bool {
+		fmt.Fprintf(&sb, " %s=%s", a.Key, a.Value)
+		return true
+	})
+	return sb.String()
+}
+
+// report logs how many copies of e were dropped
+func (e *dedupEntry) report(ctx context.Context, t time.Time) error {
+	r := slog.NewRecord(t, e.level, fmt.Sprintf("%s (message repeated %d times)", e.message, e.dropped), 0)
+	r.AddAttrs(slog.Int("repeated", e.dropped))
+	return e.handler.Handle(ctx, r)
+}
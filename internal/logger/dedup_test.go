@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDedupHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewDedupHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}), map[slog.Level]time.Duration{slog.LevelWarn: time.Minute})
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	log := func(h slog.Handler, level slog.Level, offset time.Duration, msg string, attrs ...slog.Attr) {
+		r := slog.NewRecord(start.Add(offset), level, msg, 0)
+		r.AddAttrs(attrs...)
+		if err := h.Handle(context.Background(), r); err != nil {
+			t.Fatalf("Handle() error = %v", err)
+		}
+	}
+
+	unreachable := slog.String("host", "10.0.0.1")
+	log(h, slog.LevelWarn, 0, "Server unreachable", unreachable)
+	log(h, slog.LevelWarn, time.Second, "Server unreachable", unreachable)
+	log(h, slog.LevelWarn, 2*time.Second, "Server unreachable", unreachable)
+	// Different attributes, and levels without a window, are not collapsed
+	log(h, slog.LevelWarn, 3*time.Second, "Server unreachable", slog.String("host", "10.0.0.2"))
+	log(h, slog.LevelInfo, 4*time.Second, "Opened")
+	log(h, slog.LevelInfo, 5*time.Second, "Opened")
+	// Handlers with attributes of their own are told apart
+	log(h.WithAttrs([]slog.Attr{slog.String("role", "fallback")}), slog.LevelWarn, 6*time.Second, "Server unreachable", unreachable)
+	// The window is over: the count comes first, then the record
+	log(h, slog.LevelWarn, 2*time.Minute, "Server unreachable", unreachable)
+	log(h, slog.LevelWarn, 2*time.Minute+time.Second, "Server unreachable", unreachable)
+
+	if err := h.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	want := []string{
+		`level=WARN msg="Server unreachable" host=10.0.0.1`,
+		`level=WARN msg="Server unreachable" host=10.0.0.2`,
+		`level=INFO msg=Opened`,
+		`level=INFO msg=Opened`,
+		`level=WARN msg="Server unreachable" role=fallback host=10.0.0.1`,
+		`level=WARN msg="Server unreachable (message repeated 2 times)" repeated=2`,
+		`level=WARN msg="Server unreachable" host=10.0.0.1`,
+		`level=WARN msg="Server unreachable (message repeated 1 times)" repeated=1`,
+	}
+	got := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("output =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestNewWithDedup(t *testing.T) {
+	log := New(&Config{Level: "info", Dedup: map[string]time.Duration{"error": time.Minute}})
+	if _, ok := log.Handler().(*DedupHandler); !ok {
+		t.Errorf("handler = %T, want *DedupHandler", log.Handler())
+	}
+	if len(log.closers) != 1 {
+		t.Errorf("closers = %d, want the dedup handler", len(log.closers))
+	}
+}
@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// ECSVersion is the Elastic Common Schema version the ECS format follows
+const ECSVersion = "8.11.0"
+
+// ecsFields maps the attribute keys rcode logs with to their ECS fields;
+// other keys are written as they are
+var ecsFields = map[string]string{
+	"error":       "error.message",
+	"trace_id":    "trace.id",
+	"span_id":     "span.id",
+	"remote_addr": "client.address",
+	"client_ip":   "client.ip",
+	"method":      "http.request.method",
+	"status":      "http.response.status_code",
+	"url":         "url.full",
+	"user":        "user.name",
+	"port":        "server.port",
+	"signal":      "process.signal",
+	"command":     "process.command_line",
+	"args":        "process.args",
+	"file":        "file.path",
+}
+
+// NewECSHandler returns a JSON handler that names fields after the Elastic
+// Common Schema, so logs can be shipped to Elasticsearch or Datadog without
+// a pipeline to rename them: @timestamp, log.level, message, ecs.version and
+// the fields in ecsFields
+func NewECSHandler(w io.Writer, level slog.Leveler) slog.Handler {
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: ecsAttr,
+	})
+	return handler.WithAttrs([]slog.Attr{slog.String("ecs.version", ECSVersion)})
+}
+
+// ecsAttr renames an attribute to its ECS field; attributes in groups are
+// left alone, their group names the object they belong to
+func ecsAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) > 0 {
+		return a
+	}
+	switch a.Key {
+	case slog.TimeKey:
+		a.Key = "@timestamp"
+	case slog.LevelKey:
+		a.Key = "log.level"
+		a.Value = slog.StringValue(strings.ToLower(a.Value.String()))
+	case slog.MessageKey:
+		a.Key = "message"
+	default:
+		if field, ok := ecsFields[a.Key]; ok {
+			a.Key = field
+		}
+	}
+	return a
+}
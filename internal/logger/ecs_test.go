@@ -0,0 +1,43 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+func TestECSHandler(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(NewECSHandler(&buf, slog.LevelInfo))
+
+	log.Debug("hidden")
+	log.With("trace_id", "abc").Warn("Editor failed", "error", errors.New("boom"), "editor", "cursor")
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("output is not one JSON object: %v\n%s", err, buf.String())
+	}
+	want := map[string]any{
+		"log.level":     "warn",
+		"message":       "Editor failed",
+		"ecs.version":   ECSVersion,
+		"trace.id":      "abc",
+		"error.message": "boom",
+		"editor":        "cursor",
+	}
+	for key, value := range want {
+		if entry[key] != value {
+			t.Errorf("%s = %v, want %v", key, entry[key], value)
+		}
+	}
+	if _, ok := entry["@timestamp"]; !ok {
+		t.Errorf("no @timestamp in %v", entry)
+	}
+	for _, key := range []string{"time", "level", "msg", "error", "trace_id"} {
+		if _, ok := entry[key]; ok {
+			t.Errorf("%s was not renamed: %v", key, entry)
+		}
+	}
+}
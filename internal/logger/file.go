@@ -4,6 +4,7 @@ package logger
 import (
 	"compress/gzip"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -14,22 +15,44 @@ import (
 	"time"
 )
 
+// Rotation schedules
+const (
+	RotateDaily  = "daily"
+	RotateWeekly = "weekly" // Weeks start on Monday
+)
+
+// errClosed is returned by writes after Close
+var errClosed = errors.New("file writer is closed")
+
 // FileWriter implements io.Writer with rotation support
 type FileWriter struct {
-	config    *FileWriterConfig
-	file      *os.File
-	size      int64
-	mu        sync.Mutex
-	millCh    chan struct{}
-	startMill sync.Once
+	config   *FileWriterConfig
+	filename string
+	file     *os.File
+	size     int64
+	opened   time.Time // when the file started, to rotate on schedule
+	closed   bool
+	mu       sync.Mutex // held while writing and rotating
+	millCh   chan struct{}
+	wg       sync.WaitGroup // the mill loop, and compression and cleanup after a rotation
+	now      func() time.Time
 }
 
-// FileWriterConfig holds configuration for file writer
+// FileWriterConfig holds configuration for file writer. A file is rotated
+// when it reaches MaxSize or, with a Schedule, when the day or week changes,
+// whichever comes first.
 type FileWriterConfig struct {
-	MaxSize    int  // Maximum size in MB before rotation
-	MaxBackups int  // Maximum number of old log files to keep
-	MaxAge     int  // Maximum age in days
-	Compress   bool // Whether to compress rotated files
+	MaxSize    int    // Maximum size in MB before rotation
+	MaxBackups int    // Maximum number of old log files to keep
+	MaxAge     int    // Maximum age in days
+	Compress   bool   // Whether to compress rotated files
+	Schedule   string // Also rotate when the day or week changes: "daily" or "weekly" (default: size only)
+	DateNames  bool   // Name backups after the day they start, e.g. server-2024-05-01.log, instead of server.log.TIMESTAMP
+
+	// SyncRotate rotates in Write, before the write that would overflow the
+	// file or start a new day, instead of in the background after it; every
+	// write then lands in the file it belongs to
+	SyncRotate bool
 }
 
 // NewFileWriter creates a new file writer with rotation support
@@ -50,8 +73,10 @@ func NewFileWriter(filename string, config *FileWriterConfig) (*FileWriter, erro
 	}
 
 	fw := &FileWriter{
-		config: config,
-		millCh: make(chan struct{}, 1),
+		config:   config,
+		filename: filename,
+		millCh:   make(chan struct{}, 1),
+		now:      time.Now,
 	}
 
 	// Open the file
@@ -60,9 +85,10 @@ func NewFileWriter(filename string, config *FileWriterConfig) (*FileWriter, erro
 	}
 
 	// Start the mill goroutine
-	fw.startMill.Do(func() {
-		go fw.millLoop(filename)
-	})
+	if !config.SyncRotate {
+		fw.wg.Add(1)
+		go fw.millLoop()
+	}
 
 	return fw, nil
 }
@@ -72,15 +98,25 @@ func (fw *FileWriter) Write(p []byte) (int, error) {
 	fw.mu.Lock()
 	defer fw.mu.Unlock()
 
+	if fw.closed {
+		return 0, errClosed
+	}
+
+	if fw.config.SyncRotate && (fw.file == nil || fw.rotationDue(int64(len(p)))) {
+		if err := fw.rotateLocked(); err != nil && fw.file == nil {
+			return 0, err
+		}
+	}
 	if fw.file == nil {
-		return 0, fmt.Errorf("file writer is closed")
+		// A background rotation failed to open the new file
+		return 0, fmt.Errorf("log file %s is not open", fw.filename)
 	}
 
 	n, err := fw.file.Write(p)
 	fw.size += int64(n)
 
-	// Check if rotation is needed
-	if fw.config.MaxSize > 0 && fw.size >= int64(fw.config.MaxSize)*1024*1024 {
+	// Rotate once not even another byte fits
+	if !fw.config.SyncRotate && fw.rotationDue(1) {
 		select {
 		case fw.millCh <- struct{}{}:
 		default:
@@ -90,18 +126,24 @@ func (fw *FileWriter) Write(p []byte) (int, error) {
 	return n, err
 }
 
-// Close closes the file writer
+// Close closes the file writer, waiting for a rotation in progress and for
+// the compression and cleanup of rotated files
 func (fw *FileWriter) Close() error {
 	fw.mu.Lock()
-	defer fw.mu.Unlock()
-
-	if fw.file == nil {
+	if fw.closed {
+		fw.mu.Unlock()
 		return nil
 	}
-
-	err := fw.file.Close()
-	fw.file = nil
+	fw.closed = true
+	var err error
+	if fw.file != nil {
+		err = fw.file.Close()
+		fw.file = nil
+	}
 	close(fw.millCh)
+	fw.mu.Unlock()
+
+	fw.wg.Wait()
 	return err
 }
 
@@ -122,41 +164,110 @@ func (fw *FileWriter) openFile(filename string) error {
 
 	fw.file = file
 	fw.size = info.Size()
+	// A file left from an earlier run started no later than its last write
+	fw.opened = fw.now()
+	if info.Size() > 0 && info.ModTime().Before(fw.opened) {
+		fw.opened = info.ModTime()
+	}
 	return nil
 }
 
-// rotate performs log rotation
-func (fw *FileWriter) rotate(filename string) error {
+// rotationDue reports whether next more bytes would overflow the file, or
+// whether its day or week is over. An empty file is never rotated, so a
+// write larger than MaxSize still lands somewhere.
+func (fw *FileWriter) rotationDue(next int64) bool {
+	if fw.size == 0 {
+		return false
+	}
+	if fw.config.MaxSize > 0 && fw.size+next > int64(fw.config.MaxSize)*1024*1024 {
+		return true
+	}
+	if fw.config.Schedule == "" {
+		return false
+	}
+	return periodStart(fw.config.Schedule, fw.now()).After(periodStart(fw.config.Schedule, fw.opened))
+}
+
+// periodStart returns the start of the day or week of schedule holding t
+func periodStart(schedule string, t time.Time) time.Time {
+	year, month, day := t.Date()
+	start := time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+	if schedule == RotateWeekly {
+		start = start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+	}
+	return start
+}
+
+// backupName returns the name a full log file is renamed to
+func (fw *FileWriter) backupName(filename string) string {
+	if !fw.config.DateNames {
+		// Timestamp including nanoseconds to avoid collisions
+		return fmt.Sprintf("%s.%s", filename, fw.now().Format("20060102-150405.000000000"))
+	}
+
+	ext := filepath.Ext(filename)
+	stem := strings.TrimSuffix(filename, ext) + "-" + fw.opened.Format("2006-01-02")
+	name := stem + ext
+	// Files rotated on size more than once a day are numbered
+	for i := 1; fileExists(name) || fileExists(name+".gz"); i++ {
+		name = fmt.Sprintf("%s.%d%s", stem, i, ext)
+	}
+	return name
+}
+
+// fileExists reports whether there is a file at name
+func fileExists(name string) bool {
+	_, err := os.Lstat(name)
+	return err == nil
+}
+
+// rotate performs log rotation unless the writer is closed
+func (fw *FileWriter) rotate() error {
 	fw.mu.Lock()
 	defer fw.mu.Unlock()
 
+	if fw.closed {
+		return nil
+	}
+	return fw.rotateLocked()
+}
+
+// rotateLocked renames the current file and opens a new one; the rotated
+// file is compressed and old files removed in the background. fw.mu must be
+// held.
+func (fw *FileWriter) rotateLocked() error {
+	filename := fw.filename
+
 	// Close current file
 	if fw.file != nil {
 		_ = fw.file.Close()
 		fw.file = nil
 	}
 
-	// Generate backup filename with timestamp including nanoseconds to avoid collisions
-	now := time.Now()
-	backupName := fmt.Sprintf("%s.%s", filename, now.Format("20060102-150405.000000000"))
+	backupName := fw.backupName(filename)
 
 	// Rename current file
 	if err := os.Rename(filename, backupName); err != nil && !os.IsNotExist(err) {
+		// Keep writing to the file rather than losing the logs
+		_ = fw.openFile(filename)
 		return fmt.Errorf("failed to rotate log file: %w", err)
 	}
 
-	// Compress if configured
-	if fw.config.Compress {
-		go fw.compressFile(backupName)
-	}
-
 	// Open new file
 	if err := fw.openFile(filename); err != nil {
 		return err
 	}
 
-	// Clean old files
-	go fw.cleanOldFiles(filename)
+	// Compress if configured, then clean old files; in that order, so the
+	// cleanup sees the compressed file
+	fw.wg.Add(1)
+	go func() {
+		defer fw.wg.Done()
+		if fw.config.Compress {
+			fw.compressFile(backupName)
+		}
+		fw.cleanOldFiles(filename)
+	}()
 
 	return nil
 }
@@ -202,6 +313,14 @@ func (fw *FileWriter) cleanOldFiles(filename string) {
 	if err != nil {
 		return
 	}
+	if fw.config.DateNames {
+		ext := filepath.Ext(base)
+		dated, err := filepath.Glob(filepath.Join(dir, strings.TrimSuffix(base, ext)+"-[0-9][0-9][0-9][0-9]-[0-9][0-9]-[0-9][0-9]*"+ext+"*"))
+		if err != nil {
+			return
+		}
+		matches = append(matches, dated...)
+	}
 
 	backups := make([]logFile, 0, len(matches))
 	cutoff := time.Now().Add(-24 * time.Hour * time.Duration(fw.config.MaxAge))
@@ -240,10 +359,11 @@ func (fw *FileWriter) cleanOldFiles(filename string) {
 	}
 }
 
-// millLoop runs the rotation loop
-func (fw *FileWriter) millLoop(filename string) {
+// millLoop runs the rotation loop until Close
+func (fw *FileWriter) millLoop() {
+	defer fw.wg.Done()
 	for range fw.millCh {
-		_ = fw.rotate(filename)
+		_ = fw.rotate()
 	}
 }
 
@@ -2,9 +2,11 @@ package logger
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -222,3 +224,194 @@ func TestGenerateTraceID(t *testing.T) {
 		t.Error("generateTraceID() format incorrect, missing hyphen")
 	}
 }
+
+func TestPeriodStart(t *testing.T) {
+	t.Parallel()
+
+	day := func(d, h int) time.Time { return time.Date(2024, 5, d, h, 30, 0, 0, time.UTC) }
+	tests := []struct {
+		schedule string
+		t        time.Time
+		want     time.Time
+	}{
+		{RotateDaily, day(1, 15), time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{RotateWeekly, day(1, 15), time.Date(2024, 4, 29, 0, 0, 0, 0, time.UTC)}, // Wednesday
+		{RotateWeekly, day(5, 23), time.Date(2024, 4, 29, 0, 0, 0, 0, time.UTC)}, // Sunday
+		{RotateWeekly, day(6, 0), time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)},   // Monday
+	}
+	for _, tt := range tests {
+		if got := periodStart(tt.schedule, tt.t); !got.Equal(tt.want) {
+			t.Errorf("periodStart(%s, %v) = %v, want %v", tt.schedule, tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestFileWriterScheduledRotation(t *testing.T) {
+	tempDir := t.TempDir()
+	logFile := filepath.Join(tempDir, "server.log")
+
+	// Backups from earlier days, one beyond MaxBackups
+	for i, name := range []string{"server-2024-04-27.log", "server-2024-04-28.log.gz", "server-2024-04-29.log"} {
+		path := filepath.Join(tempDir, name)
+		if err := os.WriteFile(path, []byte("old\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		modTime := time.Now().Add(time.Duration(i-3) * time.Hour)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fw, err := NewFileWriter(logFile, &FileWriterConfig{
+		MaxSize:    1,
+		MaxBackups: 3,
+		Schedule:   RotateDaily,
+		DateNames:  true,
+	})
+	if err != nil {
+		t.Fatalf("NewFileWriter() error = %v", err)
+	}
+	defer func() { _ = fw.Close() }()
+
+	fw.mu.Lock()
+	fw.opened = time.Date(2024, 4, 30, 23, 0, 0, 0, time.Local)
+	fw.now = func() time.Time { return time.Date(2024, 5, 1, 0, 1, 0, 0, time.Local) }
+	fw.mu.Unlock()
+
+	// The first line of the day was written before the mill rotated the file
+	if _, err := fw.Write([]byte("last line\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	backup := filepath.Join(tempDir, "server-2024-04-30.log")
+	deadline := time.Now().Add(2 * time.Second)
+	for !fileExists(backup) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if data, err := os.ReadFile(backup); err != nil || string(data) != "last line\n" {
+		t.Fatalf("backup = %q, %v; want the rotated file", data, err)
+	}
+
+	// The oldest backup is removed once there are more than MaxBackups
+	for time.Now().Before(deadline) && fileExists(filepath.Join(tempDir, "server-2024-04-27.log")) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if fileExists(filepath.Join(tempDir, "server-2024-04-27.log")) {
+		t.Error("oldest dated backup was kept beyond MaxBackups")
+	}
+
+	// Writes in the new day go to a new file and do not rotate it again
+	if _, err := fw.Write([]byte("new day\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if data, _ := os.ReadFile(logFile); string(data) != "new day\n" {
+		t.Errorf("current file = %q, want only the new day's line", data)
+	}
+}
+
+func TestFileWriterBackupName(t *testing.T) {
+	tempDir := t.TempDir()
+	logFile := filepath.Join(tempDir, "server.log")
+	fw := &FileWriter{
+		config: &FileWriterConfig{DateNames: true},
+		opened: time.Date(2024, 5, 1, 9, 0, 0, 0, time.Local),
+		now:    time.Now,
+	}
+
+	if got, want := fw.backupName(logFile), filepath.Join(tempDir, "server-2024-05-01.log"); got != want {
+		t.Errorf("backupName() = %s, want %s", got, want)
+	}
+
+	// Rotated on size again the same day, with the first backup compressed
+	if err := os.WriteFile(filepath.Join(tempDir, "server-2024-05-01.log.gz"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fw.backupName(logFile), filepath.Join(tempDir, "server-2024-05-01.1.log"); got != want {
+		t.Errorf("backupName() = %s, want %s", got, want)
+	}
+}
+
+func TestFileWriterSyncRotate(t *testing.T) {
+	tempDir := t.TempDir()
+	logFile := filepath.Join(tempDir, "test.log")
+
+	fw, err := NewFileWriter(logFile, &FileWriterConfig{
+		MaxSize:    1,
+		SyncRotate: true,
+	})
+	if err != nil {
+		t.Fatalf("NewFileWriter() error = %v", err)
+	}
+	defer func() { _ = fw.Close() }()
+
+	half := make([]byte, 512*1024)
+	for i := 0; i < 3; i++ {
+		if _, err := fw.Write(half); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	// The second write filled the first file, so the third started a new
+	// one before it was written, without waiting for a background rotation
+	backups, _ := filepath.Glob(logFile + ".*")
+	if len(backups) != 1 {
+		t.Fatalf("backups = %v, want 1", backups)
+	}
+	if info, err := os.Stat(backups[0]); err != nil || info.Size() != 1024*1024 {
+		t.Errorf("backup size = %v, %v; want exactly 1MB", info, err)
+	}
+	if info, err := os.Stat(logFile); err != nil || info.Size() != int64(len(half)) {
+		t.Errorf("current file = %v, %v; want the last write only", info, err)
+	}
+}
+
+func TestFileWriterConcurrentRotateAndClose(t *testing.T) {
+	for _, syncRotate := range []bool{false, true} {
+		tempDir := t.TempDir()
+		logFile := filepath.Join(tempDir, "test.log")
+
+		fw, err := NewFileWriter(logFile, &FileWriterConfig{
+			MaxSize:    1,
+			MaxBackups: 2,
+			Compress:   true,
+			SyncRotate: syncRotate,
+		})
+		if err != nil {
+			t.Fatalf("NewFileWriter() error = %v", err)
+		}
+
+		line := make([]byte, 64*1024)
+		var wg sync.WaitGroup
+		for w := 0; w < 4; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 40; i++ {
+					if _, err := fw.Write(line); err != nil {
+						return // closed
+					}
+				}
+			}()
+		}
+		time.Sleep(5 * time.Millisecond)
+		if err := fw.Close(); err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+		wg.Wait()
+
+		// Nothing is written, rotated or compressed after Close returns
+		if _, err := fw.Write(line); !errors.Is(err, errClosed) {
+			t.Errorf("Write() after Close error = %v, want errClosed", err)
+		}
+		before, _ := filepath.Glob(filepath.Join(tempDir, "*"))
+		time.Sleep(50 * time.Millisecond)
+		after, _ := filepath.Glob(filepath.Join(tempDir, "*"))
+		if strings.Join(before, ",") != strings.Join(after, ",") {
+			t.Errorf("files changed after Close (sync %v): %v -> %v", syncRotate, before, after)
+		}
+		if err := fw.Close(); err != nil {
+			t.Errorf("second Close() error = %v", err)
+		}
+	}
+}
@@ -21,7 +21,7 @@ type TextHandler struct {
 
 // TextHandlerOptions are options for the TextHandler
 type TextHandlerOptions struct {
-	Level       slog.Level
+	Level       slog.Leveler
 	TimeFormat  string
 	ColorOutput bool
 }
@@ -37,6 +37,9 @@ func NewTextHandler(w io.Writer, opts *TextHandlerOptions) *TextHandler {
 	if opts.TimeFormat == "" {
 		opts.TimeFormat = time.RFC3339
 	}
+	if opts.Level == nil {
+		opts.Level = slog.LevelInfo
+	}
 	return &TextHandler{
 		opts:   opts,
 		writer: w,
@@ -45,7 +48,7 @@ func NewTextHandler(w io.Writer, opts *TextHandlerOptions) *TextHandler {
 
 // Enabled reports whether the handler handles records at the given level
 func (h *TextHandler) Enabled(_ context.Context, level slog.Level) bool {
-	return level >= h.opts.Level
+	return level >= h.opts.Level.Level()
 }
 
 // Handle formats and writes the log record
@@ -8,12 +8,14 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 )
 
 // Logger wraps slog.Logger with additional functionality
 type Logger struct {
 	*slog.Logger
 	config  *Config
+	level   *slog.LevelVar
 	mu      sync.RWMutex
 	closers []io.Closer
 }
@@ -27,9 +29,22 @@ type Config struct {
 	MaxBackups int
 	MaxAge     int
 	Compress   bool
-	Format     string // "json" or "text"
+	Format     string // "text" (default), "json" or "ecs"
+	Rotate     string // Also rotate the file daily or weekly
+	DateNames  bool   // Name rotated files after their day
+
+	// Dedup collapses repeated records of a level ("warn", "error", ...)
+	// logged within its window into one, and a count of the copies
+	Dedup map[string]time.Duration
 }
 
+// Log formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+	FormatECS  = "ecs" // JSON with Elastic Common Schema field names
+)
+
 var (
 	defaultLogger *Logger
 	once          sync.Once
@@ -57,23 +72,14 @@ func New(config *Config) *Logger {
 		}
 	}
 
-	level := parseLevel(config.Level)
+	level := new(slog.LevelVar)
+	level.Set(parseLevel(config.Level))
 	handlers := []slog.Handler{}
 	var closers []io.Closer
 
 	// Console handler
 	if config.Console {
-		var consoleHandler slog.Handler
-		if config.Format == "json" {
-			consoleHandler = slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-				Level: level,
-			})
-		} else {
-			consoleHandler = NewTextHandler(os.Stdout, &TextHandlerOptions{
-				Level: level,
-			})
-		}
-		handlers = append(handlers, consoleHandler)
+		handlers = append(handlers, newHandler(os.Stdout, config.Format, level))
 	}
 
 	// File handler
@@ -83,20 +89,12 @@ func New(config *Config) *Logger {
 			MaxBackups: config.MaxBackups,
 			MaxAge:     config.MaxAge,
 			Compress:   config.Compress,
+			Schedule:   config.Rotate,
+			DateNames:  config.DateNames,
 		})
 		if err == nil {
 			closers = append(closers, fileWriter)
-			var fileHandler slog.Handler
-			if config.Format == "json" {
-				fileHandler = slog.NewJSONHandler(fileWriter, &slog.HandlerOptions{
-					Level: level,
-				})
-			} else {
-				fileHandler = NewTextHandler(fileWriter, &TextHandlerOptions{
-					Level: level,
-				})
-			}
-			handlers = append(handlers, fileHandler)
+			handlers = append(handlers, newHandler(fileWriter, config.Format, level))
 		}
 	}
 
@@ -114,13 +112,41 @@ func New(config *Config) *Logger {
 		handler = NewMultiHandler(handlers...)
 	}
 
+	if len(config.Dedup) > 0 {
+		windows := make(map[slog.Level]time.Duration, len(config.Dedup))
+		for name, window := range config.Dedup {
+			windows[parseLevel(name)] = window
+		}
+		dedup := NewDedupHandler(handler, windows)
+		handler = dedup
+		// Closed first, so the counts are written before the files close
+		closers = append([]io.Closer{dedup}, closers...)
+	}
+
 	return &Logger{
 		Logger:  slog.New(handler),
 		config:  config,
+		level:   level,
 		closers: closers,
 	}
 }
 
+// newHandler returns the handler writing records to w in format
+func newHandler(w io.Writer, format string, level slog.Leveler) slog.Handler {
+	switch format {
+	case FormatJSON:
+		return slog.NewJSONHandler(w, &slog.HandlerOptions{
+			Level: level,
+		})
+	case FormatECS:
+		return NewECSHandler(w, level)
+	default:
+		return NewTextHandler(w, &TextHandlerOptions{
+			Level: level,
+		})
+	}
+}
+
 // parseLevel parses a string log level to slog.Level
 func parseLevel(level string) slog.Level {
 	switch strings.ToLower(level) {
@@ -142,6 +168,7 @@ func (l *Logger) WithContext(ctx context.Context) *Logger {
 	return &Logger{
 		Logger:  l.With("trace_id", GetTraceID(ctx)),
 		config:  l.config,
+		level:   l.level,
 		closers: l.closers,
 	}
 }
@@ -155,6 +182,7 @@ func (l *Logger) WithFields(fields map[string]interface{}) *Logger {
 	return &Logger{
 		Logger:  l.With(args...),
 		config:  l.config,
+		level:   l.level,
 		closers: l.closers,
 	}
 }
@@ -167,6 +195,7 @@ func (l *Logger) WithError(err error) *Logger {
 	return &Logger{
 		Logger:  l.With("error", err.Error()),
 		config:  l.config,
+		level:   l.level,
 		closers: l.closers,
 	}
 }
@@ -211,13 +240,14 @@ func (l *Logger) Errorf(format string, args ...any) {
 	l.Logger.Error(fmt.Sprintf(format, args...))
 }
 
-// SetLevel dynamically changes the log level
+// SetLevel changes the log level of all handlers in place
 func (l *Logger) SetLevel(level string) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	l.config.Level = level
-	// Note: This would require recreating the logger to take effect
-	// For simplicity, we'll document this as requiring a restart
+	if l.level != nil {
+		l.level.Set(parseLevel(level))
+	}
 }
 
 // GetConfig returns the current logger configuration
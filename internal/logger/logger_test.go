@@ -278,6 +278,28 @@ func TestGetConfig(t *testing.T) {
 	}
 }
 
+func TestSetLevel(t *testing.T) {
+	logger := New(&Config{Level: "info", Console: true, Format: "text"})
+	ctx := context.Background()
+
+	if logger.Enabled(ctx, slog.LevelDebug) {
+		t.Fatal("Enabled(debug) = true before SetLevel, want false")
+	}
+
+	logger.SetLevel("debug")
+	if !logger.Enabled(ctx, slog.LevelDebug) {
+		t.Error("Enabled(debug) = false after SetLevel(debug), want true")
+	}
+	if got := logger.GetConfig().Level; got != "debug" {
+		t.Errorf("GetConfig().Level = %s, want debug", got)
+	}
+
+	logger.SetLevel("error")
+	if logger.Enabled(ctx, slog.LevelWarn) {
+		t.Error("Enabled(warn) = true after SetLevel(error), want false")
+	}
+}
+
 type testError struct {
 	msg string
 }
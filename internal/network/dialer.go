@@ -0,0 +1,65 @@
+package network
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+)
+
+// defaultDNSPort is the port of a DNS server given without one
+const defaultDNSPort = "53"
+
+// Dialer makes the TCP connections to the rcode server. Host names found in
+// Overrides connect to the IP address they map to, like entries in
+// /etc/hosts; other names are looked up with DNSServer when it is set, so
+// names such as "ws01tail" resolve even where the system resolver does not
+// know them.
+type Dialer struct {
+	Timeout   time.Duration
+	KeepAlive time.Duration
+	Overrides map[string]string // Host name -> IP address
+	DNSServer string            // ip or ip:port of the DNS server; empty uses the system resolver
+}
+
+// Lookup returns the IP address host is overridden with, if any. Names are
+// matched without regard to case or a trailing dot.
+func (d *Dialer) Lookup(host string) (string, bool) {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	for name, ip := range d.Overrides {
+		if strings.TrimSuffix(strings.ToLower(name), ".") == host {
+			return ip, true
+		}
+	}
+	return "", false
+}
+
+// DialContext connects to addr on the named network, using the override or
+// the DNS server for its host name
+func (d *Dialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: d.Timeout, KeepAlive: d.KeepAlive}
+	if host, port, err := net.SplitHostPort(addr); err == nil {
+		if ip, ok := d.Lookup(host); ok {
+			return dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+		}
+	}
+	if d.DNSServer != "" {
+		dialer.Resolver = d.resolver()
+	}
+	return dialer.DialContext(ctx, network, addr)
+}
+
+// resolver returns a resolver that sends every query to DNSServer
+func (d *Dialer) resolver() *net.Resolver {
+	server := d.DNSServer
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, defaultDNSPort)
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			dialer := &net.Dialer{Timeout: d.Timeout}
+			return dialer.DialContext(ctx, network, server)
+		},
+	}
+}
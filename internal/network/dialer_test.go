@@ -0,0 +1,72 @@
+package network
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestDialerOverrides(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+
+	d := &Dialer{Timeout: time.Second, Overrides: map[string]string{"WS01tail": "127.0.0.1"}}
+	if ip, ok := d.Lookup("ws01tail."); !ok || ip != "127.0.0.1" {
+		t.Errorf("Lookup(ws01tail.) = %q, %v, want 127.0.0.1", ip, ok)
+	}
+	if _, ok := d.Lookup("other"); ok {
+		t.Error("Lookup(other) found an override")
+	}
+
+	conn, err := d.DialContext(context.Background(), "tcp", net.JoinHostPort("ws01tail", port))
+	if err != nil {
+		t.Fatalf("DialContext(ws01tail) error = %v", err)
+	}
+	if got := conn.RemoteAddr().String(); got != ln.Addr().String() {
+		t.Errorf("connected to %s, want %s", got, ln.Addr())
+	}
+	_ = conn.Close()
+}
+
+func TestDialerDNSServer(t *testing.T) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	queried := make(chan struct{}, 1)
+	go func() {
+		buf := make([]byte, 512)
+		if _, _, err := server.ReadFrom(buf); err == nil {
+			queried <- struct{}{}
+		}
+	}()
+
+	// The fake server never answers; the lookup only has to reach it
+	d := &Dialer{Timeout: 200 * time.Millisecond, DNSServer: server.LocalAddr().String()}
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	if conn, err := d.DialContext(ctx, "tcp", "ws01tail.invalid-rcode-test:3339"); err == nil {
+		_ = conn.Close()
+		t.Fatal("DialContext() succeeded without an answer from the DNS server")
+	}
+	select {
+	case <-queried:
+	case <-time.After(time.Second):
+		t.Error("the configured DNS server was not queried")
+	}
+}
@@ -2,7 +2,11 @@
 // including host resolution with fallback logic.
 package network
 
-import "github.com/foxytanuki/rcode/internal/config"
+import (
+	"sort"
+
+	"github.com/foxytanuki/rcode/internal/config"
+)
 
 // HostType represents the type of host being resolved.
 type HostType int
@@ -28,13 +32,17 @@ func (t HostType) String() string {
 // ResolvedHosts contains the resolved hosts for both server and SSH connections.
 type ResolvedHosts struct {
 	// Server is the host to connect to the rcode server (e.g., "192.168.1.100:3339").
-	Server string
+	Server string `json:"server" yaml:"server"`
 	// ServerFallback is the fallback server host (e.g., Tailscale IP).
-	ServerFallback string
+	ServerFallback string `json:"server_fallback,omitempty" yaml:"server_fallback,omitempty"`
 	// SSH is the host used in editor SSH connection (e.g., "dev-server", "ws01tail").
-	SSH string
+	SSH string `json:"ssh" yaml:"ssh"`
 	// Source indicates which HostSource provided the SSH host.
-	Source string
+	Source string `json:"source" yaml:"source"`
+	// ServerSource and ServerFallbackSource name the HostSources that
+	// provided Server and ServerFallback.
+	ServerSource         string `json:"server_source,omitempty" yaml:"server_source,omitempty"`
+	ServerFallbackSource string `json:"server_fallback_source,omitempty" yaml:"server_fallback_source,omitempty"`
 }
 
 // HostSource provides host values for resolution.
@@ -56,18 +64,15 @@ type Resolver struct {
 }
 
 // NewResolver creates a new Resolver with the given sources.
-// Sources are automatically sorted by priority.
+// Sources are automatically sorted by priority; sources with the same
+// priority keep the order they were given in.
 func NewResolver(sources ...HostSource) *Resolver {
 	// Sort sources by priority (lower = higher priority)
 	sorted := make([]HostSource, len(sources))
 	copy(sorted, sources)
-	for i := 0; i < len(sorted)-1; i++ {
-		for j := i + 1; j < len(sorted); j++ {
-			if sorted[j].Priority() < sorted[i].Priority() {
-				sorted[i], sorted[j] = sorted[j], sorted[i]
-			}
-		}
-	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority() < sorted[j].Priority()
+	})
 	return &Resolver{sources: sorted}
 }
 
@@ -80,8 +85,10 @@ func (r *Resolver) Resolve() ResolvedHosts {
 		if host := src.Resolve(ServerHost); host != "" {
 			if result.Server == "" {
 				result.Server = host
+				result.ServerSource = src.Name()
 			} else if result.ServerFallback == "" && host != result.Server {
 				result.ServerFallback = host
+				result.ServerFallbackSource = src.Name()
 			}
 		}
 	}
@@ -98,6 +105,29 @@ func (r *Resolver) Resolve() ResolvedHosts {
 	return result
 }
 
+// SourceResult records what a single source returned during resolution.
+type SourceResult struct {
+	Name     string `json:"name" yaml:"name"`
+	Priority int    `json:"priority" yaml:"priority"`
+	Server   string `json:"server,omitempty" yaml:"server,omitempty"`
+	SSH      string `json:"ssh,omitempty" yaml:"ssh,omitempty"`
+}
+
+// Explain returns the value each source provides, in priority order.
+// It shows how Resolve arrived at its result.
+func (r *Resolver) Explain() []SourceResult {
+	results := make([]SourceResult, 0, len(r.sources))
+	for _, src := range r.sources {
+		results = append(results, SourceResult{
+			Name:     src.Name(),
+			Priority: src.Priority(),
+			Server:   src.Resolve(ServerHost),
+			SSH:      src.Resolve(SSHHost),
+		})
+	}
+	return results
+}
+
 // ResolveSSH resolves only the SSH host.
 func (r *Resolver) ResolveSSH() (host, source string) {
 	for _, src := range r.sources {
@@ -152,21 +182,42 @@ func NewResolverFromConfig(cfg *config.ClientConfig, hostFlag, sshClientIP strin
 		})
 	}
 
-	// 5. Tailscale auto-detection
+	// 5. Alias from ~/.ssh/config
+	if cfg.Hosts.SSH.AutoDetect.SSHConfig {
+		sources = append(sources, &SSHConfigSource{ServerIP: ExtractSSHServerIP()})
+	}
+
+	// 6. Tailscale auto-detection
 	if cfg.Hosts.SSH.AutoDetect.Tailscale {
 		sources = append(sources, &TailscaleSource{
 			Enabled:     true,
 			HostPattern: cfg.Hosts.SSH.AutoDetect.TailscalePattern,
 			ClientIP:    sshClientIP,
+			Socket:      cfg.Hosts.SSH.AutoDetect.TailscaleSocket,
 		})
 	}
 
-	// 6. SSH_CONNECTION environment
+	// 7. Other VPNs, in configured order
+	for _, vpn := range cfg.Hosts.SSH.AutoDetect.VPN {
+		sources = append(sources, &VPNSource{
+			Type:      string(vpn.Type),
+			Interface: vpn.Interface,
+			Pattern:   vpn.Pattern,
+			ClientIP:  sshClientIP,
+		})
+	}
+
+	// 8. Reverse DNS / mDNS name of the SSH client
+	if cfg.Hosts.SSH.AutoDetect.ReverseDNS {
+		sources = append(sources, &ReverseDNSSource{ClientIP: sshClientIP})
+	}
+
+	// 9. SSH_CONNECTION environment
 	sources = append(sources, &SSHConnectionSource{
 		ClientIP: sshClientIP,
 	})
 
-	// 7. Hostname fallback (lowest priority)
+	// 10. Hostname fallback (lowest priority)
 	sources = append(sources, &HostnameSource{})
 
 	return NewResolver(sources...)
@@ -1,7 +1,12 @@
 package network
 
 import (
+	"context"
+	"errors"
+	"os"
 	"testing"
+
+	"github.com/foxytanuki/rcode/internal/config"
 )
 
 func TestHostTypeString(t *testing.T) {
@@ -148,6 +153,30 @@ func TestResolver_ResolveServer(t *testing.T) {
 	if fallback != "fallback-host" {
 		t.Errorf("ResolveServer() fallback = %q, want %q", fallback, "fallback-host")
 	}
+
+	result := resolver.Resolve()
+	if result.ServerSource != "config" || result.ServerFallbackSource != "config-fallback" {
+		t.Errorf("Resolve() server sources = %q, %q, want config, config-fallback",
+			result.ServerSource, result.ServerFallbackSource)
+	}
+}
+
+func TestResolver_Explain(t *testing.T) {
+	resolver := NewResolver(
+		&SSHConnectionSource{ClientIP: "192.168.1.50"},
+		&ConfigSource{ServerPrimary: "primary-host", SSHHost: "config-ssh"},
+	)
+
+	results := resolver.Explain()
+	if len(results) != 2 {
+		t.Fatalf("Explain() returned %d results, want 2", len(results))
+	}
+	if results[0].Name != "config" || results[0].Server != "primary-host" || results[0].SSH != "config-ssh" {
+		t.Errorf("Explain()[0] = %+v, want config source first", results[0])
+	}
+	if results[1].Name != "ssh-connection" || results[1].Server != "" || results[1].SSH != "192.168.1.50" {
+		t.Errorf("Explain()[1] = %+v, want ssh-connection source", results[1])
+	}
 }
 
 func TestCommandLineSource(t *testing.T) {
@@ -168,6 +197,15 @@ func TestCommandLineSource(t *testing.T) {
 		t.Errorf("Resolve(SSHHost) = %q, want %q", got, "test-host")
 	}
 
+	// A port is for the server only
+	src.Host = "test-host:4000"
+	if got := src.Resolve(ServerHost); got != "test-host:4000" {
+		t.Errorf("Resolve(ServerHost) = %q, want %q", got, "test-host:4000")
+	}
+	if got := src.Resolve(SSHHost); got != "test-host" {
+		t.Errorf("Resolve(SSHHost) = %q, want %q", got, "test-host")
+	}
+
 	// Empty host should return empty
 	src.Host = ""
 	if got := src.Resolve(ServerHost); got != "" {
@@ -175,6 +213,22 @@ func TestCommandLineSource(t *testing.T) {
 	}
 }
 
+func TestStripPort(t *testing.T) {
+	tests := map[string]string{
+		"devbox":            "devbox",
+		"devbox:3339":       "devbox",
+		"192.168.1.10:4000": "192.168.1.10",
+		"fd7a::1":           "fd7a::1",
+		"[fd7a::1]":         "fd7a::1",
+		"[fd7a::1]:3339":    "fd7a::1",
+	}
+	for addr, want := range tests {
+		if got := StripPort(addr); got != want {
+			t.Errorf("StripPort(%q) = %q, want %q", addr, got, want)
+		}
+	}
+}
+
 func TestEnvSource(t *testing.T) {
 	src := &EnvSource{
 		ServerHostEnv: "TEST_SERVER_HOST",
@@ -365,3 +419,158 @@ func TestExtractSSHClientIP(t *testing.T) {
 		t.Errorf("ExtractSSHClientIP() with no env = %q, want empty", got)
 	}
 }
+
+func TestReverseDNSSource(t *testing.T) {
+	var mdnsCalls int
+	src := &ReverseDNSSource{
+		ClientIP: "192.168.1.50",
+		lookupAddr: func(_ context.Context, addr string) ([]string, error) {
+			if addr != "192.168.1.50" {
+				t.Errorf("lookupAddr(%q), want the client IP", addr)
+			}
+			return []string{"localhost.", "devbox.lan."}, nil
+		},
+		lookupMDNS: func(context.Context, string) (string, error) {
+			mdnsCalls++
+			return "devbox.local", nil
+		},
+	}
+
+	if src.Name() != "reverse-dns" || src.Priority() != PriorityReverseDNS {
+		t.Errorf("Name() = %q, Priority() = %d", src.Name(), src.Priority())
+	}
+	if got := src.Resolve(SSHHost); got != "devbox.lan" {
+		t.Errorf("Resolve(SSHHost) = %q, want %q", got, "devbox.lan")
+	}
+	if got := src.Resolve(ServerHost); got != "" {
+		t.Errorf("Resolve(ServerHost) = %q, want empty", got)
+	}
+	if mdnsCalls != 0 {
+		t.Errorf("mDNS lookups = %d after a DNS answer, want 0", mdnsCalls)
+	}
+
+	// Without a PTR record the mDNS name is used
+	src.lookupAddr = func(context.Context, string) ([]string, error) {
+		return nil, errors.New("no PTR record")
+	}
+	if got := src.Resolve(SSHHost); got != "devbox.local" {
+		t.Errorf("Resolve(SSHHost) via mDNS = %q, want %q", got, "devbox.local")
+	}
+
+	src.lookupMDNS = func(context.Context, string) (string, error) {
+		return "", errors.New("avahi-resolve not found")
+	}
+	if got := src.Resolve(SSHHost); got != "" {
+		t.Errorf("Resolve(SSHHost) without names = %q, want empty", got)
+	}
+
+	src.ClientIP = ""
+	if got := src.Resolve(SSHHost); got != "" {
+		t.Errorf("Resolve(SSHHost) without client IP = %q, want empty", got)
+	}
+}
+
+func TestReverseDNSSource_PriorityOrder(t *testing.T) {
+	if !(PriorityTailscale < PriorityReverseDNS && PriorityReverseDNS < PrioritySSHEnv) {
+		t.Errorf("PriorityReverseDNS = %d, want between Tailscale (%d) and SSH_CONNECTION (%d)",
+			PriorityReverseDNS, PriorityTailscale, PrioritySSHEnv)
+	}
+}
+
+func TestNewResolverFromConfig_Priority(t *testing.T) {
+	hostname, _ := os.Hostname()
+	baseConfig := func() *config.ClientConfig {
+		return &config.ClientConfig{
+			Hosts: config.HostsConfig{
+				Server: config.ServerHostConfig{Primary: "192.168.1.100", Fallback: "100.64.0.1"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		hostFlag     string
+		env          map[string]string
+		configure    func(*config.ClientConfig)
+		clientIP     string
+		wantServer   string
+		wantFallback string
+		wantSSH      string
+		wantSource   string
+	}{
+		{
+			name:         "command line wins over everything",
+			hostFlag:     "cli-host:4000",
+			env:          map[string]string{"RCODE_SERVER_HOST": "env-server", "RCODE_SSH_HOST": "env-ssh"},
+			configure:    func(c *config.ClientConfig) { c.Hosts.SSH.Host = "config-ssh" },
+			clientIP:     "192.168.1.50",
+			wantServer:   "cli-host:4000",
+			wantFallback: "env-server",
+			wantSSH:      "cli-host",
+			wantSource:   "command-line",
+		},
+		{
+			name:         "environment wins over config",
+			env:          map[string]string{"RCODE_SERVER_HOST": "env-server", "RCODE_SSH_HOST": "env-ssh"},
+			configure:    func(c *config.ClientConfig) { c.Hosts.SSH.Host = "config-ssh" },
+			clientIP:     "192.168.1.50",
+			wantServer:   "env-server",
+			wantFallback: "192.168.1.100",
+			wantSSH:      "env-ssh",
+			wantSource:   "environment",
+		},
+		{
+			name:         "legacy RCODE_HOST only sets the server",
+			env:          map[string]string{"RCODE_HOST": "legacy-server"},
+			clientIP:     "192.168.1.50",
+			wantServer:   "legacy-server",
+			wantFallback: "192.168.1.100",
+			wantSSH:      "192.168.1.50",
+			wantSource:   "ssh-connection",
+		},
+		{
+			name:         "config ssh host wins over SSH_CONNECTION",
+			configure:    func(c *config.ClientConfig) { c.Hosts.SSH.Host = "config-ssh" },
+			clientIP:     "192.168.1.50",
+			wantServer:   "192.168.1.100",
+			wantFallback: "100.64.0.1",
+			wantSSH:      "config-ssh",
+			wantSource:   "config",
+		},
+		{
+			name:         "SSH_CONNECTION client IP",
+			clientIP:     "192.168.1.50",
+			wantServer:   "192.168.1.100",
+			wantFallback: "100.64.0.1",
+			wantSSH:      "192.168.1.50",
+			wantSource:   "ssh-connection",
+		},
+		{
+			name:         "hostname when not in an SSH session",
+			wantServer:   "192.168.1.100",
+			wantFallback: "100.64.0.1",
+			wantSSH:      hostname,
+			wantSource:   "hostname",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"RCODE_SERVER_HOST", "RCODE_SSH_HOST", "RCODE_HOST"} {
+				t.Setenv(name, tt.env[name])
+			}
+			cfg := baseConfig()
+			if tt.configure != nil {
+				tt.configure(cfg)
+			}
+
+			got := NewResolverFromConfig(cfg, tt.hostFlag, tt.clientIP).Resolve()
+			if got.Server != tt.wantServer || got.ServerFallback != tt.wantFallback {
+				t.Errorf("server = %q, fallback = %q, want %q, %q", got.Server, got.ServerFallback, tt.wantServer, tt.wantFallback)
+			}
+			if got.SSH != tt.wantSSH || got.Source != tt.wantSource {
+				t.Errorf("ssh = %q (source %s), want %q (source %s)", got.SSH, got.Source, tt.wantSSH, tt.wantSource)
+			}
+		})
+	}
+}
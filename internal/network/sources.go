@@ -2,7 +2,7 @@ package network
 
 import (
 	"context"
-	"encoding/json"
+	"fmt"
 	"net"
 	"os"
 	"os/exec"
@@ -15,7 +15,10 @@ const (
 	PriorityCommandLine = 10  // Highest priority - explicit user input
 	PriorityEnvVar      = 20  // Environment variable overrides
 	PriorityConfig      = 30  // Configuration file values
+	PrioritySSHConfig   = 35  // Alias from ~/.ssh/config
 	PriorityTailscale   = 40  // Auto-detected Tailscale
+	PriorityVPN         = 42  // WireGuard, ZeroTier and Netbird detection
+	PriorityReverseDNS  = 45  // Reverse DNS / mDNS name of the SSH client IP
 	PrioritySSHEnv      = 50  // SSH_CONNECTION environment
 	PriorityHostname    = 100 // Fallback to hostname
 )
@@ -32,11 +35,15 @@ func (s *CommandLineSource) Name() string { return "command-line" }
 func (s *CommandLineSource) Priority() int { return PriorityCommandLine }
 
 // Resolve returns the host if set via command-line.
-func (s *CommandLineSource) Resolve(_ HostType) string {
+func (s *CommandLineSource) Resolve(hostType HostType) string {
 	if s.Host == "" {
 		return ""
 	}
-	// Command-line host applies to both server and SSH when specified
+	// Command-line host applies to both server and SSH when specified; a
+	// port (host:port) belongs to rcode-server, not to SSH
+	if hostType == SSHHost {
+		return StripPort(s.Host)
+	}
 	return s.Host
 }
 
@@ -130,6 +137,12 @@ type TailscaleSource struct {
 	HostPattern string
 	// ClientIP is the SSH client IP for detecting Tailscale connection.
 	ClientIP string
+	// Socket is the tailscaled LocalAPI socket. When set, status is read
+	// from it instead of the tailscale CLI, which remains the fallback.
+	Socket string
+
+	// status is fetchTailscaleStatus, replaced in tests
+	status func(socket string) (*tailscaleStatus, error)
 }
 
 // Name returns the source name.
@@ -162,15 +175,164 @@ func (s *TailscaleSource) Resolve(hostType HostType) string {
 		return tailscaleIP
 	case SSHHost:
 		// Generate Tailscale hostname for SSH
-		hostname := getTailscaleHostname()
-		if hostname == "" {
+		fetch := s.status
+		if fetch == nil {
+			fetch = fetchTailscaleStatus
+		}
+		status, err := fetch(s.Socket)
+		if err != nil {
 			return ""
 		}
-		return applyTailscalePattern(hostname, s.HostPattern)
+		return tailscaleSSHHost(status, s.ClientIP, s.HostPattern)
 	}
 	return ""
 }
 
+// tailscaleSSHHost names the SSH host. When clientIP belongs to a tailnet
+// peer, that is the machine the user connected from and its MagicDNS name is
+// used as is. Otherwise the pattern is applied to this node: a pattern
+// containing {magicdns} is given the full MagicDNS name, other patterns the
+// node's host name.
+func tailscaleSSHHost(status *tailscaleStatus, clientIP, pattern string) string {
+	if peer := status.peer(clientIP); peer != nil {
+		if name := peer.magicDNSName(); name != "" {
+			return name
+		}
+		if peer.HostName != "" {
+			return peer.HostName
+		}
+	}
+
+	if strings.Contains(pattern, "{magicdns}") {
+		if name := status.Self.magicDNSName(); name != "" {
+			return strings.ReplaceAll(pattern, "{magicdns}", name)
+		}
+		return ""
+	}
+	hostname := status.Self.HostName
+	if hostname == "" {
+		hostname = status.Self.DNSName
+	}
+	if hostname == "" {
+		return ""
+	}
+	return applyTailscalePattern(hostname, pattern)
+}
+
+// SSHConfigSource suggests this machine's alias from an OpenSSH config, so
+// the editor connects back through the Host entry (with its User, Port and
+// IdentityFile) the user already has. The config is read on this machine,
+// which helps when SSH configs are shared between machines.
+type SSHConfigSource struct {
+	// Path is the SSH config file; empty means ~/.ssh/config.
+	Path string
+	// ServerIP is this machine's address as the SSH client saw it (from SSH_CONNECTION).
+	ServerIP string
+}
+
+// Name returns the source name.
+func (s *SSHConfigSource) Name() string { return "ssh-config" }
+
+// Priority returns the source priority.
+func (s *SSHConfigSource) Priority() int { return PrioritySSHConfig }
+
+// Resolve returns the alias of the Host entry whose HostName is this
+// machine's SSH address or host name.
+func (s *SSHConfigSource) Resolve(hostType HostType) string {
+	if hostType != SSHHost {
+		return ""
+	}
+
+	path := s.Path
+	if path == "" {
+		path = defaultSSHConfigPath()
+	}
+	names := []string{s.ServerIP}
+	if hostname, err := os.Hostname(); err == nil {
+		names = append(names, hostname)
+		if short, _, found := strings.Cut(hostname, "."); found {
+			names = append(names, short)
+		}
+	}
+	return findSSHAlias(readSSHConfig(path), names...)
+}
+
+// reverseDNSTimeout bounds each reverse lookup so a slow resolver does not
+// delay every command
+const reverseDNSTimeout = time.Second
+
+// ReverseDNSSource turns the SSH client IP into a stable host name with a
+// reverse DNS lookup, falling back to avahi-resolve for mDNS (.local) names.
+type ReverseDNSSource struct {
+	// ClientIP is extracted from SSH_CONNECTION.
+	ClientIP string
+
+	// lookupAddr and lookupMDNS are replaced in tests
+	lookupAddr func(ctx context.Context, addr string) ([]string, error)
+	lookupMDNS func(ctx context.Context, addr string) (string, error)
+}
+
+// Name returns the source name.
+func (s *ReverseDNSSource) Name() string { return "reverse-dns" }
+
+// Priority returns the source priority.
+func (s *ReverseDNSSource) Priority() int { return PriorityReverseDNS }
+
+// Resolve returns the host name the SSH client IP resolves to.
+func (s *ReverseDNSSource) Resolve(hostType HostType) string {
+	if hostType != SSHHost || net.ParseIP(s.ClientIP) == nil {
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), reverseDNSTimeout)
+	defer cancel()
+
+	lookupAddr := s.lookupAddr
+	if lookupAddr == nil {
+		lookupAddr = net.DefaultResolver.LookupAddr
+	}
+	if names, err := lookupAddr(ctx, s.ClientIP); err == nil {
+		for _, name := range names {
+			if name = usableHostName(name); name != "" {
+				return name
+			}
+		}
+	}
+
+	lookupMDNS := s.lookupMDNS
+	if lookupMDNS == nil {
+		lookupMDNS = avahiResolveAddress
+	}
+	if name, err := lookupMDNS(ctx, s.ClientIP); err == nil {
+		return usableHostName(name)
+	}
+	return ""
+}
+
+// usableHostName trims the trailing dot of a DNS name and rejects names that
+// do not identify a machine
+func usableHostName(name string) string {
+	name = strings.TrimSuffix(strings.TrimSpace(name), ".")
+	if name == "" || strings.EqualFold(name, "localhost") || net.ParseIP(name) != nil {
+		return ""
+	}
+	return name
+}
+
+// avahiResolveAddress asks avahi for the mDNS name of addr
+func avahiResolveAddress(ctx context.Context, addr string) (string, error) {
+	output, err := exec.CommandContext(ctx, "avahi-resolve", "--address", addr).Output() // #nosec G204 -- addr is a parsed IP
+	if err != nil {
+		return "", err
+	}
+	// Output: "<address>\t<name>"
+	fields := strings.Fields(string(output))
+	if len(fields) < 2 {
+		return "", fmt.Errorf("unexpected avahi-resolve output: %q", output)
+	}
+	return fields[1], nil
+}
+
 // SSHConnectionSource provides hosts from SSH_CONNECTION environment.
 type SSHConnectionSource struct {
 	// ClientIP is extracted from SSH_CONNECTION.
@@ -211,6 +373,18 @@ func (s *HostnameSource) Resolve(hostType HostType) string {
 	return ""
 }
 
+// StripPort returns the host part of a host:port address, unbracketing IPv6
+// literals. Addresses without a port are returned unchanged.
+func StripPort(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	if strings.HasPrefix(addr, "[") && strings.HasSuffix(addr, "]") {
+		return addr[1 : len(addr)-1]
+	}
+	return addr
+}
+
 // Helper functions for Tailscale detection
 
 // isTailscaleIP checks if an IP is in the Tailscale range (100.64.0.0/10).
@@ -251,38 +425,6 @@ func getTailscaleInterfaceIP() string {
 	return ""
 }
 
-// tailscaleStatus represents the minimal Tailscale status structure.
-type tailscaleStatus struct {
-	Self struct {
-		HostName string `json:"HostName"`
-		DNSName  string `json:"DNSName"`
-	} `json:"Self"`
-}
-
-// getTailscaleHostname retrieves the hostname from Tailscale.
-func getTailscaleHostname() string {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	cmd := exec.CommandContext(ctx, "tailscale", "status", "--json")
-	output, err := cmd.Output()
-	if err != nil {
-		return ""
-	}
-
-	var status tailscaleStatus
-	if err := json.Unmarshal(output, &status); err != nil {
-		return ""
-	}
-
-	if status.Self.HostName != "" {
-		return status.Self.HostName
-	}
-	if status.Self.DNSName != "" {
-		return status.Self.DNSName
-	}
-	return ""
-}
-
 // applyTailscalePattern applies the hostname pattern for Tailscale.
 func applyTailscalePattern(hostname, pattern string) string {
 	// Strip common Tailscale suffixes
@@ -300,6 +442,15 @@ func applyTailscalePattern(hostname, pattern string) string {
 	return result
 }
 
+// ExtractSSHServerIP extracts this machine's address from SSH_CONNECTION.
+func ExtractSSHServerIP() string {
+	// SSH_CONNECTION: client_ip client_port server_ip server_port
+	if parts := strings.Fields(os.Getenv("SSH_CONNECTION")); len(parts) >= 3 {
+		return parts[2]
+	}
+	return ""
+}
+
 // ExtractSSHClientIP extracts the client IP from SSH environment variables.
 func ExtractSSHClientIP() string {
 	// Check SSH_CONNECTION first: client_ip client_port server_ip server_port
@@ -2,82 +2,124 @@ package network
 
 import (
 	"bufio"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 )
 
-// ResolveSSHHostAlias returns a matching SSH config alias for the given host.
-// If no exact HostName match is found, the original host is returned.
-func ResolveSSHHostAlias(host string) string {
-	if host == "" {
-		return ""
+// sshHostEntry is one Host block of an OpenSSH client configuration
+type sshHostEntry struct {
+	Aliases  []string // patterns after the Host keyword
+	HostName string   // HostName value, empty when not set
+}
+
+// alias returns the first alias that is not a pattern, or ""
+func (e sshHostEntry) alias() string {
+	for _, alias := range e.Aliases {
+		if alias != "" && !hasSSHWildcard(alias) {
+			return alias
+		}
 	}
+	return ""
+}
 
+// defaultSSHConfigPath returns the current user's ~/.ssh/config
+func defaultSSHConfigPath() string {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return host
+		return ""
 	}
+	return filepath.Join(homeDir, ".ssh", "config")
+}
 
-	configPath := filepath.Join(homeDir, ".ssh", "config")
-	file, err := os.Open(configPath) // #nosec G304 -- configPath is derived from the current user's home directory
+// readSSHConfig parses the Host blocks of the SSH config at path. A missing
+// or unreadable file has no entries.
+func readSSHConfig(path string) []sshHostEntry {
+	if path == "" {
+		return nil
+	}
+	file, err := os.Open(path) // #nosec G304 -- path is the user's SSH config
 	if err != nil {
-		return host
+		return nil
 	}
 	defer func() {
 		_ = file.Close()
 	}()
+	return parseSSHConfig(file)
+}
 
-	scanner := bufio.NewScanner(file)
-	var aliases []string
-	var hostName string
-
-	flush := func() string {
-		if hostName != host {
-			return ""
-		}
-		for _, alias := range aliases {
-			if alias == "" || hasSSHWildcard(alias) {
-				continue
-			}
-			return alias
-		}
-		return ""
-	}
+// parseSSHConfig parses Host blocks with their HostName. Other keywords,
+// Match blocks and Include directives are ignored.
+func parseSSHConfig(r io.Reader) []sshHostEntry {
+	var entries []sshHostEntry
+	var current *sshHostEntry
 
+	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
 
-		fields := strings.Fields(line)
+		// Both "Key value" and "Key=value" are valid
+		fields := strings.Fields(strings.Replace(line, "=", " ", 1))
 		if len(fields) < 2 {
 			continue
 		}
 
-		key := strings.ToLower(fields[0])
-		value := strings.Join(fields[1:], " ")
-
-		switch key {
+		switch strings.ToLower(fields[0]) {
 		case "host":
-			if alias := flush(); alias != "" {
-				return alias
-			}
-			aliases = fields[1:]
-			hostName = ""
+			entries = append(entries, sshHostEntry{Aliases: fields[1:]})
+			current = &entries[len(entries)-1]
+		case "match":
+			current = nil
 		case "hostname":
-			hostName = value
+			if current != nil {
+				current.HostName = strings.Join(fields[1:], " ")
+			}
 		}
 	}
+	return entries
+}
 
-	if alias := flush(); alias != "" {
-		return alias
+// ResolveSSHHostAlias returns a matching SSH config alias for the given host.
+// If no exact HostName match is found, the original host is returned.
+func ResolveSSHHostAlias(host string) string {
+	if host == "" {
+		return ""
+	}
+
+	for _, entry := range readSSHConfig(defaultSSHConfigPath()) {
+		if entry.HostName != host {
+			continue
+		}
+		if alias := entry.alias(); alias != "" {
+			return alias
+		}
 	}
 
 	return host
 }
 
+// findSSHAlias returns the alias of the first Host block whose HostName is
+// one of names (compared without regard to case), or ""
+func findSSHAlias(entries []sshHostEntry, names ...string) string {
+	for _, entry := range entries {
+		if entry.HostName == "" {
+			continue
+		}
+		for _, name := range names {
+			if name != "" && strings.EqualFold(entry.HostName, name) {
+				if alias := entry.alias(); alias != "" {
+					return alias
+				}
+			}
+		}
+	}
+	return ""
+}
+
 func hasSSHWildcard(pattern string) bool {
 	return strings.ContainsAny(pattern, "*?!")
 }
@@ -3,6 +3,7 @@ package network
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -53,3 +54,62 @@ func TestResolveSSHHostAlias(t *testing.T) {
 		})
 	}
 }
+
+func TestParseSSHConfig(t *testing.T) {
+	config := `# comment
+Host ws01 ws01.lan
+  HostName 192.168.100.20
+  User foxy
+
+Match host ws02
+  HostName ignored
+
+Host=laptop
+  HostName=laptop.local
+Host *
+  ServerAliveInterval 30
+`
+	entries := parseSSHConfig(strings.NewReader(config))
+
+	if len(entries) != 3 {
+		t.Fatalf("parseSSHConfig() returned %d entries, want 3: %+v", len(entries), entries)
+	}
+	if entries[0].alias() != "ws01" || entries[0].HostName != "192.168.100.20" {
+		t.Errorf("entries[0] = %+v, want ws01 -> 192.168.100.20", entries[0])
+	}
+	if entries[1].alias() != "laptop" || entries[1].HostName != "laptop.local" {
+		t.Errorf("entries[1] = %+v, want laptop -> laptop.local", entries[1])
+	}
+	if entries[2].alias() != "" || entries[2].HostName != "" {
+		t.Errorf("entries[2] = %+v, want a wildcard block without HostName", entries[2])
+	}
+}
+
+func TestSSHConfigSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	config := "Host *\n  HostName 10.0.0.5\nHost devbox\n  HostName 10.0.0.5\n"
+	if err := os.WriteFile(path, []byte(config), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	src := &SSHConfigSource{Path: path, ServerIP: "10.0.0.5"}
+	if src.Name() != "ssh-config" || src.Priority() != PrioritySSHConfig {
+		t.Errorf("Name() = %q, Priority() = %d", src.Name(), src.Priority())
+	}
+	if got := src.Resolve(SSHHost); got != "devbox" {
+		t.Errorf("Resolve(SSHHost) = %q, want %q", got, "devbox")
+	}
+	if got := src.Resolve(ServerHost); got != "" {
+		t.Errorf("Resolve(ServerHost) = %q, want empty", got)
+	}
+
+	src.ServerIP = "10.0.0.6"
+	if got := src.Resolve(SSHHost); got == "devbox" {
+		t.Errorf("Resolve(SSHHost) for another address = %q, want no alias", got)
+	}
+
+	src.Path = filepath.Join(t.TempDir(), "missing")
+	if got := src.Resolve(SSHHost); got != "" {
+		t.Errorf("Resolve(SSHHost) without a config = %q, want empty", got)
+	}
+}
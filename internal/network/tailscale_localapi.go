@@ -0,0 +1,120 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// DefaultTailscaleSocket is where tailscaled listens for LocalAPI requests on Linux.
+const DefaultTailscaleSocket = "/var/run/tailscale/tailscaled.sock"
+
+// tailscaleStatusTimeout bounds a status query over either transport
+const tailscaleStatusTimeout = 5 * time.Second
+
+// tailscaleStatus represents the minimal Tailscale status structure shared by
+// the LocalAPI and `tailscale status --json`.
+type tailscaleStatus struct {
+	Self           tailscalePeer            `json:"Self"`
+	Peer           map[string]tailscalePeer `json:"Peer"`
+	MagicDNSSuffix string                   `json:"MagicDNSSuffix"`
+}
+
+// tailscalePeer is one node of the tailnet
+type tailscalePeer struct {
+	HostName     string   `json:"HostName"`
+	DNSName      string   `json:"DNSName"`
+	TailscaleIPs []string `json:"TailscaleIPs"`
+}
+
+// magicDNSName returns the node's MagicDNS name without the trailing dot
+func (p tailscalePeer) magicDNSName() string {
+	return strings.TrimSuffix(p.DNSName, ".")
+}
+
+// peer returns the tailnet node that owns ip, or nil
+func (s *tailscaleStatus) peer(ip string) *tailscalePeer {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return nil
+	}
+	for _, p := range s.Peer {
+		for _, peerIP := range p.TailscaleIPs {
+			if addr.Equal(net.ParseIP(peerIP)) {
+				p := p
+				return &p
+			}
+		}
+	}
+	return nil
+}
+
+// fetchTailscaleStatus asks tailscaled for the tailnet status. The LocalAPI
+// socket is tried first when set; the tailscale CLI is the fallback.
+func fetchTailscaleStatus(socket string) (*tailscaleStatus, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), tailscaleStatusTimeout)
+	defer cancel()
+
+	if socket != "" {
+		if status, err := localAPIStatus(ctx, socket); err == nil {
+			return status, nil
+		}
+	}
+	return cliStatus(ctx)
+}
+
+// localAPIStatus queries the tailscaled LocalAPI over its unix socket
+func localAPIStatus(ctx context.Context, socket string) (*tailscaleStatus, error) {
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+	defer client.CloseIdleConnections()
+
+	// tailscaled only accepts LocalAPI requests addressed to this host
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://local-tailscaled.sock/localapi/v0/status", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Sec-Tailscale", "localapi")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tailscale LocalAPI returned status %d", resp.StatusCode)
+	}
+
+	var status tailscaleStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, fmt.Errorf("failed to decode tailscale status: %w", err)
+	}
+	return &status, nil
+}
+
+// cliStatus runs `tailscale status --json`
+func cliStatus(ctx context.Context) (*tailscaleStatus, error) {
+	output, err := exec.CommandContext(ctx, "tailscale", "status", "--json").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var status tailscaleStatus
+	if err := json.Unmarshal(output, &status); err != nil {
+		return nil, fmt.Errorf("failed to decode tailscale status: %w", err)
+	}
+	return &status, nil
+}
@@ -0,0 +1,136 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testTailscaleStatus = tailscaleStatus{
+	Self: tailscalePeer{
+		HostName:     "ws-01",
+		DNSName:      "ws-01.example.ts.net.",
+		TailscaleIPs: []string{"100.64.0.10"},
+	},
+	Peer: map[string]tailscalePeer{
+		"nodekey:1": {HostName: "laptop", DNSName: "laptop.example.ts.net.", TailscaleIPs: []string{"100.64.0.20", "fd7a:115c:a1e0::14"}},
+	},
+	MagicDNSSuffix: "example.ts.net",
+}
+
+// serveLocalAPI answers LocalAPI status requests on a unix socket
+func serveLocalAPI(t *testing.T, handler http.HandlerFunc) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "ts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	socket := filepath.Join(dir, "tailscaled.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	server := &http.Server{Handler: handler}
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(func() { _ = server.Close() })
+	return socket
+}
+
+func TestLocalAPIStatus(t *testing.T) {
+	socket := serveLocalAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/localapi/v0/status" || r.Host != "local-tailscaled.sock" {
+			t.Errorf("request to %s%s, want local-tailscaled.sock/localapi/v0/status", r.Host, r.URL.Path)
+		}
+		_ = json.NewEncoder(w).Encode(testTailscaleStatus)
+	})
+
+	status, err := localAPIStatus(context.Background(), socket)
+	if err != nil {
+		t.Fatalf("localAPIStatus() error = %v", err)
+	}
+	if status.Self.magicDNSName() != "ws-01.example.ts.net" {
+		t.Errorf("Self.magicDNSName() = %q", status.Self.magicDNSName())
+	}
+
+	failing := serveLocalAPI(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	if _, err := localAPIStatus(context.Background(), failing); err == nil {
+		t.Error("localAPIStatus() with a 403 response succeeded, want error")
+	}
+	if _, err := localAPIStatus(context.Background(), filepath.Join(t.TempDir(), "missing.sock")); err == nil {
+		t.Error("localAPIStatus() without a socket succeeded, want error")
+	}
+}
+
+func TestTailscaleStatusPeer(t *testing.T) {
+	status := testTailscaleStatus
+
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{"100.64.0.20", "laptop"},
+		{"fd7a:115c:a1e0::14", "laptop"},
+		{"100.64.0.99", ""},
+		{"not-an-ip", ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if p := status.peer(tt.ip); p != nil {
+			got = p.HostName
+		}
+		if got != tt.want {
+			t.Errorf("peer(%q) = %q, want %q", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestTailscaleSSHHost(t *testing.T) {
+	status := testTailscaleStatus
+
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"", "ws01tail"},
+		{"{hostname}", "ws-01"},
+		{"{magicdns}", "ws-01.example.ts.net"},
+	}
+	for _, tt := range tests {
+		if got := tailscaleSSHHost(&status, "", tt.pattern); got != tt.want {
+			t.Errorf("tailscaleSSHHost(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+
+	if got := tailscaleSSHHost(&tailscaleStatus{}, "", "{magicdns}"); got != "" {
+		t.Errorf("tailscaleSSHHost() without MagicDNS = %q, want empty", got)
+	}
+}
+
+func TestTailscaleSSHHost_ClientPeer(t *testing.T) {
+	status := testTailscaleStatus
+
+	// The peer the SSH connection came from wins over the pattern
+	if got := tailscaleSSHHost(&status, "100.64.0.20", "{hostname-}tail"); got != "laptop.example.ts.net" {
+		t.Errorf("tailscaleSSHHost(peer) = %q, want %q", got, "laptop.example.ts.net")
+	}
+
+	status.Peer = map[string]tailscalePeer{
+		"nodekey:2": {HostName: "pi", TailscaleIPs: []string{"100.64.0.30"}},
+	}
+	if got := tailscaleSSHHost(&status, "100.64.0.30", "{hostname-}tail"); got != "pi" {
+		t.Errorf("tailscaleSSHHost(peer without MagicDNS) = %q, want %q", got, "pi")
+	}
+
+	// Addresses outside the tailnet keep the pattern
+	if got := tailscaleSSHHost(&status, "100.64.0.99", "{hostname-}tail"); got != "ws01tail" {
+		t.Errorf("tailscaleSSHHost(unknown IP) = %q, want %q", got, "ws01tail")
+	}
+}
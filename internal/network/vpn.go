@@ -0,0 +1,158 @@
+package network
+
+import (
+	"net"
+	"os"
+	"strings"
+)
+
+// vpnKind describes how to recognise one VPN's interfaces and addresses
+type vpnKind struct {
+	interfacePrefixes []string // interface names start with one of these
+	network           string   // the VPN's usual address range, if it has one
+}
+
+// vpnKinds holds the VPNs VPNSource knows, keyed by config type
+var vpnKinds = map[string]vpnKind{
+	"wireguard": {interfacePrefixes: []string{"wg"}},
+	"zerotier":  {interfacePrefixes: []string{"zt"}, network: "10.147.0.0/16"},
+	"netbird":   {interfacePrefixes: []string{"wt", "nb"}, network: "100.64.0.0/10"},
+}
+
+// vpnInterface is a local network interface with its addresses
+type vpnInterface struct {
+	Name  string
+	Addrs []*net.IPNet
+}
+
+// VPNSource detects SSH connections made through a VPN other than Tailscale.
+// When the SSH client address lies in a local VPN interface's network (or
+// the VPN's usual range), the client address is a server host candidate and
+// Pattern names the SSH host.
+type VPNSource struct {
+	// Type is the VPN kind: wireguard, zerotier or netbird.
+	Type string
+	// Interface overrides the interface name prefix of the VPN kind.
+	Interface string
+	// Pattern builds the SSH host from {ip} (this machine's VPN address),
+	// {hostname} and {hostname-}. Empty means {ip}.
+	Pattern string
+	// ClientIP is the SSH client IP.
+	ClientIP string
+
+	// interfaces lists local interfaces, replaced in tests
+	interfaces func() ([]vpnInterface, error)
+}
+
+// Name returns the source name.
+func (s *VPNSource) Name() string { return s.Type }
+
+// Priority returns the source priority.
+func (s *VPNSource) Priority() int { return PriorityVPN }
+
+// Resolve returns the VPN address of the SSH client for the server and the
+// pattern for SSH when the connection came through the VPN.
+func (s *VPNSource) Resolve(hostType HostType) string {
+	kind, ok := vpnKinds[s.Type]
+	if !ok {
+		return ""
+	}
+	clientIP := net.ParseIP(s.ClientIP)
+	if clientIP == nil {
+		return ""
+	}
+
+	localIP := s.localAddress(kind, clientIP)
+	if localIP == "" {
+		return ""
+	}
+
+	switch hostType {
+	case ServerHost:
+		return s.ClientIP
+	case SSHHost:
+		return applyVPNPattern(s.Pattern, localIP)
+	}
+	return ""
+}
+
+// localAddress returns this machine's address on the VPN interface the
+// client is reachable through, or "" when the connection did not use it
+func (s *VPNSource) localAddress(kind vpnKind, clientIP net.IP) string {
+	list := s.interfaces
+	if list == nil {
+		list = localInterfaces
+	}
+	ifaces, err := list()
+	if err != nil {
+		return ""
+	}
+
+	prefixes := kind.interfacePrefixes
+	if s.Interface != "" {
+		prefixes = []string{s.Interface}
+	}
+	var usual *net.IPNet
+	if kind.network != "" {
+		_, usual, _ = net.ParseCIDR(kind.network)
+	}
+
+	for _, iface := range ifaces {
+		if !hasAnyPrefix(iface.Name, prefixes) {
+			continue
+		}
+		for _, addr := range iface.Addrs {
+			if addr.Contains(clientIP) || (usual != nil && usual.Contains(clientIP) && usual.Contains(addr.IP)) {
+				return addr.IP.String()
+			}
+		}
+	}
+	return ""
+}
+
+// localInterfaces lists the machine's interfaces that are up
+func localInterfaces() ([]vpnInterface, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []vpnInterface
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		entry := vpnInterface{Name: iface.Name}
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok {
+				entry.Addrs = append(entry.Addrs, ipnet)
+			}
+		}
+		result = append(result, entry)
+	}
+	return result, nil
+}
+
+// applyVPNPattern fills in {ip}, {hostname} and {hostname-}
+func applyVPNPattern(pattern, ip string) string {
+	if pattern == "" {
+		return ip
+	}
+	hostname, _ := os.Hostname()
+	result := strings.ReplaceAll(pattern, "{ip}", ip)
+	result = strings.ReplaceAll(result, "{hostname-}", strings.ReplaceAll(hostname, "-", ""))
+	return strings.ReplaceAll(result, "{hostname}", hostname)
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
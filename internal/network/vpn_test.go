@@ -0,0 +1,95 @@
+package network
+
+import (
+	"net"
+	"os"
+	"testing"
+)
+
+func mustCIDR(t *testing.T, cidr string) *net.IPNet {
+	t.Helper()
+	ip, ipnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ipnet.IP = ip
+	return ipnet
+}
+
+func TestVPNSource(t *testing.T) {
+	ifaces := []vpnInterface{
+		{Name: "eth0", Addrs: []*net.IPNet{mustCIDR(t, "192.168.1.20/24")}},
+		{Name: "wg0", Addrs: []*net.IPNet{mustCIDR(t, "10.8.0.2/24")}},
+		{Name: "ztabc123", Addrs: []*net.IPNet{mustCIDR(t, "10.147.17.5/24")}},
+		{Name: "wt0", Addrs: []*net.IPNet{mustCIDR(t, "100.90.1.2/16")}},
+	}
+	listInterfaces := func() ([]vpnInterface, error) { return ifaces, nil }
+	hostname, _ := os.Hostname()
+
+	tests := []struct {
+		name       string
+		source     VPNSource
+		wantServer string
+		wantSSH    string
+	}{
+		{
+			name:       "wireguard subnet",
+			source:     VPNSource{Type: "wireguard", ClientIP: "10.8.0.1"},
+			wantServer: "10.8.0.1",
+			wantSSH:    "10.8.0.2",
+		},
+		{
+			name:       "zerotier with pattern",
+			source:     VPNSource{Type: "zerotier", ClientIP: "10.147.17.9", Pattern: "{hostname}-zt"},
+			wantServer: "10.147.17.9",
+			wantSSH:    hostname + "-zt",
+		},
+		{
+			name:       "netbird usual range outside the interface subnet",
+			source:     VPNSource{Type: "netbird", ClientIP: "100.70.3.4"},
+			wantServer: "100.70.3.4",
+			wantSSH:    "100.90.1.2",
+		},
+		{
+			name:   "client on the LAN",
+			source: VPNSource{Type: "wireguard", ClientIP: "192.168.1.5"},
+		},
+		{
+			name:   "interface override",
+			source: VPNSource{Type: "wireguard", Interface: "tun", ClientIP: "10.8.0.1"},
+		},
+		{
+			name:   "unknown type",
+			source: VPNSource{Type: "openvpn", ClientIP: "10.8.0.1"},
+		},
+		{
+			name:   "no client IP",
+			source: VPNSource{Type: "wireguard"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := tt.source
+			src.interfaces = listInterfaces
+			if got := src.Resolve(ServerHost); got != tt.wantServer {
+				t.Errorf("Resolve(ServerHost) = %q, want %q", got, tt.wantServer)
+			}
+			if got := src.Resolve(SSHHost); got != tt.wantSSH {
+				t.Errorf("Resolve(SSHHost) = %q, want %q", got, tt.wantSSH)
+			}
+		})
+	}
+}
+
+func TestNewResolver_KeepsOrderWithinPriority(t *testing.T) {
+	resolver := NewResolver(&VPNSource{Type: "zerotier"}, &VPNSource{Type: "wireguard"}, &CommandLineSource{})
+
+	var names []string
+	for _, result := range resolver.Explain() {
+		names = append(names, result.Name)
+	}
+	if len(names) != 3 || names[0] != "command-line" || names[1] != "zerotier" || names[2] != "wireguard" {
+		t.Errorf("source order = %v, want [command-line zerotier wireguard]", names)
+	}
+}
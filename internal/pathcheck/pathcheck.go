@@ -0,0 +1,195 @@
+// Package pathcheck validates the paths carried by open requests. The client
+// cleans each path before sending it and the server again on receipt, so
+// both agree on what a path names: an absolute path without control
+// characters, "." or ".." segments, or repeated slashes. Optionally a path
+// is checked against the filesystem of the machine it is on, to require that
+// it exists or to replace symlinks with their targets, looked up there, or
+// created there for rcode --create.
+package pathcheck
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+var (
+	// ErrEmpty is returned for an empty path
+	ErrEmpty = errors.New("path is empty")
+	// ErrNotAbsolute is returned for a relative path
+	ErrNotAbsolute = errors.New("path is not absolute")
+	// ErrControlChar is returned for a path holding a NUL byte or another
+	// control character, which no editor should be handed
+	ErrControlChar = errors.New("path contains a control character")
+	// ErrNotExist is returned by Check with MustExist for a missing path
+	ErrNotExist = errors.New("path does not exist")
+)
+
+// Options selects the checks Check makes on this machine's filesystem
+type Options struct {
+	MustExist       bool // Fail with ErrNotExist unless the path exists
+	ResolveSymlinks bool // Replace symlinks in the path with their targets
+}
+
+// Clean returns p normalized, or an error when it is empty, relative or
+// holds a control character. Slash-separated paths, as sent from Unix
+// machines, are cleaned lexically. Windows paths with a drive letter or a
+// UNC prefix are cleaned the same way, with backslashes, whatever the OS of
+// this machine.
+func Clean(p string) (string, error) {
+	if p == "" {
+		return "", ErrEmpty
+	}
+	for i, r := range p {
+		if unicode.IsControl(r) {
+			return "", fmt.Errorf("%w at byte %d: %q", ErrControlChar, i, p)
+		}
+	}
+
+	switch {
+	case p[0] == '/':
+		return path.Clean(p), nil
+	case isWindowsAbs(p):
+		return cleanWindows(p), nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrNotAbsolute, p)
+}
+
+// Check cleans p and makes the checks of opts on this machine, returning
+// the cleaned and, with ResolveSymlinks, canonical path
+func Check(p string, opts Options) (string, error) {
+	cleaned, err := Clean(p)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.MustExist {
+		if _, err := os.Stat(cleaned); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return "", fmt.Errorf("%w: %s", ErrNotExist, cleaned)
+			}
+			return "", err
+		}
+	}
+	if opts.ResolveSymlinks {
+		return resolve(cleaned)
+	}
+	return cleaned, nil
+}
+
+// Info describes what a path names on this machine
+type Info struct {
+	Exists bool   // The path, or the target of a symlink, exists
+	IsDir  bool   // It is a directory
+	Target string // Where symlinks in the path lead, when anywhere else
+}
+
+// Stat reports what the cleaned path p names on this machine. A missing
+// path is not an error; it is reported with Exists false.
+func Stat(p string) (Info, error) {
+	var info Info
+	fi, err := os.Stat(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return info, nil
+	}
+	if err != nil {
+		return info, err
+	}
+	info.Exists, info.IsDir = true, fi.IsDir()
+
+	target, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		return info, err
+	}
+	if target != filepath.Clean(p) {
+		info.Target = target
+	}
+	return info, nil
+}
+
+// Create creates the cleaned path p on this machine, as a directory when
+// dir is set and as an empty file otherwise, along with its missing parent
+// directories. It reports whether p was created; an existing path is left
+// as it is.
+func Create(p string, dir bool, fileMode, dirMode fs.FileMode) (bool, error) {
+	if _, err := os.Stat(p); err == nil || !errors.Is(err, fs.ErrNotExist) {
+		return false, err
+	}
+
+	if dir {
+		if err := os.MkdirAll(p, dirMode); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), dirMode); err != nil {
+		return false, err
+	}
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL, fileMode) // #nosec G304 -- creating p is the point
+	if errors.Is(err, fs.ErrExist) {
+		// Created in the meantime
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, f.Close()
+}
+
+// resolve returns p with its symlinks resolved. The part of a path that
+// does not exist yet, such as a file about to be created, is kept under its
+// resolved parent.
+func resolve(p string) (string, error) {
+	var missing []string
+	dir := p
+	for {
+		resolved, err := filepath.EvalSymlinks(dir)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, missing...)...), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			// Nothing exists, not even the root
+			return p, nil
+		}
+		missing = append([]string{filepath.Base(dir)}, missing...)
+		dir = parent
+	}
+}
+
+// cleanWindows cleans an absolute Windows path lexically, as filepath.Clean
+// does on Windows: separators become backslashes, and "." and ".." segments
+// are resolved without climbing above the drive or UNC share
+func cleanWindows(p string) string {
+	slashed := strings.ReplaceAll(p, `\`, "/")
+	volume, rest := slashed[:2], slashed[2:]
+	if volume == "//" {
+		// \\server\share\rest
+		parts := strings.SplitN(rest, "/", 3)
+		volume += strings.Join(parts[:min(len(parts), 2)], "/")
+		rest = ""
+		if len(parts) == 3 {
+			rest = parts[2]
+		}
+	}
+	return strings.ReplaceAll(volume+path.Clean("/"+rest), "/", `\`)
+}
+
+// isWindowsAbs reports whether p is an absolute Windows path: a drive
+// letter followed by a separator, or a UNC path
+func isWindowsAbs(p string) bool {
+	if len(p) >= 3 && p[1] == ':' && (p[2] == '\\' || p[2] == '/') {
+		c := p[0] | 0x20
+		return c >= 'a' && c <= 'z'
+	}
+	return len(p) >= 2 && p[0] == '\\' && p[1] == '\\'
+}
@@ -0,0 +1,193 @@
+package pathcheck
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestClean(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr error
+	}{
+		{"absolute", "/home/alice/project", "/home/alice/project", nil},
+		{"dot segments", "/home/alice/./src/../project/", "/home/alice/project", nil},
+		{"repeated slashes", "//home///alice", "/home/alice", nil},
+		{"traversal stays under root", "/../../etc/passwd", "/etc/passwd", nil},
+		{"spaces and unicode", "/home/alice/my project/日本.md", "/home/alice/my project/日本.md", nil},
+		{"windows drive", `C:\Users\alice\project`, `C:\Users\alice\project`, nil},
+		{"windows drive with slashes", "d:/src", `d:\src`, nil},
+		{"windows dot segments", `C:\Users\alice\.\src\..\project\`, `C:\Users\alice\project`, nil},
+		{"windows traversal stays on drive", `C:\Users\..\..\Windows`, `C:\Windows`, nil},
+		{"unc", `\\server\share\file`, `\\server\share\file`, nil},
+		{"unc traversal stays on share", `\\server\share\a\..\..\b`, `\\server\share\b`, nil},
+		{"empty", "", "", ErrEmpty},
+		{"relative", "src/main.go", "", ErrNotAbsolute},
+		{"dot", ".", "", ErrNotAbsolute},
+		{"null byte", "/home/alice/a\x00b", "", ErrControlChar},
+		{"newline", "/home/alice/a\nb", "", ErrControlChar},
+		{"escape sequence", "/home/alice/\x1b[31m", "", ErrControlChar},
+		{"delete", "/home/alice/\x7f", "", ErrControlChar},
+		{"c1 control", "/home/alice/\u0085", "", ErrControlChar},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Clean(tt.path)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Clean(%q) error = %v, want %v", tt.path, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Clean(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses slash-separated paths and symlinks")
+	}
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := filepath.Join(dir, "target")
+	if err := os.MkdirAll(filepath.Join(target, "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		opts    Options
+		want    string
+		wantErr error
+	}{
+		{"existing", link + "/src/", Options{MustExist: true}, link + "/src", nil},
+		{"missing", link + "/new.go", Options{MustExist: true}, "", ErrNotExist},
+		{"missing allowed", link + "/new.go", Options{}, link + "/new.go", nil},
+		{"symlink resolved", link + "/src", Options{ResolveSymlinks: true}, target + "/src", nil},
+		{"missing part kept under resolved parent", link + "/new/main.go", Options{ResolveSymlinks: true}, target + "/new/main.go", nil},
+		{"invalid before filesystem", link + "/\x00", Options{MustExist: true}, "", ErrControlChar},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Check(tt.path, tt.opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Check(%q) error = %v, want %v", tt.path, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Check(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStat(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses symlinks")
+	}
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(dir, link); err != nil {
+		t.Fatal(err)
+	}
+	broken := filepath.Join(dir, "broken")
+	if err := os.Symlink(filepath.Join(dir, "gone"), broken); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want Info
+	}{
+		{"file", file, Info{Exists: true}},
+		{"directory", dir, Info{Exists: true, IsDir: true}},
+		{"symlink", link, Info{Exists: true, IsDir: true, Target: dir}},
+		{"through symlink", link + "/main.go", Info{Exists: true, Target: file}},
+		{"missing", dir + "/new.go", Info{}},
+		{"broken symlink", broken, Info{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Stat(tt.path)
+			if err != nil {
+				t.Fatalf("Stat(%q) error = %v", tt.path, err)
+			}
+			if got != tt.want {
+				t.Errorf("Stat(%q) = %+v, want %+v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(existing, []byte("package main\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		dir         bool
+		wantCreated bool
+		wantDir     bool
+	}{
+		{"file", filepath.Join(dir, "new.go"), false, true, false},
+		{"file with parents", filepath.Join(dir, "a", "b", "new.go"), false, true, false},
+		{"directory", filepath.Join(dir, "c", "d"), true, true, true},
+		{"existing file", existing, false, false, false},
+		{"existing directory", dir, true, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created, err := Create(tt.path, tt.dir, 0o600, 0o700)
+			if err != nil {
+				t.Fatalf("Create(%q) error = %v", tt.path, err)
+			}
+			if created != tt.wantCreated {
+				t.Errorf("Create(%q) created = %v, want %v", tt.path, created, tt.wantCreated)
+			}
+			info, err := os.Stat(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.IsDir() != tt.wantDir {
+				t.Errorf("%s IsDir = %v, want %v", tt.path, info.IsDir(), tt.wantDir)
+			}
+		})
+	}
+
+	// An existing file is not truncated
+	if data, _ := os.ReadFile(existing); string(data) != "package main\n" {
+		t.Errorf("existing file holds %q after Create", data)
+	}
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(filepath.Join(dir, "new.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := info.Mode().Perm(); perm&^0o600 != 0 {
+			t.Errorf("created file mode = %v, want at most 0600", perm)
+		}
+	}
+}
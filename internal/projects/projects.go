@@ -0,0 +1,164 @@
+// Package projects remembers the editor last used for each project opened
+// from this machine, so that "rcode ." keeps opening a repository in the
+// editor it was last opened in. A project is the repository a path is in,
+// or the directory opened when it is in none.
+package projects
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxProjects is the number of projects remembered; the ones used least
+// recently are forgotten first
+const MaxProjects = 200
+
+// Memory is the editor last used for each project, by project directory
+type Memory struct {
+	Projects map[string]Project `json:"projects,omitempty" yaml:"projects,omitempty"`
+}
+
+// Project is the editor last used for one project
+type Project struct {
+	Editor string    `json:"editor" yaml:"editor"`
+	Used   time.Time `json:"used" yaml:"used"`
+}
+
+// Root returns the project of the absolute path: the nearest directory at
+// or above it holding a .git entry, or else path itself, or its directory
+// when path is not a directory
+func Root(path string) string {
+	path = filepath.Clean(path)
+	dir := path
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		dir = filepath.Dir(path)
+	}
+	for d := dir; ; {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			return d
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return dir
+		}
+		d = parent
+	}
+}
+
+// Load reads the memory kept in file; a missing file remembers nothing
+func Load(file string) (*Memory, error) {
+	data, err := os.ReadFile(filepath.Clean(file))
+	if errors.Is(err, os.ErrNotExist) {
+		return &Memory{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read project editors: %w", err)
+	}
+	var m Memory
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, fmt.Errorf("failed to parse project editors: %w", err)
+		}
+	}
+	return &m, nil
+}
+
+// Editor returns the editor last used for the project holding the absolute
+// path: the remembered project that is the longest prefix of path. It
+// returns "" when none is.
+func (m *Memory) Editor(path string) string {
+	path = filepath.Clean(path)
+	var best string
+	for dir := range m.Projects {
+		if len(dir) > len(best) && within(path, dir) {
+			best = dir
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return m.Projects[best].Editor
+}
+
+// within reports whether path is dir or inside it
+func within(path, dir string) bool {
+	if path == dir {
+		return true
+	}
+	if !strings.HasSuffix(dir, string(filepath.Separator)) {
+		dir += string(filepath.Separator)
+	}
+	return strings.HasPrefix(path, dir)
+}
+
+// Set remembers editor for the project dir at now, forgetting the projects
+// used least recently beyond MaxProjects
+func (m *Memory) Set(dir, editor string, now time.Time) {
+	if m.Projects == nil {
+		m.Projects = make(map[string]Project)
+	}
+	m.Projects[filepath.Clean(dir)] = Project{Editor: editor, Used: now}
+	if len(m.Projects) <= MaxProjects {
+		return
+	}
+
+	dirs := make([]string, 0, len(m.Projects))
+	for d := range m.Projects {
+		dirs = append(dirs, d)
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		return m.Projects[dirs[i]].Used.After(m.Projects[dirs[j]].Used)
+	})
+	for _, d := range dirs[MaxProjects:] {
+		delete(m.Projects, d)
+	}
+}
+
+// Remember records in file that the project of the absolute path was
+// opened in editor. Opens from separate processes finishing at the same
+// moment may lose one of them.
+func Remember(file, path, editor string, now time.Time) error {
+	m, err := Load(file)
+	if err != nil {
+		return err
+	}
+	m.Set(Root(path), editor, now)
+	return save(file, m)
+}
+
+// save writes m to file atomically
+func save(file string, m *Memory) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal project editors: %w", err)
+	}
+
+	dir := filepath.Dir(file)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".projects-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write project editors: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write project editors: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write project editors: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write project editors: %w", err)
+	}
+	return nil
+}
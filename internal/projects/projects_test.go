@@ -0,0 +1,108 @@
+package projects
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRoot(t *testing.T) {
+	dir := t.TempDir()
+	repo := filepath.Join(dir, "repo")
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(repo, "cmd", "server"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "cmd", "server", "main.go"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	plain := filepath.Join(dir, "notes")
+	if err := os.MkdirAll(plain, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{repo, repo},
+		{filepath.Join(repo, "cmd", "server"), repo},
+		{filepath.Join(repo, "cmd", "server", "main.go"), repo},
+		{plain, plain},
+		{filepath.Join(plain, "todo.md"), plain},
+	}
+	for _, tt := range tests {
+		if got := Root(tt.path); got != tt.want {
+			t.Errorf("Root(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestMemoryEditor(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	m := &Memory{}
+	m.Set("/home/alice/src/api", "nvim", now)
+	m.Set("/home/alice/src/api/web", "cursor", now)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/home/alice/src/api", "nvim"},
+		{"/home/alice/src/api/cmd/main.go", "nvim"},
+		{"/home/alice/src/api/web/src", "cursor"},
+		{"/home/alice/src/api-v2", ""},
+		{"/home/alice/src", ""},
+	}
+	for _, tt := range tests {
+		if got := m.Editor(tt.path); got != tt.want {
+			t.Errorf("Editor(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestMemorySetForgetsLeastRecent(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	m := &Memory{}
+	for i := 0; i <= MaxProjects; i++ {
+		m.Set(filepath.Join("/src", time.Duration(i).String()), "nvim", start.Add(time.Duration(i)*time.Minute))
+	}
+	if len(m.Projects) != MaxProjects {
+		t.Fatalf("remembered %d projects, want %d", len(m.Projects), MaxProjects)
+	}
+	if _, ok := m.Projects["/src/0s"]; ok {
+		t.Error("the least recently used project was kept")
+	}
+}
+
+func TestRemember(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "rcode", "projects.json")
+	dir := t.TempDir()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	if err := Remember(file, dir, "nvim", now); err != nil {
+		t.Fatalf("Remember() error = %v", err)
+	}
+	if err := Remember(file, dir, "cursor", now.Add(time.Minute)); err != nil {
+		t.Fatalf("Remember() error = %v", err)
+	}
+
+	m, err := Load(file)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := m.Editor(filepath.Join(dir, "main.go")); got != "cursor" {
+		t.Errorf("Editor() = %q, want the last editor used", got)
+	}
+	if len(m.Projects) != 1 {
+		t.Errorf("remembered %d projects, want 1", len(m.Projects))
+	}
+
+	empty, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil || len(empty.Projects) != 0 {
+		t.Errorf("Load() of a missing file = %+v, %v", empty, err)
+	}
+}
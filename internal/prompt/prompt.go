@@ -0,0 +1,102 @@
+// Package prompt asks interactive questions on a terminal for the setup
+// wizards.
+package prompt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrNoInput is returned when the input ends before a question is answered
+var ErrNoInput = errors.New("no input")
+
+// Prompter writes questions to out and reads answers from in, one per line
+type Prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// New creates a Prompter
+func New(in io.Reader, out io.Writer) *Prompter {
+	return &Prompter{in: bufio.NewReader(in), out: out}
+}
+
+// Ask asks question and returns the trimmed answer, def for an empty line or
+// "" for "-". Answers rejected by validate are reported and asked again.
+func (p *Prompter) Ask(question, def string, validate func(string) error) (string, error) {
+	for {
+		if def != "" {
+			fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+		} else {
+			fmt.Fprintf(p.out, "%s: ", question)
+		}
+
+		answer, err := p.readLine()
+		if err != nil {
+			return "", err
+		}
+		switch answer {
+		case "":
+			answer = def
+		case "-":
+			answer = ""
+		}
+
+		if validate != nil {
+			if err := validate(answer); err != nil {
+				fmt.Fprintf(p.out, "  %v\n", err)
+				continue
+			}
+		}
+		return answer, nil
+	}
+}
+
+// Confirm asks a yes/no question, returning def for an empty line
+func (p *Prompter) Confirm(question string, def bool) (bool, error) {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+
+	for {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, hint)
+
+		answer, err := p.readLine()
+		if err != nil {
+			return false, err
+		}
+
+		switch strings.ToLower(answer) {
+		case "":
+			return def, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		default:
+			fmt.Fprintln(p.out, "  please answer y or n")
+		}
+	}
+}
+
+// Printf writes informational text between questions
+func (p *Prompter) Printf(format string, args ...any) {
+	fmt.Fprintf(p.out, format, args...)
+}
+
+// readLine reads one line without its line ending
+func (p *Prompter) readLine() (string, error) {
+	line, err := p.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		if err == io.EOF {
+			fmt.Fprintln(p.out)
+			return "", ErrNoInput
+		}
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
@@ -0,0 +1,109 @@
+package prompt
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAsk(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		def      string
+		validate func(string) error
+		want     string
+		wantErr  error
+		wantOut  string
+	}{
+		{
+			name:    "answer",
+			input:   "192.168.1.10\n",
+			want:    "192.168.1.10",
+			wantOut: "Host: ",
+		},
+		{
+			name:    "default on empty line",
+			input:   "\n",
+			def:     "cursor",
+			want:    "cursor",
+			wantOut: "Host [cursor]: ",
+		},
+		{
+			name:  "dash clears the default",
+			input: "-\n",
+			def:   "100.64.0.1",
+			want:  "",
+		},
+		{
+			name:  "re-ask after invalid answer",
+			input: "bad\ngood\n",
+			validate: func(s string) error {
+				if s == "bad" {
+					return errors.New("not good")
+				}
+				return nil
+			},
+			want:    "good",
+			wantOut: "  not good\n",
+		},
+		{
+			name:  "last line without newline",
+			input: "  zed  ",
+			want:  "zed",
+		},
+		{
+			name:    "no input",
+			input:   "",
+			wantErr: ErrNoInput,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			p := New(strings.NewReader(tt.input), &out)
+
+			got, err := p.Ask("Host", tt.def, tt.validate)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Ask() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Ask() = %q, want %q", got, tt.want)
+			}
+			if !strings.Contains(out.String(), tt.wantOut) {
+				t.Errorf("Ask() output = %q, want it to contain %q", out.String(), tt.wantOut)
+			}
+		})
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		def   bool
+		want  bool
+	}{
+		{name: "yes", input: "y\n", want: true},
+		{name: "no", input: "No\n", def: true, want: false},
+		{name: "default true", input: "\n", def: true, want: true},
+		{name: "default false", input: "\n", def: false, want: false},
+		{name: "re-ask", input: "maybe\nyes\n", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New(strings.NewReader(tt.input), &bytes.Buffer{})
+
+			got, err := p.Confirm("Use Tailscale?", tt.def)
+			if err != nil {
+				t.Fatalf("Confirm() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Confirm() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+// Package secret resolves secrets that config files refer to instead of
+// holding them: a scalar tagged !env names an environment variable, and one
+// tagged !keychain names an item in the OS keychain (the macOS Keychain, or
+// the Secret Service through libsecret's secret-tool elsewhere).
+//
+//	auth_token: !keychain rcode-token
+//	auth_token: !env RCODE_AUTH_TOKEN
+package secret
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Tags of YAML scalars that refer to a secret
+const (
+	TagEnv      = "!env"
+	TagKeychain = "!keychain"
+)
+
+// lookupTimeout bounds how long the keychain tool may take, which includes
+// an unlock prompt
+const lookupTimeout = 30 * time.Second
+
+var (
+	// ErrNotFound is returned when a referenced secret does not exist
+	ErrNotFound = errors.New("secret not found")
+	// ErrUnsupported is returned when the platform has no keychain tool
+	ErrUnsupported = errors.New("no keychain available")
+)
+
+// Backend looks up secrets by name
+type Backend interface {
+	Lookup(ctx context.Context, name string) (string, error)
+}
+
+// backends are the backends of the tags; a variable so tests can replace
+// them
+var backends = map[string]Backend{
+	TagEnv:      Env{},
+	TagKeychain: Keychain{},
+}
+
+// Env looks up secrets in environment variables
+type Env struct{}
+
+// Lookup returns the value of the environment variable name
+func (Env) Lookup(_ context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("%w: environment variable %s is not set", ErrNotFound, name)
+	}
+	return value, nil
+}
+
+// Keychain looks up secrets in the OS keychain. On macOS name is the
+// service of a generic password, added with
+//
+//	security add-generic-password -a "$USER" -s NAME -w
+//
+// and elsewhere the value of the "service" attribute, stored with
+//
+//	secret-tool store --label NAME service NAME
+type Keychain struct{}
+
+// Lookup returns the secret stored under name
+func (Keychain) Lookup(ctx context.Context, name string) (string, error) {
+	executable, args, err := keychainArgv(runtime.GOOS, name)
+	if err != nil {
+		return "", err
+	}
+	if _, err := exec.LookPath(executable); err != nil {
+		return "", fmt.Errorf("%w: %s is not installed", ErrUnsupported, executable)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, executable, args...) // #nosec G204 -- fixed keychain tools
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		// Both tools exit non-zero for a missing item
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: keychain item %s: %s", ErrNotFound, name, msg)
+		}
+		return "", fmt.Errorf("%w: keychain item %s", ErrNotFound, name)
+	}
+	value := strings.TrimRight(stdout.String(), "\r\n")
+	if value == "" {
+		// secret-tool exits 0 without output when nothing matches
+		return "", fmt.Errorf("%w: keychain item %s", ErrNotFound, name)
+	}
+	return value, nil
+}
+
+// keychainArgv returns the command that prints the keychain item name
+func keychainArgv(goos, name string) (string, []string, error) {
+	switch goos {
+	case "darwin":
+		return "security", []string{"find-generic-password", "-s", name, "-w"}, nil
+	case "windows":
+		return "", nil, fmt.Errorf("%w on Windows; use %s instead", ErrUnsupported, TagEnv)
+	default:
+		return "secret-tool", []string{"lookup", "service", name}, nil
+	}
+}
+
+// HasRefs reports whether the YAML document data refers to any secrets
+func HasRefs(data []byte) bool {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return false
+	}
+	found := false
+	walk(&doc, func(n *yaml.Node) error {
+		found = true
+		return nil
+	})
+	return found
+}
+
+// ResolveYAML returns the YAML document data with the scalars that refer
+// to secrets replaced by the secrets. Data without references is returned
+// unchanged.
+func ResolveYAML(data []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		// Left for the config decoder to report
+		return data, nil
+	}
+
+	resolved := false
+	err := walk(&doc, func(n *yaml.Node) error {
+		ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+		defer cancel()
+
+		value, err := backends[n.Tag].Lookup(ctx, strings.TrimSpace(n.Value))
+		if err != nil {
+			return fmt.Errorf("line %d: %s %s: %w", n.Line, n.Tag, n.Value, err)
+		}
+		n.Tag, n.Value, n.Style = "!!str", value, yaml.DoubleQuotedStyle
+		resolved = true
+		return nil
+	})
+	if err != nil || !resolved {
+		return data, err
+	}
+	return yaml.Marshal(&doc)
+}
+
+// walk calls fn for each scalar of node that refers to a secret
+func walk(node *yaml.Node, fn func(*yaml.Node) error) error {
+	if node.Kind == yaml.ScalarNode {
+		if _, ok := backends[node.Tag]; ok {
+			return fn(node)
+		}
+		return nil
+	}
+	for _, child := range node.Content {
+		if err := walk(child, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
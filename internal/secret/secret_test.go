@@ -0,0 +1,112 @@
+package secret
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fakeKeychain holds keychain items in memory
+type fakeKeychain map[string]string
+
+func (k fakeKeychain) Lookup(_ context.Context, name string) (string, error) {
+	if value, ok := k[name]; ok {
+		return value, nil
+	}
+	return "", ErrNotFound
+}
+
+func TestKeychainArgv(t *testing.T) {
+	tests := []struct {
+		goos     string
+		wantExec string
+		wantArgs []string
+		wantErr  bool
+	}{
+		{"darwin", "security", []string{"find-generic-password", "-s", "rcode-token", "-w"}, false},
+		{"linux", "secret-tool", []string{"lookup", "service", "rcode-token"}, false},
+		{"windows", "", nil, true},
+	}
+	for _, tt := range tests {
+		executable, args, err := keychainArgv(tt.goos, "rcode-token")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: keychainArgv() error = %v, wantErr %v", tt.goos, err, tt.wantErr)
+			continue
+		}
+		if executable != tt.wantExec || !reflect.DeepEqual(args, tt.wantArgs) {
+			t.Errorf("%s: keychainArgv() = %s %v, want %s %v", tt.goos, executable, args, tt.wantExec, tt.wantArgs)
+		}
+	}
+}
+
+func TestEnv(t *testing.T) {
+	t.Setenv("RCODE_TEST_SECRET", "s3cret")
+
+	if got, err := (Env{}).Lookup(context.Background(), "RCODE_TEST_SECRET"); err != nil || got != "s3cret" {
+		t.Errorf("Lookup() = %q, %v, want s3cret", got, err)
+	}
+	if _, err := (Env{}).Lookup(context.Background(), "RCODE_TEST_UNSET"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Lookup() of an unset variable error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestResolveYAML(t *testing.T) {
+	saved := backends[TagKeychain]
+	backends[TagKeychain] = fakeKeychain{"rcode-token": "from-keychain"}
+	defer func() { backends[TagKeychain] = saved }()
+	t.Setenv("RCODE_TEST_SECRET", "from-env: with a colon")
+
+	data := []byte(`server:
+  auth_token: !keychain rcode-token
+client:
+  hosts:
+    server:
+      auth_token: !env RCODE_TEST_SECRET
+  default_editor: code
+`)
+	if !HasRefs(data) {
+		t.Fatal("HasRefs() = false, want true")
+	}
+
+	resolved, err := ResolveYAML(data)
+	if err != nil {
+		t.Fatalf("ResolveYAML() error = %v", err)
+	}
+	var doc struct {
+		Server struct {
+			AuthToken string `yaml:"auth_token"`
+		} `yaml:"server"`
+		Client struct {
+			Hosts struct {
+				Server struct {
+					AuthToken string `yaml:"auth_token"`
+				} `yaml:"server"`
+			} `yaml:"hosts"`
+			DefaultEditor string `yaml:"default_editor"`
+		} `yaml:"client"`
+	}
+	if err := yaml.Unmarshal(resolved, &doc); err != nil {
+		t.Fatalf("resolved document does not parse: %v\n%s", err, resolved)
+	}
+	if doc.Server.AuthToken != "from-keychain" || doc.Client.Hosts.Server.AuthToken != "from-env: with a colon" || doc.Client.DefaultEditor != "code" {
+		t.Errorf("resolved document = %+v", doc)
+	}
+	if HasRefs(resolved) {
+		t.Error("HasRefs() of the resolved document = true, want false")
+	}
+
+	// Documents without references are returned as they are
+	plain := []byte("# comment\ndefault_editor:   code\n")
+	if got, err := ResolveYAML(plain); err != nil || string(got) != string(plain) {
+		t.Errorf("ResolveYAML() = %q, %v, want the input unchanged", got, err)
+	}
+
+	_, err = ResolveYAML([]byte("auth_token: !keychain missing\n"))
+	if !errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("ResolveYAML() of a missing item error = %v, want ErrNotFound with the line", err)
+	}
+}
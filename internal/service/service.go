@@ -1,37 +1,134 @@
-// Package service provides functionality to install and manage rcode-server as a system service.
+// Package service provides functionality to install and manage rcode binaries as system services.
 package service
 
 import (
 	"fmt"
+	"html"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"text/template"
 )
 
+// DefaultName is the service name used for rcode-server
+const DefaultName = "rcode-server"
+
+// defaultDescription is the systemd description used when Options.Description is empty
+const defaultDescription = "RCode Server - Remote Code Launcher"
+
+// Options customizes which program a ServiceManager installs
+type Options struct {
+	// Name names the launchd label, systemd unit and Windows task (default
+	// "rcode-server"), so several rcode services can be installed side by side
+	Name string
+	// Description is shown by systemd
+	Description string
+	// Args are passed to the binary after -config
+	Args []string
+}
+
 // ServiceManager handles service installation and management
 //
 //nolint:revive // ServiceManager is a clear name and the package is not intended to be shortened
 type ServiceManager struct {
-	binaryPath string
-	configPath string
-	userHome   string
+	binaryPath  string
+	configPath  string
+	userHome    string
+	name        string
+	description string
+	args        []string
 }
 
-// NewServiceManager creates a new service manager instance
+// NewServiceManager creates a new service manager instance for rcode-server
 func NewServiceManager(binaryPath, configPath string) (*ServiceManager, error) {
+	return NewServiceManagerWithOptions(binaryPath, configPath, Options{})
+}
+
+// NewServiceManagerWithOptions creates a service manager for an arbitrary rcode binary
+func NewServiceManagerWithOptions(binaryPath, configPath string, opts Options) (*ServiceManager, error) {
+	if err := ValidateName(opts.Name); opts.Name != "" && err != nil {
+		return nil, err
+	}
+
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user home directory: %w", err)
 	}
 
 	return &ServiceManager{
-		binaryPath: binaryPath,
-		configPath: configPath,
-		userHome:   home,
+		binaryPath:  binaryPath,
+		configPath:  configPath,
+		userHome:    home,
+		name:        opts.Name,
+		description: opts.Description,
+		args:        opts.Args,
 	}, nil
 }
 
+// ValidateName checks that name is usable as a launchd label, systemd unit
+// and Windows task name
+func ValidateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("service name cannot be empty")
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return fmt.Errorf("invalid service name %q: only letters, digits, '-', '_' and '.' are allowed", name)
+		}
+	}
+	return nil
+}
+
+// Name returns the service name
+func (sm *ServiceManager) Name() string {
+	if sm.name == "" {
+		return DefaultName
+	}
+	return sm.name
+}
+
+// launchdLabel returns the launchd label for the service
+func (sm *ServiceManager) launchdLabel() string {
+	return "com.foxytanuki." + sm.Name()
+}
+
+// plistPath returns the launchd plist location for the service
+func (sm *ServiceManager) plistPath() string {
+	return filepath.Join(sm.userHome, "Library", "LaunchAgents", sm.launchdLabel()+".plist")
+}
+
+// unitName returns the systemd unit name for the service
+func (sm *ServiceManager) unitName() string {
+	return sm.Name() + ".service"
+}
+
+// unitPath returns the systemd user unit location for the service
+func (sm *ServiceManager) unitPath() string {
+	return filepath.Join(sm.userHome, ".config", "systemd", "user", sm.unitName())
+}
+
+// logPaths returns the stdout and stderr log files for the service. The
+// default service keeps its historical service.log names.
+func (sm *ServiceManager) logPaths() (string, string) {
+	base := sm.Name()
+	if base == DefaultName {
+		base = "service"
+	}
+	logDir := filepath.Join(sm.userHome, ".local", "share", "rcode", "logs")
+	return filepath.Join(logDir, base+".log"), filepath.Join(logDir, base+"-error.log")
+}
+
+// programArgs returns the full command line the service runs
+func (sm *ServiceManager) programArgs(binaryPath string) []string {
+	args := []string{binaryPath}
+	if sm.configPath != "" {
+		args = append(args, "--config", sm.configPath)
+	}
+	return append(args, sm.args...)
+}
+
 // Install installs rcode-server as a system service
 func (sm *ServiceManager) Install() error {
 	switch runtime.GOOS {
@@ -39,6 +136,8 @@ func (sm *ServiceManager) Install() error {
 		return sm.installDarwin()
 	case "linux":
 		return sm.installLinux()
+	case "windows":
+		return sm.installWindows()
 	default:
 		return fmt.Errorf("unsupported operating system: %s", runtime.GOOS)
 	}
@@ -51,6 +150,8 @@ func (sm *ServiceManager) Uninstall() error {
 		return sm.uninstallDarwin()
 	case "linux":
 		return sm.uninstallLinux()
+	case "windows":
+		return sm.uninstallWindows()
 	default:
 		return fmt.Errorf("unsupported operating system: %s", runtime.GOOS)
 	}
@@ -63,6 +164,8 @@ func (sm *ServiceManager) Start() error {
 		return sm.startDarwin()
 	case "linux":
 		return sm.startLinux()
+	case "windows":
+		return sm.startWindows()
 	default:
 		return fmt.Errorf("unsupported operating system: %s", runtime.GOOS)
 	}
@@ -75,6 +178,8 @@ func (sm *ServiceManager) Stop() error {
 		return sm.stopDarwin()
 	case "linux":
 		return sm.stopLinux()
+	case "windows":
+		return sm.stopWindows()
 	default:
 		return fmt.Errorf("unsupported operating system: %s", runtime.GOOS)
 	}
@@ -87,6 +192,8 @@ func (sm *ServiceManager) Status() (bool, error) {
 		return sm.statusDarwin()
 	case "linux":
 		return sm.statusLinux()
+	case "windows":
+		return sm.statusWindows()
 	default:
 		return false, fmt.Errorf("unsupported operating system: %s", runtime.GOOS)
 	}
@@ -110,7 +217,7 @@ func (sm *ServiceManager) installDarwin() error {
 	plistContent := sm.generateDarwinPlist(binaryPath)
 
 	// Write plist file
-	plistPath := filepath.Join(launchAgentsDir, "com.foxytanuki.rcode-server.plist")
+	plistPath := sm.plistPath()
 	if err := os.WriteFile(plistPath, []byte(plistContent), 0600); err != nil {
 		return fmt.Errorf("failed to write plist file: %w", err)
 	}
@@ -130,7 +237,7 @@ func (sm *ServiceManager) installDarwin() error {
 
 // uninstallDarwin removes rcode-server from launchd on macOS
 func (sm *ServiceManager) uninstallDarwin() error {
-	plistPath := filepath.Join(sm.userHome, "Library", "LaunchAgents", "com.foxytanuki.rcode-server.plist")
+	plistPath := sm.plistPath()
 
 	// Unload the service if it's running
 	_ = exec.Command("launchctl", "unload", plistPath).Run() // #nosec G204 -- plistPath is constructed from user home directory
@@ -146,7 +253,7 @@ func (sm *ServiceManager) uninstallDarwin() error {
 
 // startDarwin starts the rcode-server service on macOS
 func (sm *ServiceManager) startDarwin() error {
-	plistPath := filepath.Join(sm.userHome, "Library", "LaunchAgents", "com.foxytanuki.rcode-server.plist")
+	plistPath := sm.plistPath()
 
 	// Check if plist exists
 	if _, err := os.Stat(plistPath); os.IsNotExist(err) {
@@ -167,7 +274,7 @@ func (sm *ServiceManager) startDarwin() error {
 		return nil
 	}
 
-	cmd := exec.Command("launchctl", "start", "com.foxytanuki.rcode-server")
+	cmd := exec.Command("launchctl", "start", sm.launchdLabel()) // #nosec G204 -- label is a validated service name
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to start service: %w", err)
 	}
@@ -178,7 +285,7 @@ func (sm *ServiceManager) startDarwin() error {
 
 // stopDarwin stops the rcode-server service on macOS
 func (sm *ServiceManager) stopDarwin() error {
-	cmd := exec.Command("launchctl", "stop", "com.foxytanuki.rcode-server")
+	cmd := exec.Command("launchctl", "stop", sm.launchdLabel()) // #nosec G204 -- label is a validated service name
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to stop service: %w", err)
 	}
@@ -189,7 +296,7 @@ func (sm *ServiceManager) stopDarwin() error {
 
 // statusDarwin checks the status of the rcode-server service on macOS
 func (sm *ServiceManager) statusDarwin() (bool, error) {
-	cmd := exec.Command("launchctl", "list", "com.foxytanuki.rcode-server")
+	cmd := exec.Command("launchctl", "list", sm.launchdLabel()) // #nosec G204 -- label is a validated service name
 	output, err := cmd.Output()
 	if err != nil {
 		// If the service is not loaded, launchctl list returns an error
@@ -218,7 +325,7 @@ func (sm *ServiceManager) installLinux() error {
 	serviceContent := sm.generateLinuxService(binaryPath)
 
 	// Write service file
-	servicePath := filepath.Join(systemdUserDir, "rcode-server.service")
+	servicePath := sm.unitPath()
 	if err := os.WriteFile(servicePath, []byte(serviceContent), 0600); err != nil {
 		return fmt.Errorf("failed to write service file: %w", err)
 	}
@@ -230,7 +337,7 @@ func (sm *ServiceManager) installLinux() error {
 	}
 
 	// Enable the service
-	cmd = exec.Command("systemctl", "--user", "enable", "rcode-server.service")
+	cmd = exec.Command("systemctl", "--user", "enable", sm.unitName()) // #nosec G204 -- unit name is a validated service name
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to enable service: %w", err)
 	}
@@ -242,13 +349,13 @@ func (sm *ServiceManager) installLinux() error {
 
 // uninstallLinux removes rcode-server from systemd on Linux
 func (sm *ServiceManager) uninstallLinux() error {
-	servicePath := filepath.Join(sm.userHome, ".config", "systemd", "user", "rcode-server.service")
+	servicePath := sm.unitPath()
 
 	// Disable the service
-	_ = exec.Command("systemctl", "--user", "disable", "rcode-server.service").Run()
+	_ = exec.Command("systemctl", "--user", "disable", sm.unitName()).Run() // #nosec G204 -- unit name is a validated service name
 
 	// Stop the service
-	_ = exec.Command("systemctl", "--user", "stop", "rcode-server.service").Run()
+	_ = exec.Command("systemctl", "--user", "stop", sm.unitName()).Run() // #nosec G204 -- unit name is a validated service name
 
 	// Reload systemd
 	_ = exec.Command("systemctl", "--user", "daemon-reload").Run()
@@ -264,14 +371,14 @@ func (sm *ServiceManager) uninstallLinux() error {
 
 // startLinux starts the rcode-server service on Linux
 func (sm *ServiceManager) startLinux() error {
-	servicePath := filepath.Join(sm.userHome, ".config", "systemd", "user", "rcode-server.service")
+	servicePath := sm.unitPath()
 
 	// Check if service file exists
 	if _, err := os.Stat(servicePath); os.IsNotExist(err) {
 		return fmt.Errorf("service not installed. Run 'rcode-server install-service' first")
 	}
 
-	cmd := exec.Command("systemctl", "--user", "start", "rcode-server.service")
+	cmd := exec.Command("systemctl", "--user", "start", sm.unitName()) // #nosec G204 -- unit name is a validated service name
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to start service: %w", err)
 	}
@@ -282,7 +389,7 @@ func (sm *ServiceManager) startLinux() error {
 
 // stopLinux stops the rcode-server service on Linux
 func (sm *ServiceManager) stopLinux() error {
-	cmd := exec.Command("systemctl", "--user", "stop", "rcode-server.service")
+	cmd := exec.Command("systemctl", "--user", "stop", sm.unitName()) // #nosec G204 -- unit name is a validated service name
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to stop service: %w", err)
 	}
@@ -293,7 +400,7 @@ func (sm *ServiceManager) stopLinux() error {
 
 // statusLinux checks the status of the rcode-server service on Linux
 func (sm *ServiceManager) statusLinux() (bool, error) {
-	cmd := exec.Command("systemctl", "--user", "is-active", "--quiet", "rcode-server.service")
+	cmd := exec.Command("systemctl", "--user", "is-active", "--quiet", sm.unitName()) // #nosec G204 -- unit name is a validated service name
 	err := cmd.Run()
 	if err != nil {
 		// If the service is not active, is-active returns a non-zero exit code
@@ -303,71 +410,224 @@ func (sm *ServiceManager) statusLinux() (bool, error) {
 	return true, nil
 }
 
-// generateDarwinPlist generates the launchd plist content for macOS
-func (sm *ServiceManager) generateDarwinPlist(binaryPath string) string {
-	args := []string{binaryPath}
-	if sm.configPath != "" {
-		args = append(args, "-config", sm.configPath)
+// installWindows registers rcode-server as a per-user Scheduled Task on Windows.
+// A logon task needs no administrator rights, unlike a Windows service.
+func (sm *ServiceManager) installWindows() error {
+	binaryPath, err := sm.findBinaryPath()
+	if err != nil {
+		return fmt.Errorf("failed to find binary: %w", err)
+	}
+
+	cmd := exec.Command("schtasks", "/Create", "/F", // #nosec G204 -- task command is built from the resolved binary and config paths
+		"/TN", sm.Name(),
+		"/TR", sm.generateWindowsTaskCommand(binaryPath),
+		"/SC", "ONLOGON",
+		"/RL", "LIMITED")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to create scheduled task: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	fmt.Printf("Service installed successfully as scheduled task %q\n", sm.Name())
+	fmt.Println("The service will start automatically on login.")
+	return nil
+}
+
+// uninstallWindows removes the rcode-server Scheduled Task on Windows
+func (sm *ServiceManager) uninstallWindows() error {
+	// Stop the task if it's running
+	_ = exec.Command("schtasks", "/End", "/TN", sm.Name()).Run()
+
+	installed, err := sm.IsInstalled()
+	if err != nil {
+		return err
+	}
+	if installed {
+		cmd := exec.Command("schtasks", "/Delete", "/F", "/TN", sm.Name())
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to delete scheduled task: %w: %s", err, strings.TrimSpace(string(output)))
+		}
+	}
+
+	fmt.Println("Service uninstalled successfully.")
+	return nil
+}
+
+// startWindows runs the rcode-server Scheduled Task on Windows
+func (sm *ServiceManager) startWindows() error {
+	installed, err := sm.IsInstalled()
+	if err != nil {
+		return err
+	}
+	if !installed {
+		return fmt.Errorf("service not installed. Run 'rcode-server install-service' first")
+	}
+
+	cmd := exec.Command("schtasks", "/Run", "/TN", sm.Name())
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to start service: %w", err)
+	}
+
+	fmt.Println("Service started successfully.")
+	return nil
+}
+
+// stopWindows ends the rcode-server Scheduled Task on Windows
+func (sm *ServiceManager) stopWindows() error {
+	cmd := exec.Command("schtasks", "/End", "/TN", sm.Name())
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to stop service: %w", err)
+	}
+
+	fmt.Println("Service stopped successfully.")
+	return nil
+}
+
+// statusWindows checks whether the rcode-server Scheduled Task is running on Windows
+func (sm *ServiceManager) statusWindows() (bool, error) {
+	cmd := exec.Command("schtasks", "/Query", "/TN", sm.Name(), "/FO", "CSV", "/NH")
+	output, err := cmd.Output()
+	if err != nil {
+		// If the task does not exist, schtasks returns a non-zero exit code
+		return false, nil
 	}
 
-	// Build ProgramArguments array
-	argsXML := ""
-	for _, arg := range args {
-		argsXML += fmt.Sprintf("\t\t<string>%s</string>\n", arg)
+	return isWindowsTaskRunning(string(output)), nil
+}
+
+// isWindowsTaskRunning reports whether schtasks /Query CSV output shows a running task
+func isWindowsTaskRunning(output string) bool {
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), ",")
+		if len(fields) < 3 {
+			continue
+		}
+		if strings.Trim(fields[len(fields)-1], `"`) == "Running" {
+			return true
+		}
 	}
+	return false
+}
+
+// serviceTemplateData holds the values substituted into the plist and unit templates
+type serviceTemplateData struct {
+	Label       string
+	Description string
+	Args        []string
+	StdoutPath  string
+	StderrPath  string
+}
 
-	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+// darwinPlistTemplate is the launchd agent definition
+var darwinPlistTemplate = template.Must(template.New("plist").Funcs(template.FuncMap{
+	"xml": html.EscapeString,
+}).Parse(`<?xml version="1.0" encoding="UTF-8"?>
 <!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
 <plist version="1.0">
 <dict>
 	<key>Label</key>
-	<string>com.foxytanuki.rcode-server</string>
+	<string>{{xml .Label}}</string>
 	<key>ProgramArguments</key>
 	<array>
-%s	</array>
+{{range .Args}}		<string>{{xml .}}</string>
+{{end}}	</array>
 	<key>RunAtLoad</key>
 	<true/>
 	<key>KeepAlive</key>
 	<true/>
 	<key>StandardOutPath</key>
-	<string>%s/.local/share/rcode/logs/service.log</string>
+	<string>{{xml .StdoutPath}}</string>
 	<key>StandardErrorPath</key>
-	<string>%s/.local/share/rcode/logs/service-error.log</string>
+	<string>{{xml .StderrPath}}</string>
 	<key>EnvironmentVariables</key>
 	<dict>
 		<key>PATH</key>
 		<string>/opt/homebrew/bin:/usr/local/bin:/usr/bin:/bin:/usr/sbin:/sbin</string>
 	</dict>
 </dict>
-</plist>`, argsXML, sm.userHome, sm.userHome)
-}
-
-// generateLinuxService generates the systemd service file content for Linux
-func (sm *ServiceManager) generateLinuxService(binaryPath string) string {
-	execStart := binaryPath
-	if sm.configPath != "" {
-		execStart = fmt.Sprintf("%s -config %s", binaryPath, sm.configPath)
-	}
+</plist>`))
 
-	return fmt.Sprintf(`[Unit]
-Description=RCode Server - Remote Code Launcher
+// linuxServiceTemplate is the systemd user unit definition
+var linuxServiceTemplate = template.Must(template.New("unit").Funcs(template.FuncMap{
+	"execStart": systemdExecStart,
+}).Parse(`[Unit]
+Description={{.Description}}
 After=network.target
 
 [Service]
 Type=simple
-ExecStart=%s
+ExecStart={{execStart .Args}}
 Restart=always
 RestartSec=5
-StandardOutput=append:%s/.local/share/rcode/logs/service.log
-StandardError=append:%s/.local/share/rcode/logs/service-error.log
+StandardOutput=append:{{.StdoutPath}}
+StandardError=append:{{.StderrPath}}
 Environment="PATH=/usr/local/bin:/usr/bin:/bin"
 
 [Install]
 WantedBy=default.target
-`, execStart, sm.userHome, sm.userHome)
+`))
+
+// templateData returns the template values for the service running binaryPath
+func (sm *ServiceManager) templateData(binaryPath string) serviceTemplateData {
+	description := sm.description
+	if description == "" {
+		description = defaultDescription
+	}
+	stdout, stderr := sm.logPaths()
+
+	return serviceTemplateData{
+		Label:       sm.launchdLabel(),
+		Description: description,
+		Args:        sm.programArgs(binaryPath),
+		StdoutPath:  stdout,
+		StderrPath:  stderr,
+	}
+}
+
+// renderTemplate executes tmpl; the templates are static, so failures are programming errors
+func renderTemplate(tmpl *template.Template, data serviceTemplateData) string {
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		panic(fmt.Sprintf("service: render %s template: %v", tmpl.Name(), err))
+	}
+	return buf.String()
+}
+
+// generateDarwinPlist generates the launchd plist content for macOS
+func (sm *ServiceManager) generateDarwinPlist(binaryPath string) string {
+	return renderTemplate(darwinPlistTemplate, sm.templateData(binaryPath))
+}
+
+// generateLinuxService generates the systemd service file content for Linux
+func (sm *ServiceManager) generateLinuxService(binaryPath string) string {
+	return renderTemplate(linuxServiceTemplate, sm.templateData(binaryPath))
+}
+
+// systemdExecStart joins args into an ExecStart line, quoting arguments with spaces
+func systemdExecStart(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if strings.ContainsAny(arg, " \t\"") {
+			arg = `"` + strings.ReplaceAll(arg, `"`, `\"`) + `"`
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
 }
 
-// findBinaryPath finds the path to the rcode-server binary
+// generateWindowsTaskCommand generates the Scheduled Task command line for Windows
+func (sm *ServiceManager) generateWindowsTaskCommand(binaryPath string) string {
+	args := sm.programArgs(binaryPath)
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if i == 0 || strings.ContainsAny(arg, " \t") {
+			arg = `"` + arg + `"`
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
+
+// findBinaryPath finds the path to the service binary
 func (sm *ServiceManager) findBinaryPath() (string, error) {
 	// If binaryPath is already absolute and exists, use it
 	if filepath.IsAbs(sm.binaryPath) {
@@ -377,7 +637,10 @@ func (sm *ServiceManager) findBinaryPath() (string, error) {
 	}
 
 	// Try to find the binary in PATH
-	binaryName := "rcode-server"
+	binaryName := DefaultName
+	if sm.binaryPath != "" {
+		binaryName = strings.TrimSuffix(filepath.Base(sm.binaryPath), ".exe")
+	}
 	if runtime.GOOS == "windows" {
 		binaryName += ".exe"
 	}
@@ -386,9 +649,18 @@ func (sm *ServiceManager) findBinaryPath() (string, error) {
 	if err != nil {
 		// If not in PATH, try common installation locations
 		commonPaths := []string{
-			"/usr/local/bin/rcode-server",
-			"/usr/bin/rcode-server",
-			filepath.Join(sm.userHome, "bin", "rcode-server"),
+			filepath.Join("/usr/local/bin", binaryName),
+			filepath.Join("/usr/bin", binaryName),
+			filepath.Join(sm.userHome, "bin", binaryName),
+		}
+		if runtime.GOOS == "windows" {
+			commonPaths = []string{
+				filepath.Join(sm.userHome, "go", "bin", binaryName),
+				filepath.Join(sm.userHome, "bin", binaryName),
+			}
+			if localAppData := os.Getenv("LOCALAPPDATA"); localAppData != "" {
+				commonPaths = append(commonPaths, filepath.Join(localAppData, "Programs", "rcode", binaryName))
+			}
 		}
 
 		for _, p := range commonPaths {
@@ -397,7 +669,7 @@ func (sm *ServiceManager) findBinaryPath() (string, error) {
 			}
 		}
 
-		return "", fmt.Errorf("rcode-server binary not found in PATH or common locations")
+		return "", fmt.Errorf("%s binary not found in PATH or common locations", binaryName)
 	}
 
 	return path, nil
@@ -407,13 +679,14 @@ func (sm *ServiceManager) findBinaryPath() (string, error) {
 func (sm *ServiceManager) IsInstalled() (bool, error) {
 	switch runtime.GOOS {
 	case "darwin":
-		plistPath := filepath.Join(sm.userHome, "Library", "LaunchAgents", "com.foxytanuki.rcode-server.plist")
-		_, err := os.Stat(plistPath)
+		_, err := os.Stat(sm.plistPath())
 		return !os.IsNotExist(err), nil
 	case "linux":
-		servicePath := filepath.Join(sm.userHome, ".config", "systemd", "user", "rcode-server.service")
-		_, err := os.Stat(servicePath)
+		_, err := os.Stat(sm.unitPath())
 		return !os.IsNotExist(err), nil
+	case "windows":
+		err := exec.Command("schtasks", "/Query", "/TN", sm.Name()).Run()
+		return err == nil, nil
 	default:
 		return false, fmt.Errorf("unsupported operating system: %s", runtime.GOOS)
 	}
@@ -128,3 +128,92 @@ func TestInstallDarwinReloadsServiceWithBootoutBootstrap(t *testing.T) {
 		t.Fatalf("launchctl calls = %q, want %q", got, want)
 	}
 }
+
+func TestGenerateWindowsTaskCommandQuotesPaths(t *testing.T) {
+	sm := &ServiceManager{configPath: `C:\Users\Jo Tester\AppData\Roaming\rcode\server-config.yaml`, args: []string{"broker"}}
+	got := sm.generateWindowsTaskCommand(`C:\Program Files\rcode\rcode-server.exe`)
+	want := `"C:\Program Files\rcode\rcode-server.exe" --config "C:\Users\Jo Tester\AppData\Roaming\rcode\server-config.yaml" broker`
+	if got != want {
+		t.Fatalf("generateWindowsTaskCommand() = %q, want %q", got, want)
+	}
+}
+
+func TestIsWindowsTaskRunning(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   bool
+	}{
+		{"running", "\"\\rcode-server\",\"N/A\",\"Running\"\r\n", true},
+		{"ready", "\"\\rcode-server\",\"N/A\",\"Ready\"\r\n", false},
+		{"empty", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isWindowsTaskRunning(tt.output); got != tt.want {
+				t.Errorf("isWindowsTaskRunning() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateLinuxServiceUsesServiceName(t *testing.T) {
+	sm := &ServiceManager{
+		userHome:    "/home/tester",
+		configPath:  "/home/tester/my config.yaml",
+		name:        "rcode-broker",
+		description: "RCode relay broker",
+		args:        []string{"broker", "--listen", ":3340"},
+	}
+	unit := sm.generateLinuxService("/usr/local/bin/rcode-server")
+
+	for _, want := range []string{
+		"Description=RCode relay broker\n",
+		`ExecStart=/usr/local/bin/rcode-server --config "/home/tester/my config.yaml" broker --listen :3340` + "\n",
+		"StandardOutput=append:/home/tester/.local/share/rcode/logs/rcode-broker.log\n",
+		"StandardError=append:/home/tester/.local/share/rcode/logs/rcode-broker-error.log\n",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("unit missing %q:\n%s", want, unit)
+		}
+	}
+	if got := sm.unitPath(); got != "/home/tester/.config/systemd/user/rcode-broker.service" {
+		t.Errorf("unitPath() = %q", got)
+	}
+}
+
+func TestGenerateDarwinPlistDefaults(t *testing.T) {
+	sm := &ServiceManager{userHome: "/Users/tester", configPath: "/Users/tester/a&b.yaml"}
+	plist := sm.generateDarwinPlist("/usr/local/bin/rcode-server")
+
+	for _, want := range []string{
+		"<string>com.foxytanuki.rcode-server</string>",
+		"\t\t<string>/usr/local/bin/rcode-server</string>\n\t\t<string>--config</string>\n\t\t<string>/Users/tester/a&amp;b.yaml</string>\n\t</array>",
+		"<string>/Users/tester/.local/share/rcode/logs/service.log</string>",
+		"<string>/Users/tester/.local/share/rcode/logs/service-error.log</string>",
+	} {
+		if !strings.Contains(plist, want) {
+			t.Errorf("plist missing %q:\n%s", want, plist)
+		}
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"rcode-server", false},
+		{"rcode_broker.2", false},
+		{"", true},
+		{"rcode server", true},
+		{"../evil", true},
+	}
+
+	for _, tt := range tests {
+		if err := ValidateName(tt.name); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateName(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
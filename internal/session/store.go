@@ -0,0 +1,173 @@
+// Package session records the paths opened through rcode-server so remote
+// users can quickly re-open recent projects.
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultMaxEntries is the number of sessions kept when no limit is given.
+const DefaultMaxEntries = 100
+
+// Entry is a single recorded open request.
+type Entry struct {
+	Path     string    `json:"path"`
+	Paths    []string  `json:"paths,omitempty"`
+	Editor   string    `json:"editor"`
+	User     string    `json:"user"`
+	Host     string    `json:"host"`
+	OpenedAt time.Time `json:"opened_at"`
+}
+
+// sameTarget reports whether two entries refer to the same project.
+func (e *Entry) sameTarget(other *Entry) bool {
+	if e.Path != other.Path || e.User != other.User || e.Host != other.Host {
+		return false
+	}
+	if len(e.Paths) != len(other.Paths) {
+		return false
+	}
+	for i := range e.Paths {
+		if e.Paths[i] != other.Paths[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter narrows the result of Store.List. Empty fields match everything.
+type Filter struct {
+	User  string
+	Host  string
+	Limit int
+}
+
+// Store keeps recent sessions, most recent first. When created with a file
+// path, every change is persisted as JSON.
+type Store struct {
+	path       string
+	maxEntries int
+
+	mu      sync.RWMutex
+	entries []Entry
+}
+
+// NewStore creates a store backed by path. An empty path keeps sessions in
+// memory only. Existing entries are loaded from path if the file exists.
+func NewStore(path string, maxEntries int) (*Store, error) {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+
+	s := &Store{path: path, maxEntries: maxEntries}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("failed to read sessions file: %w", err)
+	}
+	if len(data) == 0 {
+		return s, nil
+	}
+	if err := json.Unmarshal(data, &s.entries); err != nil {
+		return nil, fmt.Errorf("failed to parse sessions file: %w", err)
+	}
+	if len(s.entries) > s.maxEntries {
+		s.entries = s.entries[:s.maxEntries]
+	}
+
+	return s, nil
+}
+
+// Record adds entry as the most recent session. An existing entry for the
+// same path, user, and host is moved to the front instead of duplicated.
+func (s *Store) Record(entry Entry) error {
+	if entry.OpenedAt.IsZero() {
+		entry.OpenedAt = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]Entry, 0, len(s.entries)+1)
+	entries = append(entries, entry)
+	for i := range s.entries {
+		if !s.entries[i].sameTarget(&entry) {
+			entries = append(entries, s.entries[i])
+		}
+	}
+	if len(entries) > s.maxEntries {
+		entries = entries[:s.maxEntries]
+	}
+	s.entries = entries
+
+	return s.save()
+}
+
+// List returns sessions matching filter, most recent first.
+func (s *Store) List(filter Filter) []Entry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]Entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		if filter.User != "" && e.User != filter.User {
+			continue
+		}
+		if filter.Host != "" && e.Host != filter.Host {
+			continue
+		}
+		result = append(result, e)
+		if filter.Limit > 0 && len(result) >= filter.Limit {
+			break
+		}
+	}
+	return result
+}
+
+// save writes the entries to disk atomically. Callers must hold s.mu.
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal sessions: %w", err)
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create sessions directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".sessions-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write sessions file: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write sessions file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write sessions file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write sessions file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package session
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStore_RecordAndList(t *testing.T) {
+	store, err := NewStore("", 3)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	base := time.Unix(1700000000, 0)
+	records := []Entry{
+		{Path: "/a", Editor: "vscode", User: "alice", Host: "dev", OpenedAt: base},
+		{Path: "/b", Editor: "vscode", User: "bob", Host: "dev", OpenedAt: base.Add(time.Minute)},
+		{Path: "/c", Editor: "cursor", User: "alice", Host: "dev", OpenedAt: base.Add(2 * time.Minute)},
+		{Path: "/a", Editor: "cursor", User: "alice", Host: "dev", OpenedAt: base.Add(3 * time.Minute)},
+		{Path: "/d", Editor: "vscode", User: "alice", Host: "prod", OpenedAt: base.Add(4 * time.Minute)},
+	}
+	for _, r := range records {
+		if err := store.Record(r); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"all (trimmed to max)", Filter{}, []string{"/d", "/a", "/c"}},
+		{"by user", Filter{User: "alice"}, []string{"/d", "/a", "/c"}},
+		{"by host", Filter{Host: "dev"}, []string{"/a", "/c"}},
+		{"with limit", Filter{Limit: 1}, []string{"/d"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := store.List(tt.filter)
+			if len(got) != len(tt.want) {
+				t.Fatalf("List() returned %d entries, want %d", len(got), len(tt.want))
+			}
+			for i, e := range got {
+				if e.Path != tt.want[i] {
+					t.Errorf("List()[%d].Path = %v, want %v", i, e.Path, tt.want[i])
+				}
+			}
+		})
+	}
+
+	// The re-opened entry keeps its latest editor
+	if got := store.List(Filter{Host: "dev", Limit: 1}); got[0].Editor != "cursor" {
+		t.Errorf("Record() editor = %v, want cursor", got[0].Editor)
+	}
+}
+
+func TestStore_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "sessions.json")
+
+	store, err := NewStore(path, 0)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	if err := store.Record(Entry{Path: "/project", Editor: "vscode", User: "alice", Host: "dev"}); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+
+	reloaded, err := NewStore(path, 0)
+	if err != nil {
+		t.Fatalf("NewStore() reload error = %v", err)
+	}
+	got := reloaded.List(Filter{})
+	if len(got) != 1 || got[0].Path != "/project" {
+		t.Fatalf("List() after reload = %+v, want one /project entry", got)
+	}
+	if got[0].OpenedAt.IsZero() {
+		t.Error("Record() did not set OpenedAt")
+	}
+}
@@ -0,0 +1,159 @@
+// Package stats keeps local usage counts for "rcode stats": how often each
+// command ran and failed, and for opens which editor was used, how the
+// hosts were resolved and which host answered. Nothing leaves the machine;
+// counting is off unless enabled in the client config.
+package stats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Stats are the counts recorded since Since
+type Stats struct {
+	Since    time.Time           `json:"since" yaml:"since"`
+	Commands map[string]*Outcome `json:"commands,omitempty" yaml:"commands,omitempty"` // by command, e.g. "open" or "editors add"
+	Opens    Opens               `json:"opens" yaml:"opens"`
+}
+
+// Outcome counts the runs of a command
+type Outcome struct {
+	Succeeded int `json:"succeeded" yaml:"succeeded"`
+	Failed    int `json:"failed" yaml:"failed"`
+}
+
+// Opens counts the successful opens by editor, resolution source and the
+// host that answered
+type Opens struct {
+	Editors       map[string]int `json:"editors,omitempty" yaml:"editors,omitempty"`
+	SSHSources    map[string]int `json:"ssh_sources,omitempty" yaml:"ssh_sources,omitempty"`       // How the SSH host was resolved, e.g. "config" or "tailscale"
+	ServerSources map[string]int `json:"server_sources,omitempty" yaml:"server_sources,omitempty"` // How the server was resolved
+	Hosts         map[string]int `json:"hosts,omitempty" yaml:"hosts,omitempty"`                   // Role of the host that answered: primary, fallback, tunnel, broker or local
+}
+
+// Event is one command run
+type Event struct {
+	Command string
+	Failed  bool
+	Open    *Open // Set when the command opened something
+}
+
+// Open describes a successful open
+type Open struct {
+	Editor       string
+	SSHSource    string
+	ServerSource string
+	Host         string
+}
+
+// Load reads the stats kept in path; a missing file holds none
+func Load(path string) (*Stats, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return &Stats{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stats file: %w", err)
+	}
+	var s Stats
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("failed to parse stats file: %w", err)
+		}
+	}
+	return &s, nil
+}
+
+// Record adds e to the stats kept in path. Runs of separate processes
+// finishing at the same moment may lose a count; the stats are a rough
+// picture, not an audit log.
+func Record(path string, e Event, now time.Time) error {
+	s, err := Load(path)
+	if err != nil {
+		return err
+	}
+	s.Add(e, now)
+	return save(path, s)
+}
+
+// Add counts e
+func (s *Stats) Add(e Event, now time.Time) {
+	if s.Since.IsZero() {
+		s.Since = now
+	}
+	if s.Commands == nil {
+		s.Commands = make(map[string]*Outcome)
+	}
+	outcome := s.Commands[e.Command]
+	if outcome == nil {
+		outcome = &Outcome{}
+		s.Commands[e.Command] = outcome
+	}
+	if e.Failed {
+		outcome.Failed++
+	} else {
+		outcome.Succeeded++
+	}
+
+	if e.Open == nil || e.Failed {
+		return
+	}
+	count(&s.Opens.Editors, e.Open.Editor)
+	count(&s.Opens.SSHSources, e.Open.SSHSource)
+	count(&s.Opens.ServerSources, e.Open.ServerSource)
+	count(&s.Opens.Hosts, e.Open.Host)
+}
+
+// count adds one to key in counts, unless key is empty
+func count(counts *map[string]int, key string) {
+	if key == "" {
+		return
+	}
+	if *counts == nil {
+		*counts = make(map[string]int)
+	}
+	(*counts)[key]++
+}
+
+// Reset removes the stats kept in path
+func Reset(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove stats file: %w", err)
+	}
+	return nil
+}
+
+// save writes s to path atomically
+func save(path string, s *Stats) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal stats: %w", err)
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create stats directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".stats-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write stats file: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write stats file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write stats file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write stats file: %w", err)
+	}
+	return nil
+}
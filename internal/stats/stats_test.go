@@ -0,0 +1,54 @@
+package stats
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rcode", "stats.json")
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	events := []Event{
+		{Command: "open", Open: &Open{Editor: "cursor", SSHSource: "tailscale", ServerSource: "config", Host: "primary"}},
+		{Command: "open", Open: &Open{Editor: "cursor", SSHSource: "tailscale", ServerSource: "config", Host: "fallback"}},
+		{Command: "open", Failed: true},
+		{Command: "editors add"},
+	}
+	for i, e := range events {
+		if err := Record(path, e, start.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !s.Since.Equal(start) {
+		t.Errorf("Since = %v, want the first event", s.Since)
+	}
+	if o := s.Commands["open"]; o == nil || o.Succeeded != 2 || o.Failed != 1 {
+		t.Errorf("open = %+v, want 2 succeeded, 1 failed", o)
+	}
+	if o := s.Commands["editors add"]; o == nil || o.Succeeded != 1 {
+		t.Errorf("editors add = %+v", o)
+	}
+	if s.Opens.Editors["cursor"] != 2 || s.Opens.SSHSources["tailscale"] != 2 || s.Opens.ServerSources["config"] != 2 {
+		t.Errorf("Opens = %+v", s.Opens)
+	}
+	if s.Opens.Hosts["primary"] != 1 || s.Opens.Hosts["fallback"] != 1 {
+		t.Errorf("Hosts = %v, want one open answered by each", s.Opens.Hosts)
+	}
+
+	if err := Reset(path); err != nil {
+		t.Fatalf("Reset() error = %v", err)
+	}
+	if s, err := Load(path); err != nil || len(s.Commands) != 0 {
+		t.Errorf("Load() after Reset = %+v, %v", s, err)
+	}
+	if err := Reset(path); err != nil {
+		t.Errorf("Reset() of missing stats error = %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package telemetry
+
+import (
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+)
+
+// durationBounds are the histogram bucket bounds, in seconds, for durations
+var durationBounds = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// metric is a counter or a duration histogram with one series per attribute
+// set. Values are cumulative from the provider's start.
+type metric struct {
+	name      string
+	unit      string
+	histogram bool
+	series    map[string]*series // keyed by attrKey
+}
+
+// series is the running value of one attribute set
+type series struct {
+	attrs   []slog.Attr
+	count   int64
+	sum     float64
+	buckets []int64 // histograms only, len(durationBounds)+1
+}
+
+// Count adds n to the counter name for attrs
+func (p *Provider) Count(name string, n int64, attrs ...slog.Attr) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s := p.seriesFor(name, "1", false, attrs)
+	s.count += n
+}
+
+// RecordDuration adds d, in seconds, to the histogram name for attrs
+func (p *Provider) RecordDuration(name string, d time.Duration, attrs ...slog.Attr) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s := p.seriesFor(name, "s", true, attrs)
+	v := d.Seconds()
+	s.count++
+	s.sum += v
+	s.buckets[sort.SearchFloat64s(durationBounds, v)]++
+}
+
+// seriesFor returns the series of name for attrs, creating both as needed.
+// p.mu must be held.
+func (p *Provider) seriesFor(name, unit string, histogram bool, attrs []slog.Attr) *series {
+	m, ok := p.metrics[name]
+	if !ok {
+		m = &metric{name: name, unit: unit, histogram: histogram, series: make(map[string]*series)}
+		p.metrics[name] = m
+	}
+
+	key := attrKey(attrs)
+	s, ok := m.series[key]
+	if !ok {
+		s = &series{attrs: attrs}
+		if histogram {
+			s.buckets = make([]int64, len(durationBounds)+1)
+		}
+		m.series[key] = s
+	}
+	return s
+}
+
+// metricSnapshot is a metric's value at export time
+type metricSnapshot struct {
+	name      string
+	unit      string
+	histogram bool
+	series    []series
+	at        time.Time
+}
+
+// snapshotMetrics copies the current metric values, sorted by name.
+// p.mu must be held.
+func (p *Provider) snapshotMetrics(at time.Time) []metricSnapshot {
+	snapshots := make([]metricSnapshot, 0, len(p.metrics))
+	for _, m := range p.metrics {
+		snap := metricSnapshot{name: m.name, unit: m.unit, histogram: m.histogram, at: at}
+		for _, s := range m.series {
+			copied := *s
+			copied.buckets = append([]int64(nil), s.buckets...)
+			snap.series = append(snap.series, copied)
+		}
+		sort.Slice(snap.series, func(i, j int) bool {
+			return attrKey(snap.series[i].attrs) < attrKey(snap.series[j].attrs)
+		})
+		snapshots = append(snapshots, snap)
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].name < snapshots[j].name })
+	return snapshots
+}
+
+// attrKey identifies an attribute set regardless of order
+func attrKey(attrs []slog.Attr) string {
+	parts := make([]string, len(attrs))
+	for i, a := range attrs {
+		parts[i] = a.String()
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "\x00")
+}
@@ -0,0 +1,235 @@
+package telemetry
+
+import (
+	"encoding/hex"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/version"
+)
+
+// The types below are the parts of the OTLP JSON encoding rcode uses. IDs
+// are hex strings and 64-bit integers decimal strings, as OTLP/JSON requires.
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              SpanKind        `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpTraces struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpDataPoint struct {
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	TimeUnixNano      string          `json:"timeUnixNano"`
+	AsInt             string          `json:"asInt,omitempty"`          // sums
+	Count             string          `json:"count,omitempty"`          // histograms
+	Sum               *float64        `json:"sum,omitempty"`            // histograms
+	BucketCounts      []string        `json:"bucketCounts,omitempty"`   // histograms
+	ExplicitBounds    []float64       `json:"explicitBounds,omitempty"` // histograms
+}
+
+type otlpSum struct {
+	DataPoints             []otlpDataPoint `json:"dataPoints"`
+	AggregationTemporality int             `json:"aggregationTemporality"`
+	IsMonotonic            bool            `json:"isMonotonic"`
+}
+
+type otlpHistogram struct {
+	DataPoints             []otlpDataPoint `json:"dataPoints"`
+	AggregationTemporality int             `json:"aggregationTemporality"`
+}
+
+type otlpMetric struct {
+	Name      string         `json:"name"`
+	Unit      string         `json:"unit"`
+	Sum       *otlpSum       `json:"sum,omitempty"`
+	Histogram *otlpHistogram `json:"histogram,omitempty"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpMetrics struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+// OTLP enum values
+const (
+	statusUnset = 0
+	statusError = 2
+
+	temporalityCumulative = 2
+)
+
+// tracesPayload encodes spans as an OTLP export request
+func (p *Provider) tracesPayload(spans []spanData) otlpTraces {
+	encoded := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		span := otlpSpan{
+			TraceID:           hex.EncodeToString(s.traceID[:]),
+			SpanID:            hex.EncodeToString(s.spanID[:]),
+			Name:              s.name,
+			Kind:              s.kind,
+			StartTimeUnixNano: unixNano(s.start),
+			EndTimeUnixNano:   unixNano(s.end),
+			Attributes:        otlpAttributes(s.attrs),
+			Status:            otlpStatus{Code: statusUnset},
+		}
+		if s.parentID != [8]byte{} {
+			span.ParentSpanID = hex.EncodeToString(s.parentID[:])
+		}
+		if s.err != "" {
+			span.Status = otlpStatus{Code: statusError, Message: s.err}
+		}
+		encoded = append(encoded, span)
+	}
+
+	return otlpTraces{ResourceSpans: []otlpResourceSpans{{
+		Resource:   p.resource(),
+		ScopeSpans: []otlpScopeSpans{{Scope: scope(), Spans: encoded}},
+	}}}
+}
+
+// metricsPayload encodes metric snapshots as an OTLP export request
+func (p *Provider) metricsPayload(metrics []metricSnapshot) otlpMetrics {
+	start := unixNano(p.start)
+	encoded := make([]otlpMetric, 0, len(metrics))
+	for _, m := range metrics {
+		now := unixNano(m.at)
+		points := make([]otlpDataPoint, 0, len(m.series))
+		for _, s := range m.series {
+			point := otlpDataPoint{
+				Attributes:        otlpAttributes(s.attrs),
+				StartTimeUnixNano: start,
+				TimeUnixNano:      now,
+			}
+			if m.histogram {
+				sum := s.sum
+				point.Count = strconv.FormatInt(s.count, 10)
+				point.Sum = &sum
+				point.ExplicitBounds = durationBounds
+				for _, n := range s.buckets {
+					point.BucketCounts = append(point.BucketCounts, strconv.FormatInt(n, 10))
+				}
+			} else {
+				point.AsInt = strconv.FormatInt(s.count, 10)
+			}
+			points = append(points, point)
+		}
+
+		metric := otlpMetric{Name: m.name, Unit: m.unit}
+		if m.histogram {
+			metric.Histogram = &otlpHistogram{DataPoints: points, AggregationTemporality: temporalityCumulative}
+		} else {
+			metric.Sum = &otlpSum{DataPoints: points, AggregationTemporality: temporalityCumulative, IsMonotonic: true}
+		}
+		encoded = append(encoded, metric)
+	}
+
+	return otlpMetrics{ResourceMetrics: []otlpResourceMetrics{{
+		Resource:     p.resource(),
+		ScopeMetrics: []otlpScopeMetrics{{Scope: scope(), Metrics: encoded}},
+	}}}
+}
+
+// resource describes the process the data comes from
+func (p *Provider) resource() otlpResource {
+	return otlpResource{Attributes: otlpAttributes([]slog.Attr{
+		slog.String("service.name", p.service),
+		slog.String("service.version", version.Version),
+	})}
+}
+
+func scope() otlpScope {
+	return otlpScope{Name: scopeName, Version: version.Version}
+}
+
+// otlpAttributes converts slog attributes to OTLP ones. Kinds OTLP has no
+// value type for are sent as strings.
+func otlpAttributes(attrs []slog.Attr) []otlpAttribute {
+	if len(attrs) == 0 {
+		return nil
+	}
+	result := make([]otlpAttribute, 0, len(attrs))
+	for _, a := range attrs {
+		var v otlpValue
+		value := a.Value.Resolve()
+		switch value.Kind() {
+		case slog.KindInt64:
+			s := strconv.FormatInt(value.Int64(), 10)
+			v.IntValue = &s
+		case slog.KindUint64:
+			s := strconv.FormatUint(value.Uint64(), 10)
+			v.IntValue = &s
+		case slog.KindFloat64:
+			f := value.Float64()
+			v.DoubleValue = &f
+		case slog.KindBool:
+			b := value.Bool()
+			v.BoolValue = &b
+		default:
+			s := value.String()
+			v.StringValue = &s
+		}
+		result = append(result, otlpAttribute{Key: a.Key, Value: v})
+	}
+	return result
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
@@ -0,0 +1,182 @@
+// Package telemetry exports OpenTelemetry traces and metrics from rcode and
+// rcode-server to an OTLP/HTTP collector, so launches can be followed in an
+// existing observability stack. Data is encoded as OTLP JSON and sent in
+// batches; a nil *Provider is valid and records nothing.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/config"
+	"github.com/foxytanuki/rcode/internal/logger"
+	"github.com/foxytanuki/rcode/internal/version"
+)
+
+// scopeName is the instrumentation scope of everything rcode records
+const scopeName = "github.com/foxytanuki/rcode"
+
+// maxBufferedSpans bounds the spans held between exports; later spans are
+// dropped until the next export
+const maxBufferedSpans = 2048
+
+// exportTimeout bounds a single request to the collector
+const exportTimeout = 5 * time.Second
+
+// Provider records spans and metrics and exports them periodically
+type Provider struct {
+	service  string
+	endpoint string // collector base URL, without /v1/...
+	headers  map[string]string
+	interval time.Duration
+	client   *http.Client
+	log      *logger.Logger
+	start    time.Time // start of the cumulative metric period
+
+	mu      sync.Mutex
+	spans   []spanData
+	dropped int
+	metrics map[string]*metric // keyed by metric name
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// New starts a provider for cfg that reports as service unless
+// cfg.ServiceName overrides it. It returns nil when telemetry is disabled.
+// Callers must call Shutdown to export what is still buffered.
+func New(cfg config.TelemetryConfig, service string, log *logger.Logger) *Provider {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.ServiceName != "" {
+		service = cfg.ServiceName
+	}
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = config.DefaultTelemetryInterval
+	}
+
+	p := &Provider{
+		service:  service,
+		endpoint: strings.TrimSuffix(endpoint(cfg), "/"),
+		headers:  cfg.Headers,
+		interval: interval,
+		client:   &http.Client{Timeout: exportTimeout},
+		log:      log,
+		start:    time.Now(),
+		metrics:  make(map[string]*metric),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// endpoint returns the collector URL: the configured one, the standard
+// OpenTelemetry environment variable, or the local default
+func endpoint(cfg config.TelemetryConfig) string {
+	if cfg.Endpoint != "" {
+		return cfg.Endpoint
+	}
+	if env := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); env != "" {
+		return env
+	}
+	return config.DefaultTelemetryEndpoint
+}
+
+// run exports buffered data every interval until Shutdown
+func (p *Provider) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.flush(context.Background())
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// Shutdown stops periodic export and sends what is still buffered. ctx
+// bounds the final export.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	if p == nil {
+		return nil
+	}
+	p.stopOnce.Do(func() { close(p.stop) })
+	<-p.done
+	return p.flush(ctx)
+}
+
+// flush exports the buffered spans and the current metric values
+func (p *Provider) flush(ctx context.Context) error {
+	p.mu.Lock()
+	spans := p.spans
+	p.spans = nil
+	dropped := p.dropped
+	p.dropped = 0
+	metrics := p.snapshotMetrics(time.Now())
+	p.mu.Unlock()
+
+	if dropped > 0 && p.log != nil {
+		p.log.Warn("Telemetry spans dropped", "count", dropped)
+	}
+
+	var firstErr error
+	if len(spans) > 0 {
+		if err := p.post(ctx, "/v1/traces", p.tracesPayload(spans)); err != nil {
+			firstErr = err
+		}
+	}
+	if len(metrics) > 0 {
+		if err := p.post(ctx, "/v1/metrics", p.metricsPayload(metrics)); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil && p.log != nil {
+		p.log.Debug("Telemetry export failed", "endpoint", p.endpoint, "error", firstErr)
+	}
+	return firstErr
+}
+
+// post sends one OTLP JSON payload to the collector
+func (p *Provider) post(ctx context.Context, path string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode telemetry: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create telemetry request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", fmt.Sprintf("%s/%s", p.service, version.Version))
+	for key, value := range p.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export telemetry: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("collector returned status %d for %s", resp.StatusCode, path)
+	}
+	return nil
+}
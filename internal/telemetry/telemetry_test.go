@@ -0,0 +1,203 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/config"
+)
+
+// collector is a fake OTLP/HTTP endpoint that keeps what it receives
+type collector struct {
+	mu      sync.Mutex
+	traces  []otlpTraces
+	metrics []otlpMetrics
+	headers http.Header
+}
+
+func newCollector(t *testing.T) (*collector, *httptest.Server) {
+	t.Helper()
+	c := &collector{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.headers = r.Header.Clone()
+
+		var err error
+		switch r.URL.Path {
+		case "/v1/traces":
+			var payload otlpTraces
+			err = json.NewDecoder(r.Body).Decode(&payload)
+			c.traces = append(c.traces, payload)
+		case "/v1/metrics":
+			var payload otlpMetrics
+			err = json.NewDecoder(r.Body).Decode(&payload)
+			c.metrics = append(c.metrics, payload)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			t.Errorf("collector: decode %s: %v", r.URL.Path, err)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return c, server
+}
+
+func TestNew_Disabled(t *testing.T) {
+	p := New(config.TelemetryConfig{}, "rcode", nil)
+	if p != nil {
+		t.Fatal("New() with telemetry disabled returned a provider")
+	}
+
+	// A nil provider and its spans must be usable
+	ctx, span := p.Start(context.Background(), "op", KindInternal)
+	span.SetAttributes(slog.String("k", "v"))
+	span.RecordError(errors.New("boom"))
+	span.End()
+	p.Count("requests", 1)
+	p.RecordDuration("duration", time.Second)
+	if err := p.Shutdown(ctx); err != nil {
+		t.Errorf("Shutdown() on nil provider error = %v", err)
+	}
+}
+
+func TestProvider_ExportsSpans(t *testing.T) {
+	c, server := newCollector(t)
+	p := New(config.TelemetryConfig{
+		Enabled:  true,
+		Endpoint: server.URL,
+		Headers:  map[string]string{"Authorization": "Bearer secret"},
+		Interval: time.Hour,
+	}, "rcode-test", nil)
+
+	ctx, parent := p.Start(context.Background(), "parent", KindClient, slog.String("path", "/open-editor"))
+	_, child := p.Start(ctx, "child", KindInternal, slog.Int("attempt", 2))
+	child.RecordError(errors.New("boom"))
+	child.End()
+	parent.End()
+
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if got := c.headers.Get("Authorization"); got != "Bearer secret" {
+		t.Errorf("Authorization header = %q, want configured header", got)
+	}
+	if len(c.traces) != 1 {
+		t.Fatalf("trace exports = %d, want 1", len(c.traces))
+	}
+	rs := c.traces[0].ResourceSpans[0]
+	if name := *rs.Resource.Attributes[0].Value.StringValue; name != "rcode-test" {
+		t.Errorf("service.name = %q, want rcode-test", name)
+	}
+	spans := rs.ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("spans = %d, want 2", len(spans))
+	}
+	childSpan, parentSpan := spans[0], spans[1]
+	if childSpan.TraceID != parentSpan.TraceID || childSpan.ParentSpanID != parentSpan.SpanID {
+		t.Errorf("child span %+v is not a child of %+v", childSpan, parentSpan)
+	}
+	if parentSpan.ParentSpanID != "" {
+		t.Errorf("parent span has parent %q, want none", parentSpan.ParentSpanID)
+	}
+	if childSpan.Status.Code != statusError || childSpan.Status.Message != "boom" {
+		t.Errorf("child status = %+v, want error boom", childSpan.Status)
+	}
+	if v := childSpan.Attributes[0].Value.IntValue; v == nil || *v != "2" {
+		t.Errorf("child attempt attribute = %+v, want intValue 2", childSpan.Attributes[0])
+	}
+	if parentSpan.Kind != KindClient {
+		t.Errorf("parent kind = %d, want %d", parentSpan.Kind, KindClient)
+	}
+}
+
+func TestProvider_ExportsMetrics(t *testing.T) {
+	c, server := newCollector(t)
+	p := New(config.TelemetryConfig{Enabled: true, Endpoint: server.URL, Interval: time.Hour}, "rcode", nil)
+
+	p.Count("rcode.requests", 1, slog.String("status", "ok"))
+	p.Count("rcode.requests", 2, slog.String("status", "ok"))
+	p.Count("rcode.requests", 1, slog.String("status", "error"))
+	p.RecordDuration("rcode.duration", 30*time.Millisecond)
+	p.RecordDuration("rcode.duration", 3*time.Second)
+
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.metrics) != 1 {
+		t.Fatalf("metric exports = %d, want 1", len(c.metrics))
+	}
+	metrics := c.metrics[0].ResourceMetrics[0].ScopeMetrics[0].Metrics
+	if len(metrics) != 2 {
+		t.Fatalf("metrics = %d, want 2", len(metrics))
+	}
+
+	duration, requests := metrics[0], metrics[1]
+	if duration.Histogram == nil || len(duration.Histogram.DataPoints) != 1 {
+		t.Fatalf("duration = %+v, want one histogram point", duration)
+	}
+	point := duration.Histogram.DataPoints[0]
+	if point.Count != "2" || *point.Sum != 3.03 {
+		t.Errorf("duration point count = %s sum = %v, want 2 and 3.03", point.Count, *point.Sum)
+	}
+	if len(point.BucketCounts) != len(durationBounds)+1 || point.BucketCounts[3] != "1" || point.BucketCounts[9] != "1" {
+		t.Errorf("duration buckets = %v, want one in 0.05 and one in 5", point.BucketCounts)
+	}
+
+	if requests.Sum == nil || !requests.Sum.IsMonotonic {
+		t.Fatalf("requests = %+v, want a monotonic sum", requests)
+	}
+	got := map[string]string{}
+	for _, dp := range requests.Sum.DataPoints {
+		got[*dp.Attributes[0].Value.StringValue] = dp.AsInt
+	}
+	if got["ok"] != "3" || got["error"] != "1" {
+		t.Errorf("requests by status = %v, want ok 3 and error 1", got)
+	}
+}
+
+func TestInjectExtract(t *testing.T) {
+	p := &Provider{metrics: map[string]*metric{}}
+	ctx, span := p.Start(context.Background(), "client", KindClient)
+
+	h := http.Header{}
+	Inject(ctx, h)
+	if h.Get(traceparentHeader) == "" {
+		t.Fatal("Inject() set no traceparent header")
+	}
+
+	remote := Extract(context.Background(), h)
+	_, server := p.Start(remote, "server", KindServer)
+	if server.data.traceID != span.data.traceID || server.data.parentID != span.data.spanID {
+		t.Errorf("server span %+v does not continue client span %+v", server.data.spanContext, span.data.spanContext)
+	}
+
+	for _, bad := range []string{
+		"",
+		"00-abc-def-01",
+		"01-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		"00-00000000000000000000000000000000-b7ad6b7169203331-01",
+		"00-0af7651916cd43dd8448eb211c80319c0af7-b7ad6b7169203331-01",
+		"00-zzf7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+	} {
+		h := http.Header{traceparentHeader: []string{bad}}
+		if ctx := Extract(context.Background(), h); ctx.Value(spanContextKey{}) != nil {
+			t.Errorf("Extract(%q) accepted a malformed traceparent", bad)
+		}
+	}
+}
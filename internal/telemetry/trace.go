@@ -0,0 +1,142 @@
+package telemetry
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SpanKind is the OTLP role of a span
+type SpanKind int
+
+// Span kinds, numbered as in OTLP
+const (
+	KindInternal SpanKind = 1
+	KindServer   SpanKind = 2
+	KindClient   SpanKind = 3
+)
+
+// traceparentHeader carries the W3C trace context between client and server
+const traceparentHeader = "traceparent"
+
+// spanContext identifies a span within its trace
+type spanContext struct {
+	traceID [16]byte
+	spanID  [8]byte
+}
+
+func (sc spanContext) valid() bool {
+	return sc.traceID != [16]byte{} && sc.spanID != [8]byte{}
+}
+
+// spanContextKey is the context key of the current span
+type spanContextKey struct{}
+
+// spanData is a finished span waiting for export
+type spanData struct {
+	spanContext
+	parentID [8]byte
+	name     string
+	kind     SpanKind
+	start    time.Time
+	end      time.Time
+	attrs    []slog.Attr
+	err      string // non-empty marks the span failed
+}
+
+// Span is an operation being timed. A nil *Span is valid and records nothing.
+type Span struct {
+	p    *Provider
+	data spanData
+}
+
+// Start begins a span named name as a child of the span in ctx, or of the
+// remote parent put there by Extract. The returned context carries the new
+// span; End must be called once the operation is over.
+func (p *Provider) Start(ctx context.Context, name string, kind SpanKind, attrs ...slog.Attr) (context.Context, *Span) {
+	if p == nil {
+		return ctx, nil
+	}
+
+	s := &Span{p: p, data: spanData{name: name, kind: kind, start: time.Now(), attrs: attrs}}
+	if parent, ok := ctx.Value(spanContextKey{}).(spanContext); ok {
+		s.data.traceID = parent.traceID
+		s.data.parentID = parent.spanID
+	} else {
+		_, _ = rand.Read(s.data.traceID[:])
+	}
+	_, _ = rand.Read(s.data.spanID[:])
+
+	return context.WithValue(ctx, spanContextKey{}, s.data.spanContext), s
+}
+
+// SetAttributes adds attributes to the span
+func (s *Span) SetAttributes(attrs ...slog.Attr) {
+	if s == nil {
+		return
+	}
+	s.data.attrs = append(s.data.attrs, attrs...)
+}
+
+// RecordError marks the span failed with err; a nil err is ignored
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.data.err = err.Error()
+}
+
+// End finishes the span and queues it for export
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.data.end = time.Now()
+
+	p := s.p
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.spans) >= maxBufferedSpans {
+		p.dropped++
+		return
+	}
+	p.spans = append(p.spans, s.data)
+}
+
+// Inject writes the span in ctx to h as a W3C traceparent header so the
+// server's spans join the client's trace
+func Inject(ctx context.Context, h http.Header) {
+	sc, ok := ctx.Value(spanContextKey{}).(spanContext)
+	if !ok || !sc.valid() {
+		return
+	}
+	h.Set(traceparentHeader, "00-"+hex.EncodeToString(sc.traceID[:])+"-"+hex.EncodeToString(sc.spanID[:])+"-01")
+}
+
+// Extract returns ctx with the remote parent from h's traceparent header, or
+// ctx unchanged when there is none or it is malformed
+func Extract(ctx context.Context, h http.Header) context.Context {
+	parts := strings.Split(h.Get(traceparentHeader), "-")
+	if len(parts) != 4 || parts[0] != "00" {
+		return ctx
+	}
+
+	var sc spanContext
+	if len(parts[1]) != 2*len(sc.traceID) || len(parts[2]) != 2*len(sc.spanID) {
+		return ctx
+	}
+	if _, err := hex.Decode(sc.traceID[:], []byte(parts[1])); err != nil {
+		return ctx
+	}
+	if _, err := hex.Decode(sc.spanID[:], []byte(parts[2])); err != nil {
+		return ctx
+	}
+	if !sc.valid() {
+		return ctx
+	}
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
@@ -0,0 +1,365 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/auth"
+	"github.com/foxytanuki/rcode/internal/logger"
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+// AgentPath is the broker endpoint rcode-server connects to.
+const AgentPath = "/_rcode/agent"
+
+// Default tunnel settings
+const (
+	DefaultRequestTimeout = 30 * time.Second
+	DefaultPingInterval   = 30 * time.Second
+	maxRequestBody        = 1 << 20
+	minReconnectDelay     = 1 * time.Second
+	maxReconnectDelay     = 30 * time.Second
+)
+
+// ErrNoAgent is returned when the broker has no connected rcode-server.
+var ErrNoAgent = errors.New("no rcode-server connected to broker")
+
+// tunnelRequest is an HTTP request relayed from the broker to rcode-server.
+type tunnelRequest struct {
+	ID         uint64      `json:"id"`
+	Method     string      `json:"method"`
+	URI        string      `json:"uri"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body,omitempty"`
+	RemoteAddr string      `json:"remote_addr"`
+}
+
+// tunnelResponse is the HTTP response relayed back to the broker.
+type tunnelResponse struct {
+	ID     uint64      `json:"id"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body,omitempty"`
+}
+
+// ServeTunnel answers relayed requests on conn using handler until the
+// connection closes or ctx is cancelled.
+func ServeTunnel(ctx context.Context, conn *Conn, handler http.Handler) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+
+	for {
+		data, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		var req tunnelRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			return fmt.Errorf("invalid tunnel request: %w", err)
+		}
+
+		go func() {
+			resp := handleTunnelRequest(ctx, handler, &req)
+			payload, err := json.Marshal(resp)
+			if err != nil {
+				return
+			}
+			_ = conn.WriteMessage(payload)
+		}()
+	}
+}
+
+// handleTunnelRequest runs a single relayed request through handler.
+func handleTunnelRequest(ctx context.Context, handler http.Handler, req *tunnelRequest) *tunnelResponse {
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, req.URI, bytes.NewReader(req.Body))
+	if err != nil {
+		return &tunnelResponse{ID: req.ID, Status: http.StatusBadRequest}
+	}
+	httpReq.RequestURI = req.URI
+	httpReq.RemoteAddr = req.RemoteAddr
+	for k, v := range req.Header {
+		httpReq.Header[k] = v
+	}
+
+	rec := newResponseBuffer()
+	handler.ServeHTTP(rec, httpReq)
+
+	return &tunnelResponse{
+		ID:     req.ID,
+		Status: rec.status,
+		Header: rec.header,
+		Body:   rec.body.Bytes(),
+	}
+}
+
+// RunAgent keeps a tunnel to the broker at brokerURL open, reconnecting with
+// exponential backoff until ctx is cancelled.
+func RunAgent(ctx context.Context, brokerURL, token string, handler http.Handler, log *logger.Logger) {
+	delay := minReconnectDelay
+	for {
+		header := make(http.Header)
+		if token != "" {
+			header.Set("Authorization", "Bearer "+token)
+		}
+
+		dialCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		conn, err := Dial(dialCtx, brokerURL+AgentPath, header)
+		cancel()
+
+		if err == nil {
+			log.Info("Connected to broker", "broker", brokerURL)
+			delay = minReconnectDelay
+
+			stopPing := make(chan struct{})
+			go keepAlive(conn, stopPing)
+			err = ServeTunnel(ctx, conn, handler)
+			close(stopPing)
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		log.Warn("Broker connection lost", "broker", brokerURL, "error", err, "retry_in", delay)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+}
+
+// keepAlive pings conn periodically so idle NAT mappings stay open.
+func keepAlive(conn *Conn, stop <-chan struct{}) {
+	ticker := time.NewTicker(DefaultPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := conn.Ping(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Broker relays client HTTP requests to a connected rcode-server agent.
+type Broker struct {
+	token   string
+	timeout time.Duration
+	log     *logger.Logger
+
+	mu        sync.Mutex
+	agent     *Conn
+	agentAddr string // Remote address of agent
+	nextID    uint64
+	pending   map[uint64]chan *tunnelResponse
+}
+
+// NewBroker creates a broker. Agents must present token as a bearer token
+// when connecting; with an empty token every agent is refused.
+func NewBroker(token string, log *logger.Logger) *Broker {
+	return &Broker{
+		token:   token,
+		timeout: DefaultRequestTimeout,
+		log:     log,
+		pending: make(map[uint64]chan *tunnelResponse),
+	}
+}
+
+// Connected reports whether an agent is currently connected.
+func (b *Broker) Connected() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.agent != nil
+}
+
+// ServeHTTP implements http.Handler.
+func (b *Broker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == AgentPath {
+		b.handleAgent(w, r)
+		return
+	}
+	b.forward(w, r)
+}
+
+// handleAgent accepts an authenticated agent connection. An agent that
+// presents the token replaces the current one, so a reconnecting server is
+// not locked out by a connection the broker has not yet seen drop.
+func (b *Broker) handleAgent(w http.ResponseWriter, r *http.Request) {
+	if err := auth.VerifyRequest(r, b.token); err != nil {
+		b.log.Warn("Rejected agent connection", "error", err, "remote_addr", r.RemoteAddr)
+		respondError(w, api.ErrUnauthorized, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	conn, err := Upgrade(w, r)
+	if err != nil {
+		respondError(w, api.ErrInvalidRequest, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	b.mu.Lock()
+	previous, previousAddr := b.agent, b.agentAddr
+	b.agent, b.agentAddr = conn, r.RemoteAddr
+	b.mu.Unlock()
+
+	if previous != nil {
+		b.log.Warn("Agent replaced by a new connection", "previous_addr", previousAddr, "remote_addr", r.RemoteAddr)
+		_ = previous.Close()
+	}
+
+	b.log.Info("Agent connected", "remote_addr", r.RemoteAddr)
+
+	for {
+		data, err := conn.ReadMessage()
+		if err != nil {
+			break
+		}
+
+		var resp tunnelResponse
+		if err := json.Unmarshal(data, &resp); err != nil {
+			b.log.Warn("Invalid tunnel response", "error", err)
+			continue
+		}
+
+		b.mu.Lock()
+		ch, ok := b.pending[resp.ID]
+		delete(b.pending, resp.ID)
+		b.mu.Unlock()
+
+		if ok {
+			ch <- &resp
+		}
+	}
+
+	b.mu.Lock()
+	if b.agent == conn {
+		b.agent, b.agentAddr = nil, ""
+	}
+	b.mu.Unlock()
+	_ = conn.Close()
+
+	b.log.Info("Agent disconnected", "remote_addr", r.RemoteAddr)
+}
+
+// forward relays a client request to the connected agent.
+func (b *Broker) forward(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBody))
+	if err != nil {
+		respondError(w, api.ErrInvalidRequest, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ch := make(chan *tunnelResponse, 1)
+
+	b.mu.Lock()
+	agent := b.agent
+	if agent == nil {
+		b.mu.Unlock()
+		respondError(w, api.ErrServerDown, http.StatusServiceUnavailable, ErrNoAgent.Error())
+		return
+	}
+	b.nextID++
+	id := b.nextID
+	b.pending[id] = ch
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		delete(b.pending, id)
+		b.mu.Unlock()
+	}()
+
+	payload, err := json.Marshal(&tunnelRequest{
+		ID:         id,
+		Method:     r.Method,
+		URI:        r.URL.RequestURI(),
+		Header:     r.Header,
+		Body:       body,
+		RemoteAddr: r.RemoteAddr,
+	})
+	if err != nil {
+		respondError(w, api.ErrInternalServer, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := agent.WriteMessage(payload); err != nil {
+		respondError(w, api.ErrServerDown, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	timer := time.NewTimer(b.timeout)
+	defer timer.Stop()
+
+	select {
+	case resp := <-ch:
+		for k, v := range resp.Header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(resp.Status)
+		_, _ = w.Write(resp.Body)
+	case <-timer.C:
+		respondError(w, api.ErrTimeout, http.StatusGatewayTimeout, "agent did not respond")
+	case <-r.Context().Done():
+	}
+}
+
+// respondError writes an api.ErrorResponse.
+func respondError(w http.ResponseWriter, err error, status int, details string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(api.NewErrorResponse(err, api.GetErrorCode(err), details))
+}
+
+// responseBuffer is an in-memory http.ResponseWriter.
+type responseBuffer struct {
+	header      http.Header
+	body        bytes.Buffer
+	status      int
+	wroteHeader bool
+}
+
+func newResponseBuffer() *responseBuffer {
+	return &responseBuffer{header: make(http.Header), status: http.StatusOK}
+}
+
+func (rb *responseBuffer) Header() http.Header { return rb.header }
+
+func (rb *responseBuffer) WriteHeader(code int) {
+	if rb.wroteHeader {
+		return
+	}
+	rb.status = code
+	rb.wroteHeader = true
+}
+
+func (rb *responseBuffer) Write(p []byte) (int, error) {
+	rb.wroteHeader = true
+	return rb.body.Write(p)
+}
@@ -0,0 +1,67 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidAddress is returned when a host or host:port address is malformed.
+var ErrInvalidAddress = errors.New("invalid address")
+
+// NormalizeHostPort returns addr as host:port, adding defaultPort when addr
+// has none. IPv6 literals may be given bare ("::1"), bracketed ("[::1]") or
+// with a port ("[::1]:3339") and always come back bracketed. A bare IPv6
+// literal never carries a port: "fe80::1:3339" is an address, not a port.
+func NormalizeHostPort(addr, defaultPort string) (string, error) {
+	if addr == "" {
+		return "", fmt.Errorf("%w: address cannot be empty", ErrInvalidAddress)
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		// No port: a host name, an IPv4 address or an IPv6 literal
+		host = addr
+		if strings.HasPrefix(addr, "[") || strings.HasSuffix(addr, "]") {
+			if !strings.HasPrefix(addr, "[") || !strings.HasSuffix(addr, "]") {
+				return "", fmt.Errorf("%w: unbalanced brackets in %q", ErrInvalidAddress, addr)
+			}
+			host = addr[1 : len(addr)-1]
+			if !isIPv6(host) {
+				return "", fmt.Errorf("%w: only IPv6 addresses may be bracketed: %q", ErrInvalidAddress, addr)
+			}
+		} else if strings.Contains(host, ":") && !isIPv6(host) {
+			return "", fmt.Errorf("%w: %q", ErrInvalidAddress, addr)
+		}
+		port = defaultPort
+	}
+
+	if host == "" {
+		return "", fmt.Errorf("%w: missing host in %q", ErrInvalidAddress, addr)
+	}
+	if strings.ContainsAny(host, "/?#@ ") {
+		return "", fmt.Errorf("%w: %q is not a host (leave out the scheme and path)", ErrInvalidAddress, addr)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return "", fmt.Errorf("%w: invalid port %q in %q", ErrInvalidAddress, port, addr)
+	}
+
+	return net.JoinHostPort(host, port), nil
+}
+
+// ValidateHostPort checks that addr is a host, optionally followed by a port
+func ValidateHostPort(addr string) error {
+	_, err := NormalizeHostPort(addr, "1")
+	return err
+}
+
+// isIPv6 reports whether s is an IPv6 literal, optionally with a zone
+func isIPv6(s string) bool {
+	if i := strings.IndexByte(s, '%'); i > 0 {
+		s = s[:i]
+	}
+	ip := net.ParseIP(s)
+	return ip != nil && strings.Contains(s, ":")
+}
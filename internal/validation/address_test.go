@@ -0,0 +1,50 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizeHostPort(t *testing.T) {
+	tests := []struct {
+		name    string
+		addr    string
+		want    string
+		wantErr bool
+	}{
+		{"host name", "devbox", "devbox:3339", false},
+		{"host name with port", "devbox:8080", "devbox:8080", false},
+		{"IPv4", "192.168.1.100", "192.168.1.100:3339", false},
+		{"IPv4 with port", "192.168.1.100:4000", "192.168.1.100:4000", false},
+		{"bare IPv6", "::1", "[::1]:3339", false},
+		{"bare IPv6 ending in a number", "fe80::1:3339", "[fe80::1:3339]:3339", false},
+		{"bracketed IPv6", "[2001:db8::1]", "[2001:db8::1]:3339", false},
+		{"bracketed IPv6 with port", "[2001:db8::1]:4000", "[2001:db8::1]:4000", false},
+		{"IPv6 with zone", "fe80::1%eth0", "[fe80::1%eth0]:3339", false},
+		{"empty", "", "", true},
+		{"empty port", "devbox:", "", true},
+		{"port out of range", "devbox:70000", "", true},
+		{"non-numeric port", "devbox:http", "", true},
+		{"URL", "http://devbox:3339", "", true},
+		{"path", "devbox/rcode", "", true},
+		{"too many colons", "devbox:1:2", "", true},
+		{"bracketed host name", "[devbox]", "", true},
+		{"unbalanced bracket", "[::1", "", true},
+		{"missing host", ":3339", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeHostPort(tt.addr, "3339")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeHostPort(%q) error = %v, wantErr %v", tt.addr, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidAddress) {
+				t.Errorf("NormalizeHostPort(%q) error = %v, want ErrInvalidAddress", tt.addr, err)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeHostPort(%q) = %q, want %q", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
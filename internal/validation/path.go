@@ -0,0 +1,162 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// ErrInvalidPathPattern is returned when an allowed-path entry is malformed.
+var ErrInvalidPathPattern = errors.New("invalid path pattern")
+
+// ValidatePathPattern validates an allowed-path entry. Entries must be
+// absolute, as Unix paths or Windows paths with a drive letter or UNC
+// prefix, and may contain glob patterns understood by path.Match.
+func ValidatePathPattern(pattern string) error {
+	if pattern == "" {
+		return fmt.Errorf("%w: pattern cannot be empty", ErrInvalidPathPattern)
+	}
+	_, rest, ok := splitVolume(pattern)
+	if !ok {
+		return fmt.Errorf("%w: %s must be absolute (/path, C:\\path or \\\\server\\share)", ErrInvalidPathPattern, pattern)
+	}
+	if _, err := path.Match(rest, "/"); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidPathPattern, pattern, err)
+	}
+	return nil
+}
+
+// PathAllowed reports whether p lies within one of the allowed entries.
+// Plain entries are treated as root directories; entries containing glob
+// characters match p or any of its parent directories. Paths are cleaned
+// before matching, so "../" segments cannot escape a root. Windows paths
+// only match entries on the same drive or UNC share and ignore case. An
+// empty allow list permits every path.
+func PathAllowed(p string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	volume, rest, ok := splitVolume(p)
+	if !ok {
+		return false
+	}
+
+	cleaned := path.Clean(rest)
+	for _, allowedEntry := range allowed {
+		entryVolume, entry, ok := splitVolume(allowedEntry)
+		if !ok || entryVolume != volume {
+			continue
+		}
+		if hasGlob(entry) {
+			if matchesAncestor(entry, cleaned) {
+				return true
+			}
+			continue
+		}
+
+		root := path.Clean(entry)
+		if root == "/" || cleaned == root || strings.HasPrefix(cleaned, root+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesAncestor reports whether pattern matches p or one of its parents.
+func matchesAncestor(pattern, p string) bool {
+	for {
+		if ok, _ := path.Match(pattern, p); ok {
+			return true
+		}
+		if p == "/" {
+			return false
+		}
+		p = path.Dir(p)
+	}
+}
+
+// splitVolume splits an absolute path into its Windows volume ("c:" or
+// "//server/share"; empty for Unix paths) and the slash-separated path from
+// that volume's root. Windows paths are lower-cased, as Windows ignores
+// case. ok is false for a relative path.
+func splitVolume(p string) (volume, rest string, ok bool) {
+	switch {
+	case strings.HasPrefix(p, "/"):
+		return "", p, true
+	case isDrivePath(p):
+		p = strings.ToLower(strings.ReplaceAll(p, `\`, "/"))
+		return p[:2], p[2:], true
+	case strings.HasPrefix(p, `\\`):
+		p = strings.ToLower(strings.ReplaceAll(p, `\`, "/"))
+		parts := strings.SplitN(p[2:], "/", 3)
+		volume = "//" + strings.Join(parts[:min(len(parts), 2)], "/")
+		if len(parts) == 3 {
+			return volume, "/" + parts[2], true
+		}
+		return volume, "/", true
+	}
+	return "", "", false
+}
+
+// isDrivePath reports whether p starts with a drive letter and a separator
+func isDrivePath(p string) bool {
+	if len(p) < 3 || p[1] != ':' || (p[2] != '\\' && p[2] != '/') {
+		return false
+	}
+	c := p[0] | 0x20
+	return c >= 'a' && c <= 'z'
+}
+
+func hasGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// ErrInvalidURLPattern is returned when an allowed-URL entry is malformed.
+var ErrInvalidURLPattern = errors.New("invalid URL pattern")
+
+// ValidateURLPattern validates an allowed-URL entry of the form
+// "scheme://host", where host may contain glob patterns understood by
+// path.Match and an optional ":port" (e.g. "https://*.github.com",
+// "http://localhost:*").
+func ValidateURLPattern(pattern string) error {
+	scheme, host, ok := strings.Cut(pattern, "://")
+	if !ok || scheme == "" || host == "" {
+		return fmt.Errorf("%w: %s must look like scheme://host", ErrInvalidURLPattern, pattern)
+	}
+	if strings.Contains(host, "/") {
+		return fmt.Errorf("%w: %s must not contain a path", ErrInvalidURLPattern, pattern)
+	}
+	if _, err := path.Match(host, ""); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidURLPattern, pattern, err)
+	}
+	return nil
+}
+
+// URLAllowed reports whether u matches one of the allowed "scheme://host"
+// patterns. Schemes compare without regard to case; a host pattern without a
+// port matches the host name alone. An empty allow list permits nothing.
+func URLAllowed(u *url.URL, allowed []string) bool {
+	if u == nil || u.Host == "" {
+		return false
+	}
+
+	for _, entry := range allowed {
+		scheme, host, ok := strings.Cut(entry, "://")
+		if !ok || !strings.EqualFold(scheme, u.Scheme) {
+			continue
+		}
+
+		target := strings.ToLower(u.Hostname())
+		if strings.Contains(host, ":") {
+			target = strings.ToLower(u.Host)
+		}
+		if matched, _ := path.Match(strings.ToLower(host), target); matched {
+			return true
+		}
+	}
+
+	return false
+}
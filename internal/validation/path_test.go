@@ -0,0 +1,140 @@
+package validation
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func TestPathAllowed(t *testing.T) {
+	allowed := []string{"/home/alice/src", "/srv/*/repos"}
+
+	tests := []struct {
+		name    string
+		path    string
+		allowed []string
+		want    bool
+	}{
+		{"empty allow list", "/etc/passwd", nil, true},
+		{"exact root", "/home/alice/src", allowed, true},
+		{"inside root", "/home/alice/src/project/main.go", allowed, true},
+		{"sibling with shared prefix", "/home/alice/src-old", allowed, false},
+		{"outside root", "/etc/passwd", allowed, false},
+		{"traversal out of root", "/home/alice/src/../../bob", allowed, false},
+		{"glob match", "/srv/team/repos", allowed, true},
+		{"inside glob match", "/srv/team/repos/app", allowed, true},
+		{"glob mismatch", "/srv/team/other", allowed, false},
+		{"relative path", "src/project", allowed, false},
+		{"windows inside root", `C:\Users\alice\src\app`, []string{`C:\Users\alice\src`}, true},
+		{"windows ignores case", `c:\users\Alice\SRC`, []string{`C:\Users\alice\src`}, true},
+		{"windows slash pattern", `C:\Users\alice\src\app`, []string{"C:/Users/alice/src"}, true},
+		{"windows traversal out of root", `C:\Users\alice\src\..\..\bob`, []string{`C:\Users\alice\src`}, false},
+		{"windows traversal off drive", `C:\..\..\D:\src`, []string{`D:\src`}, false},
+		{"windows other drive", `D:\Users\alice\src`, []string{`C:\Users\alice\src`}, false},
+		{"windows glob", `C:\srv\team\repos\app`, []string{`C:\srv\*\repos`}, true},
+		{"windows unix root", `C:\Users\alice`, []string{"/"}, false},
+		{"unc inside share", `\\server\share\src\app`, []string{`\\server\share\src`}, true},
+		{"unc other share", `\\server\other\src\app`, []string{`\\server\share\src`}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PathAllowed(tt.path, tt.allowed); got != tt.want {
+				t.Errorf("PathAllowed(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePathPattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		wantErr bool
+	}{
+		{"absolute root", "/home/alice", false},
+		{"glob", "/srv/*/repos", false},
+		{"empty", "", true},
+		{"relative", "home/alice", true},
+		{"windows drive", `C:\Users\alice`, false},
+		{"windows glob", "C:/srv/*/repos", false},
+		{"unc", `\\server\share`, false},
+		{"drive relative", `C:Users\alice`, true},
+		{"bad glob", "/srv/[a", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePathPattern(tt.pattern)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePathPattern(%q) error = %v, wantErr %v", tt.pattern, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidPathPattern) {
+				t.Errorf("error = %v, want ErrInvalidPathPattern", err)
+			}
+		})
+	}
+}
+
+func TestURLAllowed(t *testing.T) {
+	allowed := []string{"https://github.com", "https://*.github.com", "http://localhost:*"}
+
+	tests := []struct {
+		name    string
+		url     string
+		allowed []string
+		want    bool
+	}{
+		{"empty allow list", "https://github.com", nil, false},
+		{"exact host", "https://github.com/foxytanuki/rcode", allowed, true},
+		{"scheme ignores case", "HTTPS://github.com", allowed, true},
+		{"host ignores case", "https://GitHub.com", allowed, true},
+		{"wildcard subdomain", "https://gist.github.com/x", allowed, true},
+		{"wrong scheme", "http://github.com", allowed, false},
+		{"lookalike domain", "https://github.com.evil.example", allowed, false},
+		{"port pattern", "http://localhost:8080/app", allowed, true},
+		{"port required by pattern", "http://localhost/app", allowed, false},
+		{"host pattern without port ignores port", "https://github.com:8443", allowed, true},
+		{"no host", "mailto:someone@example.com", allowed, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatalf("url.Parse(%q) error = %v", tt.url, err)
+			}
+			if got := URLAllowed(u, tt.allowed); got != tt.want {
+				t.Errorf("URLAllowed(%q) = %v, want %v", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateURLPattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		wantErr bool
+	}{
+		{"host", "https://github.com", false},
+		{"glob", "https://*.github.com", false},
+		{"port glob", "http://localhost:*", false},
+		{"missing scheme", "github.com", true},
+		{"empty host", "https://", true},
+		{"path", "https://github.com/foxytanuki", true},
+		{"bad glob", "https://[a", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateURLPattern(tt.pattern)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateURLPattern(%q) error = %v, wantErr %v", tt.pattern, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidURLPattern) {
+				t.Errorf("error = %v, want ErrInvalidURLPattern", err)
+			}
+		})
+	}
+}
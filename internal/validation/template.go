@@ -14,21 +14,54 @@ var (
 	ErrMissingPlaceholder = errors.New("missing required placeholder")
 )
 
-// ValidPlaceholders defines the set of allowed placeholders.
-var ValidPlaceholders = map[string]bool{
-	"{user}": true,
-	"{host}": true,
-	"{path}": true,
+// BuiltinPlaceholders are the placeholders rcode fills in from each request.
+// Any other name is a user-defined variable.
+var BuiltinPlaceholders = map[string]bool{
+	"user":   true,
+	"host":   true,
+	"path":   true,
+	"path2":  true,
+	"port":   true,
+	"line":   true,
+	"column": true,
 }
 
-// ValidateCommandTemplate validates an editor command template for correct placeholders.
-func ValidateCommandTemplate(command string) error {
-	if command == "" {
-		return fmt.Errorf("%w: command cannot be empty", ErrInvalidTemplate)
-	}
+// ValidFilters are the filters a placeholder may apply, mapped to whether
+// the filter escapes its output for a shell (so no further quoting is
+// applied). urlpath keeps characters such as $ and & that a shell reads.
+var ValidFilters = map[string]bool{
+	"urlencode":   true,
+	"urlpath":     false,
+	"shellescape": true,
+	"basename":    false,
+	"dirname":     false,
+}
+
+// Placeholder is a parsed "{name:default|filter|...}" template placeholder.
+type Placeholder struct {
+	Raw        string   // Placeholder as written, braces included
+	Name       string   // Variable name
+	Default    string   // Value used when the variable is empty
+	HasDefault bool     // Whether a default was given, even an empty one
+	Filters    []string // Filters applied in order
+	Quote      rune     // Shell quote the placeholder is written inside: ', " or 0
+}
+
+// TemplatePart is a piece of a template: literal text or a placeholder.
+type TemplatePart struct {
+	Text        string
+	Placeholder *Placeholder
+}
+
+// SplitTemplate splits a template into literal text and placeholders,
+// checking placeholder syntax and filter names. Each placeholder records the
+// shell quotes it is written inside, so its value can be escaped for them; a
+// placeholder may not follow a backslash that would escape its value.
+func SplitTemplate(command string) ([]TemplatePart, error) {
+	var parts []TemplatePart
+	var quote rune
+	var escaped bool
 
-	// Scan for placeholder-like patterns and validate them first
-	// (catches unclosed/unknown before checking required placeholders)
 	start := 0
 	for {
 		idx := strings.Index(command[start:], "{")
@@ -39,27 +72,234 @@ func ValidateCommandTemplate(command string) error {
 
 		end := strings.Index(command[idx+1:], "}")
 		if end == -1 {
-			return fmt.Errorf("%w: unclosed placeholder at position %d", ErrInvalidTemplate, idx)
+			return nil, fmt.Errorf("%w: unclosed placeholder at position %d", ErrInvalidTemplate, idx)
 		}
 		end += idx + 1 + 1
 
 		// Check for nested braces
 		if innerBrace := strings.Index(command[idx+1:end], "{"); innerBrace != -1 {
-			return fmt.Errorf("%w: unclosed placeholder at position %d", ErrInvalidTemplate, idx)
+			return nil, fmt.Errorf("%w: unclosed placeholder at position %d", ErrInvalidTemplate, idx)
 		}
 
-		placeholder := command[idx:end]
-		if !ValidPlaceholders[placeholder] {
-			return fmt.Errorf("%w: unknown placeholder %s", ErrInvalidTemplate, placeholder)
+		placeholder, err := parsePlaceholder(command[idx:end])
+		if err != nil {
+			return nil, err
 		}
 
+		if idx > start {
+			parts = append(parts, TemplatePart{Text: command[start:idx]})
+			quote, escaped = scanQuotes(command[start:idx], quote, escaped)
+		}
+		if escaped {
+			return nil, fmt.Errorf("%w: placeholder %s follows a backslash", ErrInvalidTemplate, placeholder.Raw)
+		}
+		placeholder.Quote = quote
+		parts = append(parts, TemplatePart{Placeholder: placeholder})
 		start = end
 	}
 
+	if start < len(command) {
+		parts = append(parts, TemplatePart{Text: command[start:]})
+	}
+	return parts, nil
+}
+
+// scanQuotes returns the shell quote, if any, and whether a backslash
+// escape is pending after text, given the state before it. It follows the
+// rules of the editor package's SplitCommand.
+func scanQuotes(text string, quote rune, escaped bool) (rune, bool) {
+	for _, r := range text {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			}
+		case r == '\\':
+			escaped = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		}
+	}
+	return quote, escaped
+}
+
+// ParsePlaceholders returns the placeholders of a template in order
+func ParsePlaceholders(command string) ([]Placeholder, error) {
+	parts, err := SplitTemplate(command)
+	if err != nil {
+		return nil, err
+	}
+
+	var placeholders []Placeholder
+	for _, part := range parts {
+		if part.Placeholder != nil {
+			placeholders = append(placeholders, *part.Placeholder)
+		}
+	}
+	return placeholders, nil
+}
+
+// parsePlaceholder parses raw, which includes the surrounding braces
+func parsePlaceholder(raw string) (*Placeholder, error) {
+	body := raw[1 : len(raw)-1]
+	p := &Placeholder{Raw: raw}
+
+	segments := strings.Split(body, "|")
+	name := segments[0]
+	if i := strings.Index(name, ":"); i >= 0 {
+		name, p.Default, p.HasDefault = name[:i], name[i+1:], true
+	}
+	if !validVariableName(name) {
+		return nil, fmt.Errorf("%w: invalid placeholder %s", ErrInvalidTemplate, raw)
+	}
+	p.Name = name
+
+	for _, filter := range segments[1:] {
+		if _, ok := ValidFilters[filter]; !ok {
+			return nil, fmt.Errorf("%w: unknown filter %q in %s", ErrInvalidTemplate, filter, raw)
+		}
+		p.Filters = append(p.Filters, filter)
+	}
+
+	return p, nil
+}
+
+// ValidateVariableName checks the name of a user-defined template variable
+func ValidateVariableName(name string) error {
+	if !validVariableName(name) {
+		return fmt.Errorf("%w: invalid variable name %q (use lower-case letters, digits and _)", ErrInvalidTemplate, name)
+	}
+	// {port} only comes from requests that carry one, so an editor may give
+	// it a value for the others
+	if BuiltinPlaceholders[name] && name != "port" {
+		return fmt.Errorf("%w: variable %s is built in and cannot be redefined", ErrInvalidTemplate, name)
+	}
+	return nil
+}
+
+// validVariableName reports whether name starts with a lower-case letter
+// and holds only lower-case letters, digits and underscores
+func validVariableName(name string) bool {
+	if name == "" || name[0] < 'a' || name[0] > 'z' {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateCommandTemplate validates an editor command template for correct
+// placeholders. Only built-in placeholders and variables with defaults may
+// be used.
+func ValidateCommandTemplate(command string) error {
+	return ValidateTemplate(command, nil)
+}
+
+// ValidateTemplate validates an editor command template whose user-defined
+// variables are given by variables. A variable missing from variables must
+// have a default in the template.
+func ValidateTemplate(command string, variables map[string]string) error {
+	if command == "" {
+		return fmt.Errorf("%w: command cannot be empty", ErrInvalidTemplate)
+	}
+
+	// Validate placeholders first (catches unclosed/unknown before checking
+	// required placeholders)
+	if err := ValidatePlaceholders(command, variables); err != nil {
+		return err
+	}
+
 	// Check for required {path} placeholder
-	if !strings.Contains(command, "{path}") {
+	placeholders, _ := ParsePlaceholders(command)
+	hasPath := false
+	for _, p := range placeholders {
+		if p.Name == "path" {
+			hasPath = true
+		}
+	}
+	if !hasPath {
 		return fmt.Errorf("%w: {path}", ErrMissingPlaceholder)
 	}
 
+	// A TRAMP file name must end in ":{path}", or Emacs reads the path as
+	// part of the host name
+	if token := TrampToken(command); token != "" && !endsWithPath(token) {
+		return fmt.Errorf("%w: TRAMP file name %s must end with :{path}", ErrInvalidTemplate, token)
+	}
+
 	return nil
 }
+
+// ValidateDiffTemplate validates an editor's diff command template, which
+// must contain {path2} for the second file as well as {path}
+func ValidateDiffTemplate(command string, variables map[string]string) error {
+	if err := ValidateTemplate(command, variables); err != nil {
+		return err
+	}
+	placeholders, _ := ParsePlaceholders(command)
+	for _, p := range placeholders {
+		if p.Name == "path2" {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: {path2}", ErrMissingPlaceholder)
+}
+
+// ValidatePlaceholders checks the placeholders of a template that need not
+// contain {path}, such as a working directory
+func ValidatePlaceholders(template string, variables map[string]string) error {
+	placeholders, err := ParsePlaceholders(template)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range placeholders {
+		if BuiltinPlaceholders[p.Name] || p.HasDefault {
+			continue
+		}
+		if _, ok := variables[p.Name]; !ok {
+			return fmt.Errorf("%w: unknown placeholder %s", ErrInvalidTemplate, p.Raw)
+		}
+	}
+	return nil
+}
+
+// endsWithPath reports whether token ends in a {path} placeholder preceded
+// by a colon, such as ":{path}" or ":{path|dirname}"
+func endsWithPath(token string) bool {
+	if !strings.HasSuffix(token, "}") {
+		return false
+	}
+	idx := strings.LastIndex(token, "{")
+	if idx < 1 || token[idx-1] != ':' {
+		return false
+	}
+	p, err := parsePlaceholder(token[idx:])
+	return err == nil && p.Name == "path"
+}
+
+// trampMethods are the Emacs TRAMP connection methods recognised in templates
+var trampMethods = []string{"ssh", "sshx", "scp", "scpx", "rsync"}
+
+// TrampToken returns the whitespace-separated token of command that is an
+// Emacs TRAMP remote file name such as "/ssh:{user}@{host}:{path}", or ""
+// if the command has none.
+func TrampToken(command string) string {
+	for _, field := range strings.Fields(command) {
+		for _, method := range trampMethods {
+			if strings.HasPrefix(field, "/"+method+":") {
+				return field
+			}
+		}
+	}
+	return ""
+}
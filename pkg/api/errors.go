@@ -16,12 +16,16 @@ var (
 	ErrMissingHost    = errors.New("host is required")
 	ErrInvalidEditor  = errors.New("invalid editor specified")
 	ErrInvalidRequest = errors.New("invalid request format")
+	ErrPathNotAllowed = errors.New("path not allowed")
+	ErrPathNotFound   = errors.New("path does not exist")
+	ErrURLNotAllowed  = errors.New("url not allowed")
 
 	// Editor errors
 	ErrEditorNotFound     = errors.New("editor not found")
 	ErrEditorNotAvailable = errors.New("editor not available")
 	ErrNoDefaultEditor    = errors.New("no default editor configured")
 	ErrEditorExecution    = errors.New("failed to execute editor command")
+	ErrEditorExists       = errors.New("editor already exists")
 
 	// Network errors
 	ErrConnectionFailed = errors.New("connection failed")
@@ -33,14 +37,19 @@ var (
 	ErrNotImplemented = errors.New("not implemented")
 	ErrUnauthorized   = errors.New("unauthorized request")
 	ErrRateLimited    = errors.New("rate limit exceeded")
+	ErrDisabled       = errors.New("feature disabled")
+	ErrTooLarge       = errors.New("request too large")
+	ErrShuttingDown   = errors.New("server is shutting down")
+	ErrBusy           = errors.New("server is busy")
 )
 
 // ErrorResponse represents an error response from the API
 type ErrorResponse struct {
-	Message   string `json:"error" yaml:"error"`         // Error message
-	Code      string `json:"code" yaml:"code"`           // Error code for programmatic handling
-	Details   string `json:"details" yaml:"details"`     // Additional error details
-	Timestamp int64  `json:"timestamp" yaml:"timestamp"` // Unix timestamp
+	Message   string `json:"error" yaml:"error"`                               // Error message
+	Code      string `json:"code" yaml:"code"`                                 // Error code for programmatic handling
+	Details   string `json:"details" yaml:"details"`                           // Additional error details
+	Timestamp int64  `json:"timestamp" yaml:"timestamp"`                       // Unix timestamp
+	RequestID string `json:"request_id,omitempty" yaml:"request_id,omitempty"` // ID for correlating client and server logs
 }
 
 // Error implements the error interface
@@ -83,6 +92,14 @@ const (
 	CodeNotImplemented    = "NOT_IMPLEMENTED"
 	CodeUnauthorized      = "UNAUTHORIZED"
 	CodeRateLimited       = "RATE_LIMITED"
+	CodePathNotAllowed    = "PATH_NOT_ALLOWED"
+	CodePathNotFound      = "PATH_NOT_FOUND"
+	CodeURLNotAllowed     = "URL_NOT_ALLOWED"
+	CodeEditorExists      = "EDITOR_EXISTS"
+	CodeDisabled          = "DISABLED"
+	CodeTooLarge          = "TOO_LARGE"
+	CodeShuttingDown      = "SHUTTING_DOWN"
+	CodeBusy              = "BUSY"
 )
 
 // GetErrorCode returns the appropriate error code for a given error
@@ -122,6 +139,22 @@ func GetErrorCode(err error) string {
 		return CodeRateLimited
 	case errors.Is(err, ErrInvalidRequest):
 		return CodeInvalidRequest
+	case errors.Is(err, ErrPathNotAllowed):
+		return CodePathNotAllowed
+	case errors.Is(err, ErrPathNotFound):
+		return CodePathNotFound
+	case errors.Is(err, ErrURLNotAllowed):
+		return CodeURLNotAllowed
+	case errors.Is(err, ErrEditorExists):
+		return CodeEditorExists
+	case errors.Is(err, ErrDisabled):
+		return CodeDisabled
+	case errors.Is(err, ErrTooLarge):
+		return CodeTooLarge
+	case errors.Is(err, ErrShuttingDown):
+		return CodeShuttingDown
+	case errors.Is(err, ErrBusy):
+		return CodeBusy
 	default:
 		return CodeInternalError
 	}
@@ -137,7 +170,13 @@ func IsClientError(err error) bool {
 		errors.Is(err, ErrEditorNotFound) ||
 		errors.Is(err, ErrNoDefaultEditor) ||
 		errors.Is(err, ErrUnauthorized) ||
-		errors.Is(err, ErrRateLimited)
+		errors.Is(err, ErrRateLimited) ||
+		errors.Is(err, ErrPathNotAllowed) ||
+		errors.Is(err, ErrPathNotFound) ||
+		errors.Is(err, ErrURLNotAllowed) ||
+		errors.Is(err, ErrEditorExists) ||
+		errors.Is(err, ErrDisabled) ||
+		errors.Is(err, ErrTooLarge)
 }
 
 // IsServerError returns true if the error is a server error (5xx)
@@ -146,7 +185,9 @@ func IsServerError(err error) bool {
 		errors.Is(err, ErrEditorNotAvailable) ||
 		errors.Is(err, ErrEditorExecution) ||
 		errors.Is(err, ErrNotImplemented) ||
-		errors.Is(err, ErrServerDown)
+		errors.Is(err, ErrServerDown) ||
+		errors.Is(err, ErrShuttingDown) ||
+		errors.Is(err, ErrBusy)
 }
 
 // IsNetworkError returns true if the error is network-related
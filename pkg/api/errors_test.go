@@ -89,6 +89,14 @@ func TestGetErrorCode(t *testing.T) {
 		{"unauthorized", ErrUnauthorized, CodeUnauthorized},
 		{"rate limited", ErrRateLimited, CodeRateLimited},
 		{"invalid request", ErrInvalidRequest, CodeInvalidRequest},
+		{"path not allowed", ErrPathNotAllowed, CodePathNotAllowed},
+		{"path not found", ErrPathNotFound, CodePathNotFound},
+		{"url not allowed", ErrURLNotAllowed, CodeURLNotAllowed},
+		{"editor exists", ErrEditorExists, CodeEditorExists},
+		{"disabled", ErrDisabled, CodeDisabled},
+		{"too large", ErrTooLarge, CodeTooLarge},
+		{"shutting down", ErrShuttingDown, CodeShuttingDown},
+		{"busy", ErrBusy, CodeBusy},
 		{"unknown error", errors.New("unknown"), CodeInternalError},
 	}
 
@@ -116,6 +124,11 @@ func TestIsClientError(t *testing.T) {
 		{"no default editor", ErrNoDefaultEditor, true},
 		{"unauthorized", ErrUnauthorized, true},
 		{"rate limited", ErrRateLimited, true},
+		{"path not allowed", ErrPathNotAllowed, true},
+		{"path not found", ErrPathNotFound, true},
+		{"url not allowed", ErrURLNotAllowed, true},
+		{"disabled", ErrDisabled, true},
+		{"too large", ErrTooLarge, true},
 		{"internal server error", ErrInternalServer, false},
 		{"connection failed", ErrConnectionFailed, false},
 		{"timeout", ErrTimeout, false},
@@ -141,6 +154,8 @@ func TestIsServerError(t *testing.T) {
 		{"editor execution", ErrEditorExecution, true},
 		{"not implemented", ErrNotImplemented, true},
 		{"server down", ErrServerDown, true},
+		{"shutting down", ErrShuttingDown, true},
+		{"busy", ErrBusy, true},
 		{"invalid path", ErrInvalidPath, false},
 		{"connection failed", ErrConnectionFailed, false},
 		{"timeout", ErrTimeout, false},
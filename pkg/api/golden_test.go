@@ -0,0 +1,102 @@
+//nolint:revive // package name "api" is intentional for internal testing
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// update rewrites the golden files from the current types: go test ./pkg/api -update
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// goldenTime is the time the golden messages are stamped with
+var goldenTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// goldenMessages returns the messages kept in testdata, by file name
+func goldenMessages(t *testing.T) map[string]any {
+	t.Helper()
+	original := timeNow
+	timeNow = func() time.Time { return goldenTime }
+	t.Cleanup(func() { timeNow = original })
+
+	single := NewOpenRequest([]string{"/home/alice/project"}, "cursor", "alice", "devbox")
+	single.Line, single.Column = 12, 4
+
+	multi := NewOpenRequest([]string{"/home/alice/a.go", "/home/alice/b.go"}, "", "alice", "devbox")
+
+	opened := NewOpenResponse("cursor", "cursor --remote ssh-remote+alice@devbox /home/alice/project", "Opened /home/alice/project in cursor")
+	opened.Execution = &ExecutionInfo{Outcome: "detached", Attempts: 1, DurationMs: 3}
+	opened.RequestID = "0123456789abcdef"
+
+	return map[string]any{
+		"open_request.json":       &single,
+		"open_request_paths.json": &multi,
+		"open_response.json":      opened,
+		"error_response.json":     NewErrorResponse(ErrEditorNotFound, CodeEditorNotFound, "zed"),
+	}
+}
+
+// TestGoldenWireFormat checks that the messages marshal to the JSON in
+// testdata, so a change to the wire format shows up as a diff there
+func TestGoldenWireFormat(t *testing.T) {
+	for name, msg := range goldenMessages(t) {
+		t.Run(name, func(t *testing.T) {
+			got, err := json.MarshalIndent(msg, "", "  ")
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			got = append(got, '\n')
+
+			path := filepath.Join("testdata", name)
+			if *update {
+				if err := os.WriteFile(path, got, 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read golden file (run with -update to create it): %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s changed:\ngot:\n%s\nwant:\n%s", path, got, want)
+			}
+
+			// Decoding the golden file gives the message back
+			decoded := reflect.New(reflect.TypeOf(msg).Elem()).Interface()
+			if err := json.Unmarshal(want, decoded); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(decoded, msg) {
+				t.Errorf("Unmarshal(%s) = %+v, want %+v", path, decoded, msg)
+			}
+		})
+	}
+}
+
+// TestGoldenLegacyRequest checks that a request from a client that
+// predates the protocol field still decodes and validates
+func TestGoldenLegacyRequest(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "open_request_legacy.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var req OpenRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if err := req.Validate(); err != nil {
+		t.Errorf("Validate() error = %v, want nil", err)
+	}
+	if req.Protocol != ProtocolUnknown {
+		t.Errorf("Protocol = %d, want %d", req.Protocol, ProtocolUnknown)
+	}
+	if got := req.AllPaths(); !reflect.DeepEqual(got, []string{"/home/user/project"}) {
+		t.Errorf("AllPaths() = %v, want [/home/user/project]", got)
+	}
+}
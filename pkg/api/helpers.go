@@ -1,7 +1,56 @@
 //nolint:revive // package name "api" is conventional for API type definitions
 package api
 
-import "time"
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
 
 // timeNow is a variable that can be overridden in tests
 var timeNow = time.Now
+
+// maxRequestIDLength bounds a request ID taken from a header
+const maxRequestIDLength = 64
+
+// NewRequestID returns a random 16-character hex request ID
+func NewRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(timeNow().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
+// NewIdempotencyKey returns a random 32-character hex idempotency key
+func NewIdempotencyKey() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(timeNow().UnixNano(), 36) + NewRequestID()
+	}
+	return hex.EncodeToString(b)
+}
+
+// ValidIdempotencyKey reports whether key is well formed, by the same rules
+// as a request ID
+func ValidIdempotencyKey(key string) bool {
+	return ValidRequestID(key)
+}
+
+// ValidRequestID reports whether id is safe to log and echo: up to 64
+// letters, digits, dots, dashes and underscores
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '.', c == '-', c == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+//nolint:revive // package name "api" is conventional for API type definitions
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// HeaderProtocol carries the API protocol version. The client sends the
+// version it speaks and the server answers with its own on every response.
+const HeaderProtocol = "X-RCode-Protocol"
+
+// ProtocolVersion is the API protocol version this build speaks. Bump it
+// whenever a request or response changes in a way the other side must know
+// about, such as a new request field or endpoint.
+//
+//	1: X-RCode-Protocol header, /healthz and /readyz, HealthResponse.protocol
+//	2: protocol field in open requests and responses
+//	3: create field in open requests, path_info and created in open responses
+const ProtocolVersion = 3
+
+// MinProtocolVersion is the oldest protocol this build is fully compatible
+// with. Peers below it still work for the basics but miss newer features.
+const MinProtocolVersion = 1
+
+// ProtocolUnknown is the protocol of a peer that predates version
+// negotiation and sends no X-RCode-Protocol header
+const ProtocolUnknown = 0
+
+// ProtocolFromHeader returns the protocol version in h, or ProtocolUnknown
+// when the header is missing or malformed
+func ProtocolFromHeader(h http.Header) int {
+	v, err := strconv.Atoi(h.Get(HeaderProtocol))
+	if err != nil || v < 0 {
+		return ProtocolUnknown
+	}
+	return v
+}
+
+// SetProtocolHeader sets this build's protocol version on h
+func SetProtocolHeader(h http.Header) {
+	h.Set(HeaderProtocol, strconv.Itoa(ProtocolVersion))
+}
+
+// ProtocolWarning describes how a server speaking protocol server differs
+// from this build, or returns "" when the two are compatible
+func ProtocolWarning(server int) string {
+	switch {
+	case server == ProtocolUnknown:
+		return "server predates protocol version negotiation; upgrade it for newer features"
+	case server < MinProtocolVersion:
+		return fmt.Sprintf("server speaks protocol %d, older than the supported %d-%d; upgrade it for newer features",
+			server, MinProtocolVersion, ProtocolVersion)
+	case server > ProtocolVersion:
+		return fmt.Sprintf("server speaks protocol %d, newer than this client's %d; upgrade the client for newer features",
+			server, ProtocolVersion)
+	}
+	return ""
+}
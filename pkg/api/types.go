@@ -2,67 +2,354 @@
 package api
 
 import (
+	"fmt"
+	"strings"
 	"time"
+
+	"github.com/foxytanuki/rcode/internal/pathcheck"
+)
+
+// HeaderRequestID carries the ID that correlates a request in client and
+// server logs. The client sets it; the server echoes it, generating one when
+// the request has none.
+const HeaderRequestID = "X-Request-ID"
+
+// HeaderIdempotencyKey carries a key the client picks for an open request
+// and sends with every retry of it. The server answers a retry of a request
+// that already launched the editor with the original response instead of
+// launching it again.
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+// HeaderIdempotentReplayed is set to "true" on a response that repeats the
+// original response to an earlier request with the same Idempotency-Key
+const HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+// HeaderSignature carries the HMAC-SHA256 signature of a signed request, as
+// "v2=" and the hex digest, HeaderSignatureTimestamp the Unix time in
+// seconds it was signed at and HeaderSignatureNonce a random value unique to
+// the request. Servers with signing keys reject POST requests that are
+// unsigned, signed with an unknown key, signed too long ago or whose nonce
+// was already used.
+const (
+	HeaderSignature          = "X-RCode-Signature"
+	HeaderSignatureTimestamp = "X-RCode-Timestamp"
+	HeaderSignatureNonce     = "X-RCode-Nonce"
 )
 
 // OpenRequest represents a request to open a file/directory in an editor
 type OpenRequest struct {
-	Path      string `json:"path" yaml:"path"`           // Path to open
-	Editor    string `json:"editor" yaml:"editor"`       // Editor to use (optional, uses default if empty)
-	User      string `json:"user" yaml:"user"`           // SSH username
-	Host      string `json:"host" yaml:"host"`           // Remote hostname
-	Timestamp int64  `json:"timestamp" yaml:"timestamp"` // Unix timestamp
+	Path      string   `json:"path" yaml:"path"`                         // Path to open
+	Paths     []string `json:"paths,omitempty" yaml:"paths,omitempty"`   // All paths to open (optional, overrides Path)
+	Editor    string   `json:"editor" yaml:"editor"`                     // Editor to use (optional, uses default if empty)
+	User      string   `json:"user" yaml:"user"`                         // SSH username
+	Host      string   `json:"host" yaml:"host"`                         // Remote hostname
+	Line      int      `json:"line,omitempty" yaml:"line,omitempty"`     // Line to jump to (optional, 1-based)
+	Column    int      `json:"column,omitempty" yaml:"column,omitempty"` // Column to jump to (optional, 1-based)
+	Timestamp int64    `json:"timestamp" yaml:"timestamp"`               // Unix timestamp
+
+	Terminal bool   `json:"terminal,omitempty" yaml:"terminal,omitempty"` // Open a terminal at Path instead of an editor; Editor then names the terminal
+	Path2    string `json:"path2,omitempty" yaml:"path2,omitempty"`       // Compare Path with this file using the editor's diff command
+	Port     int    `json:"port,omitempty" yaml:"port,omitempty"`         // Port of a web IDE on the remote machine, for templates using {port}
+	Protocol int    `json:"protocol,omitempty" yaml:"protocol,omitempty"` // Protocol version the client speaks (0 for clients that predate it)
+	Create   string `json:"create,omitempty" yaml:"create,omitempty"`     // Create missing paths before opening them: CreateFile or CreateDir
 }
 
+// Kinds of path an open request's Create makes
+const (
+	CreateFile = "file" // An empty file, with any missing parent directories
+	CreateDir  = "dir"  // A directory, with any missing parents
+)
+
 // OpenResponse represents the response from an open editor request
 type OpenResponse struct {
-	Success   bool   `json:"success" yaml:"success"`     // Whether the operation succeeded
-	Message   string `json:"message" yaml:"message"`     // Success or error message
-	Editor    string `json:"editor" yaml:"editor"`       // Editor that was used
-	Command   string `json:"command" yaml:"command"`     // Command that was executed
-	Timestamp int64  `json:"timestamp" yaml:"timestamp"` // Unix timestamp
+	Success      bool           `json:"success" yaml:"success"`                                 // Whether the operation succeeded
+	Message      string         `json:"message" yaml:"message"`                                 // Success or error message
+	Editor       string         `json:"editor" yaml:"editor"`                                   // Editor that was used
+	Command      string         `json:"command" yaml:"command"`                                 // Command that was executed
+	Execution    *ExecutionInfo `json:"execution,omitempty" yaml:"execution,omitempty"`         // How the command ran (command editors)
+	FallbackFrom string         `json:"fallback_from,omitempty" yaml:"fallback_from,omitempty"` // Requested editor that failed, when a fallback editor was used
+	Coalesced    bool           `json:"coalesced,omitempty" yaml:"coalesced,omitempty"`         // Answered by an identical request's launch instead of a new one
+	RequestID    string         `json:"request_id,omitempty" yaml:"request_id,omitempty"`       // ID for correlating client and server logs
+	Timestamp    int64          `json:"timestamp" yaml:"timestamp"`                             // Unix timestamp
+	Protocol     int            `json:"protocol,omitempty" yaml:"protocol,omitempty"`           // Protocol version the server speaks
+	PathInfo     []PathInfo     `json:"path_info,omitempty" yaml:"path_info,omitempty"`         // What the paths name on the host, when the server checks them
+	Created      []string       `json:"created,omitempty" yaml:"created,omitempty"`             // Paths the server created for the request's Create
+}
+
+// PathInfo describes what a requested path names on the server's machine
+type PathInfo struct {
+	Path   string `json:"path" yaml:"path"`                         // Requested path
+	Exists bool   `json:"exists" yaml:"exists"`                     // Whether the path exists
+	IsDir  bool   `json:"is_dir" yaml:"is_dir"`                     // Whether it is a directory
+	Target string `json:"target,omitempty" yaml:"target,omitempty"` // Where symlinks in the path lead, when anywhere else
+}
+
+// ExecutionInfo reports the outcome of running an editor command
+type ExecutionInfo struct {
+	Outcome    string `json:"outcome" yaml:"outcome"`         // detached, running or exited
+	Attempts   int    `json:"attempts" yaml:"attempts"`       // Number of attempts made
+	DurationMs int64  `json:"duration_ms" yaml:"duration_ms"` // Time spent in the final attempt
+}
+
+// RenderResponse represents the response from the /render endpoint
+type RenderResponse struct {
+	Editor    string `json:"editor" yaml:"editor"`                       // Editor that would be used
+	Type      string `json:"type" yaml:"type"`                           // Editor type (command, browser or tmux)
+	Command   string `json:"command" yaml:"command"`                     // Rendered command, or URL for browser editors
+	WorkDir   string `json:"workdir,omitempty" yaml:"workdir,omitempty"` // Working directory the command would run in
+	Available bool   `json:"available" yaml:"available"`                 // Whether the editor is available on the host
+	Timestamp int64  `json:"timestamp" yaml:"timestamp"`                 // Unix timestamp
+}
+
+// EditorPreviewResponse represents the response from the
+// /editors/{name}/preview endpoint: what the editor would run for a sample
+// request, and the problems that would keep an open from working
+type EditorPreviewResponse struct {
+	RenderResponse `yaml:",inline"`
+	Issues         []string `json:"issues" yaml:"issues"` // Problems found, empty when none
 }
 
 // EditorInfo represents information about an available editor
 type EditorInfo struct {
-	Name      string `json:"name" yaml:"name"`           // Editor name (e.g., "cursor", "vscode")
-	Type      string `json:"type" yaml:"type"`           // Editor type: command or browser
-	Command   string `json:"command" yaml:"command"`     // Command template (command editors)
-	URL       string `json:"url" yaml:"url"`             // URL template (browser editors)
-	Available bool   `json:"available" yaml:"available"` // Whether the editor is available
-	Default   bool   `json:"default" yaml:"default"`     // Whether this is the default editor
+	Name      string   `json:"name" yaml:"name"`                           // Editor name (e.g., "cursor", "vscode")
+	Type      string   `json:"type" yaml:"type"`                           // Editor type: command, browser or tmux
+	Command   string   `json:"command" yaml:"command"`                     // Command template (command editors)
+	URL       string   `json:"url" yaml:"url"`                             // URL template (browser editors)
+	Available bool     `json:"available" yaml:"available"`                 // Whether the editor is available
+	Default   bool     `json:"default" yaml:"default"`                     // Whether this is the default editor
+	Aliases   []string `json:"aliases,omitempty" yaml:"aliases,omitempty"` // Other names the editor answers to
+
+	Variables    map[string]string `json:"variables,omitempty" yaml:"variables,omitempty"`         // Values of user-defined template placeholders
+	AvailableVia string            `json:"available_via,omitempty" yaml:"available_via,omitempty"` // How the server found the editor: path, check, or an app, file, flatpak or snap
+	RequiresHost bool              `json:"requires_host" yaml:"requires_host"`                     // Template uses {host}: the editor opens the remote path
+	RequiresUser bool              `json:"requires_user" yaml:"requires_user"`                     // Template uses {user}
+	Diff         string            `json:"diff,omitempty" yaml:"diff,omitempty"`                   // Diff command template, when the editor can compare files
+	Sync         bool              `json:"sync,omitempty" yaml:"sync,omitempty"`                   // Opens a local copy of the file, pushing saves back
+}
+
+// EditorRequest is the body of POST and PUT requests to /admin/editors
+type EditorRequest struct {
+	Name    string `json:"name" yaml:"name"`                           // Editor name (taken from the URL for PUT)
+	Type    string `json:"type,omitempty" yaml:"type,omitempty"`       // Editor type: command (default), browser or tmux
+	Command string `json:"command,omitempty" yaml:"command,omitempty"` // Command template (command editors)
+	URL     string `json:"url,omitempty" yaml:"url,omitempty"`         // URL template (browser editors)
+	Default bool   `json:"default" yaml:"default"`                     // Make this the default editor
 }
 
 // EditorsResponse represents the response from the /editors endpoint
 type EditorsResponse struct {
 	Editors       []EditorInfo `json:"editors" yaml:"editors"`               // List of available editors
 	DefaultEditor string       `json:"default_editor" yaml:"default_editor"` // Name of the default editor
+	Total         int          `json:"total" yaml:"total"`                   // Editors matching the filters, before limit and offset
 	Timestamp     int64        `json:"timestamp" yaml:"timestamp"`           // Unix timestamp
 }
 
-// HealthResponse represents the response from the /health endpoint
+// SessionInfo describes a recently opened path
+type SessionInfo struct {
+	Path     string    `json:"path" yaml:"path"`                       // Path that was opened
+	Paths    []string  `json:"paths,omitempty" yaml:"paths,omitempty"` // All paths, for multi-path opens
+	Editor   string    `json:"editor" yaml:"editor"`                   // Editor used
+	User     string    `json:"user" yaml:"user"`                       // SSH username
+	Host     string    `json:"host" yaml:"host"`                       // Remote hostname
+	OpenedAt time.Time `json:"opened_at" yaml:"opened_at"`             // When the path was opened
+}
+
+// SessionsResponse represents the response from the /sessions endpoint
+type SessionsResponse struct {
+	Sessions  []SessionInfo `json:"sessions" yaml:"sessions"`   // Recent sessions, most recent first
+	Timestamp int64         `json:"timestamp" yaml:"timestamp"` // Unix timestamp
+}
+
+// OpenURLRequest is the body of POST /open-url
+type OpenURLRequest struct {
+	URL string `json:"url" yaml:"url"` // URL to open in the host browser
+}
+
+// OpenURLResponse represents the response from the /open-url endpoint
+type OpenURLResponse struct {
+	Success   bool   `json:"success" yaml:"success"`     // Whether the browser was launched
+	URL       string `json:"url" yaml:"url"`             // URL that was opened
+	Timestamp int64  `json:"timestamp" yaml:"timestamp"` // Unix timestamp
+}
+
+// ClipboardResponse represents the response from the /clipboard endpoint
+type ClipboardResponse struct {
+	Success   bool  `json:"success" yaml:"success"`     // Whether the clipboard was written
+	Bytes     int   `json:"bytes" yaml:"bytes"`         // Number of bytes copied
+	Timestamp int64 `json:"timestamp" yaml:"timestamp"` // Unix timestamp
+}
+
+// ShutdownResponse represents the response from POST /admin/shutdown
+type ShutdownResponse struct {
+	Success   bool  `json:"success" yaml:"success"`     // Whether the shutdown was started
+	InFlight  int   `json:"in_flight" yaml:"in_flight"` // Editor launches being drained
+	Timestamp int64 `json:"timestamp" yaml:"timestamp"` // Unix timestamp
+}
+
+// Event types streamed from the /events endpoint
+const (
+	EventOpen       = "open"        // An editor was launched
+	EventOpenFailed = "open_failed" // Launching an editor failed
+)
+
+// Event is a structured server event streamed from the /events endpoint
+type Event struct {
+	Type      string   `json:"type" yaml:"type"`                       // Event type (open, open_failed)
+	Path      string   `json:"path" yaml:"path"`                       // Path that was opened
+	Paths     []string `json:"paths,omitempty" yaml:"paths,omitempty"` // All paths, for multi-path requests
+	Editor    string   `json:"editor" yaml:"editor"`                   // Editor name
+	User      string   `json:"user" yaml:"user"`                       // SSH username
+	Host      string   `json:"host" yaml:"host"`                       // Remote hostname
+	Error     string   `json:"error,omitempty" yaml:"error,omitempty"` // Failure reason (open_failed only)
+	Timestamp int64    `json:"timestamp" yaml:"timestamp"`             // Unix timestamp
+}
+
+// Health statuses reported by /health, /healthz and /readyz
+const (
+	StatusHealthy   = "healthy"
+	StatusUnhealthy = "unhealthy"
+)
+
+// HealthResponse represents the response from the /health, /healthz
+// (liveness) and /readyz (readiness) endpoints
 type HealthResponse struct {
-	Status    string    `json:"status" yaml:"status"`         // "healthy" or "unhealthy"
-	Version   string    `json:"version" yaml:"version"`       // Server version
-	Uptime    int64     `json:"uptime" yaml:"uptime"`         // Uptime in seconds
-	Timestamp int64     `json:"timestamp" yaml:"timestamp"`   // Unix timestamp
-	StartedAt time.Time `json:"started_at" yaml:"started_at"` // Server start time
+	Status     string            `json:"status" yaml:"status"`                             // "healthy" or "unhealthy"
+	Version    string            `json:"version" yaml:"version"`                           // Server version
+	Uptime     int64             `json:"uptime" yaml:"uptime"`                             // Uptime in seconds
+	Timestamp  int64             `json:"timestamp" yaml:"timestamp"`                       // Unix timestamp
+	StartedAt  time.Time         `json:"started_at" yaml:"started_at"`                     // Server start time
+	Protocol   int               `json:"protocol,omitempty" yaml:"protocol,omitempty"`     // API protocol version (0 from servers that predate it)
+	Components []ComponentStatus `json:"components,omitempty" yaml:"components,omitempty"` // Readiness checks (/readyz only)
+}
+
+// ComponentStatus is the result of one readiness check
+type ComponentStatus struct {
+	Name    string `json:"name" yaml:"name"`                           // Component checked, e.g. "editors"
+	Healthy bool   `json:"healthy" yaml:"healthy"`                     // Whether the check passed
+	Message string `json:"message,omitempty" yaml:"message,omitempty"` // What was found
+}
+
+// NewOpenRequest returns a request to open paths, stamped with the current
+// time and this build's protocol version. Path carries the first entry so
+// servers that predate multi-path support still open something useful.
+func NewOpenRequest(paths []string, editor, user, host string) OpenRequest {
+	req := OpenRequest{
+		Editor:    editor,
+		User:      user,
+		Host:      host,
+		Timestamp: timeNow().Unix(),
+		Protocol:  ProtocolVersion,
+	}
+	if len(paths) > 0 {
+		req.Path = paths[0]
+	}
+	if len(paths) > 1 {
+		req.Paths = paths
+	}
+	return req
 }
 
-// Validate validates an OpenRequest
+// NewOpenResponse returns a successful response for a launch of editor
+// that ran command, stamped with the current time and this build's
+// protocol version
+func NewOpenResponse(editor, command, message string) *OpenResponse {
+	return &OpenResponse{
+		Success:   true,
+		Message:   message,
+		Editor:    editor,
+		Command:   command,
+		Timestamp: timeNow().Unix(),
+		Protocol:  ProtocolVersion,
+	}
+}
+
+// Validate checks the fields of an OpenRequest. The client validates a
+// request before sending it and the server again on receipt.
 func (r *OpenRequest) Validate() error {
-	if r.Path == "" {
+	if r.Path == "" && len(r.Paths) == 0 {
 		return ErrInvalidPath
 	}
+	for _, p := range r.Paths {
+		if p == "" {
+			return ErrInvalidPath
+		}
+	}
 	if r.User == "" {
 		return ErrMissingUser
 	}
 	if r.Host == "" {
 		return ErrMissingHost
 	}
+	// User and host name the sync directory of the remote, so neither may
+	// step out of it.
+	if !isPathComponent(r.User) || !isPathComponent(r.Host) {
+		return fmt.Errorf("%w: user and host must not contain path separators or ..", ErrInvalidRequest)
+	}
+	if r.Line < 0 || r.Column < 0 {
+		return fmt.Errorf("%w: line and column must not be negative", ErrInvalidRequest)
+	}
+	if r.Terminal && len(r.Paths) > 1 {
+		return fmt.Errorf("%w: a terminal opens a single path", ErrInvalidRequest)
+	}
+	if r.Path2 != "" && (r.Terminal || len(r.Paths) > 1) {
+		return fmt.Errorf("%w: path2 compares two single files", ErrInvalidRequest)
+	}
+	if r.Port < 0 || r.Port > 65535 {
+		return fmt.Errorf("%w: port must be between 1 and 65535", ErrInvalidRequest)
+	}
+	if r.Protocol < 0 {
+		return fmt.Errorf("%w: protocol must not be negative", ErrInvalidRequest)
+	}
+	switch r.Create {
+	case "", CreateFile, CreateDir:
+	default:
+		return fmt.Errorf("%w: create must be %s or %s", ErrInvalidRequest, CreateFile, CreateDir)
+	}
 	return nil
 }
 
+// CleanPaths normalizes the paths of the request with pathcheck.Clean. It
+// returns an error wrapping ErrInvalidPath for a relative path or one with
+// a control character.
+func (r *OpenRequest) CleanPaths() error {
+	for _, p := range []*string{&r.Path, &r.Path2} {
+		if *p == "" {
+			continue
+		}
+		cleaned, err := pathcheck.Clean(*p)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidPath, err)
+		}
+		*p = cleaned
+	}
+	if len(r.Paths) == 0 {
+		return nil
+	}
+	// A new slice, since Paths may be the caller's
+	paths := make([]string, len(r.Paths))
+	for i, p := range r.Paths {
+		cleaned, err := pathcheck.Clean(p)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidPath, err)
+		}
+		paths[i] = cleaned
+	}
+	r.Paths = paths
+	return nil
+}
+
+// AllPaths returns every path to open. Paths takes precedence over Path so
+// older clients that only send Path keep working.
+func (r *OpenRequest) AllPaths() []string {
+	if len(r.Paths) > 0 {
+		return r.Paths
+	}
+	if r.Path == "" {
+		return nil
+	}
+	return []string{r.Path}
+}
+
 // SetTimestamp sets the current timestamp on the request
 func (r *OpenRequest) SetTimestamp() {
 	r.Timestamp = time.Now().Unix()
@@ -73,6 +360,16 @@ func (r *OpenResponse) SetTimestamp() {
 	r.Timestamp = time.Now().Unix()
 }
 
+// SetTimestamp sets the current timestamp on the response
+func (r *ShutdownResponse) SetTimestamp() {
+	r.Timestamp = time.Now().Unix()
+}
+
+// SetTimestamp sets the current timestamp on the response
+func (r *RenderResponse) SetTimestamp() {
+	r.Timestamp = time.Now().Unix()
+}
+
 // SetTimestamp sets the current timestamp on the response
 func (r *EditorsResponse) SetTimestamp() {
 	r.Timestamp = time.Now().Unix()
@@ -85,5 +382,30 @@ func (r *HealthResponse) SetTimestamp() {
 
 // IsHealthy returns true if the status is healthy
 func (r *HealthResponse) IsHealthy() bool {
-	return r.Status == "healthy"
+	return r.Status == StatusHealthy
+}
+
+// SetTimestamp sets the current timestamp on the response
+func (r *SessionsResponse) SetTimestamp() {
+	r.Timestamp = time.Now().Unix()
+}
+
+// SetTimestamp sets the current timestamp on the response
+func (r *OpenURLResponse) SetTimestamp() {
+	r.Timestamp = time.Now().Unix()
+}
+
+// SetTimestamp sets the current timestamp on the response
+func (r *ClipboardResponse) SetTimestamp() {
+	r.Timestamp = time.Now().Unix()
+}
+
+// SetTimestamp sets the current timestamp on the event
+func (e *Event) SetTimestamp() {
+	e.Timestamp = time.Now().Unix()
+}
+
+// isPathComponent reports whether s can be used as a single path element.
+func isPathComponent(s string) bool {
+	return !strings.ContainsAny(s, `/\`) && !strings.Contains(s, "..")
 }
@@ -2,6 +2,9 @@
 package api
 
 import (
+	"errors"
+	"net/http"
+	"strings"
 	"testing"
 	"time"
 )
@@ -48,6 +51,33 @@ func TestOpenRequest_Validate(t *testing.T) {
 			},
 			wantErr: ErrMissingHost,
 		},
+		{
+			name: "host escaping the sync directory",
+			request: OpenRequest{
+				Path: "/home/user/project",
+				User: "testuser",
+				Host: "x/../../../home/alice",
+			},
+			wantErr: ErrInvalidRequest,
+		},
+		{
+			name: "user with backslash",
+			request: OpenRequest{
+				Path: "/home/user/project",
+				User: `dom\user`,
+				Host: "remote.example.com",
+			},
+			wantErr: ErrInvalidRequest,
+		},
+		{
+			name: "user with dot dot",
+			request: OpenRequest{
+				Path: "/home/user/project",
+				User: "..",
+				Host: "remote.example.com",
+			},
+			wantErr: ErrInvalidRequest,
+		},
 		{
 			name: "with optional editor",
 			request: OpenRequest{
@@ -58,18 +88,121 @@ func TestOpenRequest_Validate(t *testing.T) {
 			},
 			wantErr: nil,
 		},
+		{
+			name: "with line and column",
+			request: OpenRequest{
+				Path:   "/home/user/project/main.go",
+				User:   "testuser",
+				Host:   "remote.example.com",
+				Line:   120,
+				Column: 5,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "paths without path",
+			request: OpenRequest{
+				Paths: []string{"/home/user/a", "/home/user/b"},
+				User:  "testuser",
+				Host:  "remote.example.com",
+			},
+			wantErr: nil,
+		},
+		{
+			name: "empty entry in paths",
+			request: OpenRequest{
+				Paths: []string{"/home/user/a", ""},
+				User:  "testuser",
+				Host:  "remote.example.com",
+			},
+			wantErr: ErrInvalidPath,
+		},
+		{
+			name: "negative line",
+			request: OpenRequest{
+				Path: "/home/user/project/main.go",
+				User: "testuser",
+				Host: "remote.example.com",
+				Line: -1,
+			},
+			wantErr: ErrInvalidRequest,
+		},
+		{
+			name: "negative protocol",
+			request: OpenRequest{
+				Path:     "/home/user/project",
+				User:     "testuser",
+				Host:     "remote.example.com",
+				Protocol: -1,
+			},
+			wantErr: ErrInvalidRequest,
+		},
+		{
+			name: "create directory",
+			request: OpenRequest{
+				Path:   "/home/user/project/new",
+				User:   "testuser",
+				Host:   "remote.example.com",
+				Create: CreateDir,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "unknown create kind",
+			request: OpenRequest{
+				Path:   "/home/user/project/new",
+				User:   "testuser",
+				Host:   "remote.example.com",
+				Create: "symlink",
+			},
+			wantErr: ErrInvalidRequest,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			err := tt.request.Validate()
-			if err != tt.wantErr {
+			if !errors.Is(err, tt.wantErr) {
 				t.Errorf("OpenRequest.Validate() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
 
+func TestNewOpenRequest(t *testing.T) {
+	before := time.Now().Unix()
+	req := NewOpenRequest([]string{"/a", "/b"}, "vim", "user", "host")
+	after := time.Now().Unix()
+
+	if req.Path != "/a" || len(req.Paths) != 2 {
+		t.Errorf("NewOpenRequest() Path = %q, Paths = %v, want /a and both paths", req.Path, req.Paths)
+	}
+	if req.Protocol != ProtocolVersion {
+		t.Errorf("NewOpenRequest() Protocol = %d, want %d", req.Protocol, ProtocolVersion)
+	}
+	if req.Timestamp < before || req.Timestamp > after {
+		t.Errorf("NewOpenRequest() timestamp = %v, want between %v and %v", req.Timestamp, before, after)
+	}
+	if err := req.Validate(); err != nil {
+		t.Errorf("Validate() error = %v, want nil", err)
+	}
+
+	single := NewOpenRequest([]string{"/a"}, "", "user", "host")
+	if single.Paths != nil {
+		t.Errorf("NewOpenRequest(one path) Paths = %v, want nil", single.Paths)
+	}
+}
+
+func TestNewOpenResponse(t *testing.T) {
+	resp := NewOpenResponse("vim", "vim /a", "Opened /a in vim")
+	if !resp.Success || resp.Editor != "vim" || resp.Command != "vim /a" {
+		t.Errorf("NewOpenResponse() = %+v", resp)
+	}
+	if resp.Protocol != ProtocolVersion || resp.Timestamp == 0 {
+		t.Errorf("NewOpenResponse() Protocol = %d, Timestamp = %d, want %d and the current time", resp.Protocol, resp.Timestamp, ProtocolVersion)
+	}
+}
+
 func TestOpenRequest_SetTimestamp(t *testing.T) {
 	req := &OpenRequest{
 		Path: "/test",
@@ -187,3 +320,100 @@ func TestEditorInfo(t *testing.T) {
 		t.Errorf("EditorInfo.Default = %v, want %v", editor.Default, false)
 	}
 }
+
+func TestOpenRequest_CleanPaths(t *testing.T) {
+	paths := []string{"/home/user/./a/", "/home/user/b/../c"}
+	req := OpenRequest{Path: "/home/user/./a/", Paths: paths, Path2: "//srv/x"}
+	if err := req.CleanPaths(); err != nil {
+		t.Fatalf("CleanPaths() error = %v", err)
+	}
+	if req.Path != "/home/user/a" || req.Path2 != "/srv/x" || req.Paths[0] != "/home/user/a" || req.Paths[1] != "/home/user/c" {
+		t.Errorf("CleanPaths() = %+v", req)
+	}
+	if paths[1] != "/home/user/b/../c" {
+		t.Errorf("CleanPaths() changed the caller's slice: %q", paths)
+	}
+
+	for _, req := range []OpenRequest{
+		{Path: "relative/path"},
+		{Path: "/home/user/\x00"},
+		{Paths: []string{"/ok", "/home/\u009b"}},
+	} {
+		if err := req.CleanPaths(); !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("CleanPaths(%+v) error = %v, want ErrInvalidPath", req, err)
+		}
+	}
+}
+
+func TestOpenRequest_AllPaths(t *testing.T) {
+	tests := []struct {
+		name    string
+		request OpenRequest
+		want    []string
+	}{
+		{"single path", OpenRequest{Path: "/a"}, []string{"/a"}},
+		{"paths take precedence", OpenRequest{Path: "/a", Paths: []string{"/a", "/b"}}, []string{"/a", "/b"}},
+		{"empty", OpenRequest{}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.request.AllPaths()
+			if len(got) != len(tt.want) {
+				t.Fatalf("AllPaths() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("AllPaths() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidRequestID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"0123abcd", true},
+		{"client-1_a.b", true},
+		{"", false},
+		{"has space", false},
+		{"new\nline", false},
+		{strings.Repeat("a", 65), false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidRequestID(tt.id); got != tt.want {
+			t.Errorf("ValidRequestID(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+	if id := NewRequestID(); !ValidRequestID(id) {
+		t.Errorf("NewRequestID() = %q, not a valid request ID", id)
+	}
+}
+
+func TestProtocolNegotiation(t *testing.T) {
+	h := http.Header{}
+	if got := ProtocolFromHeader(h); got != ProtocolUnknown {
+		t.Errorf("ProtocolFromHeader(no header) = %d, want %d", got, ProtocolUnknown)
+	}
+	h.Set(HeaderProtocol, "not-a-number")
+	if got := ProtocolFromHeader(h); got != ProtocolUnknown {
+		t.Errorf("ProtocolFromHeader(malformed) = %d, want %d", got, ProtocolUnknown)
+	}
+	SetProtocolHeader(h)
+	if got := ProtocolFromHeader(h); got != ProtocolVersion {
+		t.Errorf("ProtocolFromHeader() = %d, want %d", got, ProtocolVersion)
+	}
+
+	if w := ProtocolWarning(ProtocolVersion); w != "" {
+		t.Errorf("ProtocolWarning(%d) = %q, want none", ProtocolVersion, w)
+	}
+	for _, server := range []int{ProtocolUnknown, ProtocolVersion + 1} {
+		if w := ProtocolWarning(server); w == "" {
+			t.Errorf("ProtocolWarning(%d) is empty, want a warning", server)
+		}
+	}
+}
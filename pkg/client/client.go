@@ -0,0 +1,316 @@
+// Package client is a Go client for the rcode-server HTTP API, for editor
+// plugins and scripts that want to open paths on the host without running
+// the rcode CLI.
+//
+//	c, err := client.New("192.168.1.100:3339", client.WithToken(token))
+//	if err != nil {
+//		return err
+//	}
+//	resp, err := c.Open(ctx, api.OpenRequest{Path: "/home/alice/project", User: "alice", Host: "devbox"})
+//
+// Errors answered by the server are *StatusError values wrapping the
+// server's *api.ErrorResponse; network failures wrap api.ErrConnectionFailed
+// or api.ErrTimeout. Both kinds are retried when they suggest an unreachable
+// or overloaded server.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/foxytanuki/rcode/internal/auth"
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+// Defaults for a Client without options
+const (
+	DefaultTimeout    = 5 * time.Second
+	DefaultAttempts   = 3
+	DefaultRetryDelay = 500 * time.Millisecond
+	MaxRetryDelay     = 5 * time.Second
+)
+
+// Client talks to one rcode-server. It is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	token      string
+	signingKey string
+	userAgent  string
+	timeout    time.Duration
+	attempts   int
+	retryDelay time.Duration
+
+	sleep func(context.Context, time.Duration) error // replaced in tests
+}
+
+// New returns a client for the server at addr, given as host:port or as an
+// http:// or https:// URL. A bare host gets the default port 3339.
+func New(addr string, opts ...Option) (*Client, error) {
+	base, err := parseAddr(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		baseURL:    base,
+		userAgent:  "rcode-client",
+		timeout:    DefaultTimeout,
+		attempts:   DefaultAttempts,
+		retryDelay: DefaultRetryDelay,
+		sleep:      sleepContext,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{}
+	}
+	return c, nil
+}
+
+// parseAddr turns addr into the server's base URL
+func parseAddr(addr string) (*url.URL, error) {
+	if addr == "" {
+		return nil, errors.New("server address is required")
+	}
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+	base, err := url.Parse(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid server address: %w", err)
+	}
+	if base.Scheme != "http" && base.Scheme != "https" {
+		return nil, fmt.Errorf("invalid server address: unsupported scheme %q", base.Scheme)
+	}
+	if base.Host == "" {
+		return nil, errors.New("invalid server address: missing host")
+	}
+	if base.Port() == "" {
+		base.Host = net.JoinHostPort(base.Hostname(), "3339")
+	}
+	base.Path = strings.TrimSuffix(base.Path, "/")
+	return base, nil
+}
+
+// Open asks the server to open req's paths in an editor
+func (c *Client) Open(ctx context.Context, req api.OpenRequest) (*api.OpenResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := req.CleanPaths(); err != nil {
+		return nil, err
+	}
+	var resp api.OpenResponse
+	if err := c.call(ctx, http.MethodPost, "/open-editor", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Render returns the command Open would run for req, without running it
+func (c *Client) Render(ctx context.Context, req api.OpenRequest) (*api.RenderResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := req.CleanPaths(); err != nil {
+		return nil, err
+	}
+	var resp api.RenderResponse
+	if err := c.call(ctx, http.MethodPost, "/render", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListEditors returns the editors configured on the server
+func (c *Client) ListEditors(ctx context.Context) (*api.EditorsResponse, error) {
+	var resp api.EditorsResponse
+	if err := c.call(ctx, http.MethodGet, "/editors", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Health returns the server's health. An unhealthy server that still
+// answers is reported in the response, not as an error.
+func (c *Client) Health(ctx context.Context) (*api.HealthResponse, error) {
+	var resp api.HealthResponse
+	if err := c.call(ctx, http.MethodGet, "/health", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// call sends a request with body, JSON-encoded when not nil, and decodes
+// the response into out. Network failures and gateway errors are retried.
+func (c *Client) call(ctx context.Context, method, path string, body, out any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+	}
+	id := api.NewRequestID()
+	// Retries of a POST carry one key, so the server answers a retry of a
+	// launch whose response was lost with that response
+	var key string
+	if method == http.MethodPost {
+		key = api.NewIdempotencyKey()
+	}
+
+	attempts := max(c.attempts, 1)
+	delay := c.retryDelay
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			if sleepErr := c.sleep(ctx, delay); sleepErr != nil {
+				return err
+			}
+			delay = min(delay*2, MaxRetryDelay)
+		}
+		err = c.attempt(ctx, method, path, id, key, payload, out)
+		if !retryable(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// attempt makes a single request
+func (c *Client) attempt(ctx context.Context, method, path, id, key string, payload []byte, out any) error {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	var body io.Reader = http.NoBody
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL.String()+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set(api.HeaderRequestID, id)
+	if key != "" {
+		req.Header.Set(api.HeaderIdempotencyKey, key)
+	}
+	api.SetProtocolHeader(req.Header)
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	auth.SignRequest(req, payload, c.signingKey, time.Now())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		var netErr net.Error
+		if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+			return fmt.Errorf("request failed: %w: %w", api.ErrTimeout, err)
+		}
+		return fmt.Errorf("request failed: %w: %w", api.ErrConnectionFailed, err)
+	}
+	defer resp.Body.Close()
+
+	healthy := resp.StatusCode == http.StatusOK ||
+		(resp.StatusCode == http.StatusServiceUnavailable && path == "/health")
+	if !healthy {
+		return responseError(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// responseError returns the server's error response, or an error naming
+// the status when the body holds none
+func responseError(resp *http.Response) error {
+	var errResp api.ErrorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil || errResp.Message == "" {
+		errResp = api.ErrorResponse{
+			Message: fmt.Sprintf("server returned status %d", resp.StatusCode),
+			Code:    statusCode(resp.StatusCode),
+		}
+	}
+	if errResp.RequestID == "" {
+		errResp.RequestID = resp.Header.Get(api.HeaderRequestID)
+	}
+	return &StatusError{Status: resp.StatusCode, Response: &errResp}
+}
+
+// statusCode returns the error code for a status without an error body
+func statusCode(status int) string {
+	switch status {
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return api.CodeServerDown
+	case http.StatusGatewayTimeout:
+		return api.CodeTimeout
+	}
+	return api.CodeInternalError
+}
+
+// StatusError is a non-200 answer from the server
+type StatusError struct {
+	Status   int                // HTTP status code
+	Response *api.ErrorResponse // The server's error response
+}
+
+// Error implements the error interface
+func (e *StatusError) Error() string {
+	msg := fmt.Sprintf("server error: %s", e.Response.Error())
+	if e.Response.RequestID != "" {
+		msg += fmt.Sprintf(" (request %s)", e.Response.RequestID)
+	}
+	return msg
+}
+
+// Unwrap returns the server's error response
+func (e *StatusError) Unwrap() error {
+	return e.Response
+}
+
+// retryable reports whether err is worth another attempt: a network failure
+// or a gateway status from an unreachable or overloaded server
+func retryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.Status {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	return api.IsNetworkError(err)
+}
+
+// sleepContext waits for d or until ctx ends
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,165 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/foxytanuki/rcode/pkg/api"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	c, err := New(server.URL, opts...)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	c.sleep = func(context.Context, time.Duration) error { return nil }
+	return c
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		addr    string
+		want    string
+		wantErr bool
+	}{
+		{"192.168.1.100", "http://192.168.1.100:3339", false},
+		{"devbox:4000", "http://devbox:4000", false},
+		{"https://rcode.example.com/", "https://rcode.example.com:3339", false},
+		{"[::1]", "http://[::1]:3339", false},
+		{"", "", true},
+		{"ftp://devbox", "", true},
+	}
+
+	for _, tt := range tests {
+		c, err := New(tt.addr)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("New(%q) error = %v, wantErr %v", tt.addr, err, tt.wantErr)
+			continue
+		}
+		if err == nil && c.baseURL.String() != tt.want {
+			t.Errorf("New(%q) base URL = %q, want %q", tt.addr, c.baseURL, tt.want)
+		}
+	}
+}
+
+func TestClient_Open(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/open-editor" || r.Method != http.MethodPost {
+			t.Errorf("request = %s %s, want POST /open-editor", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q, want the bearer token", got)
+		}
+		if api.ProtocolFromHeader(r.Header) != api.ProtocolVersion {
+			t.Errorf("protocol header missing")
+		}
+		if key := r.Header.Get(api.HeaderIdempotencyKey); !api.ValidIdempotencyKey(key) {
+			t.Errorf("Idempotency-Key = %q, want a generated key", key)
+		}
+		var req api.OpenRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Decode() error = %v", err)
+		}
+		_ = json.NewEncoder(w).Encode(api.OpenResponse{Success: true, Editor: req.Editor, Command: "nvim " + req.Path})
+	}, WithToken("secret"))
+
+	resp, err := c.Open(context.Background(), api.OpenRequest{Path: "/src", Editor: "nvim", User: "alice", Host: "devbox"})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if !resp.Success || resp.Editor != "nvim" || resp.Command != "nvim /src" {
+		t.Errorf("Open() = %+v", resp)
+	}
+
+	// Invalid requests are refused before they are sent
+	if _, err := c.Open(context.Background(), api.OpenRequest{Path: "/src"}); !errors.Is(err, api.ErrMissingUser) {
+		t.Errorf("Open() without a user error = %v, want ErrMissingUser", err)
+	}
+}
+
+func TestClient_Retry(t *testing.T) {
+	calls := 0
+	c := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(api.EditorsResponse{DefaultEditor: "cursor"})
+	})
+
+	resp, err := c.ListEditors(context.Background())
+	if err != nil {
+		t.Fatalf("ListEditors() error = %v", err)
+	}
+	if resp.DefaultEditor != "cursor" || calls != 3 {
+		t.Errorf("ListEditors() = %+v after %d calls, want cursor after 3", resp, calls)
+	}
+
+	// Without retries the gateway error is returned at once
+	calls = 0
+	WithRetry(1, 0)(c)
+	var statusErr *StatusError
+	if _, err := c.ListEditors(context.Background()); !errors.As(err, &statusErr) || statusErr.Response.Code != api.CodeServerDown {
+		t.Errorf("ListEditors() error = %v, want a SERVER_DOWN status error", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestClient_ErrorResponse(t *testing.T) {
+	calls := 0
+	c := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		w.Header().Set(api.HeaderRequestID, "abc123")
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(api.NewErrorResponse(api.ErrEditorNotFound, api.CodeEditorNotFound, "zed"))
+	})
+
+	_, err := c.Render(context.Background(), api.OpenRequest{Path: "/src", Editor: "zed", User: "alice", Host: "devbox"})
+	var errResp *api.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Code != api.CodeEditorNotFound || errResp.RequestID != "abc123" {
+		t.Fatalf("Render() error = %v, want EDITOR_NOT_FOUND from request abc123", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want no retries for a client error", calls)
+	}
+}
+
+func TestClient_Health(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_ = json.NewEncoder(w).Encode(api.HealthResponse{Status: api.StatusUnhealthy})
+	}, WithRetry(1, 0))
+
+	health, err := c.Health(context.Background())
+	if err != nil {
+		t.Fatalf("Health() error = %v", err)
+	}
+	if health.Status != api.StatusUnhealthy {
+		t.Errorf("Status = %q, want %q", health.Status, api.StatusUnhealthy)
+	}
+}
+
+func TestClient_NetworkError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	c, err := New(server.URL, WithRetry(2, time.Millisecond))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := c.Health(context.Background()); !errors.Is(err, api.ErrConnectionFailed) {
+		t.Errorf("Health() error = %v, want ErrConnectionFailed", err)
+	}
+}
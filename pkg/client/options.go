@@ -0,0 +1,58 @@
+package client
+
+import (
+	"net/http"
+	"time"
+)
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sends requests through hc instead of a client with the
+// default timeout
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithToken sends token as the bearer token the server's auth_token expects
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithSigningKey signs every request with key, one of the server's
+// server.signing.keys
+func WithSigningKey(key string) Option {
+	return func(c *Client) {
+		c.signingKey = key
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithTimeout bounds each attempt at a request. The context passed to a
+// method still bounds the request as a whole, retries included.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithRetry makes up to attempts attempts at a request that fails with a
+// network error or a gateway status, waiting delay before the first retry
+// and doubling it after each one, up to MaxRetryDelay. attempts <= 1
+// disables retries.
+func WithRetry(attempts int, delay time.Duration) Option {
+	return func(c *Client) {
+		c.attempts = attempts
+		c.retryDelay = delay
+	}
+}